@@ -2,89 +2,684 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"wallet-service/internal/alerts"
 	"wallet-service/internal/api"
+	"wallet-service/internal/auth"
 	"wallet-service/internal/config"
+	"wallet-service/internal/diagnostics"
+	walletgrpc "wallet-service/internal/grpc"
+	"wallet-service/internal/health"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/kafka"
+	"wallet-service/internal/lifecycle"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/maintenance"
+	"wallet-service/internal/report"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/shard"
+	"wallet-service/internal/rewards"
+	"wallet-service/internal/sandbox"
+	"wallet-service/internal/scheduler"
+	"wallet-service/internal/screening"
 	"wallet-service/internal/service"
-
-	"github.com/kelseyhightower/envconfig"
+	"wallet-service/internal/slo"
+	"wallet-service/internal/storage"
+	"wallet-service/internal/timeline"
+	"wallet-service/internal/webhook"
+	walletv1 "wallet-service/proto/wallet/v1"
 
 	_ "github.com/lib/pq"
-)
-
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
-	config.LoadEnv()
-	var config config.Config
-	err := envconfig.Process("", &config)
+	cfg, err := config.Load()
 	if err != nil {
-		return
+		log.Fatalf("Failed to load config: %v", err)
 	}
-	fmt.Println(config)
-	db, err := initDatabase(config)
+	logger := setupLogger(cfg.Env)
+	logger.Info("configuration loaded", slog.Any("config", cfg.Redacted()))
+
+	lc := lifecycle.New(logger)
+
+	db, err := initDatabase(*cfg, cfg.DataBase.URL)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 
 	}
-	defer db.Close()
+	lc.Add(lifecycle.Component{Name: "database", Phase: lifecycle.PhaseDatabase, Timeout: cfg.Shutdown.DatabaseTimeout, Stop: lifecycle.Close(db)})
 
-	logger := setupLogger(config.Env)
+	reconnectPolicy := repository.WithReconnectPolicy(repository.ReconnectPolicy{
+		Attempts:     cfg.ConnectionPool.ReconnectAttempts,
+		Backoff:      cfg.ConnectionPool.ReconnectBackoff,
+		MaxIdleConns: cfg.ConnectionPool.MaxIdleConns,
+	})
+	txMetrics := repository.NewTxMetrics()
+	repoOpts := []repository.Option{reconnectPolicy, repository.WithTxMetrics(txMetrics)}
+	if len(cfg.DataBase.ReplicaURLs) > 0 {
+		replicas, err := openReplicas(*cfg)
+		if err != nil {
+			log.Fatalf("Failed to open read replicas: %v", err)
+		}
+		for i, replica := range replicas {
+			lc.Add(lifecycle.Component{Name: fmt.Sprintf("replica-%d", i), Phase: lifecycle.PhaseDatabase, Timeout: cfg.Shutdown.DatabaseTimeout, Stop: lifecycle.Close(replica)})
+		}
+		repoOpts = append(repoOpts, repository.WithReplicas(replicas, cfg.DataBase.ReplicaMaxLag))
+	}
 
-	walletRepo := repository.NewWalletRepository(db, logger)
+	walletRepo := repository.NewWalletRepository(db, logger, repoOpts...)
 
-	if err = walletRepo.CreateTabeIfNotExists(context.Background()); err != nil {
-		log.Fatalf("Failed to create table: %v", err)
+	// The main database is shard 0 and also keeps everything that isn't
+	// sharded: jobs, idempotency keys, screening hits.
+	shards := []*repository.WalletRepository{walletRepo}
+	for i, url := range cfg.DataBase.ShardURLs {
+		shardDB, err := initDatabase(*cfg, url)
+		if err != nil {
+			log.Fatalf("Failed to initialize shard %d: %v", i+1, err)
+		}
+		lc.Add(lifecycle.Component{Name: fmt.Sprintf("shard-%d", i+1), Phase: lifecycle.PhaseDatabase, Timeout: cfg.Shutdown.DatabaseTimeout, Stop: lifecycle.Close(shardDB)})
+		shards = append(shards, repository.NewWalletRepository(shardDB, logger.With(slog.Int("shard", i+1)), reconnectPolicy, repository.WithTxMetrics(txMetrics)))
+	}
+	var serviceRepo service.WalletRepository = walletRepo
+	var shardRouter *shard.Router
+	if len(shards) > 1 {
+		cohorts := shard.EvenMap(len(shards))
+		if cfg.DataBase.ShardMap != "" {
+			if cohorts, err = shard.ParseMap(cfg.DataBase.ShardMap, len(shards)); err != nil {
+				log.Fatalf("Invalid shard map: %v", err)
+			}
+		}
+		repos := make([]shard.Shard, len(shards))
+		for i, s := range shards {
+			repos[i] = s
+		}
+		shardRouter = shard.NewRouter(repos, cohorts, logger)
+		serviceRepo = shardRouter
+		logger.Info("sharding enabled", slog.Int("shards", len(shards)))
+		if err := shardRouter.RecoverTransfers(context.Background()); err != nil {
+			logger.Warn("failed to recover cross-shard transfers", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
 	}
 
-	walletService := service.NewWalletService(walletRepo, logger)
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	go walletRepo.MonitorReplicas(monitorCtx, cfg.DataBase.ReplicaProbeInterval)
+	lc.Add(lifecycle.Component{Name: "replica-monitor", Phase: lifecycle.PhaseWorkers, Stop: lifecycle.Func(stopMonitor)})
 
-	router := api.NewRouter(walletService)
+	var windows []maintenance.Recurring
+	for _, spec := range cfg.Maintenance.Windows {
+		window, err := maintenance.ParseRecurring(spec)
+		if err != nil {
+			log.Fatalf("Invalid maintenance window: %v", err)
+		}
+		windows = append(windows, window)
+	}
+	gate := maintenance.NewGate(cfg.Maintenance.Enforce, maintenance.Policy(cfg.Maintenance.Policy), windows)
 
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", config.ServerPort),
-		Handler: router,
+	if err := gate.Allow("schema migration"); err != nil {
+		logger.Warn("skipping schema migration", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	} else {
+		for i, s := range shards {
+			migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), cfg.DataBase.MigrationLockTimeout)
+			err = s.CreateTabeIfNotExists(migrateCtx)
+			cancelMigrate()
+			if err != nil {
+				log.Fatalf("Failed to create table on shard %d: %v", i, err)
+			}
+		}
+	}
+
+	var serviceOpts []service.Option
+	var store storage.ObjectStore
+	if cfg.Storage.Bucket != "" {
+		store = storage.NewS3Store(storage.S3Config{
+			Endpoint:             cfg.Storage.Endpoint,
+			Region:               cfg.Storage.Region,
+			Bucket:               cfg.Storage.Bucket,
+			Prefix:               cfg.Storage.Prefix,
+			AccessKeyID:          cfg.Storage.AccessKeyID,
+			SecretAccessKey:      cfg.Storage.SecretAccessKey,
+			PathStyle:            cfg.Storage.PathStyle,
+			ServerSideEncryption: cfg.Storage.ServerSideEncryption,
+			KMSKeyID:             cfg.Storage.KMSKeyID,
+		})
+		serviceOpts = append(serviceOpts, service.WithObjectStore(store, cfg.Storage.SignedURLTTL))
+	}
+
+	jobOwner := cfg.Jobs.Owner
+	if jobOwner == "" {
+		hostname, _ := os.Hostname()
+		jobOwner = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	background := jobs.NewManager(logger,
+		jobs.WithStore(walletRepo, jobOwner),
+		jobs.WithLease(cfg.Jobs.Lease),
+		jobs.WithMaxAttempts(cfg.Jobs.MaxAttempts),
+	)
+	lc.Add(lifecycle.Component{Name: "jobs", Phase: lifecycle.PhaseWorkers, Timeout: cfg.Shutdown.WorkerTimeout, Stop: lifecycle.Func(background.Stop)})
+
+	serviceOpts = append(serviceOpts,
+		service.WithOwnerBalanceCacheTTL(cfg.Balances.OwnerCacheTTL),
+		service.WithMissCacheTTL(cfg.Balances.MissCacheTTL),
+		service.WithBalanceCache(cfg.Balances.HotCacheSize, cfg.Balances.HotCacheTTL),
+		service.WithJobs(background),
+		service.WithBulkWorkers(cfg.Jobs.BulkOperationWorkers),
+		service.WithMaintenanceGate(gate),
+		service.WithDisputeWindow(cfg.Disputes.Window),
+		service.WithRetryBudget(cfg.Retries.BudgetRatio, cfg.Retries.BudgetWindow, cfg.Retries.BudgetMinRetries),
+	)
+	if cfg.Webhooks.DispatchInterval > 0 {
+		serviceOpts = append(serviceOpts, service.WithWebhookDelivery(service.WebhookDeliveryPolicy{
+			Timeout:     cfg.Webhooks.Timeout,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			BackoffBase: cfg.Webhooks.BackoffBase,
+			BackoffMax:  cfg.Webhooks.BackoffMax,
+			BatchSize:   cfg.Webhooks.BatchSize,
+			Workers:     cfg.Webhooks.Workers,
+			Retention:   cfg.Webhooks.Retention,
+		}))
+	}
+	var producer *kafka.Producer
+	if len(cfg.Outbox.KafkaBrokers) > 0 {
+		kafkaTLSConfig, err := kafkaTLS(cfg.Outbox)
+		if err != nil {
+			log.Fatalf("Failed to configure Kafka TLS: %v", err)
+		}
+		producer, err = kafka.NewProducer(kafka.Config{
+			Brokers:       cfg.Outbox.KafkaBrokers,
+			ClientID:      cfg.Outbox.KafkaClientID,
+			Timeout:       cfg.Outbox.KafkaTimeout,
+			TLS:           kafkaTLSConfig,
+			SASLMechanism: cfg.Outbox.KafkaSASLMechanism,
+			SASLUsername:  cfg.Outbox.KafkaSASLUsername,
+			SASLPassword:  cfg.Outbox.KafkaSASLPassword,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create Kafka producer: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithOutbox(service.OutboxPolicy{
+			BatchSize: cfg.Outbox.BatchSize,
+			Retention: cfg.Outbox.Retention,
+		}, kafka.NewPublisher(producer, cfg.Outbox.KafkaTopic)))
+	}
+	if cfg.EventSourcing.Enabled {
+		serviceOpts = append(serviceOpts, service.WithEventSourcing(service.EventSourcingPolicy{
+			SnapshotEvery: cfg.EventSourcing.SnapshotEvery,
+		}))
+	}
+	if cfg.Withdrawals.DailyLimit > 0 || cfg.Withdrawals.MonthlyLimit > 0 {
+		serviceOpts = append(serviceOpts, service.WithWithdrawalLimits(service.WithdrawalLimits{
+			Daily:   cfg.Withdrawals.DailyLimit,
+			Monthly: cfg.Withdrawals.MonthlyLimit,
+		}))
+	}
+	if cfg.Disputes.WebhookURL != "" {
+		serviceOpts = append(serviceOpts, service.WithNotifier(webhook.NewSender(cfg.Disputes.WebhookURL, cfg.Disputes.WebhookSecret, cfg.Disputes.WebhookTimeout)))
+	}
+
+	var screeners screening.Chain
+	if cfg.Screening.DenylistFile != "" {
+		denylist, err := screening.LoadFileDenylist(cfg.Screening.DenylistFile)
+		if err != nil {
+			log.Fatalf("Failed to load denylist: %v", err)
+		}
+		screeners = append(screeners, denylist)
+	}
+	if cfg.Screening.WebhookURL != "" {
+		screeners = append(screeners, screening.NewWebhook(cfg.Screening.WebhookURL, cfg.Screening.WebhookToken, cfg.Screening.WebhookTimeout))
+	}
+	if len(screeners) > 0 {
+		serviceOpts = append(serviceOpts, service.WithScreener(screeners, cfg.Screening.LargeOperation))
+	}
+
+	var limiter *limits.Limiter
+	if cfg.Limits.File != "" {
+		limitsCfg, err := limits.LoadConfig(cfg.Limits.File)
+		if err != nil {
+			log.Fatalf("Failed to load operation limits: %v", err)
+		}
+		limiter = limits.NewLimiter(limitsCfg)
+		serviceOpts = append(serviceOpts, service.WithLimiter(limiter))
+	}
+	if cfg.Rewards.File != "" {
+		rules, err := rewards.LoadRules(cfg.Rewards.File)
+		if err != nil {
+			log.Fatalf("Failed to load rewards rules: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithRewards(rules))
+	}
+	if cfg.Dormancy.IdlePeriod > 0 {
+		serviceOpts = append(serviceOpts, service.WithDormancy(cfg.Dormancy.IdlePeriod, cfg.Dormancy.ReactivationThreshold, cfg.Dormancy.Notify))
+	}
+	if cfg.Purge.RecoveryWindow > 0 {
+		if store == nil {
+			log.Fatalf("PURGE_RECOVERY_WINDOW requires STORAGE_BUCKET to archive transactions to")
+		}
+		serviceOpts = append(serviceOpts, service.WithPurge(cfg.Purge.RecoveryWindow))
+	}
+	if cfg.Audit.Retention > 0 {
+		serviceOpts = append(serviceOpts, service.WithAuditRetention(cfg.Audit.Retention))
+	}
+	if cfg.Attempts.Retention > 0 {
+		serviceOpts = append(serviceOpts, service.WithAttemptHistory(cfg.Attempts.Retention))
+	}
+	if len(cfg.Sandbox.Tenants) > 0 {
+		serviceOpts = append(serviceOpts, service.WithSandbox(sandbox.New(cfg.Sandbox.Tenants, cfg.Sandbox.Delay)))
+	}
+	overflow, err := timeline.ParseOverflow(cfg.Streams.Overflow)
+	if err != nil {
+		log.Fatalf("Invalid stream overflow policy: %v", err)
+	}
+	broker := timeline.NewBroker(timeline.WithPolicy(timeline.Policy{Buffer: cfg.Streams.Buffer, Overflow: overflow}))
+	serviceOpts = append(serviceOpts, service.WithAfterOperation(broker))
+	walletService := service.NewWalletService(serviceRepo, logger, serviceOpts...)
+	if err := background.Resume(context.Background()); err != nil {
+		logger.Warn("failed to resume jobs", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+
+	statements, err := report.NewStatementRenderer(report.StatementConfig{
+		Brand:        cfg.Statements.Brand,
+		Footer:       cfg.Statements.Footer,
+		TemplateFile: cfg.Statements.TemplateFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load statement template: %v", err)
 	}
 
-	go func() {
-		log.Printf("Starting server on port %d", config.ServerPort)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+	sched := scheduler.New(logger)
+	if cfg.Reports.File != "" {
+		entries, err := report.LoadSchedules(cfg.Reports.File)
+		if err != nil {
+			log.Fatalf("Failed to load report schedules: %v", err)
+		}
+		smtpCfg := report.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		}
+		if err := report.Register(sched, report.NewGenerator(walletService, statements), entries, smtpCfg, store, logger); err != nil {
+			log.Fatalf("Invalid report schedules: %v", err)
+		}
+	}
+	sched.Add("promo:expire", scheduler.Every(cfg.Promo.ExpiryInterval), walletService.ExpirePromoCredits)
+	sched.Add("disputes:expire", scheduler.Every(cfg.Disputes.ExpiryInterval), walletService.ExpireDisputes)
+	if cfg.Dormancy.IdlePeriod > 0 {
+		sched.Add("wallets:dormancy", scheduler.Every(cfg.Dormancy.CheckInterval), walletService.FlagDormantWallets)
+	}
+	if cfg.Purge.RecoveryWindow > 0 {
+		sched.Add("wallets:purge", scheduler.Every(cfg.Purge.Interval), walletService.PurgeClosedWallets)
+	}
+	if cfg.Totals.CheckInterval > 0 {
+		sched.Add("ledger:totals", scheduler.Every(cfg.Totals.CheckInterval), walletService.CheckCurrencyTotals)
+	}
+	if cfg.Audit.Retention > 0 {
+		sched.Add("audit:purge", scheduler.Every(cfg.Audit.PurgeInterval), walletService.PurgeAuditEvents)
+	}
+	if cfg.Attempts.Retention > 0 {
+		sched.Add("attempts:purge", scheduler.Every(cfg.Attempts.PurgeInterval), walletService.PurgeOperationAttempts)
+	}
+	if cfg.Webhooks.DispatchInterval > 0 {
+		sched.Add("webhooks:deliver", scheduler.Every(cfg.Webhooks.DispatchInterval), walletService.DeliverWebhooks)
+		if cfg.Webhooks.Retention > 0 {
+			sched.Add("webhooks:purge", scheduler.Every(cfg.Webhooks.PurgeInterval), walletService.PurgeWebhookDeliveries)
 		}
-	}()
+	}
+	sched.Add("jobs:resume", scheduler.Every(cfg.Jobs.ResumeInterval), background.Resume)
+	if shardRouter != nil {
+		sched.Add("shards:recover-transfers", scheduler.Every(cfg.DataBase.ShardTransferRecoveryInterval), shardRouter.RecoverTransfers)
+	}
+	sched.Add("idempotency:purge", scheduler.Every(cfg.Idempotency.PurgeInterval), func(ctx context.Context) error {
+		_, err := walletRepo.PurgeIdempotencyKeys(ctx, time.Now().Add(-cfg.Idempotency.KeyTTL))
+		return err
+	})
+
+	tracker := slo.NewTracker(slo.Objective{
+		Name:    "operations",
+		Target:  cfg.SLO.Target,
+		Latency: cfg.SLO.Latency,
+	}, cfg.SLO.Window, cfg.SLO.BurnAlert, logger)
+	httpStats := httpstats.NewRecorder()
+	idempotencyStats := idempotency.NewStats()
+	metrics := prometheus.NewRegistry()
+	metrics.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}), tracker, httpStats, txMetrics, idempotencyStats, broker)
+	metrics.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "wallet_balance_cache_hits_total",
+			Help: "Balance reads answered from the in-process cache.",
+		}, func() float64 { return float64(walletService.BalanceCacheStats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "wallet_balance_cache_misses_total",
+			Help: "Balance reads the in-process cache could not answer.",
+		}, func() float64 { return float64(walletService.BalanceCacheStats().Misses) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wallet_balance_cache_entries",
+			Help: "Wallet balances held by the in-process cache.",
+		}, func() float64 { return float64(walletService.BalanceCacheStats().Entries) }),
+	)
+	sched.Add("slo:check", scheduler.Every(cfg.SLO.CheckInterval), tracker.Check)
+	var evaluator *alerts.Evaluator
+	if cfg.Alerts.File != "" {
+		rules, err := alerts.LoadRules(cfg.Alerts.File)
+		if err != nil {
+			log.Fatalf("Failed to load alert rules: %v", err)
+		}
+		var notifier webhook.Notifier
+		if cfg.Alerts.WebhookURL != "" {
+			notifier = webhook.NewSender(cfg.Alerts.WebhookURL, cfg.Alerts.WebhookSecret, cfg.Alerts.WebhookTimeout)
+		}
+		evaluator = alerts.NewEvaluator(rules, walletService, notifier, logger)
+		metrics.MustRegister(evaluator)
+		sched.Add("alerts:evaluate", scheduler.Every(cfg.Alerts.Interval), evaluator.Evaluate)
+	}
+	if len(cfg.Sandbox.Tenants) > 0 {
+		sched.Add("sandbox:wipe", scheduler.Every(cfg.Sandbox.WipeInterval), walletService.StartSandboxWipe)
+	}
+	lc.Add(lifecycle.Component{
+		Name:    "scheduler",
+		Phase:   lifecycle.PhaseWorkers,
+		Timeout: cfg.Shutdown.WorkerTimeout,
+		Start: func(context.Context) error {
+			sched.Start(context.Background())
+			return nil
+		},
+		Stop: lifecycle.Func(sched.Stop),
+	})
+
+	if producer != nil {
+		// The relay stops after the workers, and publishes what they
+		// left in the outbox before closing the producer.
+		relays := scheduler.New(logger)
+		relays.Add("outbox:relay", scheduler.Every(cfg.Outbox.RelayInterval), walletService.RelayOutbox)
+		if cfg.Outbox.Retention > 0 {
+			relays.Add("outbox:purge", scheduler.Every(cfg.Outbox.PurgeInterval), walletService.PurgeOutbox)
+		}
+		lc.Add(lifecycle.Component{
+			Name:    "outbox-relay",
+			Phase:   lifecycle.PhaseRelays,
+			Timeout: cfg.Shutdown.WorkerTimeout,
+			Start: func(context.Context) error {
+				relays.Start(context.Background())
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				relays.Stop()
+				defer producer.Close()
+				return walletService.RelayOutbox(ctx)
+			},
+		})
+	}
+
+	diag := diagnostics.NewRunner(cfg.Diagnostics.Timeout)
+	diag.Register("database", diagnostics.DatabaseCheck(walletRepo, cfg.Diagnostics.DBLatencyWarn))
+	diag.Register("replication", diagnostics.ReplicationCheck(walletRepo, cfg.Diagnostics.ReplicationLagWarn))
+	diag.Register("failover", diagnostics.FailoverCheck(walletRepo.FailoverStats()))
+	if len(cfg.DataBase.ReplicaURLs) > 0 {
+		diag.Register("replicas", diagnostics.ReplicasCheck(walletRepo))
+	}
+	diag.Register("scheduler", diagnostics.SchedulerCheck(sched))
+
+	monitor := health.NewMonitor(diag, walletv1.WalletService_ServiceDesc.ServiceName)
+	lc.Add(lifecycle.Component{
+		Name:    "health",
+		Phase:   lifecycle.PhaseWorkers,
+		Timeout: cfg.Shutdown.WorkerTimeout,
+		Start: func(context.Context) error {
+			monitor.Start(cfg.Diagnostics.HealthInterval)
+			return nil
+		},
+		Stop: lifecycle.Func(monitor.Stop),
+	})
+
+	var validator *auth.Validator
+	if cfg.Auth.Secret != "" || cfg.Auth.PublicKeyFile != "" {
+		var key any = []byte(cfg.Auth.Secret)
+		if cfg.Auth.PublicKeyFile != "" {
+			if key, err = auth.LoadPublicKey(cfg.Auth.PublicKeyFile); err != nil {
+				log.Fatalf("Failed to load JWT public key: %v", err)
+			}
+		}
+		if validator, err = auth.NewValidator(cfg.Auth.Issuer, cfg.Auth.Audience, key, cfg.Auth.Leeway); err != nil {
+			log.Fatalf("Failed to configure JWT authentication: %v", err)
+		}
+	}
+
+	var apiKeys *auth.APIKeys
+	if cfg.Auth.APIKeysFile != "" || cfg.Auth.APIKeysDatabase {
+		var keys []auth.APIKey
+		if cfg.Auth.APIKeysFile != "" {
+			if keys, err = auth.LoadAPIKeys(cfg.Auth.APIKeysFile); err != nil {
+				log.Fatalf("Failed to load API keys: %v", err)
+			}
+		}
+		var store auth.KeyStore
+		if cfg.Auth.APIKeysDatabase {
+			store = walletRepo
+		}
+		apiKeys = auth.NewAPIKeys(keys, store)
+	}
+
+	var oauth *auth.OAuth
+	if cfg.Auth.OAuthJWKSURL != "" {
+		scopes, err := auth.LoadOAuthScopes(cfg.Auth.OAuthScopesFile)
+		if err != nil {
+			log.Fatalf("Failed to load OAuth scopes: %v", err)
+		}
+		oauth = auth.NewOAuth(cfg.Auth.OAuthIssuer, cfg.Auth.OAuthAudience, auth.NewJWKS(cfg.Auth.OAuthJWKSURL), scopes, cfg.Auth.Leeway)
+	}
+
+	var workloads *auth.Workloads
+	if cfg.Auth.WorkloadsFile != "" {
+		list, err := auth.LoadWorkloads(cfg.Auth.WorkloadsFile)
+		if err != nil {
+			log.Fatalf("Failed to load workloads: %v", err)
+		}
+		if workloads, err = auth.NewWorkloads(cfg.Auth.TrustDomain, list); err != nil {
+			log.Fatalf("Failed to configure workload authentication: %v", err)
+		}
+	}
+
+	tlsConfig, err := serverTLS(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+
+	router := api.NewRouter(walletService, *cfg, api.Deps{
+		Statements:  statements,
+		Diagnostics: diag,
+		Limiter:     limiter,
+		Maintenance: gate,
+		SLO:         tracker,
+		HTTPStats:   httpStats,
+		Idempotency: walletRepo,
+		Metrics:     metrics,
+		Alerts:      evaluator,
+		Auth:        validator,
+		APIKeys:     apiKeys,
+		Workloads:   workloads,
+		OAuth:       oauth,
+		Timeline:    broker,
+		Health:      monitor,
+
+		IdempotencyStats: idempotencyStats,
+	})
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.ServerPort),
+		Handler: router,
+	}
+	server.RegisterOnShutdown(broker.Close)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 
-	log.Println("Shutting down server...")
+	lc.Add(lifecycle.Component{
+		Name:    "http",
+		Phase:   lifecycle.PhaseListeners,
+		Timeout: cfg.Shutdown.ServerTimeout,
+		Start: func(context.Context) error {
+			ln, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			if tlsConfig != nil {
+				ln = tls.NewListener(ln, tlsConfig)
+			}
+			go func() {
+				if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Error("server failed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+					select {
+					case quit <- syscall.SIGTERM:
+					default:
+					}
+				}
+			}()
+			logger.Info("server listening", slog.Int("port", cfg.ServerPort))
+			return nil
+		},
+		Stop: server.Shutdown,
+	})
+
+	if cfg.GRPCPort != 0 {
+		var grpcOpts []grpc.ServerOption
+		if tlsConfig != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		if validator != nil || oauth != nil || apiKeys != nil || workloads != nil {
+			grpcOpts = append(grpcOpts, walletgrpc.Authenticate(validator, oauth, apiKeys, workloads)...)
+		}
+		grpcServer := walletgrpc.NewServer(walletService, limiter, broker, grpcOpts...)
+		walletgrpc.RegisterStandardServices(grpcServer, monitor.GRPC())
+		lc.Add(lifecycle.Component{
+			Name:    "grpc",
+			Phase:   lifecycle.PhaseListeners,
+			Timeout: cfg.Shutdown.ServerTimeout,
+			Start: func(context.Context) error {
+				ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+				if err != nil {
+					return err
+				}
+				go func() {
+					if err := grpcServer.Serve(ln); err != nil {
+						logger.Error("grpc server failed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+						select {
+						case quit <- syscall.SIGTERM:
+						default:
+						}
+					}
+				}()
+				logger.Info("grpc server listening", slog.Int("port", cfg.GRPCPort))
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				stopped := make(chan struct{})
+				go func() {
+					grpcServer.GracefulStop()
+					close(stopped)
+				}()
+				select {
+				case <-stopped:
+					return nil
+				case <-ctx.Done():
+					grpcServer.Stop()
+					return ctx.Err()
+				}
+			},
+		})
+	}
+
+	if err := lc.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+
+	sig := <-quit
+	logger.Info("shutting down", slog.String("signal", sig.String()))
+	// Load balancers stop sending traffic once the service reports not
+	// ready, while the listeners still finish what they have.
+	monitor.Drain()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	if err := lc.Shutdown(ctx); err != nil {
+		cancel()
+		log.Fatalf("Shutdown incomplete: %v", err)
 	}
+}
 
-	log.Println("Server exited properly")
+// serverTLS returns the TLS configuration of the HTTP and gRPC servers, or
+// nil when they serve plain text. Client certificates are verified against
+// the client CA bundle when one is given but not required, so callers
+// without one can still use a JWT or API key.
+func serverTLS(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// kafkaTLS returns the TLS configuration of the connections to the Kafka
+// brokers, or nil when they are plain text. Brokers are verified against
+// the CA bundle when one is given, else against the system roots.
+func kafkaTLS(cfg config.OutboxConfig) (*tls.Config, error) {
+	if !cfg.KafkaTLS {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.KafkaCAFile != "" {
+		pem, err := os.ReadFile(cfg.KafkaCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.KafkaCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
 }
 
-func initDatabase(cfg config.Config) (*sql.DB, error) {
-	db, err := sql.Open("postgres", cfg.DataBase.URL)
-	fmt.Println(cfg.DataBase.URL)
+func initDatabase(cfg config.Config, url string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", url)
 	if err != nil {
 		return nil, err
 	}
@@ -93,21 +688,44 @@ func initDatabase(cfg config.Config) (*sql.DB, error) {
 		return nil, err
 	}
 
+	if cfg.DataBase.PgBouncerMode {
+		if err := repository.ProbePgBouncer(context.Background(), db); err != nil {
+			return nil, err
+		}
+	}
+
 	db.SetMaxOpenConns(cfg.ConnectionPool.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.ConnectionPool.MaxIdleConns)
-	db.SetConnMaxLifetime(time.Duration(cfg.ConnectionPool.MaxLifetime) * time.Second)
+	db.SetConnMaxLifetime(cfg.ConnectionPool.MaxLifetime)
 
 	return db, nil
 }
 
+// openReplicas opens read replicas lazily: an unreachable replica must not
+// block startup, the lag monitor keeps it out of rotation instead.
+func openReplicas(cfg config.Config) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for _, url := range cfg.DataBase.ReplicaURLs {
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxOpenConns(cfg.ConnectionPool.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.ConnectionPool.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnectionPool.MaxLifetime)
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 	switch env {
-	case envLocal:
+	case config.EnvLocal:
 		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDev:
+	case config.EnvDev:
 		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envProd:
+	case config.EnvProd:
 		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	}
 	return log
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Request headers that override fault injection for a single request, so
+// tests can trigger a failure deterministically.
+const (
+	HeaderLatency = "X-Mock-Latency"
+	HeaderStatus  = "X-Mock-Status"
+)
+
+// Faults describes the latency and errors injected into every request.
+type Faults struct {
+	Latency   time.Duration
+	Jitter    time.Duration
+	ErrorRate float64
+	// ErrorStatus is the status of injected errors.
+	ErrorStatus int
+}
+
+// injector applies Faults in front of the API.
+type injector struct {
+	faults Faults
+	next   http.Handler
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newInjector(faults Faults, seed uint64, next http.Handler) *injector {
+	return &injector{faults: faults, next: next, rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (in *injector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	latency, status := in.draw()
+	if v := r.Header.Get(HeaderLatency); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "Invalid "+HeaderLatency, http.StatusBadRequest)
+			return
+		}
+		latency = d
+	}
+	if v := r.Header.Get(HeaderStatus); v != "" {
+		code, err := strconv.Atoi(v)
+		if err != nil || code < 100 || code > 599 {
+			http.Error(w, "Invalid "+HeaderStatus, http.StatusBadRequest)
+			return
+		}
+		status = code
+	}
+
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		}
+	}
+	if status != 0 {
+		http.Error(w, "injected failure", status)
+		return
+	}
+	in.next.ServeHTTP(w, r)
+}
+
+// draw picks the latency and, with probability ErrorRate, the error status
+// of a request.
+func (in *injector) draw() (time.Duration, int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	latency := in.faults.Latency
+	if in.faults.Jitter > 0 {
+		latency += time.Duration(in.rnd.Int64N(int64(in.faults.Jitter)))
+	}
+	status := 0
+	if in.faults.ErrorRate > 0 && in.rnd.Float64() < in.faults.ErrorRate {
+		status = in.faults.ErrorStatus
+	}
+	return latency, status
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	t.Run("error rate of one fails every request", func(t *testing.T) {
+		in := newInjector(Faults{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}, 1, ok)
+		rec := httptest.NewRecorder()
+		in.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("status header forces a failure", func(t *testing.T) {
+		in := newInjector(Faults{}, 1, ok)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil)
+		req.Header.Set(HeaderStatus, "500")
+		rec := httptest.NewRecorder()
+		in.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("no faults passes through", func(t *testing.T) {
+		in := newInjector(Faults{}, 1, ok)
+		rec := httptest.NewRecorder()
+		in.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,76 @@
+// Command mockserver serves the wallet HTTP API backed by the in-memory
+// repository, for integration environments that can't run Postgres. State
+// is lost on exit. Latency and errors can be injected globally with flags
+// or per request with the X-Mock-Latency and X-Mock-Status headers.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"wallet-service/internal/api"
+	"wallet-service/internal/config"
+	"wallet-service/internal/diagnostics"
+	"wallet-service/internal/report"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/service"
+)
+
+func main() {
+	port := flag.Int("port", 8080, "port to listen on")
+	adminToken := flag.String("admin-token", os.Getenv("ADMIN_TOKEN"), "token of /api/v1/admin routes; admin routes are disabled when empty")
+	latency := flag.Duration("latency", 0, "latency added to every request")
+	jitter := flag.Duration("jitter", 0, "random extra latency of up to this duration")
+	errorRate := flag.Float64("error-rate", 0, "fraction of requests, 0 to 1, failed with -error-status")
+	errorStatus := flag.Int("error-status", http.StatusServiceUnavailable, "HTTP status of injected errors")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "seed of the fault injection, for reproducible runs")
+	flag.Parse()
+
+	if *errorRate < 0 || *errorRate > 1 {
+		log.Fatalf("-error-rate must be between 0 and 1")
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	walletService := service.NewWalletService(memory.New(), logger)
+
+	statements, err := report.NewStatementRenderer(report.StatementConfig{Brand: "Wallet Service (mock)"})
+	if err != nil {
+		log.Fatalf("Failed to load statement template: %v", err)
+	}
+
+	cfg := config.Config{Env: config.EnvLocal, ServerPort: *port, Admin: config.AdminConfig{Token: *adminToken}}
+	router := api.NewRouter(walletService, cfg, api.Deps{
+		Statements:  statements,
+		Diagnostics: diagnostics.NewRunner(time.Second),
+	})
+
+	faults := Faults{Latency: *latency, Jitter: *jitter, ErrorRate: *errorRate, ErrorStatus: *errorStatus}
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", *port),
+		Handler: newInjector(faults, *seed, router),
+	}
+
+	go func() {
+		logger.Info("starting mock server", slog.Int("port", *port), slog.Any("faults", faults), slog.Uint64("seed", *seed))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+// Package alerts evaluates operator-defined rules on balances, such as a
+// fee wallet growing past a limit, money sitting in suspense or wallets no
+// longer adding up to the ledger, and
+// reports rules that start or stop firing to the log, to Prometheus and
+// optionally to a webhook.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Webhook event types.
+const (
+	EventFiring   = "alert.firing"
+	EventResolved = "alert.resolved"
+)
+
+// Kind is what a rule watches.
+type Kind string
+
+const (
+	// KindBalanceAbove fires while the balance of WalletID exceeds
+	// Threshold.
+	KindBalanceAbove Kind = "balance_above"
+	// KindBalanceBelow fires while the balance of WalletID, or without one
+	// of any wallet in Currency (any currency if empty), is below
+	// Threshold.
+	KindBalanceBelow Kind = "balance_below"
+	// KindSuspenseAbove fires while the open suspense cases in Currency
+	// (any currency if empty) add up to more than Threshold.
+	KindSuspenseAbove Kind = "suspense_above"
+	// KindLedgerMismatch fires while the wallets in Currency (any currency
+	// if empty) hold more or less than the ledger accounts for by more than
+	// Threshold, normally 0. See models.CurrencyTotals.
+	KindLedgerMismatch Kind = "ledger_mismatch"
+)
+
+// Rule fires once its condition has held for For, a duration such as
+// "24h"; immediately if For is empty. Pending time is kept in memory, so a
+// restart starts it over.
+type Rule struct {
+	Name      string        `json:"name"`
+	Kind      Kind          `json:"kind"`
+	WalletID  uuid.NullUUID `json:"walletId"`
+	Currency  string        `json:"currency,omitempty"`
+	Threshold int64         `json:"threshold"`
+	For       string        `json:"for,omitempty"`
+
+	hold time.Duration
+}
+
+// LoadRules reads a JSON file of the form {"rules": [...]}.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid alert rules file: %w", err)
+	}
+	names := make(map[string]bool)
+	for i := range file.Rules {
+		r := &file.Rules[i]
+		if r.Name == "" {
+			return nil, fmt.Errorf("alert rules file: rule %d has no name", i)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("alert rules file: rule %q defined twice", r.Name)
+		}
+		names[r.Name] = true
+		switch r.Kind {
+		case KindBalanceAbove:
+			if !r.WalletID.Valid {
+				return nil, fmt.Errorf("alert rules file: rule %q: walletId is required", r.Name)
+			}
+		case KindBalanceBelow, KindSuspenseAbove, KindLedgerMismatch:
+		default:
+			return nil, fmt.Errorf("alert rules file: rule %q: unknown kind %q", r.Name, r.Kind)
+		}
+		if r.For != "" {
+			if r.hold, err = time.ParseDuration(r.For); err != nil || r.hold < 0 {
+				return nil, fmt.Errorf("alert rules file: rule %q: invalid for %q", r.Name, r.For)
+			}
+		}
+	}
+	return file.Rules, nil
+}
+
+// Source is where rules read balances from, normally the wallet service.
+type Source interface {
+	GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	ListWallets(ctx context.Context, f models.WalletFilter, after uuid.UUID, limit int) ([]models.Wallet, bool, error)
+	ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error)
+	CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error)
+}
+
+// States of a rule.
+const (
+	StateUnknown = "unknown"
+	StateOK      = "ok"
+	StatePending = "pending"
+	StateFiring  = "firing"
+)
+
+// Alert is the current state of a rule. Value is the balance or total last
+// seen and Wallet the wallet it belongs to, if any. Since is when the
+// condition started to hold.
+type Alert struct {
+	Rule      string     `json:"rule"`
+	Kind      Kind       `json:"kind"`
+	State     string     `json:"state"`
+	Value     int64      `json:"value"`
+	Threshold int64      `json:"threshold"`
+	Wallet    *uuid.UUID `json:"wallet,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type state struct {
+	evaluated bool
+	breached  bool
+	firing    bool
+	value     int64
+	wallet    *uuid.UUID
+	since     time.Time
+	err       string
+	failures  uint64
+}
+
+// Evaluator checks its rules against a Source on every Evaluate.
+type Evaluator struct {
+	rules    []Rule
+	src      Source
+	notifier webhook.Notifier
+	log      *slog.Logger
+	now      func() time.Time
+
+	mu     sync.Mutex
+	states []state
+
+	firingDesc   *prometheus.Desc
+	valueDesc    *prometheus.Desc
+	failuresDesc *prometheus.Desc
+}
+
+// NewEvaluator evaluates rules against src. Alerts that start or stop
+// firing are sent to notifier unless it is nil.
+func NewEvaluator(rules []Rule, src Source, notifier webhook.Notifier, log *slog.Logger) *Evaluator {
+	return &Evaluator{
+		rules:    rules,
+		src:      src,
+		notifier: notifier,
+		log:      log,
+		now:      time.Now,
+		states:   make([]state, len(rules)),
+
+		firingDesc: prometheus.NewDesc("wallet_alert_firing",
+			"Whether the alert rule is firing.", []string{"rule"}, nil),
+		valueDesc: prometheus.NewDesc("wallet_alert_value",
+			"Balance or total last seen by the alert rule, in minor units.", []string{"rule"}, nil),
+		failuresDesc: prometheus.NewDesc("wallet_alert_evaluation_failures_total",
+			"Evaluations of the alert rule that failed to read their data.", []string{"rule"}, nil),
+	}
+}
+
+// Evaluate checks every rule once. A rule whose data can't be read keeps
+// its previous state; the failures are returned together after all rules
+// have been checked.
+func (e *Evaluator) Evaluate(ctx context.Context) error {
+	var errs []error
+	for i, rule := range e.rules {
+		value, wallet, breached, err := e.check(ctx, rule)
+		now := e.now()
+
+		e.mu.Lock()
+		st := &e.states[i]
+		if err != nil {
+			st.err = err.Error()
+			st.failures++
+			e.mu.Unlock()
+			e.log.Error("alert rule evaluation failed", slog.String("rule", rule.Name),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			errs = append(errs, fmt.Errorf("rule %q: %w", rule.Name, err))
+			continue
+		}
+		if breached && !st.breached {
+			st.since = now
+		}
+		st.evaluated, st.breached, st.value, st.wallet, st.err = true, breached, value, wallet, ""
+		var event string
+		switch {
+		case breached && !st.firing && now.Sub(st.since) >= rule.hold:
+			st.firing = true
+			event = EventFiring
+		case !breached && st.firing:
+			st.firing = false
+			event = EventResolved
+		}
+		alert := e.alert(rule, st)
+		e.mu.Unlock()
+
+		if event != "" {
+			e.report(ctx, event, alert)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Evaluator) check(ctx context.Context, rule Rule) (int64, *uuid.UUID, bool, error) {
+	switch rule.Kind {
+	case KindBalanceAbove, KindBalanceBelow:
+		if rule.WalletID.Valid {
+			w, err := e.src.GetWallet(ctx, rule.WalletID.UUID)
+			if err != nil {
+				return 0, nil, false, err
+			}
+			if rule.Kind == KindBalanceAbove {
+				return w.Balance, &w.ID, w.Balance > rule.Threshold, nil
+			}
+			return w.Balance, &w.ID, w.Balance < rule.Threshold, nil
+		}
+		below := rule.Threshold - 1
+		wallets, _, err := e.src.ListWallets(ctx, models.WalletFilter{Currency: rule.Currency, MaxBalance: &below}, uuid.Nil, 1)
+		if err != nil || len(wallets) == 0 {
+			return 0, nil, false, err
+		}
+		return wallets[0].Balance, &wallets[0].ID, true, nil
+	case KindSuspenseAbove:
+		cases, err := e.src.ListSuspenseCases(ctx, models.SuspenseCaseOpen)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		var total int64
+		for _, c := range cases {
+			if rule.Currency == "" || c.Currency == rule.Currency {
+				total += c.Amount
+			}
+		}
+		return total, nil, total > rule.Threshold, nil
+	case KindLedgerMismatch:
+		totals, err := e.src.CurrencyTotals(ctx)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		// The value is the largest discrepancy, whichever way it goes.
+		var worst int64
+		for _, t := range totals {
+			if rule.Currency == "" || t.Currency == rule.Currency {
+				d := t.Discrepancy()
+				if max(d, -d) > max(worst, -worst) {
+					worst = d
+				}
+			}
+		}
+		return worst, nil, max(worst, -worst) > rule.Threshold, nil
+	}
+	return 0, nil, false, fmt.Errorf("unknown kind %q", rule.Kind)
+}
+
+func (e *Evaluator) report(ctx context.Context, event string, alert Alert) {
+	attrs := []any{slog.String("rule", alert.Rule), slog.String("kind", string(alert.Kind)),
+		slog.Int64("value", alert.Value), slog.Int64("threshold", alert.Threshold)}
+	if alert.Wallet != nil {
+		attrs = append(attrs, slog.String("wallet_id", alert.Wallet.String()))
+	}
+	if event == EventFiring {
+		e.log.Warn("alert firing", attrs...)
+	} else {
+		e.log.Info("alert resolved", attrs...)
+	}
+
+	if e.notifier == nil {
+		return
+	}
+	ev := webhook.NewEvent(event, alert)
+	if err := e.notifier.Notify(context.WithoutCancel(ctx), ev); err != nil {
+		e.log.Error("failed to deliver alert webhook", slog.String("event_id", ev.ID.String()), slog.String("rule", alert.Rule),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+}
+
+// alert describes a rule's state. e.mu must be held.
+func (e *Evaluator) alert(rule Rule, st *state) Alert {
+	a := Alert{
+		Rule:      rule.Name,
+		Kind:      rule.Kind,
+		State:     StateOK,
+		Value:     st.value,
+		Threshold: rule.Threshold,
+		Wallet:    st.wallet,
+		Error:     st.err,
+	}
+	switch {
+	case !st.evaluated:
+		a.State = StateUnknown
+	case st.firing:
+		a.State = StateFiring
+	case st.breached:
+		a.State = StatePending
+	}
+	if st.breached {
+		since := st.since
+		a.Since = &since
+	}
+	return a
+}
+
+// Alerts returns the state of every rule, in the order of the rules file.
+func (e *Evaluator) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]Alert, len(e.rules))
+	for i, rule := range e.rules {
+		alerts[i] = e.alert(rule, &e.states[i])
+	}
+	return alerts
+}
+
+// Describe and Collect make the evaluator a prometheus.Collector.
+func (e *Evaluator) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.firingDesc
+	ch <- e.valueDesc
+	ch <- e.failuresDesc
+}
+
+func (e *Evaluator) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, rule := range e.rules {
+		st := e.states[i]
+		firing := 0.0
+		if st.firing {
+			firing = 1
+		}
+		ch <- prometheus.MustNewConstMetric(e.firingDesc, prometheus.GaugeValue, firing, rule.Name)
+		ch <- prometheus.MustNewConstMetric(e.valueDesc, prometheus.GaugeValue, float64(st.value), rule.Name)
+		ch <- prometheus.MustNewConstMetric(e.failuresDesc, prometheus.CounterValue, float64(st.failures), rule.Name)
+	}
+}
@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	wallets  map[uuid.UUID]*models.Wallet
+	suspense []models.SuspenseCase
+	totals   []models.CurrencyTotals
+	err      error
+}
+
+func (s *fakeSource) GetWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.wallets[id], nil
+}
+
+func (s *fakeSource) ListWallets(_ context.Context, f models.WalletFilter, _ uuid.UUID, limit int) ([]models.Wallet, bool, error) {
+	var out []models.Wallet
+	for _, w := range s.wallets {
+		if (f.Currency == "" || w.Currency == f.Currency) && (f.MaxBalance == nil || w.Balance <= *f.MaxBalance) && len(out) < limit {
+			out = append(out, *w)
+		}
+	}
+	return out, false, s.err
+}
+
+func (s *fakeSource) ListSuspenseCases(_ context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	return s.suspense, s.err
+}
+
+func (s *fakeSource) CurrencyTotals(context.Context) ([]models.CurrencyTotals, error) {
+	return s.totals, s.err
+}
+
+type fakeNotifier struct {
+	events []webhook.Event
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, e webhook.Event) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+func writeRules(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	return path
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, `{"rules": [
+		{"name": "fees", "kind": "balance_above", "walletId": "8f2b5c1e-3a4d-4e6f-9a0b-1c2d3e4f5a6b", "threshold": 100},
+		{"name": "suspense", "kind": "suspense_above", "for": "24h"}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, 24*time.Hour, rules[1].hold)
+
+	for name, body := range map[string]string{
+		"no name":        `{"rules": [{"kind": "suspense_above"}]}`,
+		"unknown kind":   `{"rules": [{"name": "x", "kind": "nope"}]}`,
+		"missing wallet": `{"rules": [{"name": "x", "kind": "balance_above"}]}`,
+		"bad for":        `{"rules": [{"name": "x", "kind": "suspense_above", "for": "soon"}]}`,
+		"duplicate":      `{"rules": [{"name": "x", "kind": "suspense_above"}, {"name": "x", "kind": "balance_below"}]}`,
+	} {
+		_, err := LoadRules(writeRules(t, body))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEvaluator_FiresAfterHoldAndResolves(t *testing.T) {
+	src := &fakeSource{suspense: []models.SuspenseCase{{Amount: 500, Currency: "USD"}, {Amount: 7, Currency: "EUR"}}}
+	notifier := &fakeNotifier{}
+	rules := []Rule{{Name: "suspense", Kind: KindSuspenseAbove, Currency: "USD", hold: 24 * time.Hour}}
+	e := NewEvaluator(rules, src, notifier, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	assert.Equal(t, StateUnknown, e.Alerts()[0].State)
+
+	require.NoError(t, e.Evaluate(context.Background()))
+	got := e.Alerts()[0]
+	assert.Equal(t, StatePending, got.State)
+	assert.Equal(t, int64(500), got.Value)
+	assert.Empty(t, notifier.events)
+
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, e.Evaluate(context.Background()))
+	assert.Equal(t, StateFiring, e.Alerts()[0].State)
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, EventFiring, notifier.events[0].Type)
+	expected := `
+# HELP wallet_alert_firing Whether the alert rule is firing.
+# TYPE wallet_alert_firing gauge
+wallet_alert_firing{rule="suspense"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected), "wallet_alert_firing"))
+
+	// Staying breached doesn't notify again.
+	require.NoError(t, e.Evaluate(context.Background()))
+	assert.Len(t, notifier.events, 1)
+
+	src.suspense = nil
+	require.NoError(t, e.Evaluate(context.Background()))
+	assert.Equal(t, StateOK, e.Alerts()[0].State)
+	require.Len(t, notifier.events, 2)
+	assert.Equal(t, EventResolved, notifier.events[1].Type)
+}
+
+func TestEvaluator_BalanceRules(t *testing.T) {
+	fees, low := uuid.New(), uuid.New()
+	src := &fakeSource{wallets: map[uuid.UUID]*models.Wallet{
+		fees: {ID: fees, Balance: 2000, Currency: "USD"},
+		low:  {ID: low, Balance: 10, Currency: "EUR"},
+	}}
+	rules := []Rule{
+		{Name: "fees", Kind: KindBalanceAbove, WalletID: uuid.NullUUID{UUID: fees, Valid: true}, Threshold: 1000},
+		{Name: "low-usd", Kind: KindBalanceBelow, Currency: "USD", Threshold: 100},
+		{Name: "low-any", Kind: KindBalanceBelow, Threshold: 100},
+	}
+	e := NewEvaluator(rules, src, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, e.Evaluate(context.Background()))
+	got := e.Alerts()
+	assert.Equal(t, StateFiring, got[0].State)
+	assert.Equal(t, StateOK, got[1].State)
+	assert.Equal(t, StateFiring, got[2].State)
+	if assert.NotNil(t, got[2].Wallet) {
+		assert.Equal(t, low, *got[2].Wallet)
+	}
+}
+
+func TestEvaluator_LedgerMismatch(t *testing.T) {
+	src := &fakeSource{totals: []models.CurrencyTotals{
+		{Currency: "EUR", Balance: 300, Credits: 500, Debits: 200},
+		{Currency: "USD", Balance: 1200, SystemBalance: 50, Credits: 2000, Debits: 700},
+	}}
+	rules := []Rule{
+		{Name: "ledger-eur", Kind: KindLedgerMismatch, Currency: "EUR"},
+		{Name: "ledger-any", Kind: KindLedgerMismatch},
+		{Name: "ledger-tolerant", Kind: KindLedgerMismatch, Threshold: 50},
+	}
+	e := NewEvaluator(rules, src, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, e.Evaluate(context.Background()))
+	got := e.Alerts()
+	assert.Equal(t, StateOK, got[0].State)
+	assert.Equal(t, StateFiring, got[1].State)
+	assert.Equal(t, int64(-50), got[1].Value)
+	assert.Equal(t, StateOK, got[2].State)
+}
+
+func TestEvaluator_FailureKeepsState(t *testing.T) {
+	src := &fakeSource{suspense: []models.SuspenseCase{{Amount: 1}}}
+	e := NewEvaluator([]Rule{{Name: "suspense", Kind: KindSuspenseAbove}}, src, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, e.Evaluate(context.Background()))
+	src.err = errors.New("db down")
+	err := e.Evaluate(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `rule "suspense"`)
+
+	got := e.Alerts()[0]
+	assert.Equal(t, StateFiring, got.State)
+	assert.Equal(t, "db down", got.Error)
+
+	expected := `
+# HELP wallet_alert_evaluation_failures_total Evaluations of the alert rule that failed to read their data.
+# TYPE wallet_alert_evaluation_failures_total counter
+wallet_alert_evaluation_failures_total{rule="suspense"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected), "wallet_alert_evaluation_failures_total"))
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/alerts"
+	"wallet-service/internal/config"
+	"wallet-service/internal/diagnostics"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/maintenance"
+	"wallet-service/internal/slo"
+)
+
+type AdminHandler struct {
+	cfg         config.Config
+	diagnostics *diagnostics.Runner
+	maintenance *maintenance.Gate
+	slo         *slo.Tracker
+	httpStats   *httpstats.Recorder
+	alerts      *alerts.Evaluator
+	idempotency *idempotency.Stats
+}
+
+func NewAdminHandler(cfg config.Config, diag *diagnostics.Runner, gate *maintenance.Gate, tracker *slo.Tracker, stats *httpstats.Recorder,
+	evaluator *alerts.Evaluator, idem *idempotency.Stats) *AdminHandler {
+	return &AdminHandler{
+		cfg:         cfg.Redacted(),
+		diagnostics: diag,
+		maintenance: gate,
+		slo:         tracker,
+		httpStats:   stats,
+		alerts:      evaluator,
+		idempotency: idem,
+	}
+}
+
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.cfg)
+}
+
+// GetDiagnostics runs the live self-checks. The response is 200 even when
+// checks fail so that on-call always gets the full report; the overall
+// status is in the body.
+func (h *AdminHandler) GetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.diagnostics.Run(r.Context()))
+}
+
+// GetSLO reports the success ratio, remaining error budget and burn rates
+// of the operations objective.
+func (h *AdminHandler) GetSLO(w http.ResponseWriter, r *http.Request) {
+	if h.slo == nil {
+		http.Error(w, "SLO tracking is not configured", http.StatusNotFound)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.slo.Status())
+}
+
+// GetEndpointStats reports request counts, error rates and p50/p95/p99
+// latency per route over the last hour, optionally for a single ?tenant=.
+func (h *AdminHandler) GetEndpointStats(w http.ResponseWriter, r *http.Request) {
+	if h.httpStats == nil {
+		http.Error(w, "HTTP stats are not configured", http.StatusNotFound)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.httpStats.Summary(r.URL.Query().Get("tenant")))
+}
+
+// GetIdempotencyStats reports how many requests with an Idempotency-Key
+// were new, replayed, in progress or conflicting since the service
+// started, the replay hit rate and the most recent conflicts.
+func (h *AdminHandler) GetIdempotencyStats(w http.ResponseWriter, r *http.Request) {
+	if h.idempotency == nil {
+		http.Error(w, "idempotency stats are not configured", http.StatusNotFound)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.idempotency.Summary())
+}
+
+// GetAlerts reports the state of every alert rule as of its last
+// evaluation.
+func (h *AdminHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		http.Error(w, "alerting is not configured", http.StatusNotFound)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, h.alerts.Alerts())
+}
+
+// GetMaintenance reports whether destructive actions may run right now and
+// lists the configured and scheduled maintenance windows.
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.maintenance.Status())
+}
+
+// ScheduleMaintenance declares a one-off maintenance window.
+func (h *AdminHandler) ScheduleMaintenance(w http.ResponseWriter, r *http.Request) {
+	var window maintenance.Window
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.maintenance.Schedule(window); err != nil {
+		if errors.Is(err, maintenance.ErrInvalidWindow) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, window)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed adminui
+var adminUIFiles embed.FS
+
+// adminUIHandler serves the embedded support console. The static assets are
+// public; every API call they make carries the admin token and goes through
+// requireAdmin.
+func adminUIHandler() http.Handler {
+	sub, _ := fs.Sub(adminUIFiles, "adminui")
+	return http.StripPrefix("/admin/", http.FileServer(http.FS(sub)))
+}
@@ -0,0 +1,171 @@
+"use strict";
+
+const api = "/api/v1/admin";
+let currentWallet = null;
+
+function token() {
+	return sessionStorage.getItem("adminToken") || "";
+}
+
+function setStatus(message, isError) {
+	const el = document.getElementById("status");
+	el.textContent = message;
+	el.className = isError ? "error" : "";
+}
+
+async function call(path, options = {}) {
+	const headers = Object.assign({ Authorization: "Bearer " + token() }, options.headers || {});
+	const res = await fetch(api + path, Object.assign({}, options, { headers }));
+	if (!res.ok) {
+		throw new Error(res.status + " " + (await res.text()).trim());
+	}
+	return res;
+}
+
+function renderWallet(wallet) {
+	currentWallet = wallet;
+	const el = document.getElementById("wallet");
+	el.replaceChildren();
+
+	const table = document.createElement("table");
+	for (const [label, value] of [
+		["ID", wallet.id],
+		["Balance", wallet.balance],
+		["Version", wallet.version],
+		["Created", wallet.created_at],
+		["Updated", wallet.updated_at],
+	]) {
+		const row = table.insertRow();
+		const th = document.createElement("th");
+		th.textContent = label;
+		row.appendChild(th);
+		row.insertCell().textContent = value;
+	}
+	el.appendChild(table);
+
+	const link = document.createElement("button");
+	link.textContent = "Statement (PDF)";
+	link.addEventListener("click", () => download("/wallets/" + wallet.id + "/statement.pdf", "statement-" + wallet.id + ".pdf"));
+	el.appendChild(link);
+
+	document.getElementById("adjust-section").hidden = false;
+}
+
+async function download(path, filename) {
+	try {
+		const res = await call(path);
+		const url = URL.createObjectURL(await res.blob());
+		const a = document.createElement("a");
+		a.href = url;
+		a.download = filename;
+		a.click();
+		URL.revokeObjectURL(url);
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+}
+
+document.getElementById("login").addEventListener("submit", (e) => {
+	e.preventDefault();
+	sessionStorage.setItem("adminToken", document.getElementById("token").value);
+	document.getElementById("token").value = "";
+	setStatus("Token saved for this session.");
+});
+
+document.getElementById("search").addEventListener("submit", async (e) => {
+	e.preventDefault();
+	const id = document.getElementById("wallet-id").value.trim();
+	try {
+		if (!uuidPattern.test(id)) {
+			await searchWallets(id);
+			return;
+		}
+		const res = await call("/wallets/" + encodeURIComponent(id));
+		renderWallet(await res.json());
+		setStatus("");
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+});
+
+const uuidPattern = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
+
+// searchWallets lists the wallets whose id, owner, label or tenant starts
+// with q; picking one opens it.
+async function searchWallets(q) {
+	const res = await call("/wallets/search?limit=20&q=" + encodeURIComponent(q));
+	const page = await res.json();
+	const el = document.getElementById("wallet");
+	el.replaceChildren();
+	for (const wallet of page.items) {
+		const button = document.createElement("button");
+		button.textContent = [wallet.id, wallet.label, wallet.tenant].filter(Boolean).join(" · ");
+		button.addEventListener("click", () => renderWallet(wallet));
+		el.appendChild(button);
+	}
+	setStatus(page.items.length ? "" : "No wallets match.");
+}
+
+document.getElementById("adjust").addEventListener("submit", async (e) => {
+	e.preventDefault();
+	if (!currentWallet) {
+		return;
+	}
+	const body = {
+		walletId: currentWallet.id,
+		poerationType: document.getElementById("operation-type").value,
+		amount: Number(document.getElementById("amount").value),
+	};
+	try {
+		const res = await call("/operations", {
+			method: "POST",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify(body),
+		});
+		renderWallet(await res.json());
+		setStatus("Balance adjusted.");
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+});
+
+document.getElementById("export").addEventListener("click", () => download("/wallets/export", "wallets.ndjson"));
+
+async function showJSON(path) {
+	try {
+		const res = await call(path);
+		document.getElementById("config").textContent = JSON.stringify(await res.json(), null, 2);
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+}
+
+document.getElementById("show-config").addEventListener("click", () => showJSON("/config"));
+document.getElementById("diagnostics").addEventListener("click", () => showJSON("/diagnostics"));
+
+function ms(ns) {
+	return (ns / 1e6).toFixed(1) + " ms";
+}
+
+document.getElementById("endpoints").addEventListener("click", async () => {
+	try {
+		const res = await call("/endpoints");
+		const table = document.createElement("table");
+		const head = table.createTHead().insertRow();
+		for (const label of ["Route", "Requests", "Error rate", "p50", "p95", "p99"]) {
+			const th = document.createElement("th");
+			th.textContent = label;
+			head.appendChild(th);
+		}
+		for (const e of await res.json()) {
+			const row = table.insertRow();
+			for (const value of [e.route, e.requests, (e.errorRate * 100).toFixed(2) + "%", ms(e.p50), ms(e.p95), ms(e.p99)]) {
+				row.insertCell().textContent = value;
+			}
+		}
+		document.getElementById("endpoint-stats").replaceChildren(table);
+		setStatus("");
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+});
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/shard"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ProcessAtomic applies a list of dependent operations all-or-nothing and
+// returns a combined receipt. Errors name the failing step.
+func (h *WalletHandler) ProcessAtomic(w http.ResponseWriter, r *http.Request) {
+	var req models.AtomicRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ids := make([]uuid.UUID, len(req.Steps))
+	for i, step := range req.Steps {
+		ids[i] = step.WalletID
+	}
+	if !h.authorize(w, r, ids...) {
+		return
+	}
+
+	receipt, err := h.service.ProcessAtomic(r.Context(), req)
+	if err != nil {
+		writeAtomicError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, receipt)
+}
+
+// ProcessBatch applies a batch of deposits and withdrawals all-or-nothing
+// and returns one result per operation. Errors name the failing operation.
+func (h *WalletHandler) ProcessBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ids := make([]uuid.UUID, len(req.Operations))
+	for i, operation := range req.Operations {
+		ids[i] = operation.WalletID
+	}
+	if !h.authorize(w, r, ids...) {
+		return
+	}
+
+	receipt, err := h.service.ProcessBatch(r.Context(), req)
+	if err != nil {
+		writeAtomicError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, receipt)
+}
+
+func writeAtomicError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput),
+		errors.Is(err, repository.ErrInsufficientFunds),
+		errors.Is(err, repository.ErrUnknownOperationType):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrWalletFrozen),
+		errors.Is(err, repository.ErrWalletClosed),
+		errors.Is(err, service.ErrReactivationRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrScreeningBlocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrScreeningUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, service.ErrOperationRejected):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, limits.ErrLimitExceeded),
+		errors.Is(err, service.ErrLimitExceeded),
+		errors.Is(err, shard.ErrCrossShard):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repository.ErrRetryable):
+		respondWithRetryable(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/service"
+)
+
+// ListOperationAttempts returns the attempts recorded for the request whose
+// X-Request-ID is in the path, showing the retries it took.
+func (h *WalletHandler) ListOperationAttempts(w http.ResponseWriter, r *http.Request) {
+	attempts, err := h.service.ListOperationAttempts(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, attempts)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// requireWalletOwner restricts users to the wallets they own, taking the
+// wallet id from the path parameter param. Invalid ids are left to next to
+// reject.
+func requireWalletOwner(svc *service.WalletService, param string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, err := uuid.Parse(r.PathValue(param)); err == nil {
+			if err := svc.AuthorizeWallet(r.Context(), id); err != nil {
+				respondWithAuthzError(w, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireOwner restricts users to their own owner id in the path parameter
+// param.
+func requireOwner(svc *service.WalletService, param string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, err := uuid.Parse(r.PathValue(param)); err == nil {
+			if err := svc.AuthorizeOwner(r.Context(), id); err != nil {
+				respondWithAuthzError(w, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireUnrestricted rejects users, leaving a route to admins and API
+// keys, e.g. because it acts on many wallets at once.
+func requireUnrestricted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sub, ok := auth.SubjectFrom(r.Context()); ok && sub.Restricted() {
+			http.Error(w, "route is not available to users", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func respondWithAuthzError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// authorize reports whether the caller may act on all of ids, writing the
+// error response if not. Handlers use it for wallets named in the body.
+func (h *WalletHandler) authorize(w http.ResponseWriter, r *http.Request, ids ...uuid.UUID) bool {
+	for _, id := range ids {
+		if err := h.service.AuthorizeWallet(r.Context(), id); err != nil {
+			respondWithAuthzError(w, err)
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeCounterparty keeps users from debiting mandates granted to
+// another counterparty.
+func authorizeCounterparty(w http.ResponseWriter, r *http.Request, counterparty string) bool {
+	if sub, ok := auth.SubjectFrom(r.Context()); ok && sub.Restricted() && sub.ID != counterparty {
+		http.Error(w, "users can only debit mandates granted to them", http.StatusForbidden)
+		return false
+	}
+	return true
+}
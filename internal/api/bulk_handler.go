@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/maintenance"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+)
+
+// FreezeWallets starts a background job freezing every wallet that matches
+// the filter in the body and responds with the job to poll.
+func (h *WalletHandler) FreezeWallets(w http.ResponseWriter, r *http.Request) {
+	h.setWalletsStatus(w, r, models.WalletStatusFrozen)
+}
+
+// UnfreezeWallets is the inverse of FreezeWallets.
+func (h *WalletHandler) UnfreezeWallets(w http.ResponseWriter, r *http.Request) {
+	h.setWalletsStatus(w, r, models.WalletStatusActive)
+}
+
+func (h *WalletHandler) setWalletsStatus(w http.ResponseWriter, r *http.Request, status models.WalletStatus) {
+	var filter models.WalletFilter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.SetWalletsStatus(r.Context(), filter, status)
+	if err != nil {
+		if errors.Is(err, service.ErrFilterRequired) || errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, maintenance.ErrOutsideWindow) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJob(w, job)
+}
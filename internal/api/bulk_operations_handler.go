@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// MaxBulkOperationsBytes caps the size of a bulk operations upload.
+const MaxBulkOperationsBytes = 32 << 20
+
+var errInvalidBulkBody = errors.New("invalid bulk operations body")
+
+// StartBulkOperations accepts operations as a JSON array of operation
+// objects, a CSV body (text/csv) or a multipart upload with a "file" part,
+// and starts a job processing them. CSV input has the header
+// walletId,operationType,amount and an optional category column.
+func (h *WalletHandler) StartBulkOperations(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBulkOperationsBytes)
+
+	ops, err := decodeBulkOperations(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.StartBulkOperations(r.Context(), ops)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/jobs/operations/"+job.ID.String())
+	respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// GetBulkOperationsJob reports the progress of a bulk operations job.
+func (h *WalletHandler) GetBulkOperationsJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetBulkOperationsJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// GetBulkOperationsReport downloads the per-operation results of a finished
+// bulk operations job as CSV.
+func (h *WalletHandler) GetBulkOperationsReport(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetBulkOperationsReport(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, jobs.ErrReportNotFound):
+			http.Error(w, "job is still running", http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", report.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="operations-%s.csv"`, jobID))
+	w.Write(report.Data)
+}
+
+func decodeBulkOperations(r *http.Request) ([]models.WalletOperation, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "application/json"
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidBulkBody, err)
+		}
+		defer file.Close()
+		partType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+		if partType == "text/csv" || strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
+			return decodeOperationsCSV(file)
+		}
+		return decodeOperationsJSON(file)
+	case "text/csv":
+		return decodeOperationsCSV(r.Body)
+	default:
+		return decodeOperationsJSON(r.Body)
+	}
+}
+
+func decodeOperationsJSON(body io.Reader) ([]models.WalletOperation, error) {
+	var ops []models.WalletOperation
+	if err := json.NewDecoder(body).Decode(&ops); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errInvalidBulkBody, err)
+	}
+	return ops, nil
+}
+
+func decodeOperationsCSV(body io.Reader) ([]models.WalletOperation, error) {
+	rows := csv.NewReader(body)
+	rows.FieldsPerRecord = -1
+
+	header, err := rows.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header: %v", errInvalidBulkBody, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"walletId", "operationType", "amount"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", errInvalidBulkBody, required)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var ops []models.WalletOperation
+	for line := 2; ; line++ {
+		rec, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			return ops, nil
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", errInvalidBulkBody, err)
+		}
+		walletID, err := uuid.Parse(field(rec, "walletId"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid walletId", errInvalidBulkBody, line)
+		}
+		amount, err := strconv.ParseInt(field(rec, "amount"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid amount", errInvalidBulkBody, line)
+		}
+		ops = append(ops, models.WalletOperation{
+			WalletID:      walletID,
+			OperationType: models.OperationType(field(rec, "operationType")),
+			Amount:        amount,
+			Category:      field(rec, "category"),
+		})
+	}
+}
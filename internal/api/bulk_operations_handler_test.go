@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBulkOperations(t *testing.T) {
+	walletID := uuid.New()
+
+	t.Run("json", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+			`[{"walletId":"`+walletID.String()+`","poerationType":"DEPOSIT","amount":10}]`))
+		r.Header.Set("Content-Type", "application/json")
+
+		ops, err := decodeBulkOperations(r)
+		require.NoError(t, err)
+		assert.Equal(t, []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 10}}, ops)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+			"walletId,operationType,amount,category\n"+walletID.String()+",WITHDRAW,25,fees\n"))
+		r.Header.Set("Content-Type", "text/csv")
+
+		ops, err := decodeBulkOperations(r)
+		require.NoError(t, err)
+		assert.Equal(t, []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 25, Category: "fees"}}, ops)
+	})
+
+	t.Run("multipart csv file", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("file", "ops.csv")
+		require.NoError(t, err)
+		part.Write([]byte("walletId,operationType,amount\n" + walletID.String() + ",DEPOSIT,5\n"))
+		require.NoError(t, mw.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/", &body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+
+		ops, err := decodeBulkOperations(r)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		assert.Equal(t, int64(5), ops[0].Amount)
+	})
+
+	t.Run("csv errors", func(t *testing.T) {
+		for _, body := range []string{
+			"walletId,amount\n",
+			"walletId,operationType,amount\nnot-a-uuid,DEPOSIT,5\n",
+			"walletId,operationType,amount\n" + walletID.String() + ",DEPOSIT,five\n",
+		} {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "text/csv")
+			_, err := decodeBulkOperations(r)
+			assert.ErrorIs(t, err, errInvalidBulkBody)
+		}
+	})
+}
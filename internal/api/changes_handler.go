@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/service"
+	"wallet-service/internal/timeline"
+
+	"github.com/google/uuid"
+)
+
+// waitForChanges long-polls a wallet for clients that can't hold a stream
+// open: it answers with the wallet as soon as its version exceeds the
+// sinceVersion query parameter, or with 204 No Content once timeout passes
+// without a change, after which the client asks again. Operations
+// published to broker end the wait at once; the wallet is also reread
+// every interval to notice changes committed by other instances. Without
+// a positive timeout the current state is returned straight away.
+func waitForChanges(svc *service.WalletService, broker *timeline.Broker, timeout, interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		walletID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+			return
+		}
+		since, err := strconv.Atoi(r.URL.Query().Get("sinceVersion"))
+		if err != nil || since < 0 {
+			http.Error(w, "Invalid sinceVersion", http.StatusBadRequest)
+			return
+		}
+
+		// Subscribe before reading the wallet so that no change committed
+		// in between is missed. Events only end the wait, so buffering the
+		// latest one is enough.
+		var changes <-chan timeline.Event
+		if broker != nil {
+			sub := broker.SubscribeWith(timeline.Filter{WalletID: walletID}, timeline.Policy{Buffer: 1, Overflow: timeline.DropOldest})
+			defer sub.Close()
+			changes = sub.C
+		}
+		var reread <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			reread = ticker.C
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), max(timeout, 0))
+		defer cancel()
+
+		for {
+			wallet, err := svc.GetWallet(r.Context(), walletID)
+			switch {
+			case errors.Is(err, service.ErrInvalidInput):
+				http.Error(w, "wallet not found", http.StatusNotFound)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			case wallet.Version > since:
+				respondWithJSON(w, http.StatusOK, wallet)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case _, ok := <-changes:
+				if !ok {
+					// The broker closes subscriptions on shutdown.
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			case <-reread:
+			}
+		}
+	}
+}
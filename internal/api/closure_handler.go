@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// CloseWallet closes an empty wallet. Its transactions are kept until the
+// recovery window has passed, so it can be restored until then.
+func (h *WalletHandler) CloseWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.CloseWallet(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletClosed), errors.Is(err, repository.ErrWalletNotEmpty):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
+
+// DeleteWallet closes a wallet for its owner. The wallet must be empty
+// unless ?force=true, which sweeps its balance off first; held funds still
+// keep it open.
+func (h *WalletHandler) DeleteWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	var force bool
+	if v := r.URL.Query().Get("force"); v != "" {
+		if force, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid force flag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	closeWallet := h.service.CloseWallet
+	if force {
+		closeWallet = h.service.ForceCloseWallet
+	}
+	wallet, err := closeWallet(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletClosed), errors.Is(err, repository.ErrWalletNotEmpty),
+			errors.Is(err, repository.ErrWalletFrozen):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
+
+// RestoreWallet reopens a closed wallet that hasn't been purged yet.
+func (h *WalletHandler) RestoreWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.RestoreWallet(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletNotClosed), errors.Is(err, repository.ErrWalletPurged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// SetCreditLimit sets how far below zero withdrawals may take a wallet's
+// balance.
+func (h *WalletHandler) SetCreditLimit(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	var req models.SetCreditLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.SetCreditLimit(r.Context(), walletID, req.CreditLimit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrCreditLimitTooLow):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
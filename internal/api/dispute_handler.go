@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// OpenDispute disputes a wallet operation identified by wallet and version.
+func (h *WalletHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.service.OpenDispute(r.Context(), req)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, d)
+}
+
+// ListDisputes lists disputes, optionally filtered by the status query
+// parameter.
+func (h *WalletHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	status := models.DisputeStatus(r.URL.Query().Get("status"))
+
+	disputes, err := h.service.ListDisputes(r.Context(), status)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, disputes)
+}
+
+func (h *WalletHandler) GetDispute(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.service.GetDispute(r.Context(), id)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, d)
+}
+
+func (h *WalletHandler) ResolveDispute(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.service.ResolveDispute(r.Context(), id, req)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, d)
+}
+
+func writeDisputeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput),
+		errors.Is(err, repository.ErrNotDisputable):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrDisputeNotFound),
+		errors.Is(err, repository.ErrTransactionNotFound),
+		errors.Is(err, repository.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrDisputeExists),
+		errors.Is(err, repository.ErrDisputeClosed),
+		errors.Is(err, repository.ErrInsufficientFunds),
+		errors.Is(err, repository.ErrWalletFrozen):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ReactivateWallet returns a dormant wallet to active once its owner has
+// been re-verified, lifting the restriction on large withdrawals.
+func (h *WalletHandler) ReactivateWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.ReactivateWallet(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletNotDormant):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
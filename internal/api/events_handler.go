@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"wallet-service/internal/eventlog"
+	"wallet-service/internal/models"
+)
+
+// NextOffsetTrailer carries the offset to resume the event export from.
+// Every exported event carries its own offset too, so clients that can't
+// read trailers resume from the last event they stored.
+const NextOffsetTrailer = "X-Next-Offset"
+
+// ExportEvents streams the ledger event log after the offset given by the
+// "after" query parameter, as NDJSON or, with format=avro, as an Avro
+// object container file.
+func (h *WalletHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var after int64
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid after", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	format := eventlog.Format(q.Get("format"))
+	switch format {
+	case "":
+		format = eventlog.FormatNDJSON
+	case eventlog.FormatNDJSON, eventlog.FormatAvro:
+	default:
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Trailer", NextOffsetTrailer)
+	w.WriteHeader(http.StatusOK)
+	enc, err := eventlog.NewWriter(format, w)
+	if err != nil {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+
+	next, err := h.service.ExportEvents(r.Context(), after, limit, func(batch []models.LedgerEvent) error {
+		if err := enc.Write(batch); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// The missing trailer tells the client the export was cut short.
+		return
+	}
+	enc.Close()
+	w.Header().Set(NextOffsetTrailer, strconv.FormatInt(next, 10))
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// FreezeWallet places a fraud or compliance hold on one wallet; operations
+// on it are rejected until it is unfrozen.
+func (h *WalletHandler) FreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.setWalletStatus(w, r, h.service.FreezeWallet)
+}
+
+// UnfreezeWallet lifts the hold FreezeWallet placed on a wallet.
+func (h *WalletHandler) UnfreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.setWalletStatus(w, r, h.service.UnfreezeWallet)
+}
+
+func (h *WalletHandler) setWalletStatus(w http.ResponseWriter, r *http.Request,
+	set func(context.Context, uuid.UUID) (*models.Wallet, error)) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := set(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletFrozen), errors.Is(err, repository.ErrWalletNotFrozen),
+			errors.Is(err, repository.ErrWalletClosed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
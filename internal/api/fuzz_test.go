@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"wallet-service/internal/config"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// fuzzRouter returns a router over an in-memory service with one funded
+// wallet, so that fuzzed requests can reach past the lookup of a wallet.
+func fuzzRouter(f *testing.F) (http.Handler, uuid.UUID) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	if err != nil {
+		f.Fatal(err)
+	}
+	if _, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 1000}); err != nil {
+		f.Fatal(err)
+	}
+	return NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()}), wallet.ID
+}
+
+// FuzzJSONRequests posts arbitrary bodies to the routes that decode JSON.
+// Malformed or invalid requests must be rejected with a 4xx status; a 5xx
+// or a panic means a decoder or validator let bad input through.
+func FuzzJSONRequests(f *testing.F) {
+	router, walletID := fuzzRouter(f)
+	id := walletID.String()
+	routes := []string{
+		"/api/v1/wallets",
+		"/api/v1/wallets/bulk",
+		"/api/v1/wallet",
+		"/api/v1/wallets/transfer",
+		"/api/v1/atomic",
+		"/api/v1/operations/batch",
+		"/api/v1/webhooks",
+	}
+
+	for i := range routes {
+		f.Add(uint8(i), `{}`)
+		f.Add(uint8(i), `null`)
+		f.Add(uint8(i), `[]`)
+		f.Add(uint8(i), ``)
+	}
+	f.Add(uint8(0), `{"currency":"USD","label":"main","tenant":"acme"}`)
+	f.Add(uint8(0), `{"currency":"\u0000"}`)
+	f.Add(uint8(1), `{"count":3,"currency":"EUR"}`)
+	f.Add(uint8(1), `{"count":-1}`)
+	f.Add(uint8(2), `{"walletId":"`+id+`","poerationType":"DEPOSIT","amount":100}`)
+	f.Add(uint8(2), `{"walletId":"`+id+`","poerationType":"WITHDRAW","amount":9223372036854775807}`)
+	f.Add(uint8(2), `{"walletId":"`+id+`","poerationType":"DEPOSIT","amount":1e400}`)
+	f.Add(uint8(2), `{"walletId":"not-a-uuid","poerationType":"DEPOSIT","amount":1}`)
+	f.Add(uint8(3), `{"fromWalletId":"`+id+`","toWalletId":"`+id+`","amount":1}`)
+	f.Add(uint8(4), `{"steps":[{"walletId":"`+id+`","poerationType":"WITHDRAW","amount":-5}]}`)
+	f.Add(uint8(5), `{"operations":[null,{}]}`)
+	f.Add(uint8(6), `{"url":"https://example.com","secret":"s","eventTypes":["wallet.created"]}`)
+	f.Add(uint8(6), `{"url":"http://[::1","secret":"s","eventTypes":[]}`)
+
+	f.Fuzz(func(t *testing.T, route uint8, body string) {
+		path := routes[int(route)%len(routes)]
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rec.Code >= http.StatusInternalServerError {
+			t.Fatalf("POST %s %q: status %d: %s", path, body, rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// FuzzWalletPath requests a wallet by an arbitrary path segment, which
+// must be parsed as a UUID or rejected.
+func FuzzWalletPath(f *testing.F) {
+	router, walletID := fuzzRouter(f)
+
+	f.Add(walletID.String())
+	f.Add(strings.ToUpper(walletID.String()))
+	f.Add("{" + walletID.String() + "}")
+	f.Add("urn:uuid:" + walletID.String())
+	f.Add(strings.ReplaceAll(walletID.String(), "-", ""))
+	f.Add(uuid.Nil.String())
+	f.Add("00000000-0000-0000-0000-00000000000g")
+	f.Add("")
+	f.Add("..")
+
+	f.Fuzz(func(t *testing.T, segment string) {
+		for _, suffix := range []string{"", "/balance", "/versions", "/transactions"} {
+			target := "/api/v1/wallets/" + url.PathEscape(segment) + suffix
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code >= http.StatusInternalServerError {
+				t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK {
+				if id, err := uuid.Parse(segment); err != nil || id != walletID {
+					t.Fatalf("GET %s: found a wallet for %q", target, segment)
+				}
+			}
+		}
+	})
+}
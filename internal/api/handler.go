@@ -3,42 +3,90 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
+	"wallet-service/internal/limits"
 	"wallet-service/internal/models"
+	"wallet-service/internal/report"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/sandbox"
 	"wallet-service/internal/service"
+	"wallet-service/internal/storage"
 
 	"github.com/google/uuid"
 )
 
 type WalletHandler struct {
-	service *service.WalletService
+	service    *service.WalletService
+	statements *report.StatementRenderer
 }
 
-func NewWalletHandler(service *service.WalletService) *WalletHandler {
+func NewWalletHandler(service *service.WalletService, statements *report.StatementRenderer) *WalletHandler {
 	return &WalletHandler{
-		service: service,
+		service:    service,
+		statements: statements,
 	}
 }
 
+// CreateWallet accepts an optional body with the wallet's owner and
+// currency; an empty body creates an unowned wallet in the default currency.
 func (h *WalletHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
-	wallet, err := h.service.CreateWallet(r.Context())
+	var req models.CreateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.AuthorizeNewWallet(r.Context(), &req); err != nil {
+		respondWithAuthzError(w, err)
+		return
+	}
+
+	wallet, err := h.service.CreateWallet(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCreateWalletError(w, err)
 		return
 	}
 	respondWithJSON(w, http.StatusCreated, wallet)
 }
 
-func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
-	path := strings.Split(r.URL.Path, "/")
-	if len(path) < 5 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
+// CreateWallets creates the number of wallets in the body's count, all with
+// the body's owner, currency, label and tenant, and returns their ids.
+func (h *WalletHandler) CreateWallets(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWalletsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if err := h.service.AuthorizeNewWallet(r.Context(), &req.CreateWalletRequest); err != nil {
+		respondWithAuthzError(w, err)
+		return
+	}
+
+	created, err := h.service.CreateWallets(r.Context(), req)
+	if err != nil {
+		writeCreateWalletError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, created)
+}
+
+func writeCreateWalletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrScreeningBlocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrScreeningUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
 
-	walletID, err := uuid.Parse(path[4])
+func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
 		return
@@ -46,14 +94,117 @@ func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
 
 	wallet, err := h.service.GetWallet(r.Context(), walletID)
 	if err != nil {
-		if errors.Is(err, repository.ErrWalletNotFound) {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
 			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, wallet)
+}
+
+// GetWalletBalance returns only the balance of a wallet.
+func (h *WalletHandler) GetWalletBalance(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.service.GetWalletBalance(r.Context(), walletID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, balance)
+}
+
+// GetWalletVersions lists every version of the wallet with the operation
+// that produced it.
+func (h *WalletHandler) GetWalletVersions(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.service.GetWalletVersions(r.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, "wallet not found", http.StatusNotFound)
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	respondWithJSON(w, http.StatusOK, wallet)
+	respondWithPage(w, r, versions)
+}
+
+// GetOwnerBalance returns the owner's balance aggregated per currency. The
+// optional maxStaleness parameter (a duration such as "500ms") bounds how old
+// a cached result may be; "0s" forces a fresh read.
+func (h *WalletHandler) GetOwnerBalance(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := uuid.Parse(r.PathValue("ownerId"))
+	if err != nil {
+		http.Error(w, "Invalid owner ID", http.StatusBadRequest)
+		return
+	}
+	maxStaleness, err := parseMaxStaleness(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.service.OwnerBalanceWithin(r.Context(), ownerID, maxStaleness)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, balance)
+}
+
+// GetTenantBalance returns the tenant's balance aggregated per currency,
+// with the same maxStaleness parameter as GetOwnerBalance.
+func (h *WalletHandler) GetTenantBalance(w http.ResponseWriter, r *http.Request) {
+	maxStaleness, err := parseMaxStaleness(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.service.TenantBalance(r.Context(), r.PathValue("tenant"), maxStaleness)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, balance)
+}
+
+func parseMaxStaleness(r *http.Request) (time.Duration, error) {
+	raw := r.URL.Query().Get("maxStaleness")
+	if raw == "" {
+		return service.AnyStaleness, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, errors.New("maxStaleness must be a non-negative duration")
+	}
+	return d, nil
 }
 
 func (h *WalletHandler) ProcessOperation(w http.ResponseWriter, r *http.Request) {
@@ -62,14 +213,35 @@ func (h *WalletHandler) ProcessOperation(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if !h.authorize(w, r, operation.WalletID) {
+		return
+	}
 
 	wallet, err := h.service.ProcessOperation(r.Context(), operation)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrWalletNotFound):
 			http.Error(w, err.Error(), http.StatusNotFound)
-		case errors.Is(err, repository.ErrInsufficientFunds):
+		case errors.Is(err, service.ErrInvalidInput),
+			errors.Is(err, repository.ErrInsufficientFunds):
 			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletFrozen),
+			errors.Is(err, repository.ErrWalletClosed),
+			errors.Is(err, service.ErrReactivationRequired):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrScreeningBlocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrScreeningUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrOperationRejected):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, limits.ErrLimitExceeded),
+			errors.Is(err, service.ErrLimitExceeded):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, sandbox.ErrProviderFailure):
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		case errors.Is(err, repository.ErrRetryable):
+			respondWithRetryable(w, err)
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -78,8 +250,141 @@ func (h *WalletHandler) ProcessOperation(w http.ResponseWriter, r *http.Request)
 	respondWithJSON(w, http.StatusOK, wallet)
 }
 
+// GetStatement renders a PDF statement for the wallet. The period defaults to
+// the current calendar month; from/to accept YYYY-MM-DD dates.
+func (h *WalletHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+	}
+	if to.Before(from) {
+		http.Error(w, "Invalid period", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.GetWallet(r.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, "wallet not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pdf, err := h.statements.Render(wallet, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="statement-`+walletID.String()+`.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
+
+// ExportWallets streams all wallets as newline-delimited JSON. The dump is
+// taken from a single database snapshot, so it is consistent even while
+// operations keep running. With ?destination=storage the dump is uploaded
+// to object storage instead and a signed download URL is returned; adding
+// async=true runs the upload as a background job whose report holds the
+// URL.
+func (h *WalletHandler) ExportWallets(w http.ResponseWriter, r *http.Request) {
+	batchSize := 0
+	if v := r.URL.Query().Get("batchSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid batchSize", http.StatusBadRequest)
+			return
+		}
+		batchSize = n
+	}
+
+	if r.URL.Query().Get("destination") == "storage" && r.URL.Query().Get("async") == "true" {
+		job, err := h.service.StartExportWalletsToStore(r.Context(), batchSize)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotConfigured) {
+				http.Error(w, err.Error(), http.StatusNotImplemented)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondWithJob(w, job)
+		return
+	}
+
+	if r.URL.Query().Get("destination") == "storage" {
+		result, err := h.service.ExportWalletsToStore(r.Context(), batchSize)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotConfigured) {
+				http.Error(w, err.Error(), http.StatusNotImplemented)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondWithJSON(w, http.StatusCreated, result)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	h.service.ExportWallets(r.Context(), batchSize, func(batch []models.Wallet) error {
+		for i := range batch {
+			if err := enc.Encode(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
 func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// retryableError is the body of a 409 for a conflict the service kept
+// hitting through its own retries, e.g. concurrent modifications of a hot
+// wallet. Nothing was applied, so the client may resend the request.
+type retryableError struct {
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+	Attempts  int    `json:"attempts,omitempty"`
+}
+
+// respondWithRetryable answers a repository.ErrRetryable failure with 409,
+// the retryable hint and the number of attempts the service made.
+func respondWithRetryable(w http.ResponseWriter, err error) {
+	body := retryableError{Error: err.Error(), Retryable: true}
+	var exhausted *service.RetriesExhaustedError
+	if errors.As(err, &exhausted) {
+		body.Attempts = exhausted.Attempts
+	}
+	respondWithJSON(w, http.StatusConflict, body)
+}
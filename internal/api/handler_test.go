@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespondWithRetryable(t *testing.T) {
+	err := fmt.Errorf("failed to process operation after multiple retries: %w",
+		&service.RetriesExhaustedError{Attempts: 5, Err: repository.ErrConcurrentModification})
+	rec := httptest.NewRecorder()
+
+	respondWithRetryable(rec, err)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	var body retryableError
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.Retryable)
+	assert.Equal(t, 5, body.Attempts)
+	assert.Contains(t, body.Error, "concurrent modification")
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"wallet-service/internal/diagnostics"
+	"wallet-service/internal/health"
+)
+
+// healthResponse is the body of the health endpoints. Checks are included
+// by the readiness endpoint.
+type healthResponse struct {
+	Status   string               `json:"status"`
+	Draining bool                 `json:"draining,omitempty"`
+	Checks   []diagnostics.Result `json:"checks,omitempty"`
+}
+
+// liveness answers GET /healthz: the process is up and serving HTTP. It
+// checks nothing else, so that an orchestrator doesn't restart the service
+// over a database outage it can't fix.
+func liveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readiness answers GET /readyz with 200 if the service should get traffic
+// and 503 if a check fails or it is draining for shutdown. It runs the
+// checks behind the admin diagnostics, and its answer is also served by
+// the gRPC health service.
+func readiness(m *health.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ready := m.Check(r.Context())
+		resp := healthResponse{Status: "ok", Draining: m.Draining(), Checks: report.Checks}
+		code := http.StatusOK
+		if !ready {
+			resp.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+		respondWithJSON(w, code, resp)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// PlaceHold reserves part of the wallet's available balance until the hold
+// is captured or released.
+func (h *WalletHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PlaceHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.PlaceHold(r.Context(), walletID, req)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, hold)
+}
+
+func (h *WalletHandler) ListHolds(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	holds, err := h.service.ListHolds(r.Context(), walletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, holds)
+}
+
+// CaptureHold withdraws the amount in the body, or the whole hold without
+// one, and releases the rest.
+func (h *WalletHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	walletID, holdID, ok := holdPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CaptureHoldRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hold, err := h.service.CaptureHold(r.Context(), walletID, holdID, req)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, hold)
+}
+
+func (h *WalletHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	walletID, holdID, ok := holdPath(w, r)
+	if !ok {
+		return
+	}
+
+	hold, err := h.service.ReleaseHold(r.Context(), walletID, holdID)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, hold)
+}
+
+func holdPath(w http.ResponseWriter, r *http.Request) (walletID, holdID uuid.UUID, ok bool) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	holdID, err = uuid.Parse(r.PathValue("holdId"))
+	if err != nil {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return walletID, holdID, true
+}
+
+func writeHoldError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput),
+		errors.Is(err, repository.ErrInsufficientFunds),
+		errors.Is(err, repository.ErrHoldExceeded):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrWalletNotFound),
+		errors.Is(err, repository.ErrHoldNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrScreeningBlocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repository.ErrHoldResolved),
+		errors.Is(err, repository.ErrWalletFrozen),
+		errors.Is(err, repository.ErrWalletClosed),
+		errors.Is(err, service.ErrReactivationRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrLimitExceeded):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrScreeningUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, repository.ErrRetryable):
+		respondWithRetryable(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
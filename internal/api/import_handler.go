@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+)
+
+// ImportWallet migrates a wallet with its balance from a legacy ledger.
+// A second import of the same external id is rejected with 409.
+func (h *WalletHandler) ImportWallet(w http.ResponseWriter, r *http.Request) {
+	var req models.ImportWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.ImportWallet(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletAlreadyImported):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrScreeningBlocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrScreeningUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, wallet)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"wallet-service/internal/jobs"
+
+	"github.com/google/uuid"
+)
+
+// maxListedJobs bounds the job listing to the most recent jobs.
+const maxListedJobs = 1000
+
+var reportExtensions = map[string]string{
+	"text/csv":         ".csv",
+	"application/json": ".json",
+}
+
+// ListJobs lists recent background jobs, newest first, optionally only
+// those of the kind query parameter.
+func (h *WalletHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListJobs(r.Context(), r.URL.Query().Get("kind"), maxListedJobs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, list)
+}
+
+// GetJob reports the state and progress of a background job.
+func (h *WalletHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// GetJobReport downloads the report a background job attached.
+func (h *WalletHandler) GetJobReport(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetJobReport(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, jobs.ErrReportNotFound):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	filename := "job-" + jobID.String() + reportExtensions[report.ContentType]
+	w.Header().Set("Content-Type", report.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Write(report.Data)
+}
+
+// respondWithJob answers a request that started a background job.
+func respondWithJob(w http.ResponseWriter, job jobs.Job) {
+	w.Header().Set("Location", "/api/v1/admin/jobs/"+job.ID.String())
+	respondWithJSON(w, http.StatusAccepted, job)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// PlaceLegalHold keeps a wallet's records past retention until the hold is
+// released.
+func (h *WalletHandler) PlaceLegalHold(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	var req models.PlaceLegalHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.PlaceLegalHold(r.Context(), walletID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrLegalHoldExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, hold)
+}
+
+// GetLegalHold returns the legal hold on a wallet.
+func (h *WalletHandler) GetLegalHold(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.GetLegalHold(r.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLegalHoldNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, hold)
+}
+
+// ReleaseLegalHold lifts the legal hold on a wallet and returns it.
+func (h *WalletHandler) ReleaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.ReleaseLegalHold(r.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLegalHoldNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, hold)
+}
+
+// ExportWalletRecords streams everything kept on a wallet as NDJSON, for
+// subpoena requests. Failures before the first record get a status code;
+// later ones cut the response short.
+func (h *WalletHandler) ExportWalletRecords(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	out := &ndjsonWriter{w: w}
+	err = h.service.ExportWalletRecords(r.Context(), walletID, out)
+	if err != nil && !out.started {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ndjsonWriter sends the NDJSON headers with the first write, so a handler
+// can still report errors that occur before it.
+type ndjsonWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (n *ndjsonWriter) Write(p []byte) (int, error) {
+	if !n.started {
+		n.w.Header().Set("Content-Type", "application/x-ndjson")
+		n.w.WriteHeader(http.StatusOK)
+		n.started = true
+	}
+	return n.w.Write(p)
+}
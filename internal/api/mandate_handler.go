@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// CreateMandate authorizes a counterparty to pull funds from the wallet.
+func (h *WalletHandler) CreateMandate(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateMandateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	mandate, err := h.service.CreateMandate(r.Context(), walletID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, mandate)
+}
+
+func (h *WalletHandler) ListMandates(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	mandates, err := h.service.ListMandates(r.Context(), walletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, mandates)
+}
+
+func (h *WalletHandler) RevokeMandate(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	mandateID, err := uuid.Parse(r.PathValue("mandateId"))
+	if err != nil {
+		http.Error(w, "Invalid mandate ID", http.StatusBadRequest)
+		return
+	}
+
+	mandate, err := h.service.RevokeMandate(r.Context(), walletID, mandateID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMandateNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, mandate)
+}
+
+// DebitMandate is called by the counterparty to pull funds under a mandate.
+func (h *WalletHandler) DebitMandate(w http.ResponseWriter, r *http.Request) {
+	mandateID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid mandate ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MandateDebitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !authorizeCounterparty(w, r, req.Counterparty) {
+		return
+	}
+
+	debit, err := h.service.DebitMandate(r.Context(), mandateID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput),
+			errors.Is(err, repository.ErrInsufficientFunds):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrMandateNotFound),
+			errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrMandateCounterparty),
+			errors.Is(err, service.ErrScreeningBlocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, repository.ErrMandateRevoked),
+			errors.Is(err, repository.ErrWalletFrozen),
+			errors.Is(err, repository.ErrWalletClosed),
+			errors.Is(err, service.ErrReactivationRequired):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, repository.ErrMandateLimitExceeded),
+			errors.Is(err, limits.ErrLimitExceeded),
+			errors.Is(err, service.ErrLimitExceeded):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrScreeningUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, repository.ErrRetryable):
+			respondWithRetryable(w, err)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, debit)
+}
@@ -0,0 +1,377 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/slo"
+)
+
+// requireAdmin admits requests carrying the configured admin token as a
+// bearer token, or authenticated by v, oauth, keys or workloads as a
+// subject with the admin role. With none configured admin routes are
+// disabled. OAuth2 tokens, API keys and workloads are matched against the
+// pattern routes, the mux next serves, matches the request to, or against
+// the request's own pattern when routes is nil; credentials not allowed on
+// it get 403.
+func requireAdmin(token string, v *auth.Validator, oauth *auth.OAuth, keys *auth.APIKeys, workloads *auth.Workloads,
+	routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" && v == nil && oauth == nil && keys == nil && workloads == nil {
+			http.Error(w, "admin access is not configured", http.StatusForbidden)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := r.Pattern
+		if routes != nil {
+			_, route = routes.Handler(r)
+		}
+		sub, err := authenticateRequest(r, v, oauth, keys, workloads, route)
+		switch {
+		case errors.Is(err, auth.ErrRouteNotAllowed):
+			http.Error(w, "not allowed on this route", http.StatusForbidden)
+			return
+		case errors.Is(err, auth.ErrUnauthenticated):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		case !sub.IsAdmin():
+			http.Error(w, "admin role required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithSubject(r.Context(), sub)))
+	})
+}
+
+// authenticate requires a bearer JWT checked by v or, on routes the token,
+// key or workload is allowed on, an OAuth2 access token checked by oauth,
+// an X-API-Key checked by keys or a client certificate checked by
+// workloads, and puts the caller's subject into the request context. Any
+// of them may be nil; without all of them the route is open.
+func authenticate(v *auth.Validator, oauth *auth.OAuth, keys *auth.APIKeys, workloads *auth.Workloads,
+	next http.Handler) http.Handler {
+	if v == nil && oauth == nil && keys == nil && workloads == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := authenticateRequest(r, v, oauth, keys, workloads, r.Pattern)
+		switch {
+		case errors.Is(err, auth.ErrUnauthenticated):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithSubject(r.Context(), sub)))
+	})
+}
+
+// authenticateRequest checks bearer tokens against v first and falls back
+// to oauth, since both come in the Authorization header.
+func authenticateRequest(r *http.Request, v *auth.Validator, oauth *auth.OAuth, keys *auth.APIKeys,
+	workloads *auth.Workloads, route string) (auth.Subject, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && (v != nil || oauth != nil) {
+		if v != nil {
+			sub, err := v.Validate(token)
+			if err == nil || oauth == nil {
+				return sub, err
+			}
+		}
+		return oauth.Authenticate(r.Context(), token, route)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" && keys != nil {
+		return keys.Authenticate(r.Context(), key, route)
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 && workloads != nil {
+		return workloads.Authenticate(r.TLS, route)
+	}
+	return auth.Subject{}, auth.ErrUnauthenticated
+}
+
+// withLimitScope tags the request with the operation-limit scope of its
+// X-API-Key. Without a limiter every caller falls into the default scope.
+func withLimitScope(limiter *limits.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := limits.DefaultScope
+		if limiter != nil {
+			scope = limiter.ScopeForKey(r.Header.Get("X-API-Key"))
+		}
+		next.ServeHTTP(w, r.WithContext(limits.WithScope(r.Context(), scope)))
+	})
+}
+
+// withFixedLimitScope puts every request into scope, e.g. admin routes
+// that are authenticated separately.
+func withFixedLimitScope(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(limits.WithScope(r.Context(), scope)))
+	})
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streaming responses.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withSLO counts every request against the tracker's objective. Server
+// errors are failures; client errors are the caller's problem and only
+// count if they are slow. Without a tracker requests pass through.
+func withSLO(tracker *slo.Tracker, next http.Handler) http.Handler {
+	if tracker == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		tracker.Observe(rec.status >= http.StatusInternalServerError, time.Since(start))
+	})
+}
+
+// withRequestID gives every request an id, the caller's X-Request-ID if it
+// is usable or a new one otherwise, returns it in the response and puts it
+// into the request context, where the service and repository logs pick it
+// up.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+	})
+}
+
+// withOperation names the request's operation after the route pattern it
+// is served under, so that the database transactions it runs can be told
+// apart in pg_stat_activity.
+func withOperation(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(requestid.WithOperation(r.Context(), pattern)))
+	})
+}
+
+type routeKey struct{}
+
+// nestedMux mounts mux under another one and reports the pattern it matches
+// as the request's route to withHTTPStats, and as its operation like
+// withOperation. Middleware in between passes copies of the request down, so
+// the outer mux's request would otherwise only carry the prefix mux is
+// mounted at.
+func nestedMux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			*route = pattern
+		}
+		if pattern != "" {
+			r = r.WithContext(requestid.WithOperation(r.Context(), pattern))
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// withHTTPStats records every request under the route template the mux
+// matched and the caller's tenant, its limit scope, so neither label grows
+// with traffic. It must wrap the mux to see the pattern the mux sets.
+func withHTTPStats(stats *httpstats.Recorder, limiter *limits.Limiter, next http.Handler) http.Handler {
+	if stats == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		var nested string
+		r = r.WithContext(context.WithValue(r.Context(), routeKey{}, &nested))
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if nested != "" {
+			route = nested
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		tenant := limits.DefaultScope
+		switch {
+		case strings.Contains(route, "/api/v1/admin/"):
+			tenant = AdminLimitScope
+		case limiter != nil:
+			tenant = limiter.ScopeForKey(r.Header.Get("X-API-Key"))
+		}
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		stats.Observe(route, tenant, status, time.Since(start))
+	})
+}
+
+// maxIdempotentBody bounds the request bodies withIdempotency buffers to
+// fingerprint them.
+const maxIdempotentBody = 1 << 20
+
+// responseCapture keeps a copy of the response for withIdempotency.
+type responseCapture struct {
+	statusRecorder
+	body bytes.Buffer
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.statusRecorder.Write(b)
+}
+
+// withIdempotency answers a request carrying an Idempotency-Key already
+// seen from the same caller with the stored response, marked by the
+// Idempotency-Replayed and Idempotency-Original-Date headers, instead of
+// processing it again. Server errors aren't stored, so they can be
+// retried under the same key. Keys are kept apart per limit scope and per
+// authenticated subject, so a caller reusing another's key and body can't
+// be replayed the other's response; it must run inside authenticate.
+func withIdempotency(store idempotency.Store, stats *idempotency.Stats, next http.Handler) http.Handler {
+	if store == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotency.Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil || len(body) > maxIdempotentBody {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := limits.ScopeFrom(r.Context())
+		scoped := idempotencyScope(r.Context()) + ":" + key
+		fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+		rec, err := store.ReserveIdempotencyKey(r.Context(), scoped, fingerprint, time.Now())
+		if err != nil {
+			http.Error(w, "failed to check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				requestID, _ := requestid.IDFrom(r.Context())
+				stats.ObserveConflict(idempotency.Conflict{
+					Scope:       scope,
+					Key:         key,
+					Method:      r.Method,
+					Path:        r.URL.Path,
+					RequestID:   requestID,
+					FirstSeenAt: rec.CreatedAt.UTC(),
+					At:          time.Now().UTC(),
+				})
+				http.Error(w, "Idempotency-Key was used for a different request", http.StatusUnprocessableEntity)
+			case !rec.Completed:
+				stats.Observe(idempotency.OutcomeInProgress)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				stats.Observe(idempotency.OutcomeReplayed)
+				w.Header().Set(idempotency.ReplayedHeader, "true")
+				w.Header().Set(idempotency.OriginalDateHeader, rec.CreatedAt.UTC().Format(http.TimeFormat))
+				if rec.Response.ContentType != "" {
+					w.Header().Set("Content-Type", rec.Response.ContentType)
+				}
+				w.WriteHeader(rec.Response.Status)
+				w.Write(rec.Response.Body)
+			}
+			return
+		}
+		stats.Observe(idempotency.OutcomeNew)
+
+		capture := &responseCapture{statusRecorder: statusRecorder{ResponseWriter: w}}
+		next.ServeHTTP(capture, r)
+
+		ctx := context.WithoutCancel(r.Context())
+		status := capture.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			_ = store.ReleaseIdempotencyKey(ctx, scoped)
+			return
+		}
+		err = store.CompleteIdempotencyKey(ctx, scoped, idempotency.Response{
+			Status:      status,
+			ContentType: capture.Header().Get("Content-Type"),
+			Body:        capture.body.Bytes(),
+		})
+		if err != nil {
+			// Don't leave the key claimed forever; a retry will be
+			// processed again, as without a key.
+			_ = store.ReleaseIdempotencyKey(ctx, scoped)
+		}
+	})
+}
+
+// idempotencyScope names whose Idempotency-Keys a request's belongs with:
+// its limit scope and, if authenticated, its subject, prefixed by kind so a
+// user can't share keys with an API key of the same name.
+func idempotencyScope(ctx context.Context) string {
+	scope := limits.ScopeFrom(ctx)
+	sub, ok := auth.SubjectFrom(ctx)
+	switch {
+	case !ok:
+		return scope
+	case sub.APIKey:
+		return scope + ":api_key:" + sub.ID
+	case sub.Workload:
+		return scope + ":workload:" + sub.ID
+	case sub.Client:
+		return scope + ":oauth_client:" + sub.ID
+	}
+	return scope + ":subject:" + sub.ID
+}
@@ -0,0 +1,416 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/slo"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signToken(t *testing.T, secret []byte, subject string, roles ...string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   "issuer",
+		"aud":   "wallet-service",
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestRequireAdmin(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	secret := []byte("test-secret")
+	v, err := auth.NewValidator("issuer", "wallet-service", secret, 0)
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys([]auth.APIKey{
+		{Name: "ops", Hash: auth.HashAPIKey("ops-key"), Role: auth.RoleAdmin, Routes: []string{"GET /api/v1/admin/config"}},
+		{Name: "batch", Hash: auth.HashAPIKey("batch-key"), Role: auth.RoleUser},
+	}, nil)
+
+	tests := []struct {
+		name      string
+		token     string
+		validator *auth.Validator
+		header    string
+		value     string
+		want      int
+	}{
+		{"disabled without token", "", nil, "Authorization", "Bearer anything", http.StatusForbidden},
+		{"missing header", "secret", nil, "", "", http.StatusUnauthorized},
+		{"wrong token", "secret", nil, "Authorization", "Bearer nope", http.StatusUnauthorized},
+		{"valid token", "secret", nil, "Authorization", "Bearer secret", http.StatusNoContent},
+		{"valid token with jwt configured", "secret", v, "Authorization", "Bearer secret", http.StatusNoContent},
+		{"admin jwt", "", v, "Authorization", "Bearer " + signToken(t, secret, "alice", "admin"), http.StatusNoContent},
+		{"user jwt", "secret", v, "Authorization", "Bearer " + signToken(t, secret, "bob", "user"), http.StatusForbidden},
+		{"admin api key", "", v, "X-API-Key", "ops-key", http.StatusNoContent},
+		{"user api key", "", v, "X-API-Key", "batch-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validator *auth.Validator
+			var apiKeys *auth.APIKeys
+			if tt.validator != nil {
+				validator, apiKeys = tt.validator, keys
+			}
+			admin := http.NewServeMux()
+			admin.Handle("GET /api/v1/admin/config", ok)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/config", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			requireAdmin(tt.token, validator, nil, apiKeys, nil, admin, admin).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("test-secret")
+	v, err := auth.NewValidator("issuer", "wallet-service", secret, 0)
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys([]auth.APIKey{
+		{Name: "batch", Hash: auth.HashAPIKey("batch-key"), Routes: []string{"GET /api/v1/wallets/{id}"}},
+		{Name: "reports", Hash: auth.HashAPIKey("reports-key"), Routes: []string{"GET /api/v1/owners/{ownerId}/balance"}},
+	}, nil)
+	valid, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "issuer",
+		Audience:  jwt.ClaimStrings{"wallet-service"},
+		Subject:   "user-42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(secret)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		validator   *auth.Validator
+		keys        *auth.APIKeys
+		header      string
+		value       string
+		want        int
+		wantSubject auth.Subject
+	}{
+		{"open without validator", nil, nil, "", "", http.StatusNoContent, auth.Subject{}},
+		{"missing credentials", v, keys, "", "", http.StatusUnauthorized, auth.Subject{}},
+		{"invalid token", v, keys, "Authorization", "Bearer nope", http.StatusUnauthorized, auth.Subject{}},
+		{"valid token", v, keys, "Authorization", "Bearer " + valid, http.StatusNoContent, auth.Subject{ID: "user-42", Role: auth.RoleUser}},
+		{"unknown api key", v, keys, "X-API-Key", "nope", http.StatusUnauthorized, auth.Subject{}},
+		{"api key for another route", v, keys, "X-API-Key", "reports-key", http.StatusUnauthorized, auth.Subject{}},
+		{"api key for the route", nil, keys, "X-API-Key", "batch-key", http.StatusNoContent, auth.Subject{ID: "batch", APIKey: true}},
+		{"api keys not accepted", v, nil, "X-API-Key", "batch-key", http.StatusUnauthorized, auth.Subject{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject auth.Subject
+			mux := http.NewServeMux()
+			mux.Handle("GET /api/v1/wallets/{id}", authenticate(tt.validator, nil, tt.keys, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subject, _ = auth.SubjectFrom(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			})))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, tt.wantSubject, subject)
+			if tt.want == http.StatusUnauthorized {
+				assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestWithCORS(t *testing.T) {
+	h := withCORS([]string{"https://admin.example"}, time.Hour, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/admin/config", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodOptions, "https://admin.example")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://admin.example", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "3600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+
+	rec = serve(http.MethodOptions, "https://evil.example")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = serve(http.MethodGet, "https://admin.example")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://admin.example", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+
+	rec = serve(http.MethodGet, "https://evil.example")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = serve(http.MethodGet, "")
+	assert.Empty(t, rec.Header().Get("Vary"))
+}
+
+func TestWithRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestid.Logger(r.Context(), log).Info("handled")
+	}))
+	serve := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "trace-1", serve("trace-1").Header().Get("X-Request-ID"))
+	assert.Contains(t, logs.String(), `"request_id":"trace-1"`)
+
+	// Missing and unusable ids are replaced by fresh ones.
+	for _, id := range []string{"", "has space", strings.Repeat("x", requestid.MaxLength+1)} {
+		got := serve(id).Header().Get("X-Request-ID")
+		assert.NoError(t, uuid.Validate(got), id)
+	}
+}
+
+func TestWithOperation(t *testing.T) {
+	var got string
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/wallets/{id}", withOperation("GET /api/v1/wallets/{id}",
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = requestid.OperationFrom(r.Context())
+		})))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+uuid.NewString(), nil))
+
+	assert.Equal(t, "GET /api/v1/wallets/{id}", got)
+}
+
+func TestAdminUIHandler_ServesIndex(t *testing.T) {
+	rec := httptest.NewRecorder()
+	adminUIHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Wallet admin")
+}
+
+func TestWithLimitScope(t *testing.T) {
+	sum := sha256.Sum256([]byte("batch-key"))
+	limiter := limits.NewLimiter(limits.Config{
+		Scopes: map[string]limits.Limit{"batch": {}},
+		Keys:   map[string]string{hex.EncodeToString(sum[:]): "batch"},
+	})
+
+	var scope string
+	h := withLimitScope(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope = limits.ScopeFrom(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", nil)
+	req.Header.Set("X-API-Key", "batch-key")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "batch", scope)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/wallet", nil))
+	assert.Equal(t, limits.DefaultScope, scope)
+}
+
+func TestWithSLO_CountsServerErrors(t *testing.T) {
+	tracker := slo.NewTracker(slo.Objective{Name: "test", Target: 0.9, Latency: time.Minute}, time.Hour, 1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	statuses := []int{http.StatusOK, http.StatusBadRequest, http.StatusServiceUnavailable}
+	for _, status := range statuses {
+		h := withSLO(tracker, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/wallet", nil))
+	}
+
+	s := tracker.Status()
+	assert.Equal(t, int64(3), s.Total)
+	assert.Equal(t, int64(1), s.Bad)
+}
+
+func TestWithHTTPStats_LabelsRouteAndTenant(t *testing.T) {
+	stats := httpstats.NewRecorder()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/wallets/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	h := withHTTPStats(stats, nil, mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/wallets/abc", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/wallets/def", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	got := stats.Summary(limits.DefaultScope)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "GET /api/v1/wallets/{id}", got[0].Route)
+		assert.Equal(t, uint64(2), got[0].Requests)
+		assert.Equal(t, "unmatched", got[1].Route)
+	}
+}
+
+func TestWithHTTPStats_LabelsNestedRoutes(t *testing.T) {
+	stats := httpstats.NewRecorder()
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/admin/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nestedMux(admin).ServeHTTP(w, r.WithContext(r.Context()))
+	}))
+	h := withHTTPStats(stats, nil, mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+uuid.NewString(), nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+uuid.NewString(), nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/admin/nope", nil))
+
+	got := stats.Summary(AdminLimitScope)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "GET /api/v1/admin/wallets/{id}", got[0].Route)
+		assert.Equal(t, uint64(2), got[0].Requests)
+		assert.Equal(t, "/api/v1/admin/", got[1].Route)
+	}
+}
+
+type memIdempotencyStore struct {
+	records map[string]*idempotency.Record
+}
+
+func (s *memIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key, fingerprint string, now time.Time) (*idempotency.Record, error) {
+	if rec, ok := s.records[key]; ok {
+		return rec, nil
+	}
+	s.records[key] = &idempotency.Record{Key: key, Fingerprint: fingerprint, CreatedAt: now}
+	return nil, nil
+}
+
+func (s *memIdempotencyStore) CompleteIdempotencyKey(_ context.Context, key string, resp idempotency.Response) error {
+	s.records[key].Completed = true
+	s.records[key].Response = resp
+	return nil
+}
+
+func (s *memIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memIdempotencyStore) PurgeIdempotencyKeys(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestWithIdempotency_ReplaysStoredResponse(t *testing.T) {
+	store := &memIdempotencyStore{records: map[string]*idempotency.Record{}}
+	calls := 0
+	h := withIdempotency(store, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondWithJSON(w, http.StatusOK, map[string]int{"balance": 100 * calls})
+	}))
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body))
+		req.Header.Set(idempotency.Header, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("k1", `{"amount":1}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	replay := send("k1", `{"amount":1}`)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(idempotency.ReplayedHeader))
+	assert.NotEmpty(t, replay.Header().Get(idempotency.OriginalDateHeader))
+	assert.Equal(t, "application/json", replay.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, send("k1", `{"amount":2}`).Code)
+
+	send("k2", `{"amount":1}`)
+	assert.Equal(t, 2, calls, "keys are independent")
+}
+
+func TestWithIdempotency_RecordsReplaysAndConflicts(t *testing.T) {
+	store := &memIdempotencyStore{records: map[string]*idempotency.Record{}}
+	stats := idempotency.NewStats()
+	h := withIdempotency(store, stats, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, map[string]int{"balance": 100})
+	}))
+	send := func(key, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body))
+		req.Header.Set(idempotency.Header, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	send("k1", `{"amount":1}`)
+	send("k1", `{"amount":1}`)
+	send("k1", `{"amount":1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, send("k1", `{"amount":2}`))
+
+	sum := stats.Summary()
+	assert.Equal(t, uint64(1), sum.New)
+	assert.Equal(t, uint64(2), sum.Replayed)
+	assert.Equal(t, uint64(1), sum.Conflicts)
+	assert.InDelta(t, 0.5, sum.HitRate, 1e-9)
+	require.Len(t, sum.RecentConflicts, 1)
+	assert.Equal(t, "k1", sum.RecentConflicts[0].Key)
+	assert.Equal(t, limits.DefaultScope, sum.RecentConflicts[0].Scope)
+	assert.Equal(t, "/api/v1/wallet", sum.RecentConflicts[0].Path)
+}
+
+func TestWithIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	store := &memIdempotencyStore{records: map[string]*idempotency.Record{}}
+	h := withIdempotency(store, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(`{}`))
+	req.Header.Set(idempotency.Header, "k1")
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, store.records)
+}
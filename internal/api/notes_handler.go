@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/shard"
+	"wallet-service/internal/service"
+)
+
+// AddTransactionNote attaches a note to a transaction, identified by its
+// ledger event offset, without modifying it.
+func (h *WalletHandler) AddTransactionNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddTransactionNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.service.AddTransactionNote(r.Context(), id, req)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, n)
+}
+
+func (h *WalletHandler) ListTransactionNotes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	notes, err := h.service.ListTransactionNotes(r.Context(), id)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+	respondWithPage(w, r, notes)
+}
+
+func writeNoteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrTransactionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, shard.ErrNotSharded):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
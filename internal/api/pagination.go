@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+var errInvalidPage = errors.New("invalid pagination parameters")
+
+// Page is the envelope every listing endpoint responds with. NextCursor is
+// set when HasMore is true and is passed back as ?cursor= to fetch the next
+// page. Total is omitted when counting the listing isn't cheap.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+type pageRequest struct {
+	limit  int
+	cursor string
+}
+
+// parsePageRequest reads ?limit= (clamped to MaxPageSize) and ?cursor=.
+// Cursors are opaque to clients; they encode the position in the listing's
+// fixed order, an offset or the key of the last item seen.
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	req := pageRequest{limit: DefaultPageSize}
+	q := r.URL.Query()
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return req, errInvalidPage
+		}
+		req.limit = min(limit, MaxPageSize)
+	}
+	if v := q.Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil || len(raw) == 0 {
+			return req, errInvalidPage
+		}
+		req.cursor = string(raw)
+	}
+	return req, nil
+}
+
+func encodeCursor(position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// offset reads the cursor of an offset-paginated listing.
+func (p pageRequest) offset() (int, error) {
+	if p.cursor == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(p.cursor)
+	if err != nil || offset < 0 {
+		return 0, errInvalidPage
+	}
+	return offset, nil
+}
+
+// paginate cuts one page out of a complete, ordered listing.
+func paginate[T any](items []T, req pageRequest, offset int) Page[T] {
+	total := len(items)
+	start := min(offset, total)
+	end := min(start+req.limit, total)
+
+	page := Page[T]{
+		Items: append(make([]T, 0, end-start), items[start:end]...),
+		Total: &total,
+	}
+	if end < total {
+		page.HasMore = true
+		page.NextCursor = encodeCursor(strconv.Itoa(end))
+	}
+	return page
+}
+
+// respondWithPage writes one page of items, or 400 on malformed pagination
+// parameters.
+func respondWithPage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	req, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, err := req.offset()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, paginate(items, req, offset))
+}
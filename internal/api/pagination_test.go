@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespondWithPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	get := func(query string) (int, Page[int]) {
+		rec := httptest.NewRecorder()
+		respondWithPage(rec, httptest.NewRequest(http.MethodGet, "/items"+query, nil), items)
+		var page Page[int]
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+		}
+		return rec.Code, page
+	}
+
+	code, first := get("?limit=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []int{1, 2}, first.Items)
+	assert.True(t, first.HasMore)
+	require.NotNil(t, first.Total)
+	assert.Equal(t, 5, *first.Total)
+
+	_, second := get("?limit=2&cursor=" + first.NextCursor)
+	assert.Equal(t, []int{3, 4}, second.Items)
+
+	_, last := get("?limit=2&cursor=" + second.NextCursor)
+	assert.Equal(t, []int{5}, last.Items)
+	assert.False(t, last.HasMore)
+	assert.Empty(t, last.NextCursor)
+
+	code, _ = get("?limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("?cursor=%21%21")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestRespondWithPage_Empty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondWithPage[string](rec, httptest.NewRequest(http.MethodGet, "/items", nil), nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items":[],"hasMore":false,"total":0}`, rec.Body.String())
+}
+
+func TestWalletCursor(t *testing.T) {
+	c := models.WalletCursor{ID: uuid.New(), Balance: -250, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)}
+
+	for _, v := range []string{"", "id", "-balance", "createdAt"} {
+		sort, err := parseWalletSort(v)
+		require.NoError(t, err, v)
+		raw, err := base64.RawURLEncoding.DecodeString(encodeWalletCursor(c, sort))
+		require.NoError(t, err)
+		got, err := decodeWalletCursor(string(raw), sort)
+		require.NoError(t, err, v)
+		assert.Equal(t, c.ID, got.ID)
+		switch sort.Field {
+		case models.WalletSortBalance:
+			assert.Equal(t, c.Balance, got.Balance)
+		case models.WalletSortCreatedAt:
+			assert.True(t, c.CreatedAt.Equal(got.CreatedAt))
+		}
+	}
+
+	// Plain id cursors predate sorting and must keep working, but only for
+	// the listing order they were issued for.
+	got, err := decodeWalletCursor(c.ID.String(), models.WalletSort{})
+	require.NoError(t, err)
+	assert.Equal(t, c.ID, got.ID)
+	_, err = decodeWalletCursor(c.ID.String(), models.WalletSort{Field: models.WalletSortBalance})
+	assert.ErrorIs(t, err, errInvalidPage)
+	_, err = decodeWalletCursor(c.ID.String()+"|5", models.WalletSort{Field: models.WalletSortID})
+	assert.ErrorIs(t, err, errInvalidPage)
+
+	for _, v := range []string{"-", "label", "-status"} {
+		_, err := parseWalletSort(v)
+		assert.Error(t, err, v)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// RebuildWallet replays a wallet's history and reports whether its stored
+// balances match. With ?repair=true mismatched balances are overwritten
+// with the replayed ones.
+func (h *WalletHandler) RebuildWallet(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	var repair bool
+	if v := r.URL.Query().Get("repair"); v != "" {
+		if repair, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid repair flag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rebuild, err := h.service.RebuildWallet(r.Context(), walletID, repair)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ledger.ErrHistoryGap):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, rebuild)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"wallet-service/internal/reconcile"
+	"wallet-service/internal/service"
+)
+
+// maxSettlementFileSize bounds the body of a reconciliation request.
+const maxSettlementFileSize = 32 << 20
+
+// Reconcile takes an external settlement file as the body, in the format
+// given by the format query parameter (csv or mt940), and returns the
+// reconciliation report. from and to (YYYY-MM-DD) optionally bound the
+// internal transactions considered. With async=true the reconciliation runs
+// as a background job and the report is attached to it.
+func (h *WalletHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = reconcile.FormatCSV
+	}
+
+	var from, to time.Time
+	for name, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := r.URL.Query().Get(name); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+
+	entries, err := reconcile.Parse(format, http.MaxBytesReader(w, r.Body, maxSettlementFileSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		job, err := h.service.StartReconcile(r.Context(), entries, from, to)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondWithJob(w, job)
+		return
+	}
+
+	report, err := h.service.Reconcile(r.Context(), entries, from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ListRewardAccruals lists the cashback earned by the wallet's transactions.
+func (h *WalletHandler) ListRewardAccruals(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	accruals, err := h.service.ListRewardAccruals(r.Context(), walletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, accruals)
+}
@@ -2,15 +2,178 @@ package api
 
 import (
 	"net/http"
+	"wallet-service/internal/alerts"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/config"
+	"wallet-service/internal/diagnostics"
+	"wallet-service/internal/health"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/maintenance"
+	"wallet-service/internal/report"
 	"wallet-service/internal/service"
+	"wallet-service/internal/slo"
+	"wallet-service/internal/timeline"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func NewRouter(walletService *service.WalletService) *http.ServeMux {
-	handler := NewWalletHandler(walletService)
+// Deps are the optional collaborators of the HTTP layer besides the wallet
+// service itself.
+type Deps struct {
+	Statements  *report.StatementRenderer
+	Diagnostics *diagnostics.Runner
+	Limiter     *limits.Limiter
+	Maintenance *maintenance.Gate
+	SLO         *slo.Tracker
+	HTTPStats   *httpstats.Recorder
+	Idempotency idempotency.Store
+	Metrics     prometheus.Gatherer
+	Alerts      *alerts.Evaluator
+	Auth        *auth.Validator
+	APIKeys     *auth.APIKeys
+	Workloads   *auth.Workloads
+	OAuth       *auth.OAuth
+	Timeline    *timeline.Broker
+	Health      *health.Monitor
+
+	// IdempotencyStats counts replays and conflicts of Idempotency-Keys.
+	IdempotencyStats *idempotency.Stats
+}
+
+// AdminLimitScope is the operation-limit scope of admin-initiated operations.
+const AdminLimitScope = "admin"
+
+func NewRouter(walletService *service.WalletService, cfg config.Config, deps Deps) http.Handler {
+	handler := NewWalletHandler(walletService, deps.Statements)
+	if deps.Maintenance == nil {
+		deps.Maintenance = maintenance.NewGate(false, maintenance.PolicyReject, nil)
+	}
+	adminHandler := NewAdminHandler(cfg, deps.Diagnostics, deps.Maintenance, deps.SLO, deps.HTTPStats, deps.Alerts, deps.IdempotencyStats)
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /api/v1/wallets", handler.CreateWallet)
-	mux.HandleFunc("GET /api/v1/wallets/{id}", handler.GetWallet)
-	mux.HandleFunc("POST /api/v1/wallet", handler.ProcessOperation)
-	return mux
+	// Wallet routes require a JWT, OAuth2 access token, API key or workload
+	// certificate once authentication is configured. Users may only act on wallets they own; admin routes need
+	// the admin token or the admin role.
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, withOperation(pattern, authenticate(deps.Auth, deps.OAuth, deps.APIKeys, deps.Workloads, h)))
+	}
+	own := func(h http.HandlerFunc) http.Handler {
+		return requireWalletOwner(walletService, "id", h)
+	}
+
+	handle("POST /api/v1/wallets", withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.CreateWallet)))
+	handle("POST /api/v1/wallets/bulk", withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.CreateWallets))))
+	handle("GET /api/v1/wallets", http.HandlerFunc(handler.ListWallets))
+	handle("GET /api/v1/wallets/{id}", own(handler.GetWallet))
+	handle("DELETE /api/v1/wallets/{id}", own(handler.DeleteWallet))
+	handle("GET /api/v1/wallets/{id}/balance", own(handler.GetWalletBalance))
+	handle("GET /api/v1/wallets/{id}/statement.pdf", own(handler.GetStatement))
+	handle("GET /api/v1/wallets/{id}/versions", own(handler.GetWalletVersions))
+	handle("GET /api/v1/wallets/{id}/changes", own(waitForChanges(walletService, deps.Timeline, cfg.LongPoll.Timeout, cfg.LongPoll.Interval)))
+	handle("GET /api/v1/wallets/{id}/transactions", own(handler.ListTransactions))
+	handle("GET /api/v1/wallets/{id}/promo", own(handler.ListPromoCredits))
+	handle("GET /api/v1/wallets/{id}/rewards", own(handler.ListRewardAccruals))
+	handle("GET /api/v1/wallets/{id}/mandates", own(handler.ListMandates))
+	handle("POST /api/v1/wallets/{id}/mandates", own(handler.CreateMandate))
+	handle("DELETE /api/v1/wallets/{id}/mandates/{mandateId}", own(handler.RevokeMandate))
+	handle("GET /api/v1/wallets/{id}/holds", own(handler.ListHolds))
+	handle("POST /api/v1/wallets/{id}/holds", own(handler.PlaceHold))
+	handle("POST /api/v1/wallets/{id}/holds/{holdId}/capture", own(handler.CaptureHold))
+	handle("POST /api/v1/wallets/{id}/holds/{holdId}/release", own(handler.ReleaseHold))
+	handle("GET /api/v1/owners/{ownerId}/balance", requireOwner(walletService, "ownerId", http.HandlerFunc(handler.GetOwnerBalance)))
+	handle("POST /api/v1/wallet", withSLO(deps.SLO, withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.ProcessOperation)))))
+	handle("POST /api/v1/mandates/{id}/debits", withSLO(deps.SLO, withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.DebitMandate)))))
+	handle("POST /api/v1/wallets/transfer", withSLO(deps.SLO, withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.Transfer)))))
+	handle("POST /api/v1/atomic", withSLO(deps.SLO, withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.ProcessAtomic)))))
+	handle("POST /api/v1/operations/batch", withSLO(deps.SLO, withLimitScope(deps.Limiter, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.ProcessBatch)))))
+	handle("GET /api/v1/operations/{id}/attempts", requireUnrestricted(http.HandlerFunc(handler.ListOperationAttempts)))
+	handle("POST /api/v1/webhooks", requireUnrestricted(http.HandlerFunc(handler.CreateWebhookSubscription)))
+	handle("GET /api/v1/webhooks", requireUnrestricted(http.HandlerFunc(handler.ListWebhookSubscriptions)))
+	handle("GET /api/v1/webhooks/{id}", requireUnrestricted(http.HandlerFunc(handler.GetWebhookSubscription)))
+	handle("PUT /api/v1/webhooks/{id}", requireUnrestricted(http.HandlerFunc(handler.UpdateWebhookSubscription)))
+	handle("DELETE /api/v1/webhooks/{id}", requireUnrestricted(http.HandlerFunc(handler.DeleteWebhookSubscription)))
+	handle("GET /api/v1/webhooks/{id}/attempts", requireUnrestricted(http.HandlerFunc(handler.ListWebhookDeliveryAttempts)))
+	handle("GET /api/v1/webhooks/{id}/stats", requireUnrestricted(http.HandlerFunc(handler.GetWebhookSubscriptionStats)))
+	handle("POST /api/v1/webhooks/{id}/test", requireUnrestricted(http.HandlerFunc(handler.TestWebhookSubscription)))
+	handle("POST /api/v1/webhooks/{id}/pause", requireUnrestricted(http.HandlerFunc(handler.PauseWebhookSubscription)))
+	handle("POST /api/v1/webhooks/{id}/resume", requireUnrestricted(http.HandlerFunc(handler.ResumeWebhookSubscription)))
+	handle("POST /api/v1/jobs/operations", requireUnrestricted(withLimitScope(deps.Limiter, http.HandlerFunc(handler.StartBulkOperations))))
+	handle("GET /api/v1/jobs/operations/{id}", requireUnrestricted(http.HandlerFunc(handler.GetBulkOperationsJob)))
+	handle("GET /api/v1/jobs/operations/{id}/report", requireUnrestricted(http.HandlerFunc(handler.GetBulkOperationsReport)))
+	// Admin routes may be called from the admin UI's origins, which get
+	// their own CORS policy.
+	adminRoute := func(routes *http.ServeMux, h http.Handler) http.Handler {
+		return withCORS(cfg.Admin.CORSOrigins, cfg.Admin.CORSMaxAge, requireAdmin(cfg.Admin.Token, deps.Auth, deps.OAuth, deps.APIKeys, deps.Workloads, routes, h))
+	}
+	// Transaction notes are admin-only: only support staff annotate the
+	// ledger.
+	handleAdmin := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, withOperation(pattern, adminRoute(nil, h)))
+	}
+	handleAdmin("POST /api/v1/transactions/{id}/notes", http.HandlerFunc(handler.AddTransactionNote))
+	handleAdmin("GET /api/v1/transactions/{id}/notes", http.HandlerFunc(handler.ListTransactionNotes))
+	handleAdmin("OPTIONS /api/v1/transactions/{id}/notes", http.NotFoundHandler())
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /api/v1/admin/config", adminHandler.GetConfig)
+	admin.HandleFunc("GET /api/v1/admin/diagnostics", adminHandler.GetDiagnostics)
+	admin.HandleFunc("GET /api/v1/admin/slo", adminHandler.GetSLO)
+	admin.HandleFunc("GET /api/v1/admin/endpoints", adminHandler.GetEndpointStats)
+	admin.HandleFunc("GET /api/v1/admin/idempotency", adminHandler.GetIdempotencyStats)
+	admin.HandleFunc("GET /api/v1/admin/alerts", adminHandler.GetAlerts)
+	admin.HandleFunc("GET /api/v1/admin/maintenance", adminHandler.GetMaintenance)
+	admin.HandleFunc("POST /api/v1/admin/maintenance/windows", adminHandler.ScheduleMaintenance)
+	admin.HandleFunc("GET /api/v1/admin/wallets", handler.ListWallets)
+	admin.HandleFunc("GET /api/v1/admin/wallets/search", handler.SearchWallets)
+	admin.HandleFunc("GET /api/v1/admin/wallets/export", handler.ExportWallets)
+	admin.HandleFunc("POST /api/v1/admin/wallets/freeze", handler.FreezeWallets)
+	admin.HandleFunc("POST /api/v1/admin/wallets/unfreeze", handler.UnfreezeWallets)
+	admin.HandleFunc("POST /api/v1/admin/wallets/import", handler.ImportWallet)
+	admin.HandleFunc("GET /api/v1/admin/jobs", handler.ListJobs)
+	admin.HandleFunc("GET /api/v1/admin/jobs/{id}", handler.GetJob)
+	admin.HandleFunc("GET /api/v1/admin/jobs/{id}/report", handler.GetJobReport)
+	admin.HandleFunc("GET /api/v1/admin/events", handler.ExportEvents)
+	admin.HandleFunc("POST /api/v1/admin/reconciliations", handler.Reconcile)
+	admin.HandleFunc("POST /api/v1/admin/inbound", handler.ReceiveInbound)
+	admin.HandleFunc("GET /api/v1/admin/suspense/cases", handler.ListSuspenseCases)
+	admin.HandleFunc("POST /api/v1/admin/suspense/cases/{id}/resolve", handler.ResolveSuspenseCase)
+	admin.HandleFunc("GET /api/v1/admin/disputes", handler.ListDisputes)
+	admin.HandleFunc("POST /api/v1/admin/disputes", handler.OpenDispute)
+	admin.HandleFunc("GET /api/v1/admin/disputes/{id}", handler.GetDispute)
+	admin.HandleFunc("POST /api/v1/admin/disputes/{id}/resolve", handler.ResolveDispute)
+	admin.HandleFunc("GET /api/v1/admin/tenants/{tenant}/balance", handler.GetTenantBalance)
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}", handler.GetWallet)
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}/statement.pdf", handler.GetStatement)
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}/versions", handler.GetWalletVersions)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/rebuild", handler.RebuildWallet)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/promo", handler.GrantPromo)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/reactivate", handler.ReactivateWallet)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/freeze", handler.FreezeWallet)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/unfreeze", handler.UnfreezeWallet)
+	admin.HandleFunc("PUT /api/v1/admin/wallets/{id}/credit-limit", handler.SetCreditLimit)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/close", handler.CloseWallet)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/restore", handler.RestoreWallet)
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}/legal-hold", handler.GetLegalHold)
+	admin.HandleFunc("POST /api/v1/admin/wallets/{id}/legal-hold", handler.PlaceLegalHold)
+	admin.HandleFunc("DELETE /api/v1/admin/wallets/{id}/legal-hold", handler.ReleaseLegalHold)
+	admin.HandleFunc("GET /api/v1/admin/wallets/{id}/legal-export", handler.ExportWalletRecords)
+	if deps.Timeline != nil {
+		admin.Handle("GET /api/v1/admin/transactions/stream", streamTransactions(deps.Timeline, cfg.Streams.WriteTimeout))
+	}
+	admin.Handle("POST /api/v1/admin/operations", withSLO(deps.SLO, withFixedLimitScope(AdminLimitScope, withIdempotency(deps.Idempotency, deps.IdempotencyStats, http.HandlerFunc(handler.ProcessOperation)))))
+	mux.Handle("/api/v1/admin/", adminRoute(admin, nestedMux(admin)))
+
+	mux.Handle("GET /admin/", adminUIHandler())
+	// Probes of load balancers and orchestrators come without credentials.
+	mux.HandleFunc("GET /healthz", liveness)
+	if deps.Health != nil {
+		mux.Handle("GET /readyz", readiness(deps.Health))
+	}
+	if deps.Metrics != nil {
+		mux.Handle("GET /metrics", promhttp.HandlerFor(deps.Metrics, promhttp.HandlerOpts{}))
+	}
+	return withRequestID(withHTTPStats(deps.HTTPStats, deps.Limiter, mux))
 }
@@ -0,0 +1,859 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/config"
+	"wallet-service/internal/diagnostics"
+	"wallet-service/internal/health"
+	"wallet-service/internal/httpstats"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/service"
+	"wallet-service/internal/timeline"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouter_ExposesHTTPMetrics(t *testing.T) {
+	stats := httpstats.NewRecorder()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(stats)
+
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: stats, Metrics: registry})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/wallets/not-a-uuid", nil))
+	admin := httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/search?q=x", nil)
+	admin.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(httptest.NewRecorder(), admin)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+
+	// Requests are counted by status code and timed under their route
+	// template, including routes of the nested admin mux.
+	assert.Contains(t, body, `wallet_http_requests_total{code="400",route="GET /api/v1/wallets/{id}",tenant="default"} 1`)
+	assert.Contains(t, body, `wallet_http_request_duration_seconds_count{route="GET /api/v1/wallets/{id}",tenant="default"} 1`)
+	assert.Contains(t, body, `route="GET /api/v1/admin/wallets/search",tenant="admin"`)
+}
+
+func TestNewRouter_StreamsTransactions(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	broker := timeline.NewBroker()
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithAfterOperation(broker))
+	srv := httptest.NewServer(NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder(), Timeline: broker}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/admin/transactions/stream?minAmount=100", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	for _, amount := range []int64{50, 250} {
+		_, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	// Only the deposit above minAmount is streamed.
+	lines := bufio.NewScanner(res.Body)
+	require.True(t, lines.Scan())
+	assert.Equal(t, "event: transaction", lines.Text())
+	require.True(t, lines.Scan())
+	data, ok := strings.CutPrefix(lines.Text(), "data: ")
+	require.True(t, ok)
+	var e timeline.Event
+	require.NoError(t, json.Unmarshal([]byte(data), &e))
+	assert.Equal(t, wallet.ID, e.WalletID)
+	assert.Equal(t, int64(250), e.Amount)
+	assert.Equal(t, int64(300), e.Balance)
+}
+
+func TestNewRouter_LongPollsWalletChanges(t *testing.T) {
+	var cfg config.Config
+	cfg.LongPoll.Timeout = time.Minute
+	broker := timeline.NewBroker()
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithAfterOperation(broker))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder(), Timeline: broker})
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	poll := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	changes := fmt.Sprintf("/api/v1/wallets/%s/changes?sinceVersion=", wallet.ID)
+
+	// A version the client hasn't seen yet is returned at once.
+	rec := poll(changes + "0")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got models.Wallet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, wallet.Version, got.Version)
+
+	// Otherwise the request waits for the next operation.
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- poll(changes + strconv.Itoa(wallet.Version)) }()
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, time.Millisecond)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+	require.NoError(t, err)
+	rec = <-done
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, wallet.Version+1, got.Version)
+	assert.Equal(t, int64(100), got.Balance)
+
+	assert.Equal(t, http.StatusBadRequest, poll(strings.TrimSuffix(changes, "?sinceVersion=")).Code)
+	assert.Equal(t, http.StatusNotFound, poll(fmt.Sprintf("/api/v1/wallets/%s/changes?sinceVersion=0", uuid.New())).Code)
+
+	// Without a change the request ends with 204 after the timeout.
+	cfg.LongPoll.Timeout = 10 * time.Millisecond
+	router = NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder(), Timeline: broker})
+	rec = poll(changes + strconv.Itoa(got.Version))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestNewRouter_EnforcesRoles(t *testing.T) {
+	secret := []byte("test-secret")
+	v, err := auth.NewValidator("issuer", "wallet-service", secret, 0)
+	require.NoError(t, err)
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder(), Auth: v})
+
+	alice, bob := uuid.New(), uuid.New()
+	aliceToken := signToken(t, secret, alice.String())
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Users own the wallets they create and may not create them for others.
+	rec := do(http.MethodPost, "/api/v1/wallets", aliceToken, `{"currency": "USD"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var own models.Wallet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &own))
+	assert.Equal(t, uuid.NullUUID{UUID: alice, Valid: true}, own.OwnerID)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/wallets", aliceToken, `{"ownerId": "`+bob.String()+`"}`).Code)
+
+	other, err := svc.CreateWallet(context.Background(), models.CreateWalletRequest{OwnerID: uuid.NullUUID{UUID: bob, Valid: true}, Currency: "USD"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/wallets/"+own.ID.String(), aliceToken, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/wallets/"+other.ID.String(), aliceToken, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/owners/"+bob.String()+"/balance", aliceToken, "").Code)
+	deposit := `{"walletId": "%s", "poerationType": "DEPOSIT", "amount": 100}`
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/wallet", aliceToken, fmt.Sprintf(deposit, own.ID)).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/wallet", aliceToken, fmt.Sprintf(deposit, other.ID)).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/wallets/transfer", aliceToken,
+		`{"fromWalletId": "`+other.ID.String()+`", "toWalletId": "`+own.ID.String()+`", "amount": 1}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/jobs/operations/"+uuid.NewString(), aliceToken, "").Code)
+
+	// Admin routes need the admin role; admins may act on every wallet.
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/admin/wallets/"+own.ID.String(), aliceToken, "").Code)
+	adminToken := signToken(t, secret, "ops", "admin")
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/admin/wallets/"+own.ID.String(), adminToken, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/wallets/"+other.ID.String(), adminToken, "").Code)
+}
+
+func TestNewRouter_DeletesWallets(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	del := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+		return rec
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+	require.NoError(t, err)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	// A wallet holding funds is only closed when forced, which sweeps them.
+	assert.Equal(t, http.StatusConflict, del(path).Code)
+	assert.Equal(t, http.StatusBadRequest, del(path+"?force=maybe").Code)
+	rec := del(path + "?force=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var closed models.Wallet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &closed))
+	assert.Equal(t, models.WalletStatusClosed, closed.Status)
+	assert.Zero(t, closed.Balance)
+
+	// A closed wallet takes no further operations.
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 1})
+	assert.ErrorIs(t, err, repository.ErrWalletClosed)
+	assert.Equal(t, http.StatusConflict, del(path).Code)
+	assert.Equal(t, http.StatusNotFound, del("/api/v1/wallets/"+uuid.NewString()).Code)
+}
+
+func TestNewRouter_ProcessesBatches(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	batch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/operations/batch", strings.NewReader(body)))
+		return rec
+	}
+
+	ctx := context.Background()
+	payer, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	payee, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: payer.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+	require.NoError(t, err)
+	ops := func(withdraw int) string {
+		return fmt.Sprintf(`{"operations":[{"walletId":%q,"poerationType":"WITHDRAW","amount":%d},{"walletId":%q,"poerationType":"DEPOSIT","amount":60}]}`,
+			payer.ID, withdraw, payee.ID)
+	}
+
+	rec := batch(ops(60))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var receipt models.AtomicReceipt
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &receipt))
+	require.Len(t, receipt.Steps, 2)
+	assert.Equal(t, int64(40), receipt.Steps[0].Balance)
+	assert.Equal(t, int64(60), receipt.Steps[1].Balance)
+
+	// A failing operation leaves every wallet of the batch as it was.
+	assert.Equal(t, http.StatusBadRequest, batch(ops(41)).Code)
+	b, err := svc.GetWalletBalance(ctx, payee.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), b.Balance)
+
+	assert.Equal(t, http.StatusBadRequest, batch(`{"operations":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		batch(fmt.Sprintf(`{"operations":[{"walletId":%q,"poerationType":"REWARD","amount":1}]}`, payee.ID)).Code)
+}
+
+func TestNewRouter_CreatesWalletsInBulk(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	bulk := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallets/bulk", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := bulk(`{"count":3,"currency":"EUR","tenant":"acme"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created models.CreateWalletsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Len(t, created.IDs, 3)
+	w, err := svc.GetWallet(context.Background(), created.IDs[2])
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", w.Currency)
+	assert.Equal(t, "acme", w.Tenant)
+
+	assert.Equal(t, http.StatusBadRequest, bulk(`{"count":0}`).Code)
+	assert.Equal(t, http.StatusBadRequest, bulk(fmt.Sprintf(`{"count":%d}`, service.MaxBulkWallets+1)).Code)
+	assert.Equal(t, http.StatusBadRequest, bulk(`{"count":1,"currency":"euro"}`).Code)
+}
+
+func TestNewRouter_ManagesWebhookSubscriptions(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := call(http.MethodPost, "/api/v1/webhooks",
+		`{"url":"https://example.com/hook","secret":"s3cret","eventTypes":["balance.updated","wallet.created","balance.updated"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "s3cret")
+	var sub models.WebhookSubscription
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sub))
+	assert.Equal(t, []string{"balance.updated", "wallet.created"}, sub.EventTypes)
+	path := "/api/v1/webhooks/" + sub.ID.String()
+
+	rec = call(http.MethodPut, path, `{"url":"https://example.com/v2","eventTypes":["wallet.created"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored, err := svc.GetWebhookSubscription(context.Background(), sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v2", stored.URL)
+	assert.Equal(t, "s3cret", stored.Secret)
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/v1/webhooks", "").Code)
+	assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, path, "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, path, "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/webhooks",
+		`{"url":"https://example.com/hook","eventTypes":["wallet.created"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/webhooks",
+		`{"url":"ftp://example.com","secret":"s","eventTypes":["wallet.created"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/v1/webhooks",
+		`{"url":"https://example.com","secret":"s","eventTypes":["wallet.deleted"]}`).Code)
+}
+
+func TestNewRouter_DeliversWebhooks(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []webhook.Event
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify("s3cret", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var e webhook.Event
+		require.NoError(t, json.Unmarshal(body, &e))
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithWebhookDelivery(service.WebhookDeliveryPolicy{
+			Timeout: time.Second, MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour, BatchSize: 10, Workers: 1,
+		}))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	ctx := context.Background()
+
+	sub, err := svc.CreateWebhookSubscription(ctx, models.WebhookSubscriptionRequest{
+		URL: receiver.URL, Secret: "s3cret", EventTypes: []string{service.EventWalletCreated, service.EventBalanceUpdated},
+	})
+	require.NoError(t, err)
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.DeliverWebhooks(ctx))
+	require.Len(t, events, 2)
+	assert.Equal(t, service.EventWalletCreated, events[0].Type)
+	assert.Equal(t, service.EventBalanceUpdated, events[1].Type)
+
+	require.NoError(t, svc.DeliverWebhooks(ctx))
+	assert.Len(t, events, 2, "delivered events are not sent again")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/"+sub.ID.String()+"/attempts", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var attempts []models.WebhookDeliveryAttempt
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &attempts))
+	require.Len(t, attempts, 2)
+	assert.Equal(t, http.StatusOK, attempts[0].StatusCode)
+}
+
+func TestNewRouter_FiltersAndPausesWebhooks(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		amounts []int64
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e struct {
+			Type string               `json:"type"`
+			Data models.BalanceUpdate `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Type == service.EventBalanceUpdated {
+			amounts = append(amounts, e.Data.Operation.Amount)
+		}
+	}))
+	defer receiver.Close()
+
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithWebhookDelivery(service.WebhookDeliveryPolicy{
+			Timeout: time.Second, MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour, BatchSize: 10, Workers: 1,
+		}))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder()})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	ctx := context.Background()
+
+	rec := do(http.MethodPost, "/api/v1/webhooks", `{"url":"`+receiver.URL+`","secret":"s3cret",`+
+		`"eventTypes":["balance.updated"],"filter":{"operationTypes":["CREDIT"]}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "unknown operation types are rejected")
+	rec = do(http.MethodPost, "/api/v1/webhooks", `{"url":"`+receiver.URL+`","secret":"s3cret",`+
+		`"eventTypes":["balance.updated"],"filter":{"operationTypes":["WITHDRAW"],"minAmount":50}}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var sub models.WebhookSubscription
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sub))
+	path := "/api/v1/webhooks/" + sub.ID.String()
+
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{})
+	require.NoError(t, err)
+	operate := func(typ models.OperationType, amount int64) {
+		_, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: typ, Amount: amount})
+		require.NoError(t, err)
+	}
+	operate(models.OperationTypeDeposit, 1000)
+	operate(models.OperationTypeWithdraw, 20)
+	operate(models.OperationTypeWithdraw, 60)
+	require.NoError(t, svc.DeliverWebhooks(ctx))
+	assert.Equal(t, []int64{60}, amounts, "only withdrawals of at least 50 are delivered")
+
+	rec = do(http.MethodPost, path+"/pause", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sub))
+	assert.True(t, sub.Paused)
+	operate(models.OperationTypeWithdraw, 70)
+	require.NoError(t, svc.DeliverWebhooks(ctx))
+	assert.Len(t, amounts, 1, "a paused subscription gets nothing")
+
+	rec = do(http.MethodGet, path+"/stats", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var stats models.WebhookSubscriptionStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Pending)
+	assert.Equal(t, int64(1), stats.Delivered)
+	assert.Equal(t, int64(1), stats.Attempts)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, path+"/resume", "").Code)
+	require.NoError(t, svc.DeliverWebhooks(ctx))
+	assert.Equal(t, []int64{60, 70}, amounts, "events queued while paused are delivered on resume")
+
+	rec = do(http.MethodPost, path+"/test", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var result models.WebhookTestResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.True(t, result.Delivered)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/v1/webhooks/"+uuid.NewString()+"/pause", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/v1/webhooks/"+uuid.NewString()+"/stats", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/v1/webhooks/"+uuid.NewString()+"/test", "").Code)
+}
+
+func TestNewRouter_FreezesWallets(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder()})
+	admin := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	path := "/api/v1/admin/wallets/" + wallet.ID.String()
+	deposit := func() error {
+		_, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 10})
+		return err
+	}
+
+	assert.Equal(t, http.StatusConflict, admin(path+"/unfreeze"))
+	assert.Equal(t, http.StatusOK, admin(path+"/freeze"))
+	assert.Equal(t, http.StatusConflict, admin(path+"/freeze"))
+	assert.ErrorIs(t, deposit(), repository.ErrWalletFrozen)
+
+	assert.Equal(t, http.StatusOK, admin(path+"/unfreeze"))
+	assert.NoError(t, deposit())
+	assert.Equal(t, http.StatusNotFound, admin("/api/v1/admin/wallets/"+uuid.NewString()+"/freeze"))
+}
+
+func TestNewRouter_SetsCreditLimits(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder()})
+	setLimit := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path+"/credit-limit", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	path := "/api/v1/admin/wallets/" + wallet.ID.String()
+	withdraw := func(amount int64) error {
+		_, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeWithdraw, Amount: amount})
+		return err
+	}
+	require.ErrorIs(t, withdraw(10), service.ErrInvalidInput)
+
+	assert.Equal(t, http.StatusBadRequest, setLimit(path, `{"creditLimit":-1}`).Code)
+	rec := setLimit(path, `{"creditLimit":100}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var got models.Wallet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, int64(100), got.CreditLimit)
+
+	require.NoError(t, withdraw(60))
+	assert.ErrorIs(t, withdraw(41), service.ErrInvalidInput)
+	balance, err := svc.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-60), balance.Balance)
+	assert.Equal(t, int64(40), balance.Available)
+
+	assert.Equal(t, http.StatusConflict, setLimit(path, `{"creditLimit":50}`).Code, "the limit must cover the overdraft")
+	assert.Equal(t, http.StatusOK, setLimit(path, `{"creditLimit":60}`).Code)
+	assert.Equal(t, http.StatusNotFound, setLimit("/api/v1/admin/wallets/"+uuid.NewString(), `{"creditLimit":1}`).Code)
+}
+
+func TestNewRouter_ExportsWalletRecordsUnderLegalHold(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder()})
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 10})
+	require.NoError(t, err)
+	path := "/api/v1/admin/wallets/" + wallet.ID.String()
+
+	assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, path+"/legal-hold", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, admin(http.MethodPost, path+"/legal-hold", `{"reason":"case 42"}`).Code)
+	assert.Equal(t, http.StatusConflict, admin(http.MethodPost, path+"/legal-hold", `{"reason":"case 42"}`).Code)
+	assert.Equal(t, http.StatusOK, admin(http.MethodGet, path+"/legal-hold", "").Code)
+
+	rec := admin(http.MethodGet, path+"/legal-export", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		var record struct{ Type string }
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		types = append(types, record.Type)
+	}
+	// The export lists the hold's placement and the export itself.
+	assert.Equal(t, []string{"wallet", "legal_hold", "transaction", "audit_event", "audit_event"}, types)
+
+	assert.Equal(t, http.StatusOK, admin(http.MethodDelete, path+"/legal-hold", "").Code)
+	assert.Equal(t, http.StatusNotFound, admin(http.MethodDelete, path+"/legal-hold", "").Code)
+	assert.Equal(t, http.StatusNotFound, admin(http.MethodGet, "/api/v1/admin/wallets/"+uuid.NewString()+"/legal-export", "").Code)
+}
+
+func TestNewRouter_RebuildsWallets(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	repo := memory.New()
+	svc := service.NewWalletService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithEventSourcing(service.EventSourcingPolicy{SnapshotEvery: 2}))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder()})
+	rebuild := func(path string) (int, models.WalletRebuild) {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body models.WalletRebuild
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		}
+		return rec.Code, body
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{})
+	require.NoError(t, err)
+	for _, amount := range []int64{100, 50, 25} {
+		wallet, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: amount})
+		require.NoError(t, err)
+	}
+	path := "/api/v1/admin/wallets/" + wallet.ID.String() + "/rebuild"
+
+	code, body := rebuild(path)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, body.Consistent)
+	assert.Equal(t, int64(175), body.Replayed.Balance)
+
+	// Corrupt the stored balance behind the history's back.
+	require.NoError(t, repo.Snapshots().RestoreBalances(ctx, models.WalletSnapshot{WalletID: wallet.ID, Version: wallet.Version, Balance: 1}))
+	code, body = rebuild(path)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, body.Consistent)
+	assert.False(t, body.Repaired)
+
+	code, body = rebuild(path + "?repair=true")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, body.Repaired)
+	stored, err := svc.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(175), stored.Balance)
+
+	// Operations apply to the replayed balances even if the stored ones
+	// drift.
+	require.NoError(t, repo.Snapshots().RestoreBalances(ctx, models.WalletSnapshot{WalletID: wallet.ID, Version: wallet.Version, Balance: 1}))
+	updated, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeWithdraw, Amount: 75})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), updated.Balance)
+
+	code, _ = rebuild("/api/v1/admin/wallets/" + uuid.NewString() + "/rebuild")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestNewRouter_ReportsHealth(t *testing.T) {
+	var fail error
+	diag := diagnostics.NewRunner(time.Second)
+	diag.Register("database", func(context.Context) (diagnostics.Status, map[string]any, error) {
+		return diagnostics.StatusOK, nil, fail
+	})
+	monitor := health.NewMonitor(diag)
+
+	validator, err := auth.NewValidator("issuer", "wallet-service", []byte("secret"), 0)
+	require.NoError(t, err)
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{Auth: validator, Health: monitor})
+
+	probe := func(path string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return rec.Code, body
+	}
+
+	// Probes need no credentials.
+	code, body := probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["checks"], 1)
+
+	// A failing check takes the service out of rotation but leaves it
+	// alive.
+	fail = errors.New("connection refused")
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body["status"])
+	code, _ = probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	fail = nil
+	monitor.Drain()
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, true, body["draining"])
+}
+
+func TestNewRouter_LimitsWithdrawals(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithWithdrawalLimits(service.WithdrawalLimits{Daily: 100}))
+	router := NewRouter(svc, config.Config{}, Deps{})
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 500})
+	require.NoError(t, err)
+
+	withdraw := func(amount int64) int {
+		body := fmt.Sprintf(`{"walletId":%q,"poerationType":"WITHDRAW","amount":%d}`, wallet.ID, amount)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body)))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, withdraw(60))
+	assert.Equal(t, http.StatusUnprocessableEntity, withdraw(50))
+	assert.Equal(t, http.StatusOK, withdraw(40))
+	assert.Equal(t, http.StatusUnprocessableEntity, withdraw(1))
+}
+
+func TestNewRouter_LimitsWithdrawalsOfTransfersAndBatches(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithWithdrawalLimits(service.WithdrawalLimits{Daily: 100}))
+	router := NewRouter(svc, config.Config{}, Deps{})
+	post := func(path, body string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	ctx := context.Background()
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	other, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 500})
+	require.NoError(t, err)
+	withdrawal := func(amount int64) string {
+		return fmt.Sprintf(`{"walletId":%q,"poerationType":"WITHDRAW","amount":%d}`, wallet.ID, amount)
+	}
+	transfer := func(amount int64) string {
+		return fmt.Sprintf(`{"fromWalletId":%q,"toWalletId":%q,"amount":%d}`, wallet.ID, other.ID, amount)
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, post("/api/v1/wallets/transfer", transfer(101)))
+	assert.Equal(t, http.StatusOK, post("/api/v1/wallets/transfer", transfer(30)))
+	// Withdrawals of one batch count together.
+	assert.Equal(t, http.StatusUnprocessableEntity,
+		post("/api/v1/operations/batch", `{"operations":[`+withdrawal(40)+`,`+withdrawal(40)+`]}`))
+	assert.Equal(t, http.StatusOK, post("/api/v1/operations/batch", `{"operations":[`+withdrawal(40)+`]}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, post("/api/v1/atomic", `{"steps":[`+withdrawal(31)+`]}`))
+	assert.Equal(t, http.StatusOK, post("/api/v1/atomic", `{"steps":[`+withdrawal(30)+`]}`))
+
+	balance, err := svc.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(400), balance.Balance)
+}
+
+// txOperationRepository records the request id and operation each
+// transaction is begun with, which the Postgres repository tags it with.
+type txOperationRepository struct {
+	*memory.Repository
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *txOperationRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	id, _ := requestid.IDFrom(ctx)
+	op, _ := requestid.OperationFrom(ctx)
+	r.mu.Lock()
+	r.ops = append(r.ops, id+" "+op)
+	r.mu.Unlock()
+	return r.Repository.WithinTx(ctx, fn)
+}
+
+func TestNewRouter_TagsAdminTransactionsWithOperation(t *testing.T) {
+	var cfg config.Config
+	cfg.Admin.Token = "secret"
+	repo := &txOperationRepository{Repository: memory.New()}
+	svc := service.NewWalletService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, cfg, Deps{HTTPStats: httpstats.NewRecorder()})
+
+	wallet, err := svc.CreateWallet(context.Background(), models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	repo.ops = nil
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+wallet.ID.String()+"/credit-limit", strings.NewReader(`{"creditLimit":100}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Contains(t, repo.ops, "req-1 PUT /api/v1/admin/wallets/{id}/credit-limit")
+}
+
+func TestNewRouter_ReplaysWalletCreation(t *testing.T) {
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	store := &memIdempotencyStore{records: map[string]*idempotency.Record{}}
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder(), Idempotency: store})
+	create := func() models.Wallet {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", strings.NewReader(`{"currency":"EUR"}`))
+		req.Header.Set(idempotency.Header, "create-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var w models.Wallet
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &w))
+		return w
+	}
+
+	first := create()
+	assert.Equal(t, first, create())
+
+	wallets, _, err := svc.ListWallets(context.Background(), models.WalletFilter{}, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, wallets, 1)
+}
+
+func TestNewRouter_KeepsIdempotencyKeysPerSubject(t *testing.T) {
+	secret := []byte("test-secret")
+	v, err := auth.NewValidator("issuer", "wallet-service", secret, 0)
+	require.NoError(t, err)
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	store := &memIdempotencyStore{records: map[string]*idempotency.Record{}}
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder(), Auth: v, Idempotency: store})
+	create := func(owner uuid.UUID) models.Wallet {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", strings.NewReader(`{"currency":"EUR"}`))
+		req.Header.Set("Authorization", "Bearer "+signToken(t, secret, owner.String()))
+		req.Header.Set(idempotency.Header, "create-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Empty(t, rec.Header().Get(idempotency.ReplayedHeader))
+		var w models.Wallet
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &w))
+		return w
+	}
+
+	alice, bob := uuid.New(), uuid.New()
+	first, second := create(alice), create(bob)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, uuid.NullUUID{UUID: alice, Valid: true}, first.OwnerID)
+	assert.Equal(t, uuid.NullUUID{UUID: bob, Valid: true}, second.OwnerID)
+}
+
+func TestNewRouter_LimitsAdminKeysToTheirRoutes(t *testing.T) {
+	keys := auth.NewAPIKeys([]auth.APIKey{
+		{Name: "ops", Hash: auth.HashAPIKey("ops-key"), Role: auth.RoleAdmin, Routes: []string{"GET /api/v1/admin/wallets/{id}"}},
+		{Name: "mount", Hash: auth.HashAPIKey("mount-key"), Role: auth.RoleAdmin, Routes: []string{"/api/v1/admin/"}},
+	}, nil)
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	router := NewRouter(svc, config.Config{}, Deps{HTTPStats: httpstats.NewRecorder(), APIKeys: keys})
+	wallet, err := svc.CreateWallet(context.Background(), models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	get := func(key, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("ops-key", "/api/v1/admin/wallets/"+wallet.ID.String()))
+	assert.Equal(t, http.StatusForbidden, get("ops-key", "/api/v1/admin/wallets"))
+	assert.Equal(t, http.StatusForbidden, get("ops-key", "/api/v1/admin/config"))
+	// The prefix the admin routes are mounted at grants none of them.
+	assert.Equal(t, http.StatusForbidden, get("mount-key", "/api/v1/admin/wallets/"+wallet.ID.String()))
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/shard"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ReceiveInbound books funds received from outside, either to their wallet
+// or to suspense.
+func (h *WalletHandler) ReceiveInbound(w http.ResponseWriter, r *http.Request) {
+	var credit models.InboundCredit
+	if err := json.NewDecoder(r.Body).Decode(&credit); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ReceiveInbound(r.Context(), credit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrScreeningBlocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrScreeningUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrOperationRejected):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ListSuspenseCases lists suspense cases, optionally filtered by the status
+// query parameter.
+func (h *WalletHandler) ListSuspenseCases(w http.ResponseWriter, r *http.Request) {
+	status := models.SuspenseCaseStatus(r.URL.Query().Get("status"))
+
+	cases, err := h.service.ListSuspenseCases(r.Context(), status)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, cases)
+}
+
+func (h *WalletHandler) ResolveSuspenseCase(w http.ResponseWriter, r *http.Request) {
+	caseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid case ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ResolveSuspenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.service.ResolveSuspenseCase(r.Context(), caseID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput),
+			errors.Is(err, repository.ErrCurrencyMismatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrSuspenseCaseNotFound),
+			errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrSuspenseCaseResolved),
+			errors.Is(err, repository.ErrWalletFrozen):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, shard.ErrCrossShard):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, c)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"wallet-service/internal/timeline"
+)
+
+// timelineHeartbeat is how often an idle transaction stream sends a comment
+// to keep proxies from closing the connection.
+const timelineHeartbeat = 15 * time.Second
+
+// streamTransactions serves committed operations as server-sent events
+// ("transaction" events with the JSON-encoded timeline.Event) for as long
+// as the client stays connected. The optional "tenant" and "minAmount"
+// query parameters filter the stream. A client that falls behind misses
+// events and is told so with a "dropped" event carrying the total count,
+// or, under the broker's disconnect policy, gets a "disconnected" event
+// once it has read what was buffered for it. A client that doesn't take a
+// write within writeTimeout, if positive, is disconnected as well.
+func streamTransactions(broker *timeline.Broker, writeTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := timeline.Filter{Tenant: q.Get("tenant")}
+		if v := q.Get("minAmount"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid minAmount", http.StatusBadRequest)
+				return
+			}
+			filter.MinAmount = n
+		}
+
+		sub := broker.Subscribe(filter)
+		defer sub.Close()
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		// send writes and flushes an event, reporting whether the client
+		// took it in time.
+		send := func(format string, args ...any) bool {
+			if writeTimeout > 0 {
+				_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			_, err := fmt.Fprintf(w, format, args...)
+			if err == nil {
+				err = rc.Flush()
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				sub.CloseSlow()
+			}
+			return err == nil
+		}
+
+		heartbeat := time.NewTicker(timelineHeartbeat)
+		defer heartbeat.Stop()
+		var dropped uint64
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if !send(": heartbeat\n\n") {
+					return
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					if errors.Is(sub.Err(), timeline.ErrSlowConsumer) {
+						send("event: disconnected\ndata: {\"reason\":\"slow consumer\"}\n\n")
+					}
+					return
+				}
+				data, err := json.Marshal(e)
+				if err != nil {
+					return
+				}
+				if n := sub.Dropped(); n != dropped {
+					dropped = n
+					if !send("event: dropped\ndata: {\"dropped\":%d}\n\n", n) {
+						return
+					}
+				}
+				if !send("event: transaction\ndata: %s\n\n", data) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ListTransactions returns a wallet's balance changes, newest first, one
+// page at a time. The cursor is the version of the last change seen.
+func (h *WalletHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+	req, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	before := 0
+	if req.cursor != "" {
+		if before, err = strconv.Atoi(req.cursor); err != nil || before <= 0 {
+			http.Error(w, errInvalidPage.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	transactions, hasMore, err := h.service.ListTransactions(r.Context(), walletID, before, req.limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, "wallet not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	page := Page[models.Transaction]{Items: transactions, HasMore: hasMore}
+	if hasMore {
+		page.NextCursor = encodeCursor(strconv.Itoa(transactions[len(transactions)-1].Version))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+)
+
+// Transfer moves funds between two wallets atomically and returns the
+// receipt with both resulting balances.
+func (h *WalletHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	var req models.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !h.authorize(w, r, req.FromWalletID) {
+		return
+	}
+
+	transfer, err := h.service.Transfer(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput),
+			errors.Is(err, repository.ErrInsufficientFunds),
+			errors.Is(err, repository.ErrCurrencyMismatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrWalletNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrWalletFrozen),
+			errors.Is(err, repository.ErrWalletClosed),
+			errors.Is(err, service.ErrReactivationRequired):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrScreeningBlocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrScreeningUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrOperationRejected):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, limits.ErrLimitExceeded),
+			errors.Is(err, service.ErrLimitExceeded):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, repository.ErrRetryable):
+			respondWithRetryable(w, err)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, transfer)
+}
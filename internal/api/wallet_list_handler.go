@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ListWallets pages through wallets filtered by the ownerId, status,
+// currency, minBalance, maxBalance, createdAfter and createdBefore query
+// parameters and ordered by ?sort=: id (the default), balance or createdAt,
+// prefixed with "-" for descending order, ties broken by id. Timestamps are
+// RFC3339. Users only see the wallets they own. The total is not reported:
+// counting a filtered wallets table is too expensive to do per page.
+func (h *WalletHandler) ListWallets(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sort, err := parseWalletSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := decodeWalletCursor(req.cursor, sort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseWalletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.service.AuthorizeWalletFilter(r.Context(), &filter); err != nil {
+		respondWithAuthzError(w, err)
+		return
+	}
+
+	wallets, hasMore, err := h.service.ListWalletsSorted(r.Context(), filter, sort, after, req.limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := Page[models.Wallet]{Items: append(make([]models.Wallet, 0, len(wallets)), wallets...), HasMore: hasMore}
+	if hasMore {
+		page.NextCursor = encodeWalletCursor(models.CursorAt(wallets[len(wallets)-1]), sort)
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func parseWalletSort(v string) (models.WalletSort, error) {
+	var sort models.WalletSort
+	field, desc := strings.CutPrefix(v, "-")
+	switch models.WalletSortField(field) {
+	case "":
+		if desc {
+			return sort, errors.New("invalid sort")
+		}
+	case models.WalletSortID, models.WalletSortBalance, models.WalletSortCreatedAt:
+		sort = models.WalletSort{Field: models.WalletSortField(field), Desc: desc}
+	default:
+		return sort, errors.New("invalid sort")
+	}
+	return sort, nil
+}
+
+// encodeWalletCursor writes the position after c in a listing ordered by
+// sort: the bare id when ordering by id, so cursors from before sorting
+// was supported keep working, otherwise the id and the sort key separated
+// by "|".
+func encodeWalletCursor(c models.WalletCursor, sort models.WalletSort) string {
+	switch sort.Field {
+	case models.WalletSortBalance:
+		return encodeCursor(c.ID.String() + "|" + strconv.FormatInt(c.Balance, 10))
+	case models.WalletSortCreatedAt:
+		return encodeCursor(c.ID.String() + "|" + c.CreatedAt.UTC().Format(time.RFC3339Nano))
+	default:
+		return encodeCursor(c.ID.String())
+	}
+}
+
+// decodeWalletCursor reads a cursor written by encodeWalletCursor for the
+// same sort; a cursor from a listing in another order is rejected.
+func decodeWalletCursor(cursor string, sort models.WalletSort) (models.WalletCursor, error) {
+	var c models.WalletCursor
+	if cursor == "" {
+		return c, nil
+	}
+	id, key, keyed := strings.Cut(cursor, "|")
+	hasKey := sort.Field == models.WalletSortBalance || sort.Field == models.WalletSortCreatedAt
+	if keyed != hasKey {
+		return c, errInvalidPage
+	}
+	var err error
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return c, errInvalidPage
+	}
+	switch sort.Field {
+	case models.WalletSortBalance:
+		c.Balance, err = strconv.ParseInt(key, 10, 64)
+	case models.WalletSortCreatedAt:
+		c.CreatedAt, err = time.Parse(time.RFC3339Nano, key)
+	}
+	if err != nil {
+		return c, errInvalidPage
+	}
+	return c, nil
+}
+
+// SearchWallets pages through wallets whose id, owner id, label or tenant
+// starts with ?q=, ignoring case, for support agents holding only part of
+// an id.
+func (h *WalletHandler) SearchWallets(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after := uuid.Nil
+	if req.cursor != "" {
+		if after, err = uuid.Parse(req.cursor); err != nil {
+			http.Error(w, errInvalidPage.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	wallets, hasMore, err := h.service.SearchWallets(r.Context(), r.URL.Query().Get("q"), after, req.limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := Page[models.Wallet]{Items: append(make([]models.Wallet, 0, len(wallets)), wallets...), HasMore: hasMore}
+	if hasMore {
+		page.NextCursor = encodeCursor(wallets[len(wallets)-1].ID.String())
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func parseWalletFilter(r *http.Request) (models.WalletFilter, error) {
+	q := r.URL.Query()
+	filter := models.WalletFilter{
+		Status:   models.WalletStatus(q.Get("status")),
+		Currency: q.Get("currency"),
+	}
+
+	balance := func(name string) (*int64, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid " + name)
+		}
+		return &n, nil
+	}
+	timestamp := func(name string) (*time.Time, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("invalid " + name)
+		}
+		return &t, nil
+	}
+
+	if v := q.Get("ownerId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid ownerId")
+		}
+		filter.OwnerID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	var err error
+	if filter.MinBalance, err = balance("minBalance"); err != nil {
+		return filter, err
+	}
+	if filter.MaxBalance, err = balance("maxBalance"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedAfter, err = timestamp("createdAfter"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = timestamp("createdBefore"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
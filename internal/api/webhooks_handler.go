@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebhookSubscription registers a URL for wallet events. The secret
+// in the body signs the deliveries and is not returned.
+func (h *WalletHandler) CreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.CreateWebhookSubscription(r.Context(), req)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, sub)
+}
+
+func (h *WalletHandler) ListWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondWithPage(w, r, subs)
+}
+
+func (h *WalletHandler) GetWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+// UpdateWebhookSubscription replaces a subscription. An empty secret keeps
+// the current one.
+func (h *WalletHandler) UpdateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.UpdateWebhookSubscription(r.Context(), id, req)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+func (h *WalletHandler) DeleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveryAttempts returns the latest delivery attempts of a
+// subscription, newest first, up to ?limit=, with the response status or
+// error of each.
+func (h *WalletHandler) ListWebhookDeliveryAttempts(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	attempts, err := h.service.ListWebhookDeliveryAttempts(r.Context(), id, limit)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, attempts)
+}
+
+// GetWebhookSubscriptionStats sums up a subscription's retained
+// deliveries by status and its delivery attempts.
+func (h *WalletHandler) GetWebhookSubscriptionStats(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.WebhookSubscriptionStats(r.Context(), id)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// TestWebhookSubscription sends a webhook.test event to a subscription at
+// once and returns how the receiver answered. A receiver that fails the
+// test is still a 200: the test itself went through.
+func (h *WalletHandler) TestWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.TestWebhookSubscription(r.Context(), id)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// PauseWebhookSubscription holds back a subscription's deliveries until
+// it is resumed.
+func (h *WalletHandler) PauseWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	h.pauseWebhookSubscription(w, r, true)
+}
+
+// ResumeWebhookSubscription delivers a paused subscription's events
+// again, starting with those queued while it was paused.
+func (h *WalletHandler) ResumeWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	h.pauseWebhookSubscription(w, r, false)
+}
+
+func (h *WalletHandler) pauseWebhookSubscription(w http.ResponseWriter, r *http.Request, paused bool) {
+	id, ok := webhookSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.PauseWebhookSubscription(r.Context(), id, paused)
+	if err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+func webhookSubscriptionID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeWebhookSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrWebhookSubscriptionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
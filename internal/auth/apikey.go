@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrAPIKeyNotFound is returned by a KeyStore for unknown or revoked keys.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey lets a service call the API without a user JWT. Only the SHA-256
+// hex digest of the key is kept. Routes lists the route patterns, such as
+// "POST /api/v1/jobs/operations", the key may call; all routes that accept
+// API keys if empty. Keys with RoleAdmin may also call the admin routes.
+type APIKey struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"hash"`
+	Routes []string `json:"routes,omitempty"`
+	Role   Role     `json:"role,omitempty"`
+}
+
+// Allows reports whether the key may call route.
+func (k APIKey) Allows(route string) bool {
+	return len(k.Routes) == 0 || slices.Contains(k.Routes, route)
+}
+
+// HashAPIKey returns the digest under which key is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadAPIKeys reads a JSON file of the form {"keys": [...]}. Each entry
+// gives either the hash of the key or, for static keys, the key itself.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []struct {
+			APIKey
+			Key string `json:"key"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid api keys file: %w", err)
+	}
+	keys := make([]APIKey, len(file.Keys))
+	for i, k := range file.Keys {
+		switch {
+		case k.Name == "":
+			return nil, fmt.Errorf("api keys file: key %d has no name", i)
+		case (k.Key == "") == (k.Hash == ""):
+			return nil, fmt.Errorf("api keys file: key %q needs exactly one of key and hash", k.Name)
+		case k.Role != "" && k.Role != RoleUser && k.Role != RoleAdmin:
+			return nil, fmt.Errorf("api keys file: key %q has unknown role %q", k.Name, k.Role)
+		case k.Key != "":
+			k.Hash = HashAPIKey(k.Key)
+		}
+		if k.Role == "" {
+			k.Role = RoleUser
+		}
+		keys[i] = k.APIKey
+	}
+	return keys, nil
+}
+
+// KeyStore looks up API keys kept outside the keys file, normally in the
+// database.
+type KeyStore interface {
+	APIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+}
+
+// apiKeyCacheTTL bounds how long a key found in the KeyStore is reused,
+// and so how long a revoked key keeps working. Unknown keys aren't cached,
+// so that random keys can't grow the cache.
+const apiKeyCacheTTL = time.Minute
+
+type cachedKey struct {
+	key     APIKey
+	expires time.Time
+}
+
+// APIKeys authenticates API keys against a fixed set of keys and, for keys
+// not among them, a KeyStore.
+type APIKeys struct {
+	static map[string]APIKey
+	store  KeyStore
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+// NewAPIKeys accepts keys and the keys in store, which may be nil.
+func NewAPIKeys(keys []APIKey, store KeyStore) *APIKeys {
+	static := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		static[k.Hash] = k
+	}
+	return &APIKeys{static: static, store: store, now: time.Now, cache: make(map[string]cachedKey)}
+}
+
+// Authenticate returns the subject of key if it may call route. Unknown
+// keys fail with ErrUnauthenticated, keys not allowed on route with
+// ErrRouteNotAllowed; errors
+// of the KeyStore are returned as they are.
+func (a *APIKeys) Authenticate(ctx context.Context, key, route string) (Subject, error) {
+	k, err := a.lookup(ctx, HashAPIKey(key))
+	if err != nil {
+		return Subject{}, err
+	}
+	if !k.Allows(route) {
+		return Subject{}, fmt.Errorf("%w: api key %q may not call %s", ErrRouteNotAllowed, k.Name, route)
+	}
+	return Subject{ID: k.Name, APIKey: true, Role: k.Role}, nil
+}
+
+func (a *APIKeys) lookup(ctx context.Context, hash string) (APIKey, error) {
+	if k, ok := a.static[hash]; ok {
+		return k, nil
+	}
+	if a.store == nil {
+		return APIKey{}, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+
+	now := a.now()
+	a.mu.Lock()
+	c, ok := a.cache[hash]
+	a.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.key, nil
+	}
+
+	k, err := a.store.APIKeyByHash(ctx, hash)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		a.mu.Lock()
+		delete(a.cache, hash)
+		a.mu.Unlock()
+		return APIKey{}, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+	a.mu.Lock()
+	a.cache[hash] = cachedKey{key: k, expires: now.Add(apiKeyCacheTTL)}
+	a.mu.Unlock()
+	return k, nil
+}
@@ -0,0 +1,185 @@
+// Package auth authenticates API callers with JWTs, OAuth2 access tokens,
+// API keys or SPIFFE workload identities and carries the authenticated subject through the
+// request context to handlers and the audit log.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrUnauthenticated is returned for missing, malformed, expired or
+// otherwise invalid tokens.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrRouteNotAllowed is returned for valid credentials that may not call
+// the route. It wraps ErrUnauthenticated.
+var ErrRouteNotAllowed = fmt.Errorf("%w: route not allowed", ErrUnauthenticated)
+
+// Role is what a subject may do. Users may only act on their own wallets;
+// admins may act on all wallets and call the admin routes.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Subject is the authenticated caller: the "sub" claim of a JWT, the
+// client of an OAuth2 access token, the name of an API key or the SPIFFE ID
+// of a workload.
+type Subject struct {
+	ID       string
+	APIKey   bool
+	Workload bool
+	Client   bool
+	Role     Role
+}
+
+// IsAdmin reports whether the subject has the admin role.
+func (s Subject) IsAdmin() bool {
+	return s.Role == RoleAdmin
+}
+
+// Restricted reports whether the subject may only act on wallets it owns.
+// That holds for users authenticated with a JWT; API keys, workloads and
+// OAuth2 clients are limited to their routes instead.
+func (s Subject) Restricted() bool {
+	return !s.IsAdmin() && !s.APIKey && !s.Workload && !s.Client
+}
+
+// Owns reports whether owner, a wallet's owner id, is the subject.
+func (s Subject) Owns(owner uuid.NullUUID) bool {
+	id, err := uuid.Parse(s.ID)
+	return err == nil && owner.Valid && owner.UUID == id
+}
+
+type subjectKey struct{}
+
+// WithSubject stores the authenticated subject in ctx.
+func WithSubject(ctx context.Context, sub Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, sub)
+}
+
+// SubjectFrom returns the subject stored by WithSubject, if any.
+func SubjectFrom(ctx context.Context) (Subject, bool) {
+	sub, ok := ctx.Value(subjectKey{}).(Subject)
+	return sub, ok
+}
+
+// claims adds the "roles" claim, a string or a list of strings, to the
+// registered claims.
+type claims struct {
+	jwt.RegisteredClaims
+	Roles roles `json:"roles"`
+}
+
+type roles []string
+
+func (r *roles) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*r = roles{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(r))
+}
+
+// Validator verifies JWTs signed with a single key for one issuer and
+// audience.
+type Validator struct {
+	key    any
+	parser *jwt.Parser
+}
+
+// NewValidator returns a validator for tokens issued by issuer for
+// audience. key is an HMAC secret ([]byte) or an RSA, ECDSA or Ed25519
+// public key; only signing methods matching it are accepted. leeway
+// allows for clock skew when checking exp, nbf and iat.
+func NewValidator(issuer, audience string, key any, leeway time.Duration) (*Validator, error) {
+	var methods []string
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == 0 {
+			return nil, errors.New("empty HMAC secret")
+		}
+		methods = []string{"HS256", "HS384", "HS512"}
+	case *rsa.PublicKey:
+		methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		methods = []string{"ES256", "ES384", "ES512"}
+	case ed25519.PublicKey:
+		methods = []string{"EdDSA"}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return &Validator{
+		key: key,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(methods),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(leeway),
+		),
+	}, nil
+}
+
+// Validate verifies token and returns its subject. Subjects with "admin"
+// among their roles get RoleAdmin, all others RoleUser. Every failure wraps
+// ErrUnauthenticated.
+func (v *Validator) Validate(token string) (Subject, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return v.key, nil
+	})
+	if err != nil {
+		return Subject{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	if strings.TrimSpace(c.Subject) == "" {
+		return Subject{}, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+	role := RoleUser
+	if slices.Contains(c.Roles, string(RoleAdmin)) {
+		role = RoleAdmin
+	}
+	return Subject{ID: c.Subject, Role: role}, nil
+}
+
+// LoadPublicKey reads a PEM-encoded PKIX public key or certificate.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+}
@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("test-secret")
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    "https://issuer.example",
+		Audience:  jwt.ClaimStrings{"wallet-service"},
+		Subject:   "user-42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestValidator_HMAC(t *testing.T) {
+	v, err := NewValidator("https://issuer.example", "wallet-service", secret, 0)
+	require.NoError(t, err)
+
+	sub, err := v.Validate(sign(t, jwt.SigningMethodHS256, secret, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, Subject{ID: "user-42", Role: RoleUser}, sub)
+
+	tests := map[string]func(*jwt.RegisteredClaims){
+		"wrong issuer":   func(c *jwt.RegisteredClaims) { c.Issuer = "https://other.example" },
+		"wrong audience": func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other"} },
+		"expired":        func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) },
+		"no expiry":      func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil },
+		"no subject":     func(c *jwt.RegisteredClaims) { c.Subject = "" },
+	}
+	for name, mutate := range tests {
+		claims := validClaims()
+		mutate(&claims)
+		_, err := v.Validate(sign(t, jwt.SigningMethodHS256, secret, claims))
+		assert.ErrorIs(t, err, ErrUnauthenticated, name)
+	}
+
+	_, err = v.Validate(sign(t, jwt.SigningMethodHS256, []byte("other-secret"), validClaims()))
+	assert.ErrorIs(t, err, ErrUnauthenticated, "wrong key")
+	_, err = v.Validate(sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims()))
+	assert.ErrorIs(t, err, ErrUnauthenticated, "unsigned")
+	_, err = v.Validate("not-a-token")
+	assert.ErrorIs(t, err, ErrUnauthenticated, "malformed")
+}
+
+func TestValidator_Roles(t *testing.T) {
+	v, err := NewValidator("https://issuer.example", "wallet-service", secret, 0)
+	require.NoError(t, err)
+
+	for roles, want := range map[any]Role{"admin": RoleAdmin, "support": RoleUser} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss": "https://issuer.example", "aud": "wallet-service", "sub": "user-42",
+			"exp": time.Now().Add(time.Hour).Unix(), "roles": roles,
+		}).SignedString(secret)
+		require.NoError(t, err)
+		sub, err := v.Validate(token)
+		require.NoError(t, err)
+		assert.Equal(t, want, sub.Role, roles)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": "https://issuer.example", "aud": "wallet-service", "sub": "user-42",
+		"exp": time.Now().Add(time.Hour).Unix(), "roles": []string{"support", "admin"},
+	}).SignedString(secret)
+	require.NoError(t, err)
+	sub, err := v.Validate(token)
+	require.NoError(t, err)
+	assert.True(t, sub.IsAdmin())
+	assert.False(t, sub.Restricted())
+}
+
+func TestSubject_Owns(t *testing.T) {
+	owner := uuid.New()
+	assert.True(t, Subject{ID: owner.String()}.Owns(uuid.NullUUID{UUID: owner, Valid: true}))
+	assert.False(t, Subject{ID: owner.String()}.Owns(uuid.NullUUID{}))
+	assert.False(t, Subject{ID: "user-42"}.Owns(uuid.NullUUID{UUID: owner, Valid: true}))
+	assert.True(t, Subject{ID: "user-42", Role: RoleUser}.Restricted())
+	assert.False(t, Subject{ID: "batch", APIKey: true, Role: RoleUser}.Restricted())
+}
+
+func TestValidator_PublicKey(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	key, err := LoadPublicKey(path)
+	require.NoError(t, err)
+	v, err := NewValidator("https://issuer.example", "wallet-service", key, 0)
+	require.NoError(t, err)
+
+	sub, err := v.Validate(sign(t, jwt.SigningMethodEdDSA, priv, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", sub.ID)
+
+	// An HMAC token must not be accepted with the public key as secret.
+	_, err = v.Validate(sign(t, jwt.SigningMethodHS256, []byte(pub), validClaims()))
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestSubjectContext(t *testing.T) {
+	_, ok := SubjectFrom(context.Background())
+	assert.False(t, ok)
+
+	sub, ok := SubjectFrom(WithSubject(context.Background(), Subject{ID: "user-42"}))
+	assert.True(t, ok)
+	assert.Equal(t, "user-42", sub.ID)
+}
+
+type fakeStore map[string]APIKey
+
+func (s fakeStore) APIKeyByHash(_ context.Context, hash string) (APIKey, error) {
+	if k, ok := s[hash]; ok {
+		return k, nil
+	}
+	return APIKey{}, ErrAPIKeyNotFound
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [
+		{"name": "batch", "key": "batch-key", "routes": ["POST /api/v1/jobs/operations"]},
+		{"name": "reports", "hash": "`+HashAPIKey("reports-key")+`", "role": "admin"}
+	]}`), 0o600))
+
+	keys, err := LoadAPIKeys(path)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, HashAPIKey("batch-key"), keys[0].Hash)
+	assert.True(t, keys[0].Allows("POST /api/v1/jobs/operations"))
+	assert.False(t, keys[0].Allows("POST /api/v1/wallet"))
+	assert.True(t, keys[1].Allows("POST /api/v1/wallet"))
+	assert.Equal(t, RoleUser, keys[0].Role)
+	assert.Equal(t, RoleAdmin, keys[1].Role)
+
+	for name, body := range map[string]string{
+		"no name":      `{"keys": [{"key": "k"}]}`,
+		"no key":       `{"keys": [{"name": "x"}]}`,
+		"key and hash": `{"keys": [{"name": "x", "key": "k", "hash": "h"}]}`,
+		"unknown role": `{"keys": [{"name": "x", "key": "k", "role": "root"}]}`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		_, err := LoadAPIKeys(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	store := fakeStore{HashAPIKey("db-key"): {Name: "db", Hash: HashAPIKey("db-key")}}
+	keys := NewAPIKeys([]APIKey{{Name: "static", Hash: HashAPIKey("static-key"), Routes: []string{"GET /a"}}}, store)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	sub, err := keys.Authenticate(ctx, "static-key", "GET /a")
+	require.NoError(t, err)
+	assert.Equal(t, Subject{ID: "static", APIKey: true}, sub)
+	_, err = keys.Authenticate(ctx, "static-key", "GET /b")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = keys.Authenticate(ctx, "nope", "GET /a")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	sub, err = keys.Authenticate(ctx, "db-key", "GET /b")
+	require.NoError(t, err)
+	assert.Equal(t, "db", sub.ID)
+
+	// Revocation takes effect once the cached lookup expires.
+	delete(store, HashAPIKey("db-key"))
+	_, err = keys.Authenticate(ctx, "db-key", "GET /b")
+	assert.NoError(t, err)
+	now = now.Add(apiKeyCacheTTL + time.Second)
+	_, err = keys.Authenticate(ctx, "db-key", "GET /b")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestLoadWorkloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workloads.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"workloads": [
+		{"id": "spiffe://prod.example/payments", "routes": ["POST /api/v1/wallet/operation"]},
+		{"id": "spiffe://prod.example/reports", "role": "admin"}
+	]}`), 0o600))
+
+	list, err := LoadWorkloads(path)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, RoleUser, list[0].Role)
+	assert.Equal(t, RoleAdmin, list[1].Role)
+
+	for name, body := range map[string]string{
+		"no id":        `{"workloads": [{"role": "user"}]}`,
+		"unknown role": `{"workloads": [{"id": "spiffe://prod.example/x", "role": "root"}]}`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		_, err := LoadWorkloads(path)
+		assert.Error(t, err, name)
+	}
+
+	_, err = NewWorkloads("prod.example", []Workload{{ID: "spiffe://other.example/x"}})
+	assert.Error(t, err)
+	_, err = NewWorkloads("prod.example", []Workload{{ID: "https://prod.example/x"}})
+	assert.Error(t, err)
+}
+
+func TestWorkloads_Authenticate(t *testing.T) {
+	workloads, err := NewWorkloads("prod.example", []Workload{
+		{ID: "spiffe://prod.example/payments", Routes: []string{"GET /a"}, Role: RoleUser},
+	})
+	require.NoError(t, err)
+
+	state := func(uris ...string) *tls.ConnectionState {
+		cert := &x509.Certificate{}
+		for _, uri := range uris {
+			u, err := url.Parse(uri)
+			require.NoError(t, err)
+			cert.URIs = append(cert.URIs, u)
+		}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	sub, err := workloads.Authenticate(state("spiffe://prod.example/payments"), "GET /a")
+	require.NoError(t, err)
+	assert.Equal(t, Subject{ID: "spiffe://prod.example/payments", Workload: true, Role: RoleUser}, sub)
+	assert.False(t, sub.Restricted())
+
+	for name, tc := range map[string]struct {
+		state *tls.ConnectionState
+		route string
+	}{
+		"unverified":         {&tls.ConnectionState{}, "GET /a"},
+		"no SPIFFE ID":       {state("https://prod.example/payments"), "GET /a"},
+		"other trust domain": {state("spiffe://other.example/payments"), "GET /a"},
+		"unknown workload":   {state("spiffe://prod.example/reports"), "GET /a"},
+		"route not allowed":  {state("spiffe://prod.example/payments"), "GET /b"},
+	} {
+		_, err := workloads.Authenticate(tc.state, tc.route)
+		assert.ErrorIs(t, err, ErrUnauthenticated, name)
+	}
+}
+
+func TestOAuth_Authenticate(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	fetches := 0
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprintf(w, `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","use":"sig","x":%q},{"kty":"RSA","use":"enc","kid":"e1"}]}`,
+			base64.RawURLEncoding.EncodeToString(pub))
+	}))
+	defer idp.Close()
+
+	oauth := NewOAuth("https://idp.example", "wallet-service", NewJWKS(idp.URL), []OAuthScope{
+		{Name: "wallet.operations", Routes: []string{"POST /api/v1/wallet/operation"}, Role: RoleUser},
+		{Name: "wallet.admin", Role: RoleAdmin},
+	}, 0)
+	issue := func(kid string, claims map[string]any) string {
+		t.Helper()
+		claims["iss"], claims["aud"] = "https://idp.example", "wallet-service"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims(claims))
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(priv)
+		require.NoError(t, err)
+		return signed
+	}
+	ctx := context.Background()
+
+	sub, err := oauth.Authenticate(ctx, issue("k1", map[string]any{"client_id": "payouts", "scope": "openid wallet.operations"}),
+		"POST /api/v1/wallet/operation")
+	require.NoError(t, err)
+	assert.Equal(t, Subject{ID: "payouts", Client: true, Role: RoleUser}, sub)
+	assert.False(t, sub.Restricted())
+
+	sub, err = oauth.Authenticate(ctx, issue("k1", map[string]any{"sub": "ops", "scp": []string{"wallet.admin"}}), "GET /admin/x")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, sub.Role)
+
+	_, err = oauth.Authenticate(ctx, issue("k1", map[string]any{"client_id": "payouts", "scope": "wallet.operations"}),
+		"GET /api/v1/wallets")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = oauth.Authenticate(ctx, issue("k1", map[string]any{"scope": "wallet.admin"}), "GET /admin/x")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	// Unknown key ids trigger at most one refetch a minute.
+	_, err = oauth.Authenticate(ctx, issue("k2", map[string]any{"client_id": "payouts", "scope": "wallet.admin"}), "GET /admin/x")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = oauth.Authenticate(ctx, issue("k2", map[string]any{"client_id": "payouts", "scope": "wallet.admin"}), "GET /admin/x")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestLoadOAuthScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scopes.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"scopes": [
+		{"name": "wallet.read", "routes": ["GET /api/v1/wallets/{id}"]},
+		{"name": "wallet.admin", "role": "admin"}
+	]}`), 0o600))
+
+	scopes, err := LoadOAuthScopes(path)
+	require.NoError(t, err)
+	require.Len(t, scopes, 2)
+	assert.Equal(t, RoleUser, scopes[0].Role)
+	assert.True(t, scopes[0].Allows("GET /api/v1/wallets/{id}"))
+	assert.False(t, scopes[0].Allows("DELETE /api/v1/wallets/{id}"))
+	assert.True(t, scopes[1].Allows("DELETE /api/v1/wallets/{id}"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"scopes": [{"name": "x", "role": "root"}]}`), 0o600))
+	_, err = LoadOAuthScopes(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthScope grants OAuth2 access tokens carrying the scope Name the routes
+// in Routes, all routes that accept OAuth2 tokens if empty. Tokens with a
+// scope whose Role is RoleAdmin are admins.
+type OAuthScope struct {
+	Name   string   `json:"name"`
+	Routes []string `json:"routes,omitempty"`
+	Role   Role     `json:"role,omitempty"`
+}
+
+// Allows reports whether the scope grants route.
+func (s OAuthScope) Allows(route string) bool {
+	return len(s.Routes) == 0 || slices.Contains(s.Routes, route)
+}
+
+// LoadOAuthScopes reads a JSON file of the form {"scopes": [...]}.
+func LoadOAuthScopes(path string) ([]OAuthScope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Scopes []OAuthScope `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid oauth scopes file: %w", err)
+	}
+	for i, s := range file.Scopes {
+		switch {
+		case s.Name == "":
+			return nil, fmt.Errorf("oauth scopes file: scope %d has no name", i)
+		case s.Role != "" && s.Role != RoleUser && s.Role != RoleAdmin:
+			return nil, fmt.Errorf("oauth scopes file: scope %q has unknown role %q", s.Name, s.Role)
+		case s.Role == "":
+			file.Scopes[i].Role = RoleUser
+		}
+	}
+	return file.Scopes, nil
+}
+
+// oauthClaims are the claims of an RFC 9068 access token. Some providers
+// put the scopes in a "scp" list instead of the space-separated "scope".
+type oauthClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id"`
+	AZP      string `json:"azp"`
+	Scope    string `json:"scope"`
+	Scp      roles  `json:"scp"`
+}
+
+func (c oauthClaims) client() string {
+	for _, id := range []string{c.ClientID, c.AZP, c.Subject} {
+		if strings.TrimSpace(id) != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func (c oauthClaims) scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// OAuth validates access tokens issued by an external OAuth2 authorization
+// server, typically to services with the client-credentials grant. Tokens
+// are verified with the server's published keys and authorized by their
+// scopes instead of the "roles" claim of locally issued JWTs.
+type OAuth struct {
+	keys   *JWKS
+	scopes map[string]OAuthScope
+	parser *jwt.Parser
+}
+
+// NewOAuth returns a validator for tokens issued by issuer for audience and
+// signed with one of keys. Only the given scopes grant access. leeway
+// allows for clock skew when checking exp, nbf and iat.
+func NewOAuth(issuer, audience string, keys *JWKS, scopes []OAuthScope, leeway time.Duration) *OAuth {
+	byName := make(map[string]OAuthScope, len(scopes))
+	for _, s := range scopes {
+		byName[s.Name] = s
+	}
+	return &OAuth{
+		keys:   keys,
+		scopes: byName,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(leeway),
+		),
+	}
+}
+
+// Authenticate verifies token and returns the subject of its client if one
+// of its scopes grants route. The subject is an admin if any granting scope
+// has the admin role. Every failure wraps ErrUnauthenticated, and
+// ErrRouteNotAllowed if no scope grants route.
+func (o *OAuth) Authenticate(ctx context.Context, token, route string) (Subject, error) {
+	var c oauthClaims
+	_, err := o.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return o.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return Subject{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	id := c.client()
+	if id == "" {
+		return Subject{}, fmt.Errorf("%w: token has no client", ErrUnauthenticated)
+	}
+
+	granted, role := false, RoleUser
+	for _, name := range c.scopes() {
+		s, ok := o.scopes[name]
+		if !ok || !s.Allows(route) {
+			continue
+		}
+		granted = true
+		if s.Role == RoleAdmin {
+			role = RoleAdmin
+		}
+	}
+	if !granted {
+		return Subject{}, fmt.Errorf("%w: no scope of client %q grants %s", ErrRouteNotAllowed, id, route)
+	}
+	return Subject{ID: id, Client: true, Role: role}, nil
+}
+
+const (
+	// jwksTTL is how long fetched keys are used before being refetched.
+	jwksTTL = time.Hour
+	// jwksMinRefresh limits refetches for unknown key ids, so tokens with
+	// made-up ids can't make the service hammer the authorization server.
+	jwksMinRefresh = time.Minute
+)
+
+// JWKS fetches and caches the JSON Web Key Set published at a URL, e.g.
+// the jwks_uri of an OAuth2 authorization server. Keys are refetched
+// hourly and when a token names an unknown key, at most once a minute, so
+// rotated keys are picked up without a restart.
+type JWKS struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetched   time.Time
+	attempted time.Time
+}
+
+// NewJWKS returns a key set fetched lazily from url.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{url: url, client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}
+}
+
+// Key returns the public key with id kid. A failed refetch keeps serving
+// the keys fetched before.
+func (j *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	key, ok := j.keys[kid]
+	if (!ok || now.Sub(j.fetched) >= jwksTTL) && now.Sub(j.attempted) >= jwksMinRefresh {
+		j.attempted = now
+		keys, err := j.fetch(ctx)
+		if err != nil && !ok {
+			return nil, err
+		}
+		if err == nil {
+			j.keys, j.fetched = keys, now
+			key, ok = keys[kid]
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks: unexpected status %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// jwk is a public JSON Web Key (RFC 7517) of one of the types tokens may be
+// signed with.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes k, returning nil for key types tokens aren't signed
+// with here.
+func (k jwk) publicKey() (any, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
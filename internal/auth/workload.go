@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+)
+
+// Workload lets an internal service call the API with its SPIFFE identity,
+// the spiffe:// URI SAN of the X.509 SVID it presents over mutual TLS,
+// instead of a shared API key. Routes and Role work as for API keys.
+type Workload struct {
+	ID     string   `json:"id"`
+	Routes []string `json:"routes,omitempty"`
+	Role   Role     `json:"role,omitempty"`
+}
+
+// Allows reports whether the workload may call route.
+func (w Workload) Allows(route string) bool {
+	return len(w.Routes) == 0 || slices.Contains(w.Routes, route)
+}
+
+// LoadWorkloads reads a JSON file of the form {"workloads": [...]}.
+func LoadWorkloads(path string) ([]Workload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Workloads []Workload `json:"workloads"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid workloads file: %w", err)
+	}
+	for i, w := range file.Workloads {
+		switch {
+		case w.ID == "":
+			return nil, fmt.Errorf("workloads file: workload %d has no id", i)
+		case w.Role != "" && w.Role != RoleUser && w.Role != RoleAdmin:
+			return nil, fmt.Errorf("workloads file: workload %q has unknown role %q", w.ID, w.Role)
+		case w.Role == "":
+			file.Workloads[i].Role = RoleUser
+		}
+	}
+	return file.Workloads, nil
+}
+
+// Workloads authenticates the workloads of one SPIFFE trust domain by the
+// client certificates of their TLS connections. Verifying the certificate
+// chain against the trust bundle is left to the TLS server.
+type Workloads struct {
+	trustDomain string
+	byID        map[string]Workload
+}
+
+// NewWorkloads accepts workloads, all of which must belong to trustDomain.
+func NewWorkloads(trustDomain string, workloads []Workload) (*Workloads, error) {
+	byID := make(map[string]Workload, len(workloads))
+	for _, w := range workloads {
+		id, err := parseSPIFFEID(w.ID)
+		if err != nil {
+			return nil, err
+		}
+		if id.Host != trustDomain {
+			return nil, fmt.Errorf("workload %q is not in trust domain %q", w.ID, trustDomain)
+		}
+		byID[id.String()] = w
+	}
+	return &Workloads{trustDomain: trustDomain, byID: byID}, nil
+}
+
+// Authenticate returns the subject of the workload whose verified client
+// certificate state carries, if it may call route. Connections without a
+// verified certificate, certificates without a SPIFFE ID of the trust
+// domain and unknown workloads fail with ErrUnauthenticated, workloads not
+// allowed on route with ErrRouteNotAllowed.
+func (ws *Workloads) Authenticate(state *tls.ConnectionState, route string) (Subject, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Subject{}, fmt.Errorf("%w: no verified client certificate", ErrUnauthenticated)
+	}
+	var id *url.URL
+	for _, uri := range state.VerifiedChains[0][0].URIs {
+		if uri.Scheme == "spiffe" {
+			id = uri
+			break
+		}
+	}
+	switch {
+	case id == nil:
+		return Subject{}, fmt.Errorf("%w: client certificate has no SPIFFE ID", ErrUnauthenticated)
+	case id.Host != ws.trustDomain:
+		return Subject{}, fmt.Errorf("%w: SPIFFE ID %q is not in trust domain %q", ErrUnauthenticated, id, ws.trustDomain)
+	}
+	w, ok := ws.byID[id.String()]
+	if !ok {
+		return Subject{}, fmt.Errorf("%w: unknown workload %q", ErrUnauthenticated, id)
+	}
+	if !w.Allows(route) {
+		return Subject{}, fmt.Errorf("%w: workload %q may not call %s", ErrRouteNotAllowed, w.ID, route)
+	}
+	return Subject{ID: w.ID, Workload: true, Role: w.Role}, nil
+}
+
+// parseSPIFFEID checks that id has the form spiffe://trust-domain/path.
+func parseSPIFFEID(id string) (*url.URL, error) {
+	u, err := url.Parse(id)
+	if err != nil || u.Scheme != "spiffe" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid SPIFFE ID %q", id)
+	}
+	return u, nil
+}
@@ -4,45 +4,776 @@ import (
 	"bufio"
 	"errors"
 	"flag"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+)
 
-	"github.com/ilyakaznacheev/cleanenv"
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
 )
 
 type Config struct {
-	Env        string `env:"ENV" envconfig:"ENV"`
-	ServerPort int    `env:"SERVER_PORT" envconfig:"SERVER_PORT"`
-	DataBase   DatabaseConfig
+	Env        string         `json:"env" env:"ENV" env-default:"local"`
+	ServerPort int            `json:"serverPort" env:"SERVER_PORT" env-default:"8080"`
+	GRPCPort   int            `json:"grpcPort" env:"GRPC_PORT" env-default:"0"` // 0 disables the gRPC API
+	TLS        TLSConfig      `json:"tls"`
+	DataBase   DatabaseConfig `json:"database"`
 
-	ConnectionPool ConnectionPoolConfig
+	ConnectionPool ConnectionPoolConfig `json:"connectionPool"`
+	Storage        StorageConfig        `json:"storage"`
+	SMTP           SMTPConfig           `json:"smtp"`
+	Reports        ReportsConfig        `json:"reports"`
+	Statements     StatementsConfig     `json:"statements"`
+	Admin          AdminConfig          `json:"admin"`
+	Auth           AuthConfig           `json:"auth"`
+	Diagnostics    DiagnosticsConfig    `json:"diagnostics"`
+	Balances       BalancesConfig       `json:"balances"`
+	Screening      ScreeningConfig      `json:"screening"`
+	Limits         LimitsConfig         `json:"limits"`
+	Maintenance    MaintenanceConfig    `json:"maintenance"`
+	Promo          PromoConfig          `json:"promo"`
+	Rewards        RewardsConfig        `json:"rewards"`
+	Disputes       DisputesConfig       `json:"disputes"`
+	Dormancy       DormancyConfig       `json:"dormancy"`
+	Purge          PurgeConfig          `json:"purge"`
+	Totals         TotalsConfig         `json:"totals"`
+	Audit          AuditConfig          `json:"audit"`
+	Attempts       AttemptsConfig       `json:"attempts"`
+	Alerts         AlertsConfig         `json:"alerts"`
+	Sandbox        SandboxConfig        `json:"sandbox"`
+	Jobs           JobsConfig           `json:"jobs"`
+	Shutdown       ShutdownConfig       `json:"shutdown"`
+	SLO            SLOConfig            `json:"slo"`
+	Idempotency    IdempotencyConfig    `json:"idempotency"`
+	Retries        RetriesConfig        `json:"retries"`
+	LongPoll       LongPollConfig       `json:"longPoll"`
+	Streams        StreamsConfig        `json:"streams"`
+	Webhooks       WebhooksConfig       `json:"webhooks"`
+	Outbox         OutboxConfig         `json:"outbox"`
+	EventSourcing  EventSourcingConfig  `json:"eventSourcing"`
+	Withdrawals    WithdrawalsConfig    `json:"withdrawals"`
 }
 
+// DatabaseConfig accepts either a full DATABASE_URL or discrete settings.
+// Whichever form is given, Load fills in the other one.
 type DatabaseConfig struct {
-	URL string `env:"DATABASE_URL" env-required:"true"`
+	URL          string `json:"url" env:"DATABASE_URL"`
+	Host         string `json:"host" env:"DB_HOST"`
+	Port         int    `json:"port" env:"DB_PORT" env-default:"5432"`
+	User         string `json:"user" env:"DB_USER"`
+	Password     string `json:"password" env:"DB_PASSWORD"`
+	PasswordFile string `json:"passwordFile" env:"DB_PASSWORD_FILE"`
+	Name         string `json:"name" env:"DB_NAME"`
+	SSLMode      string `json:"sslMode" env:"DB_SSLMODE" env-default:"disable"`
+
+	// ReplicaURLs are read replicas used for reads while their replication
+	// lag stays within ReplicaMaxLag.
+	ReplicaURLs          []string      `json:"replicaUrls" env:"DATABASE_REPLICA_URLS"`
+	ReplicaMaxLag        time.Duration `json:"replicaMaxLag" env:"REPLICA_MAX_LAG" env-default:"5s"`
+	ReplicaProbeInterval time.Duration `json:"replicaProbeInterval" env:"REPLICA_PROBE_INTERVAL" env-default:"1s"`
+
+	// ShardURLs are further Postgres databases wallets are spread over; the
+	// main database is shard 0 and shard i+1 is ShardURLs[i]. ShardMap
+	// assigns wallet cohorts to shards, e.g. "0-511:0,512-1023:1"; empty
+	// splits them evenly. Both must stay stable once wallets exist.
+	ShardURLs []string `json:"shardUrls" env:"DATABASE_SHARD_URLS"`
+	ShardMap  string   `json:"shardMap" env:"DATABASE_SHARD_MAP"`
+	// ShardTransferRecoveryInterval is how often cross-shard transfers
+	// left pending, such as by a crash, are finished.
+	ShardTransferRecoveryInterval time.Duration `json:"shardTransferRecoveryInterval" env:"SHARD_TRANSFER_RECOVERY_INTERVAL" env-default:"1m"`
+
+	// PgBouncerMode disables driver-side prepared statements so the service
+	// can run behind a transaction-pooling PgBouncer.
+	PgBouncerMode bool `json:"pgBouncerMode" env:"DB_PGBOUNCER_MODE" env-default:"false"`
+
+	// MigrationLockTimeout bounds how long startup waits for another
+	// replica to finish setting up the schema.
+	MigrationLockTimeout time.Duration `json:"migrationLockTimeout" env:"MIGRATION_LOCK_TIMEOUT" env-default:"5m"`
 }
 
 type ConnectionPoolConfig struct {
-	MaxOpenConns int           `env:"MAX_OPEN_CONNS" env-default:"25"`
-	MaxIdleConns int           `env:"MAX_IDLE_CONNS" env-default:"25"`
-	MaxLifetime  time.Duration `env:"MAX_LIFETIME" env-default:"300s"`
+	MaxOpenConns int           `json:"maxOpenConns" env:"MAX_OPEN_CONNS" env-default:"25"`
+	MaxIdleConns int           `json:"maxIdleConns" env:"MAX_IDLE_CONNS" env-default:"25"`
+	MaxLifetime  time.Duration `json:"maxLifetime" env:"MAX_LIFETIME" env-default:"300s"`
+
+	ReconnectAttempts int           `json:"reconnectAttempts" env:"DB_RECONNECT_ATTEMPTS" env-default:"3"`
+	ReconnectBackoff  time.Duration `json:"reconnectBackoff" env:"DB_RECONNECT_BACKOFF" env-default:"200ms"`
 }
 
-func MustLoad() *Config {
-	configPath := fetchConfigPath()
-	if configPath == "" {
-		panic("config path is empty")
-	}
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		panic("config file does not exist: " + configPath)
+// StorageConfig points at an S3-compatible bucket (S3, or GCS through its
+// interoperability endpoint) used for exports, backups and archives.
+// Storage is disabled when Bucket is empty.
+type StorageConfig struct {
+	Endpoint             string        `json:"endpoint" env:"STORAGE_ENDPOINT"`
+	Region               string        `json:"region" env:"STORAGE_REGION" env-default:"us-east-1"`
+	Bucket               string        `json:"bucket" env:"STORAGE_BUCKET"`
+	Prefix               string        `json:"prefix" env:"STORAGE_PREFIX"`
+	AccessKeyID          string        `json:"accessKeyId" env:"STORAGE_ACCESS_KEY_ID"`
+	SecretAccessKey      string        `json:"secretAccessKey" env:"STORAGE_SECRET_ACCESS_KEY"`
+	PathStyle            bool          `json:"pathStyle" env:"STORAGE_PATH_STYLE" env-default:"false"`
+	ServerSideEncryption string        `json:"serverSideEncryption" env:"STORAGE_SSE"`
+	KMSKeyID             string        `json:"kmsKeyId" env:"STORAGE_SSE_KMS_KEY_ID"`
+	SignedURLTTL         time.Duration `json:"signedUrlTtl" env:"STORAGE_SIGNED_URL_TTL" env-default:"15m"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host" env:"SMTP_HOST"`
+	Port     int    `json:"port" env:"SMTP_PORT" env-default:"587"`
+	Username string `json:"username" env:"SMTP_USERNAME"`
+	Password string `json:"password" env:"SMTP_PASSWORD"`
+	From     string `json:"from" env:"SMTP_FROM" env-default:"wallet-service@localhost"`
+}
+
+// ReportsConfig points at a JSON file with scheduled report definitions.
+type ReportsConfig struct {
+	File string `json:"file" env:"REPORTS_FILE"`
+}
+
+// StatementsConfig controls branding of PDF statements.
+type StatementsConfig struct {
+	Brand        string `json:"brand" env:"STATEMENT_BRAND" env-default:"Wallet Service"`
+	Footer       string `json:"footer" env:"STATEMENT_FOOTER"`
+	TemplateFile string `json:"templateFile" env:"STATEMENT_TEMPLATE_FILE"`
+}
+
+// AdminConfig protects /api/v1/admin routes and the admin UI. Besides
+// Token, admin routes accept JWTs and API keys with the admin role once
+// authentication is configured, and are disabled while neither is.
+//
+// Browsers may call admin routes cross-origin only from CORSOrigins, exact
+// origins such as "https://admin.internal.example"; preflight responses are
+// cached for CORSMaxAge. Cross-origin calls are refused while CORSOrigins
+// is empty.
+type AdminConfig struct {
+	Token       string        `json:"token" env:"ADMIN_TOKEN"`
+	CORSOrigins []string      `json:"corsOrigins" env:"ADMIN_CORS_ORIGINS"`
+	CORSMaxAge  time.Duration `json:"corsMaxAge" env:"ADMIN_CORS_MAX_AGE" env-default:"24h"`
+}
+
+// AuthConfig requires a JWT or an API key on /api/v1 routes. Tokens are
+// verified with the HMAC Secret or the PEM public key in PublicKeyFile, and
+// must be issued by Issuer for Audience; subjects with "admin" in the roles
+// claim are admins, all others users limited to their own wallets. API
+// keys, sent as X-API-Key, come from the JSON APIKeysFile and, with
+// APIKeysDatabase, the api_keys table. Internal services may instead
+// present an X.509 SVID over mutual TLS; the workloads of TrustDomain
+// allowed to call come from the JSON WorkloadsFile. Access tokens of an
+// external OAuth2 server are verified with the keys at OAuthJWKSURL, must
+// be issued by OAuthIssuer for OAuthAudience and are authorized by the
+// scopes in the JSON OAuthScopesFile. The API is open while none of them
+// is set.
+type AuthConfig struct {
+	Issuer          string        `json:"issuer" env:"JWT_ISSUER"`
+	Audience        string        `json:"audience" env:"JWT_AUDIENCE"`
+	Secret          string        `json:"secret" env:"JWT_SECRET"`
+	PublicKeyFile   string        `json:"publicKeyFile" env:"JWT_PUBLIC_KEY_FILE"`
+	Leeway          time.Duration `json:"leeway" env:"JWT_LEEWAY" env-default:"30s"`
+	APIKeysFile     string        `json:"apiKeysFile" env:"API_KEYS_FILE"`
+	APIKeysDatabase bool          `json:"apiKeysDatabase" env:"API_KEYS_DATABASE"`
+	TrustDomain     string        `json:"trustDomain" env:"SPIFFE_TRUST_DOMAIN"`
+	WorkloadsFile   string        `json:"workloadsFile" env:"SPIFFE_WORKLOADS_FILE"`
+	OAuthIssuer     string        `json:"oauthIssuer" env:"OAUTH_ISSUER"`
+	OAuthAudience   string        `json:"oauthAudience" env:"OAUTH_AUDIENCE"`
+	OAuthJWKSURL    string        `json:"oauthJwksUrl" env:"OAUTH_JWKS_URL"`
+	OAuthScopesFile string        `json:"oauthScopesFile" env:"OAUTH_SCOPES_FILE"`
+}
+
+// TLSConfig serves the HTTP and gRPC APIs over TLS with the PEM certificate
+// and key in CertFile and KeyFile. Clients presenting a certificate must
+// chain to the PEM bundle in ClientCAFile, e.g. the SPIFFE trust bundle;
+// clients without one are still accepted and authenticate otherwise.
+type TLSConfig struct {
+	CertFile     string `json:"certFile" env:"TLS_CERT_FILE"`
+	KeyFile      string `json:"keyFile" env:"TLS_KEY_FILE"`
+	ClientCAFile string `json:"clientCAFile" env:"TLS_CLIENT_CA_FILE"`
+}
+
+// DiagnosticsConfig holds thresholds above which self-checks report "warn".
+// The checks also decide readiness, which the gRPC health service
+// refreshes every HealthInterval.
+type DiagnosticsConfig struct {
+	Timeout            time.Duration `json:"timeout" env:"DIAG_TIMEOUT" env-default:"2s"`
+	DBLatencyWarn      time.Duration `json:"dbLatencyWarn" env:"DIAG_DB_LATENCY_WARN" env-default:"100ms"`
+	ReplicationLagWarn time.Duration `json:"replicationLagWarn" env:"DIAG_REPLICATION_LAG_WARN" env-default:"10s"`
+	HealthInterval     time.Duration `json:"healthInterval" env:"DIAG_HEALTH_INTERVAL" env-default:"5s"`
+}
+
+// BalancesConfig tunes balance reads. Wallet ids found missing are answered
+// from memory for MissCacheTTL; zero disables that. Up to HotCacheSize
+// wallet balances are served from memory for at most HotCacheTTL; zero
+// for either disables that cache.
+type BalancesConfig struct {
+	OwnerCacheTTL time.Duration `json:"ownerCacheTtl" env:"OWNER_BALANCE_CACHE_TTL" env-default:"2s"`
+	MissCacheTTL  time.Duration `json:"missCacheTtl" env:"WALLET_MISS_CACHE_TTL" env-default:"1s"`
+	HotCacheSize  int           `json:"hotCacheSize" env:"WALLET_BALANCE_CACHE_SIZE" env-default:"10000"`
+	HotCacheTTL   time.Duration `json:"hotCacheTtl" env:"WALLET_BALANCE_CACHE_TTL" env-default:"250ms"`
+}
+
+// ScreeningConfig enables denylist screening when a file or webhook is set.
+// Operations of at least LargeOperation are screened too.
+type ScreeningConfig struct {
+	DenylistFile   string        `json:"denylistFile" env:"SCREENING_DENYLIST_FILE"`
+	WebhookURL     string        `json:"webhookUrl" env:"SCREENING_WEBHOOK_URL"`
+	WebhookToken   string        `json:"webhookToken" env:"SCREENING_WEBHOOK_TOKEN"`
+	WebhookTimeout time.Duration `json:"webhookTimeout" env:"SCREENING_WEBHOOK_TIMEOUT" env-default:"2s"`
+	LargeOperation int64         `json:"largeOperation" env:"SCREENING_LARGE_OPERATION" env-default:"1000000"`
+}
+
+// LimitsConfig points at the JSON file with per-API-key operation limits.
+type LimitsConfig struct {
+	File string `json:"file" env:"OPERATION_LIMITS_FILE"`
+}
+
+// MaintenanceConfig restricts destructive actions (schema migrations, bulk
+// adjustments, purges) to maintenance windows when Enforce is set. Windows
+// are "<schedule>/<duration>" specs such as "daily 02:00/2h"; more can be
+// declared at runtime through the admin API.
+type MaintenanceConfig struct {
+	Enforce bool     `json:"enforce" env:"MAINTENANCE_ENFORCE" env-default:"false"`
+	Policy  string   `json:"policy" env:"MAINTENANCE_POLICY" env-default:"reject"`
+	Windows []string `json:"windows" env:"MAINTENANCE_WINDOWS"`
+}
+
+// PromoConfig sets how often expired promotional credits are removed.
+type PromoConfig struct {
+	ExpiryInterval time.Duration `json:"expiryInterval" env:"PROMO_EXPIRY_INTERVAL" env-default:"1m"`
+}
+
+// RewardsConfig points at the JSON file with cashback rules; rewards are
+// disabled without one.
+type RewardsConfig struct {
+	File string `json:"file" env:"REWARDS_RULES_FILE"`
+}
+
+// DisputesConfig sets how long disputes stay open and where lifecycle
+// webhooks are sent; webhooks are disabled while WebhookURL is empty.
+type DisputesConfig struct {
+	Window         time.Duration `json:"window" env:"DISPUTE_WINDOW" env-default:"720h"`
+	ExpiryInterval time.Duration `json:"expiryInterval" env:"DISPUTE_EXPIRY_INTERVAL" env-default:"1m"`
+	WebhookURL     string        `json:"webhookUrl" env:"DISPUTE_WEBHOOK_URL"`
+	WebhookSecret  string        `json:"webhookSecret" env:"DISPUTE_WEBHOOK_SECRET"`
+	WebhookTimeout time.Duration `json:"webhookTimeout" env:"DISPUTE_WEBHOOK_TIMEOUT" env-default:"5s"`
+}
+
+// DormancyConfig flags wallets without activity for IdlePeriod as dormant,
+// checking every CheckInterval; zero IdlePeriod disables it. Withdrawals of
+// at least ReactivationThreshold from a dormant wallet require it to be
+// reactivated first. With Notify, owners are told through the dispute
+// webhook when their wallet turns dormant or is reactivated.
+type DormancyConfig struct {
+	IdlePeriod            time.Duration `json:"idlePeriod" env:"DORMANCY_IDLE_PERIOD" env-default:"0"`
+	CheckInterval         time.Duration `json:"checkInterval" env:"DORMANCY_CHECK_INTERVAL" env-default:"1h"`
+	ReactivationThreshold int64         `json:"reactivationThreshold" env:"DORMANCY_REACTIVATION_THRESHOLD" env-default:"100000"`
+	Notify                bool          `json:"notify" env:"DORMANCY_NOTIFY" env-default:"false"`
+}
+
+// PurgeConfig sets how long closed wallets can be restored. Once
+// RecoveryWindow has passed, their transactions are archived to the export
+// store and deleted, checking every Interval; zero RecoveryWindow disables
+// purging.
+type PurgeConfig struct {
+	RecoveryWindow time.Duration `json:"recoveryWindow" env:"PURGE_RECOVERY_WINDOW" env-default:"0"`
+	Interval       time.Duration `json:"interval" env:"PURGE_INTERVAL" env-default:"1h"`
+}
+
+// TotalsConfig checks every CheckInterval that in each currency wallet
+// balances add up to the ledger's credits minus its debits; zero disables
+// the check.
+type TotalsConfig struct {
+	CheckInterval time.Duration `json:"checkInterval" env:"TOTALS_CHECK_INTERVAL" env-default:"1h"`
+}
+
+// AuditConfig sets how long audit events are kept. Every PurgeInterval,
+// events older than Retention are deleted unless their wallet is on legal
+// hold; zero Retention keeps them indefinitely.
+type AuditConfig struct {
+	Retention     time.Duration `json:"retention" env:"AUDIT_RETENTION" env-default:"0"`
+	PurgeInterval time.Duration `json:"purgeInterval" env:"AUDIT_PURGE_INTERVAL" env-default:"24h"`
+}
+
+// AttemptsConfig keeps the attempts of requests that change wallets, retries
+// included, for Retention; zero disables recording them. Expired attempts
+// are purged every PurgeInterval.
+type AttemptsConfig struct {
+	Retention     time.Duration `json:"retention" env:"ATTEMPTS_RETENTION" env-default:"72h"`
+	PurgeInterval time.Duration `json:"purgeInterval" env:"ATTEMPTS_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// AlertsConfig points at the JSON file of alert rules, evaluated every
+// Interval; alerting is disabled without one. Alerts that start or stop
+// firing are logged, exported as metrics and, while WebhookURL is set,
+// posted there.
+type AlertsConfig struct {
+	File           string        `json:"file" env:"ALERT_RULES_FILE"`
+	Interval       time.Duration `json:"interval" env:"ALERT_INTERVAL" env-default:"1m"`
+	WebhookURL     string        `json:"webhookUrl" env:"ALERT_WEBHOOK_URL"`
+	WebhookSecret  string        `json:"webhookSecret" env:"ALERT_WEBHOOK_SECRET"`
+	WebhookTimeout time.Duration `json:"webhookTimeout" env:"ALERT_WEBHOOK_TIMEOUT" env-default:"5s"`
+}
+
+// SandboxConfig lists the sandbox tenants, whose wallets react to magic
+// amounts and are wiped every WipeInterval.
+type SandboxConfig struct {
+	Tenants      []string      `json:"tenants" env:"SANDBOX_TENANTS"`
+	Delay        time.Duration `json:"delay" env:"SANDBOX_DELAY" env-default:"5s"`
+	WipeInterval time.Duration `json:"wipeInterval" env:"SANDBOX_WIPE_INTERVAL" env-default:"24h"`
+}
+
+// JobsConfig sizes background jobs; BulkOperationWorkers operations of a
+// bulk operations job run concurrently. Jobs are persisted and leased to
+// the instance named Owner (hostname and pid when empty); one whose lease
+// isn't renewed for Lease is resumed by the next check, every
+// ResumeInterval, on any instance, up to MaxAttempts runs in total.
+type JobsConfig struct {
+	BulkOperationWorkers int           `json:"bulkOperationWorkers" env:"BULK_OPERATION_WORKERS" env-default:"8"`
+	Owner                string        `json:"owner" env:"JOB_OWNER"`
+	Lease                time.Duration `json:"lease" env:"JOB_LEASE" env-default:"1m"`
+	ResumeInterval       time.Duration `json:"resumeInterval" env:"JOB_RESUME_INTERVAL" env-default:"30s"`
+	MaxAttempts          int           `json:"maxAttempts" env:"JOB_MAX_ATTEMPTS" env-default:"3"`
+}
+
+// ShutdownConfig bounds graceful shutdown: Timeout for the whole sequence,
+// and each phase's components individually: listeners draining requests,
+// workers finishing or releasing jobs, and the database pools closing.
+type ShutdownConfig struct {
+	Timeout         time.Duration `json:"timeout" env:"SHUTDOWN_TIMEOUT" env-default:"30s"`
+	ServerTimeout   time.Duration `json:"serverTimeout" env:"SHUTDOWN_SERVER_TIMEOUT" env-default:"15s"`
+	WorkerTimeout   time.Duration `json:"workerTimeout" env:"SHUTDOWN_WORKER_TIMEOUT" env-default:"10s"`
+	DatabaseTimeout time.Duration `json:"databaseTimeout" env:"SHUTDOWN_DATABASE_TIMEOUT" env-default:"5s"`
+}
+
+// LongPollConfig bounds GET /api/v1/wallets/{id}/changes: a request waits
+// at most Timeout for the wallet to change. Changes committed by this
+// instance end the wait at once; those of other instances are noticed by
+// rereading the wallet every Interval.
+type LongPollConfig struct {
+	Timeout  time.Duration `json:"timeout" env:"LONG_POLL_TIMEOUT" env-default:"30s"`
+	Interval time.Duration `json:"interval" env:"LONG_POLL_INTERVAL" env-default:"5s"`
+}
+
+// StreamsConfig bounds the subscribers of the admin transaction stream:
+// each buffers up to Buffer events, and one that falls that far behind has
+// the newest (drop-newest) or oldest (drop-oldest) event dropped, or is
+// disconnected (disconnect), following Overflow. A client that doesn't
+// take a write within WriteTimeout is disconnected too; zero disables the
+// timeout.
+type StreamsConfig struct {
+	Buffer       int           `json:"buffer" env:"STREAM_BUFFER" env-default:"256"`
+	Overflow     string        `json:"overflow" env:"STREAM_OVERFLOW" env-default:"drop-newest"`
+	WriteTimeout time.Duration `json:"writeTimeout" env:"STREAM_WRITE_TIMEOUT" env-default:"10s"`
+}
+
+// WebhooksConfig delivers the events of webhook subscriptions every
+// DispatchInterval, up to BatchSize per run with Workers requests at a
+// time; zero DispatchInterval disables delivery. Each request times out
+// after Timeout. Failed deliveries are retried after BackoffBase, doubling
+// up to BackoffMax, for MaxAttempts attempts in total. Finished deliveries
+// are kept for Retention, zero meaning forever, and purged every
+// PurgeInterval.
+type WebhooksConfig struct {
+	DispatchInterval time.Duration `json:"dispatchInterval" env:"WEBHOOKS_DISPATCH_INTERVAL" env-default:"5s"`
+	BatchSize        int           `json:"batchSize" env:"WEBHOOKS_BATCH_SIZE" env-default:"100"`
+	Workers          int           `json:"workers" env:"WEBHOOKS_WORKERS" env-default:"8"`
+	Timeout          time.Duration `json:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	MaxAttempts      int           `json:"maxAttempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"10"`
+	BackoffBase      time.Duration `json:"backoffBase" env:"WEBHOOKS_BACKOFF_BASE" env-default:"30s"`
+	BackoffMax       time.Duration `json:"backoffMax" env:"WEBHOOKS_BACKOFF_MAX" env-default:"6h"`
+	Retention        time.Duration `json:"retention" env:"WEBHOOKS_RETENTION" env-default:"168h"`
+	PurgeInterval    time.Duration `json:"purgeInterval" env:"WEBHOOKS_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// OutboxConfig enables the transactional outbox when KafkaBrokers is set:
+// balance changes write their events to the outbox in their own
+// transaction, and every RelayInterval the unpublished events are
+// published to KafkaTopic, BatchSize at a time. Each Kafka request times
+// out after KafkaTimeout. With KafkaTLS the brokers are reached over TLS,
+// verified against the PEM bundle in KafkaCAFile or else the system roots;
+// KafkaSASLMechanism (PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512) authenticates
+// as KafkaSASLUsername. Published events are kept for Retention, zero
+// meaning forever, and purged every PurgeInterval.
+type OutboxConfig struct {
+	KafkaBrokers       []string      `json:"kafkaBrokers" env:"OUTBOX_KAFKA_BROKERS"`
+	KafkaTopic         string        `json:"kafkaTopic" env:"OUTBOX_KAFKA_TOPIC" env-default:"wallet-events"`
+	KafkaClientID      string        `json:"kafkaClientId" env:"OUTBOX_KAFKA_CLIENT_ID" env-default:"wallet-service"`
+	KafkaTimeout       time.Duration `json:"kafkaTimeout" env:"OUTBOX_KAFKA_TIMEOUT" env-default:"10s"`
+	KafkaTLS           bool          `json:"kafkaTls" env:"OUTBOX_KAFKA_TLS"`
+	KafkaCAFile        string        `json:"kafkaCAFile" env:"OUTBOX_KAFKA_CA_FILE"`
+	KafkaSASLMechanism string        `json:"kafkaSaslMechanism" env:"OUTBOX_KAFKA_SASL_MECHANISM"`
+	KafkaSASLUsername  string        `json:"kafkaSaslUsername" env:"OUTBOX_KAFKA_SASL_USERNAME"`
+	KafkaSASLPassword  string        `json:"kafkaSaslPassword" env:"OUTBOX_KAFKA_SASL_PASSWORD"`
+	RelayInterval      time.Duration `json:"relayInterval" env:"OUTBOX_RELAY_INTERVAL" env-default:"1s"`
+	BatchSize          int           `json:"batchSize" env:"OUTBOX_BATCH_SIZE" env-default:"500"`
+	Retention          time.Duration `json:"retention" env:"OUTBOX_RETENTION" env-default:"24h"`
+	PurgeInterval      time.Duration `json:"purgeInterval" env:"OUTBOX_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// EventSourcingConfig makes wallet histories the source of balances when
+// Enabled: operations apply to the balances replayed from a wallet's
+// history, and a snapshot is saved once SnapshotEvery versions have piled
+// up since the last one.
+type EventSourcingConfig struct {
+	Enabled       bool `json:"enabled" env:"EVENT_SOURCING_ENABLED" env-default:"false"`
+	SnapshotEvery int  `json:"snapshotEvery" env:"EVENT_SOURCING_SNAPSHOT_EVERY" env-default:"100"`
+}
+
+// WithdrawalsConfig limits what each wallet may withdraw per UTC day
+// (DailyLimit) and per UTC calendar month (MonthlyLimit), in minor units.
+// Zero means unlimited.
+type WithdrawalsConfig struct {
+	DailyLimit   int64 `json:"dailyLimit" env:"WITHDRAWAL_DAILY_LIMIT" env-default:"0"`
+	MonthlyLimit int64 `json:"monthlyLimit" env:"WITHDRAWAL_MONTHLY_LIMIT" env-default:"0"`
+}
+
+// SLOConfig is the objective for money-moving operations: Target of them
+// must succeed within Latency over a rolling Window. Every CheckInterval
+// an alert is logged while the error budget burns BurnAlert times faster
+// than Window can absorb.
+type SLOConfig struct {
+	Target        float64       `json:"target" env:"SLO_TARGET" env-default:"0.999"`
+	Latency       time.Duration `json:"latency" env:"SLO_LATENCY" env-default:"100ms"`
+	Window        time.Duration `json:"window" env:"SLO_WINDOW" env-default:"24h"`
+	BurnAlert     float64       `json:"burnAlert" env:"SLO_BURN_ALERT" env-default:"14.4"`
+	CheckInterval time.Duration `json:"checkInterval" env:"SLO_CHECK_INTERVAL" env-default:"1m"`
+}
+
+// IdempotencyConfig sets how long responses to requests with an
+// Idempotency-Key are kept for replay; older ones are purged every
+// PurgeInterval.
+type IdempotencyConfig struct {
+	KeyTTL        time.Duration `json:"keyTtl" env:"IDEMPOTENCY_KEY_TTL" env-default:"24h"`
+	PurgeInterval time.Duration `json:"purgeInterval" env:"IDEMPOTENCY_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// RetriesConfig is the retry budget: over BudgetWindow, at most
+// BudgetMinRetries plus BudgetRatio of all attempts may be retries of
+// concurrent modifications; beyond that they fail fast. A ratio of 1 lifts
+// the cap.
+type RetriesConfig struct {
+	BudgetRatio      float64       `json:"budgetRatio" env:"RETRY_BUDGET_RATIO" env-default:"0.2"`
+	BudgetWindow     time.Duration `json:"budgetWindow" env:"RETRY_BUDGET_WINDOW" env-default:"10s"`
+	BudgetMinRetries int           `json:"budgetMinRetries" env:"RETRY_BUDGET_MIN_RETRIES" env-default:"10"`
+}
+
+// Load is the single entry point for configuration. It applies the optional
+// .env file given by --config or CONFIG_PATH, reads the environment into
+// Config honouring env-default/env-required tags and validates the result.
+// All problems are reported together in a *ValidationError.
+func Load() (*Config, error) {
+	if path := fetchConfigPath(); path != "" {
+		if err := loadEnvFile(path); err != nil {
+			return nil, err
+		}
 	}
+
 	var cfg Config
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("cannot read config: " + err.Error())
+	verr := &ValidationError{}
+	readEnv(&cfg, verr)
+	cfg.DataBase.resolve(verr)
+	cfg.validate(verr)
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate(verr *ValidationError) {
+	switch c.Env {
+	case EnvLocal, EnvDev, EnvProd:
+	default:
+		verr.add("ENV", "must be one of local, dev, prod")
+	}
+	if c.ServerPort <= 0 || c.ServerPort > 65535 {
+		verr.add("SERVER_PORT", "must be between 1 and 65535")
+	}
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		verr.add("GRPC_PORT", "must be between 0 and 65535")
+	} else if c.GRPCPort != 0 && c.GRPCPort == c.ServerPort {
+		verr.add("GRPC_PORT", "must differ from SERVER_PORT")
+	}
+	if c.ConnectionPool.MaxOpenConns < 0 {
+		verr.add("MAX_OPEN_CONNS", "must not be negative")
+	}
+	if c.ConnectionPool.MaxIdleConns < 0 {
+		verr.add("MAX_IDLE_CONNS", "must not be negative")
+	}
+	if c.ConnectionPool.MaxLifetime < 0 {
+		verr.add("MAX_LIFETIME", "must not be negative")
+	}
+	switch c.Maintenance.Policy {
+	case "reject", "queue":
+	default:
+		verr.add("MAINTENANCE_POLICY", "must be one of reject, queue")
+	}
+	if c.Storage.Bucket != "" && (c.Storage.AccessKeyID == "" || c.Storage.SecretAccessKey == "") {
+		verr.add("STORAGE_ACCESS_KEY_ID", "credentials are required when STORAGE_BUCKET is set")
+	}
+	if c.ConnectionPool.ReconnectAttempts < 0 {
+		verr.add("DB_RECONNECT_ATTEMPTS", "must not be negative")
+	}
+	if c.Balances.MissCacheTTL < 0 {
+		verr.add("WALLET_MISS_CACHE_TTL", "must not be negative")
+	}
+	if c.Balances.HotCacheSize < 0 {
+		verr.add("WALLET_BALANCE_CACHE_SIZE", "must not be negative")
+	}
+	if c.Balances.HotCacheTTL < 0 {
+		verr.add("WALLET_BALANCE_CACHE_TTL", "must not be negative")
+	}
+	if c.Promo.ExpiryInterval <= 0 {
+		verr.add("PROMO_EXPIRY_INTERVAL", "must be positive")
+	}
+	if c.Disputes.Window <= 0 {
+		verr.add("DISPUTE_WINDOW", "must be positive")
+	}
+	if c.Disputes.ExpiryInterval <= 0 {
+		verr.add("DISPUTE_EXPIRY_INTERVAL", "must be positive")
+	}
+	if c.Dormancy.IdlePeriod < 0 {
+		verr.add("DORMANCY_IDLE_PERIOD", "must not be negative")
+	}
+	if c.Dormancy.CheckInterval <= 0 {
+		verr.add("DORMANCY_CHECK_INTERVAL", "must be positive")
+	}
+	if c.Dormancy.ReactivationThreshold < 0 {
+		verr.add("DORMANCY_REACTIVATION_THRESHOLD", "must not be negative")
+	}
+	for _, origin := range c.Admin.CORSOrigins {
+		if !isOrigin(origin) {
+			verr.add("ADMIN_CORS_ORIGINS", "must list origins like https://admin.example.com, got "+strconv.Quote(origin))
+		}
+	}
+	if c.Admin.CORSMaxAge < 0 {
+		verr.add("ADMIN_CORS_MAX_AGE", "must not be negative")
+	}
+	if c.Purge.RecoveryWindow < 0 {
+		verr.add("PURGE_RECOVERY_WINDOW", "must not be negative")
+	}
+	if c.Purge.Interval <= 0 {
+		verr.add("PURGE_INTERVAL", "must be positive")
+	}
+	if c.Totals.CheckInterval < 0 {
+		verr.add("TOTALS_CHECK_INTERVAL", "must not be negative")
+	}
+	if c.Audit.Retention < 0 {
+		verr.add("AUDIT_RETENTION", "must not be negative")
+	}
+	if c.Audit.PurgeInterval <= 0 {
+		verr.add("AUDIT_PURGE_INTERVAL", "must be positive")
+	}
+	if c.Attempts.Retention < 0 {
+		verr.add("ATTEMPTS_RETENTION", "must not be negative")
+	}
+	if c.Attempts.PurgeInterval <= 0 {
+		verr.add("ATTEMPTS_PURGE_INTERVAL", "must be positive")
+	}
+	if c.Auth.Secret != "" && c.Auth.PublicKeyFile != "" {
+		verr.add("JWT_SECRET", "must not be set together with JWT_PUBLIC_KEY_FILE")
+	}
+	if c.Auth.Secret != "" || c.Auth.PublicKeyFile != "" {
+		if c.Auth.Issuer == "" {
+			verr.add("JWT_ISSUER", "is required when JWT_SECRET or JWT_PUBLIC_KEY_FILE is set")
+		}
+		if c.Auth.Audience == "" {
+			verr.add("JWT_AUDIENCE", "is required when JWT_SECRET or JWT_PUBLIC_KEY_FILE is set")
+		}
+	}
+	if c.Auth.Leeway < 0 {
+		verr.add("JWT_LEEWAY", "must not be negative")
+	}
+	if c.Auth.OAuthJWKSURL != "" {
+		if u, err := url.Parse(c.Auth.OAuthJWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			verr.add("OAUTH_JWKS_URL", "must be an http(s) URL")
+		}
+		if c.Auth.OAuthIssuer == "" {
+			verr.add("OAUTH_ISSUER", "is required when OAUTH_JWKS_URL is set")
+		}
+		if c.Auth.OAuthAudience == "" {
+			verr.add("OAUTH_AUDIENCE", "is required when OAUTH_JWKS_URL is set")
+		}
+		if c.Auth.OAuthScopesFile == "" {
+			verr.add("OAUTH_SCOPES_FILE", "is required when OAUTH_JWKS_URL is set")
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		verr.add("TLS_CERT_FILE", "must be set together with TLS_KEY_FILE")
+	}
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		verr.add("TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE")
+	}
+	if c.Auth.WorkloadsFile != "" {
+		if c.Auth.TrustDomain == "" {
+			verr.add("SPIFFE_TRUST_DOMAIN", "is required when SPIFFE_WORKLOADS_FILE is set")
+		}
+		if c.TLS.ClientCAFile == "" {
+			verr.add("TLS_CLIENT_CA_FILE", "is required when SPIFFE_WORKLOADS_FILE is set")
+		}
+	}
+	if c.Alerts.Interval <= 0 {
+		verr.add("ALERT_INTERVAL", "must be positive")
+	}
+	if slices.Contains(c.Sandbox.Tenants, "") {
+		verr.add("SANDBOX_TENANTS", "must not contain the default (empty) tenant")
+	}
+	if c.Sandbox.Delay < 0 {
+		verr.add("SANDBOX_DELAY", "must not be negative")
+	}
+	if c.Sandbox.WipeInterval <= 0 {
+		verr.add("SANDBOX_WIPE_INTERVAL", "must be positive")
+	}
+	if c.Jobs.BulkOperationWorkers <= 0 {
+		verr.add("BULK_OPERATION_WORKERS", "must be positive")
+	}
+	if c.Jobs.Lease <= 0 {
+		verr.add("JOB_LEASE", "must be positive")
+	}
+	if c.Jobs.ResumeInterval <= 0 {
+		verr.add("JOB_RESUME_INTERVAL", "must be positive")
+	}
+	if c.Jobs.MaxAttempts <= 0 {
+		verr.add("JOB_MAX_ATTEMPTS", "must be positive")
+	}
+	if c.DataBase.MigrationLockTimeout <= 0 {
+		verr.add("MIGRATION_LOCK_TIMEOUT", "must be positive")
+	}
+	if c.Diagnostics.HealthInterval <= 0 {
+		verr.add("DIAG_HEALTH_INTERVAL", "must be positive")
+	}
+	if c.LongPoll.Timeout <= 0 {
+		verr.add("LONG_POLL_TIMEOUT", "must be positive")
+	}
+	if c.LongPoll.Interval <= 0 {
+		verr.add("LONG_POLL_INTERVAL", "must be positive")
+	}
+	if c.Streams.Buffer <= 0 {
+		verr.add("STREAM_BUFFER", "must be positive")
+	}
+	switch c.Streams.Overflow {
+	case "drop-newest", "drop-oldest", "disconnect":
+	default:
+		verr.add("STREAM_OVERFLOW", "must be one of drop-newest, drop-oldest, disconnect")
+	}
+	if c.Streams.WriteTimeout < 0 {
+		verr.add("STREAM_WRITE_TIMEOUT", "must not be negative")
+	}
+	if c.Webhooks.DispatchInterval < 0 {
+		verr.add("WEBHOOKS_DISPATCH_INTERVAL", "must not be negative")
+	}
+	if c.Webhooks.DispatchInterval > 0 {
+		if c.Webhooks.BatchSize <= 0 {
+			verr.add("WEBHOOKS_BATCH_SIZE", "must be positive")
+		}
+		if c.Webhooks.Workers <= 0 {
+			verr.add("WEBHOOKS_WORKERS", "must be positive")
+		}
+		if c.Webhooks.Timeout <= 0 {
+			verr.add("WEBHOOKS_TIMEOUT", "must be positive")
+		}
+		if c.Webhooks.MaxAttempts <= 0 {
+			verr.add("WEBHOOKS_MAX_ATTEMPTS", "must be positive")
+		}
+		if c.Webhooks.BackoffBase <= 0 {
+			verr.add("WEBHOOKS_BACKOFF_BASE", "must be positive")
+		}
+		if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+			verr.add("WEBHOOKS_BACKOFF_MAX", "must not be less than WEBHOOKS_BACKOFF_BASE")
+		}
+		if c.Webhooks.Retention < 0 {
+			verr.add("WEBHOOKS_RETENTION", "must not be negative")
+		}
+		if c.Webhooks.PurgeInterval <= 0 {
+			verr.add("WEBHOOKS_PURGE_INTERVAL", "must be positive")
+		}
+	}
+	if len(c.Outbox.KafkaBrokers) > 0 {
+		if c.Outbox.KafkaTopic == "" {
+			verr.add("OUTBOX_KAFKA_TOPIC", "must not be empty")
+		}
+		if c.Outbox.KafkaTimeout <= 0 {
+			verr.add("OUTBOX_KAFKA_TIMEOUT", "must be positive")
+		}
+		if c.Outbox.KafkaCAFile != "" && !c.Outbox.KafkaTLS {
+			verr.add("OUTBOX_KAFKA_CA_FILE", "requires OUTBOX_KAFKA_TLS")
+		}
+		switch c.Outbox.KafkaSASLMechanism {
+		case "":
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+			if c.Outbox.KafkaSASLUsername == "" {
+				verr.add("OUTBOX_KAFKA_SASL_USERNAME", "must not be empty with OUTBOX_KAFKA_SASL_MECHANISM")
+			}
+		default:
+			verr.add("OUTBOX_KAFKA_SASL_MECHANISM", "must be one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512")
+		}
+		if c.Outbox.RelayInterval <= 0 {
+			verr.add("OUTBOX_RELAY_INTERVAL", "must be positive")
+		}
+		if c.Outbox.BatchSize <= 0 {
+			verr.add("OUTBOX_BATCH_SIZE", "must be positive")
+		}
+		if c.Outbox.Retention < 0 {
+			verr.add("OUTBOX_RETENTION", "must not be negative")
+		}
+		if c.Outbox.PurgeInterval <= 0 {
+			verr.add("OUTBOX_PURGE_INTERVAL", "must be positive")
+		}
+	}
+	if c.EventSourcing.Enabled && c.EventSourcing.SnapshotEvery <= 0 {
+		verr.add("EVENT_SOURCING_SNAPSHOT_EVERY", "must be positive")
+	}
+	if c.Withdrawals.DailyLimit < 0 {
+		verr.add("WITHDRAWAL_DAILY_LIMIT", "must not be negative")
+	}
+	if c.Withdrawals.MonthlyLimit < 0 {
+		verr.add("WITHDRAWAL_MONTHLY_LIMIT", "must not be negative")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		verr.add("SHUTDOWN_TIMEOUT", "must be positive")
+	}
+	if c.Shutdown.ServerTimeout <= 0 {
+		verr.add("SHUTDOWN_SERVER_TIMEOUT", "must be positive")
+	}
+	if c.Shutdown.WorkerTimeout <= 0 {
+		verr.add("SHUTDOWN_WORKER_TIMEOUT", "must be positive")
+	}
+	if c.Shutdown.DatabaseTimeout <= 0 {
+		verr.add("SHUTDOWN_DATABASE_TIMEOUT", "must be positive")
+	}
+	if c.SLO.Target <= 0 || c.SLO.Target >= 1 {
+		verr.add("SLO_TARGET", "must be between 0 and 1 exclusive")
+	}
+	if c.SLO.Latency <= 0 {
+		verr.add("SLO_LATENCY", "must be positive")
+	}
+	if c.SLO.Window <= 0 {
+		verr.add("SLO_WINDOW", "must be positive")
+	}
+	if c.SLO.BurnAlert <= 0 {
+		verr.add("SLO_BURN_ALERT", "must be positive")
+	}
+	if c.SLO.CheckInterval <= 0 {
+		verr.add("SLO_CHECK_INTERVAL", "must be positive")
+	}
+	if c.Idempotency.KeyTTL <= 0 {
+		verr.add("IDEMPOTENCY_KEY_TTL", "must be positive")
+	}
+	if c.Idempotency.PurgeInterval <= 0 {
+		verr.add("IDEMPOTENCY_PURGE_INTERVAL", "must be positive")
+	}
+	if c.Retries.BudgetRatio < 0 {
+		verr.add("RETRY_BUDGET_RATIO", "must not be negative")
+	}
+	if c.Retries.BudgetWindow <= 0 {
+		verr.add("RETRY_BUDGET_WINDOW", "must be positive")
+	}
+	if c.Retries.BudgetMinRetries < 0 {
+		verr.add("RETRY_BUDGET_MIN_RETRIES", "must not be negative")
 	}
-	return &cfg
 }
 
 func fetchConfigPath() string {
@@ -60,8 +791,10 @@ var ErrInvalidString = errors.New("invalid string")
 var ErrFileFormat = errors.New("incorrect file format")
 
 func LoadEnv() error {
-	filePath := fetchConfigPath()
+	return loadEnvFile(fetchConfigPath())
+}
 
+func loadEnvFile(filePath string) error {
 	if filepath.Ext(filePath) != ".env" {
 		return ErrFileFormat
 	}
@@ -96,3 +829,10 @@ func LoadEnv() error {
 
 	return nil
 }
+
+// isOrigin reports whether s is a serialized origin: an http or https
+// scheme and host, with an optional port and nothing else.
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && s == u.Scheme+"://"+u.Host
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resolve makes URL and the discrete fields consistent. A DATABASE_URL is
+// decomposed into host/port/user/name/sslmode; otherwise the URL is built
+// from the discrete fields, reading the password from DB_PASSWORD_FILE when
+// credentials are mounted as a secret file.
+func (d *DatabaseConfig) resolve(verr *ValidationError) {
+	if d.PasswordFile != "" {
+		data, err := os.ReadFile(d.PasswordFile)
+		if err != nil {
+			verr.add("DB_PASSWORD_FILE", err.Error())
+			return
+		}
+		d.Password = strings.TrimSpace(string(data))
+	}
+
+	if d.URL != "" {
+		d.parseURL(verr)
+	} else if d.Host == "" || d.User == "" || d.Name == "" {
+		verr.add("DATABASE_URL", "required variable is not set (or set DB_HOST, DB_USER and DB_NAME)")
+		return
+	} else {
+		d.URL = d.buildURL()
+	}
+
+	if d.PgBouncerMode {
+		d.enablePgBouncerMode()
+	}
+}
+
+// enablePgBouncerMode makes lib/pq send parameters inline with the query
+// instead of preparing a statement first, which breaks under transaction
+// pooling when the prepare and execute land on different server sessions.
+// Shards and replicas sit behind the same pooler, so their DSNs are
+// rewritten too.
+func (d *DatabaseConfig) enablePgBouncerMode() {
+	d.URL = withBinaryParameters(d.URL)
+	for i, dsn := range d.ShardURLs {
+		d.ShardURLs[i] = withBinaryParameters(dsn)
+	}
+	for i, dsn := range d.ReplicaURLs {
+		d.ReplicaURLs[i] = withBinaryParameters(dsn)
+	}
+}
+
+// withBinaryParameters sets binary_parameters=yes on dsn, a postgres:// URL
+// or a key=value connection string.
+func withBinaryParameters(dsn string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		if strings.Contains(dsn, "binary_parameters=") {
+			return dsn
+		}
+		return strings.TrimSpace(dsn + " binary_parameters=yes")
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	q := u.Query()
+	q.Set("binary_parameters", "yes")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (d *DatabaseConfig) parseURL(verr *ValidationError) {
+	u, err := url.Parse(d.URL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		verr.add("DATABASE_URL", "must be a postgres:// URL")
+		return
+	}
+
+	d.Host = u.Hostname()
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			verr.add("DATABASE_URL", "invalid port")
+			return
+		}
+		d.Port = port
+	}
+	if u.User != nil {
+		d.User = u.User.Username()
+		if pass, ok := u.User.Password(); ok {
+			d.Password = pass
+		} else if d.Password != "" {
+			u.User = url.UserPassword(d.User, d.Password)
+			d.URL = u.String()
+		}
+	}
+	d.Name = strings.TrimPrefix(u.Path, "/")
+	if mode := u.Query().Get("sslmode"); mode != "" {
+		d.SSLMode = mode
+	}
+}
+
+func (d *DatabaseConfig) buildURL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:   "/" + d.Name,
+	}
+	if d.Password != "" {
+		u.User = url.UserPassword(d.User, d.Password)
+	} else {
+		u.User = url.User(d.User)
+	}
+	if d.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {d.SSLMode}}.Encode()
+	}
+	return u.String()
+}
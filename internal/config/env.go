@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes a single missing or malformed variable.
+type FieldError struct {
+	Var    string
+	Reason string
+}
+
+// ValidationError lists every configuration problem found during Load.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Var+": "+f.Reason)
+	}
+	return "invalid configuration: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(name, reason string) {
+	for _, f := range e.Fields {
+		if f.Var == name {
+			return
+		}
+	}
+	e.Fields = append(e.Fields, FieldError{Var: name, Reason: reason})
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// readEnv fills the struct pointed to by dst from environment variables named
+// by `env` tags, falling back to `env-default` and recording `env-required`
+// and parse failures in verr. Untagged struct fields are walked recursively.
+func readEnv(dst interface{}, verr *ValidationError) {
+	readStruct(reflect.ValueOf(dst).Elem(), verr)
+}
+
+func readStruct(v reflect.Value, verr *ValidationError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			if value.Kind() == reflect.Struct {
+				readStruct(value, verr)
+			}
+			continue
+		}
+
+		raw, set := os.LookupEnv(name)
+		if !set || raw == "" {
+			if field.Tag.Get("env-required") == "true" {
+				verr.add(name, "required variable is not set")
+				continue
+			}
+			raw, set = field.Tag.Lookup("env-default")
+			if !set {
+				continue
+			}
+		}
+
+		if err := setValue(value, raw); err != nil {
+			verr.add(name, "invalid value "+strconv.Quote(raw)+": "+err.Error())
+		}
+	}
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return strconv.ErrSyntax
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return strconv.ErrSyntax
+	}
+	return nil
+}
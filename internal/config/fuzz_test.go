@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// restoreEnv puts the process environment back the way it was when the
+// function was called, since loadEnvFile sets whatever keys it parses.
+func restoreEnv(t *testing.T) {
+	saved := os.Environ()
+	t.Cleanup(func() {
+		os.Clearenv()
+		for _, kv := range saved {
+			k, v, _ := strings.Cut(kv, "=")
+			os.Setenv(k, v)
+		}
+	})
+}
+
+// FuzzLoadEnvFile feeds arbitrary contents to the .env file parser. It must
+// either accept the file or report ErrInvalidString (or a scanner error for
+// an overlong line), and an accepted KEY=value line must end up in the
+// environment with its surrounding space trimmed.
+func FuzzLoadEnvFile(f *testing.F) {
+	f.Add("# Comment\nKEY=value\nANOTHER=123\n")
+	f.Add("KEY=a=b=c\n")
+	f.Add("  SPACED  =  value  \n")
+	f.Add("NOVALUE\n")
+	f.Add("=value\n")
+	f.Add("KEY=\r\n\r\n")
+	f.Add("KEY=\x00\n")
+	f.Add("\n\n#only comments\n")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		restoreEnv(t)
+		path := filepath.Join(t.TempDir(), "fuzz.env")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		err := loadEnvFile(path)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidString) && !errors.Is(err, bufio.ErrTooLong) {
+				t.Fatalf("unexpected error %v for %q", err, content)
+			}
+			return
+		}
+
+		// The last assignment to a key wins, so only check each key's
+		// final value.
+		want := map[string]string{}
+		scanner := bufio.NewScanner(strings.NewReader(content))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			k, v, _ := strings.Cut(line, "=")
+			want[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		for k, v := range want {
+			if k == "" || strings.ContainsAny(k, "=\x00") || strings.Contains(v, "\x00") {
+				continue // rejected by os.Setenv
+			}
+			if got, ok := os.LookupEnv(k); !ok || got != v {
+				t.Fatalf("%q: %s = %q, want %q", content, k, got, v)
+			}
+		}
+	})
+}
+
+// FuzzSetValue parses arbitrary environment values into every kind of
+// field Config uses. A failure is fine; a panic or a value that doesn't
+// round-trip for the simple kinds is not.
+func FuzzSetValue(f *testing.F) {
+	for _, raw := range []string{"", "0", "-1", "9223372036854775808", "1.5", "NaN", "true", "TRUE", "1h30m", "-5s", "a, b,,c", " , "} {
+		f.Add(raw)
+	}
+
+	var fields struct {
+		S  string
+		I  int
+		I6 int64
+		B  bool
+		F  float64
+		D  time.Duration
+		L  []string
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		v := reflect.ValueOf(&fields).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			_ = setValue(v.Field(i), raw)
+		}
+		if fields.S != raw {
+			t.Fatalf("string field = %q, want %q", fields.S, raw)
+		}
+		for _, item := range fields.L {
+			if item == "" || item != strings.TrimSpace(item) {
+				t.Fatalf("list item %q from %q is empty or untrimmed", item, raw)
+			}
+		}
+	})
+}
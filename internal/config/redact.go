@@ -0,0 +1,63 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+)
+
+const redactedValue = "xxxxx"
+
+var dsnPasswordRe = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// Redacted returns a copy of the config that is safe to log or expose:
+// credentials embedded in connection strings are masked.
+func (c Config) Redacted() Config {
+	c.DataBase.URL = RedactDSN(c.DataBase.URL)
+	replicas := make([]string, len(c.DataBase.ReplicaURLs))
+	for i, u := range c.DataBase.ReplicaURLs {
+		replicas[i] = RedactDSN(u)
+	}
+	c.DataBase.ReplicaURLs = replicas
+	shards := make([]string, len(c.DataBase.ShardURLs))
+	for i, u := range c.DataBase.ShardURLs {
+		shards[i] = RedactDSN(u)
+	}
+	c.DataBase.ShardURLs = shards
+	if c.DataBase.Password != "" {
+		c.DataBase.Password = redactedValue
+	}
+	if c.Admin.Token != "" {
+		c.Admin.Token = redactedValue
+	}
+	if c.Auth.Secret != "" {
+		c.Auth.Secret = redactedValue
+	}
+	if c.SMTP.Password != "" {
+		c.SMTP.Password = redactedValue
+	}
+	if c.Storage.SecretAccessKey != "" {
+		c.Storage.SecretAccessKey = redactedValue
+	}
+	if c.Screening.WebhookToken != "" {
+		c.Screening.WebhookToken = redactedValue
+	}
+	if c.Disputes.WebhookSecret != "" {
+		c.Disputes.WebhookSecret = redactedValue
+	}
+	if c.Alerts.WebhookSecret != "" {
+		c.Alerts.WebhookSecret = redactedValue
+	}
+	return c
+}
+
+// RedactDSN masks the password in both URL-style and key=value style
+// Postgres connection strings.
+func RedactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+		}
+		return u.String()
+	}
+	return dsnPasswordRe.ReplaceAllString(dsn, "${1}"+redactedValue)
+}
@@ -0,0 +1,107 @@
+package diagnostics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/scheduler"
+)
+
+type Database interface {
+	PingLatency(ctx context.Context) (time.Duration, error)
+	PoolStats() sql.DBStats
+}
+
+// DatabaseCheck measures round-trip latency and reports pool usage; latency
+// above warnAfter degrades the check.
+func DatabaseCheck(db Database, warnAfter time.Duration) CheckFunc {
+	return func(ctx context.Context) (Status, map[string]any, error) {
+		latency, err := db.PingLatency(ctx)
+		if err != nil {
+			return StatusFail, nil, err
+		}
+		stats := db.PoolStats()
+		details := map[string]any{
+			"latencyMs":       float64(latency.Microseconds()) / 1000,
+			"openConnections": stats.OpenConnections,
+			"inUse":           stats.InUse,
+			"idle":            stats.Idle,
+			"waitCount":       stats.WaitCount,
+		}
+		if latency > warnAfter {
+			return StatusWarn, details, nil
+		}
+		return StatusOK, details, nil
+	}
+}
+
+type Replication interface {
+	ReplicationStatus(ctx context.Context) (*repository.ReplicationStatus, error)
+}
+
+// ReplicationCheck reports replay lag, degrading when it exceeds warnAfter.
+func ReplicationCheck(db Replication, warnAfter time.Duration) CheckFunc {
+	return func(ctx context.Context) (Status, map[string]any, error) {
+		status, err := db.ReplicationStatus(ctx)
+		if err != nil {
+			return StatusFail, nil, err
+		}
+		details := map[string]any{
+			"inRecovery": status.InRecovery,
+			"lagSeconds": status.Lag.Seconds(),
+		}
+		if status.Lag > warnAfter {
+			return StatusWarn, details, nil
+		}
+		return StatusOK, details, nil
+	}
+}
+
+// FailoverCheck reports failover counters; exhausted reconnects degrade it.
+func FailoverCheck(stats *repository.FailoverStats) CheckFunc {
+	return func(ctx context.Context) (Status, map[string]any, error) {
+		details := map[string]any{
+			"detected":  stats.Detected.Load(),
+			"recovered": stats.Recovered.Load(),
+			"exhausted": stats.Exhausted.Load(),
+		}
+		if stats.Exhausted.Load() > 0 {
+			return StatusWarn, details, nil
+		}
+		return StatusOK, details, nil
+	}
+}
+
+// SchedulerCheck lists background jobs; a job whose last run failed
+// degrades the check.
+func SchedulerCheck(s *scheduler.Scheduler) CheckFunc {
+	return func(ctx context.Context) (Status, map[string]any, error) {
+		jobs := s.Status()
+		status := StatusOK
+		for _, j := range jobs {
+			if j.LastError != "" {
+				status = StatusWarn
+			}
+		}
+		return status, map[string]any{"jobs": jobs}, nil
+	}
+}
+
+type Replicas interface {
+	ReplicaStatuses() []repository.ReplicaStatus
+}
+
+// ReplicasCheck degrades when any configured replica can't serve reads.
+func ReplicasCheck(r Replicas) CheckFunc {
+	return func(ctx context.Context) (Status, map[string]any, error) {
+		replicas := r.ReplicaStatuses()
+		status := StatusOK
+		for _, rep := range replicas {
+			if !rep.AcceptsRead {
+				status = StatusWarn
+			}
+		}
+		return status, map[string]any{"replicas": replicas}, nil
+	}
+}
@@ -0,0 +1,102 @@
+package diagnostics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a single live check.
+type Result struct {
+	Name       string         `json:"name"`
+	Status     Status         `json:"status"`
+	DurationMs int64          `json:"durationMs"`
+	Details    map[string]any `json:"details,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Report is the aggregated self-diagnostics; Status is the worst of the
+// individual check statuses.
+type Report struct {
+	Status      Status    `json:"status"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Checks      []Result  `json:"checks"`
+}
+
+// CheckFunc runs one check. A returned error marks the check failed.
+type CheckFunc func(ctx context.Context) (Status, map[string]any, error)
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Runner executes registered checks concurrently, each bounded by timeout.
+type Runner struct {
+	timeout time.Duration
+	checks  []check
+}
+
+func NewRunner(timeout time.Duration) *Runner {
+	return &Runner{timeout: timeout}
+}
+
+// Register adds a check. It must be called before the runner is used.
+func (r *Runner) Register(name string, fn CheckFunc) {
+	r.checks = append(r.checks, check{name: name, fn: fn})
+}
+
+func (r *Runner) Run(ctx context.Context) Report {
+	results := make([]Result, len(r.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = r.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, GeneratedAt: time.Now().UTC(), Checks: results}
+	for _, res := range results {
+		report.Status = worst(report.Status, res.Status)
+	}
+	return report
+}
+
+func (r *Runner) runCheck(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	status, details, err := c.fn(ctx)
+	res := Result{
+		Name:       c.name,
+		Status:     status,
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    details,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func worst(a, b Status) Status {
+	rank := map[Status]int{StatusOK: 0, StatusWarn: 1, StatusFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,63 @@
+package diagnostics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDB struct {
+	latency time.Duration
+	lag     time.Duration
+	err     error
+}
+
+func (f fakeDB) PingLatency(context.Context) (time.Duration, error) { return f.latency, f.err }
+func (f fakeDB) PoolStats() sql.DBStats                             { return sql.DBStats{OpenConnections: 3} }
+func (f fakeDB) ReplicationStatus(context.Context) (*repository.ReplicationStatus, error) {
+	return &repository.ReplicationStatus{InRecovery: true, Lag: f.lag}, f.err
+}
+
+func TestRunner_AggregatesWorstStatus(t *testing.T) {
+	r := NewRunner(time.Second)
+	r.Register("database", DatabaseCheck(fakeDB{latency: time.Millisecond}, 100*time.Millisecond))
+	r.Register("replication", ReplicationCheck(fakeDB{lag: time.Minute}, 10*time.Second))
+
+	report := r.Run(context.Background())
+
+	assert.Equal(t, StatusWarn, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.Equal(t, 3, report.Checks[0].Details["openConnections"])
+	assert.Equal(t, StatusWarn, report.Checks[1].Status)
+	assert.Equal(t, 60.0, report.Checks[1].Details["lagSeconds"])
+}
+
+func TestRunner_ErrorFailsCheck(t *testing.T) {
+	r := NewRunner(time.Second)
+	r.Register("database", DatabaseCheck(fakeDB{err: errors.New("connection refused")}, time.Second))
+
+	report := r.Run(context.Background())
+
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+}
+
+func TestRunner_TimeoutBoundsCheck(t *testing.T) {
+	r := NewRunner(10 * time.Millisecond)
+	r.Register("slow", func(ctx context.Context) (Status, map[string]any, error) {
+		<-ctx.Done()
+		return StatusFail, nil, ctx.Err()
+	})
+
+	report := r.Run(context.Background())
+
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Contains(t, report.Checks[0].Error, "deadline exceeded")
+}
@@ -0,0 +1,89 @@
+package eventlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"wallet-service/internal/models"
+)
+
+// Schema is the Avro schema of exported events.
+const Schema = `{"type":"record","name":"LedgerEvent","namespace":"wallet_service","fields":[` +
+	`{"name":"offset","type":"long"},` +
+	`{"name":"walletId","type":{"type":"string","logicalType":"uuid"}},` +
+	`{"name":"version","type":"int"},` +
+	`{"name":"operationType","type":"string"},` +
+	`{"name":"amount","type":"long"},` +
+	`{"name":"balance","type":"long"},` +
+	`{"name":"createdAt","type":{"type":"long","logicalType":"timestamp-micros"}}]}`
+
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// avroWriter writes an uncompressed Avro object container file with one
+// data block per Write.
+type avroWriter struct {
+	w    io.Writer
+	sync [16]byte
+	buf  bytes.Buffer
+}
+
+func newAvroWriter(w io.Writer) (*avroWriter, error) {
+	a := &avroWriter{w: w}
+	if _, err := rand.Read(a.sync[:]); err != nil {
+		return nil, err
+	}
+
+	a.buf.Write(avroMagic)
+	putLong(&a.buf, 2)
+	putString(&a.buf, "avro.schema")
+	putString(&a.buf, Schema)
+	putString(&a.buf, "avro.codec")
+	putString(&a.buf, "null")
+	putLong(&a.buf, 0)
+	a.buf.Write(a.sync[:])
+	if _, err := w.Write(a.buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *avroWriter) Write(events []models.LedgerEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var data bytes.Buffer
+	for _, e := range events {
+		putLong(&data, e.Offset)
+		putString(&data, e.WalletID.String())
+		putLong(&data, int64(e.Version))
+		putString(&data, string(e.OperationType))
+		putLong(&data, e.Amount)
+		putLong(&data, e.Balance)
+		putLong(&data, e.CreatedAt.UnixMicro())
+	}
+
+	a.buf.Reset()
+	putLong(&a.buf, int64(len(events)))
+	putLong(&a.buf, int64(data.Len()))
+	a.buf.Write(data.Bytes())
+	a.buf.Write(a.sync[:])
+	_, err := a.w.Write(a.buf.Bytes())
+	return err
+}
+
+func (a *avroWriter) Close() error {
+	return nil
+}
+
+// putLong writes n as an Avro long: zig-zag encoded variable-length.
+func putLong(buf *bytes.Buffer, n int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func putString(buf *bytes.Buffer, s string) {
+	putLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
@@ -0,0 +1,60 @@
+// Package eventlog encodes the ledger event stream for export to the data
+// warehouse, as newline-delimited JSON or as an Avro object container file.
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"wallet-service/internal/models"
+)
+
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatAvro   Format = "avro"
+)
+
+// ContentType returns the media type of f.
+func (f Format) ContentType() string {
+	if f == FormatAvro {
+		return "avro/binary"
+	}
+	return "application/x-ndjson"
+}
+
+// Writer encodes batches of events. Close completes the output but doesn't
+// close the underlying writer.
+type Writer interface {
+	Write(events []models.LedgerEvent) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON, "":
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatAvro:
+		return newAvroWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown event log format %q", format)
+	}
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(events []models.LedgerEvent) error {
+	for i := range events {
+		if err := w.enc.Encode(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
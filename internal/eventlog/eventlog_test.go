@@ -0,0 +1,89 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvents = []models.LedgerEvent{
+	{Offset: 7, WalletID: uuid.New(), Version: 2, OperationType: models.OperationTypeDeposit, Amount: 100, Balance: 100,
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	{Offset: 9, WalletID: uuid.New(), Version: 3, OperationType: models.OperationTypeWithdraw, Amount: 30, Balance: 70,
+		CreatedAt: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)},
+}
+
+func TestNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatNDJSON, &buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(testEvents))
+	require.NoError(t, w.Close())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var e models.LedgerEvent
+	require.NoError(t, json.Unmarshal(lines[1], &e))
+	assert.Equal(t, testEvents[1], e)
+}
+
+func TestAvro(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatAvro, &buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(testEvents))
+	require.NoError(t, w.Close())
+
+	r := bufio.NewReader(&buf)
+	magic := make([]byte, 4)
+	_, err = r.Read(magic)
+	require.NoError(t, err)
+	assert.Equal(t, avroMagic, magic)
+
+	readLong := func() int64 {
+		n, err := binary.ReadVarint(r)
+		require.NoError(t, err)
+		return n
+	}
+	readString := func() string {
+		b := make([]byte, readLong())
+		_, err := r.Read(b)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	meta := map[string]string{}
+	for n := readLong(); n > 0; n-- {
+		k := readString()
+		meta[k] = readString()
+	}
+	assert.Zero(t, readLong())
+	assert.Equal(t, Schema, meta["avro.schema"])
+	assert.Equal(t, "null", meta["avro.codec"])
+	sync := make([]byte, 16)
+	_, err = r.Read(sync)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), readLong())
+	readLong() // block size
+	assert.Equal(t, int64(7), readLong())
+	assert.Equal(t, testEvents[0].WalletID.String(), readString())
+	assert.Equal(t, int64(2), readLong())
+	assert.Equal(t, "DEPOSIT", readString())
+	assert.Equal(t, int64(100), readLong())
+	assert.Equal(t, int64(100), readLong())
+	assert.Equal(t, testEvents[0].CreatedAt.UnixMicro(), readLong())
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := NewWriter("xml", &bytes.Buffer{})
+	assert.Error(t, err)
+}
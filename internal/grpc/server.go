@@ -0,0 +1,299 @@
+// Package grpc serves the wallet service over gRPC for internal callers
+// that want typed, low-latency access. It exposes a subset of the HTTP
+// API, backed by the same service.WalletService, so both behave alike.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"strings"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/pbconv"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/sandbox"
+	"wallet-service/internal/service"
+	"wallet-service/internal/timeline"
+	walletv1 "wallet-service/proto/wallet/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadata carries the caller's API key, which selects its limit
+// scope like the X-API-Key header does over HTTP.
+const APIKeyMetadata = "x-api-key"
+
+type server struct {
+	walletv1.UnimplementedWalletServiceServer
+
+	service *service.WalletService
+	broker  *timeline.Broker
+}
+
+// NewServer returns a gRPC server exposing walletService. Requests are
+// given a request id and assigned a limit scope by limiter, which may be
+// nil. WatchWallet streams learn about new versions from broker as soon as
+// they are committed; without one they poll.
+func NewServer(walletService *service.WalletService, limiter *limits.Limiter, broker *timeline.Broker, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(requestID),
+		grpc.ChainStreamInterceptor(streamWithContext(func(ctx context.Context, method string) (context.Context, error) {
+			return withRequestID(ctx, method), nil
+		})),
+	}, opts...)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(limitScope(limiter)),
+		grpc.ChainStreamInterceptor(streamWithContext(func(ctx context.Context, _ string) (context.Context, error) {
+			return withLimitScope(ctx, limiter), nil
+		})))
+	gs := grpc.NewServer(opts...)
+	walletv1.RegisterWalletServiceServer(gs, &server{service: walletService, broker: broker})
+	return gs
+}
+
+// RegisterStandardServices registers the gRPC health checking protocol,
+// answered by hs, and server reflection on gs, so that load balancers,
+// Envoy and grpcurl work without further setup. Neither requires
+// authentication.
+func RegisterStandardServices(gs *grpc.Server, hs healthpb.HealthServer) {
+	healthpb.RegisterHealthServer(gs, hs)
+	reflection.Register(gs)
+}
+
+// public reports whether method belongs to one of the services registered
+// by RegisterStandardServices, which callers use without credentials.
+func public(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") ||
+		strings.HasPrefix(method, "/grpc.reflection.")
+}
+
+func (s *server) CreateWallet(ctx context.Context, req *walletv1.CreateWalletRequest) (*walletv1.CreateWalletResponse, error) {
+	params := models.CreateWalletRequest{
+		Currency: req.GetCurrency(),
+		Label:    req.GetLabel(),
+		Tenant:   req.GetTenant(),
+	}
+	if req.GetOwnerId() != "" {
+		ownerID, err := uuid.Parse(req.GetOwnerId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid owner id")
+		}
+		params.OwnerID = uuid.NullUUID{UUID: ownerID, Valid: true}
+	}
+	if err := s.service.AuthorizeNewWallet(ctx, &params); err != nil {
+		return nil, statusError(err)
+	}
+
+	wallet, err := s.service.CreateWallet(ctx, params)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &walletv1.CreateWalletResponse{Wallet: pbconv.Wallet(wallet)}, nil
+}
+
+func (s *server) GetWallet(ctx context.Context, req *walletv1.GetWalletRequest) (*walletv1.GetWalletResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid wallet id")
+	}
+	if err := s.service.AuthorizeWallet(ctx, id); err != nil {
+		return nil, statusError(err)
+	}
+
+	wallet, err := s.service.GetWallet(ctx, id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &walletv1.GetWalletResponse{Wallet: pbconv.Wallet(wallet)}, nil
+}
+
+func (s *server) ProcessOperation(ctx context.Context, req *walletv1.ProcessOperationRequest) (*walletv1.ProcessOperationResponse, error) {
+	operation, err := pbconv.WalletOperationFromProto(req.GetOperation())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.service.AuthorizeWallet(ctx, operation.WalletID); err != nil {
+		return nil, statusError(err)
+	}
+
+	wallet, err := s.service.ProcessOperation(ctx, operation)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &walletv1.ProcessOperationResponse{Wallet: pbconv.Wallet(wallet)}, nil
+}
+
+// statusError maps service errors to the status codes documented in
+// wallet_service.proto, along the lines of the HTTP handlers.
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, service.ErrInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, repository.ErrWalletNotFound):
+		code = codes.NotFound
+	case errors.Is(err, repository.ErrInsufficientFunds),
+		errors.Is(err, repository.ErrWalletFrozen),
+		errors.Is(err, repository.ErrWalletClosed),
+		errors.Is(err, service.ErrReactivationRequired),
+		errors.Is(err, service.ErrOperationRejected),
+		errors.Is(err, limits.ErrLimitExceeded),
+		errors.Is(err, service.ErrLimitExceeded):
+		code = codes.FailedPrecondition
+	case errors.Is(err, service.ErrScreeningBlocked),
+		errors.Is(err, service.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, service.ErrScreeningUnavailable),
+		errors.Is(err, sandbox.ErrProviderFailure):
+		code = codes.Unavailable
+	case errors.Is(err, repository.ErrRetryable):
+		code = codes.Aborted
+	}
+	return status.Error(code, err.Error())
+}
+
+// Authenticate requires a bearer JWT checked by v or an OAuth2 access token
+// checked by oauth in the "authorization" metadata, an API key checked by
+// keys in APIKeyMetadata or a client certificate checked by workloads, like
+// the HTTP API does once authentication is configured, and puts the
+// caller's subject into the context. OAuth2 tokens, API keys and workloads
+// are matched against the full method name, e.g.
+// "/wallet.v1.WalletService/GetWallet". Any of them may be nil. Pass the
+// options, which cover unary and streaming calls, to NewServer. Health
+// checks and reflection are left open.
+func Authenticate(v *auth.Validator, oauth *auth.OAuth, keys *auth.APIKeys, workloads *auth.Workloads) []grpc.ServerOption {
+	authenticate := func(ctx context.Context, method string) (context.Context, error) {
+		if public(method) {
+			return ctx, nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		var (
+			sub auth.Subject
+			err = auth.ErrUnauthenticated
+		)
+		if values := md.Get("authorization"); len(values) > 0 && (v != nil || oauth != nil) {
+			if token, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+				if v != nil {
+					sub, err = v.Validate(token)
+				}
+				if err != nil && oauth != nil {
+					sub, err = oauth.Authenticate(ctx, token, method)
+				}
+			}
+		} else if values := md.Get(APIKeyMetadata); len(values) > 0 && keys != nil {
+			sub, err = keys.Authenticate(ctx, values[0], method)
+		} else if state := peerTLS(ctx); state != nil && len(state.PeerCertificates) > 0 && workloads != nil {
+			sub, err = workloads.Authenticate(state, method)
+		}
+		switch {
+		case errors.Is(err, auth.ErrUnauthenticated):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return auth.WithSubject(ctx, sub), nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := authenticate(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(streamWithContext(authenticate)),
+	}
+}
+
+// peerTLS returns the TLS state of the caller's connection, or nil without
+// TLS.
+func peerTLS(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return &info.State
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamWithContext turns fn, which derives a call's context from the
+// incoming one or rejects the call, into a stream interceptor.
+func streamWithContext(fn func(ctx context.Context, method string) (context.Context, error)) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := fn(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// requestID gives every request the id in the "x-request-id" metadata if
+// it is usable, or a new one, returns it as response header and puts it
+// into the context like the HTTP API does, along with the method called as
+// the request's operation.
+func requestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx, info.FullMethod), req)
+}
+
+func withRequestID(ctx context.Context, method string) context.Context {
+	key := strings.ToLower(requestid.Header)
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(key); len(ids) > 0 {
+			id = ids[0]
+		}
+	}
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(key, id))
+	return requestid.WithOperation(requestid.WithID(ctx, id), method)
+}
+
+// limitScope puts every request into the limit scope of its API key.
+func limitScope(limiter *limits.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withLimitScope(ctx, limiter), req)
+	}
+}
+
+func withLimitScope(ctx context.Context, limiter *limits.Limiter) context.Context {
+	scope := limits.DefaultScope
+	if limiter != nil {
+		var key string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if keys := md.Get(APIKeyMetadata); len(keys) > 0 {
+				key = keys[0]
+			}
+		}
+		scope = limiter.ScopeForKey(key)
+	}
+	return limits.WithScope(ctx, scope)
+}
@@ -0,0 +1,232 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/service"
+	"wallet-service/internal/timeline"
+	walletv1 "wallet-service/proto/wallet/v1"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) walletv1.WalletServiceClient {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return serve(t, service.NewWalletService(memory.New(), log), nil)
+}
+
+func serve(t *testing.T, svc *service.WalletService, broker *timeline.Broker) walletv1.WalletServiceClient {
+	t.Helper()
+	gs := NewServer(svc, nil, broker)
+
+	ln := bufconn.Listen(1 << 20)
+	go gs.Serve(ln)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return walletv1.NewWalletServiceClient(conn)
+}
+
+func TestServer_WalletLifecycle(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{Currency: "EUR", Label: "savings"})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", created.GetWallet().GetCurrency())
+	assert.Equal(t, walletv1.WalletStatus_WALLET_STATUS_ACTIVE, created.GetWallet().GetStatus())
+
+	deposited, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{Operation: &walletv1.WalletOperation{
+		WalletId:      created.GetWallet().GetId(),
+		OperationType: walletv1.OperationType_OPERATION_TYPE_DEPOSIT,
+		Amount:        250,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), deposited.GetWallet().GetBalance())
+
+	got, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{Id: created.GetWallet().GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), got.GetWallet().GetBalance())
+	assert.Equal(t, deposited.GetWallet().GetVersion(), got.GetWallet().GetVersion())
+}
+
+func TestServer_RequestID(t *testing.T) {
+	client := newClient(t)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "trace-1")
+	_, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{Currency: "EUR"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trace-1"}, header.Get("x-request-id"))
+
+	_, err = client.CreateWallet(context.Background(), &walletv1.CreateWalletRequest{Currency: "EUR"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get("x-request-id"), 1)
+	assert.NotEqual(t, "trace-1", header.Get("x-request-id")[0])
+}
+
+func TestServer_WatchWallet(t *testing.T) {
+	broker := timeline.NewBroker()
+	svc := service.NewWalletService(memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)),
+		service.WithAfterOperation(broker))
+	client := serve(t, svc, broker)
+	ctx := context.Background()
+	watching, cancel := context.WithCancel(ctx)
+
+	wallet, err := svc.CreateWallet(ctx, models.CreateWalletRequest{Currency: "EUR"})
+	require.NoError(t, err)
+	deposit := func(amount int64) {
+		_, err := svc.ProcessOperation(ctx, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: amount})
+		require.NoError(t, err)
+	}
+	deposit(100)
+
+	// The history after from_version is replayed, then new versions follow.
+	stream, err := client.WatchWallet(watching, &walletv1.WatchWalletRequest{Id: wallet.ID.String()})
+	require.NoError(t, err)
+	for _, want := range []int32{1, 2} {
+		got, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, want, got.GetTransaction().GetVersion())
+	}
+	deposit(50)
+	got, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), got.GetTransaction().GetVersion())
+	assert.Equal(t, int64(150), got.GetTransaction().GetBalance())
+	assert.Equal(t, walletv1.OperationType_OPERATION_TYPE_DEPOSIT, got.GetTransaction().GetOperationType())
+	cancel()
+
+	// A client resuming from the last version it saw gets only newer ones.
+	deposit(25)
+	resumed, cancelResumed := context.WithCancel(context.Background())
+	stream, err = client.WatchWallet(resumed, &walletv1.WatchWalletRequest{Id: wallet.ID.String(), FromVersion: 3})
+	require.NoError(t, err)
+	got, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), got.GetTransaction().GetVersion())
+	cancelResumed()
+	require.Eventually(t, func() bool { return broker.Subscribers() == 0 }, time.Second, time.Millisecond)
+
+	stream, err = client.WatchWallet(context.Background(), &walletv1.WatchWalletRequest{Id: uuid.NewString()})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	stream, err = client.WatchWallet(context.Background(), &walletv1.WatchWalletRequest{Id: wallet.ID.String(), FromVersion: -1})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Shutting the broker down ends open streams.
+	stream, err = client.WatchWallet(context.Background(), &walletv1.WatchWalletRequest{Id: wallet.ID.String(), FromVersion: 4})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, time.Millisecond)
+	broker.Close()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServer_StatusCodes(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"malformed id", func() error {
+			_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{Id: "nope"})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown wallet", func() error {
+			_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{Id: uuid.NewString()})
+			return err
+		}, codes.InvalidArgument},
+		{"unspecified operation", func() error {
+			_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{Operation: &walletv1.WalletOperation{
+				WalletId: created.GetWallet().GetId(), Amount: 1,
+			}})
+			return err
+		}, codes.InvalidArgument},
+		{"insufficient funds", func() error {
+			_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{Operation: &walletv1.WalletOperation{
+				WalletId: created.GetWallet().GetId(), OperationType: walletv1.OperationType_OPERATION_TYPE_WITHDRAW, Amount: 1,
+			}})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid currency", func() error {
+			_, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{Currency: "euro"})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(tt.call()))
+		})
+	}
+}
+
+func TestServer_StandardServices(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gs := NewServer(service.NewWalletService(memory.New(), log), nil, nil, Authenticate(nil, nil, nil, nil)...)
+	RegisterStandardServices(gs, health.NewServer())
+
+	ln := bufconn.Listen(1 << 20)
+	go gs.Serve(ln)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	ctx := context.Background()
+
+	// The wallet service requires credentials, health checks and
+	// reflection don't.
+	_, err = walletv1.NewWalletServiceClient(conn).GetWallet(ctx, &walletv1.GetWalletRequest{Id: uuid.NewString()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	listed, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, s := range listed.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	assert.Contains(t, services, walletv1.WalletService_ServiceDesc.ServiceName)
+	assert.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+}
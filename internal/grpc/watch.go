@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"time"
+	"wallet-service/internal/pbconv"
+	"wallet-service/internal/timeline"
+	walletv1 "wallet-service/proto/wallet/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// watchBatchSize is the number of versions WatchWallet reads at once
+	// while catching up.
+	watchBatchSize = 100
+	// watchPollInterval is how often WatchWallet rereads the wallet to
+	// notice versions committed by other instances, whose operations
+	// aren't published to this instance's broker.
+	watchPollInterval = 5 * time.Second
+)
+
+func (s *server) WatchWallet(req *walletv1.WatchWalletRequest, stream grpc.ServerStreamingServer[walletv1.WatchWalletResponse]) error {
+	ctx := stream.Context()
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid wallet id")
+	}
+	if req.GetFromVersion() < 0 {
+		return status.Error(codes.InvalidArgument, "invalid from version")
+	}
+	if err := s.service.AuthorizeWallet(ctx, id); err != nil {
+		return statusError(err)
+	}
+
+	// Subscribe before catching up so that no version committed in between
+	// is missed. Versions are always read from the store, in order; events
+	// only say when to read, so buffering the latest one is enough.
+	var changes <-chan timeline.Event
+	if s.broker != nil {
+		sub := s.broker.SubscribeWith(timeline.Filter{WalletID: id}, timeline.Policy{Buffer: 1, Overflow: timeline.DropOldest})
+		defer sub.Close()
+		changes = sub.C
+	}
+	poll := time.NewTicker(watchPollInterval)
+	defer poll.Stop()
+
+	last := int(req.GetFromVersion())
+	for {
+		for {
+			versions, err := s.service.WalletVersionsAfter(ctx, id, last, watchBatchSize)
+			if err != nil {
+				return statusError(err)
+			}
+			for _, v := range versions {
+				if err := stream.Send(&walletv1.WatchWalletResponse{Transaction: pbconv.Transaction(v)}); err != nil {
+					return err
+				}
+				last = v.Version
+			}
+			if len(versions) < watchBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case _, ok := <-changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+		case <-poll.C:
+		}
+	}
+}
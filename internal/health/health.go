@@ -0,0 +1,107 @@
+// Package health tells load balancers and orchestrators whether the
+// service can take traffic. The same answer is served over HTTP and over
+// the standard gRPC health checking protocol, so that Kubernetes probes,
+// Envoy and grpcurl all see the service alike.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wallet-service/internal/diagnostics"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Monitor decides readiness from the diagnostics checks: the service is
+// ready unless a check fails or it is draining for shutdown. A warning,
+// such as a slow database, doesn't take it out of rotation.
+type Monitor struct {
+	diag     *diagnostics.Runner
+	server   *grpchealth.Server
+	services []string
+	draining atomic.Bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor returns a monitor of the checks of diag. The gRPC health
+// service reports the overall status under the empty service name and
+// under each of services. They are not serving until the first check.
+func NewMonitor(diag *diagnostics.Runner, services ...string) *Monitor {
+	m := &Monitor{
+		diag:     diag,
+		server:   grpchealth.NewServer(),
+		services: append([]string{""}, services...),
+	}
+	for _, s := range m.services {
+		m.server.SetServingStatus(s, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return m
+}
+
+// Check runs the diagnostics, updates the status served over gRPC and
+// returns the report and whether the service is ready.
+func (m *Monitor) Check(ctx context.Context) (diagnostics.Report, bool) {
+	report := m.diag.Run(ctx)
+	ready := report.Status != diagnostics.StatusFail && !m.draining.Load()
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	for _, s := range m.services {
+		m.server.SetServingStatus(s, status)
+	}
+	return report, ready
+}
+
+// Draining reports whether Drain has been called.
+func (m *Monitor) Draining() bool {
+	return m.draining.Load()
+}
+
+// Drain takes the service out of rotation for good: it is reported not
+// ready from now on, and gRPC watchers are told at once. It is called when
+// shutdown begins, before the listeners stop.
+func (m *Monitor) Drain() {
+	m.draining.Store(true)
+	m.server.Shutdown()
+}
+
+// GRPC returns the gRPC health service.
+func (m *Monitor) GRPC() healthpb.HealthServer {
+	return m.server
+}
+
+// Start checks readiness right away and then every interval, so that the
+// gRPC status stays current between checks made over HTTP.
+func (m *Monitor) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the checks started by Start and waits for them to return.
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/diagnostics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func servingStatus(t *testing.T, m *Monitor, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := m.GRPC().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestMonitor(t *testing.T) {
+	const service = "wallet.v1.WalletService"
+	var status diagnostics.Status
+	var fail error
+	diag := diagnostics.NewRunner(time.Second)
+	diag.Register("database", func(context.Context) (diagnostics.Status, map[string]any, error) {
+		return status, nil, fail
+	})
+	m := NewMonitor(diag, service)
+
+	// Nothing is served before the first check.
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, ""))
+
+	status = diagnostics.StatusWarn
+	_, ready := m.Check(context.Background())
+	assert.True(t, ready, "a warning keeps the service in rotation")
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, m, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, m, service))
+
+	fail = errors.New("connection refused")
+	report, ready := m.Check(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, diagnostics.StatusFail, report.Status)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, service))
+
+	fail = nil
+	_, ready = m.Check(context.Background())
+	require.True(t, ready)
+
+	m.Drain()
+	assert.True(t, m.Draining())
+	_, ready = m.Check(context.Background())
+	assert.False(t, ready, "a draining service stays out of rotation")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, service))
+}
+
+func TestMonitor_Start(t *testing.T) {
+	m := NewMonitor(diagnostics.NewRunner(time.Second))
+	m.Start(time.Hour)
+	defer m.Stop()
+
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, m, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
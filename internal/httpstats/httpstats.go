@@ -0,0 +1,200 @@
+// Package httpstats records request latency and errors per route template
+// and tenant, both as Prometheus metrics and as in-memory histograms of the
+// last hour that the admin console turns into percentiles.
+package httpstats
+
+import (
+	"cmp"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Window is how far back Summary looks.
+const Window = time.Hour
+
+const slotWidth = time.Minute
+
+// bounds are the upper bounds of the latency buckets, 0.5ms doubling up to
+// about 33s; slower requests land in an overflow bucket.
+var bounds = func() []float64 {
+	b := make([]float64, 17)
+	for i := range b {
+		b[i] = 0.0005 * math.Pow(2, float64(i))
+	}
+	return b
+}()
+
+type key struct {
+	route  string
+	tenant string
+}
+
+type slot struct {
+	minute int64
+	counts [18]uint64 // len(bounds)+1
+	total  uint64
+	errors uint64
+}
+
+type series struct {
+	slots [int(Window / slotWidth)]slot
+}
+
+// Recorder counts requests by route and tenant. Both labels must come from
+// bounded sets, route templates and configured tenants, since every pair
+// is a time series and an hour of histograms.
+type Recorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	now      func() time.Time
+
+	mu     sync.Mutex
+	series map[key]*series
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_http_requests_total",
+			Help: "HTTP requests by route template, tenant and status code.",
+		}, []string{"route", "tenant", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_http_request_duration_seconds",
+			Help:    "HTTP request latency by route template and tenant.",
+			Buckets: bounds,
+		}, []string{"route", "tenant"}),
+		now:    time.Now,
+		series: make(map[key]*series),
+	}
+}
+
+// Observe records one request. Status codes of 500 and above are errors.
+func (r *Recorder) Observe(route, tenant string, status int, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	r.requests.WithLabelValues(route, tenant, strconv.Itoa(status)).Inc()
+	r.duration.WithLabelValues(route, tenant).Observe(seconds)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{route: route, tenant: tenant}
+	s, ok := r.series[k]
+	if !ok {
+		s = &series{}
+		r.series[k] = s
+	}
+	minute := r.now().Unix() / int64(slotWidth/time.Second)
+	sl := &s.slots[minute%int64(len(s.slots))]
+	if sl.minute != minute {
+		*sl = slot{minute: minute}
+	}
+	sl.counts[sort.SearchFloat64s(bounds, seconds)]++
+	sl.total++
+	if status >= http.StatusInternalServerError {
+		sl.errors++
+	}
+}
+
+// EndpointStats summarises one route over the last Window.
+type EndpointStats struct {
+	Route     string        `json:"route"`
+	Requests  uint64        `json:"requests"`
+	Errors    uint64        `json:"errors"`
+	ErrorRate float64       `json:"errorRate"`
+	P50       time.Duration `json:"p50"`
+	P95       time.Duration `json:"p95"`
+	P99       time.Duration `json:"p99"`
+}
+
+// Summary returns per-route request counts, error rates and latency
+// percentiles over the last Window, busiest route first. With a tenant
+// only that tenant's requests count.
+func (r *Recorder) Summary(tenant string) []EndpointStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.now().Unix() / int64(slotWidth/time.Second)
+	oldest := current - int64(Window/slotWidth) + 1
+	merged := make(map[string]*slot)
+	for k, s := range r.series {
+		if tenant != "" && k.tenant != tenant {
+			continue
+		}
+		for _, sl := range s.slots {
+			if sl.minute < oldest || sl.minute > current || sl.total == 0 {
+				continue
+			}
+			m, ok := merged[k.route]
+			if !ok {
+				m = &slot{}
+				merged[k.route] = m
+			}
+			for i, c := range sl.counts {
+				m.counts[i] += c
+			}
+			m.total += sl.total
+			m.errors += sl.errors
+		}
+	}
+
+	stats := make([]EndpointStats, 0, len(merged))
+	for route, m := range merged {
+		stats = append(stats, EndpointStats{
+			Route:     route,
+			Requests:  m.total,
+			Errors:    m.errors,
+			ErrorRate: float64(m.errors) / float64(m.total),
+			P50:       quantile(m, 0.50),
+			P95:       quantile(m, 0.95),
+			P99:       quantile(m, 0.99),
+		})
+	}
+	slices.SortFunc(stats, func(a, b EndpointStats) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Route, b.Route))
+	})
+	return stats
+}
+
+// quantile estimates the q-quantile by interpolating linearly within the
+// bucket it falls into, the way Prometheus' histogram_quantile does.
+func quantile(s *slot, q float64) time.Duration {
+	rank := q * float64(s.total)
+	var seen float64
+	for i, c := range s.counts {
+		if c == 0 || seen+float64(c) < rank {
+			seen += float64(c)
+			continue
+		}
+		if i == len(bounds) {
+			return seconds(bounds[len(bounds)-1])
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = bounds[i-1]
+		}
+		return seconds(lower + (bounds[i]-lower)*(rank-seen)/float64(c))
+	}
+	return 0
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Describe and Collect make the recorder a prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.requests.Describe(ch)
+	r.duration.Describe(ch)
+}
+
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.requests.Collect(ch)
+	r.duration.Collect(ch)
+}
@@ -0,0 +1,61 @@
+package httpstats
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Summary(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRecorder()
+	r.now = func() time.Time { return now }
+
+	for i := range 100 {
+		status := http.StatusOK
+		if i < 5 {
+			status = http.StatusBadGateway
+		}
+		r.Observe("POST /api/v1/wallet", "default", status, time.Duration(i+1)*time.Millisecond)
+	}
+	r.Observe("POST /api/v1/wallet", "batch", http.StatusOK, time.Millisecond)
+	r.Observe("GET /api/v1/wallets/{id}", "default", http.StatusNotFound, time.Millisecond)
+
+	stats := r.Summary("")
+	require.Len(t, stats, 2)
+	s := stats[0]
+	assert.Equal(t, "POST /api/v1/wallet", s.Route)
+	assert.Equal(t, uint64(101), s.Requests)
+	assert.Equal(t, uint64(5), s.Errors)
+	assert.InDelta(t, 5.0/101, s.ErrorRate, 1e-9)
+	// Interpolated within the doubling buckets, so close rather than exact.
+	assert.InDelta(t, 50*time.Millisecond, s.P50, float64(15*time.Millisecond))
+	assert.InDelta(t, 95*time.Millisecond, s.P95, float64(35*time.Millisecond))
+	assert.LessOrEqual(t, s.P95, s.P99)
+	assert.Zero(t, stats[1].Errors, "client errors are not errors")
+
+	batch := r.Summary("batch")
+	require.Len(t, batch, 1)
+	assert.Equal(t, uint64(1), batch[0].Requests)
+
+	now = now.Add(Window)
+	assert.Empty(t, r.Summary(""))
+}
+
+func TestRecorder_Collect(t *testing.T) {
+	r := NewRecorder()
+	r.Observe("POST /api/v1/wallet", "default", http.StatusOK, time.Millisecond)
+	r.Observe("POST /api/v1/wallet", "default", http.StatusOK, time.Millisecond)
+
+	err := testutil.CollectAndCompare(r, strings.NewReader(`
+# HELP wallet_http_requests_total HTTP requests by route template, tenant and status code.
+# TYPE wallet_http_requests_total counter
+wallet_http_requests_total{code="200",route="POST /api/v1/wallet",tenant="default"} 2
+`), "wallet_http_requests_total")
+	assert.NoError(t, err)
+}
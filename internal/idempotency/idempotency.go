@@ -0,0 +1,60 @@
+// Package idempotency lets clients retry a request safely by tagging it
+// with an Idempotency-Key: the first response under a key is stored and
+// every later request with the same key gets it back instead of being
+// processed again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Header carries the client's key; ReplayedHeader and OriginalDateHeader
+// mark a stored response played back for a repeated key.
+const (
+	Header             = "Idempotency-Key"
+	ReplayedHeader     = "Idempotency-Replayed"
+	OriginalDateHeader = "Idempotency-Original-Date"
+
+	// MaxKeyLength bounds the keys clients may send.
+	MaxKeyLength = 255
+)
+
+// Response is the part of an HTTP response that is stored and replayed.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Record is a key seen before. Until the first request under it completes
+// it has no response.
+type Record struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	Response    Response
+	CreatedAt   time.Time
+}
+
+// Store persists keys. Reserve claims key for a request with fingerprint
+// and returns nil, or the existing record if key was claimed before; a
+// claimed key is then either completed with the response or released so
+// that the request can be retried.
+type Store interface {
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, now time.Time) (*Record, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, resp Response) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Fingerprint identifies a request by method, path and body, so that a key
+// reused for a different request is told apart from a retry.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
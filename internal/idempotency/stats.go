@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a request carrying a key, as metric labels. New is a key seen
+// for the first time, Replayed a repeated key answered from the
+// store, Conflict a key reused for a different request and InProgress a
+// key whose first request hasn't completed yet.
+const (
+	OutcomeNew        = "new"
+	OutcomeReplayed   = "replayed"
+	OutcomeConflict   = "conflict"
+	OutcomeInProgress = "in_progress"
+)
+
+// MaxRecentConflicts is how many conflicts Stats keeps for inspection.
+const MaxRecentConflicts = 100
+
+// Conflict is a request rejected because its key was used before for a
+// different request.
+type Conflict struct {
+	Scope     string `json:"scope"`
+	Key       string `json:"key"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	RequestID string `json:"requestId,omitempty"`
+	// FirstSeenAt is when the key was first used.
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	At          time.Time `json:"at"`
+}
+
+// Summary counts the requests with a key since the service started. HitRate
+// is the share of them answered from the store.
+type Summary struct {
+	New             uint64     `json:"new"`
+	Replayed        uint64     `json:"replayed"`
+	Conflicts       uint64     `json:"conflicts"`
+	InProgress      uint64     `json:"inProgress"`
+	HitRate         float64    `json:"hitRate"`
+	RecentConflicts []Conflict `json:"recentConflicts"`
+}
+
+// Stats counts requests with a key by outcome, as Prometheus metrics and
+// in memory, and keeps the most recent conflicts so that integrators can
+// find out why their retries are rejected. A nil *Stats records nothing.
+type Stats struct {
+	requests *prometheus.CounterVec
+
+	mu        sync.Mutex
+	counts    map[string]uint64
+	conflicts []Conflict
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_idempotency_requests_total",
+			Help: "Requests with an Idempotency-Key by outcome: new, replayed, conflict or in_progress.",
+		}, []string{"outcome"}),
+		counts: make(map[string]uint64),
+	}
+}
+
+// Observe records a request with outcome.
+func (s *Stats) Observe(outcome string) {
+	if s == nil {
+		return
+	}
+	s.requests.WithLabelValues(outcome).Inc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[outcome]++
+}
+
+// ObserveConflict records a conflict, dropping the oldest one kept if there
+// are MaxRecentConflicts already.
+func (s *Stats) ObserveConflict(c Conflict) {
+	if s == nil {
+		return
+	}
+	s.requests.WithLabelValues(OutcomeConflict).Inc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[OutcomeConflict]++
+	if len(s.conflicts) == MaxRecentConflicts {
+		s.conflicts = slices.Delete(s.conflicts, 0, 1)
+	}
+	s.conflicts = append(s.conflicts, c)
+}
+
+// Summary returns the counts so far and the recent conflicts, newest first.
+func (s *Stats) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := Summary{
+		New:             s.counts[OutcomeNew],
+		Replayed:        s.counts[OutcomeReplayed],
+		Conflicts:       s.counts[OutcomeConflict],
+		InProgress:      s.counts[OutcomeInProgress],
+		RecentConflicts: slices.Clone(s.conflicts),
+	}
+	if total := sum.New + sum.Replayed + sum.Conflicts + sum.InProgress; total > 0 {
+		sum.HitRate = float64(sum.Replayed) / float64(total)
+	}
+	if sum.RecentConflicts == nil {
+		sum.RecentConflicts = []Conflict{}
+	}
+	slices.Reverse(sum.RecentConflicts)
+	return sum
+}
+
+// Describe and Collect make the stats a prometheus.Collector.
+func (s *Stats) Describe(ch chan<- *prometheus.Desc) {
+	s.requests.Describe(ch)
+}
+
+func (s *Stats) Collect(ch chan<- prometheus.Metric) {
+	s.requests.Collect(ch)
+}
@@ -0,0 +1,585 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrReportNotFound = errors.New("job has no report")
+	ErrUnknownKind    = errors.New("no handler registered for job kind")
+	ErrLeaseLost      = errors.New("job is owned by another instance")
+)
+
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCanceled  State = "canceled"
+)
+
+// Job is a snapshot of a background job and its progress. Processed counts
+// every handled item, Failed the subset that failed; Remaining is derived
+// from Total. Attempts counts runs, including resumes after a restart, and
+// Owner is the instance holding the job's lease.
+type Job struct {
+	ID         uuid.UUID  `json:"id"`
+	Kind       string     `json:"kind"`
+	State      State      `json:"state"`
+	Attempts   int        `json:"attempts"`
+	Owner      string     `json:"owner,omitempty"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Failed     int64      `json:"failed"`
+	Remaining  int64      `json:"remaining"`
+	HasReport  bool       `json:"hasReport"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+
+	report    *Report
+	persisted bool
+}
+
+// Report is a downloadable artifact a job leaves behind, e.g. per-item
+// results.
+type Report struct {
+	ContentType string
+	Data        []byte
+}
+
+func (j *Job) snapshot() Job {
+	s := *j
+	s.Remaining = max(s.Total-s.Processed, 0)
+	s.report = nil
+	return s
+}
+
+// Progress lets a running job report how far it got.
+type Progress struct {
+	mu  *sync.Mutex
+	job *Job
+
+	checkpoint json.RawMessage
+	pending    []byte
+	// flush writes the job to the store; nil for jobs that aren't persisted.
+	flush func(ctx context.Context) error
+}
+
+// SetTotal records the number of items the job expects to process.
+func (p *Progress) SetTotal(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Total = n
+}
+
+// Queued marks the job as waiting for a precondition, e.g. a maintenance
+// window; Running marks it as making progress again.
+func (p *Progress) Queued() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.State = StateQueued
+}
+
+func (p *Progress) Running() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.State = StateRunning
+}
+
+// Add records n more processed items.
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed += n
+}
+
+// Fail records n more processed items that failed.
+func (p *Progress) Fail(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed += n
+	p.job.Failed += n
+}
+
+// Restore resets the processed and failed counts, for a resumed job whose
+// checkpoint is behind the progress last saved.
+func (p *Progress) Restore(processed, failed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed = processed
+	p.job.Failed = failed
+}
+
+// AppendReport appends data to the job's report. Persisted jobs write it
+// out with the next Save or heartbeat, so a report built incrementally
+// survives a restart.
+func (p *Progress) AppendReport(contentType string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.job.report == nil {
+		p.job.report = &Report{ContentType: contentType}
+	}
+	p.job.report.Data = append(p.job.report.Data, data...)
+	p.job.HasReport = true
+	p.pending = append(p.pending, data...)
+}
+
+// Checkpoint decodes the state last passed to Save into v and reports
+// whether there was one. A resumed job uses it to skip the work it did
+// before it was interrupted.
+func (p *Progress) Checkpoint(v any) (bool, error) {
+	p.mu.Lock()
+	checkpoint := p.checkpoint
+	p.mu.Unlock()
+
+	if len(checkpoint) == 0 {
+		return false, nil
+	}
+	return true, json.Unmarshal(checkpoint, v)
+}
+
+// Save records v as the job's checkpoint. A persisted job is written to the
+// store, with its progress and pending report, before Save returns.
+func (p *Progress) Save(ctx context.Context, v any) error {
+	checkpoint, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	p.mu.Lock()
+	p.checkpoint = checkpoint
+	p.mu.Unlock()
+
+	if p.flush == nil {
+		return nil
+	}
+	return p.flush(ctx)
+}
+
+// Func is the body of a job. The context is canceled on Stop.
+type Func func(ctx context.Context, p *Progress) error
+
+// Handler runs a persisted job of one kind from its payload. It may be
+// called again for the same job after a restart, and must then continue
+// from p.Checkpoint rather than redo finished work.
+type Handler func(ctx context.Context, payload json.RawMessage, p *Progress) error
+
+const (
+	DefaultLease       = time.Minute
+	DefaultMaxAttempts = 3
+
+	// claimBatch caps the jobs one Resume call takes over.
+	claimBatch = 16
+	// releaseTimeout bounds the final write of a job once it returned.
+	releaseTimeout = 10 * time.Second
+)
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithStore persists jobs started with Submit in store, leased to owner,
+// which must be unique per running instance.
+func WithStore(store Store, owner string) Option {
+	return func(m *Manager) {
+		m.store = store
+		m.owner = owner
+	}
+}
+
+// WithLease sets how long a persisted job stays with its owner without a
+// heartbeat before another instance may resume it.
+func WithLease(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.lease = d
+		}
+	}
+}
+
+// WithMaxAttempts sets how many times a persisted job is run before an
+// interruption fails it for good.
+func WithMaxAttempts(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.maxAttempts = n
+		}
+	}
+}
+
+// Manager runs jobs in the background, detached from the request that
+// started them, and keeps their state in memory for status queries. With a
+// Store, jobs started with Submit are also persisted: they report progress
+// through periodic heartbeats, are released on Stop and are picked up again
+// by Resume, on this or another instance.
+type Manager struct {
+	log *slog.Logger
+
+	store       Store
+	owner       string
+	lease       time.Duration
+	maxAttempts int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	jobs     map[uuid.UUID]*Job
+	handlers map[string]Handler
+}
+
+func NewManager(log *slog.Logger, opts ...Option) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		log:         log,
+		lease:       DefaultLease,
+		maxAttempts: DefaultMaxAttempts,
+		ctx:         ctx,
+		cancel:      cancel,
+		jobs:        make(map[uuid.UUID]*Job),
+		handlers:    make(map[string]Handler),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Register makes jobs of kind runnable by Submit and Resume.
+func (m *Manager) Register(kind string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[kind] = h
+}
+
+// Start launches fn in its own goroutine and returns the job's initial
+// snapshot. The job lives in memory only and is lost on restart.
+func (m *Manager) Start(kind string, fn Func) Job {
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        uuid.New(),
+		Kind:      kind,
+		State:     StateRunning,
+		Attempts:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return m.launch(job, nil, fn)
+}
+
+// Submit starts a job of a registered kind with payload, which must encode
+// to JSON. With a Store the job is persisted before it starts, so it is
+// resumed if this instance stops before the job finishes.
+func (m *Manager) Submit(ctx context.Context, kind string, payload any) (Job, error) {
+	m.mu.Lock()
+	h, ok := m.handlers[kind]
+	m.mu.Unlock()
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        uuid.New(),
+		Kind:      kind,
+		State:     StateRunning,
+		Attempts:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if m.store != nil {
+		job.Owner = m.owner
+		job.persisted = true
+		if err := m.store.CreateJob(ctx, Record{Job: *job, Payload: data}); err != nil {
+			return Job{}, fmt.Errorf("failed to persist job: %w", err)
+		}
+	}
+	return m.launch(job, nil, bind(h, data)), nil
+}
+
+// Resume takes over persisted jobs that were released on shutdown or whose
+// owner stopped renewing the lease, and runs them again from their last
+// checkpoint. Jobs that used up their attempts, or whose kind isn't
+// registered here, are failed instead. Without a Store it does nothing.
+func (m *Manager) Resume(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	recs, err := m.store.ClaimJobs(ctx, m.owner, time.Now().UTC().Add(-m.lease), claimBatch)
+	if err != nil {
+		return fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	for _, rec := range recs {
+		log := m.log.With(slog.String("job_id", rec.ID.String()), slog.String("kind", rec.Kind), slog.Int("attempt", rec.Attempts))
+
+		m.mu.Lock()
+		h, ok := m.handlers[rec.Kind]
+		m.mu.Unlock()
+
+		var reason error
+		switch {
+		case !ok:
+			reason = fmt.Errorf("%w: %s", ErrUnknownKind, rec.Kind)
+		case rec.Attempts > m.maxAttempts:
+			reason = fmt.Errorf("interrupted %d times, giving up", rec.Attempts-1)
+		}
+		if reason != nil {
+			finished := time.Now().UTC()
+			rec.State, rec.Error, rec.FinishedAt, rec.UpdatedAt = StateFailed, reason.Error(), &finished, finished
+			if err := m.store.SaveJob(ctx, m.owner, rec, nil); err != nil {
+				log.Error("failed to fail job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			}
+			log.Warn("job not resumed", slog.String("reason", reason.Error()))
+			continue
+		}
+
+		job := rec.Job
+		job.persisted = true
+		m.launch(&job, rec.Checkpoint, bind(h, rec.Payload))
+		log.Info("job resumed")
+	}
+	return nil
+}
+
+func bind(h Handler, payload json.RawMessage) Func {
+	return func(ctx context.Context, p *Progress) error {
+		return h(ctx, payload, p)
+	}
+}
+
+func (m *Manager) launch(job *Job, checkpoint json.RawMessage, fn Func) Job {
+	p := &Progress{mu: &m.mu, job: job, checkpoint: checkpoint}
+	if job.persisted {
+		p.flush = m.flusher(p)
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	snapshot := job.snapshot()
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go m.run(p, fn)
+	return snapshot
+}
+
+// flusher returns the function writing p's job to the store. Writes are
+// serialized so that report parts are appended in order.
+func (m *Manager) flusher(p *Progress) func(ctx context.Context) error {
+	var mu sync.Mutex
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		m.mu.Lock()
+		p.job.UpdatedAt = time.Now().UTC()
+		rec := Record{Job: p.job.snapshot(), Checkpoint: p.checkpoint}
+		if p.job.report != nil {
+			rec.ReportType = p.job.report.ContentType
+		}
+		part := p.pending
+		p.pending = nil
+		m.mu.Unlock()
+
+		if err := m.store.SaveJob(ctx, m.owner, rec, part); err != nil {
+			m.mu.Lock()
+			p.pending = append(part, p.pending...)
+			m.mu.Unlock()
+			return err
+		}
+		return nil
+	}
+}
+
+// heartbeat renews the lease of a persisted job until ctx is done. It
+// cancels the job and reports true once another instance has taken it over.
+func (m *Manager) heartbeat(ctx context.Context, cancel context.CancelFunc, p *Progress, log *slog.Logger) bool {
+	ticker := time.NewTicker(m.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			err := p.flush(ctx)
+			switch {
+			case errors.Is(err, ErrLeaseLost):
+				log.Warn("job lease lost, stopping")
+				cancel()
+				return true
+			case err != nil && ctx.Err() == nil:
+				log.Warn("failed to save job progress", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			}
+		}
+	}
+}
+
+func (m *Manager) run(p *Progress, fn Func) {
+	defer m.wg.Done()
+	job := p.job
+	log := m.log.With(slog.String("job_id", job.ID.String()), slog.String("kind", job.Kind))
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+	beating := make(chan struct{})
+	var lost bool
+	if p.flush != nil {
+		go func() {
+			defer close(beating)
+			lost = m.heartbeat(ctx, cancel, p, log)
+		}()
+	} else {
+		close(beating)
+	}
+
+	err := fn(ctx, p)
+	cancel()
+	<-beating
+	if lost || errors.Is(err, ErrLeaseLost) {
+		lost = true
+		err = ErrLeaseLost
+	}
+
+	m.mu.Lock()
+	now := time.Now().UTC()
+	release := p.flush != nil && m.ctx.Err() != nil && errors.Is(err, context.Canceled)
+	switch {
+	case lost:
+		job.State = StateCanceled
+		job.Error = ErrLeaseLost.Error()
+		job.FinishedAt = &now
+	case release:
+		// Shutting down: hand the job back for another run to resume.
+		job.State = StateQueued
+		job.Owner = ""
+	case err == nil:
+		job.State = StateSucceeded
+		job.FinishedAt = &now
+	case errors.Is(err, context.Canceled):
+		job.State = StateCanceled
+		job.Error = err.Error()
+		job.FinishedAt = &now
+	default:
+		job.State = StateFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+	}
+	processed := job.Processed
+	m.mu.Unlock()
+
+	if p.flush != nil && !lost {
+		saveCtx, cancelSave := context.WithTimeout(context.Background(), releaseTimeout)
+		if err := p.flush(saveCtx); err != nil {
+			log.Error("failed to save job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		cancelSave()
+	}
+
+	switch {
+	case release:
+		log.Info("background job released", slog.Int64("processed", processed))
+	case err != nil:
+		log.Error("background job failed", slog.Int64("processed", processed), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	default:
+		log.Info("background job completed", slog.Int64("processed", processed))
+	}
+}
+
+// Get returns the current snapshot of a job, from the store when it isn't
+// running on this instance.
+func (m *Manager) Get(ctx context.Context, id uuid.UUID) (Job, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	var snapshot Job
+	if ok {
+		snapshot = job.snapshot()
+	}
+	m.mu.Unlock()
+
+	if ok || m.store == nil {
+		if !ok {
+			return Job{}, ErrJobNotFound
+		}
+		return snapshot, nil
+	}
+	rec, err := m.store.GetJob(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+	return rec.Job.snapshot(), nil
+}
+
+// List returns the most recent jobs of kind, or of every kind if kind is
+// empty, newest first. With a Store only persisted jobs are listed.
+func (m *Manager) List(ctx context.Context, kind string, limit int) ([]Job, error) {
+	if m.store != nil {
+		return m.store.ListJobs(ctx, kind, limit)
+	}
+
+	m.mu.Lock()
+	list := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if kind == "" || job.Kind == kind {
+			list = append(list, job.snapshot())
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// Report returns the report a job attached, ErrReportNotFound while it has
+// none. Reports of persisted jobs are read from the store.
+func (m *Manager) Report(ctx context.Context, id uuid.UUID) (Report, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	var report *Report
+	persisted := m.store != nil
+	if ok {
+		report, persisted = job.report, job.persisted
+	}
+	m.mu.Unlock()
+
+	if persisted {
+		return m.store.JobReport(ctx, id)
+	}
+	if !ok {
+		return Report{}, ErrJobNotFound
+	}
+	if report == nil {
+		return Report{}, ErrReportNotFound
+	}
+	return *report, nil
+}
+
+// Stop cancels running jobs and waits for them to return. Persisted jobs
+// are released rather than canceled, so that Resume continues them.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitFinished(t *testing.T, m *Manager, id uuid.UUID) Job {
+	t.Helper()
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(context.Background(), id)
+		return err == nil && job.State != StateRunning && job.State != StateQueued
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestManager_ReportsProgress(t *testing.T) {
+	m := NewManager(slog.Default())
+	defer m.Stop()
+
+	started := m.Start("test", func(ctx context.Context, p *Progress) error {
+		p.SetTotal(3)
+		p.Add(2)
+		p.Add(1)
+		return nil
+	})
+	assert.Equal(t, StateRunning, started.State)
+
+	job := waitFinished(t, m, started.ID)
+	assert.Equal(t, StateSucceeded, job.State)
+	assert.Equal(t, int64(3), job.Total)
+	assert.Equal(t, int64(3), job.Processed)
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestManager_Failure(t *testing.T) {
+	m := NewManager(slog.Default())
+	defer m.Stop()
+
+	started := m.Start("test", func(ctx context.Context, p *Progress) error {
+		return errors.New("boom")
+	})
+
+	job := waitFinished(t, m, started.ID)
+	assert.Equal(t, StateFailed, job.State)
+	assert.Equal(t, "boom", job.Error)
+}
+
+func TestManager_StopCancels(t *testing.T) {
+	m := NewManager(slog.Default())
+	started := m.Start("test", func(ctx context.Context, p *Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	m.Stop()
+
+	job, err := m.Get(context.Background(), started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StateCanceled, job.State)
+}
+
+func TestManager_GetUnknown(t *testing.T) {
+	_, err := NewManager(slog.Default()).Get(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestManager_FailuresAndReport(t *testing.T) {
+	m := NewManager(slog.Default())
+	defer m.Stop()
+
+	started := m.Start("test", func(ctx context.Context, p *Progress) error {
+		p.SetTotal(5)
+		p.Add(2)
+		p.Fail(1)
+		p.AppendReport("text/csv", []byte("a,b\n"))
+		p.AppendReport("text/csv", []byte("c,d\n"))
+		return nil
+	})
+
+	job := waitFinished(t, m, started.ID)
+	assert.Equal(t, int64(3), job.Processed)
+	assert.Equal(t, int64(1), job.Failed)
+	assert.Equal(t, int64(2), job.Remaining)
+	assert.True(t, job.HasReport)
+
+	report, err := m.Report(context.Background(), started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", report.ContentType)
+	assert.Equal(t, "a,b\nc,d\n", string(report.Data))
+
+	unreported := m.Start("test", func(ctx context.Context, p *Progress) error { return nil })
+	waitFinished(t, m, unreported.ID)
+	_, err = m.Report(context.Background(), unreported.ID)
+	assert.ErrorIs(t, err, ErrReportNotFound)
+}
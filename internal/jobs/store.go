@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record is a job as kept by a Store: its snapshot plus what it takes to
+// run it again after a restart.
+type Record struct {
+	Job
+	Payload    json.RawMessage
+	Checkpoint json.RawMessage
+	ReportType string
+}
+
+// Store persists jobs so that they outlive the instance running them.
+// Ownership is a lease: the owner renews it with every SaveJob, and a job
+// that was released or whose lease expired can be claimed by any instance.
+type Store interface {
+	CreateJob(ctx context.Context, rec Record) error
+	// SaveJob writes rec's state, progress, owner and checkpoint and appends
+	// report to the job's report, provided owner still holds the job;
+	// otherwise it returns ErrLeaseLost.
+	SaveJob(ctx context.Context, owner string, rec Record, report []byte) error
+	GetJob(ctx context.Context, id uuid.UUID) (Record, error)
+	ListJobs(ctx context.Context, kind string, limit int) ([]Job, error)
+	// ClaimJobs hands up to limit unfinished jobs that are released or were
+	// last saved before staleBefore to owner, counting a new attempt.
+	ClaimJobs(ctx context.Context, owner string, staleBefore time.Time, limit int) ([]Record, error)
+	// JobReport returns the concatenated report of a job.
+	JobReport(ctx context.Context, id uuid.UUID) (Report, error)
+}
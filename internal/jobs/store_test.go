@@ -0,0 +1,277 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore is a Store kept in memory, following the lease rules of the
+// database implementation.
+type memStore struct {
+	mu      sync.Mutex
+	recs    map[uuid.UUID]Record
+	reports map[uuid.UUID][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{recs: make(map[uuid.UUID]Record), reports: make(map[uuid.UUID][]byte)}
+}
+
+func (s *memStore) CreateJob(ctx context.Context, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs[rec.ID] = rec
+	return nil
+}
+
+func (s *memStore) SaveJob(ctx context.Context, owner string, rec Record, report []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.recs[rec.ID]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if cur.Owner != owner {
+		return ErrLeaseLost
+	}
+	rec.Payload = cur.Payload
+	s.recs[rec.ID] = rec
+	s.reports[rec.ID] = append(s.reports[rec.ID], report...)
+	return nil
+}
+
+func (s *memStore) GetJob(ctx context.Context, id uuid.UUID) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recs[id]
+	if !ok {
+		return Record{}, ErrJobNotFound
+	}
+	return rec, nil
+}
+
+func (s *memStore) ListJobs(ctx context.Context, kind string, limit int) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []Job
+	for _, rec := range s.recs {
+		if kind == "" || rec.Kind == kind {
+			list = append(list, rec.Job)
+		}
+	}
+	return list, nil
+}
+
+func (s *memStore) ClaimJobs(ctx context.Context, owner string, staleBefore time.Time, limit int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []Record
+	for id, rec := range s.recs {
+		unfinished := rec.State == StateQueued || rec.State == StateRunning
+		if !unfinished || (rec.Owner != "" && !rec.UpdatedAt.Before(staleBefore)) {
+			continue
+		}
+		rec.Owner, rec.State, rec.UpdatedAt = owner, StateRunning, time.Now().UTC()
+		rec.Attempts++
+		s.recs[id] = rec
+		claimed = append(claimed, rec)
+	}
+	return claimed, nil
+}
+
+func (s *memStore) JobReport(ctx context.Context, id uuid.UUID) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recs[id]
+	if !ok {
+		return Report{}, ErrJobNotFound
+	}
+	if len(s.reports[id]) == 0 {
+		return Report{}, ErrReportNotFound
+	}
+	return Report{ContentType: rec.ReportType, Data: s.reports[id]}, nil
+}
+
+func (s *memStore) get(t *testing.T, id uuid.UUID) Record {
+	t.Helper()
+	rec, err := s.GetJob(context.Background(), id)
+	require.NoError(t, err)
+	return rec
+}
+
+func TestManager_SubmitPersists(t *testing.T) {
+	store := newMemStore()
+	m := NewManager(slog.Default(), WithStore(store, "a"))
+	defer m.Stop()
+
+	m.Register("sum", func(ctx context.Context, payload json.RawMessage, p *Progress) error {
+		var n []int64
+		if err := json.Unmarshal(payload, &n); err != nil {
+			return err
+		}
+		p.SetTotal(int64(len(n)))
+		p.Add(int64(len(n)))
+		p.AppendReport("text/plain", payload)
+		return nil
+	})
+
+	started, err := m.Submit(context.Background(), "sum", []int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, "a", store.get(t, started.ID).Owner)
+
+	waitFinished(t, m, started.ID)
+	require.Eventually(t, func() bool {
+		return store.get(t, started.ID).State == StateSucceeded
+	}, time.Second, 5*time.Millisecond)
+
+	rec := store.get(t, started.ID)
+	assert.Equal(t, int64(2), rec.Processed)
+	assert.JSONEq(t, "[1,2]", string(rec.Payload))
+
+	report, err := m.Report(context.Background(), started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", report.ContentType)
+	assert.Equal(t, "[1,2]", string(report.Data))
+}
+
+func TestManager_SubmitUnknownKind(t *testing.T) {
+	m := NewManager(slog.Default(), WithStore(newMemStore(), "a"))
+	defer m.Stop()
+
+	_, err := m.Submit(context.Background(), "missing", nil)
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+// countTo counts up to the payload, saving a checkpoint after every step,
+// and blocks once it reached stopAt until its context is canceled.
+func countTo(stopAt int, seen chan<- int) Handler {
+	return func(ctx context.Context, payload json.RawMessage, p *Progress) error {
+		var total int
+		if err := json.Unmarshal(payload, &total); err != nil {
+			return err
+		}
+		var next int
+		if _, err := p.Checkpoint(&next); err != nil {
+			return err
+		}
+		seen <- next
+		for ; next < total; next++ {
+			if next == stopAt {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			p.Add(1)
+			if err := p.Save(ctx, next+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestManager_ResumeAfterStop(t *testing.T) {
+	store := newMemStore()
+	seen := make(chan int, 2)
+
+	first := NewManager(slog.Default(), WithStore(store, "a"))
+	first.Register("count", countTo(3, seen))
+	started, err := first.Submit(context.Background(), "count", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 0, <-seen)
+	require.Eventually(t, func() bool {
+		job, _ := first.Get(context.Background(), started.ID)
+		return job.Processed == 3
+	}, time.Second, 5*time.Millisecond)
+	first.Stop()
+
+	released := store.get(t, started.ID)
+	assert.Equal(t, StateQueued, released.State)
+	assert.Empty(t, released.Owner)
+	assert.JSONEq(t, "3", string(released.Checkpoint))
+
+	second := NewManager(slog.Default(), WithStore(store, "b"))
+	defer second.Stop()
+	second.Register("count", countTo(-1, seen))
+	require.NoError(t, second.Resume(context.Background()))
+	assert.Equal(t, 3, <-seen)
+
+	job := waitFinished(t, second, started.ID)
+	assert.Equal(t, StateSucceeded, job.State)
+	assert.Equal(t, int64(5), job.Processed)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "b", job.Owner)
+}
+
+func TestManager_ResumeSkipsLeasedJobs(t *testing.T) {
+	store := newMemStore()
+	id := uuid.New()
+	require.NoError(t, store.CreateJob(context.Background(), Record{
+		Job: Job{ID: id, Kind: "count", State: StateRunning, Attempts: 1, Owner: "a", UpdatedAt: time.Now().UTC()},
+	}))
+
+	m := NewManager(slog.Default(), WithStore(store, "b"))
+	defer m.Stop()
+	m.Register("count", countTo(-1, make(chan int, 1)))
+	require.NoError(t, m.Resume(context.Background()))
+
+	assert.Equal(t, "a", store.get(t, id).Owner)
+}
+
+func TestManager_ResumeGivesUp(t *testing.T) {
+	store := newMemStore()
+	exhausted, unknown := uuid.New(), uuid.New()
+	stale := time.Now().UTC().Add(-time.Hour)
+	require.NoError(t, store.CreateJob(context.Background(), Record{
+		Job:     Job{ID: exhausted, Kind: "count", State: StateRunning, Attempts: 2, Owner: "a", UpdatedAt: stale},
+		Payload: json.RawMessage("5"),
+	}))
+	require.NoError(t, store.CreateJob(context.Background(), Record{
+		Job: Job{ID: unknown, Kind: "other", State: StateQueued, Attempts: 1, UpdatedAt: stale},
+	}))
+
+	m := NewManager(slog.Default(), WithStore(store, "b"), WithMaxAttempts(2))
+	defer m.Stop()
+	m.Register("count", countTo(-1, make(chan int, 1)))
+	require.NoError(t, m.Resume(context.Background()))
+
+	rec := store.get(t, exhausted)
+	assert.Equal(t, StateFailed, rec.State)
+	assert.Equal(t, 3, rec.Attempts)
+	assert.Contains(t, rec.Error, "giving up")
+	assert.NotNil(t, rec.FinishedAt)
+
+	rec = store.get(t, unknown)
+	assert.Equal(t, StateFailed, rec.State)
+	assert.Contains(t, rec.Error, ErrUnknownKind.Error())
+}
+
+func TestManager_LeaseLostCancels(t *testing.T) {
+	store := newMemStore()
+	m := NewManager(slog.Default(), WithStore(store, "a"), WithLease(30*time.Millisecond))
+	defer m.Stop()
+	seen := make(chan int, 1)
+	m.Register("count", countTo(0, seen))
+
+	started, err := m.Submit(context.Background(), "count", 1)
+	require.NoError(t, err)
+	<-seen
+
+	store.mu.Lock()
+	rec := store.recs[started.ID]
+	rec.Owner = "b"
+	store.recs[started.ID] = rec
+	store.mu.Unlock()
+
+	job := waitFinished(t, m, started.ID)
+	assert.Equal(t, StateCanceled, job.State)
+	assert.Equal(t, ErrLeaseLost.Error(), job.Error)
+	assert.Equal(t, "b", store.get(t, started.ID).Owner)
+}
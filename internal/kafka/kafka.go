@@ -0,0 +1,160 @@
+// Package kafka publishes to Kafka with segmentio/kafka-go, waiting for
+// every in-sync replica to acknowledge each message. Connections to the
+// brokers can use TLS and authenticate with SASL PLAIN or SCRAM.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+
+	// batchTimeout is how long a partial batch waits for more messages.
+	// Produce writes all of its messages at once, so there is nothing
+	// worth waiting for.
+	batchTimeout = 5 * time.Millisecond
+	// writeAttempts bounds the attempts at writing a batch, such as
+	// while a partition changes leader.
+	writeAttempts = 3
+)
+
+var ErrNoBrokers = errors.New("no kafka brokers configured")
+
+// Config configures a Producer. Each request, and the dial before it,
+// times out after Timeout. TLS, when set, encrypts the connections to the
+// brokers. SASLMechanism is one of SASLPlain, SASLScramSHA256 and
+// SASLScramSHA512 to authenticate as SASLUsername, or empty for none.
+type Config struct {
+	Brokers       []string
+	ClientID      string
+	Timeout       time.Duration
+	TLS           *tls.Config
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// Header is a message header.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message is a record to produce. Time defaults to the time of Produce.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers []Header
+	Time    time.Time
+}
+
+// Producer publishes messages to the partition leaders of a cluster. It is
+// safe for concurrent use.
+type Producer struct {
+	w *kafkago.Writer
+}
+
+// NewProducer returns a producer bootstrapping from cfg.Brokers
+// (host:port). It connects on the first Produce.
+func NewProducer(cfg Config) (*Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+	mechanism, err := saslMechanism(cfg.SASLMechanism, cfg.SASLUsername, cfg.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{w: &kafkago.Writer{
+		Addr: kafkago.TCP(cfg.Brokers...),
+		// Hashes keys as the Java client's default partitioner does, so
+		// messages land where other producers would put them.
+		Balancer:     &kafkago.Murmur2Balancer{},
+		RequiredAcks: kafkago.RequireAll,
+		MaxAttempts:  writeAttempts,
+		BatchTimeout: batchTimeout,
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+		Transport: &kafkago.Transport{
+			ClientID:    cfg.ClientID,
+			DialTimeout: cfg.Timeout,
+			TLS:         cfg.TLS,
+			SASL:        mechanism,
+		},
+	}}, nil
+}
+
+func saslMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q", name)
+	}
+}
+
+// Produce appends msgs to topic and returns once all in-sync replicas have
+// them. Each message goes to the partition its key hashes to, so messages
+// of one key stay in order; messages without a key are spread over the
+// partitions. A failed call may have written some partitions: retrying it
+// can duplicate messages.
+func (p *Producer) Produce(ctx context.Context, topic string, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	out := make([]kafkago.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = kafkago.Message{Topic: topic, Key: m.Key, Value: m.Value, Time: m.Time}
+		if out[i].Time.IsZero() {
+			out[i].Time = now
+		}
+		for _, h := range m.Headers {
+			out[i].Headers = append(out[i].Headers, kafkago.Header{Key: h.Key, Value: h.Value})
+		}
+	}
+	if err := p.w.WriteMessages(ctx, out...); err != nil {
+		return fmt.Errorf("produce to %s: %w", topic, unwrapWriteErrors(err))
+	}
+	return nil
+}
+
+// unwrapWriteErrors joins the distinct errors of the messages a write
+// failed for, which kafkago.WriteErrors hides from errors.Is.
+func unwrapWriteErrors(err error) error {
+	var werr kafkago.WriteErrors
+	if !errors.As(err, &werr) {
+		return err
+	}
+	var errs []error
+	seen := map[string]bool{}
+	for _, e := range werr {
+		if e != nil && !seen[e.Error()] {
+			seen[e.Error()] = true
+			errs = append(errs, e)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes pending writes and closes the connections to the brokers.
+func (p *Producer) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	key, value string
+	headers    map[string]string
+}
+
+// fakeCluster is a kafkago.RoundTripper serving one topic from memory.
+type fakeCluster struct {
+	topic      string
+	partitions int
+	errorCode  int16
+
+	mu      sync.Mutex
+	acks    []int16
+	records map[int32][]record
+}
+
+func newFakeCluster(topic string, partitions int) *fakeCluster {
+	return &fakeCluster{topic: topic, partitions: partitions, records: map[int32][]record{}}
+}
+
+func (c *fakeCluster) RoundTrip(_ context.Context, _ net.Addr, req kafkago.Request) (kafkago.Response, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		resp := &metadata.Response{Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "localhost", Port: 9092}}}
+		for _, name := range req.TopicNames {
+			topic := metadata.ResponseTopic{Name: name, ErrorCode: 3} // UNKNOWN_TOPIC_OR_PARTITION
+			if name == c.topic {
+				topic.ErrorCode = 0
+				for i := range c.partitions {
+					topic.Partitions = append(topic.Partitions, metadata.ResponsePartition{PartitionIndex: int32(i), LeaderID: 1})
+				}
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp, nil
+	case *produce.Request:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.acks = append(c.acks, req.Acks)
+		resp := &produce.Response{}
+		for _, t := range req.Topics {
+			topic := produce.ResponseTopic{Topic: t.Topic}
+			for _, p := range t.Partitions {
+				records, err := readRecords(p.RecordSet.Records)
+				if err != nil {
+					return nil, err
+				}
+				if c.errorCode == 0 {
+					c.records[p.Partition] = append(c.records[p.Partition], records...)
+				}
+				topic.Partitions = append(topic.Partitions, produce.ResponsePartition{Partition: p.Partition, ErrorCode: c.errorCode})
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp, nil
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func readRecords(r protocol.RecordReader) ([]record, error) {
+	var records []record
+	for {
+		rec, err := r.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		key, _ := protocol.ReadAll(rec.Key)
+		value, _ := protocol.ReadAll(rec.Value)
+		out := record{key: string(key), value: string(value), headers: map[string]string{}}
+		for _, h := range rec.Headers {
+			out.headers[h.Key] = string(h.Value)
+		}
+		records = append(records, out)
+	}
+}
+
+func newTestProducer(t *testing.T, cluster *fakeCluster) *Producer {
+	t.Helper()
+	p, err := NewProducer(Config{Brokers: []string{"localhost:9092"}, ClientID: "test", Timeout: time.Second})
+	require.NoError(t, err)
+	p.w.Transport = cluster
+	p.w.WriteBackoffMin, p.w.WriteBackoffMax = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestNewProducer(t *testing.T) {
+	_, err := NewProducer(Config{})
+	assert.ErrorIs(t, err, ErrNoBrokers)
+
+	for _, mechanism := range []string{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		p, err := NewProducer(Config{Brokers: []string{"localhost:9092"}, SASLMechanism: mechanism, SASLUsername: "u", SASLPassword: "p"})
+		require.NoError(t, err, mechanism)
+		assert.Equal(t, mechanism, p.w.Transport.(*kafkago.Transport).SASL.Name())
+	}
+
+	_, err = NewProducer(Config{Brokers: []string{"localhost:9092"}, SASLMechanism: "GSSAPI"})
+	assert.Error(t, err)
+}
+
+func TestProducer_Produce(t *testing.T) {
+	cluster := newFakeCluster("events", 4)
+	p := newTestProducer(t, cluster)
+
+	var msgs []Message
+	for i := 0; i < 12; i++ {
+		key := []string{"foobar", "abc", "21"}[i%3]
+		msgs = append(msgs, Message{
+			Key:     []byte(key),
+			Value:   []byte(strconv.Itoa(i)),
+			Headers: []Header{{Key: "event-type", Value: []byte("test")}},
+		})
+	}
+	require.NoError(t, p.Produce(context.Background(), "events", msgs))
+
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+	for _, acks := range cluster.acks {
+		assert.Equal(t, int16(-1), acks, "acknowledged by all in-sync replicas")
+	}
+	// Partitions of the Java client's default partitioner.
+	want := map[string]int32{"foobar": 2, "abc": 3, "21": 0}
+	total := 0
+	for partition, records := range cluster.records {
+		total += len(records)
+		last := map[string]int{}
+		for _, r := range records {
+			assert.Equal(t, want[r.key], partition, "partition of %s", r.key)
+			assert.Equal(t, "test", r.headers["event-type"])
+			v, _ := strconv.Atoi(r.value)
+			if prev, ok := last[r.key]; ok {
+				assert.Less(t, prev, v, "order of %s", r.key)
+			}
+			last[r.key] = v
+		}
+	}
+	assert.Equal(t, 12, total)
+}
+
+func TestProducer_BrokerError(t *testing.T) {
+	cluster := newFakeCluster("events", 1)
+	cluster.errorCode = 19 // NOT_ENOUGH_REPLICAS
+	p := newTestProducer(t, cluster)
+
+	err := p.Produce(context.Background(), "events", []Message{{Value: []byte("x")}})
+
+	assert.ErrorIs(t, err, kafkago.NotEnoughReplicas)
+}
+
+func TestProducer_UnknownTopic(t *testing.T) {
+	p := newTestProducer(t, newFakeCluster("events", 1))
+
+	err := p.Produce(context.Background(), "other", []Message{{Value: []byte("x")}})
+
+	assert.ErrorIs(t, err, kafkago.UnknownTopicOrPartition)
+}
@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"context"
+	"wallet-service/internal/models"
+)
+
+// Publisher publishes outbox events to a topic. Events are keyed by wallet
+// ID, so each wallet's events are consumed in order, and carry their ID
+// and type as the event-id and event-type headers; consumers should drop
+// events whose ID they have seen, as a retried publish can repeat them.
+type Publisher struct {
+	producer *Producer
+	topic    string
+}
+
+func NewPublisher(producer *Producer, topic string) *Publisher {
+	return &Publisher{producer: producer, topic: topic}
+}
+
+// Publish produces events in order and returns once all are acknowledged.
+func (p *Publisher) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	msgs := make([]Message, len(events))
+	for i, e := range events {
+		msgs[i] = Message{
+			Key:   []byte(e.WalletID.String()),
+			Value: e.Payload,
+			Headers: []Header{
+				{Key: "event-id", Value: []byte(e.ID.String())},
+				{Key: "event-type", Value: []byte(e.Type)},
+			},
+			Time: e.CreatedAt,
+		}
+	}
+	return p.producer.Produce(ctx, p.topic, msgs)
+}
@@ -0,0 +1,137 @@
+// Package ledger holds the rules for applying an operation to a wallet:
+// which statuses accept operations, when funds suffice and how balances
+// and promo credits move. Repositories store the outcome; they don't
+// decide it.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"wallet-service/internal/models"
+)
+
+var (
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrUnknownOperationType = errors.New("unknown operation type")
+	ErrWalletFrozen         = errors.New("wallet is frozen")
+	ErrWalletClosed         = errors.New("wallet is closed")
+
+	ErrHistoryGap      = errors.New("wallet history has a gap")
+	ErrHistoryMismatch = errors.New("wallet history doesn't add up")
+)
+
+// Credits and Debits are the operations that add to and take from a
+// balance, as Apply applies them. Opening balances of imported wallets
+// count as credits.
+var (
+	Credits = []models.OperationType{
+		models.OperationTypeDeposit, models.OperationTypeReward, models.OperationTypeReversalCredit,
+		models.OperationTypePromoCredit, models.OperationTypeOpeningBalance,
+	}
+	Debits = []models.OperationType{
+		models.OperationTypeWithdraw, models.OperationTypeReversalDebit, models.OperationTypePromoExpiry,
+	}
+)
+
+// Change is the outcome of an operation on a wallet.
+type Change struct {
+	// Balance and PromoBalance are the wallet's balances after the
+	// operation.
+	Balance      int64
+	PromoBalance int64
+	// Amount is the amount applied, which for promo expiries is capped at
+	// the promo balance left.
+	Amount int64
+	// PromoSpent is the promo credit a withdrawal used up, to be consumed
+	// oldest first.
+	PromoSpent int64
+}
+
+// Apply works out the change amount of operation makes to w, or why w
+// doesn't accept it. Holds on w's balance can't be withdrawn; its credit
+// limit can, taking the balance negative.
+func Apply(w models.Wallet, amount int64, operation models.OperationType) (Change, error) {
+	switch w.Status {
+	case models.WalletStatusFrozen:
+		return Change{}, ErrWalletFrozen
+	case models.WalletStatusClosed:
+		return Change{}, ErrWalletClosed
+	}
+
+	c := Change{Balance: w.Balance, PromoBalance: w.PromoBalance, Amount: amount}
+	switch operation {
+	case models.OperationTypeWithdraw:
+		if w.AvailableBalance() < amount {
+			return Change{}, ErrInsufficientFunds
+		}
+		c.Balance -= amount
+		c.PromoBalance = max(c.PromoBalance-amount, 0)
+		c.PromoSpent = w.PromoBalance - c.PromoBalance
+	case models.OperationTypeReversalDebit:
+		if w.AvailableBalance() < amount {
+			return Change{}, ErrInsufficientFunds
+		}
+		c.Balance -= amount
+		c.PromoBalance = min(c.PromoBalance, max(c.Balance, 0))
+	case models.OperationTypeDeposit, models.OperationTypeReward, models.OperationTypeReversalCredit:
+		c.Balance += amount
+	case models.OperationTypePromoCredit:
+		c.Balance += amount
+		c.PromoBalance += amount
+	case models.OperationTypePromoExpiry:
+		c.Amount = min(amount, w.PromoBalance)
+		c.Balance -= c.Amount
+		c.PromoBalance -= c.Amount
+	default:
+		return Change{}, ErrUnknownOperationType
+	}
+	return c, nil
+}
+
+// Hold checks that amount of w's balance can be held: w must accept
+// operations and have at least amount available beyond its existing holds.
+func Hold(w models.Wallet, amount int64) error {
+	switch w.Status {
+	case models.WalletStatusFrozen:
+		return ErrWalletFrozen
+	case models.WalletStatusClosed:
+		return ErrWalletClosed
+	}
+	if w.AvailableBalance() < amount {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// Replay applies versions, the history of a wallet following s, to s and
+// returns the state they add up to. Without a snapshot, history starts
+// from the zero state of the wallet's creation at version 1, which imports
+// store as an opening balance. Each version must follow the one before and
+// record the balance its operation gives; statuses and holds aren't part
+// of the history and don't affect it. Nor are credit limits, which may
+// have changed since: a version may take the balance as far below zero as
+// it records.
+func Replay(s models.WalletSnapshot, versions []models.WalletVersion) (models.WalletSnapshot, error) {
+	for _, v := range versions {
+		if v.Version == 1 && s.Version == 1 {
+			if v.OperationType == models.OperationTypeOpeningBalance {
+				s.Balance = v.Amount
+			}
+			continue
+		}
+		if v.Version != s.Version+1 {
+			return s, fmt.Errorf("%w: version %d follows %d", ErrHistoryGap, v.Version, s.Version)
+		}
+		w := models.Wallet{Status: models.WalletStatusActive, Balance: s.Balance, PromoBalance: s.PromoBalance,
+			CreditLimit: max(-v.Balance, 0)}
+		c, err := Apply(w, v.Amount, v.OperationType)
+		if err != nil {
+			return s, fmt.Errorf("version %d: %w", v.Version, err)
+		}
+		if c.Balance != v.Balance {
+			return s, fmt.Errorf("%w: version %d records balance %d, replay gives %d", ErrHistoryMismatch, v.Version, v.Balance, c.Balance)
+		}
+		s.Version, s.Balance, s.PromoBalance = v.Version, c.Balance, c.PromoBalance
+	}
+	return s, nil
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"testing"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	active := models.Wallet{Status: models.WalletStatusActive, Balance: 100, PromoBalance: 30, HeldBalance: 20}
+
+	tests := []struct {
+		name      string
+		wallet    models.Wallet
+		amount    int64
+		operation models.OperationType
+		want      Change
+		err       error
+	}{
+		{"deposit", active, 50, models.OperationTypeDeposit, Change{Balance: 150, PromoBalance: 30, Amount: 50}, nil},
+		{"withdraw spends promo first", active, 40, models.OperationTypeWithdraw,
+			Change{Balance: 60, PromoBalance: 0, Amount: 40, PromoSpent: 30}, nil},
+		{"withdraw can't touch holds", active, 81, models.OperationTypeWithdraw, Change{}, ErrInsufficientFunds},
+		{"reversal keeps promo within balance", active, 75, models.OperationTypeReversalDebit,
+			Change{Balance: 25, PromoBalance: 25, Amount: 75}, nil},
+		{"promo credit", active, 10, models.OperationTypePromoCredit, Change{Balance: 110, PromoBalance: 40, Amount: 10}, nil},
+		{"promo expiry is capped", active, 50, models.OperationTypePromoExpiry, Change{Balance: 70, PromoBalance: 0, Amount: 30}, nil},
+		{"withdraw on credit", models.Wallet{Status: models.WalletStatusActive, Balance: 100, PromoBalance: 30, CreditLimit: 50},
+			150, models.OperationTypeWithdraw, Change{Balance: -50, PromoBalance: 0, Amount: 150, PromoSpent: 30}, nil},
+		{"withdraw past the credit limit", models.Wallet{Status: models.WalletStatusActive, Balance: 100, CreditLimit: 50},
+			151, models.OperationTypeWithdraw, Change{}, ErrInsufficientFunds},
+		{"reversal on credit drops promo", models.Wallet{Status: models.WalletStatusActive, Balance: 100, PromoBalance: 30, CreditLimit: 50},
+			120, models.OperationTypeReversalDebit, Change{Balance: -20, PromoBalance: 0, Amount: 120}, nil},
+		{"frozen", models.Wallet{Status: models.WalletStatusFrozen, Balance: 100}, 1, models.OperationTypeDeposit, Change{}, ErrWalletFrozen},
+		{"closed", models.Wallet{Status: models.WalletStatusClosed}, 1, models.OperationTypeDeposit, Change{}, ErrWalletClosed},
+		{"unknown", active, 1, models.OperationTypeCreate, Change{}, ErrUnknownOperationType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.wallet, tt.amount, tt.operation)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreditsAndDebits(t *testing.T) {
+	active := models.Wallet{Status: models.WalletStatusActive, Balance: 100, PromoBalance: 100}
+
+	for _, op := range Credits {
+		if op == models.OperationTypeOpeningBalance {
+			// Opening balances are written by imports, not applied.
+			continue
+		}
+		c, err := Apply(active, 10, op)
+		assert.NoError(t, err, op)
+		assert.Equal(t, int64(110), c.Balance, op)
+	}
+	for _, op := range Debits {
+		c, err := Apply(active, 10, op)
+		assert.NoError(t, err, op)
+		assert.Equal(t, int64(90), c.Balance, op)
+	}
+}
+
+func TestHold(t *testing.T) {
+	active := models.Wallet{Status: models.WalletStatusActive, Balance: 100, HeldBalance: 20}
+
+	assert.NoError(t, Hold(active, 80))
+	assert.ErrorIs(t, Hold(active, 81), ErrInsufficientFunds)
+	assert.ErrorIs(t, Hold(models.Wallet{Status: models.WalletStatusFrozen, Balance: 100}, 1), ErrWalletFrozen)
+	assert.ErrorIs(t, Hold(models.Wallet{Status: models.WalletStatusClosed, Balance: 100}, 1), ErrWalletClosed)
+}
+
+func TestReplay(t *testing.T) {
+	id := uuid.New()
+	version := func(n int, balance int64, op models.OperationType, amount int64) models.WalletVersion {
+		return models.WalletVersion{WalletID: id, Version: n, Balance: balance, OperationType: op, Amount: amount}
+	}
+	start := models.WalletSnapshot{WalletID: id, Version: 1}
+
+	t.Run("from creation", func(t *testing.T) {
+		got, err := Replay(start, []models.WalletVersion{
+			version(1, 0, models.OperationTypeCreate, 0),
+			version(2, 100, models.OperationTypeDeposit, 100),
+			version(3, 130, models.OperationTypePromoCredit, 30),
+			version(4, 90, models.OperationTypeWithdraw, 40),
+			version(5, 90, models.OperationTypePromoExpiry, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletSnapshot{WalletID: id, Version: 5, Balance: 90}, got)
+	})
+
+	t.Run("opening balance", func(t *testing.T) {
+		got, err := Replay(start, []models.WalletVersion{
+			version(1, 500, models.OperationTypeOpeningBalance, 500),
+			version(2, 450, models.OperationTypeWithdraw, 50),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletSnapshot{WalletID: id, Version: 2, Balance: 450}, got)
+	})
+
+	t.Run("from a snapshot", func(t *testing.T) {
+		snapshot := models.WalletSnapshot{WalletID: id, Version: 7, Balance: 70, PromoBalance: 20}
+		got, err := Replay(snapshot, []models.WalletVersion{version(8, 40, models.OperationTypeWithdraw, 30)})
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletSnapshot{WalletID: id, Version: 8, Balance: 40}, got)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		_, err := Replay(start, []models.WalletVersion{version(3, 10, models.OperationTypeDeposit, 10)})
+		assert.ErrorIs(t, err, ErrHistoryGap)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := Replay(start, []models.WalletVersion{version(2, 11, models.OperationTypeDeposit, 10)})
+		assert.ErrorIs(t, err, ErrHistoryMismatch)
+	})
+
+	t.Run("on credit", func(t *testing.T) {
+		got, err := Replay(start, []models.WalletVersion{
+			version(2, -30, models.OperationTypeWithdraw, 30),
+			version(3, 20, models.OperationTypeDeposit, 50),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletSnapshot{WalletID: id, Version: 3, Balance: 20}, got)
+	})
+	t.Run("overdrawn", func(t *testing.T) {
+		_, err := Replay(start, []models.WalletVersion{version(2, 0, models.OperationTypeWithdraw, 10)})
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+	})
+}
@@ -0,0 +1,192 @@
+// Package lifecycle starts and stops the long-lived components of the
+// service in a fixed order: components are started from the database up and
+// stopped from the listeners down, so that nothing is torn down while
+// something above it may still use it.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Phase groups components that start and stop together. Phases are
+// stopped in the order they are declared and started in reverse.
+type Phase int
+
+const (
+	// PhaseListeners accept new work: HTTP and gRPC servers.
+	PhaseListeners Phase = iota
+	// PhaseWorkers run work in the background: schedulers, jobs, monitors.
+	PhaseWorkers
+	// PhaseRelays deliver what the workers produced, e.g. an outbox relay,
+	// and so stop only once the workers are done.
+	PhaseRelays
+	// PhaseDatabase holds connection pools.
+	PhaseDatabase
+)
+
+var phaseNames = [...]string{"listeners", "workers", "relays", "database"}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// Component is one part of the service under lifecycle control. Start is
+// optional, for components that were running before they were added. Stop
+// is given Timeout, or whatever remains of the shutdown deadline when zero.
+type Component struct {
+	Name    string
+	Phase   Phase
+	Timeout time.Duration
+	Start   func(ctx context.Context) error
+	Stop    func(ctx context.Context) error
+}
+
+// Func adapts a stop function that neither takes a context nor fails. It
+// keeps running in the background if the component's timeout expires.
+func Func(stop func()) func(ctx context.Context) error {
+	return func(context.Context) error {
+		stop()
+		return nil
+	}
+}
+
+// Close adapts an io.Closer such as *sql.DB.
+func Close(c io.Closer) func(ctx context.Context) error {
+	return func(context.Context) error {
+		return c.Close()
+	}
+}
+
+// Manager starts and stops components phase by phase.
+type Manager struct {
+	log *slog.Logger
+
+	mu         sync.Mutex
+	components []Component
+	started    []bool
+}
+
+func New(log *slog.Logger) *Manager {
+	return &Manager{log: log}
+}
+
+// Add registers c. A component without Start counts as started.
+func (m *Manager) Add(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, c)
+	m.started = append(m.started, c.Start == nil)
+}
+
+// Start starts the components phase by phase, from the database up, in
+// the order they were added. If one fails, the components already started
+// are stopped again and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for phase := PhaseDatabase; phase >= PhaseListeners; phase-- {
+		for i, c := range m.components {
+			if c.Phase != phase || m.started[i] {
+				continue
+			}
+			if err := c.Start(ctx); err != nil {
+				m.log.Error("component failed to start", slog.String("component", c.Name), slog.String("phase", phase.String()),
+					slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				m.shutdown(context.Background())
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+			m.started[i] = true
+			m.log.Info("component started", slog.String("component", c.Name), slog.String("phase", phase.String()))
+		}
+	}
+	return nil
+}
+
+// Shutdown stops the started components phase by phase, from the listeners
+// down. Components of one phase stop concurrently, each within its timeout
+// and the deadline of ctx; a component that doesn't stop in time is
+// abandoned so that the next phase still runs. All failures are returned
+// together.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shutdown(ctx)
+}
+
+func (m *Manager) shutdown(ctx context.Context) error {
+	begin := time.Now()
+	var errs []error
+	for phase := PhaseListeners; phase <= PhaseDatabase; phase++ {
+		var stopping []int
+		for i, c := range m.components {
+			if c.Phase == phase && m.started[i] {
+				stopping = append(stopping, i)
+			}
+		}
+		if len(stopping) == 0 {
+			continue
+		}
+
+		m.log.Info("stopping phase", slog.String("phase", phase.String()), slog.Int("components", len(stopping)))
+		results := make([]error, len(stopping))
+		var wg sync.WaitGroup
+		for n, i := range stopping {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[n] = m.stop(ctx, m.components[i])
+			}()
+		}
+		wg.Wait()
+
+		for n, i := range stopping {
+			m.started[i] = false
+			if results[n] != nil {
+				errs = append(errs, results[n])
+			}
+		}
+	}
+	m.log.Info("shutdown complete", slog.Duration("elapsed", time.Since(begin)), slog.Int("errors", len(errs)))
+	return errors.Join(errs...)
+}
+
+// stop runs c.Stop and waits for it at most until c's timeout or the
+// deadline of ctx.
+func (m *Manager) stop(ctx context.Context, c Component) error {
+	log := m.log.With(slog.String("component", c.Name), slog.String("phase", c.Phase.String()))
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	begin := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		log.Error("component did not stop cleanly", slog.Duration("elapsed", time.Since(begin)),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to stop %s: %w", c.Name, err)
+	}
+	log.Info("component stopped", slog.Duration("elapsed", time.Since(begin)))
+	return nil
+}
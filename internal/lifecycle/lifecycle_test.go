@@ -0,0 +1,112 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) component(name string, phase Phase) Component {
+	return Component{
+		Name:  name,
+		Phase: phase,
+		Start: func(context.Context) error {
+			r.record("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestManager_Order(t *testing.T) {
+	rec := &recorder{}
+	m := New(slog.Default())
+	m.Add(rec.component("http", PhaseListeners))
+	m.Add(rec.component("db", PhaseDatabase))
+	m.Add(rec.component("relay", PhaseRelays))
+	m.Add(rec.component("jobs", PhaseWorkers))
+
+	require.NoError(t, m.Start(context.Background()))
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{
+		"start db", "start relay", "start jobs", "start http",
+		"stop http", "stop jobs", "stop relay", "stop db",
+	}, rec.calls)
+}
+
+func TestManager_TimeoutMovesOn(t *testing.T) {
+	rec := &recorder{}
+	m := New(slog.Default())
+	m.Add(Component{
+		Name:    "stuck",
+		Phase:   PhaseWorkers,
+		Timeout: 10 * time.Millisecond,
+		Stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		},
+	})
+	m.Add(rec.component("db", PhaseDatabase))
+	require.NoError(t, m.Start(context.Background()))
+
+	begin := time.Now()
+	err := m.Shutdown(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stuck")
+	assert.Less(t, time.Since(begin), 50*time.Millisecond)
+	assert.Equal(t, []string{"start db", "stop db"}, rec.calls)
+}
+
+func TestManager_StartFailureStopsStarted(t *testing.T) {
+	rec := &recorder{}
+	m := New(slog.Default())
+	m.Add(rec.component("db", PhaseDatabase))
+	m.Add(Component{
+		Name:  "http",
+		Phase: PhaseListeners,
+		Start: func(context.Context) error { return errors.New("address in use") },
+		Stop: func(context.Context) error {
+			rec.record("stop http")
+			return nil
+		},
+	})
+
+	err := m.Start(context.Background())
+
+	assert.ErrorContains(t, err, "failed to start http: address in use")
+	assert.Equal(t, []string{"start db", "stop db"}, rec.calls)
+}
+
+func TestManager_ShutdownIsIdempotent(t *testing.T) {
+	rec := &recorder{}
+	m := New(slog.Default())
+	m.Add(Component{Name: "pool", Phase: PhaseDatabase, Stop: Func(func() { rec.record("close pool") })})
+
+	require.NoError(t, m.Shutdown(context.Background()))
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"close pool"}, rec.calls)
+}
@@ -0,0 +1,137 @@
+package limits
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrLimitExceeded = errors.New("operation limit exceeded")
+
+// DefaultScope applies to callers without a recognised API key.
+const DefaultScope = "default"
+
+// Limit caps a single operation amount and the UTC-daily total of a scope.
+// Zero means unlimited.
+type Limit struct {
+	MaxAmount  int64 `json:"maxAmount"`
+	DailyTotal int64 `json:"dailyTotal"`
+}
+
+// Config is the limits file: limits per scope, and SHA-256 hex digests of
+// API keys mapped to the scope they belong to, so the file holds no
+// usable secrets.
+type Config struct {
+	Scopes map[string]Limit  `json:"scopes"`
+	Keys   map[string]string `json:"keys"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid limits file: %w", err)
+	}
+	for hash, scope := range cfg.Keys {
+		if _, ok := cfg.Scopes[scope]; !ok {
+			return Config{}, fmt.Errorf("limits file: key %.8s… refers to unknown scope %q", hash, scope)
+		}
+	}
+	return cfg, nil
+}
+
+type usage struct {
+	day   string
+	total int64
+}
+
+// Limiter enforces Config. Daily totals are tracked in memory, so with
+// several instances each one enforces the daily total on its own.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*usage
+}
+
+func NewLimiter(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, now: time.Now, usage: make(map[string]*usage)}
+}
+
+// ScopeForKey returns the scope of an API key, or DefaultScope.
+func (l *Limiter) ScopeForKey(key string) string {
+	if key == "" {
+		return DefaultScope
+	}
+	sum := sha256.Sum256([]byte(key))
+	if scope, ok := l.cfg.Keys[hex.EncodeToString(sum[:])]; ok {
+		return scope
+	}
+	return DefaultScope
+}
+
+// Reserve checks amount against the scope's limits and counts it towards
+// the daily total. Call the returned release func if the operation doesn't
+// go through, so it stops counting.
+func (l *Limiter) Reserve(scope string, amount int64) (release func(), err error) {
+	limit, ok := l.cfg.Scopes[scope]
+	if !ok {
+		return func() {}, nil
+	}
+	if limit.MaxAmount > 0 && amount > limit.MaxAmount {
+		return nil, fmt.Errorf("%w: amount %d above %d for scope %q", ErrLimitExceeded, amount, limit.MaxAmount, scope)
+	}
+	if limit.DailyTotal <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	day := l.now().UTC().Format(time.DateOnly)
+	u, ok := l.usage[scope]
+	if !ok || u.day != day {
+		u = &usage{day: day}
+		l.usage[scope] = u
+	}
+	if u.total+amount > limit.DailyTotal {
+		return nil, fmt.Errorf("%w: daily total %d for scope %q", ErrLimitExceeded, limit.DailyTotal, scope)
+	}
+	u.total += amount
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if u.day == day {
+				u.total -= amount
+			}
+		})
+	}, nil
+}
+
+type scopeKey struct{}
+
+// WithScope stores the caller's scope in ctx.
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom returns the scope stored by WithScope, or DefaultScope.
+func ScopeFrom(ctx context.Context) string {
+	if scope, ok := ctx.Value(scopeKey{}).(string); ok {
+		return scope
+	}
+	return DefaultScope
+}
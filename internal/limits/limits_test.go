@@ -0,0 +1,79 @@
+package limits
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLimiter_MaxAmount(t *testing.T) {
+	l := NewLimiter(Config{Scopes: map[string]Limit{"payroll": {MaxAmount: 100}}})
+
+	_, err := l.Reserve("payroll", 101)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	_, err = l.Reserve("payroll", 100)
+	assert.NoError(t, err)
+
+	_, err = l.Reserve("unknown", 1_000_000)
+	assert.NoError(t, err)
+}
+
+func TestLimiter_DailyTotal(t *testing.T) {
+	l := NewLimiter(Config{Scopes: map[string]Limit{DefaultScope: {DailyTotal: 100}}})
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	_, err := l.Reserve(DefaultScope, 60)
+	require.NoError(t, err)
+	release, err := l.Reserve(DefaultScope, 40)
+	require.NoError(t, err)
+
+	_, err = l.Reserve(DefaultScope, 1)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	release()
+	release()
+	_, err = l.Reserve(DefaultScope, 40)
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = l.Reserve(DefaultScope, 100)
+	assert.NoError(t, err)
+}
+
+func TestLimiter_ScopeForKey(t *testing.T) {
+	l := NewLimiter(Config{
+		Scopes: map[string]Limit{"batch": {MaxAmount: 1}},
+		Keys:   map[string]string{hashKey("s3cret"): "batch"},
+	})
+
+	assert.Equal(t, "batch", l.ScopeForKey("s3cret"))
+	assert.Equal(t, DefaultScope, l.ScopeForKey("other"))
+	assert.Equal(t, DefaultScope, l.ScopeForKey(""))
+}
+
+func TestLoadConfig_UnknownScope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"scopes":{},"keys":{"abc":"batch"}}`), 0o600))
+
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, "unknown scope")
+}
+
+func TestScopeContext(t *testing.T) {
+	assert.Equal(t, DefaultScope, ScopeFrom(context.Background()))
+	assert.Equal(t, "batch", ScopeFrom(WithScope(context.Background(), "batch")))
+}
@@ -0,0 +1,199 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/internal/scheduler"
+)
+
+var (
+	ErrOutsideWindow = errors.New("destructive action outside maintenance window")
+	ErrInvalidWindow = errors.New("invalid maintenance window")
+)
+
+// Policy decides what happens to a destructive action requested outside a
+// window.
+type Policy string
+
+const (
+	PolicyReject Policy = "reject"
+	PolicyQueue  Policy = "queue"
+)
+
+// Window is a one-off maintenance window declared through the admin API.
+type Window struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Recurring is a window opening on Schedule and lasting Duration, written
+// as "<schedule>/<duration>", e.g. "daily 02:00/2h".
+type Recurring struct {
+	Spec     string             `json:"spec"`
+	Schedule scheduler.Schedule `json:"-"`
+	Duration time.Duration      `json:"duration"`
+}
+
+func ParseRecurring(spec string) (Recurring, error) {
+	schedSpec, dur, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Recurring{}, fmt.Errorf("%w: %q: expected <schedule>/<duration>", ErrInvalidWindow, spec)
+	}
+	sched, err := scheduler.Parse(strings.TrimSpace(schedSpec))
+	if err != nil {
+		return Recurring{}, fmt.Errorf("%w: %q: %v", ErrInvalidWindow, spec, err)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(dur))
+	if err != nil || d <= 0 {
+		return Recurring{}, fmt.Errorf("%w: %q: bad duration", ErrInvalidWindow, spec)
+	}
+	return Recurring{Spec: spec, Schedule: sched, Duration: d}, nil
+}
+
+// opening returns the occurrence containing t, or the next one after t.
+func (r Recurring) opening(t time.Time) Window {
+	start := r.Schedule.Next(t.Add(-r.Duration))
+	return Window{Start: start, End: start.Add(r.Duration), Reason: r.Spec}
+}
+
+// Gate lets destructive actions run only inside maintenance windows. A gate
+// that isn't Enforced allows everything.
+type Gate struct {
+	enforced  bool
+	policy    Policy
+	recurring []Recurring
+	now       func() time.Time
+
+	mu    sync.Mutex
+	adhoc []Window
+}
+
+func NewGate(enforced bool, policy Policy, recurring []Recurring) *Gate {
+	if policy == "" {
+		policy = PolicyReject
+	}
+	return &Gate{enforced: enforced, policy: policy, recurring: recurring, now: time.Now}
+}
+
+func (g *Gate) Policy() Policy {
+	return g.policy
+}
+
+// Schedule declares a one-off window. Windows that already ended are
+// rejected.
+func (g *Gate) Schedule(w Window) error {
+	if !w.End.After(w.Start) || !w.End.After(g.now()) {
+		return ErrInvalidWindow
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.adhoc = append(g.adhoc, w)
+	sort.Slice(g.adhoc, func(i, j int) bool { return g.adhoc[i].Start.Before(g.adhoc[j].Start) })
+	return nil
+}
+
+// current returns the window t falls into, and otherwise the next one to
+// open; ok is false when no window will ever open.
+func (g *Gate) current(t time.Time) (w Window, open, ok bool) {
+	g.mu.Lock()
+	candidates := make([]Window, 0, len(g.adhoc)+len(g.recurring))
+	for _, a := range g.adhoc {
+		if a.End.After(t) {
+			candidates = append(candidates, a)
+		}
+	}
+	g.mu.Unlock()
+	for _, r := range g.recurring {
+		candidates = append(candidates, r.opening(t))
+	}
+
+	for _, c := range candidates {
+		if !c.Start.After(t) && c.End.After(t) {
+			return c, true, true
+		}
+		if !ok || c.Start.Before(w.Start) {
+			w, ok = c, true
+		}
+	}
+	return w, false, ok
+}
+
+// Allow returns nil when action may run right now.
+func (g *Gate) Allow(action string) error {
+	if !g.enforced {
+		return nil
+	}
+	now := g.now()
+	next, open, ok := g.current(now)
+	if open {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s (no window scheduled)", ErrOutsideWindow, action)
+	}
+	return fmt.Errorf("%w: %s (next window opens %s)", ErrOutsideWindow, action, next.Start.UTC().Format(time.RFC3339))
+}
+
+// Await blocks until action may run. Under PolicyReject it doesn't wait and
+// returns Allow's verdict; under PolicyQueue it waits for the next window
+// (or ctx) and fails only when no window is scheduled at all.
+func (g *Gate) Await(ctx context.Context, action string) error {
+	for {
+		err := g.Allow(action)
+		if err == nil || g.policy != PolicyQueue {
+			return err
+		}
+		next, _, ok := g.current(g.now())
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(time.Until(next.Start))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Status describes the gate for the admin API.
+type Status struct {
+	Enforced  bool        `json:"enforced"`
+	Policy    Policy      `json:"policy"`
+	Open      bool        `json:"open"`
+	Next      *Window     `json:"next,omitempty"`
+	Recurring []Recurring `json:"recurring"`
+	Scheduled []Window    `json:"scheduled"`
+}
+
+func (g *Gate) Status() Status {
+	now := g.now()
+	w, open, ok := g.current(now)
+	st := Status{
+		Enforced:  g.enforced,
+		Policy:    g.policy,
+		Open:      open || !g.enforced,
+		Recurring: g.recurring,
+		Scheduled: []Window{},
+	}
+	if ok {
+		st.Next = &w
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, a := range g.adhoc {
+		if a.End.After(now) {
+			st.Scheduled = append(st.Scheduled, a)
+		}
+	}
+	return st
+}
@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurring(t *testing.T) {
+	r, err := ParseRecurring("daily 02:00/2h")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, r.Duration)
+
+	for _, spec := range []string{"daily 02:00", "daily 25:00/1h", "daily 02:00/-1h"} {
+		_, err := ParseRecurring(spec)
+		assert.ErrorIs(t, err, ErrInvalidWindow, spec)
+	}
+}
+
+func TestGate_Recurring(t *testing.T) {
+	r, err := ParseRecurring("daily 02:00/2h")
+	require.NoError(t, err)
+	g := NewGate(true, PolicyReject, []Recurring{r})
+
+	g.now = func() time.Time { return time.Date(2024, 5, 1, 3, 30, 0, 0, time.UTC) }
+	assert.NoError(t, g.Allow("purge"))
+
+	g.now = func() time.Time { return time.Date(2024, 5, 1, 4, 0, 0, 0, time.UTC) }
+	err = g.Allow("purge")
+	assert.ErrorIs(t, err, ErrOutsideWindow)
+	assert.ErrorContains(t, err, "2024-05-02T02:00:00Z")
+}
+
+func TestGate_AdHoc(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGate(true, PolicyReject, nil)
+	g.now = func() time.Time { return now }
+
+	assert.ErrorContains(t, g.Allow("purge"), "no window scheduled")
+	assert.ErrorIs(t, g.Schedule(Window{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}), ErrInvalidWindow)
+
+	require.NoError(t, g.Schedule(Window{Start: now.Add(-time.Minute), End: now.Add(time.Hour), Reason: "release"}))
+	assert.NoError(t, g.Allow("purge"))
+	assert.True(t, g.Status().Open)
+}
+
+func TestGate_NotEnforced(t *testing.T) {
+	assert.NoError(t, NewGate(false, PolicyReject, nil).Allow("purge"))
+}
+
+func TestGate_AwaitQueues(t *testing.T) {
+	g := NewGate(true, PolicyQueue, nil)
+	require.NoError(t, g.Schedule(Window{Start: time.Now().Add(30 * time.Millisecond), End: time.Now().Add(time.Hour)}))
+
+	start := time.Now()
+	require.NoError(t, g.Await(context.Background(), "purge"))
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g2 := NewGate(true, PolicyQueue, nil)
+	require.NoError(t, g2.Schedule(Window{Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour)}))
+	assert.ErrorIs(t, g2.Await(ctx, "purge"), context.Canceled)
+}
@@ -1,22 +1,152 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: ./internal/service/Irepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/service/Irepository.go -destination=./internal/mock/mock_repository/mock_repository.go -package=mockrepository
+//
 
-// Package mock_repository is a generated GoMock package.
+// Package mockrepository is a generated GoMock package.
 package mockrepository
 
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	models "wallet-service/internal/models"
+	repository "wallet-service/internal/repository"
 
-	gomock "go.uber.org/mock/gomock"
 	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
 )
 
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+	isgomock struct{}
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// Outbox mocks base method.
+func (m *MockUnitOfWork) Outbox() repository.OutboxStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Outbox")
+	ret0, _ := ret[0].(repository.OutboxStore)
+	return ret0
+}
+
+// Outbox indicates an expected call of Outbox.
+func (mr *MockUnitOfWorkMockRecorder) Outbox() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Outbox", reflect.TypeOf((*MockUnitOfWork)(nil).Outbox))
+}
+
+// Promos mocks base method.
+func (m *MockUnitOfWork) Promos() repository.PromoStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Promos")
+	ret0, _ := ret[0].(repository.PromoStore)
+	return ret0
+}
+
+// Promos indicates an expected call of Promos.
+func (mr *MockUnitOfWorkMockRecorder) Promos() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Promos", reflect.TypeOf((*MockUnitOfWork)(nil).Promos))
+}
+
+// Snapshots mocks base method.
+func (m *MockUnitOfWork) Snapshots() repository.SnapshotStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshots")
+	ret0, _ := ret[0].(repository.SnapshotStore)
+	return ret0
+}
+
+// Snapshots indicates an expected call of Snapshots.
+func (mr *MockUnitOfWorkMockRecorder) Snapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshots", reflect.TypeOf((*MockUnitOfWork)(nil).Snapshots))
+}
+
+// Versions mocks base method.
+func (m *MockUnitOfWork) Versions() repository.VersionStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions")
+	ret0, _ := ret[0].(repository.VersionStore)
+	return ret0
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *MockUnitOfWorkMockRecorder) Versions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*MockUnitOfWork)(nil).Versions))
+}
+
+// Wallets mocks base method.
+func (m *MockUnitOfWork) Wallets() repository.WalletStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wallets")
+	ret0, _ := ret[0].(repository.WalletStore)
+	return ret0
+}
+
+// Wallets indicates an expected call of Wallets.
+func (mr *MockUnitOfWorkMockRecorder) Wallets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wallets", reflect.TypeOf((*MockUnitOfWork)(nil).Wallets))
+}
+
+// WithinTx mocks base method.
+func (m *MockUnitOfWork) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockUnitOfWorkMockRecorder) WithinTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithinTx), ctx, fn)
+}
+
+// WithinWalletTx mocks base method.
+func (m *MockUnitOfWork) WithinWalletTx(ctx context.Context, walletID uuid.UUID, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinWalletTx", ctx, walletID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinWalletTx indicates an expected call of WithinWalletTx.
+func (mr *MockUnitOfWorkMockRecorder) WithinWalletTx(ctx, walletID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinWalletTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithinWalletTx), ctx, walletID, fn)
+}
+
 // MockWalletRepository is a mock of WalletRepository interface.
 type MockWalletRepository struct {
 	ctrl     *gomock.Controller
 	recorder *MockWalletRepositoryMockRecorder
+	isgomock struct{}
 }
 
 // MockWalletRepositoryMockRecorder is the mock recorder for MockWalletRepository.
@@ -36,47 +166,1339 @@ func (m *MockWalletRepository) EXPECT() *MockWalletRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AccrueReward mocks base method.
+func (m *MockWalletRepository) AccrueReward(ctx context.Context, accrual models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccrueReward", ctx, accrual)
+	ret0, _ := ret[0].(*models.RewardAccrual)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AccrueReward indicates an expected call of AccrueReward.
+func (mr *MockWalletRepositoryMockRecorder) AccrueReward(ctx, accrual any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrueReward", reflect.TypeOf((*MockWalletRepository)(nil).AccrueReward), ctx, accrual)
+}
+
+// AddTransactionNote mocks base method.
+func (m *MockWalletRepository) AddTransactionNote(ctx context.Context, n models.TransactionNote) (*models.TransactionNote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTransactionNote", ctx, n)
+	ret0, _ := ret[0].(*models.TransactionNote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTransactionNote indicates an expected call of AddTransactionNote.
+func (mr *MockWalletRepositoryMockRecorder) AddTransactionNote(ctx, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransactionNote", reflect.TypeOf((*MockWalletRepository)(nil).AddTransactionNote), ctx, n)
+}
+
+// AppendAuditEvent mocks base method.
+func (m *MockWalletRepository) AppendAuditEvent(ctx context.Context, e models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEvent", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendAuditEvent indicates an expected call of AppendAuditEvent.
+func (mr *MockWalletRepositoryMockRecorder) AppendAuditEvent(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEvent", reflect.TypeOf((*MockWalletRepository)(nil).AppendAuditEvent), ctx, e)
+}
+
+// ApplyAtomic mocks base method.
+func (m *MockWalletRepository) ApplyAtomic(ctx context.Context, steps []models.WalletOperation) ([]models.AtomicStepResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAtomic", ctx, steps)
+	ret0, _ := ret[0].([]models.AtomicStepResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyAtomic indicates an expected call of ApplyAtomic.
+func (mr *MockWalletRepositoryMockRecorder) ApplyAtomic(ctx, steps any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAtomic", reflect.TypeOf((*MockWalletRepository)(nil).ApplyAtomic), ctx, steps)
+}
+
+// ArchiveTransactions mocks base method.
+func (m *MockWalletRepository) ArchiveTransactions(ctx context.Context, walletID uuid.UUID, afterVersion, limit int) ([]models.ArchivedTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveTransactions", ctx, walletID, afterVersion, limit)
+	ret0, _ := ret[0].([]models.ArchivedTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveTransactions indicates an expected call of ArchiveTransactions.
+func (mr *MockWalletRepositoryMockRecorder) ArchiveTransactions(ctx, walletID, afterVersion, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveTransactions", reflect.TypeOf((*MockWalletRepository)(nil).ArchiveTransactions), ctx, walletID, afterVersion, limit)
+}
+
+// BalanceSummary mocks base method.
+func (m *MockWalletRepository) BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalanceSummary", ctx, from, to)
+	ret0, _ := ret[0].(*models.BalanceSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BalanceSummary indicates an expected call of BalanceSummary.
+func (mr *MockWalletRepositoryMockRecorder) BalanceSummary(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceSummary", reflect.TypeOf((*MockWalletRepository)(nil).BalanceSummary), ctx, from, to)
+}
+
+// CaptureHold mocks base method.
+func (m *MockWalletRepository) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, at time.Time) (*models.FundsHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, walletID, holdID, amount, at)
+	ret0, _ := ret[0].(*models.FundsHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockWalletRepositoryMockRecorder) CaptureHold(ctx, walletID, holdID, amount, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockWalletRepository)(nil).CaptureHold), ctx, walletID, holdID, amount, at)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockWalletRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockWalletRepositoryMockRecorder) ClaimWebhookDeliveries(ctx, now, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockWalletRepository)(nil).ClaimWebhookDeliveries), ctx, now, lease, limit)
+}
+
+// CloseDispute mocks base method.
+func (m *MockWalletRepository) CloseDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, note string, at time.Time) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseDispute", ctx, id, status, note, at)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseDispute indicates an expected call of CloseDispute.
+func (mr *MockWalletRepositoryMockRecorder) CloseDispute(ctx, id, status, note, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseDispute", reflect.TypeOf((*MockWalletRepository)(nil).CloseDispute), ctx, id, status, note, at)
+}
+
+// CloseWallet mocks base method.
+func (m *MockWalletRepository) CloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseWallet", ctx, id)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseWallet indicates an expected call of CloseWallet.
+func (mr *MockWalletRepositoryMockRecorder) CloseWallet(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseWallet", reflect.TypeOf((*MockWalletRepository)(nil).CloseWallet), ctx, id)
+}
+
+// CountWalletsToSetStatus mocks base method.
+func (m *MockWalletRepository) CountWalletsToSetStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountWalletsToSetStatus", ctx, f, status)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountWalletsToSetStatus indicates an expected call of CountWalletsToSetStatus.
+func (mr *MockWalletRepositoryMockRecorder) CountWalletsToSetStatus(ctx, f, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountWalletsToSetStatus", reflect.TypeOf((*MockWalletRepository)(nil).CountWalletsToSetStatus), ctx, f, status)
+}
+
+// CreateMandate mocks base method.
+func (m_2 *MockWalletRepository) CreateMandate(ctx context.Context, m models.Mandate) (*models.Mandate, error) {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "CreateMandate", ctx, m)
+	ret0, _ := ret[0].(*models.Mandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMandate indicates an expected call of CreateMandate.
+func (mr *MockWalletRepositoryMockRecorder) CreateMandate(ctx, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMandate", reflect.TypeOf((*MockWalletRepository)(nil).CreateMandate), ctx, m)
+}
+
 // CreateWallet mocks base method.
-func (m *MockWalletRepository) CreateWallet(arg0 context.Context, arg1 uuid.UUID) (*models.Wallet, error) {
+func (m *MockWalletRepository) CreateWallet(arg0 context.Context, arg1 uuid.UUID, arg2 models.CreateWalletRequest) (*models.Wallet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateWallet", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateWallet", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*models.Wallet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateWallet indicates an expected call of CreateWallet.
-func (mr *MockWalletRepositoryMockRecorder) CreateWallet(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockWalletRepositoryMockRecorder) CreateWallet(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWallet", reflect.TypeOf((*MockWalletRepository)(nil).CreateWallet), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWallet", reflect.TypeOf((*MockWalletRepository)(nil).CreateWallet), arg0, arg1, arg2)
 }
 
-// GetWallet mocks base method.
-func (m *MockWalletRepository) GetWallet(arg0 context.Context, arg1 uuid.UUID) (*models.Wallet, error) {
+// CreateWallets mocks base method.
+func (m *MockWalletRepository) CreateWallets(arg0 context.Context, arg1 []uuid.UUID, arg2 models.CreateWalletRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWallet", arg0, arg1)
-	ret0, _ := ret[0].(*models.Wallet)
+	ret := m.ctrl.Call(m, "CreateWallets", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWallets indicates an expected call of CreateWallets.
+func (mr *MockWalletRepositoryMockRecorder) CreateWallets(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWallets", reflect.TypeOf((*MockWalletRepository)(nil).CreateWallets), arg0, arg1, arg2)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWalletRepository) CreateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, s)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetWallet indicates an expected call of GetWallet.
-func (mr *MockWalletRepositoryMockRecorder) GetWallet(arg0, arg1 interface{}) *gomock.Call {
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) CreateWebhookSubscription(ctx, s any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWallet", reflect.TypeOf((*MockWalletRepository)(nil).GetWallet), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).CreateWebhookSubscription), ctx, s)
 }
 
-// UpdateWalletBalance mocks base method.
-func (m *MockWalletRepository) UpdateWalletBalance(arg0 context.Context, arg1 uuid.UUID, arg2 int64, arg3 models.OperationType) (*models.Wallet, error) {
+// CurrencyTotals mocks base method.
+func (m *MockWalletRepository) CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateWalletBalance", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "CurrencyTotals", ctx)
+	ret0, _ := ret[0].([]models.CurrencyTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CurrencyTotals indicates an expected call of CurrencyTotals.
+func (mr *MockWalletRepositoryMockRecorder) CurrencyTotals(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrencyTotals", reflect.TypeOf((*MockWalletRepository)(nil).CurrencyTotals), ctx)
+}
+
+// DebitMandate mocks base method.
+func (m *MockWalletRepository) DebitMandate(ctx context.Context, debit models.MandateDebit, periodStart time.Time) (*models.MandateDebit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebitMandate", ctx, debit, periodStart)
+	ret0, _ := ret[0].(*models.MandateDebit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebitMandate indicates an expected call of DebitMandate.
+func (mr *MockWalletRepositoryMockRecorder) DebitMandate(ctx, debit, periodStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebitMandate", reflect.TypeOf((*MockWalletRepository)(nil).DebitMandate), ctx, debit, periodStart)
+}
+
+// DeleteTransactions mocks base method.
+func (m *MockWalletRepository) DeleteTransactions(ctx context.Context, walletID uuid.UUID, throughVersion, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTransactions", ctx, walletID, throughVersion, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTransactions indicates an expected call of DeleteTransactions.
+func (mr *MockWalletRepositoryMockRecorder) DeleteTransactions(ctx, walletID, throughVersion, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransactions", reflect.TypeOf((*MockWalletRepository)(nil).DeleteTransactions), ctx, walletID, throughVersion, limit)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWalletRepository) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) DeleteWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// DueDisputes mocks base method.
+func (m *MockWalletRepository) DueDisputes(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DueDisputes", ctx, now, limit)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DueDisputes indicates an expected call of DueDisputes.
+func (mr *MockWalletRepositoryMockRecorder) DueDisputes(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DueDisputes", reflect.TypeOf((*MockWalletRepository)(nil).DueDisputes), ctx, now, limit)
+}
+
+// DuePromoCredits mocks base method.
+func (m *MockWalletRepository) DuePromoCredits(ctx context.Context, now time.Time, limit int) ([]models.PromoCredit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DuePromoCredits", ctx, now, limit)
+	ret0, _ := ret[0].([]models.PromoCredit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DuePromoCredits indicates an expected call of DuePromoCredits.
+func (mr *MockWalletRepositoryMockRecorder) DuePromoCredits(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DuePromoCredits", reflect.TypeOf((*MockWalletRepository)(nil).DuePromoCredits), ctx, now, limit)
+}
+
+// EnqueueWebhookEvent mocks base method.
+func (m *MockWalletRepository) EnqueueWebhookEvent(ctx context.Context, e models.WebhookEvent) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueWebhookEvent", ctx, e)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueWebhookEvent indicates an expected call of EnqueueWebhookEvent.
+func (mr *MockWalletRepositoryMockRecorder) EnqueueWebhookEvent(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueWebhookEvent", reflect.TypeOf((*MockWalletRepository)(nil).EnqueueWebhookEvent), ctx, e)
+}
+
+// ExpirePromoCredit mocks base method.
+func (m *MockWalletRepository) ExpirePromoCredit(ctx context.Context, id uuid.UUID, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePromoCredit", ctx, id, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpirePromoCredit indicates an expected call of ExpirePromoCredit.
+func (mr *MockWalletRepositoryMockRecorder) ExpirePromoCredit(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePromoCredit", reflect.TypeOf((*MockWalletRepository)(nil).ExpirePromoCredit), ctx, id, now)
+}
+
+// ExportWallets mocks base method.
+func (m *MockWalletRepository) ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportWallets", ctx, batchSize, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportWallets indicates an expected call of ExportWallets.
+func (mr *MockWalletRepositoryMockRecorder) ExportWallets(ctx, batchSize, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportWallets", reflect.TypeOf((*MockWalletRepository)(nil).ExportWallets), ctx, batchSize, fn)
+}
+
+// FlagDormantWallets mocks base method.
+func (m *MockWalletRepository) FlagDormantWallets(ctx context.Context, idleSince time.Time, batchSize int) ([]models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagDormantWallets", ctx, idleSince, batchSize)
+	ret0, _ := ret[0].([]models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlagDormantWallets indicates an expected call of FlagDormantWallets.
+func (mr *MockWalletRepositoryMockRecorder) FlagDormantWallets(ctx, idleSince, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagDormantWallets", reflect.TypeOf((*MockWalletRepository)(nil).FlagDormantWallets), ctx, idleSince, batchSize)
+}
+
+// FreezeWallet mocks base method.
+func (m *MockWalletRepository) FreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeWallet", ctx, id)
 	ret0, _ := ret[0].(*models.Wallet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateWalletBalance indicates an expected call of UpdateWalletBalance.
-func (mr *MockWalletRepositoryMockRecorder) UpdateWalletBalance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// FreezeWallet indicates an expected call of FreezeWallet.
+func (mr *MockWalletRepositoryMockRecorder) FreezeWallet(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWalletBalance", reflect.TypeOf((*MockWalletRepository)(nil).UpdateWalletBalance), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeWallet", reflect.TypeOf((*MockWalletRepository)(nil).FreezeWallet), ctx, id)
+}
+
+// GetDispute mocks base method.
+func (m *MockWalletRepository) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDispute", ctx, id)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDispute indicates an expected call of GetDispute.
+func (mr *MockWalletRepositoryMockRecorder) GetDispute(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDispute", reflect.TypeOf((*MockWalletRepository)(nil).GetDispute), ctx, id)
+}
+
+// GetLegalHold mocks base method.
+func (m *MockWalletRepository) GetLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLegalHold", ctx, walletID)
+	ret0, _ := ret[0].(*models.LegalHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLegalHold indicates an expected call of GetLegalHold.
+func (mr *MockWalletRepositoryMockRecorder) GetLegalHold(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLegalHold", reflect.TypeOf((*MockWalletRepository)(nil).GetLegalHold), ctx, walletID)
+}
+
+// GetMandate mocks base method.
+func (m *MockWalletRepository) GetMandate(ctx context.Context, id uuid.UUID) (*models.Mandate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMandate", ctx, id)
+	ret0, _ := ret[0].(*models.Mandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMandate indicates an expected call of GetMandate.
+func (mr *MockWalletRepositoryMockRecorder) GetMandate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMandate", reflect.TypeOf((*MockWalletRepository)(nil).GetMandate), ctx, id)
+}
+
+// GetWallet mocks base method.
+func (m *MockWalletRepository) GetWallet(arg0 context.Context, arg1 uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWallet", arg0, arg1)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWallet indicates an expected call of GetWallet.
+func (mr *MockWalletRepositoryMockRecorder) GetWallet(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWallet", reflect.TypeOf((*MockWalletRepository)(nil).GetWallet), arg0, arg1)
+}
+
+// GetWalletBalance mocks base method.
+func (m *MockWalletRepository) GetWalletBalance(ctx context.Context, id uuid.UUID) (*models.WalletBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWalletBalance", ctx, id)
+	ret0, _ := ret[0].(*models.WalletBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWalletBalance indicates an expected call of GetWalletBalance.
+func (mr *MockWalletRepositoryMockRecorder) GetWalletBalance(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletBalance", reflect.TypeOf((*MockWalletRepository)(nil).GetWalletBalance), ctx, id)
+}
+
+// GetWalletVersions mocks base method.
+func (m *MockWalletRepository) GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWalletVersions", ctx, id)
+	ret0, _ := ret[0].([]models.WalletVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWalletVersions indicates an expected call of GetWalletVersions.
+func (mr *MockWalletRepositoryMockRecorder) GetWalletVersions(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletVersions", reflect.TypeOf((*MockWalletRepository)(nil).GetWalletVersions), ctx, id)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockWalletRepository) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) GetWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).GetWebhookSubscription), ctx, id)
+}
+
+// GrantPromo mocks base method.
+func (m *MockWalletRepository) GrantPromo(ctx context.Context, credit models.PromoCredit) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantPromo", ctx, credit)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantPromo indicates an expected call of GrantPromo.
+func (mr *MockWalletRepositoryMockRecorder) GrantPromo(ctx, credit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantPromo", reflect.TypeOf((*MockWalletRepository)(nil).GrantPromo), ctx, credit)
+}
+
+// ImportWallet mocks base method.
+func (m *MockWalletRepository) ImportWallet(ctx context.Context, id uuid.UUID, req models.ImportWalletRequest) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportWallet", ctx, id, req)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportWallet indicates an expected call of ImportWallet.
+func (mr *MockWalletRepositoryMockRecorder) ImportWallet(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportWallet", reflect.TypeOf((*MockWalletRepository)(nil).ImportWallet), ctx, id, req)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockWalletRepository) ListAuditEvents(ctx context.Context, walletID uuid.UUID, after models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, walletID, after, limit)
+	ret0, _ := ret[0].([]models.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockWalletRepositoryMockRecorder) ListAuditEvents(ctx, walletID, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockWalletRepository)(nil).ListAuditEvents), ctx, walletID, after, limit)
+}
+
+// ListDisputes mocks base method.
+func (m *MockWalletRepository) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisputes", ctx, status)
+	ret0, _ := ret[0].([]models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisputes indicates an expected call of ListDisputes.
+func (mr *MockWalletRepositoryMockRecorder) ListDisputes(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisputes", reflect.TypeOf((*MockWalletRepository)(nil).ListDisputes), ctx, status)
+}
+
+// ListEvents mocks base method.
+func (m *MockWalletRepository) ListEvents(ctx context.Context, after int64, until time.Time, limit int) ([]models.LedgerEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, after, until, limit)
+	ret0, _ := ret[0].([]models.LedgerEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockWalletRepositoryMockRecorder) ListEvents(ctx, after, until, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockWalletRepository)(nil).ListEvents), ctx, after, until, limit)
+}
+
+// ListHolds mocks base method.
+func (m *MockWalletRepository) ListHolds(ctx context.Context, walletID uuid.UUID) ([]models.FundsHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHolds", ctx, walletID)
+	ret0, _ := ret[0].([]models.FundsHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHolds indicates an expected call of ListHolds.
+func (mr *MockWalletRepositoryMockRecorder) ListHolds(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHolds", reflect.TypeOf((*MockWalletRepository)(nil).ListHolds), ctx, walletID)
+}
+
+// ListMandates mocks base method.
+func (m *MockWalletRepository) ListMandates(ctx context.Context, walletID uuid.UUID) ([]models.Mandate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMandates", ctx, walletID)
+	ret0, _ := ret[0].([]models.Mandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMandates indicates an expected call of ListMandates.
+func (mr *MockWalletRepositoryMockRecorder) ListMandates(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMandates", reflect.TypeOf((*MockWalletRepository)(nil).ListMandates), ctx, walletID)
+}
+
+// ListOperationAttempts mocks base method.
+func (m *MockWalletRepository) ListOperationAttempts(ctx context.Context, operationID string) ([]models.OperationAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOperationAttempts", ctx, operationID)
+	ret0, _ := ret[0].([]models.OperationAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOperationAttempts indicates an expected call of ListOperationAttempts.
+func (mr *MockWalletRepositoryMockRecorder) ListOperationAttempts(ctx, operationID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOperationAttempts", reflect.TypeOf((*MockWalletRepository)(nil).ListOperationAttempts), ctx, operationID)
+}
+
+// ListPromoCredits mocks base method.
+func (m *MockWalletRepository) ListPromoCredits(ctx context.Context, walletID uuid.UUID) ([]models.PromoCredit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPromoCredits", ctx, walletID)
+	ret0, _ := ret[0].([]models.PromoCredit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPromoCredits indicates an expected call of ListPromoCredits.
+func (mr *MockWalletRepositoryMockRecorder) ListPromoCredits(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPromoCredits", reflect.TypeOf((*MockWalletRepository)(nil).ListPromoCredits), ctx, walletID)
+}
+
+// ListRewardAccruals mocks base method.
+func (m *MockWalletRepository) ListRewardAccruals(ctx context.Context, walletID uuid.UUID) ([]models.RewardAccrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRewardAccruals", ctx, walletID)
+	ret0, _ := ret[0].([]models.RewardAccrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRewardAccruals indicates an expected call of ListRewardAccruals.
+func (mr *MockWalletRepositoryMockRecorder) ListRewardAccruals(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRewardAccruals", reflect.TypeOf((*MockWalletRepository)(nil).ListRewardAccruals), ctx, walletID)
+}
+
+// ListSuspenseCases mocks base method.
+func (m *MockWalletRepository) ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSuspenseCases", ctx, status)
+	ret0, _ := ret[0].([]models.SuspenseCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSuspenseCases indicates an expected call of ListSuspenseCases.
+func (mr *MockWalletRepositoryMockRecorder) ListSuspenseCases(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSuspenseCases", reflect.TypeOf((*MockWalletRepository)(nil).ListSuspenseCases), ctx, status)
+}
+
+// ListTransactionNotes mocks base method.
+func (m *MockWalletRepository) ListTransactionNotes(ctx context.Context, transactionID int64) ([]models.TransactionNote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactionNotes", ctx, transactionID)
+	ret0, _ := ret[0].([]models.TransactionNote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactionNotes indicates an expected call of ListTransactionNotes.
+func (mr *MockWalletRepositoryMockRecorder) ListTransactionNotes(ctx, transactionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactionNotes", reflect.TypeOf((*MockWalletRepository)(nil).ListTransactionNotes), ctx, transactionID)
+}
+
+// ListTransactions mocks base method.
+func (m *MockWalletRepository) ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, walletID, beforeVersion, limit)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockWalletRepositoryMockRecorder) ListTransactions(ctx, walletID, beforeVersion, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockWalletRepository)(nil).ListTransactions), ctx, walletID, beforeVersion, limit)
+}
+
+// ListWalletVersions mocks base method.
+func (m *MockWalletRepository) ListWalletVersions(ctx context.Context, from, to time.Time) ([]models.WalletVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWalletVersions", ctx, from, to)
+	ret0, _ := ret[0].([]models.WalletVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWalletVersions indicates an expected call of ListWalletVersions.
+func (mr *MockWalletRepositoryMockRecorder) ListWalletVersions(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWalletVersions", reflect.TypeOf((*MockWalletRepository)(nil).ListWalletVersions), ctx, from, to)
+}
+
+// ListWallets mocks base method.
+func (m *MockWalletRepository) ListWallets(ctx context.Context, f models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWallets", ctx, f, sort, after, limit)
+	ret0, _ := ret[0].([]models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWallets indicates an expected call of ListWallets.
+func (mr *MockWalletRepositoryMockRecorder) ListWallets(ctx, f, sort, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWallets", reflect.TypeOf((*MockWalletRepository)(nil).ListWallets), ctx, f, sort, after, limit)
+}
+
+// ListWebhookDeliveryAttempts mocks base method.
+func (m *MockWalletRepository) ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveryAttempts", ctx, subscriptionID, limit)
+	ret0, _ := ret[0].([]models.WebhookDeliveryAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveryAttempts indicates an expected call of ListWebhookDeliveryAttempts.
+func (mr *MockWalletRepositoryMockRecorder) ListWebhookDeliveryAttempts(ctx, subscriptionID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveryAttempts", reflect.TypeOf((*MockWalletRepository)(nil).ListWebhookDeliveryAttempts), ctx, subscriptionID, limit)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWalletRepository) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWalletRepositoryMockRecorder) ListWebhookSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWalletRepository)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// MarkWalletPurged mocks base method.
+func (m *MockWalletRepository) MarkWalletPurged(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWalletPurged", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWalletPurged indicates an expected call of MarkWalletPurged.
+func (mr *MockWalletRepositoryMockRecorder) MarkWalletPurged(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWalletPurged", reflect.TypeOf((*MockWalletRepository)(nil).MarkWalletPurged), ctx, id)
+}
+
+// OpenDispute mocks base method.
+func (m *MockWalletRepository) OpenDispute(ctx context.Context, d models.Dispute) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDispute", ctx, d)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenDispute indicates an expected call of OpenDispute.
+func (mr *MockWalletRepositoryMockRecorder) OpenDispute(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDispute", reflect.TypeOf((*MockWalletRepository)(nil).OpenDispute), ctx, d)
+}
+
+// Outbox mocks base method.
+func (m *MockWalletRepository) Outbox() repository.OutboxStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Outbox")
+	ret0, _ := ret[0].(repository.OutboxStore)
+	return ret0
+}
+
+// Outbox indicates an expected call of Outbox.
+func (mr *MockWalletRepositoryMockRecorder) Outbox() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Outbox", reflect.TypeOf((*MockWalletRepository)(nil).Outbox))
+}
+
+// OwnerBalances mocks base method.
+func (m *MockWalletRepository) OwnerBalances(ctx context.Context, ownerID uuid.UUID) ([]models.CurrencyBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OwnerBalances", ctx, ownerID)
+	ret0, _ := ret[0].([]models.CurrencyBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OwnerBalances indicates an expected call of OwnerBalances.
+func (mr *MockWalletRepositoryMockRecorder) OwnerBalances(ctx, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OwnerBalances", reflect.TypeOf((*MockWalletRepository)(nil).OwnerBalances), ctx, ownerID)
+}
+
+// PauseWebhookSubscription mocks base method.
+func (m *MockWalletRepository) PauseWebhookSubscription(ctx context.Context, id uuid.UUID, paused bool, updatedAt time.Time) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseWebhookSubscription", ctx, id, paused, updatedAt)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PauseWebhookSubscription indicates an expected call of PauseWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) PauseWebhookSubscription(ctx, id, paused, updatedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).PauseWebhookSubscription), ctx, id, paused, updatedAt)
+}
+
+// PlaceHold mocks base method.
+func (m *MockWalletRepository) PlaceHold(ctx context.Context, h models.FundsHold) (*models.FundsHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceHold", ctx, h)
+	ret0, _ := ret[0].(*models.FundsHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceHold indicates an expected call of PlaceHold.
+func (mr *MockWalletRepositoryMockRecorder) PlaceHold(ctx, h any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceHold", reflect.TypeOf((*MockWalletRepository)(nil).PlaceHold), ctx, h)
+}
+
+// PlaceLegalHold mocks base method.
+func (m *MockWalletRepository) PlaceLegalHold(ctx context.Context, h models.LegalHold) (*models.LegalHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceLegalHold", ctx, h)
+	ret0, _ := ret[0].(*models.LegalHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceLegalHold indicates an expected call of PlaceLegalHold.
+func (mr *MockWalletRepositoryMockRecorder) PlaceLegalHold(ctx, h any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceLegalHold", reflect.TypeOf((*MockWalletRepository)(nil).PlaceLegalHold), ctx, h)
+}
+
+// Promos mocks base method.
+func (m *MockWalletRepository) Promos() repository.PromoStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Promos")
+	ret0, _ := ret[0].(repository.PromoStore)
+	return ret0
+}
+
+// Promos indicates an expected call of Promos.
+func (mr *MockWalletRepositoryMockRecorder) Promos() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Promos", reflect.TypeOf((*MockWalletRepository)(nil).Promos))
+}
+
+// PurgeAuditEvents mocks base method.
+func (m *MockWalletRepository) PurgeAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeAuditEvents", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeAuditEvents indicates an expected call of PurgeAuditEvents.
+func (mr *MockWalletRepositoryMockRecorder) PurgeAuditEvents(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeAuditEvents", reflect.TypeOf((*MockWalletRepository)(nil).PurgeAuditEvents), ctx, before)
+}
+
+// PurgeOperationAttempts mocks base method.
+func (m *MockWalletRepository) PurgeOperationAttempts(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeOperationAttempts", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeOperationAttempts indicates an expected call of PurgeOperationAttempts.
+func (mr *MockWalletRepositoryMockRecorder) PurgeOperationAttempts(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOperationAttempts", reflect.TypeOf((*MockWalletRepository)(nil).PurgeOperationAttempts), ctx, before)
+}
+
+// PurgeOutbox mocks base method.
+func (m *MockWalletRepository) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeOutbox", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeOutbox indicates an expected call of PurgeOutbox.
+func (mr *MockWalletRepositoryMockRecorder) PurgeOutbox(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOutbox", reflect.TypeOf((*MockWalletRepository)(nil).PurgeOutbox), ctx, before)
+}
+
+// PurgeWebhookDeliveries mocks base method.
+func (m *MockWalletRepository) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeWebhookDeliveries", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeWebhookDeliveries indicates an expected call of PurgeWebhookDeliveries.
+func (mr *MockWalletRepositoryMockRecorder) PurgeWebhookDeliveries(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeWebhookDeliveries", reflect.TypeOf((*MockWalletRepository)(nil).PurgeWebhookDeliveries), ctx, before)
+}
+
+// ReactivateWallet mocks base method.
+func (m *MockWalletRepository) ReactivateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateWallet", ctx, id)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReactivateWallet indicates an expected call of ReactivateWallet.
+func (mr *MockWalletRepositoryMockRecorder) ReactivateWallet(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateWallet", reflect.TypeOf((*MockWalletRepository)(nil).ReactivateWallet), ctx, id)
+}
+
+// ReceiveToSuspense mocks base method.
+func (m *MockWalletRepository) ReceiveToSuspense(ctx context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveToSuspense", ctx, c)
+	ret0, _ := ret[0].(*models.SuspenseCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReceiveToSuspense indicates an expected call of ReceiveToSuspense.
+func (mr *MockWalletRepositoryMockRecorder) ReceiveToSuspense(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveToSuspense", reflect.TypeOf((*MockWalletRepository)(nil).ReceiveToSuspense), ctx, c)
+}
+
+// RecordOperationAttempts mocks base method.
+func (m *MockWalletRepository) RecordOperationAttempts(ctx context.Context, attempts []models.OperationAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordOperationAttempts", ctx, attempts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordOperationAttempts indicates an expected call of RecordOperationAttempts.
+func (mr *MockWalletRepositoryMockRecorder) RecordOperationAttempts(ctx, attempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordOperationAttempts", reflect.TypeOf((*MockWalletRepository)(nil).RecordOperationAttempts), ctx, attempts)
+}
+
+// RecordScreeningHit mocks base method.
+func (m *MockWalletRepository) RecordScreeningHit(ctx context.Context, hit models.ScreeningHit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordScreeningHit", ctx, hit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordScreeningHit indicates an expected call of RecordScreeningHit.
+func (mr *MockWalletRepositoryMockRecorder) RecordScreeningHit(ctx, hit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScreeningHit", reflect.TypeOf((*MockWalletRepository)(nil).RecordScreeningHit), ctx, hit)
+}
+
+// RecordWebhookDeliveryAttempt mocks base method.
+func (m *MockWalletRepository) RecordWebhookDeliveryAttempt(ctx context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDeliveryAttempt", ctx, a, status, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookDeliveryAttempt indicates an expected call of RecordWebhookDeliveryAttempt.
+func (mr *MockWalletRepositoryMockRecorder) RecordWebhookDeliveryAttempt(ctx, a, status, nextAttemptAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDeliveryAttempt", reflect.TypeOf((*MockWalletRepository)(nil).RecordWebhookDeliveryAttempt), ctx, a, status, nextAttemptAt)
+}
+
+// RelayOutbox mocks base method.
+func (m *MockWalletRepository) RelayOutbox(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayOutbox", ctx, limit, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayOutbox indicates an expected call of RelayOutbox.
+func (mr *MockWalletRepositoryMockRecorder) RelayOutbox(ctx, limit, publish any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayOutbox", reflect.TypeOf((*MockWalletRepository)(nil).RelayOutbox), ctx, limit, publish)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockWalletRepository) ReleaseHold(ctx context.Context, walletID, holdID uuid.UUID, at time.Time) (*models.FundsHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, walletID, holdID, at)
+	ret0, _ := ret[0].(*models.FundsHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockWalletRepositoryMockRecorder) ReleaseHold(ctx, walletID, holdID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockWalletRepository)(nil).ReleaseHold), ctx, walletID, holdID, at)
+}
+
+// ReleaseLegalHold mocks base method.
+func (m *MockWalletRepository) ReleaseLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLegalHold", ctx, walletID)
+	ret0, _ := ret[0].(*models.LegalHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseLegalHold indicates an expected call of ReleaseLegalHold.
+func (mr *MockWalletRepositoryMockRecorder) ReleaseLegalHold(ctx, walletID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLegalHold", reflect.TypeOf((*MockWalletRepository)(nil).ReleaseLegalHold), ctx, walletID)
+}
+
+// ResolveSuspenseCase mocks base method.
+func (m *MockWalletRepository) ResolveSuspenseCase(ctx context.Context, id, targetID uuid.UUID, note string, at time.Time) (*models.SuspenseCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveSuspenseCase", ctx, id, targetID, note, at)
+	ret0, _ := ret[0].(*models.SuspenseCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveSuspenseCase indicates an expected call of ResolveSuspenseCase.
+func (mr *MockWalletRepositoryMockRecorder) ResolveSuspenseCase(ctx, id, targetID, note, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveSuspenseCase", reflect.TypeOf((*MockWalletRepository)(nil).ResolveSuspenseCase), ctx, id, targetID, note, at)
+}
+
+// RestoreWallet mocks base method.
+func (m *MockWalletRepository) RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreWallet", ctx, id)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreWallet indicates an expected call of RestoreWallet.
+func (mr *MockWalletRepositoryMockRecorder) RestoreWallet(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreWallet", reflect.TypeOf((*MockWalletRepository)(nil).RestoreWallet), ctx, id)
+}
+
+// RevokeMandate mocks base method.
+func (m *MockWalletRepository) RevokeMandate(ctx context.Context, walletID, mandateID uuid.UUID, at time.Time) (*models.Mandate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeMandate", ctx, walletID, mandateID, at)
+	ret0, _ := ret[0].(*models.Mandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeMandate indicates an expected call of RevokeMandate.
+func (mr *MockWalletRepositoryMockRecorder) RevokeMandate(ctx, walletID, mandateID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeMandate", reflect.TypeOf((*MockWalletRepository)(nil).RevokeMandate), ctx, walletID, mandateID, at)
+}
+
+// SearchWallets mocks base method.
+func (m *MockWalletRepository) SearchWallets(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchWallets", ctx, prefix, after, limit)
+	ret0, _ := ret[0].([]models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchWallets indicates an expected call of SearchWallets.
+func (mr *MockWalletRepositoryMockRecorder) SearchWallets(ctx, prefix, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchWallets", reflect.TypeOf((*MockWalletRepository)(nil).SearchWallets), ctx, prefix, after, limit)
+}
+
+// SetCreditLimit mocks base method.
+func (m *MockWalletRepository) SetCreditLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCreditLimit", ctx, id, limit)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCreditLimit indicates an expected call of SetCreditLimit.
+func (mr *MockWalletRepositoryMockRecorder) SetCreditLimit(ctx, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCreditLimit", reflect.TypeOf((*MockWalletRepository)(nil).SetCreditLimit), ctx, id, limit)
+}
+
+// SetWalletsStatus mocks base method.
+func (m *MockWalletRepository) SetWalletsStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus, batchSize int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWalletsStatus", ctx, f, status, batchSize)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetWalletsStatus indicates an expected call of SetWalletsStatus.
+func (mr *MockWalletRepositoryMockRecorder) SetWalletsStatus(ctx, f, status, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWalletsStatus", reflect.TypeOf((*MockWalletRepository)(nil).SetWalletsStatus), ctx, f, status, batchSize)
+}
+
+// Snapshots mocks base method.
+func (m *MockWalletRepository) Snapshots() repository.SnapshotStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshots")
+	ret0, _ := ret[0].(repository.SnapshotStore)
+	return ret0
+}
+
+// Snapshots indicates an expected call of Snapshots.
+func (mr *MockWalletRepositoryMockRecorder) Snapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshots", reflect.TypeOf((*MockWalletRepository)(nil).Snapshots))
+}
+
+// TenantBalances mocks base method.
+func (m *MockWalletRepository) TenantBalances(ctx context.Context, tenant string) ([]models.CurrencyBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantBalances", ctx, tenant)
+	ret0, _ := ret[0].([]models.CurrencyBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantBalances indicates an expected call of TenantBalances.
+func (mr *MockWalletRepositoryMockRecorder) TenantBalances(ctx, tenant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantBalances", reflect.TypeOf((*MockWalletRepository)(nil).TenantBalances), ctx, tenant)
+}
+
+// Transfer mocks base method.
+func (m *MockWalletRepository) Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (*models.Wallet, *models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromID, toID, amount)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(*models.Wallet)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockWalletRepositoryMockRecorder) Transfer(ctx, fromID, toID, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockWalletRepository)(nil).Transfer), ctx, fromID, toID, amount)
+}
+
+// UnfreezeWallet mocks base method.
+func (m *MockWalletRepository) UnfreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeWallet", ctx, id)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfreezeWallet indicates an expected call of UnfreezeWallet.
+func (mr *MockWalletRepositoryMockRecorder) UnfreezeWallet(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeWallet", reflect.TypeOf((*MockWalletRepository)(nil).UnfreezeWallet), ctx, id)
+}
+
+// UpdateWalletBalance mocks base method.
+func (m *MockWalletRepository) UpdateWalletBalance(arg0 context.Context, arg1 uuid.UUID, arg2 int64, arg3 models.OperationType) (*models.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWalletBalance", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWalletBalance indicates an expected call of UpdateWalletBalance.
+func (mr *MockWalletRepositoryMockRecorder) UpdateWalletBalance(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWalletBalance", reflect.TypeOf((*MockWalletRepository)(nil).UpdateWalletBalance), arg0, arg1, arg2, arg3)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockWalletRepository) UpdateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", ctx, s)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) UpdateWebhookSubscription(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).UpdateWebhookSubscription), ctx, s)
+}
+
+// Versions mocks base method.
+func (m *MockWalletRepository) Versions() repository.VersionStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions")
+	ret0, _ := ret[0].(repository.VersionStore)
+	return ret0
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *MockWalletRepositoryMockRecorder) Versions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*MockWalletRepository)(nil).Versions))
+}
+
+// WalletVersionsAfter mocks base method.
+func (m *MockWalletRepository) WalletVersionsAfter(ctx context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletVersionsAfter", ctx, id, afterVersion, limit)
+	ret0, _ := ret[0].([]models.WalletVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletVersionsAfter indicates an expected call of WalletVersionsAfter.
+func (mr *MockWalletRepositoryMockRecorder) WalletVersionsAfter(ctx, id, afterVersion, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletVersionsAfter", reflect.TypeOf((*MockWalletRepository)(nil).WalletVersionsAfter), ctx, id, afterVersion, limit)
+}
+
+// Wallets mocks base method.
+func (m *MockWalletRepository) Wallets() repository.WalletStore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wallets")
+	ret0, _ := ret[0].(repository.WalletStore)
+	return ret0
+}
+
+// Wallets indicates an expected call of Wallets.
+func (mr *MockWalletRepositoryMockRecorder) Wallets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wallets", reflect.TypeOf((*MockWalletRepository)(nil).Wallets))
+}
+
+// WalletsToPurge mocks base method.
+func (m *MockWalletRepository) WalletsToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletsToPurge", ctx, closedBefore, limit)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletsToPurge indicates an expected call of WalletsToPurge.
+func (mr *MockWalletRepositoryMockRecorder) WalletsToPurge(ctx, closedBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletsToPurge", reflect.TypeOf((*MockWalletRepository)(nil).WalletsToPurge), ctx, closedBefore, limit)
+}
+
+// WebhookSubscriptionStats mocks base method.
+func (m *MockWalletRepository) WebhookSubscriptionStats(ctx context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscriptionStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookSubscriptionStats", ctx, subscriptionID)
+	ret0, _ := ret[0].(*models.WebhookSubscriptionStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookSubscriptionStats indicates an expected call of WebhookSubscriptionStats.
+func (mr *MockWalletRepositoryMockRecorder) WebhookSubscriptionStats(ctx, subscriptionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookSubscriptionStats", reflect.TypeOf((*MockWalletRepository)(nil).WebhookSubscriptionStats), ctx, subscriptionID)
+}
+
+// WipeTenant mocks base method.
+func (m *MockWalletRepository) WipeTenant(ctx context.Context, tenant string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WipeTenant", ctx, tenant)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WipeTenant indicates an expected call of WipeTenant.
+func (mr *MockWalletRepositoryMockRecorder) WipeTenant(ctx, tenant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WipeTenant", reflect.TypeOf((*MockWalletRepository)(nil).WipeTenant), ctx, tenant)
+}
+
+// WithinTx mocks base method.
+func (m *MockWalletRepository) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockWalletRepositoryMockRecorder) WithinTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockWalletRepository)(nil).WithinTx), ctx, fn)
+}
+
+// WithinWalletTx mocks base method.
+func (m *MockWalletRepository) WithinWalletTx(ctx context.Context, walletID uuid.UUID, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinWalletTx", ctx, walletID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinWalletTx indicates an expected call of WithinWalletTx.
+func (mr *MockWalletRepositoryMockRecorder) WithinWalletTx(ctx, walletID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinWalletTx", reflect.TypeOf((*MockWalletRepository)(nil).WithinWalletTx), ctx, walletID, fn)
 }
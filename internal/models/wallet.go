@@ -1,6 +1,9 @@
 package models
 
 import (
+	"bytes"
+	"cmp"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,21 +16,872 @@ const (
 	OperationTypeWithdraw OperationType = "WITHDRAW"
 )
 
+// DefaultCurrency is assigned to wallets created without an explicit
+// currency.
+const DefaultCurrency = "USD"
+
+type WalletStatus string
+
+const (
+	WalletStatusActive WalletStatus = "ACTIVE"
+	WalletStatusFrozen WalletStatus = "FROZEN"
+	// WalletStatusDormant marks a wallet idle for longer than the dormancy
+	// period. It keeps working, except that large withdrawals require the
+	// wallet to be reactivated first.
+	WalletStatusDormant WalletStatus = "DORMANT"
+	// WalletStatusClosed marks a wallet closed by its owner or support. It
+	// takes no operations; it can be restored until its transactions are
+	// purged at the end of the recovery window.
+	WalletStatusClosed WalletStatus = "CLOSED"
+)
+
+// Wallet.Balance includes PromoBalance, the part of it made of unexpired
+// promotional credits. HeldBalance is the part of it that can't be
+// withdrawn. CreditLimit is how far below zero withdrawals may take the
+// balance.
 type Wallet struct {
-	ID        uuid.UUID `json:"id"`
-	Balance   int64     `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Version   int       `json:"version"`
+	ID           uuid.UUID     `json:"id"`
+	Balance      int64         `json:"balance"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Version      int           `json:"version"`
+	OwnerID      uuid.NullUUID `json:"ownerId"`
+	Currency     string        `json:"currency"`
+	Status       WalletStatus  `json:"status"`
+	Label        string        `json:"label,omitempty"`
+	Tenant       string        `json:"tenant,omitempty"`
+	PromoBalance int64         `json:"promoBalance"`
+	HeldBalance  int64         `json:"heldBalance"`
+	CreditLimit  int64         `json:"creditLimit"`
+}
+
+// AvailableBalance is what can be withdrawn: the part of the balance that
+// isn't held, plus the credit limit.
+func (w *Wallet) AvailableBalance() int64 {
+	return w.Balance + w.CreditLimit - w.HeldBalance
+}
+
+// SetCreditLimitRequest sets a wallet's credit limit.
+type SetCreditLimitRequest struct {
+	CreditLimit int64 `json:"creditLimit"`
+}
+
+// CreateWalletRequest holds the optional attributes of a new wallet.
+type CreateWalletRequest struct {
+	OwnerID  uuid.NullUUID `json:"ownerId"`
+	Currency string        `json:"currency"`
+	Label    string        `json:"label"`
+	Tenant   string        `json:"tenant"`
+}
+
+// CreateWalletsRequest creates Count wallets sharing the attributes of the
+// embedded CreateWalletRequest, e.g. when onboarding a tenant.
+type CreateWalletsRequest struct {
+	CreateWalletRequest
+	Count int `json:"count"`
+}
+
+// CreateWalletsResponse lists the ids of wallets created in bulk.
+type CreateWalletsResponse struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// WalletFilter selects wallets by attributes; zero-valued fields don't
+// restrict the selection.
+type WalletFilter struct {
+	OwnerID       uuid.NullUUID `json:"ownerId"`
+	Label         string        `json:"label"`
+	Tenant        string        `json:"tenant"`
+	Status        WalletStatus  `json:"status"`
+	Currency      string        `json:"currency"`
+	MinBalance    *int64        `json:"minBalance"`
+	MaxBalance    *int64        `json:"maxBalance"`
+	CreatedAfter  *time.Time    `json:"createdAfter"`
+	CreatedBefore *time.Time    `json:"createdBefore"`
+}
+
+// IsEmpty reports whether the filter matches every wallet.
+func (f WalletFilter) IsEmpty() bool {
+	return !f.OwnerID.Valid && f.Label == "" && f.Tenant == "" && f.Status == "" && f.Currency == "" &&
+		f.MinBalance == nil && f.MaxBalance == nil && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// WalletSortField is what a wallet listing is ordered by.
+type WalletSortField string
+
+const (
+	WalletSortID        WalletSortField = "id"
+	WalletSortBalance   WalletSortField = "balance"
+	WalletSortCreatedAt WalletSortField = "createdAt"
+)
+
+// WalletSort orders a wallet listing by Field and then by id, descending
+// if Desc is set. The zero WalletSort orders by id, ascending.
+type WalletSort struct {
+	Field WalletSortField
+	Desc  bool
+}
+
+// WalletCursor is the position in a sorted wallet listing the next page
+// continues after: the id and sort keys of the last wallet seen. The zero
+// cursor starts at the beginning.
+type WalletCursor struct {
+	ID        uuid.UUID
+	Balance   int64
+	CreatedAt time.Time
+}
+
+// Compare orders a before b, as cmp.Compare does, in the order of s. Ids
+// compare bytewise, as Postgres compares uuids.
+func (s WalletSort) Compare(a, b Wallet) int {
+	return s.compare(CursorAt(a), CursorAt(b))
+}
+
+// After reports whether w follows c in the order of s. Every wallet
+// follows the zero cursor.
+func (s WalletSort) After(w Wallet, c WalletCursor) bool {
+	return c.ID == uuid.Nil || s.compare(CursorAt(w), c) > 0
+}
+
+func (s WalletSort) compare(a, b WalletCursor) int {
+	var n int
+	switch s.Field {
+	case WalletSortBalance:
+		n = cmp.Compare(a.Balance, b.Balance)
+	case WalletSortCreatedAt:
+		n = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if n == 0 {
+		n = bytes.Compare(a.ID[:], b.ID[:])
+	}
+	if s.Desc {
+		return -n
+	}
+	return n
+}
+
+// CursorAt returns the cursor continuing after w.
+func CursorAt(w Wallet) WalletCursor {
+	return WalletCursor{ID: w.ID, Balance: w.Balance, CreatedAt: w.CreatedAt}
 }
 
 type WalletOperation struct {
 	WalletID      uuid.UUID     `json:"walletId"`
 	OperationType OperationType `json:"poerationType"`
 	Amount        int64         `json:"amount"`
+	Category      string        `json:"category,omitempty"`
 }
 
+// WalletBalance is the balance of a wallet alone. Available is the part of
+// Balance that isn't held, plus the wallet's credit limit, which
+// withdrawals and new holds may take.
 type WalletBalance struct {
+	WalletID  uuid.UUID `json:"walletId"`
+	Balance   int64     `json:"balance"`
+	Available int64     `json:"available"`
+}
+
+type ExportResult struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	Count     int       `json:"count"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type BalanceSummary struct {
+	WalletCount    int64     `json:"walletCount"`
+	TotalBalance   int64     `json:"totalBalance"`
+	CreatedInRange int64     `json:"createdInRange"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+}
+
+// OperationTypeCreate marks the initial version of a wallet in its history.
+const OperationTypeCreate OperationType = "CREATE"
+
+// WalletVersion is the state of a wallet right after the operation that
+// produced it.
+type WalletVersion struct {
+	WalletID      uuid.UUID     `json:"walletId"`
+	Version       int           `json:"version"`
+	Balance       int64         `json:"balance"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// WalletSnapshot is the state a wallet's history adds up to at Version,
+// saved so that replaying the history can start from it instead of from
+// the wallet's creation.
+type WalletSnapshot struct {
+	WalletID     uuid.UUID `json:"walletId"`
+	Version      int       `json:"version"`
+	Balance      int64     `json:"balance"`
+	PromoBalance int64     `json:"promoBalance"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// WalletRebuild compares a wallet's stored balances, the projection of its
+// history, with the state replaying the history gives.
+type WalletRebuild struct {
+	WalletID   uuid.UUID      `json:"walletId"`
+	Stored     WalletSnapshot `json:"stored"`
+	Replayed   WalletSnapshot `json:"replayed"`
+	Events     int            `json:"events"`
+	Consistent bool           `json:"consistent"`
+	// Repaired is set when the stored balances were overwritten with the
+	// replayed ones.
+	Repaired bool `json:"repaired"`
+}
+
+// LedgerEvent is a wallet version in the replayable event log. Offset
+// orders events globally and is the position to resume reading from.
+type LedgerEvent struct {
+	Offset        int64         `json:"offset"`
+	WalletID      uuid.UUID     `json:"walletId"`
+	Version       int           `json:"version"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	Balance       int64         `json:"balance"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// Transaction is one balance change in a wallet's history. ID is the
+// change's ledger sequence number, which transaction notes refer to;
+// Balance is the wallet's balance right after the change.
+type Transaction struct {
+	ID            int64         `json:"id"`
+	WalletID      uuid.UUID     `json:"walletId"`
+	Version       int           `json:"version"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	Balance       int64         `json:"balance"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// ArchivedTransaction is a transaction with its notes, as written to the
+// archive of a purged wallet.
+type ArchivedTransaction struct {
+	Transaction
+	Notes []TransactionNote `json:"notes,omitempty"`
+}
+
+// CurrencyBalance is the total balance of a group of wallets in one currency.
+type CurrencyBalance struct {
+	Currency    string `json:"currency"`
+	Balance     int64  `json:"balance"`
+	WalletCount int64  `json:"walletCount"`
+}
+
+// CurrencyTotals compares, for one currency, what wallets hold with what
+// the ledger says they should: Balance is held by customer wallets and
+// SystemBalance by system wallets such as the suspense wallets, while
+// Credits and Debits add up the amounts of every recorded operation that
+// added to or took from a balance.
+type CurrencyTotals struct {
+	Currency      string `json:"currency"`
+	Balance       int64  `json:"balance"`
+	SystemBalance int64  `json:"systemBalance"`
+	Credits       int64  `json:"credits"`
+	Debits        int64  `json:"debits"`
+}
+
+// Discrepancy is how much more the wallets hold than the ledger accounts
+// for; anything but zero means money was created or lost.
+func (t CurrencyTotals) Discrepancy() int64 {
+	return t.Balance + t.SystemBalance - (t.Credits - t.Debits)
+}
+
+// OwnerBalance aggregates all wallets of an owner per currency as of
+// AsOf; it may lag behind the latest operations by the cache TTL.
+type OwnerBalance struct {
+	OwnerID  uuid.UUID         `json:"ownerId"`
+	Balances []CurrencyBalance `json:"balances"`
+	AsOf     time.Time         `json:"asOf"`
+}
+
+// TenantBalance aggregates all wallets of a tenant per currency as of AsOf.
+type TenantBalance struct {
+	Tenant   string            `json:"tenant"`
+	Balances []CurrencyBalance `json:"balances"`
+	AsOf     time.Time         `json:"asOf"`
+}
+
+// ScreeningHit records a denylist match that blocked a request.
+type ScreeningHit struct {
+	ID          uuid.UUID `json:"id"`
+	SubjectKind string    `json:"subjectKind"`
+	SubjectID   string    `json:"subjectId"`
+	List        string    `json:"list"`
+	Reason      string    `json:"reason"`
+	Action      string    `json:"action"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AtomicRequest is a list of dependent operations applied all-or-nothing.
+type AtomicRequest struct {
+	Steps []WalletOperation `json:"steps"`
+}
+
+// BatchRequest is a list of independent deposits and withdrawals, such as
+// a payroll run, applied all-or-nothing.
+type BatchRequest struct {
+	Operations []WalletOperation `json:"operations"`
+}
+
+// AtomicStepResult is the state of a wallet right after one step.
+type AtomicStepResult struct {
+	Index         int           `json:"index"`
+	WalletID      uuid.UUID     `json:"walletId"`
+	OperationType OperationType `json:"operationType"`
+	Amount        int64         `json:"amount"`
+	Balance       int64         `json:"balance"`
+	Version       int           `json:"version"`
+}
+
+// AtomicReceipt is the combined receipt of an atomic request.
+type AtomicReceipt struct {
+	ID        uuid.UUID          `json:"id"`
+	Steps     []AtomicStepResult `json:"steps"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// TransferRequest moves Amount from one wallet to another of the same
+// currency.
+type TransferRequest struct {
+	FromWalletID uuid.UUID `json:"fromWalletId"`
+	ToWalletID   uuid.UUID `json:"toWalletId"`
+	Amount       int64     `json:"amount"`
+}
+
+// Transfer is the receipt of a completed transfer, with the state of both
+// wallets right after it.
+type Transfer struct {
+	ID           uuid.UUID `json:"id"`
+	FromWalletID uuid.UUID `json:"fromWalletId"`
+	ToWalletID   uuid.UUID `json:"toWalletId"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	FromBalance  int64     `json:"fromBalance"`
+	FromVersion  int       `json:"fromVersion"`
+	ToBalance    int64     `json:"toBalance"`
+	ToVersion    int       `json:"toVersion"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ShardTransferStatus string
+
+const (
+	ShardTransferStatusPending     ShardTransferStatus = "PENDING"
+	ShardTransferStatusCompleted   ShardTransferStatus = "COMPLETED"
+	ShardTransferStatusCompensated ShardTransferStatus = "COMPENSATED"
+)
+
+// ShardTransfer is the durable record of a transfer between wallets on
+// different shards. It is written on the source shard together with the
+// debit and stays PENDING until the credit or its reversal is applied.
+type ShardTransfer struct {
+	ID           uuid.UUID           `json:"id"`
+	FromWalletID uuid.UUID           `json:"fromWalletId"`
+	ToWalletID   uuid.UUID           `json:"toWalletId"`
+	Amount       int64               `json:"amount"`
+	Status       ShardTransferStatus `json:"status"`
+	CreatedAt    time.Time           `json:"created_at"`
+	SettledAt    *time.Time          `json:"settled_at,omitempty"`
+}
+
+type MandateStatus string
+
+const (
+	MandateStatusActive  MandateStatus = "ACTIVE"
+	MandateStatusRevoked MandateStatus = "REVOKED"
+)
+
+// MandatePeriod is the window over which a mandate's limits are counted.
+type MandatePeriod string
+
+const (
+	MandatePeriodDay   MandatePeriod = "DAY"
+	MandatePeriodWeek  MandatePeriod = "WEEK"
+	MandatePeriodMonth MandatePeriod = "MONTH"
+)
+
+// Start returns the beginning of the period containing t, in UTC. Weeks
+// start on Monday. ok is false for an unknown period.
+func (p MandatePeriod) Start(t time.Time) (start time.Time, ok bool) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case MandatePeriodDay:
+		return day, true
+	case MandatePeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), true
+	case MandatePeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// Mandate authorizes Counterparty to debit WalletID: at most Limit in total
+// and MaxDebits debits (0 for no count limit) per Period.
+type Mandate struct {
+	ID           uuid.UUID     `json:"id"`
+	WalletID     uuid.UUID     `json:"walletId"`
+	Counterparty string        `json:"counterparty"`
+	Limit        int64         `json:"limit"`
+	MaxDebits    int           `json:"maxDebits"`
+	Period       MandatePeriod `json:"period"`
+	Status       MandateStatus `json:"status"`
+	CreatedAt    time.Time     `json:"created_at"`
+	RevokedAt    *time.Time    `json:"revoked_at,omitempty"`
+}
+
+type CreateMandateRequest struct {
+	Counterparty string        `json:"counterparty"`
+	Limit        int64         `json:"limit"`
+	MaxDebits    int           `json:"maxDebits"`
+	Period       MandatePeriod `json:"period"`
+}
+
+type MandateDebitRequest struct {
+	Counterparty string `json:"counterparty"`
+	Amount       int64  `json:"amount"`
+}
+
+// MandateDebit is one debit made under a mandate.
+type MandateDebit struct {
+	ID           uuid.UUID `json:"id"`
+	MandateID    uuid.UUID `json:"mandateId"`
+	WalletID     uuid.UUID `json:"walletId"`
+	Counterparty string    `json:"counterparty"`
+	Amount       int64     `json:"amount"`
+	Balance      int64     `json:"balance"`
+	Version      int       `json:"version"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Promotional credits are recorded in a wallet's history with these
+// operation types; they can't be submitted as regular operations.
+const (
+	OperationTypePromoCredit OperationType = "PROMO_CREDIT"
+	OperationTypePromoExpiry OperationType = "PROMO_EXPIRY"
+)
+
+// PromoCredit is a promotional credit; Remaining is the part of Amount not
+// yet spent. Withdrawals consume credits expiring soonest first, and the
+// expiry job removes whatever remains at ExpiresAt.
+type PromoCredit struct {
+	ID        uuid.UUID  `json:"id"`
+	WalletID  uuid.UUID  `json:"walletId"`
+	Amount    int64      `json:"amount"`
+	Remaining int64      `json:"remaining"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"`
+}
+
+type GrantPromoRequest struct {
+	Amount    int64     `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// OperationTypeReward credits accrued cashback to a rewards sub-wallet.
+const OperationTypeReward OperationType = "REWARD"
+
+// RewardsWalletLabel marks the rewards sub-wallet created for a wallet on
+// its first accrual.
+const RewardsWalletLabel = "rewards"
+
+// RewardAccrual is the reward earned by one transaction, identified by
+// TransactionID, of WalletID.
+type RewardAccrual struct {
+	TransactionID   string    `json:"transactionId"`
+	WalletID        uuid.UUID `json:"walletId"`
+	RewardsWalletID uuid.UUID `json:"rewardsWalletId"`
+	Rule            string    `json:"rule"`
+	Amount          int64     `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SuspenseWalletLabel marks the per-currency system wallets that hold
+// unmatched inbound funds.
+const SuspenseWalletLabel = "suspense"
+
+type SuspenseCaseStatus string
+
+const (
+	SuspenseCaseOpen     SuspenseCaseStatus = "OPEN"
+	SuspenseCaseResolved SuspenseCaseStatus = "RESOLVED"
+)
+
+// InboundCredit is funds received from outside for WalletID. Credits that
+// can't be applied to it land in suspense.
+type InboundCredit struct {
+	WalletID    uuid.NullUUID `json:"walletId"`
+	Reference   string        `json:"reference"`
+	Amount      int64         `json:"amount"`
+	Currency    string        `json:"currency"`
+	Description string        `json:"description,omitempty"`
+}
+
+// SuspenseCase tracks an inbound credit held in suspense until an operator
+// moves it to the right wallet. CreditVersion is the suspense wallet
+// version that received the funds; on resolution ReleaseVersion and
+// TargetVersion are the versions of the suspense and target wallets that
+// moved them.
+type SuspenseCase struct {
+	ID               uuid.UUID          `json:"id"`
+	SuspenseWalletID uuid.UUID          `json:"suspenseWalletId"`
+	Amount           int64              `json:"amount"`
+	Currency         string             `json:"currency"`
+	Reference        string             `json:"reference"`
+	Description      string             `json:"description,omitempty"`
+	Reason           string             `json:"reason"`
+	Status           SuspenseCaseStatus `json:"status"`
+	CreatedAt        time.Time          `json:"created_at"`
+	CreditVersion    int                `json:"creditVersion"`
+	ResolvedAt       *time.Time         `json:"resolved_at,omitempty"`
+	TargetWalletID   uuid.NullUUID      `json:"targetWalletId"`
+	ReleaseVersion   *int               `json:"releaseVersion,omitempty"`
+	TargetVersion    *int               `json:"targetVersion,omitempty"`
+	ResolutionNote   string             `json:"resolutionNote,omitempty"`
+}
+
+const (
+	InboundCredited = "CREDITED"
+	InboundSuspense = "SUSPENSE"
+)
+
+// InboundResult tells whether an inbound credit reached its wallet or was
+// put in suspense.
+type InboundResult struct {
+	Status string        `json:"status"`
+	Wallet *Wallet       `json:"wallet,omitempty"`
+	Case   *SuspenseCase `json:"case,omitempty"`
+}
+
+type ResolveSuspenseRequest struct {
+	WalletID uuid.UUID `json:"walletId"`
+	Note     string    `json:"note"`
+}
+
+// Reversals of disputed transactions are recorded with these operation
+// types.
+const (
+	OperationTypeReversalDebit  OperationType = "REVERSAL_DEBIT"
+	OperationTypeReversalCredit OperationType = "REVERSAL_CREDIT"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen     DisputeStatus = "OPEN"
+	DisputeStatusReversed DisputeStatus = "REVERSED"
+	DisputeStatusReleased DisputeStatus = "RELEASED"
+	DisputeStatusExpired  DisputeStatus = "EXPIRED"
+)
+
+// Dispute outcomes chosen on resolution.
+const (
+	DisputeOutcomeReverse = "REVERSE"
+	DisputeOutcomeRelease = "RELEASE"
+)
+
+// Dispute contests the operation that produced Version of WalletID. While
+// open, HoldAmount of the wallet can't be withdrawn; it is released when
+// the dispute is resolved or expires at Deadline, and ReversalVersion is
+// set if the operation was reversed.
+type Dispute struct {
+	ID              uuid.UUID     `json:"id"`
+	WalletID        uuid.UUID     `json:"walletId"`
+	Version         int           `json:"version"`
+	OperationType   OperationType `json:"operationType"`
+	Amount          int64         `json:"amount"`
+	HoldAmount      int64         `json:"holdAmount"`
+	Reason          string        `json:"reason"`
+	Status          DisputeStatus `json:"status"`
+	Deadline        time.Time     `json:"deadline"`
+	CreatedAt       time.Time     `json:"created_at"`
+	ResolvedAt      *time.Time    `json:"resolved_at,omitempty"`
+	ReversalVersion *int          `json:"reversalVersion,omitempty"`
+	Note            string        `json:"note,omitempty"`
+}
+
+// OpenDisputeRequest names the disputed operation by wallet and version.
+type OpenDisputeRequest struct {
+	WalletID uuid.UUID `json:"walletId"`
+	Version  int       `json:"version"`
+	Reason   string    `json:"reason"`
+}
+
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+// TransactionNote annotates a committed transaction, the wallet version at
+// ledger offset TransactionID, without touching the financial record.
+// Attachments are references, e.g. URLs or object keys, not file contents.
+type TransactionNote struct {
+	ID            uuid.UUID `json:"id"`
+	TransactionID int64     `json:"transactionId"`
+	Author        string    `json:"author"`
+	Body          string    `json:"body"`
+	Attachments   []string  `json:"attachments"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AddTransactionNoteRequest struct {
+	Author      string   `json:"author"`
+	Body        string   `json:"body"`
+	Attachments []string `json:"attachments"`
+}
+
+// OperationTypeOpeningBalance records the balance an imported wallet had in
+// the legacy ledger; it can't be submitted as a regular operation.
+const OperationTypeOpeningBalance OperationType = "OPENING_BALANCE"
+
+// ImportWalletRequest migrates a wallet from a legacy ledger. ExternalID is
+// its id there; a wallet is imported at most once per ExternalID. The
+// opening transaction is dated OpenedAt, or the time of the import if
+// unset, and Metadata is kept with it, e.g. the legacy account reference.
+type ImportWalletRequest struct {
+	ExternalID string            `json:"externalId"`
+	OwnerID    uuid.NullUUID     `json:"ownerId"`
+	Currency   string            `json:"currency"`
+	Label      string            `json:"label"`
+	Tenant     string            `json:"tenant"`
+	Balance    int64             `json:"balance"`
+	OpenedAt   *time.Time        `json:"openedAt,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Audit actions.
+const (
+	AuditWalletFrozen      = "wallet.frozen"
+	AuditWalletUnfrozen    = "wallet.unfrozen"
+	AuditWalletClosed      = "wallet.closed"
+	AuditWalletRestored    = "wallet.restored"
+	AuditWalletPurged      = "wallet.purged"
+	AuditLegalHoldPlaced   = "legal_hold.placed"
+	AuditLegalHoldReleased = "legal_hold.released"
+	AuditRecordsExported   = "records.exported"
+	AuditBalancesRebuilt   = "wallet.balances_rebuilt"
+	AuditCreditLimitSet    = "wallet.credit_limit_set"
+)
+
+// AuditEvent records an administrative action, such as freezing a wallet
+// or placing it under legal hold, and who took it. Events are kept in
+// monthly partitions by OccurredAt.
+type AuditEvent struct {
+	ID         uuid.UUID     `json:"id"`
+	WalletID   uuid.NullUUID `json:"walletId"`
+	Action     string        `json:"action"`
+	Actor      string        `json:"actor,omitempty"`
+	Detail     string        `json:"detail,omitempty"`
+	OccurredAt time.Time     `json:"occurredAt"`
+}
+
+// LegalHold preserves a wallet's records for legal proceedings: while it is
+// in place, retention purges skip the wallet's transactions and audit
+// events.
+type LegalHold struct {
 	WalletID uuid.UUID `json:"walletId"`
-	Balance  int64     `json:"balance"`
+	Reason   string    `json:"reason"`
+	PlacedBy string    `json:"placedBy,omitempty"`
+	PlacedAt time.Time `json:"placedAt"`
+}
+
+// PlaceLegalHoldRequest names the matter a legal hold is placed for, e.g.
+// a case or subpoena reference.
+type PlaceLegalHoldRequest struct {
+	Reason string `json:"reason"`
+}
+
+type FundsHoldStatus string
+
+const (
+	FundsHoldPending  FundsHoldStatus = "PENDING"
+	FundsHoldCaptured FundsHoldStatus = "CAPTURED"
+	FundsHoldReleased FundsHoldStatus = "RELEASED"
+)
+
+// FundsHold reserves Amount of a wallet's balance, e.g. for a card
+// authorization: it counts towards HeldBalance, so it can't be withdrawn,
+// but stays part of Balance until captured. Capturing withdraws up to
+// Amount as the operation of CaptureVersion and releases the rest.
+type FundsHold struct {
+	ID             uuid.UUID       `json:"id"`
+	WalletID       uuid.UUID       `json:"walletId"`
+	Amount         int64           `json:"amount"`
+	Reference      string          `json:"reference,omitempty"`
+	Status         FundsHoldStatus `json:"status"`
+	CapturedAmount int64           `json:"capturedAmount"`
+	CaptureVersion *int            `json:"captureVersion,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	ResolvedAt     *time.Time      `json:"resolvedAt,omitempty"`
+}
+
+// PlaceHoldRequest reserves Amount of a wallet's available balance.
+// Reference is the caller's name for the hold, e.g. an authorization code.
+type PlaceHoldRequest struct {
+	Amount    int64  `json:"amount"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// CaptureHoldRequest withdraws Amount of a hold; zero captures all of it.
+type CaptureHoldRequest struct {
+	Amount int64 `json:"amount"`
+}
+
+// OperationAttempt is one try of a request to apply a change, such as an
+// operation or a transfer. Conflicts with concurrent changes are retried,
+// so a request may take several attempts. OperationID is the request id;
+// ErrorClass is empty for the attempt that succeeded.
+type OperationAttempt struct {
+	OperationID string    `json:"operationId"`
+	Attempt     int       `json:"attempt"`
+	Op          string    `json:"op"`
+	ErrorClass  string    `json:"errorClass,omitempty"`
+	DurationMs  float64   `json:"durationMs"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// WebhookSubscription registers URL for the wallet events whose types are
+// in EventTypes and that pass Filter. Deliveries are signed with Secret,
+// which is never returned. The deliveries of a paused subscription are
+// queued but not attempted until it is resumed.
+type WebhookSubscription struct {
+	ID         uuid.UUID     `json:"id"`
+	URL        string        `json:"url"`
+	Secret     string        `json:"-"`
+	EventTypes []string      `json:"eventTypes"`
+	Filter     WebhookFilter `json:"filter"`
+	Paused     bool          `json:"paused"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	UpdatedAt  time.Time     `json:"updatedAt"`
+}
+
+// WebhookFilter narrows down the events reporting an operation, such as
+// balance.updated, to operations of OperationTypes, if any are given, of
+// at least MinAmount. Events without an operation pass.
+type WebhookFilter struct {
+	OperationTypes []OperationType `json:"operationTypes,omitempty"`
+	MinAmount      int64           `json:"minAmount,omitempty"`
+}
+
+// Matches reports whether e passes the filter.
+func (f WebhookFilter) Matches(e WebhookEvent) bool {
+	if e.OperationType == "" {
+		return true
+	}
+	if len(f.OperationTypes) > 0 && !slices.Contains(f.OperationTypes, e.OperationType) {
+		return false
+	}
+	return e.Amount >= f.MinAmount
+}
+
+// WebhookSubscriptionRequest creates or replaces a webhook subscription.
+// Replacing keeps the previous secret when Secret is empty.
+type WebhookSubscriptionRequest struct {
+	URL        string        `json:"url"`
+	Secret     string        `json:"secret"`
+	EventTypes []string      `json:"eventTypes"`
+	Filter     WebhookFilter `json:"filter"`
+}
+
+// WebhookSubscriptionStats sums up the deliveries to a subscription that
+// haven't been purged yet, and their attempts.
+type WebhookSubscriptionStats struct {
+	SubscriptionID  uuid.UUID  `json:"subscriptionId"`
+	Pending         int64      `json:"pending"`
+	Delivered       int64      `json:"delivered"`
+	Failed          int64      `json:"failed"`
+	Attempts        int64      `json:"attempts"`
+	FailedAttempts  int64      `json:"failedAttempts"`
+	AvgDurationMs   float64    `json:"avgDurationMs"`
+	LastAttemptAt   *time.Time `json:"lastAttemptAt,omitempty"`
+	LastDeliveredAt *time.Time `json:"lastDeliveredAt,omitempty"`
+}
+
+// WebhookTestResult is the outcome of a test delivery to a subscription.
+// StatusCode is zero when no response was received.
+type WebhookTestResult struct {
+	EventID    uuid.UUID `json:"eventId"`
+	Delivered  bool      `json:"delivered"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"durationMs"`
+}
+
+// BalanceUpdate is the data of a balance.updated webhook event: a wallet
+// after an operation was applied to it.
+type BalanceUpdate struct {
+	Wallet    *Wallet         `json:"wallet"`
+	Operation WalletOperation `json:"operation"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookEvent is an event queued for delivery to every subscription of its
+// type. Payload is the JSON body POSTed to the subscribers. OperationType
+// and Amount are those of the operation the event reports, if any, for
+// subscription filters.
+type WebhookEvent struct {
+	ID            uuid.UUID
+	Type          string
+	Payload       []byte
+	OperationType OperationType
+	Amount        int64
+	CreatedAt     time.Time
+}
+
+// OutboxEvent is a domain event written to the outbox in the transaction
+// of the change it reports, for the relay to publish. Seq orders the
+// events; PublishedAt is set once the broker has acknowledged the event.
+type OutboxEvent struct {
+	Seq         int64
+	ID          uuid.UUID
+	WalletID    uuid.UUID
+	Type        string
+	Payload     []byte
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+}
+
+// WebhookDelivery is an event on its way to one subscription. Pending
+// deliveries are attempted from NextAttemptAt on; URL and Secret are the
+// subscription's at the time the delivery was claimed.
+type WebhookDelivery struct {
+	EventID        uuid.UUID             `json:"eventId"`
+	SubscriptionID uuid.UUID             `json:"subscriptionId"`
+	EventType      string                `json:"eventType"`
+	Payload        []byte                `json:"-"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`
+	LastError      string                `json:"lastError,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	URL            string                `json:"-"`
+	Secret         string                `json:"-"`
+}
+
+// WebhookDeliveryAttempt is one POST of a delivery. StatusCode is zero when
+// no response was received.
+type WebhookDeliveryAttempt struct {
+	EventID        uuid.UUID `json:"eventId"`
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	EventType      string    `json:"eventType"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     float64   `json:"durationMs"`
+	AttemptedAt    time.Time `json:"attemptedAt"`
 }
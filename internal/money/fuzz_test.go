@@ -0,0 +1,66 @@
+package money
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// FuzzFormat checks that Format is lossless: dropping the decimal point
+// from its output must give back the amount, with exactly Exponent digits
+// after the point.
+func FuzzFormat(f *testing.F) {
+	for _, c := range []string{"USD", "JPY", "KWD", "usd", "XXX", ""} {
+		f.Add(int64(0), c)
+		f.Add(int64(-1), c)
+		f.Add(int64(1234), c)
+		f.Add(int64(-9223372036854775808), c)
+		f.Add(int64(9223372036854775807), c)
+	}
+
+	f.Fuzz(func(t *testing.T, amount int64, currency string) {
+		s, err := Format(amount, currency)
+		if err != nil {
+			if _, expErr := Exponent(currency); expErr == nil {
+				t.Fatalf("Format(%d, %q): %v for a known currency", amount, currency, err)
+			}
+			return
+		}
+		exp, _ := Exponent(currency)
+		whole, frac, found := strings.Cut(s, ".")
+		if found != (exp > 0) || len(frac) != exp || whole == "" || whole == "-" {
+			t.Fatalf("Format(%d, %q) = %q: malformed for exponent %d", amount, currency, s, exp)
+		}
+		back, err := strconv.ParseInt(whole+frac, 10, 64)
+		if err != nil || back != amount {
+			t.Fatalf("Format(%d, %q) = %q: parses back as %d (%v)", amount, currency, s, back, err)
+		}
+	})
+}
+
+// FuzzAllocate checks that an allocation never loses or creates money,
+// whatever the ratios.
+func FuzzAllocate(f *testing.F) {
+	f.Add(int64(100), int64(1), int64(1), int64(1))
+	f.Add(int64(-101), int64(2), int64(0), int64(1))
+	f.Add(int64(9223372036854775807), int64(9223372036854775807), int64(1), int64(0))
+	f.Add(int64(5), int64(0), int64(0), int64(0))
+	f.Add(int64(5), int64(-1), int64(2), int64(0))
+
+	f.Fuzz(func(t *testing.T, amount, a, b, c int64) {
+		parts, err := Allocate(amount, a, b, c)
+		if err != nil {
+			return
+		}
+		if len(parts) != 3 {
+			t.Fatalf("Allocate(%d, %d, %d, %d) returned %d parts", amount, a, b, c, len(parts))
+		}
+		var sum int64
+		for _, p := range parts {
+			sum += p
+		}
+		if sum != amount {
+			t.Fatalf("Allocate(%d, %d, %d, %d) = %v, sums to %d", amount, a, b, c, parts, sum)
+		}
+	})
+}
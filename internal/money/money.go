@@ -0,0 +1,183 @@
+// Package money does arithmetic on amounts in minor units (cents), where
+// every division has to decide what happens to the remainder: allocations
+// hand it out so nothing is lost, percentages round it explicitly.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrOverflow        = errors.New("amount overflows int64")
+	ErrInvalidRatios   = errors.New("ratios must be non-negative and not all zero")
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// BasisPoints is the denominator of rates given in basis points.
+const BasisPoints = 10000
+
+// RoundingMode decides what happens to a fractional minor unit.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest unit and ties to the even one
+	// (banker's rounding), so rounding errors don't drift in one direction
+	// over many operations.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest unit and ties away from zero.
+	RoundHalfUp
+	// RoundDown truncates toward zero.
+	RoundDown
+)
+
+// MulDiv returns amount*num/den rounded with mode. The product is computed
+// exactly, so it only fails if the result itself doesn't fit in an int64.
+func MulDiv(amount, num, den int64, mode RoundingMode) (int64, error) {
+	if den == 0 {
+		return 0, errors.New("division by zero")
+	}
+	n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(num))
+	d := big.NewInt(den)
+	if d.Sign() < 0 {
+		n.Neg(n)
+		d.Neg(d)
+	}
+
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() != 0 && mode != RoundDown {
+		// Compare twice the remainder with the divisor to find the side of
+		// the half-way point without leaving integers.
+		twice := new(big.Int).Abs(r)
+		cmp := twice.Lsh(twice, 1).Cmp(d)
+		if cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)) {
+			q.Add(q, big.NewInt(int64(n.Sign())))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
+
+// Percent returns basisPoints/10000 of amount with banker's rounding, e.g.
+// a 2.5% fee is Percent(amount, 250).
+func Percent(amount, basisPoints int64) (int64, error) {
+	return MulDiv(amount, basisPoints, BasisPoints, RoundHalfEven)
+}
+
+// Allocate splits amount across len(ratios) parts in proportion to ratios.
+// The parts always add up to amount: each gets its share rounded toward
+// zero, and the units left over go one each to the parts with the largest
+// remainders, earlier parts first on ties. No part is more than one unit
+// away from its exact share.
+func Allocate(amount int64, ratios ...int64) ([]int64, error) {
+	total := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, ErrInvalidRatios
+		}
+		total.Add(total, big.NewInt(r))
+	}
+	if total.Sign() == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	parts := make([]int64, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	left := amount
+	for i, r := range ratios {
+		n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(r))
+		q, rem := n.QuoRem(n, total, new(big.Int))
+		// |q| <= |amount| since r <= total, so it always fits.
+		parts[i] = q.Int64()
+		remainders[i] = rem.Abs(rem)
+		left -= parts[i]
+	}
+
+	step := int64(1)
+	if left < 0 {
+		step = -1
+	}
+	for ; left != 0; left -= step {
+		best := -1
+		for i, rem := range remainders {
+			if rem.Sign() > 0 && (best < 0 || rem.Cmp(remainders[best]) > 0) {
+				best = i
+			}
+		}
+		parts[best] += step
+		remainders[best].SetInt64(0)
+	}
+	return parts, nil
+}
+
+// Split divides amount into n parts differing by at most one unit, larger
+// parts first.
+func Split(amount int64, n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, ErrInvalidRatios
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return Allocate(amount, ratios...)
+}
+
+// exponents are the ISO 4217 minor unit digits of currencies that don't
+// use the usual two.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// twoDigit are the remaining supported currencies.
+var twoDigit = []string{
+	"AED", "ARS", "AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "COP", "CZK",
+	"DKK", "EGP", "EUR", "GBP", "HKD", "HUF", "IDR", "ILS", "INR", "KZT",
+	"MAD", "MXN", "MYR", "NGN", "NOK", "NZD", "PEN", "PHP", "PKR", "PLN",
+	"RON", "RSD", "RUB", "SAR", "SEK", "SGD", "THB", "TRY", "TWD", "UAH",
+	"USD", "ZAR",
+}
+
+func init() {
+	for _, c := range twoDigit {
+		exponents[c] = 2
+	}
+}
+
+// Exponent returns the number of minor unit digits of currency, e.g. 2 for
+// USD and 0 for JPY.
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[strings.ToUpper(currency)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// Format renders amount minor units of currency as a decimal, e.g.
+// Format(-1234, "USD") is "-12.34".
+func Format(amount int64, currency string) (string, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return "", err
+	}
+	digits := new(big.Int).Abs(big.NewInt(amount)).String()
+	if exp > 0 {
+		if len(digits) <= exp {
+			digits = strings.Repeat("0", exp-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+	}
+	if amount < 0 {
+		digits = "-" + digits
+	}
+	return digits, nil
+}
@@ -0,0 +1,174 @@
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		num, den int64
+		halfEven int64
+		halfUp   int64
+		down     int64
+	}{
+		{name: "exact", amount: 1000, num: 250, den: 10000, halfEven: 25, halfUp: 25, down: 25},
+		{name: "below half", amount: 1001, num: 1, den: 10, halfEven: 100, halfUp: 100, down: 100},
+		{name: "above half", amount: 1006, num: 1, den: 10, halfEven: 101, halfUp: 101, down: 100},
+		{name: "half to even down", amount: 1025, num: 1, den: 10, halfEven: 102, halfUp: 103, down: 102},
+		{name: "half to even up", amount: 1035, num: 1, den: 10, halfEven: 104, halfUp: 104, down: 103},
+		{name: "negative half to even", amount: -1025, num: 1, den: 10, halfEven: -102, halfUp: -103, down: -102},
+		{name: "negative above half", amount: -1006, num: 1, den: 10, halfEven: -101, halfUp: -101, down: -100},
+		{name: "negative denominator", amount: 1035, num: 1, den: -10, halfEven: -104, halfUp: -104, down: -103},
+		{name: "zero", amount: 0, num: 7, den: 3},
+		{name: "product beyond int64", amount: math.MaxInt64, num: 3, den: 4, halfEven: 6917529027641081855, halfUp: 6917529027641081855, down: 6917529027641081855},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for mode, want := range map[RoundingMode]int64{RoundHalfEven: tt.halfEven, RoundHalfUp: tt.halfUp, RoundDown: tt.down} {
+				got, err := MulDiv(tt.amount, tt.num, tt.den, mode)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, "mode %d", mode)
+			}
+		})
+	}
+
+	_, err := MulDiv(math.MaxInt64, 2, 1, RoundHalfEven)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = MulDiv(1, 1, 0, RoundHalfEven)
+	assert.Error(t, err)
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		amount, bps, want int64
+	}{
+		{amount: 10000, bps: 250, want: 250},
+		{amount: 199, bps: 250, want: 5},  // 4.975
+		{amount: 20, bps: 2500, want: 5},  // exact
+		{amount: 2, bps: 2500, want: 0},   // 0.5 ties to even 0
+		{amount: 6, bps: 2500, want: 2},   // 1.5 ties to even 2
+		{amount: 10, bps: 2500, want: 2},  // 2.5 ties to even 2
+		{amount: -6, bps: 2500, want: -2}, // -1.5 ties to even -2
+		{amount: 12345, bps: 10000, want: 12345},
+		{amount: 12345, bps: 0, want: 0},
+	}
+	for _, tt := range tests {
+		got, err := Percent(tt.amount, tt.bps)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "Percent(%d, %d)", tt.amount, tt.bps)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		ratios []int64
+		want   []int64
+	}{
+		{name: "even", amount: 100, ratios: []int64{1, 1}, want: []int64{50, 50}},
+		{name: "thirds", amount: 100, ratios: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "exact shares", amount: 100, ratios: []int64{30, 70}, want: []int64{30, 70}},
+		{name: "remainder to larger fraction", amount: 10, ratios: []int64{1, 2}, want: []int64{3, 7}},
+		{name: "tie goes to earlier part", amount: 5, ratios: []int64{3, 7}, want: []int64{2, 3}},
+		{name: "zero ratio gets nothing", amount: 10, ratios: []int64{0, 1, 2}, want: []int64{0, 3, 7}},
+		{name: "negative amount", amount: -100, ratios: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "fewer units than parts", amount: 2, ratios: []int64{1, 1, 1, 1}, want: []int64{1, 1, 0, 0}},
+		{name: "zero amount", amount: 0, ratios: []int64{1, 2}, want: []int64{0, 0}},
+		{name: "huge amount", amount: math.MaxInt64, ratios: []int64{1, 1}, want: []int64{4611686018427387904, 4611686018427387903}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.amount, tt.ratios...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, ratios := range [][]int64{nil, {0, 0}, {1, -1}} {
+		_, err := Allocate(100, ratios...)
+		assert.ErrorIs(t, err, ErrInvalidRatios, "ratios %v", ratios)
+	}
+}
+
+// TestAllocate_Exhaustive checks over a grid of amounts and ratios that
+// nothing is lost and no part strays more than one unit from its share.
+func TestAllocate_Exhaustive(t *testing.T) {
+	ratioSets := [][]int64{{1}, {1, 1}, {1, 2}, {1, 1, 1}, {3, 7}, {1, 2, 3, 4}, {0, 5, 5}, {97, 1, 1, 1}, {1, 1, 1, 1, 1, 1, 1}}
+	for _, ratios := range ratioSets {
+		var total int64
+		for _, r := range ratios {
+			total += r
+		}
+		for amount := int64(-250); amount <= 250; amount++ {
+			parts, err := Allocate(amount, ratios...)
+			require.NoError(t, err)
+
+			var sum int64
+			for i, p := range parts {
+				sum += p
+				exact := float64(amount) * float64(ratios[i]) / float64(total)
+				assert.LessOrEqual(t, math.Abs(float64(p)-exact), 1.0, "amount %d ratios %v part %d", amount, ratios, i)
+				if ratios[i] == 0 {
+					assert.Zero(t, p)
+				}
+			}
+			require.Equal(t, amount, sum, "amount %d ratios %v", amount, ratios)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	got, err := Split(1000, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{334, 333, 333}, got)
+
+	got, err = Split(7, 7)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 1, 1, 1, 1, 1, 1}, got)
+
+	_, err = Split(100, 0)
+	assert.ErrorIs(t, err, ErrInvalidRatios)
+}
+
+func TestExponent(t *testing.T) {
+	for currency, want := range map[string]int{"USD": 2, "eur": 2, "JPY": 0, "KRW": 0, "KWD": 3, "BHD": 3, "CLF": 4} {
+		got, err := Exponent(currency)
+		require.NoError(t, err, currency)
+		assert.Equal(t, want, got, currency)
+	}
+
+	_, err := Exponent("XXX")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 1234, currency: "USD", want: "12.34"},
+		{amount: -1234, currency: "USD", want: "-12.34"},
+		{amount: 5, currency: "USD", want: "0.05"},
+		{amount: -5, currency: "EUR", want: "-0.05"},
+		{amount: 0, currency: "USD", want: "0.00"},
+		{amount: 1234, currency: "JPY", want: "1234"},
+		{amount: 1234, currency: "KWD", want: "1.234"},
+		{amount: math.MinInt64, currency: "USD", want: "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.amount, tt.currency)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := Format(1, "XXX")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
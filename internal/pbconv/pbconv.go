@@ -0,0 +1,190 @@
+// Package pbconv converts between the internal models and their protobuf
+// counterparts in proto/wallet/v1.
+package pbconv
+
+import (
+	"fmt"
+	"strings"
+	"wallet-service/internal/models"
+	walletv1 "wallet-service/proto/wallet/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	operationTypePrefix = "OPERATION_TYPE_"
+	walletStatusPrefix  = "WALLET_STATUS_"
+)
+
+// OperationType maps t to its enum value; unknown types map to
+// OPERATION_TYPE_UNSPECIFIED.
+func OperationType(t models.OperationType) walletv1.OperationType {
+	return walletv1.OperationType(walletv1.OperationType_value[operationTypePrefix+string(t)])
+}
+
+func OperationTypeFromProto(t walletv1.OperationType) (models.OperationType, error) {
+	if t == walletv1.OperationType_OPERATION_TYPE_UNSPECIFIED {
+		return "", fmt.Errorf("unspecified operation type")
+	}
+	name, ok := walletv1.OperationType_name[int32(t)]
+	if !ok {
+		return "", fmt.Errorf("unknown operation type %d", t)
+	}
+	return models.OperationType(strings.TrimPrefix(name, operationTypePrefix)), nil
+}
+
+func WalletStatus(s models.WalletStatus) walletv1.WalletStatus {
+	return walletv1.WalletStatus(walletv1.WalletStatus_value[walletStatusPrefix+string(s)])
+}
+
+func WalletStatusFromProto(s walletv1.WalletStatus) (models.WalletStatus, error) {
+	if s == walletv1.WalletStatus_WALLET_STATUS_UNSPECIFIED {
+		return "", fmt.Errorf("unspecified wallet status")
+	}
+	name, ok := walletv1.WalletStatus_name[int32(s)]
+	if !ok {
+		return "", fmt.Errorf("unknown wallet status %d", s)
+	}
+	return models.WalletStatus(strings.TrimPrefix(name, walletStatusPrefix)), nil
+}
+
+func Wallet(w *models.Wallet) *walletv1.Wallet {
+	pb := &walletv1.Wallet{
+		Id:           w.ID.String(),
+		Balance:      w.Balance,
+		CreatedAt:    timestamppb.New(w.CreatedAt),
+		UpdatedAt:    timestamppb.New(w.UpdatedAt),
+		Version:      int32(w.Version),
+		Currency:     w.Currency,
+		Status:       WalletStatus(w.Status),
+		Label:        w.Label,
+		Tenant:       w.Tenant,
+		PromoBalance: w.PromoBalance,
+		HeldBalance:  w.HeldBalance,
+		CreditLimit:  w.CreditLimit,
+	}
+	if w.OwnerID.Valid {
+		pb.OwnerId = w.OwnerID.UUID.String()
+	}
+	return pb
+}
+
+func WalletFromProto(pb *walletv1.Wallet) (*models.Wallet, error) {
+	id, err := uuid.Parse(pb.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet id: %w", err)
+	}
+	status, err := WalletStatusFromProto(pb.GetStatus())
+	if err != nil {
+		return nil, err
+	}
+	w := &models.Wallet{
+		ID:           id,
+		Balance:      pb.GetBalance(),
+		CreatedAt:    pb.GetCreatedAt().AsTime(),
+		UpdatedAt:    pb.GetUpdatedAt().AsTime(),
+		Version:      int(pb.GetVersion()),
+		Currency:     pb.GetCurrency(),
+		Status:       status,
+		Label:        pb.GetLabel(),
+		Tenant:       pb.GetTenant(),
+		PromoBalance: pb.GetPromoBalance(),
+		HeldBalance:  pb.GetHeldBalance(),
+		CreditLimit:  pb.GetCreditLimit(),
+	}
+	if pb.GetOwnerId() != "" {
+		ownerID, err := uuid.Parse(pb.GetOwnerId())
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner id: %w", err)
+		}
+		w.OwnerID = uuid.NullUUID{UUID: ownerID, Valid: true}
+	}
+	return w, nil
+}
+
+func WalletOperation(op models.WalletOperation) *walletv1.WalletOperation {
+	return &walletv1.WalletOperation{
+		WalletId:      op.WalletID.String(),
+		OperationType: OperationType(op.OperationType),
+		Amount:        op.Amount,
+		Category:      op.Category,
+	}
+}
+
+func WalletOperationFromProto(pb *walletv1.WalletOperation) (models.WalletOperation, error) {
+	id, err := uuid.Parse(pb.GetWalletId())
+	if err != nil {
+		return models.WalletOperation{}, fmt.Errorf("invalid wallet id: %w", err)
+	}
+	opType, err := OperationTypeFromProto(pb.GetOperationType())
+	if err != nil {
+		return models.WalletOperation{}, err
+	}
+	return models.WalletOperation{
+		WalletID:      id,
+		OperationType: opType,
+		Amount:        pb.GetAmount(),
+		Category:      pb.GetCategory(),
+	}, nil
+}
+
+func Transaction(v models.WalletVersion) *walletv1.Transaction {
+	return &walletv1.Transaction{
+		WalletId:      v.WalletID.String(),
+		Version:       int32(v.Version),
+		Balance:       v.Balance,
+		OperationType: OperationType(v.OperationType),
+		Amount:        v.Amount,
+		CreatedAt:     timestamppb.New(v.CreatedAt),
+	}
+}
+
+func TransactionFromProto(pb *walletv1.Transaction) (models.WalletVersion, error) {
+	id, err := uuid.Parse(pb.GetWalletId())
+	if err != nil {
+		return models.WalletVersion{}, fmt.Errorf("invalid wallet id: %w", err)
+	}
+	opType, err := OperationTypeFromProto(pb.GetOperationType())
+	if err != nil {
+		return models.WalletVersion{}, err
+	}
+	return models.WalletVersion{
+		WalletID:      id,
+		Version:       int(pb.GetVersion()),
+		Balance:       pb.GetBalance(),
+		OperationType: opType,
+		Amount:        pb.GetAmount(),
+		CreatedAt:     pb.GetCreatedAt().AsTime(),
+	}, nil
+}
+
+func LedgerEvent(e models.LedgerEvent) *walletv1.LedgerEvent {
+	return &walletv1.LedgerEvent{
+		Offset: e.Offset,
+		Transaction: Transaction(models.WalletVersion{
+			WalletID:      e.WalletID,
+			Version:       e.Version,
+			Balance:       e.Balance,
+			OperationType: e.OperationType,
+			Amount:        e.Amount,
+			CreatedAt:     e.CreatedAt,
+		}),
+	}
+}
+
+func LedgerEventFromProto(pb *walletv1.LedgerEvent) (models.LedgerEvent, error) {
+	v, err := TransactionFromProto(pb.GetTransaction())
+	if err != nil {
+		return models.LedgerEvent{}, err
+	}
+	return models.LedgerEvent{
+		Offset:        pb.GetOffset(),
+		WalletID:      v.WalletID,
+		Version:       v.Version,
+		OperationType: v.OperationType,
+		Amount:        v.Amount,
+		Balance:       v.Balance,
+		CreatedAt:     v.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package pbconv
+
+import (
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	walletv1 "wallet-service/proto/wallet/v1"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWallet_RoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	w := &models.Wallet{
+		ID:          uuid.New(),
+		Balance:     1500,
+		CreatedAt:   now,
+		UpdatedAt:   now.Add(time.Minute),
+		Version:     3,
+		OwnerID:     uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		Currency:    "EUR",
+		Status:      models.WalletStatusFrozen,
+		Tenant:      "acme",
+		HeldBalance: 200,
+		CreditLimit: 500,
+	}
+
+	b, err := proto.Marshal(Wallet(w))
+	require.NoError(t, err)
+	var pb walletv1.Wallet
+	require.NoError(t, proto.Unmarshal(b, &pb))
+	got, err := WalletFromProto(&pb)
+
+	require.NoError(t, err)
+	assert.Equal(t, w, got)
+}
+
+func TestOperationType(t *testing.T) {
+	for _, opType := range []models.OperationType{
+		models.OperationTypeCreate,
+		models.OperationTypeDeposit,
+		models.OperationTypeWithdraw,
+		models.OperationTypePromoCredit,
+		models.OperationTypePromoExpiry,
+		models.OperationTypeReward,
+		models.OperationTypeReversalDebit,
+		models.OperationTypeReversalCredit,
+	} {
+		pb := OperationType(opType)
+		assert.NotEqual(t, walletv1.OperationType_OPERATION_TYPE_UNSPECIFIED, pb, opType)
+		got, err := OperationTypeFromProto(pb)
+		require.NoError(t, err)
+		assert.Equal(t, opType, got)
+	}
+
+	assert.Equal(t, walletv1.OperationType_OPERATION_TYPE_UNSPECIFIED, OperationType("BOGUS"))
+	_, err := OperationTypeFromProto(walletv1.OperationType_OPERATION_TYPE_UNSPECIFIED)
+	assert.Error(t, err)
+}
+
+func TestLedgerEvent_RoundTrip(t *testing.T) {
+	e := models.LedgerEvent{
+		Offset:        42,
+		WalletID:      uuid.New(),
+		Version:       2,
+		OperationType: models.OperationTypeDeposit,
+		Amount:        100,
+		Balance:       100,
+		CreatedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	got, err := LedgerEventFromProto(LedgerEvent(e))
+
+	require.NoError(t, err)
+	assert.Equal(t, e, got)
+}
@@ -0,0 +1,38 @@
+package reconcile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// FuzzParse runs both settlement file parsers over arbitrary input. Any
+// failure must be reported as ErrInvalidFile, and every parsed entry must
+// carry the line number it came from.
+func FuzzParse(f *testing.F) {
+	f.Add(FormatCSV, "date,amount,reference,description\n2024-05-01,1000,w1:2,top up\n")
+	f.Add(FormatCSV, "reference,amount,date\nx,9223372036854775808,2024-05-01\n")
+	f.Add(FormatCSV, "reference,amount,date\n\"x,1\n")
+	f.Add(FormatCSV, "reference,amount,date,reference\nx,1,2024-05-01\n")
+	f.Add(FormatMT940, ":61:2405010501C10,00NTRFNONREF//w1:2\n:86:top up\n")
+	f.Add(FormatMT940, ":61:240502RD2,5NTRFBANK123\n")
+	f.Add(FormatMT940, ":61:240502D,NTRF\n")
+	f.Add(FormatMT940, ":61:240502C1,234NTRF\n")
+	f.Add(FormatMT940, ":61:240502C99999999999999999,99NTRF\n")
+	f.Add(FormatMT940, ":86:description before any entry\n")
+
+	f.Fuzz(func(t *testing.T, format, file string) {
+		entries, err := Parse(format, strings.NewReader(file))
+		if err != nil {
+			if !errors.Is(err, ErrInvalidFile) {
+				t.Fatalf("Parse(%q, %q): error %v is not ErrInvalidFile", format, file, err)
+			}
+			return
+		}
+		for _, e := range entries {
+			if e.Line < 1 {
+				t.Fatalf("Parse(%q, %q): entry %+v has no line number", format, file, e)
+			}
+		}
+	})
+}
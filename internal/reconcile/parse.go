@@ -0,0 +1,177 @@
+package reconcile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatMT940 = "mt940"
+)
+
+// Parse reads a settlement file in the given format.
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatMT940:
+		return ParseMT940(r)
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidFile, format)
+}
+
+// ParseCSV reads a CSV file with a header naming at least the reference,
+// amount and date (YYYY-MM-DD) columns, in any order; a description column
+// is optional. Amounts are signed decimal numbers in minor units.
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrInvalidFile, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"reference", "amount", "date"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("%w: missing %q column", ErrInvalidFile, name)
+		}
+	}
+
+	var entries []Entry
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(record[cols["amount"]]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid amount", ErrInvalidFile, line)
+		}
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(record[cols["date"]]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid date", ErrInvalidFile, line)
+		}
+		e := Entry{
+			Line:      line,
+			Reference: strings.TrimSpace(record[cols["reference"]]),
+			Amount:    amount,
+			Date:      date,
+		}
+		if i, ok := cols["description"]; ok {
+			e.Description = strings.TrimSpace(record[i])
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ParseMT940 reads the statement lines (:61:) of an MT940 file, with the
+// following :86: field as description. Of the :61: subfields only the value
+// date, the debit/credit mark, the amount (two decimals, comma separated)
+// and the reference after "//" (or the customer reference without one) are
+// used.
+func ParseMT940(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(text, ":61:"):
+			e, err := parseStatementLine(text[len(":61:"):])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+			}
+			e.Line = line
+			entries = append(entries, e)
+		case strings.HasPrefix(text, ":86:") && len(entries) > 0:
+			entries[len(entries)-1].Description = strings.TrimSpace(text[len(":86:"):])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return entries, nil
+}
+
+// parseStatementLine parses e.g. "2405010501D12,50NTRFNONREF//ref".
+func parseStatementLine(s string) (Entry, error) {
+	if len(s) < 6 {
+		return Entry{}, errors.New("statement line too short")
+	}
+	date, err := time.Parse("060102", s[:6])
+	if err != nil {
+		return Entry{}, errors.New("invalid value date")
+	}
+	s = s[6:]
+	// optional entry date
+	if len(s) >= 4 && s[0] >= '0' && s[0] <= '9' {
+		s = s[4:]
+	}
+
+	sign := int64(1)
+	switch {
+	case strings.HasPrefix(s, "RC"):
+		sign, s = -1, s[2:]
+	case strings.HasPrefix(s, "RD"):
+		s = s[2:]
+	case strings.HasPrefix(s, "C"):
+		s = s[1:]
+	case strings.HasPrefix(s, "D"):
+		sign, s = -1, s[1:]
+	default:
+		return Entry{}, errors.New("missing debit/credit mark")
+	}
+	// optional funds code
+	if s != "" && s[0] >= 'A' && s[0] <= 'Z' {
+		s = s[1:]
+	}
+
+	end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != ',' })
+	if end <= 0 {
+		return Entry{}, errors.New("missing amount")
+	}
+	amount, err := parseMT940Amount(s[:end])
+	if err != nil {
+		return Entry{}, err
+	}
+	s = s[end:]
+
+	// transaction type identification code, e.g. NTRF
+	if len(s) < 4 {
+		return Entry{}, errors.New("missing transaction type")
+	}
+	s = s[4:]
+
+	reference := s
+	if i := strings.Index(s, "//"); i >= 0 {
+		reference = s[i+2:]
+	}
+	return Entry{Reference: strings.TrimSpace(reference), Amount: sign * amount, Date: date}, nil
+}
+
+func parseMT940Amount(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ",")
+	if len(frac) > 2 {
+		return 0, errors.New("invalid amount")
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount")
+	}
+	return n, nil
+}
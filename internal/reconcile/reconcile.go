@@ -0,0 +1,105 @@
+package reconcile
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrInvalidFile = errors.New("invalid settlement file")
+
+// Entry is one line of an external settlement file. Amount is in minor
+// units, positive for credits and negative for debits.
+type Entry struct {
+	Line        int       `json:"line"`
+	Reference   string    `json:"reference"`
+	Amount      int64     `json:"amount"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Transaction is an internal transaction to reconcile against, signed the
+// same way as Entry.
+type Transaction struct {
+	ID     string    `json:"id"`
+	Amount int64     `json:"amount"`
+	Date   time.Time `json:"date"`
+}
+
+const (
+	ByReference  = "reference"
+	ByAmountDate = "amount_date"
+)
+
+type Match struct {
+	Entry       Entry       `json:"entry"`
+	Transaction Transaction `json:"transaction"`
+	By          string      `json:"by"`
+}
+
+// Report is the outcome of a reconciliation: entries matched to internal
+// transactions, internal transactions missing from the file and file
+// entries with no internal counterpart.
+type Report struct {
+	Matched    []Match       `json:"matched"`
+	Missing    []Transaction `json:"missing"`
+	Unexpected []Entry       `json:"unexpected"`
+}
+
+// Reconcile pairs entries with transactions. An entry whose reference is the ID
+// of a transaction with the same amount matches it; the remaining entries
+// are matched to the earliest unmatched transaction with the same amount
+// whose date is within dateTolerance.
+func Reconcile(entries []Entry, txs []Transaction, dateTolerance time.Duration) Report {
+	report := Report{Matched: []Match{}, Missing: []Transaction{}, Unexpected: []Entry{}}
+
+	sorted := make([]Transaction, len(txs))
+	copy(sorted, txs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	byID := make(map[string]int, len(sorted))
+	for i, tx := range sorted {
+		byID[tx.ID] = i
+	}
+	used := make([]bool, len(sorted))
+
+	var rest []Entry
+	for _, e := range entries {
+		if i, ok := byID[e.Reference]; ok && !used[i] && sorted[i].Amount == e.Amount {
+			used[i] = true
+			report.Matched = append(report.Matched, Match{Entry: e, Transaction: sorted[i], By: ByReference})
+			continue
+		}
+		rest = append(rest, e)
+	}
+
+	for _, e := range rest {
+		matched := false
+		for i, tx := range sorted {
+			if used[i] || tx.Amount != e.Amount || absDuration(tx.Date.Sub(e.Date)) > dateTolerance {
+				continue
+			}
+			used[i] = true
+			matched = true
+			report.Matched = append(report.Matched, Match{Entry: e, Transaction: tx, By: ByAmountDate})
+			break
+		}
+		if !matched {
+			report.Unexpected = append(report.Unexpected, e)
+		}
+	}
+
+	for i, tx := range sorted {
+		if !used[i] {
+			report.Missing = append(report.Missing, tx)
+		}
+	}
+	return report
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,86 @@
+package reconcile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func TestReconcile(t *testing.T) {
+	txs := []Transaction{
+		{ID: "w1:2", Amount: 1000, Date: day("2024-05-01")},
+		{ID: "w1:3", Amount: -250, Date: day("2024-05-02")},
+		{ID: "w2:2", Amount: 500, Date: day("2024-05-02")},
+		{ID: "w2:3", Amount: 700, Date: day("2024-05-03")},
+	}
+	entries := []Entry{
+		{Line: 2, Reference: "w1:2", Amount: 1000, Date: day("2024-05-01")},
+		{Line: 3, Reference: "BANK123", Amount: -250, Date: day("2024-05-03")},
+		{Line: 4, Reference: "w2:2", Amount: 499, Date: day("2024-05-02")},
+		{Line: 5, Reference: "BANK124", Amount: 900, Date: day("2024-05-03")},
+	}
+
+	report := Reconcile(entries, txs, 24*time.Hour)
+
+	require.Len(t, report.Matched, 2)
+	assert.Equal(t, ByReference, report.Matched[0].By)
+	assert.Equal(t, "w1:2", report.Matched[0].Transaction.ID)
+	assert.Equal(t, ByAmountDate, report.Matched[1].By)
+	assert.Equal(t, "w1:3", report.Matched[1].Transaction.ID)
+
+	require.Len(t, report.Missing, 2)
+	assert.Equal(t, "w2:2", report.Missing[0].ID)
+	assert.Equal(t, "w2:3", report.Missing[1].ID)
+
+	require.Len(t, report.Unexpected, 2)
+	assert.Equal(t, 4, report.Unexpected[0].Line)
+	assert.Equal(t, 5, report.Unexpected[1].Line)
+}
+
+func TestParseCSV(t *testing.T) {
+	file := "date,amount,reference,description\n2024-05-01,1000,w1:2,top up\n2024-05-02,-250,BANK123,\n"
+
+	entries, err := ParseCSV(strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Line: 2, Reference: "w1:2", Amount: 1000, Date: day("2024-05-01"), Description: "top up"},
+		{Line: 3, Reference: "BANK123", Amount: -250, Date: day("2024-05-02")},
+	}, entries)
+
+	_, err = ParseCSV(strings.NewReader("date,amount\n2024-05-01,1\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	_, err = ParseCSV(strings.NewReader("date,amount,reference\n01/05/2024,1,x\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
+
+func TestParseMT940(t *testing.T) {
+	file := `:20:STMT
+:25:NL00BANK0123456789
+:60F:C240430EUR100,00
+:61:2405010501C10,00NTRFNONREF//w1:2
+:86:top up
+:61:240502D2,5NTRFBANK123
+:62F:C240502EUR107,50
+`
+
+	entries, err := ParseMT940(strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Line: 4, Reference: "w1:2", Amount: 1000, Date: day("2024-05-01"), Description: "top up"},
+		{Line: 6, Reference: "BANK123", Amount: -250, Date: day("2024-05-02")},
+	}, entries)
+
+	_, err = ParseMT940(strings.NewReader(":61:240501X10,00NTRF\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/internal/storage"
+)
+
+// Deliverer sends a generated report to its destination.
+type Deliverer interface {
+	Deliver(ctx context.Context, r *Report) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// EmailDeliverer mails the report as an attachment.
+type EmailDeliverer struct {
+	smtp SMTPConfig
+	to   []string
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmailDeliverer(cfg SMTPConfig, to []string) *EmailDeliverer {
+	return &EmailDeliverer{smtp: cfg, to: to, send: smtp.SendMail}
+}
+
+func (d *EmailDeliverer) Deliver(_ context.Context, r *Report) error {
+	var auth smtp.Auth
+	if d.smtp.Username != "" {
+		auth = smtp.PlainAuth("", d.smtp.Username, d.smtp.Password, d.smtp.Host)
+	}
+	addr := net.JoinHostPort(d.smtp.Host, strconv.Itoa(d.smtp.Port))
+	return d.send(addr, auth, d.smtp.From, d.to, buildMessage(d.smtp.From, d.to, r))
+}
+
+func buildMessage(from string, to []string, r *Report) []byte {
+	const boundary = "wallet-report-boundary"
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", r.Name))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s is attached.\r\n\r\n", r.Name)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", r.ContentType)
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n\r\n", r.Filename)
+	encoded := base64.StdEncoding.EncodeToString(r.Body)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes()
+}
+
+// StorageDeliverer uploads the report under prefix in object storage.
+type StorageDeliverer struct {
+	store  storage.ObjectStore
+	prefix string
+}
+
+func NewStorageDeliverer(store storage.ObjectStore, prefix string) *StorageDeliverer {
+	return &StorageDeliverer{store: store, prefix: prefix}
+}
+
+func (d *StorageDeliverer) Deliver(ctx context.Context, r *Report) error {
+	key := strings.TrimSuffix(d.prefix, "/") + "/" + r.Filename
+	return d.store.Put(ctx, strings.TrimPrefix(key, "/"), bytes.NewReader(r.Body), r.ContentType)
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth    = 595 // A4 in points
+	pdfPageHeight   = 842
+	pdfMargin       = 56
+	pdfFontSize     = 11
+	pdfHeadingSize  = 16
+	pdfLineHeight   = 16
+	pdfHeadingSpace = 24
+)
+
+// pdfLine is a single line of text; headings use the bold font.
+type pdfLine struct {
+	text    string
+	heading bool
+}
+
+// renderPDF lays the lines out top to bottom on as many A4 pages as needed
+// and encodes a minimal PDF 1.4 document using the standard Helvetica fonts,
+// so no font embedding is required.
+func renderPDF(lines []pdfLine) []byte {
+	var pages [][]byte
+	var content bytes.Buffer
+	y := pdfPageHeight - pdfMargin
+
+	flush := func() {
+		pages = append(pages, append([]byte(nil), content.Bytes()...))
+		content.Reset()
+		y = pdfPageHeight - pdfMargin
+	}
+
+	for _, l := range lines {
+		step := pdfLineHeight
+		font, size := "F1", pdfFontSize
+		if l.heading {
+			step, font, size = pdfHeadingSpace, "F2", pdfHeadingSize
+		}
+		if y-step < pdfMargin {
+			flush()
+		}
+		y -= step
+		fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, pdfMargin, y, pdfEscape(l.text))
+	}
+	flush()
+
+	var out bytes.Buffer
+	var offsets []int
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	// Objects 1-4 are fixed; each page then takes two objects (page, content).
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(p), p))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape escapes string delimiters and replaces characters outside the
+// WinAnsi range that the standard fonts can't draw.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindDailyBalanceSummary = "daily_balance_summary"
+	KindMonthlyStatements   = "monthly_statements"
+	KindWalletStatement     = "wallet_statement"
+)
+
+var ErrUnknownReport = errors.New("unknown report kind")
+
+type Report struct {
+	Name        string
+	Filename    string
+	ContentType string
+	Body        []byte
+}
+
+// Source is the data the reports are built from.
+type Source interface {
+	GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error)
+	ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error
+}
+
+type Generator struct {
+	source     Source
+	statements *StatementRenderer
+}
+
+func NewGenerator(source Source, statements *StatementRenderer) *Generator {
+	return &Generator{source: source, statements: statements}
+}
+
+// Generate builds the report of the given kind for the period that ended at
+// now: the previous UTC day for daily reports, the previous calendar month
+// for monthly ones.
+func (g *Generator) Generate(ctx context.Context, kind string, now time.Time) (*Report, error) {
+	switch kind {
+	case KindDailyBalanceSummary:
+		to := now.UTC().Truncate(24 * time.Hour)
+		return g.dailyBalanceSummary(ctx, to.AddDate(0, 0, -1), to)
+	case KindMonthlyStatements:
+		now = now.UTC()
+		to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return g.monthlyStatements(ctx, to.AddDate(0, -1, 0), to)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownReport, kind)
+}
+
+func (g *Generator) dailyBalanceSummary(ctx context.Context, from, to time.Time) (*Report, error) {
+	summary, err := g.source.BalanceSummary(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"metric", "value"})
+	w.Write([]string{"period_start", from.Format(time.RFC3339)})
+	w.Write([]string{"period_end", to.Format(time.RFC3339)})
+	w.Write([]string{"wallet_count", strconv.FormatInt(summary.WalletCount, 10)})
+	w.Write([]string{"total_balance", strconv.FormatInt(summary.TotalBalance, 10)})
+	w.Write([]string{"wallets_created", strconv.FormatInt(summary.CreatedInRange, 10)})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Name:        "Daily balance summary " + from.Format("2006-01-02"),
+		Filename:    "balance-summary-" + from.Format("2006-01-02") + ".csv",
+		ContentType: "text/csv",
+		Body:        buf.Bytes(),
+	}, nil
+}
+
+// monthlyStatements lists every wallet's balance as of the report run.
+func (g *Generator) monthlyStatements(ctx context.Context, from, to time.Time) (*Report, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"wallet_id", "balance", "version", "updated_at"})
+
+	err := g.source.ExportWallets(ctx, 0, func(batch []models.Wallet) error {
+		for _, wallet := range batch {
+			w.Write([]string{
+				wallet.ID.String(),
+				strconv.FormatInt(wallet.Balance, 10),
+				strconv.Itoa(wallet.Version),
+				wallet.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		return w.Error()
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Flush()
+
+	return &Report{
+		Name:        "Monthly statements " + from.Format("2006-01"),
+		Filename:    "statements-" + from.Format("2006-01") + ".csv",
+		ContentType: "text/csv",
+		Body:        buf.Bytes(),
+	}, nil
+}
+
+// GenerateStatement renders the PDF statement of one wallet for the previous
+// calendar month.
+func (g *Generator) GenerateStatement(ctx context.Context, walletID uuid.UUID, now time.Time) (*Report, error) {
+	now = now.UTC()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, -1, 0)
+
+	wallet, err := g.source.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := g.statements.Render(wallet, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Name:        "Statement " + walletID.String() + " " + from.Format("2006-01"),
+		Filename:    "statement-" + walletID.String() + "-" + from.Format("2006-01") + ".pdf",
+		ContentType: "application/pdf",
+		Body:        body,
+	}, nil
+}
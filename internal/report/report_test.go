@@ -0,0 +1,127 @@
+package report
+
+import (
+	"context"
+	"log/slog"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	from, to time.Time
+	wallets  []models.Wallet
+}
+
+func (f *fakeSource) GetWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return &models.Wallet{ID: id, Balance: 250}, nil
+}
+
+func (f *fakeSource) BalanceSummary(_ context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	f.from, f.to = from, to
+	return &models.BalanceSummary{WalletCount: 3, TotalBalance: 1500, CreatedInRange: 1, From: from, To: to}, nil
+}
+
+func (f *fakeSource) ExportWallets(_ context.Context, _ int, fn func([]models.Wallet) error) error {
+	return fn(f.wallets)
+}
+
+func TestGenerator_DailyBalanceSummary(t *testing.T) {
+	src := &fakeSource{}
+	now := time.Date(2024, 5, 2, 6, 0, 0, 0, time.UTC)
+
+	r, err := NewGenerator(src, nil).Generate(context.Background(), KindDailyBalanceSummary, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), src.from)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), src.to)
+	assert.Equal(t, "balance-summary-2024-05-01.csv", r.Filename)
+	assert.Contains(t, string(r.Body), "total_balance,1500")
+}
+
+func TestGenerator_MonthlyStatements(t *testing.T) {
+	id := uuid.New()
+	src := &fakeSource{wallets: []models.Wallet{{ID: id, Balance: 42, Version: 3}}}
+	now := time.Date(2024, 6, 1, 0, 5, 0, 0, time.UTC)
+
+	r, err := NewGenerator(src, nil).Generate(context.Background(), KindMonthlyStatements, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, "statements-2024-05.csv", r.Filename)
+	assert.Contains(t, string(r.Body), id.String()+",42,3,")
+}
+
+func TestGenerator_UnknownKind(t *testing.T) {
+	_, err := NewGenerator(&fakeSource{}, nil).Generate(context.Background(), "weekly", time.Now())
+	assert.ErrorIs(t, err, ErrUnknownReport)
+}
+
+func TestEmailDeliverer(t *testing.T) {
+	d := NewEmailDeliverer(SMTPConfig{Host: "smtp.local", Port: 25, From: "reports@wallet"}, []string{"ops@corp"})
+	var sent []byte
+	var addr string
+	d.send = func(a string, _ smtp.Auth, _ string, _ []string, msg []byte) error {
+		addr, sent = a, msg
+		return nil
+	}
+
+	err := d.Deliver(context.Background(), &Report{Name: "Daily", Filename: "d.csv", ContentType: "text/csv", Body: []byte("a,b\n")})
+
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.local:25", addr)
+	assert.Contains(t, string(sent), `filename="d.csv"`)
+	assert.Contains(t, string(sent), "To: ops@corp")
+}
+
+func TestRegister_ValidatesEntries(t *testing.T) {
+	s := scheduler.New(slog.Default())
+	entries := []ScheduleConfig{
+		{Name: "ok", Report: KindDailyBalanceSummary, Schedule: "daily 06:00",
+			Delivery: DeliveryConfig{Type: DeliveryEmail, To: []string{"a@b"}}},
+		{Name: "bad-schedule", Report: KindDailyBalanceSummary, Schedule: "sometimes",
+			Delivery: DeliveryConfig{Type: DeliveryEmail, To: []string{"a@b"}}},
+		{Name: "no-store", Report: KindMonthlyStatements, Schedule: "monthly 1 00:00",
+			Delivery: DeliveryConfig{Type: DeliveryStorage}},
+	}
+
+	err := Register(s, NewGenerator(&fakeSource{}, nil), entries, SMTPConfig{Host: "smtp"}, nil, slog.Default())
+
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "bad-schedule"))
+	assert.True(t, strings.Contains(err.Error(), "no-store"))
+	assert.False(t, strings.Contains(err.Error(), `"ok"`))
+}
+
+func TestGenerator_WalletStatement(t *testing.T) {
+	renderer, err := NewStatementRenderer(StatementConfig{Brand: "Acme Pay", Footer: "Thank you (really)"})
+	require.NoError(t, err)
+	id := uuid.New()
+
+	r, err := NewGenerator(&fakeSource{}, renderer).GenerateStatement(context.Background(), id, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", r.ContentType)
+	assert.Equal(t, "statement-"+id.String()+"-2024-02.pdf", r.Filename)
+	body := string(r.Body)
+	assert.True(t, strings.HasPrefix(body, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(body, "%%EOF\n"))
+	assert.Contains(t, body, "(Acme Pay) Tj")
+	assert.Contains(t, body, "(Closing balance: 250) Tj")
+	assert.Contains(t, body, `(Thank you \(really\)) Tj`)
+}
+
+func TestRenderPDF_Paginates(t *testing.T) {
+	lines := make([]pdfLine, 200)
+	for i := range lines {
+		lines[i] = pdfLine{text: "line"}
+	}
+	pdf := string(renderPDF(lines))
+	assert.Contains(t, pdf, "/Count 5")
+}
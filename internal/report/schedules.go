@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	"wallet-service/internal/scheduler"
+	"wallet-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeliveryEmail   = "email"
+	DeliveryStorage = "storage"
+)
+
+// ScheduleConfig is one entry of the reports file: which report to build
+// for which tenant, when, and where to send it.
+type ScheduleConfig struct {
+	Name     string         `json:"name"`
+	Tenant   string         `json:"tenant"`
+	Report   string         `json:"report"`
+	Schedule string         `json:"schedule"`
+	WalletID uuid.UUID      `json:"walletId,omitempty"`
+	Delivery DeliveryConfig `json:"delivery"`
+}
+
+type DeliveryConfig struct {
+	Type   string   `json:"type"`
+	To     []string `json:"to,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+// LoadSchedules reads a JSON array of ScheduleConfig from path.
+func LoadSchedules(path string) ([]ScheduleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ScheduleConfig
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid reports file: %w", err)
+	}
+	return entries, nil
+}
+
+// Register validates entries and adds one scheduler job per entry. store may
+// be nil when no entry uses storage delivery.
+func Register(s *scheduler.Scheduler, gen *Generator, entries []ScheduleConfig, smtp SMTPConfig,
+	store storage.ObjectStore, log *slog.Logger) error {
+	var errs []error
+	for _, e := range entries {
+		sched, err := scheduler.Parse(e.Schedule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("report %q: %w", e.Name, err))
+			continue
+		}
+		switch e.Report {
+		case KindDailyBalanceSummary, KindMonthlyStatements:
+		case KindWalletStatement:
+			if e.WalletID == uuid.Nil {
+				errs = append(errs, fmt.Errorf("report %q: walletId is required for %s", e.Name, e.Report))
+				continue
+			}
+		default:
+			errs = append(errs, fmt.Errorf("report %q: %w: %s", e.Name, ErrUnknownReport, e.Report))
+			continue
+		}
+
+		var deliverer Deliverer
+		switch e.Delivery.Type {
+		case DeliveryEmail:
+			if smtp.Host == "" || len(e.Delivery.To) == 0 {
+				errs = append(errs, fmt.Errorf("report %q: email delivery requires SMTP_HOST and recipients", e.Name))
+				continue
+			}
+			deliverer = NewEmailDeliverer(smtp, e.Delivery.To)
+		case DeliveryStorage:
+			if store == nil {
+				errs = append(errs, fmt.Errorf("report %q: %w", e.Name, storage.ErrNotConfigured))
+				continue
+			}
+			prefix := e.Delivery.Prefix
+			if prefix == "" {
+				prefix = "reports/" + e.Tenant
+			}
+			deliverer = NewStorageDeliverer(store, prefix)
+		default:
+			errs = append(errs, fmt.Errorf("report %q: unknown delivery type %q", e.Name, e.Delivery.Type))
+			continue
+		}
+
+		entry := e
+		jobLog := log.With(slog.String("report", entry.Name), slog.String("tenant", entry.Tenant))
+		s.Add("report:"+entry.Name, sched, func(ctx context.Context) error {
+			var r *Report
+			var err error
+			if entry.Report == KindWalletStatement {
+				r, err = gen.GenerateStatement(ctx, entry.WalletID, time.Now())
+			} else {
+				r, err = gen.Generate(ctx, entry.Report, time.Now())
+			}
+			if err != nil {
+				return fmt.Errorf("generate: %w", err)
+			}
+			if err := deliverer.Deliver(ctx, r); err != nil {
+				return fmt.Errorf("deliver: %w", err)
+			}
+			jobLog.Info("report delivered", slog.String("file", r.Filename), slog.String("delivery", entry.Delivery.Type))
+			return nil
+		})
+	}
+	return errors.Join(errs...)
+}
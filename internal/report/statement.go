@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"wallet-service/internal/models"
+)
+
+// defaultStatementTemplate is used unless STATEMENT_TEMPLATE_FILE is set.
+// Lines starting with "# " are rendered as headings.
+const defaultStatementTemplate = `# {{.Brand}}
+# Account statement
+Wallet: {{.Wallet.ID}}
+Period: {{.From.Format "2006-01-02"}} - {{.To.Format "2006-01-02"}}
+Generated: {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}
+
+Opened: {{.Wallet.CreatedAt.UTC.Format "2006-01-02"}}
+Last activity: {{.Wallet.UpdatedAt.UTC.Format "2006-01-02 15:04 MST"}}
+Closing balance: {{.Wallet.Balance}}
+{{if .Footer}}
+{{.Footer}}{{end}}
+`
+
+type StatementData struct {
+	Brand       string
+	Footer      string
+	Wallet      *models.Wallet
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+}
+
+type StatementConfig struct {
+	Brand        string
+	Footer       string
+	TemplateFile string
+}
+
+// StatementRenderer renders wallet statements to PDF from a text template.
+type StatementRenderer struct {
+	brand  string
+	footer string
+	tmpl   *template.Template
+}
+
+func NewStatementRenderer(cfg StatementConfig) (*StatementRenderer, error) {
+	text := defaultStatementTemplate
+	if cfg.TemplateFile != "" {
+		data, err := os.ReadFile(cfg.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("statement").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &StatementRenderer{brand: cfg.Brand, footer: cfg.Footer, tmpl: tmpl}, nil
+}
+
+func (r *StatementRenderer) Render(wallet *models.Wallet, from, to time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	err := r.tmpl.Execute(&buf, StatementData{
+		Brand:       r.brand,
+		Footer:      r.footer,
+		Wallet:      wallet,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []pdfLine
+	for _, text := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if heading, ok := strings.CutPrefix(text, "# "); ok {
+			lines = append(lines, pdfLine{text: heading, heading: true})
+			continue
+		}
+		lines = append(lines, pdfLine{text: text})
+	}
+	return renderPDF(lines), nil
+}
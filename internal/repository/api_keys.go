@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"wallet-service/internal/auth"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyByHash returns the unrevoked API key with hash, or
+// auth.ErrAPIKeyNotFound.
+func (r *WalletRepository) APIKeyByHash(ctx context.Context, hash string) (auth.APIKey, error) {
+	op := "repository.APIKeyByHash"
+
+	key := auth.APIKey{Hash: hash}
+	err := r.withReconnect(ctx, op, func() error {
+		err := r.conn(ctx).QueryRowContext(ctx, `SELECT name, routes, role FROM api_keys
+		WHERE hash = $1 AND revoked_at IS NULL`, hash).Scan(&key.Name, pq.Array(&key.Routes), &key.Role)
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrAPIKeyNotFound
+		}
+		return queryError("select_api_key", err)
+	})
+	if err != nil && !isRejection(err) {
+		r.logger(ctx).Error("error looking up api key", slog.String("op", op), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return key, err
+}
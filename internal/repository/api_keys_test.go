@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/auth"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectQuery(`SELECT name, routes, role FROM api_keys\s+WHERE hash = \$1 AND revoked_at IS NULL`).
+		WithArgs("h1").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "routes", "role"}).AddRow("batch", `{"POST /api/v1/jobs/operations"}`, "user"))
+	key, err := repo.APIKeyByHash(context.Background(), "h1")
+	require.NoError(t, err)
+	assert.Equal(t, auth.APIKey{Name: "batch", Hash: "h1", Routes: []string{"POST /api/v1/jobs/operations"}, Role: auth.RoleUser}, key)
+
+	mock.ExpectQuery(`SELECT name, routes, role FROM api_keys`).WithArgs("h2").WillReturnRows(sqlmock.NewRows([]string{"name", "routes", "role"}))
+	_, err = repo.APIKeyByHash(context.Background(), "h2")
+	assert.ErrorIs(t, err, auth.ErrAPIKeyNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
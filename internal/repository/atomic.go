@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// StepError reports which step of an atomic request failed.
+type StepError struct {
+	Index int
+	Err   error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %d: %v", e.Index, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyAtomic applies steps in order inside one serializable transaction;
+// either every step is applied or none. All involved wallets are locked up
+// front in id order, so concurrent requests touching the same wallets can't
+// deadlock. Failures of individual steps are returned as *StepError.
+func (r *WalletRepository) ApplyAtomic(ctx context.Context, steps []models.WalletOperation) ([]models.AtomicStepResult, error) {
+	var results []models.AtomicStepResult
+	err := r.withReconnect(ctx, "repository.ApplyAtomic", func() error {
+		var err error
+		results, err = r.applyAtomic(ctx, steps)
+		return err
+	})
+	return results, err
+}
+
+func (r *WalletRepository) applyAtomic(ctx context.Context, steps []models.WalletOperation) (_ []models.AtomicStepResult, err error) {
+	op := "repository.ApplyAtomic"
+	log := r.logger(ctx).With(slog.String("op", op), slog.Int("steps", len(steps)))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("begin", err)
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	seen := make(map[uuid.UUID]bool)
+	var ids []string
+	for _, step := range steps {
+		if !seen[step.WalletID] {
+			seen[step.WalletID] = true
+			ids = append(ids, step.WalletID.String())
+		}
+	}
+
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	wallets, err := scanWallets(rows, err)
+	if err != nil {
+		log.Error("error locking wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("lock_wallets", err)
+	}
+	locked := make(map[uuid.UUID]*models.Wallet, len(wallets))
+	for i := range wallets {
+		locked[wallets[i].ID] = &wallets[i]
+	}
+
+	results := make([]models.AtomicStepResult, 0, len(steps))
+	for i, step := range steps {
+		wallet, ok := locked[step.WalletID]
+		if !ok {
+			log.Warn("wallet not found", slog.Int("step", i), slog.String("wallet_id", step.WalletID.String()))
+			return nil, &StepError{Index: i, Err: ErrWalletNotFound}
+		}
+		stepLog := log.With(slog.Int("step", i), slog.String("wallet_id", step.WalletID.String()))
+		updated, err := r.applyOperation(ctx, tx, stepLog, wallet, step.Amount, step.OperationType)
+		if err != nil {
+			return nil, &StepError{Index: i, Err: err}
+		}
+		locked[step.WalletID] = updated
+		results = append(results, models.AtomicStepResult{
+			Index:         i,
+			WalletID:      updated.ID,
+			OperationType: step.OperationType,
+			Amount:        step.Amount,
+			Balance:       updated.Balance,
+			Version:       updated.Version,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("commit", err)
+	}
+	return results, nil
+}
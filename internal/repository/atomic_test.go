@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyAtomic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	a, b := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE id = ANY\(\$1::uuid\[\]\) ORDER BY id FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(a, 100, now, now, 1)...).
+			AddRow(walletRow(b, 0, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(70, sqlmock.AnyArg(), a, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(a, 70, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(30, sqlmock.AnyArg(), b, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(b, 30, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	results, err := repo.ApplyAtomic(context.Background(), []models.WalletOperation{
+		{WalletID: a, OperationType: models.OperationTypeWithdraw, Amount: 30},
+		{WalletID: b, OperationType: models.OperationTypeDeposit, Amount: 30},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, int64(70), results[0].Balance)
+	assert.Equal(t, int64(30), results[1].Balance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyAtomic_StepFailureRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	a, b := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(a, 100, now, now, 1)...).
+			AddRow(walletRow(b, 5, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(a, 110, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	_, err = repo.ApplyAtomic(context.Background(), []models.WalletOperation{
+		{WalletID: a, OperationType: models.OperationTypeDeposit, Amount: 10},
+		{WalletID: b, OperationType: models.OperationTypeWithdraw, Amount: 10},
+	})
+
+	var stepErr *StepError
+	require.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, 1, stepErr.Index)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+)
+
+// RecordOperationAttempts stores the attempts of one request in a single
+// insert.
+func (r *WalletRepository) RecordOperationAttempts(ctx context.Context, attempts []models.OperationAttempt) error {
+	op := "repository.RecordOperationAttempts"
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	var values strings.Builder
+	args := make([]any, 0, len(attempts)*6)
+	for i, a := range attempts {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		n := len(args)
+		values.WriteString("(")
+		for j := 1; j <= 6; j++ {
+			if j > 1 {
+				values.WriteString(", ")
+			}
+			values.WriteString("$" + strconv.Itoa(n+j))
+		}
+		values.WriteString(")")
+		args = append(args, a.OperationID, a.Attempt, a.Op, a.ErrorClass, a.DurationMs, a.StartedAt)
+	}
+	query := `INSERT INTO operation_attempts (operation_id, attempt, op, error_class, duration_ms, started_at)
+	VALUES ` + values.String()
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, query, args...)
+		return queryError("insert_operation_attempts", err)
+	})
+	if err != nil {
+		r.logger(ctx).Error("error recording operation attempts", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	return nil
+}
+
+// ListOperationAttempts returns the attempts recorded for a request id,
+// oldest first.
+func (r *WalletRepository) ListOperationAttempts(ctx context.Context, operationID string) ([]models.OperationAttempt, error) {
+	op := "repository.ListOperationAttempts"
+
+	query := `SELECT operation_id, attempt, op, error_class, duration_ms, started_at
+	FROM operation_attempts WHERE operation_id = $1 ORDER BY started_at, attempt`
+
+	attempts := []models.OperationAttempt{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, operationID)
+		if err != nil {
+			return queryError("select_operation_attempts", err)
+		}
+		defer rows.Close()
+
+		attempts = attempts[:0]
+		for rows.Next() {
+			var a models.OperationAttempt
+			if err := rows.Scan(&a.OperationID, &a.Attempt, &a.Op, &a.ErrorClass, &a.DurationMs, utc(&a.StartedAt)); err != nil {
+				return queryError("select_operation_attempts", err)
+			}
+			attempts = append(attempts, a)
+		}
+		return queryError("select_operation_attempts", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error listing operation attempts", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// PurgeOperationAttempts deletes attempts started before before.
+func (r *WalletRepository) PurgeOperationAttempts(ctx context.Context, before time.Time) (int64, error) {
+	op := "repository.PurgeOperationAttempts"
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM operation_attempts WHERE started_at < $1`, before.UTC())
+		if err != nil {
+			return queryError("purge_operation_attempts", err)
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error purging operation attempts", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
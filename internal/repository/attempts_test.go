@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordOperationAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	now := time.Now()
+
+	mock.ExpectExec(`INSERT INTO operation_attempts .+ VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\), \(\$7, \$8, \$9, \$10, \$11, \$12\)`).
+		WithArgs("req-1", 1, "service.Transfer", "conflict", 1.5, now, "req-1", 2, "service.Transfer", "", 0.8, now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.RecordOperationAttempts(context.Background(), []models.OperationAttempt{
+		{OperationID: "req-1", Attempt: 1, Op: "service.Transfer", ErrorClass: "conflict", DurationMs: 1.5, StartedAt: now},
+		{OperationID: "req-1", Attempt: 2, Op: "service.Transfer", DurationMs: 0.8, StartedAt: now},
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrLegalHoldExists   = errors.New("wallet is already on legal hold")
+	ErrLegalHoldNotFound = errors.New("wallet is not on legal hold")
+	ErrWalletOnLegalHold = errors.New("wallet is on legal hold")
+)
+
+const auditEventColumns = `id, wallet_id, action, actor, detail, occurred_at`
+
+// auditPartitionPrefix names the monthly partitions of audit_events, e.g.
+// audit_events_2024_05.
+const auditPartitionPrefix = "audit_events_"
+
+func scanAuditEvent(row rowScanner, e *models.AuditEvent) error {
+	return row.Scan(&e.ID, &e.WalletID, &e.Action, &e.Actor, &e.Detail, utc(&e.OccurredAt))
+}
+
+// AppendAuditEvent records e in the partition of the month it occurred in,
+// creating the partition first if needed.
+func (r *WalletRepository) AppendAuditEvent(ctx context.Context, e models.AuditEvent) error {
+	op := "repository.AppendAuditEvent"
+
+	if err := r.ensureAuditPartition(ctx, e.OccurredAt); err != nil {
+		r.logger(ctx).Error("error creating audit partition", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, `INSERT INTO audit_events (`+auditEventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)`, e.ID, e.WalletID, e.Action, e.Actor, e.Detail, e.OccurredAt.UTC())
+		return queryError("insert_audit_event", err)
+	})
+	if err != nil {
+		r.logger(ctx).Error("error recording audit event", slog.String("op", op), slog.String("action", e.Action),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
+
+// ensureAuditPartition creates the partition for the month of at unless
+// this process has seen it already. The partition is created outside of
+// any ambient transaction, so it outlives a rollback of the event.
+func (r *WalletRepository) ensureAuditPartition(ctx context.Context, at time.Time) error {
+	month := time.Date(at.UTC().Year(), at.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	if _, ok := r.auditPartitions.Load(month); ok {
+		return nil
+	}
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF audit_events FOR VALUES FROM ('%s') TO ('%s')`,
+		auditPartition(month), month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))
+	err := r.withReconnect(ctx, "repository.ensureAuditPartition", func() error {
+		_, err := r.db.ExecContext(ctx, query)
+		var pqErr *pq.Error
+		// Another replica may create the partition at the same time.
+		if errors.As(err, &pqErr) && (pqErr.Code == "42P07" || pqErr.Code == "23505") {
+			return nil
+		}
+		return queryError("create_audit_partition", err)
+	})
+	if err != nil {
+		return err
+	}
+	r.auditPartitions.Store(month, true)
+	return nil
+}
+
+func auditPartition(month time.Time) string {
+	return fmt.Sprintf("%s%04d_%02d", auditPartitionPrefix, month.Year(), month.Month())
+}
+
+// ListAuditEvents returns up to limit of a wallet's audit events in the
+// order they occurred, starting after the event after, or from the first
+// with a zero after.
+func (r *WalletRepository) ListAuditEvents(ctx context.Context, walletID uuid.UUID, after models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	op := "repository.ListAuditEvents"
+
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events
+	WHERE wallet_id = $1 AND (occurred_at, id) > ($2, $3)
+	ORDER BY occurred_at, id
+	LIMIT $4`
+
+	events := []models.AuditEvent{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, walletID, after.OccurredAt.UTC(), after.ID, limit)
+		if err != nil {
+			return queryError("select_audit_events", err)
+		}
+		defer rows.Close()
+
+		events = events[:0]
+		for rows.Next() {
+			var e models.AuditEvent
+			if err := scanAuditEvent(rows, &e); err != nil {
+				return queryError("select_audit_events", err)
+			}
+			events = append(events, e)
+		}
+		return queryError("select_audit_events", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error listing audit events", slog.String("op", op), slog.String("wallet_id", walletID.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return events, nil
+}
+
+// PurgeAuditEvents applies the retention period to audit events: from
+// every monthly partition that ended by before it deletes the events of
+// wallets not on legal hold, and drops the partition once it is empty. It
+// returns the number of events deleted.
+func (r *WalletRepository) PurgeAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	op := "repository.PurgeAuditEvents"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	var partitions []string
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, `SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'audit_events'::regclass
+		ORDER BY c.relname`)
+		if err != nil {
+			return queryError("select_audit_partitions", err)
+		}
+		defer rows.Close()
+
+		partitions = partitions[:0]
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return queryError("select_audit_partitions", err)
+			}
+			partitions = append(partitions, name)
+		}
+		return queryError("select_audit_partitions", rows.Err())
+	})
+	if err != nil {
+		log.Error("error listing audit partitions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+
+	var deleted int64
+	for _, name := range partitions {
+		month, err := time.Parse("2006_01", strings.TrimPrefix(name, auditPartitionPrefix))
+		if err != nil || month.AddDate(0, 1, 0).After(before) {
+			continue
+		}
+		n, err := r.purgeAuditPartition(ctx, name)
+		if err != nil {
+			log.Error("error purging audit partition", slog.String("partition", name),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return deleted, err
+		}
+		deleted += n
+		r.auditPartitions.Delete(month)
+	}
+	return deleted, nil
+}
+
+// purgeAuditPartition empties a partition of everything not on legal hold
+// and drops it if nothing is left.
+func (r *WalletRepository) purgeAuditPartition(ctx context.Context, name string) (int64, error) {
+	var deleted int64
+	err := r.withReconnect(ctx, "repository.purgeAuditPartition", func() error {
+		tx, err := r.beginTx(ctx, nil)
+		if err != nil {
+			return queryError("begin", err)
+		}
+		defer tx.Rollback()
+
+		table := pq.QuoteIdentifier(name)
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+table+` e
+		WHERE e.wallet_id IS NULL OR NOT EXISTS (SELECT 1 FROM legal_holds h WHERE h.wallet_id = e.wallet_id)`)
+		if err != nil {
+			return queryError("delete_audit_events", err)
+		}
+		if deleted, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		var held bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+`)`).Scan(&held); err != nil {
+			return queryError("select_held_audit_events", err)
+		}
+		if !held {
+			if _, err := tx.ExecContext(ctx, `DROP TABLE `+table); err != nil {
+				return queryError("drop_audit_partition", err)
+			}
+		}
+		return queryError("commit", tx.Commit())
+	})
+	return deleted, err
+}
+
+const legalHoldColumns = `wallet_id, reason, placed_by, placed_at`
+
+func scanLegalHold(row rowScanner, h *models.LegalHold) error {
+	return row.Scan(&h.WalletID, &h.Reason, &h.PlacedBy, utc(&h.PlacedAt))
+}
+
+// PlaceLegalHold places h on its wallet. It fails with ErrLegalHoldExists
+// if the wallet is on hold already.
+func (r *WalletRepository) PlaceLegalHold(ctx context.Context, h models.LegalHold) (*models.LegalHold, error) {
+	op := "repository.PlaceLegalHold"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", h.WalletID.String()))
+
+	query := `INSERT INTO legal_holds (` + legalHoldColumns + `)
+	SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM wallets WHERE id = $1)
+	ON CONFLICT (wallet_id) DO NOTHING
+	RETURNING ` + legalHoldColumns
+
+	hold := &models.LegalHold{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanLegalHold(r.conn(ctx).QueryRowContext(ctx, query, h.WalletID, h.Reason, h.PlacedBy, h.PlacedAt.UTC()), hold)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return queryError("insert_legal_hold", err)
+		}
+		var exists bool
+		if err := r.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1)`, h.WalletID).Scan(&exists); err != nil {
+			return queryError("select_wallet", err)
+		}
+		if !exists {
+			return ErrWalletNotFound
+		}
+		return ErrLegalHoldExists
+	})
+	if err != nil {
+		if !isRejection(err) {
+			log.Error("error placing legal hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetLegalHold returns the legal hold on a wallet, or ErrLegalHoldNotFound.
+func (r *WalletRepository) GetLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	op := "repository.GetLegalHold"
+
+	hold := &models.LegalHold{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanLegalHold(r.conn(ctx).QueryRowContext(ctx,
+			`SELECT `+legalHoldColumns+` FROM legal_holds WHERE wallet_id = $1`, walletID), hold)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLegalHoldNotFound
+		}
+		return queryError("select_legal_hold", err)
+	})
+	if err != nil {
+		if !isRejection(err) {
+			r.logger(ctx).Error("error reading legal hold", slog.String("op", op), slog.String("wallet_id", walletID.String()),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ReleaseLegalHold lifts the legal hold on a wallet and returns it. The
+// wallet's records become subject to retention again.
+func (r *WalletRepository) ReleaseLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	op := "repository.ReleaseLegalHold"
+
+	hold := &models.LegalHold{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanLegalHold(r.conn(ctx).QueryRowContext(ctx,
+			`DELETE FROM legal_holds WHERE wallet_id = $1 RETURNING `+legalHoldColumns, walletID), hold)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLegalHoldNotFound
+		}
+		return queryError("delete_legal_hold", err)
+	})
+	if err != nil {
+		if !isRejection(err) {
+			r.logger(ctx).Error("error releasing legal hold", slog.String("op", op), slog.String("wallet_id", walletID.String()),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return hold, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAuditEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	e := models.AuditEvent{
+		ID:         uuid.New(),
+		WalletID:   uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		Action:     models.AuditWalletFrozen,
+		OccurredAt: time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC),
+	}
+
+	// The month's partition is created once per process.
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS audit_events_2024_05 PARTITION OF audit_events\s+FOR VALUES FROM \('2024-05-01T00:00:00Z'\) TO \('2024-06-01T00:00:00Z'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for range 2 {
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(e.ID, e.WalletID, e.Action, e.Actor, e.Detail, e.OccurredAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repo.AppendAuditEvent(context.Background(), e))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeAuditEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	// April has ended by the cutoff and is dropped once nothing in it is
+	// held; May hasn't.
+	mock.ExpectQuery(`SELECT c.relname FROM pg_inherits`).
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).AddRow("audit_events_2024_04").AddRow("audit_events_2024_05"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "audit_events_2024_04" e\s+WHERE e.wallet_id IS NULL OR NOT EXISTS \(SELECT 1 FROM legal_holds`).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM "audit_events_2024_04"\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`DROP TABLE "audit_events_2024_04"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := repo.PurgeAuditEvents(context.Background(), time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlaceLegalHold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	now := time.Now().UTC()
+	h := models.LegalHold{WalletID: uuid.New(), Reason: "case 42", PlacedBy: "counsel", PlacedAt: now}
+	holdCols := []string{"wallet_id", "reason", "placed_by", "placed_at"}
+
+	mock.ExpectQuery(`INSERT INTO legal_holds`).
+		WithArgs(h.WalletID, h.Reason, h.PlacedBy, h.PlacedAt).
+		WillReturnRows(sqlmock.NewRows(holdCols).AddRow(h.WalletID, h.Reason, h.PlacedBy, now))
+	hold, err := repo.PlaceLegalHold(context.Background(), h)
+	require.NoError(t, err)
+	assert.Equal(t, h, *hold)
+
+	for exists, want := range map[bool]error{true: ErrLegalHoldExists, false: ErrWalletNotFound} {
+		mock.ExpectQuery(`INSERT INTO legal_holds`).WillReturnRows(sqlmock.NewRows(holdCols))
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM wallets WHERE id = \$1\)`).WithArgs(h.WalletID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+		_, err = repo.PlaceLegalHold(context.Background(), h)
+		assert.ErrorIs(t, err, want)
+	}
+
+	mock.ExpectQuery(`DELETE FROM legal_holds WHERE wallet_id = \$1`).WithArgs(h.WalletID).
+		WillReturnRows(sqlmock.NewRows(holdCols))
+	_, err = repo.ReleaseLegalHold(context.Background(), h.WalletID)
+	assert.ErrorIs(t, err, ErrLegalHoldNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// walletFilterClause renders f as SQL conditions joined by AND, numbering
+// placeholders after the given args. It returns "TRUE" for an empty filter.
+func walletFilterClause(f models.WalletFilter, args []any) (string, []any) {
+	var conds []string
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.OwnerID.Valid {
+		add("owner_id = $%d", f.OwnerID.UUID)
+	}
+	if f.Label != "" {
+		add("label = $%d", f.Label)
+	}
+	if f.Tenant != "" {
+		add("tenant = $%d", f.Tenant)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.Currency != "" {
+		add("currency = $%d", f.Currency)
+	}
+	if f.MinBalance != nil {
+		add("balance >= $%d", *f.MinBalance)
+	}
+	if f.MaxBalance != nil {
+		add("balance <= $%d", *f.MaxBalance)
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= $%d", f.CreatedAfter.UTC())
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < $%d", f.CreatedBefore.UTC())
+	}
+	if len(conds) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// CountWalletsToSetStatus counts the wallets matching f that aren't in
+// status yet.
+// CreateWallets creates a wallet with params for each of ids in a single
+// statement, so either all of them are created or none is.
+func (r *WalletRepository) CreateWallets(ctx context.Context, ids []uuid.UUID, params models.CreateWalletRequest) error {
+	op := "repository.CreateWallets"
+	log := r.logger(ctx).With(slog.String("op", op), slog.Int("count", len(ids)))
+
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	query := `INSERT INTO wallets (id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant)
+	SELECT id, 0, $2, $2, 1, $3, $4, $5, $6, $7 FROM unnest($1::uuid[]) AS id`
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, query, pq.Array(strs), time.Now().UTC(), params.OwnerID, params.Currency,
+			models.WalletStatusActive, params.Label, params.Tenant)
+		return queryError("insert_wallets", err)
+	})
+	if err != nil {
+		log.Error("unexpected error while creating wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	return nil
+}
+
+func (r *WalletRepository) CountWalletsToSetStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus) (int64, error) {
+	op := "repository.CountWalletsToSetStatus"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	where, args := walletFilterClause(f, []any{status})
+	query := `SELECT COUNT(*) FROM wallets WHERE status <> $1 AND ` + where
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		return r.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&n)
+	})
+	if err != nil {
+		log.Error("error counting wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
+
+// SetWalletsStatus moves up to batchSize wallets matching f into status and
+// returns how many were changed. Callers repeat it until it returns zero.
+// Keeping batches small keeps row locks short for concurrent operations.
+func (r *WalletRepository) SetWalletsStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus, batchSize int) (int64, error) {
+	op := "repository.SetWalletsStatus"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("status", string(status)))
+
+	where, args := walletFilterClause(f, []any{status, time.Now().UTC(), batchSize})
+	query := `UPDATE wallets SET status = $1, updated_at = $2
+	WHERE id IN (
+		SELECT id FROM wallets
+		WHERE status <> $1 AND ` + where + `
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE
+	)`
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		log.Error("error updating wallet status", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletFilterClause(t *testing.T) {
+	owner := uuid.New()
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := walletFilterClause(models.WalletFilter{
+		OwnerID:       uuid.NullUUID{UUID: owner, Valid: true},
+		Tenant:        "acme",
+		CreatedBefore: &before,
+	}, []any{"x"})
+
+	assert.Equal(t, "owner_id = $2 AND tenant = $3 AND created_at < $4", where)
+	assert.Equal(t, []any{"x", owner, "acme", before}, args)
+
+	after := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	where, args = walletFilterClause(models.WalletFilter{
+		Status:       models.WalletStatusFrozen,
+		CreatedAfter: &after,
+	}, nil)
+	assert.Equal(t, "status = $1 AND created_at >= $2", where)
+	assert.Equal(t, []any{models.WalletStatusFrozen, after}, args)
+
+	where, args = walletFilterClause(models.WalletFilter{}, nil)
+	assert.Equal(t, "TRUE", where)
+	assert.Empty(t, args)
+}
+
+func TestCreateWallets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectExec(`INSERT INTO wallets .+ FROM unnest\(\$1::uuid\[\]\)`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), uuid.NullUUID{}, "EUR", models.WalletStatusActive, "", "acme").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.CreateWallets(context.Background(), ids, models.CreateWalletRequest{Currency: "EUR", Tenant: "acme"})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetWalletsStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	filter := models.WalletFilter{Label: "sanctioned"}
+
+	mock.ExpectExec(`UPDATE wallets SET status = \$1, updated_at = \$2\s+WHERE id IN \(\s+SELECT id FROM wallets\s+WHERE status <> \$1 AND label = \$4`).
+		WithArgs(models.WalletStatusFrozen, sqlmock.AnyArg(), 100, "sanctioned").
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	n, err := repo.SetWalletsStatus(context.Background(), filter, models.WalletStatusFrozen, 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateWalletBalance_Frozen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	row := walletRow(testID, 100, time.Now(), time.Now(), 1)
+	row[7] = "FROZEN"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectRollback()
+
+	_, err = repo.UpdateWalletBalance(context.Background(), testID, 10, models.OperationTypeDeposit)
+
+	assert.ErrorIs(t, err, ErrWalletFrozen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
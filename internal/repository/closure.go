@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWalletNotClosed = errors.New("wallet is not closed")
+	ErrWalletNotEmpty  = errors.New("wallet has a balance")
+	ErrWalletPurged    = errors.New("wallet has been purged")
+)
+
+// CloseWallet closes a wallet without balance or holds. It fails with
+// ErrWalletNotEmpty while money is left on it and with ErrWalletClosed if
+// it is closed already.
+func (r *WalletRepository) CloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "repository.CloseWallet"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `UPDATE wallets SET status = $1, closed_at = $2, updated_at = $2
+	WHERE id = $3 AND status <> $1 AND balance = 0 AND held_balance = 0
+	RETURNING ` + walletColumns
+
+	wallet := &models.Wallet{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWallet(r.conn(ctx).QueryRowContext(ctx, query, models.WalletStatusClosed, time.Now().UTC(), id), wallet)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return queryError("close_wallet", err)
+		}
+		var status models.WalletStatus
+		err = r.conn(ctx).QueryRowContext(ctx, `SELECT status FROM wallets WHERE id = $1`, id).Scan(&status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrWalletNotFound
+		case err != nil:
+			return queryError("select_wallet_status", err)
+		case status == models.WalletStatusClosed:
+			return ErrWalletClosed
+		}
+		return ErrWalletNotEmpty
+	})
+	if err != nil {
+		if isRejection(err) {
+			log.Warn("wallet not closed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("error closing wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// RestoreWallet reopens a closed wallet whose transactions haven't been
+// purged yet.
+func (r *WalletRepository) RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "repository.RestoreWallet"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `UPDATE wallets SET status = $1, closed_at = NULL, updated_at = $2
+	WHERE id = $3 AND status = $4 AND purged_at IS NULL
+	RETURNING ` + walletColumns
+
+	wallet := &models.Wallet{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWallet(r.conn(ctx).QueryRowContext(ctx, query, models.WalletStatusActive, time.Now().UTC(), id,
+			models.WalletStatusClosed), wallet)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return queryError("restore_wallet", err)
+		}
+		var purged bool
+		err = r.conn(ctx).QueryRowContext(ctx, `SELECT purged_at IS NOT NULL FROM wallets WHERE id = $1`, id).Scan(&purged)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrWalletNotFound
+		case err != nil:
+			return queryError("select_wallet_purged", err)
+		case purged:
+			return ErrWalletPurged
+		}
+		return ErrWalletNotClosed
+	})
+	if err != nil {
+		if isRejection(err) {
+			log.Warn("wallet not restored", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("error restoring wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// WalletsToPurge returns up to limit wallets closed before closedBefore
+// whose transactions haven't been purged, longest closed first. Wallets on
+// legal hold are skipped.
+func (r *WalletRepository) WalletsToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	op := "repository.WalletsToPurge"
+
+	query := `SELECT id FROM wallets
+	WHERE status = $1 AND purged_at IS NULL AND closed_at < $2
+		AND NOT EXISTS (SELECT 1 FROM legal_holds h WHERE h.wallet_id = wallets.id)
+	ORDER BY closed_at, id
+	LIMIT $3`
+
+	var ids []uuid.UUID
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, models.WalletStatusClosed, closedBefore.UTC(), limit)
+		if err != nil {
+			return queryError("select_wallets_to_purge", err)
+		}
+		defer rows.Close()
+
+		ids = ids[:0]
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return queryError("select_wallets_to_purge", err)
+			}
+			ids = append(ids, id)
+		}
+		return queryError("select_wallets_to_purge", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error listing wallets to purge", slog.String("op", op), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ArchiveTransactions returns up to limit transactions of a wallet after
+// afterVersion, oldest first, each with its notes.
+func (r *WalletRepository) ArchiveTransactions(ctx context.Context, walletID uuid.UUID, afterVersion, limit int) ([]models.ArchivedTransaction, error) {
+	op := "repository.ArchiveTransactions"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT seq, wallet_id, version, operation_type, amount, balance, created_at
+	FROM wallet_versions
+	WHERE wallet_id = $1 AND version > $2
+	ORDER BY version
+	LIMIT $3`
+	notesQuery := `SELECT ` + transactionNoteColumns + ` FROM transaction_notes
+	WHERE seq = ANY($1)
+	ORDER BY created_at, id`
+
+	var transactions []models.ArchivedTransaction
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, walletID, afterVersion, limit)
+		if err != nil {
+			return queryError("select_transactions", err)
+		}
+		defer rows.Close()
+
+		transactions = transactions[:0]
+		bySeq := make(map[int64]int)
+		seqs := make([]int64, 0, limit)
+		for rows.Next() {
+			var t models.ArchivedTransaction
+			if err := rows.Scan(&t.ID, &t.WalletID, &t.Version, &t.OperationType, &t.Amount, &t.Balance, utc(&t.CreatedAt)); err != nil {
+				return queryError("select_transactions", err)
+			}
+			bySeq[t.ID] = len(transactions)
+			seqs = append(seqs, t.ID)
+			transactions = append(transactions, t)
+		}
+		if err := rows.Err(); err != nil {
+			return queryError("select_transactions", err)
+		}
+		if len(seqs) == 0 {
+			return nil
+		}
+
+		noteRows, err := r.conn(ctx).QueryContext(ctx, notesQuery, pq.Array(seqs))
+		if err != nil {
+			return queryError("select_transaction_notes", err)
+		}
+		defer noteRows.Close()
+		for noteRows.Next() {
+			var n models.TransactionNote
+			if err := scanTransactionNote(noteRows, &n); err != nil {
+				return err
+			}
+			t := &transactions[bySeq[n.TransactionID]]
+			t.Notes = append(t.Notes, n)
+		}
+		return queryError("select_transaction_notes", noteRows.Err())
+	})
+	if err != nil {
+		log.Error("error reading transactions to archive", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// DeleteTransactions deletes up to limit of a wallet's oldest transactions
+// up to throughVersion, with their notes, and returns how many it deleted.
+// Callers repeat it until it returns 0, keeping each statement short. The
+// transactions of a wallet on legal hold are never deleted.
+func (r *WalletRepository) DeleteTransactions(ctx context.Context, walletID uuid.UUID, throughVersion, limit int) (int64, error) {
+	op := "repository.DeleteTransactions"
+
+	query := `WITH batch AS (
+		SELECT seq FROM wallet_versions
+		WHERE wallet_id = $1 AND version <= $2
+			AND NOT EXISTS (SELECT 1 FROM legal_holds WHERE wallet_id = $1)
+		ORDER BY version
+		LIMIT $3
+	), notes AS (
+		DELETE FROM transaction_notes WHERE seq IN (SELECT seq FROM batch)
+	)
+	DELETE FROM wallet_versions WHERE seq IN (SELECT seq FROM batch)`
+
+	var deleted int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, query, walletID, throughVersion, limit)
+		if err != nil {
+			return queryError("delete_transactions", err)
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error deleting transactions", slog.String("op", op), slog.String("wallet_id", walletID.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// MarkWalletPurged records that a closed wallet's transactions have been
+// archived and deleted, which makes the closure final. It fails with
+// ErrWalletOnLegalHold if a legal hold was placed during the purge.
+func (r *WalletRepository) MarkWalletPurged(ctx context.Context, id uuid.UUID) error {
+	op := "repository.MarkWalletPurged"
+
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `UPDATE wallets SET purged_at = $1
+		WHERE id = $2 AND status = $3 AND NOT EXISTS (SELECT 1 FROM legal_holds WHERE wallet_id = $2)`,
+			time.Now().UTC(), id, models.WalletStatusClosed)
+		if err != nil {
+			return queryError("mark_wallet_purged", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 1 {
+			return err
+		}
+		var held bool
+		if err := r.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM legal_holds WHERE wallet_id = $1)`, id).Scan(&held); err != nil {
+			return queryError("select_legal_hold", err)
+		}
+		if held {
+			return ErrWalletOnLegalHold
+		}
+		return ErrWalletNotClosed
+	})
+	if err != nil && !isRejection(err) {
+		r.logger(ctx).Error("error marking wallet purged", slog.String("op", op), slog.String("wallet_id", id.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, now := uuid.New(), time.Now().UTC()
+	row := walletRow(id, 0, now, now, 3)
+	row[slices.Index(walletCols, "status")] = "CLOSED"
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1, closed_at = \$2`).
+		WithArgs(models.WalletStatusClosed, sqlmock.AnyArg(), id).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	wallet, err := repo.CloseWallet(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletStatusClosed, wallet.Status)
+
+	for status, want := range map[string]error{"ACTIVE": ErrWalletNotEmpty, "CLOSED": ErrWalletClosed} {
+		mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+		_, err = repo.CloseWallet(context.Background(), id)
+		assert.ErrorIs(t, err, want, status)
+	}
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	_, err = repo.CloseWallet(context.Background(), id)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, now := uuid.New(), time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1, closed_at = NULL`).
+		WithArgs(models.WalletStatusActive, sqlmock.AnyArg(), id, models.WalletStatusClosed).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 0, now, now, 3)...))
+	wallet, err := repo.RestoreWallet(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletStatusActive, wallet.Status)
+
+	for purged, want := range map[bool]error{true: ErrWalletPurged, false: ErrWalletNotClosed} {
+		mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+		mock.ExpectQuery(`SELECT purged_at IS NOT NULL`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"purged"}).AddRow(purged))
+		_, err = repo.RestoreWallet(context.Background(), id)
+		assert.ErrorIs(t, err, want)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestArchiveTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID, now := uuid.New(), time.Now().UTC()
+
+	mock.ExpectQuery(`SELECT seq, wallet_id, version, operation_type, amount, balance, created_at\s+FROM wallet_versions\s+WHERE wallet_id = \$1 AND version > \$2`).
+		WithArgs(walletID, 10, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "wallet_id", "version", "operation_type", "amount", "balance", "created_at"}).
+			AddRow(41, walletID, 11, "DEPOSIT", 100, 100, now).
+			AddRow(42, walletID, 12, "WITHDRAW", 30, 70, now))
+	mock.ExpectQuery(`SELECT id, seq, author, body, attachments, created_at FROM transaction_notes\s+WHERE seq = ANY\(\$1\)`).
+		WithArgs("{41,42}").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seq", "author", "body", "attachments", "created_at"}).
+			AddRow(uuid.New(), 42, "ops", "refund", []byte("[]"), now))
+
+	transactions, err := repo.ArchiveTransactions(context.Background(), walletID, 10, 2)
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	assert.Empty(t, transactions[0].Notes)
+	require.Len(t, transactions[1].Notes, 1)
+	assert.Equal(t, "refund", transactions[1].Notes[0].Body)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM transaction_notes WHERE seq IN \(SELECT seq FROM batch\)\s+\)\s+DELETE FROM wallet_versions`).
+		WithArgs(walletID, 12, 1000).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	n, err := repo.DeleteTransactions(context.Background(), walletID, 12, 1000)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrCreditLimitTooLow is returned when a credit limit wouldn't cover what
+// a wallet already owes and holds.
+var ErrCreditLimitTooLow = errors.New("credit limit doesn't cover the wallet's overdraft and holds")
+
+// SetCreditLimit sets how far below zero withdrawals may take a wallet's
+// balance. A lower limit must still cover the wallet's overdraft and holds,
+// or it fails with ErrCreditLimitTooLow.
+func (r *WalletRepository) SetCreditLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	op := "repository.SetCreditLimit"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `UPDATE wallets SET credit_limit = $1, updated_at = $2
+	WHERE id = $3 AND balance + $1 - held_balance >= 0
+	RETURNING ` + walletColumns
+
+	wallet := &models.Wallet{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWallet(r.conn(ctx).QueryRowContext(ctx, query, limit, time.Now().UTC(), id), wallet)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return queryError("update_credit_limit", err)
+		}
+		var exists bool
+		err = r.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1)`, id).Scan(&exists)
+		switch {
+		case err != nil:
+			return queryError("select_wallet_exists", err)
+		case !exists:
+			return ErrWalletNotFound
+		}
+		return ErrCreditLimitTooLow
+	})
+	if err != nil {
+		if isRejection(err) {
+			log.Warn("credit limit not set", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("error setting credit limit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCreditLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, now := uuid.New(), time.Now().UTC()
+	row := walletRow(id, -40, now, now, 3)
+	row[slices.Index(walletCols, "credit_limit")] = 100
+
+	mock.ExpectQuery(`UPDATE wallets SET credit_limit = \$1, updated_at = \$2\s+WHERE id = \$3 AND balance \+ \$1 - held_balance >= 0`).
+		WithArgs(int64(100), sqlmock.AnyArg(), id).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	w, err := repo.SetCreditLimit(context.Background(), id, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), w.CreditLimit)
+	assert.Equal(t, int64(60), w.AvailableBalance())
+
+	for exists, want := range map[bool]error{true: ErrCreditLimitTooLow, false: ErrWalletNotFound} {
+		mock.ExpectQuery(`UPDATE wallets SET credit_limit`).WillReturnRows(sqlmock.NewRows(walletCols))
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM wallets WHERE id = \$1\)`).WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+		_, err = repo.SetCreditLimit(context.Background(), id, 10)
+		assert.ErrorIs(t, err, want)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ReplicationStatus struct {
+	InRecovery bool
+	Lag        time.Duration
+}
+
+// PingLatency measures a round trip to the database.
+func (r *WalletRepository) PingLatency(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if err := r.db.PingContext(ctx); err != nil {
+		return 0, wrapError("repository.PingLatency", queryError("ping", err))
+	}
+	return time.Since(start), nil
+}
+
+// ReplicationStatus reports replay lag: on a replica the age of the last
+// replayed transaction, on a primary the worst replay lag among its
+// standbys (zero without standbys).
+func (r *WalletRepository) ReplicationStatus(ctx context.Context) (*ReplicationStatus, error) {
+	query := `SELECT pg_is_in_recovery(),
+	CASE WHEN pg_is_in_recovery()
+		THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		ELSE COALESCE((SELECT MAX(EXTRACT(EPOCH FROM replay_lag)) FROM pg_stat_replication), 0)
+	END`
+
+	var status ReplicationStatus
+	var lagSeconds float64
+	if err := r.conn(ctx).QueryRowContext(ctx, query).Scan(&status.InRecovery, &lagSeconds); err != nil {
+		return nil, wrapError("repository.ReplicationStatus", queryError("select_replication_lag", err))
+	}
+	status.Lag = time.Duration(lagSeconds * float64(time.Second))
+	return &status, nil
+}
+
+// PoolStats exposes database/sql connection pool statistics.
+func (r *WalletRepository) PoolStats() sql.DBStats {
+	return r.db.Stats()
+}
@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotDisputable       = errors.New("transaction can't be disputed")
+	ErrDisputeExists       = errors.New("transaction already disputed")
+	ErrDisputeNotFound     = errors.New("dispute not found")
+	ErrDisputeClosed       = errors.New("dispute is not open")
+)
+
+const disputeColumns = `id, wallet_id, version, operation_type, amount, hold_amount, reason, status, deadline, created_at,
+	resolved_at, reversal_version, note`
+
+func scanDispute(row rowScanner, d *models.Dispute) error {
+	var resolvedAt sql.NullTime
+	var reversalVersion sql.NullInt64
+	if err := row.Scan(&d.ID, &d.WalletID, &d.Version, &d.OperationType, &d.Amount, &d.HoldAmount, &d.Reason, &d.Status,
+		utc(&d.Deadline), utc(&d.CreatedAt), &resolvedAt, &reversalVersion, &d.Note); err != nil {
+		return err
+	}
+	d.ResolvedAt, d.ReversalVersion = utcPtr(resolvedAt), nil
+	if reversalVersion.Valid {
+		v := int(reversalVersion.Int64)
+		d.ReversalVersion = &v
+	}
+	return nil
+}
+
+// OpenDispute opens a dispute on the operation that produced d.Version of
+// d.WalletID. Disputing a deposit holds its amount on the wallet until the
+// dispute is closed; a disputed withdrawal holds nothing.
+func (r *WalletRepository) OpenDispute(ctx context.Context, d models.Dispute) (*models.Dispute, error) {
+	var result *models.Dispute
+	err := r.withReconnect(ctx, "repository.OpenDispute", func() error {
+		var err error
+		result, err = r.openDispute(ctx, d)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) openDispute(ctx context.Context, d models.Dispute) (_ *models.Dispute, err error) {
+	op := "repository.OpenDispute"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", d.WalletID.String()), slog.Int("version", d.Version))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, d.WalletID), &wallet)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWalletNotFound
+		}
+		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT operation_type, amount FROM wallet_versions WHERE wallet_id = $1 AND version = $2`,
+		d.WalletID, d.Version).Scan(&d.OperationType, &d.Amount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		log.Error("error receiving wallet version", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	switch d.OperationType {
+	case models.OperationTypeDeposit:
+		d.HoldAmount = d.Amount
+	case models.OperationTypeWithdraw:
+		d.HoldAmount = 0
+	default:
+		return nil, ErrNotDisputable
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM disputes WHERE wallet_id = $1 AND version = $2)`,
+		d.WalletID, d.Version).Scan(&exists)
+	if err != nil {
+		log.Error("error checking disputes", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDisputeExists
+	}
+
+	if d.HoldAmount > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE wallets SET held_balance = held_balance + $1 WHERE id = $2`,
+			d.HoldAmount, d.WalletID); err != nil {
+			log.Error("error placing hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+	}
+
+	result := &models.Dispute{}
+	err = scanDispute(tx.QueryRowContext(ctx, `INSERT INTO disputes
+	(id, wallet_id, version, operation_type, amount, hold_amount, reason, status, deadline, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING `+disputeColumns,
+		d.ID,
+		d.WalletID,
+		d.Version,
+		d.OperationType,
+		d.Amount,
+		d.HoldAmount,
+		d.Reason,
+		models.DisputeStatusOpen,
+		d.Deadline,
+		d.CreatedAt,
+	), result)
+	if err != nil {
+		log.Error("error opening dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *WalletRepository) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	op := "repository.GetDispute"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("dispute_id", id.String()))
+
+	d := &models.Dispute{}
+	err := r.withReconnect(ctx, op, func() error {
+		return scanDispute(r.reader(ctx).QueryRowContext(ctx, `SELECT `+disputeColumns+` FROM disputes WHERE id = $1`, id), d)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDisputeNotFound
+		}
+		log.Error("error receiving dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDisputes returns the disputes with the given status, or all disputes
+// for an empty status, oldest first.
+func (r *WalletRepository) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]models.Dispute, error) {
+	op := "repository.ListDisputes"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("status", string(status)))
+
+	query := `SELECT ` + disputeColumns + ` FROM disputes
+	WHERE $1 = '' OR status = $1
+	ORDER BY created_at, id`
+
+	disputes := []models.Dispute{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, status)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		disputes = disputes[:0]
+		for rows.Next() {
+			var d models.Dispute
+			if err := scanDispute(rows, &d); err != nil {
+				return err
+			}
+			disputes = append(disputes, d)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing disputes", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return disputes, nil
+}
+
+// DueDisputes returns up to limit ids of open disputes past their deadline.
+func (r *WalletRepository) DueDisputes(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	op := "repository.DueDisputes"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `SELECT id FROM disputes WHERE status = $1 AND deadline <= $2 ORDER BY deadline LIMIT $3`
+
+	var ids []uuid.UUID
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, models.DisputeStatusOpen, now, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		ids = ids[:0]
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing due disputes", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CloseDispute closes an open dispute with the given status, releasing its
+// hold. With DisputeStatusReversed the disputed operation is reversed too.
+func (r *WalletRepository) CloseDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, note string, at time.Time) (*models.Dispute, error) {
+	var result *models.Dispute
+	err := r.withReconnect(ctx, "repository.CloseDispute", func() error {
+		var err error
+		result, err = r.closeDispute(ctx, id, status, note, at)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) closeDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, note string, at time.Time) (_ *models.Dispute, err error) {
+	op := "repository.CloseDispute"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("dispute_id", id.String()), slog.String("status", string(status)))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	d := models.Dispute{}
+	err = scanDispute(tx.QueryRowContext(ctx, `SELECT `+disputeColumns+` FROM disputes WHERE id = $1 FOR UPDATE`, id), &d)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDisputeNotFound
+		}
+		log.Error("error receiving dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if d.Status != models.DisputeStatusOpen {
+		return nil, ErrDisputeClosed
+	}
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, d.WalletID), &wallet)
+	if err != nil {
+		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if d.HoldAmount > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE wallets SET held_balance = held_balance - $1 WHERE id = $2`,
+			d.HoldAmount, d.WalletID); err != nil {
+			log.Error("error releasing hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		wallet.HeldBalance -= d.HoldAmount
+	}
+
+	var reversalVersion *int
+	if status == models.DisputeStatusReversed {
+		reversal := models.OperationTypeReversalDebit
+		if d.OperationType == models.OperationTypeWithdraw {
+			reversal = models.OperationTypeReversalCredit
+		}
+		updated, err := r.applyOperation(ctx, tx, log, &wallet, d.Amount, reversal)
+		if err != nil {
+			return nil, err
+		}
+		reversalVersion = &updated.Version
+	}
+
+	result := &models.Dispute{}
+	err = scanDispute(tx.QueryRowContext(ctx, `UPDATE disputes
+	SET status = $2, resolved_at = $3, reversal_version = $4, note = $5
+	WHERE id = $1
+	RETURNING `+disputeColumns,
+		id,
+		status,
+		at,
+		reversalVersion,
+		note,
+	), result)
+	if err != nil {
+		log.Error("error closing dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
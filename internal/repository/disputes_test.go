@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var disputeCols = []string{"id", "wallet_id", "version", "operation_type", "amount", "hold_amount", "reason", "status",
+	"deadline", "created_at", "resolved_at", "reversal_version", "note"}
+
+var heldCol = slices.Index(walletCols, "held_balance")
+
+func TestOpenDispute_DepositHoldsAmount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 1000, now, now, 3)...))
+	mock.ExpectQuery(`FROM wallet_versions`).WithArgs(walletID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"operation_type", "amount"}).AddRow("DEPOSIT", 300))
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(walletID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`UPDATE wallets SET held_balance = held_balance \+ \$1`).WithArgs(300, walletID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO disputes`).
+		WithArgs(id, walletID, 2, "DEPOSIT", 300, 300, "fraud", "OPEN", now, now).
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow(id, walletID, 2, "DEPOSIT", 300, 300, "fraud", "OPEN", now, now, nil, nil, ""))
+	mock.ExpectCommit()
+
+	d, err := repo.OpenDispute(context.Background(), models.Dispute{
+		ID: id, WalletID: walletID, Version: 2, Reason: "fraud", Deadline: now, CreatedAt: now,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), d.HoldAmount)
+	assert.Equal(t, models.DisputeStatusOpen, d.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOpenDispute_NotDisputable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 1000, now, now, 3)...))
+	mock.ExpectQuery(`FROM wallet_versions`).WithArgs(walletID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"operation_type", "amount"}).AddRow("CREATE", 0))
+	mock.ExpectRollback()
+
+	_, err = repo.OpenDispute(context.Background(), models.Dispute{ID: uuid.New(), WalletID: walletID, Version: 1})
+
+	assert.ErrorIs(t, err, ErrNotDisputable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloseDispute_ReversedDebitsWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	row := walletRow(walletID, 1000, now, now, 3)
+	row[heldCol] = int64(300)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM disputes WHERE id = \$1 FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow(id, walletID, 2, "DEPOSIT", 300, 300, "fraud", "OPEN", now, now, nil, nil, ""))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectExec(`UPDATE wallets SET held_balance = held_balance - \$1`).WithArgs(300, walletID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(700, sqlmock.AnyArg(), walletID, 3, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 700, now, now, 4)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE disputes`).WithArgs(id, "REVERSED", now, 4, "chargeback").
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow(id, walletID, 2, "DEPOSIT", 300, 300, "fraud", "REVERSED", now, now, now, 4, "chargeback"))
+	mock.ExpectCommit()
+
+	d, err := repo.CloseDispute(context.Background(), id, models.DisputeStatusReversed, "chargeback", now)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.DisputeStatusReversed, d.Status)
+	assert.Equal(t, 4, *d.ReversalVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrWalletNotDormant = errors.New("wallet is not dormant")
+
+// FlagDormantWallets marks up to batchSize active wallets that haven't
+// changed since idleSince as dormant and returns them. Callers repeat it
+// until it returns fewer than batchSize wallets. Rows locked by concurrent
+// operations are skipped: those wallets are evidently not idle.
+func (r *WalletRepository) FlagDormantWallets(ctx context.Context, idleSince time.Time, batchSize int) ([]models.Wallet, error) {
+	op := "repository.FlagDormantWallets"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `UPDATE wallets SET status = $1, updated_at = $2
+	WHERE id IN (
+		SELECT id FROM wallets
+		WHERE status = $3 AND updated_at < $4
+		ORDER BY updated_at, id
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + walletColumns
+
+	var wallets []models.Wallet
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, models.WalletStatusDormant, time.Now().UTC(),
+			models.WalletStatusActive, idleSince.UTC(), batchSize)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		wallets = wallets[:0]
+		for rows.Next() {
+			var w models.Wallet
+			if err := scanWallet(rows, &w); err != nil {
+				return err
+			}
+			wallets = append(wallets, w)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error flagging dormant wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// ReactivateWallet returns a dormant wallet to active. It fails with
+// ErrWalletNotDormant for wallets in any other status.
+func (r *WalletRepository) ReactivateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "repository.ReactivateWallet"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `UPDATE wallets SET status = $1, updated_at = $2
+	WHERE id = $3 AND status = $4
+	RETURNING ` + walletColumns
+
+	wallet := &models.Wallet{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWallet(r.conn(ctx).QueryRowContext(ctx, query, models.WalletStatusActive, time.Now().UTC(), id,
+			models.WalletStatusDormant), wallet)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		var exists bool
+		if err := r.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrWalletNotFound
+		}
+		return ErrWalletNotDormant
+	})
+	if err != nil {
+		if isRejection(err) {
+			log.Warn("wallet not reactivated", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("error reactivating wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagDormantWallets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	idleSince := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	id, now := uuid.New(), time.Now().UTC()
+	row := walletRow(id, 100, now, now, 3)
+	row[slices.Index(walletCols, "status")] = "DORMANT"
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1, updated_at = \$2\s+WHERE id IN \(\s+SELECT id FROM wallets\s+WHERE status = \$3 AND updated_at < \$4`).
+		WithArgs(models.WalletStatusDormant, sqlmock.AnyArg(), models.WalletStatusActive, idleSince, 100).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+
+	wallets, err := repo.FlagDormantWallets(context.Background(), idleSince, 100)
+
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	assert.Equal(t, models.WalletStatusDormant, wallets[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactivateWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, now := uuid.New(), time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).
+		WithArgs(models.WalletStatusActive, sqlmock.AnyArg(), id, models.WalletStatusDormant).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 100, now, now, 3)...))
+	wallet, err := repo.ReactivateWallet(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletStatusActive, wallet.Status)
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err = repo.ReactivateWallet(context.Background(), id)
+	assert.ErrorIs(t, err, ErrWalletNotDormant)
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	_, err = repo.ReactivateWallet(context.Background(), id)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
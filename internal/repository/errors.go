@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/jobs"
+
+	"github.com/lib/pq"
+)
+
+// ErrorKind is a coarse classification of a database failure, stable enough
+// to branch on and to use as a metrics label.
+type ErrorKind string
+
+const (
+	KindUnknown    ErrorKind = "unknown"
+	KindConstraint ErrorKind = "constraint"
+	KindConnection ErrorKind = "connection"
+	KindTimeout    ErrorKind = "timeout"
+	KindConflict   ErrorKind = "conflict"
+	KindCanceled   ErrorKind = "canceled"
+)
+
+// RepoError describes a failed database call: the repository operation, the
+// statement that failed and the Postgres SQLSTATE when the server reported
+// one. Query is empty when the failure isn't tied to a tagged statement.
+type RepoError struct {
+	Op    string
+	Query string
+	Code  pq.ErrorCode
+	Err   error
+}
+
+func (e *RepoError) Error() string {
+	msg := e.Op
+	if e.Query != "" {
+		msg += " [" + e.Query + "]"
+	}
+	if e.Code != "" {
+		msg += " (SQLSTATE " + string(e.Code) + ")"
+	}
+	return fmt.Sprintf("%s: %v", msg, e.Err)
+}
+
+func (e *RepoError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes serialization failures and deadlocks match ErrRetryable.
+func (e *RepoError) Is(target error) bool {
+	return target == ErrRetryable && isRetryable(e.Err)
+}
+
+// Kind classifies the underlying failure.
+func (e *RepoError) Kind() ErrorKind {
+	switch {
+	case isRetryable(e.Err):
+		return KindConflict
+	case strings.HasPrefix(string(e.Code), "23"): // integrity_constraint_violation
+		return KindConstraint
+	case e.Code == "57014" || e.Code == "55P03" || errors.Is(e.Err, context.DeadlineExceeded):
+		// query_canceled by statement_timeout, lock_not_available
+		return KindTimeout
+	case errors.Is(e.Err, context.Canceled):
+		return KindCanceled
+	case isFailoverError(e.Err):
+		return KindConnection
+	}
+	return KindUnknown
+}
+
+// Classify returns the kind of the RepoError in err's chain, or KindUnknown
+// if err didn't come from a failed database call.
+func Classify(err error) ErrorKind {
+	var repoErr *RepoError
+	if errors.As(err, &repoErr) {
+		return repoErr.Kind()
+	}
+	return KindUnknown
+}
+
+// rejections are the repository's domain errors: the request was refused on
+// its merits rather than by a failing database, so they are returned as is.
+var rejections = []error{
+	ErrWalletNotFound, ErrInsufficientFunds, ErrConcurrentModification, ErrUnknownOperationType, ErrWalletFrozen,
+	ErrTransactionNotFound, ErrNotDisputable, ErrDisputeExists, ErrDisputeNotFound, ErrDisputeClosed,
+	ErrMandateNotFound, ErrMandateRevoked, ErrMandateCounterparty, ErrMandateLimitExceeded,
+	ErrSuspenseCaseNotFound, ErrSuspenseCaseResolved, ErrCurrencyMismatch, ErrWalletNotDormant,
+	ErrWalletClosed, ErrWalletNotClosed, ErrWalletNotEmpty, ErrWalletPurged, ErrWalletAlreadyImported, ErrWalletNotFrozen,
+	ErrLegalHoldExists, ErrLegalHoldNotFound, ErrWalletOnLegalHold, ErrHoldNotFound, ErrHoldResolved, ErrHoldExceeded,
+	ErrWebhookSubscriptionNotFound, ErrCreditLimitTooLow,
+	jobs.ErrJobNotFound, jobs.ErrReportNotFound, jobs.ErrLeaseLost, auth.ErrAPIKeyNotFound,
+}
+
+func isRejection(err error) bool {
+	for _, rejection := range rejections {
+		if errors.Is(err, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryError tags err with the statement that produced it; the operation
+// and SQLSTATE are filled in by wrapError.
+func queryError(query string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RepoError{Query: query, Err: err}
+}
+
+// wrapError turns a database failure into a *RepoError for op. Rejections
+// and nil pass through unchanged.
+func wrapError(op string, err error) error {
+	if err == nil || isRejection(err) {
+		return err
+	}
+
+	var repoErr *RepoError
+	if !errors.As(err, &repoErr) {
+		repoErr = &RepoError{Err: err}
+		err = repoErr
+	}
+	if repoErr.Op == "" {
+		repoErr.Op = op
+	}
+	var pqErr *pq.Error
+	if repoErr.Code == "" && errors.As(repoErr.Err, &pqErr) {
+		repoErr.Code = pqErr.Code
+	}
+	return err
+}
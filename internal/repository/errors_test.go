@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoError_Kind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"unique violation", &pq.Error{Code: "23505"}, KindConstraint},
+		{"check violation", &pq.Error{Code: "23514"}, KindConstraint},
+		{"serialization failure", &pq.Error{Code: "40001"}, KindConflict},
+		{"deadlock", &pq.Error{Code: "40P01"}, KindConflict},
+		{"statement timeout", &pq.Error{Code: "57014"}, KindTimeout},
+		{"deadline exceeded", context.DeadlineExceeded, KindTimeout},
+		{"canceled", context.Canceled, KindCanceled},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, KindConnection},
+		{"other", errors.New("boom"), KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapError("repository.Test", tt.err)
+			assert.Equal(t, tt.want, Classify(err))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	assert.Equal(t, KindUnknown, Classify(errors.New("not from the repository")))
+}
+
+func TestWrapError(t *testing.T) {
+	assert.NoError(t, wrapError("repository.Test", nil))
+	assert.Same(t, ErrWalletNotFound, wrapError("repository.Test", ErrWalletNotFound))
+
+	stepErr := &StepError{Index: 1, Err: ErrInsufficientFunds}
+	assert.Same(t, stepErr, wrapError("repository.Test", stepErr))
+
+	err := wrapError("repository.Test", queryError("update_wallet_balance", &pq.Error{Code: "23514", Message: "violates check constraint"}))
+	var repoErr *RepoError
+	require.ErrorAs(t, err, &repoErr)
+	assert.Equal(t, "repository.Test", repoErr.Op)
+	assert.Equal(t, "update_wallet_balance", repoErr.Query)
+	assert.Equal(t, pq.ErrorCode("23514"), repoErr.Code)
+	assert.Equal(t, "repository.Test [update_wallet_balance] (SQLSTATE 23514): pq: violates check constraint", err.Error())
+}
+
+func TestUpdateWalletBalance_RepoError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 100, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).
+		WillReturnError(&pq.Error{Code: "23514"})
+
+	_, err = repo.UpdateWalletBalance(context.Background(), testID, 50, models.OperationTypeDeposit)
+
+	var repoErr *RepoError
+	require.ErrorAs(t, err, &repoErr)
+	assert.Equal(t, "repository.UpdateWalletBalance", repoErr.Op)
+	assert.Equal(t, "update_wallet_balance", repoErr.Query)
+	assert.Equal(t, KindConstraint, repoErr.Kind())
+	assert.NotErrorIs(t, err, ErrRetryable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+)
+
+// ListEvents returns up to limit wallet versions with an offset greater
+// than after, in offset order. Offsets are assigned at insert, before
+// commit, so only versions created no later than until are returned; a
+// settle delay keeps a slow transaction from committing a lower offset
+// behind a reader that has already moved past it.
+func (r *WalletRepository) ListEvents(ctx context.Context, after int64, until time.Time, limit int) ([]models.LedgerEvent, error) {
+	op := "repository.ListEvents"
+	log := r.logger(ctx).With(slog.String("op", op), slog.Int64("after", after))
+
+	query := `SELECT seq, wallet_id, version, operation_type, amount, balance, created_at
+	FROM wallet_versions
+	WHERE seq > $1 AND created_at <= $2
+	ORDER BY seq
+	LIMIT $3`
+
+	events := []models.LedgerEvent{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, after, until, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		events = events[:0]
+		for rows.Next() {
+			var e models.LedgerEvent
+			if err := rows.Scan(&e.Offset, &e.WalletID, &e.Version, &e.OperationType, &e.Amount, &e.Balance, utc(&e.CreatedAt)); err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing events", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return events, nil
+}
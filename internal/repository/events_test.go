@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID := uuid.New()
+	until := time.Now()
+
+	mock.ExpectQuery(`FROM wallet_versions\s+WHERE seq > \$1 AND created_at <= \$2\s+ORDER BY seq`).
+		WithArgs(41, until, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "wallet_id", "version", "operation_type", "amount", "balance", "created_at"}).
+			AddRow(42, walletID, 2, "DEPOSIT", 100, 100, until).
+			AddRow(43, walletID, 3, "WITHDRAW", 30, 70, until))
+
+	events, err := repo.ListEvents(context.Background(), 41, until, 2)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(43), events[1].Offset)
+	assert.Equal(t, int64(70), events[1].Balance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ExportWallets walks the whole wallets table inside a single read-only
+// REPEATABLE READ transaction, so every batch sees the same snapshot, and
+// pages through it by primary key (keyset pagination) in batches of
+// batchSize. fn is called once per non-empty batch; returning an error from
+// fn aborts the export.
+func (r *WalletRepository) ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error {
+	op := "repository.ExportWallets"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return wrapError(op, queryError("begin", err))
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + walletColumns + ` FROM wallets
+	WHERE id > $1
+	ORDER BY id
+	LIMIT $2`
+
+	cursor := uuid.Nil
+	for {
+		batch, err := scanWallets(tx.QueryContext(ctx, query, cursor, batchSize))
+		if err != nil {
+			log.Error("error reading export batch", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return wrapError(op, queryError("select_wallets_batch", err))
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			break
+		}
+		cursor = batch[len(batch)-1].ID
+	}
+
+	return wrapError(op, queryError("commit", tx.Commit()))
+}
+
+func scanWallets(rows *sql.Rows, err error) ([]models.Wallet, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []models.Wallet
+	for rows.Next() {
+		var w models.Wallet
+		if err := scanWallet(rows, &w); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, rows.Err()
+}
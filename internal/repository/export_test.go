@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportWallets_KeysetBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	now := time.Now()
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM wallets\s+WHERE id > \$1`).WithArgs(uuid.Nil, 2).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id1, 1, now, now, 1)...).AddRow(walletRow(id2, 2, now, now, 1)...))
+	mock.ExpectQuery(`SELECT .* FROM wallets\s+WHERE id > \$1`).WithArgs(id2, 2).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id3, 3, now, now, 1)...))
+	mock.ExpectCommit()
+
+	var got [][]models.Wallet
+	err = repo.ExportWallets(context.Background(), 2, func(batch []models.Wallet) error {
+		got = append(got, batch)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, id3, got[1][0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBalanceSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(balance\), 0\)`).WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum", "created"}).AddRow(10, 5000, 2))
+
+	summary, err := repo.BalanceSummary(context.Background(), from, to)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), summary.WalletCount)
+	assert.Equal(t, int64(5000), summary.TotalBalance)
+	assert.Equal(t, int64(2), summary.CreatedInRange)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplicationStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"in_recovery", "lag"}).AddRow(true, 1.5))
+
+	status, err := repo.ReplicationStatus(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, status.InRecovery)
+	assert.Equal(t, 1500*time.Millisecond, status.Lag)
+}
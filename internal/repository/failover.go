@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ReconnectPolicy bounds how hard the repository tries to recover from a
+// lost or demoted primary before giving up on a call.
+type ReconnectPolicy struct {
+	Attempts     int
+	Backoff      time.Duration
+	MaxIdleConns int
+}
+
+var defaultReconnectPolicy = ReconnectPolicy{
+	Attempts:     3,
+	Backoff:      200 * time.Millisecond,
+	MaxIdleConns: 25,
+}
+
+// FailoverStats counts failover events for metrics and diagnostics.
+type FailoverStats struct {
+	Detected  atomic.Int64
+	Recovered atomic.Int64
+	Exhausted atomic.Int64
+}
+
+// isFailoverError reports whether err indicates that the connection no longer
+// points at a writable primary: broken connections, server shutdowns or a
+// read-only session after the old primary was demoted.
+func isFailoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "25006", // read_only_sql_transaction
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
+
+// withReconnect runs fn and, when it fails with a failover error, flushes the
+// connection pool so the next attempt dials (and re-resolves) the primary
+// again. Retries are bounded by the reconnect policy and skipped within a
+// WithinTx transaction, which the lost connection has aborted. Database
+// failures are returned as *RepoError.
+func (r *WalletRepository) withReconnect(ctx context.Context, op string, fn func() error) error {
+	return wrapError(op, r.reconnectLoop(ctx, op, fn))
+}
+
+func (r *WalletRepository) reconnectLoop(ctx context.Context, op string, fn func() error) error {
+	err := fn()
+	if !isFailoverError(err) {
+		return err
+	}
+	if _, ok := r.ambient(ctx); ok {
+		return err
+	}
+
+	log := r.logger(ctx).With(slog.String("op", op))
+	r.failover.Detected.Add(1)
+	log.Warn("database failover detected", slog.String("error", err.Error()))
+
+	backoff := r.reconnect.Backoff
+	for attempt := 1; attempt <= r.reconnect.Attempts; attempt++ {
+		r.flush()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		err = fn()
+		if !isFailoverError(err) {
+			r.failover.Recovered.Add(1)
+			log.Info("database connection recovered", slog.Int("attempt", attempt))
+			return err
+		}
+		log.Warn("reconnect attempt failed", slog.Int("attempt", attempt), slog.String("error", err.Error()))
+		backoff *= 2
+	}
+
+	r.failover.Exhausted.Add(1)
+	log.Error("database reconnect attempts exhausted", slog.String("error", err.Error()))
+	return err
+}
+
+// flushPool drops idle connections so that new ones are dialed from scratch.
+func (r *WalletRepository) flushPool() {
+	r.db.SetMaxIdleConns(0)
+	r.db.SetMaxIdleConns(r.reconnect.MaxIdleConns)
+}
+
+// FailoverStats exposes failover counters.
+func (r *WalletRepository) FailoverStats() *FailoverStats {
+	return &r.failover
+}
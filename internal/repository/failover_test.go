@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFailoverError(t *testing.T) {
+	assert.True(t, isFailoverError(driver.ErrBadConn))
+	assert.True(t, isFailoverError(&pq.Error{Code: "25006"}))
+	assert.True(t, isFailoverError(&pq.Error{Code: "08006"}))
+	assert.False(t, isFailoverError(&pq.Error{Code: "23505"}))
+	assert.False(t, isFailoverError(errors.New("boom")))
+	assert.False(t, isFailoverError(nil))
+}
+
+func TestGetWallet_RecoversAfterFailover(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log, WithReconnectPolicy(ReconnectPolicy{Attempts: 2, Backoff: time.Millisecond}))
+	repo.flush = func() {}
+	testID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).WillReturnError(&pq.Error{Code: "25006"})
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 10, now, now, 1)...))
+
+	wallet, err := repo.GetWallet(context.Background(), testID)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), wallet.Balance)
+	assert.Equal(t, int64(1), repo.FailoverStats().Detected.Load())
+	assert.Equal(t, int64(1), repo.FailoverStats().Recovered.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetWallet_ReconnectExhausted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log, WithReconnectPolicy(ReconnectPolicy{Attempts: 1, Backoff: time.Millisecond}))
+	repo.flush = func() {}
+	testID := uuid.New()
+
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).WillReturnError(&pq.Error{Code: "57P01"})
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).WillReturnError(&pq.Error{Code: "57P01"})
+
+	_, err = repo.GetWallet(context.Background(), testID)
+
+	require.Error(t, err)
+	assert.Equal(t, int64(1), repo.FailoverStats().Exhausted.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
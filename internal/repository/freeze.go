@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrWalletNotFrozen is returned when unfreezing a wallet that isn't frozen.
+var ErrWalletNotFrozen = errors.New("wallet is not frozen")
+
+// FreezeWallet freezes an active or dormant wallet, which then takes no
+// operations until UnfreezeWallet. It fails with ErrWalletFrozen if the
+// wallet is frozen already and ErrWalletClosed if it is closed.
+func (r *WalletRepository) FreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.setWalletStatus(ctx, "repository.FreezeWallet", id, models.WalletStatusFrozen,
+		models.WalletStatusActive, models.WalletStatusDormant)
+}
+
+// UnfreezeWallet returns a frozen wallet to active. It fails with
+// ErrWalletNotFrozen if the wallet isn't frozen.
+func (r *WalletRepository) UnfreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.setWalletStatus(ctx, "repository.UnfreezeWallet", id, models.WalletStatusActive,
+		models.WalletStatusFrozen)
+}
+
+// setWalletStatus moves a wallet into status if it is in one of from, and
+// otherwise explains why not.
+func (r *WalletRepository) setWalletStatus(ctx context.Context, op string, id uuid.UUID, status models.WalletStatus,
+	from ...models.WalletStatus) (*models.Wallet, error) {
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `UPDATE wallets SET status = $1, updated_at = $2
+	WHERE id = $3 AND status = ANY($4)
+	RETURNING ` + walletColumns
+
+	allowed := make([]string, len(from))
+	for i, s := range from {
+		allowed[i] = string(s)
+	}
+	wallet := &models.Wallet{}
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWallet(r.conn(ctx).QueryRowContext(ctx, query, status, time.Now().UTC(), id, pq.Array(allowed)), wallet)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		var current models.WalletStatus
+		err = r.conn(ctx).QueryRowContext(ctx, `SELECT status FROM wallets WHERE id = $1`, id).Scan(&current)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrWalletNotFound
+		case err != nil:
+			return err
+		case current == models.WalletStatusClosed:
+			return ErrWalletClosed
+		case current == models.WalletStatusFrozen:
+			return ErrWalletFrozen
+		}
+		return ErrWalletNotFrozen
+	})
+	if err != nil {
+		if isRejection(err) {
+			log.Warn("wallet status not changed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("error changing wallet status", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
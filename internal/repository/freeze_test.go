@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, now := uuid.New(), time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1, updated_at = \$2\s+WHERE id = \$3 AND status = ANY\(\$4\)`).
+		WithArgs(models.WalletStatusFrozen, sqlmock.AnyArg(), id, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 100, now, now, 3)...))
+	_, err = repo.FreezeWallet(context.Background(), id)
+	require.NoError(t, err)
+
+	for status, want := range map[models.WalletStatus]error{
+		models.WalletStatusFrozen: ErrWalletFrozen,
+		models.WalletStatusClosed: ErrWalletClosed,
+	} {
+		mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+		_, err = repo.FreezeWallet(context.Background(), id)
+		assert.ErrorIs(t, err, want)
+	}
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	_, err = repo.FreezeWallet(context.Background(), id)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnfreezeWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+
+	mock.ExpectQuery(`UPDATE wallets SET status = \$1`).
+		WithArgs(models.WalletStatusActive, sqlmock.AnyArg(), id, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.WalletStatusActive))
+	_, err = repo.UnfreezeWallet(context.Background(), id)
+	assert.ErrorIs(t, err, ErrWalletNotFrozen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
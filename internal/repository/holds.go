@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrHoldNotFound = errors.New("hold not found")
+	ErrHoldResolved = errors.New("hold is not pending")
+	ErrHoldExceeded = errors.New("capture exceeds held amount")
+)
+
+const holdColumns = `id, wallet_id, amount, reference, status, captured_amount, capture_version, created_at, resolved_at`
+
+func scanHold(row rowScanner, h *models.FundsHold) error {
+	var resolvedAt sql.NullTime
+	var captureVersion sql.NullInt64
+	if err := row.Scan(&h.ID, &h.WalletID, &h.Amount, &h.Reference, &h.Status, &h.CapturedAmount, &captureVersion,
+		utc(&h.CreatedAt), &resolvedAt); err != nil {
+		return err
+	}
+	h.ResolvedAt, h.CaptureVersion = utcPtr(resolvedAt), nil
+	if captureVersion.Valid {
+		v := int(captureVersion.Int64)
+		h.CaptureVersion = &v
+	}
+	return nil
+}
+
+// PlaceHold reserves h.Amount of the available balance of h.WalletID. The
+// wallet's balance is unchanged; only its held balance grows.
+func (r *WalletRepository) PlaceHold(ctx context.Context, h models.FundsHold) (*models.FundsHold, error) {
+	var result *models.FundsHold
+	err := r.withReconnect(ctx, "repository.PlaceHold", func() error {
+		var err error
+		result, err = r.placeHold(ctx, h)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) placeHold(ctx context.Context, h models.FundsHold) (_ *models.FundsHold, err error) {
+	op := "repository.PlaceHold"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", h.WalletID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(ctx, tx, h.WalletID)
+	if err != nil {
+		if !errors.Is(err, ErrWalletNotFound) {
+			log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	if err := ledger.Hold(*wallet, h.Amount); err != nil {
+		log.Warn("hold rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE wallets SET held_balance = held_balance + $1 WHERE id = $2`,
+		h.Amount, h.WalletID); err != nil {
+		log.Error("error placing hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	result := &models.FundsHold{}
+	err = scanHold(tx.QueryRowContext(ctx, `INSERT INTO holds (id, wallet_id, amount, reference, status, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING `+holdColumns,
+		h.ID,
+		h.WalletID,
+		h.Amount,
+		h.Reference,
+		models.FundsHoldPending,
+		h.CreatedAt,
+	), result)
+	if err != nil {
+		log.Error("error recording hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListHolds returns all holds of a wallet, newest first.
+func (r *WalletRepository) ListHolds(ctx context.Context, walletID uuid.UUID) ([]models.FundsHold, error) {
+	op := "repository.ListHolds"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE wallet_id = $1 ORDER BY created_at DESC, id`
+
+	holds := []models.FundsHold{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, walletID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		holds = holds[:0]
+		for rows.Next() {
+			var h models.FundsHold
+			if err := scanHold(rows, &h); err != nil {
+				return err
+			}
+			holds = append(holds, h)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing holds", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return holds, nil
+}
+
+// CaptureHold withdraws amount of a pending hold from its wallet and
+// releases the rest of the hold; an amount of zero captures all of it.
+// The withdrawal becomes a version of the wallet like any other.
+func (r *WalletRepository) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, at time.Time) (*models.FundsHold, error) {
+	var result *models.FundsHold
+	err := r.withReconnect(ctx, "repository.CaptureHold", func() error {
+		var err error
+		result, err = r.resolveHold(ctx, "repository.CaptureHold", walletID, holdID, models.FundsHoldCaptured, amount, at)
+		return err
+	})
+	return result, err
+}
+
+// ReleaseHold gives the whole of a pending hold back to the available
+// balance of its wallet.
+func (r *WalletRepository) ReleaseHold(ctx context.Context, walletID, holdID uuid.UUID, at time.Time) (*models.FundsHold, error) {
+	var result *models.FundsHold
+	err := r.withReconnect(ctx, "repository.ReleaseHold", func() error {
+		var err error
+		result, err = r.resolveHold(ctx, "repository.ReleaseHold", walletID, holdID, models.FundsHoldReleased, 0, at)
+		return err
+	})
+	return result, err
+}
+
+// resolveHold ends a pending hold with status, withdrawing amount of it
+// when captured.
+func (r *WalletRepository) resolveHold(ctx context.Context, op string, walletID, holdID uuid.UUID,
+	status models.FundsHoldStatus, amount int64, at time.Time) (_ *models.FundsHold, err error) {
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()),
+		slog.String("hold_id", holdID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		if errors.Is(err, ErrWalletNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	h := models.FundsHold{}
+	err = scanHold(tx.QueryRowContext(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1 AND wallet_id = $2 FOR UPDATE`,
+		holdID, walletID), &h)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrHoldNotFound
+		}
+		log.Error("error receiving hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if h.Status != models.FundsHoldPending {
+		return nil, ErrHoldResolved
+	}
+	if status == models.FundsHoldCaptured && amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return nil, ErrHoldExceeded
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE wallets SET held_balance = held_balance - $1 WHERE id = $2`,
+		h.Amount, walletID); err != nil {
+		log.Error("error releasing hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	wallet.HeldBalance -= h.Amount
+
+	var captureVersion *int
+	if status == models.FundsHoldCaptured {
+		updated, err := r.applyOperation(ctx, tx, log, wallet, amount, models.OperationTypeWithdraw)
+		if err != nil {
+			return nil, err
+		}
+		captureVersion = &updated.Version
+	}
+
+	result := &models.FundsHold{}
+	err = scanHold(tx.QueryRowContext(ctx, `UPDATE holds
+	SET status = $2, captured_amount = $3, capture_version = $4, resolved_at = $5
+	WHERE id = $1
+	RETURNING `+holdColumns,
+		holdID,
+		status,
+		amount,
+		captureVersion,
+		at,
+	), result)
+	if err != nil {
+		log.Error("error resolving hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var holdCols = []string{"id", "wallet_id", "amount", "reference", "status", "captured_amount", "capture_version",
+	"created_at", "resolved_at"}
+
+func TestPlaceHold_ReservesAvailableBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	row := walletRow(walletID, 1000, now, now, 3)
+	row[heldCol] = int64(600)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectExec(`UPDATE wallets SET held_balance = held_balance \+ \$1`).WithArgs(400, walletID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO holds`).WithArgs(id, walletID, 400, "auth-1", "PENDING", now).
+		WillReturnRows(sqlmock.NewRows(holdCols).AddRow(id, walletID, 400, "auth-1", "PENDING", 0, nil, now, nil))
+	mock.ExpectCommit()
+
+	h, err := repo.PlaceHold(context.Background(), models.FundsHold{
+		ID: id, WalletID: walletID, Amount: 400, Reference: "auth-1", CreatedAt: now,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.FundsHoldPending, h.Status)
+	assert.Nil(t, h.CaptureVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlaceHold_InsufficientFunds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID := uuid.New()
+	now := time.Now()
+
+	row := walletRow(walletID, 1000, now, now, 3)
+	row[heldCol] = int64(700)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectRollback()
+
+	_, err = repo.PlaceHold(context.Background(), models.FundsHold{ID: uuid.New(), WalletID: walletID, Amount: 400})
+
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCaptureHold_PartialWithdrawsAndReleasesRest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	row := walletRow(walletID, 1000, now, now, 3)
+	row[heldCol] = int64(400)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectQuery(`FROM holds WHERE id = \$1 AND wallet_id = \$2 FOR UPDATE`).WithArgs(id, walletID).
+		WillReturnRows(sqlmock.NewRows(holdCols).AddRow(id, walletID, 400, "", "PENDING", 0, nil, now, nil))
+	mock.ExpectExec(`UPDATE wallets SET held_balance = held_balance - \$1`).WithArgs(400, walletID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(750, sqlmock.AnyArg(), walletID, 3, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 750, now, now, 4)...))
+	// The capture is a withdrawal, counted against withdrawal limits.
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(walletID, 4, 750, models.OperationTypeWithdraw, 250, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE holds`).WithArgs(id, "CAPTURED", 250, 4, now).
+		WillReturnRows(sqlmock.NewRows(holdCols).AddRow(id, walletID, 400, "", "CAPTURED", 250, 4, now, now))
+	mock.ExpectCommit()
+
+	h, err := repo.CaptureHold(context.Background(), walletID, id, 250, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.FundsHoldCaptured, h.Status)
+	assert.Equal(t, int64(250), h.CapturedAmount)
+	assert.Equal(t, 4, *h.CaptureVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCaptureHold_Exceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 1000, now, now, 3)...))
+	mock.ExpectQuery(`FROM holds WHERE id = \$1 AND wallet_id = \$2 FOR UPDATE`).WithArgs(id, walletID).
+		WillReturnRows(sqlmock.NewRows(holdCols).AddRow(id, walletID, 400, "", "PENDING", 0, nil, now, nil))
+	mock.ExpectRollback()
+
+	_, err = repo.CaptureHold(context.Background(), walletID, id, 401, now)
+
+	assert.ErrorIs(t, err, ErrHoldExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
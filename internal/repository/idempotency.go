@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/idempotency"
+)
+
+// ReserveIdempotencyKey claims key for a request with fingerprint. It
+// returns nil once claimed, or the record of an earlier request with key.
+func (r *WalletRepository) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, now time.Time) (*idempotency.Record, error) {
+	op := "repository.ReserveIdempotencyKey"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	var rec *idempotency.Record
+	err := r.withReconnect(ctx, op, func() error {
+		rec = nil
+		res, err := r.conn(ctx).ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`, key, fingerprint, now.UTC())
+		if err != nil {
+			return queryError("insert_idempotency_key", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 1 {
+			return err
+		}
+
+		var status sql.NullInt64
+		found := idempotency.Record{Key: key}
+		err = r.conn(ctx).QueryRowContext(ctx, `SELECT fingerprint, status, content_type, body, created_at
+		FROM idempotency_keys WHERE key = $1`, key).
+			Scan(&found.Fingerprint, &status, &found.Response.ContentType, &found.Response.Body, utc(&found.CreatedAt))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The conflicting claim was released or purged in between, or
+			// isn't visible yet; either way the key is not ours now.
+			found = idempotency.Record{Key: key, Fingerprint: fingerprint, CreatedAt: now.UTC()}
+		case err != nil:
+			return queryError("select_idempotency_key", err)
+		}
+		found.Completed = status.Valid
+		found.Response.Status = int(status.Int64)
+		rec = &found
+		return nil
+	})
+	if err != nil {
+		log.Error("error reserving idempotency key", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return rec, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed
+// key.
+func (r *WalletRepository) CompleteIdempotencyKey(ctx context.Context, key string, resp idempotency.Response) error {
+	op := "repository.CompleteIdempotencyKey"
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, `UPDATE idempotency_keys SET status = $2, content_type = $3, body = $4
+		WHERE key = $1`, key, resp.Status, resp.ContentType, resp.Body)
+		return queryError("complete_idempotency_key", err)
+	})
+	if err != nil {
+		r.logger(ctx).Error("error completing idempotency key", slog.String("op", op), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
+
+// ReleaseIdempotencyKey gives up a claim whose request failed, so the
+// client can retry it under the same key.
+func (r *WalletRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	op := "repository.ReleaseIdempotencyKey"
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL`, key)
+		return queryError("release_idempotency_key", err)
+	})
+	if err != nil {
+		r.logger(ctx).Error("error releasing idempotency key", slog.String("op", op), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
+
+// PurgeIdempotencyKeys forgets keys claimed before before and returns how
+// many there were.
+func (r *WalletRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	op := "repository.PurgeIdempotencyKeys"
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before.UTC())
+		if err != nil {
+			return queryError("purge_idempotency_keys", err)
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error purging idempotency keys", slog.String("op", op), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
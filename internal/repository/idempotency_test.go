@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`INSERT INTO idempotency_keys .+ ON CONFLICT \(key\) DO NOTHING`).
+		WithArgs("default:k1", "fp", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	rec, err := repo.ReserveIdempotencyKey(context.Background(), "default:k1", "fp", now)
+	require.NoError(t, err)
+	assert.Nil(t, rec, "a new key is claimed")
+
+	mock.ExpectExec(`INSERT INTO idempotency_keys`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT fingerprint, status, content_type, body, created_at\s+FROM idempotency_keys WHERE key = \$1`).
+		WithArgs("default:k1").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status", "content_type", "body", "created_at"}).
+			AddRow("fp", 200, "application/json", []byte(`{}`), now))
+	rec, err = repo.ReserveIdempotencyKey(context.Background(), "default:k1", "fp", now)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.True(t, rec.Completed)
+	assert.Equal(t, 200, rec.Response.Status)
+	assert.Equal(t, []byte(`{}`), rec.Response.Body)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrWalletAlreadyImported is returned for a second import with the same
+// external id.
+var ErrWalletAlreadyImported = errors.New("wallet already imported")
+
+// ImportWallet creates wallet id from req with its opening balance recorded
+// as an OPENING_BALANCE version, all in one transaction. It fails with
+// ErrWalletAlreadyImported if req.ExternalID has been imported before.
+func (r *WalletRepository) ImportWallet(ctx context.Context, id uuid.UUID, req models.ImportWalletRequest) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := r.withReconnect(ctx, "repository.ImportWallet", func() error {
+		var err error
+		wallet, err = r.importWallet(ctx, id, req)
+		return err
+	})
+	return wallet, err
+}
+
+func (r *WalletRepository) importWallet(ctx context.Context, id uuid.UUID, req models.ImportWalletRequest) (_ *models.Wallet, err error) {
+	op := "repository.ImportWallet"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()), slog.String("external_id", req.ExternalID))
+
+	metadata, err := json.Marshal(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if req.Metadata == nil {
+		metadata = []byte("{}")
+	}
+	now := time.Now().UTC()
+	openedAt := now
+	if req.OpenedAt != nil {
+		openedAt = req.OpenedAt.UTC()
+	}
+
+	tx, err := r.beginTx(ctx, nil)
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	// Imported wallet ids are derived from the external id, so a concurrent
+	// import of it waits for the first one here and then finds it taken.
+	wallet := &models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx, `INSERT INTO wallets (id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant)
+	VALUES ($1, $2, $3, $3, 1, $4, $5, $6, $7, $8)
+	ON CONFLICT (id) DO NOTHING
+	RETURNING `+walletColumns,
+		id,
+		req.Balance,
+		openedAt,
+		req.OwnerID,
+		req.Currency,
+		models.WalletStatusActive,
+		req.Label,
+		req.Tenant,
+	), wallet)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Warn("wallet already imported")
+		return nil, fmt.Errorf("%w: external id %q", ErrWalletAlreadyImported, req.ExternalID)
+	}
+	if err != nil {
+		log.Error("error creating imported wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("insert_wallet", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO wallet_imports (external_id, wallet_id, metadata, imported_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (external_id) DO NOTHING`, req.ExternalID, id, metadata, now)
+	if err != nil {
+		log.Error("error recording import", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("insert_wallet_import", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		log.Warn("wallet already imported")
+		return nil, fmt.Errorf("%w: external id %q", ErrWalletAlreadyImported, req.ExternalID)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO wallet_versions (wallet_id, version, balance, operation_type, amount, created_at)
+	VALUES ($1, 1, $2, $3, $2, $4)`, id, req.Balance, models.OperationTypeOpeningBalance, openedAt)
+	if err != nil {
+		log.Error("error recording opening balance", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("insert_wallet_version", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallet, nil
+}
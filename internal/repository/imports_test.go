@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	openedAt := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	req := models.ImportWalletRequest{
+		ExternalID: "legacy-42", Currency: "USD", Balance: 1500, OpenedAt: &openedAt,
+		Metadata: map[string]string{"account": "A-42"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO wallets .+ ON CONFLICT \(id\) DO NOTHING`).
+		WithArgs(id, int64(1500), openedAt, req.OwnerID, "USD", models.WalletStatusActive, "", "").
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 1500, openedAt, openedAt, 1)...))
+	mock.ExpectExec(`INSERT INTO wallet_imports`).
+		WithArgs("legacy-42", id, []byte(`{"account":"A-42"}`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(id, int64(1500), models.OperationTypeOpeningBalance, openedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	wallet, err := repo.ImportWallet(context.Background(), id, req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), wallet.Balance)
+
+	// A second import of the external id finds the wallet taken.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO wallets`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectRollback()
+	_, err = repo.ImportWallet(context.Background(), id, req)
+	assert.ErrorIs(t, err, ErrWalletAlreadyImported)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
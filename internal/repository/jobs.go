@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/jobs"
+
+	"github.com/google/uuid"
+)
+
+// jobColumns are scanned by scanJob; has_report is derived from the report
+// parts.
+const jobColumns = `id, kind, state, attempts, owner, total, processed, failed, error, created_at, updated_at, finished_at,
+	EXISTS (SELECT 1 FROM job_report_parts WHERE job_id = jobs.id)`
+
+func scanJob(row rowScanner, job *jobs.Job, extra ...any) error {
+	var finished sql.NullTime
+	dest := []any{&job.ID, &job.Kind, &job.State, &job.Attempts, &job.Owner, &job.Total, &job.Processed, &job.Failed,
+		&job.Error, utc(&job.CreatedAt), utc(&job.UpdatedAt), &finished, &job.HasReport}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	job.FinishedAt = utcPtr(finished)
+	return nil
+}
+
+// nullJSON stores an empty document as NULL.
+func nullJSON(doc json.RawMessage) any {
+	if len(doc) == 0 {
+		return nil
+	}
+	return []byte(doc)
+}
+
+// CreateJob persists a newly submitted job.
+func (r *WalletRepository) CreateJob(ctx context.Context, rec jobs.Record) error {
+	op := "repository.CreateJob"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("job_id", rec.ID.String()))
+
+	query := `INSERT INTO jobs (id, kind, payload, state, attempts, owner, total, processed, failed, checkpoint, error, report_type, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	payload := rec.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, query, rec.ID, rec.Kind, []byte(payload), rec.State, rec.Attempts, rec.Owner,
+			rec.Total, rec.Processed, rec.Failed, nullJSON(rec.Checkpoint), rec.Error, rec.ReportType, rec.CreatedAt, rec.UpdatedAt)
+		return queryError("insert_job", err)
+	})
+	if err != nil {
+		log.Error("error creating job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	return nil
+}
+
+// SaveJob writes the job's state and progress and appends report as its
+// next report part, in one transaction. It fails with jobs.ErrLeaseLost
+// when owner no longer holds the job.
+func (r *WalletRepository) SaveJob(ctx context.Context, owner string, rec jobs.Record, report []byte) error {
+	op := "repository.SaveJob"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("job_id", rec.ID.String()))
+
+	err := r.withReconnect(ctx, op, func() error {
+		return r.saveJob(ctx, owner, rec, report)
+	})
+	if err != nil && !errors.Is(err, jobs.ErrLeaseLost) {
+		log.Error("error saving job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
+
+func (r *WalletRepository) saveJob(ctx context.Context, owner string, rec jobs.Record, report []byte) error {
+	tx, err := r.beginTx(ctx, nil)
+	if err != nil {
+		return queryError("begin", err)
+	}
+	defer tx.Rollback()
+
+	updateQuery := `UPDATE jobs SET state = $3, attempts = $4, owner = $5, total = $6, processed = $7, failed = $8,
+		checkpoint = $9, error = $10, report_type = COALESCE(NULLIF($11, ''), report_type), updated_at = $12, finished_at = $13
+	WHERE id = $1 AND owner = $2`
+	res, err := tx.ExecContext(ctx, updateQuery, rec.ID, owner, rec.State, rec.Attempts, rec.Owner, rec.Total, rec.Processed,
+		rec.Failed, nullJSON(rec.Checkpoint), rec.Error, rec.ReportType, rec.UpdatedAt, rec.FinishedAt)
+	if err != nil {
+		return queryError("update_job", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return queryError("update_job", err)
+	}
+	if n == 0 {
+		return jobs.ErrLeaseLost
+	}
+
+	if len(report) > 0 {
+		partQuery := `INSERT INTO job_report_parts (job_id, seq, data)
+		SELECT $1, COALESCE(MAX(seq), 0) + 1, $2 FROM job_report_parts WHERE job_id = $1`
+		if _, err := tx.ExecContext(ctx, partQuery, rec.ID, report); err != nil {
+			return queryError("insert_job_report_part", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return queryError("commit", err)
+	}
+	return nil
+}
+
+// GetJob returns a job with its payload and last checkpoint.
+func (r *WalletRepository) GetJob(ctx context.Context, id uuid.UUID) (jobs.Record, error) {
+	op := "repository.GetJob"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("job_id", id.String()))
+
+	query := `SELECT ` + jobColumns + `, payload, checkpoint, report_type FROM jobs WHERE id = $1`
+
+	var rec jobs.Record
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanJob(r.conn(ctx).QueryRowContext(ctx, query, id), &rec.Job, (*[]byte)(&rec.Payload), (*[]byte)(&rec.Checkpoint), &rec.ReportType)
+		if errors.Is(err, sql.ErrNoRows) {
+			return jobs.ErrJobNotFound
+		}
+		return queryError("select_job", err)
+	})
+	if err != nil {
+		if !errors.Is(err, jobs.ErrJobNotFound) {
+			log.Error("error receiving job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return jobs.Record{}, err
+	}
+	return rec, nil
+}
+
+// ListJobs returns the most recent jobs, of kind unless it is empty,
+// newest first.
+func (r *WalletRepository) ListJobs(ctx context.Context, kind string, limit int) ([]jobs.Job, error) {
+	op := "repository.ListJobs"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("kind", kind))
+
+	query := `SELECT ` + jobColumns + ` FROM jobs
+	WHERE $1 = '' OR kind = $1
+	ORDER BY created_at DESC
+	LIMIT $2`
+
+	list := []jobs.Job{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, kind, limit)
+		if err != nil {
+			return queryError("select_jobs", err)
+		}
+		defer rows.Close()
+
+		list = list[:0]
+		for rows.Next() {
+			var job jobs.Job
+			if err := scanJob(rows, &job); err != nil {
+				return queryError("select_jobs", err)
+			}
+			list = append(list, job)
+		}
+		return queryError("select_jobs", rows.Err())
+	})
+	if err != nil {
+		log.Error("error listing jobs", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return list, nil
+}
+
+// ClaimJobs takes over up to limit unfinished jobs that were released or
+// whose owner last saved them before staleBefore, oldest first. Rows locked
+// by a concurrent claim are skipped, so each job goes to one instance.
+func (r *WalletRepository) ClaimJobs(ctx context.Context, owner string, staleBefore time.Time, limit int) ([]jobs.Record, error) {
+	op := "repository.ClaimJobs"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("owner", owner))
+
+	query := `UPDATE jobs SET owner = $1, state = 'running', attempts = attempts + 1, updated_at = $2
+	WHERE id IN (
+		SELECT id FROM jobs
+		WHERE state IN ('queued', 'running') AND (owner = '' OR updated_at < $3)
+		ORDER BY created_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + jobColumns + `, payload, checkpoint, report_type`
+
+	var claimed []jobs.Record
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, owner, time.Now().UTC(), staleBefore, limit)
+		if err != nil {
+			return queryError("claim_jobs", err)
+		}
+		defer rows.Close()
+
+		claimed = claimed[:0]
+		for rows.Next() {
+			var rec jobs.Record
+			if err := scanJob(rows, &rec.Job, (*[]byte)(&rec.Payload), (*[]byte)(&rec.Checkpoint), &rec.ReportType); err != nil {
+				return queryError("claim_jobs", err)
+			}
+			claimed = append(claimed, rec)
+		}
+		return queryError("claim_jobs", rows.Err())
+	})
+	if err != nil {
+		log.Error("error claiming jobs", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// JobReport concatenates the report parts of a job.
+func (r *WalletRepository) JobReport(ctx context.Context, id uuid.UUID) (jobs.Report, error) {
+	op := "repository.JobReport"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("job_id", id.String()))
+
+	query := `SELECT report_type,
+		(SELECT string_agg(data, ''::bytea ORDER BY seq) FROM job_report_parts WHERE job_id = jobs.id)
+	FROM jobs WHERE id = $1`
+
+	var report jobs.Report
+	err := r.withReconnect(ctx, op, func() error {
+		err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(&report.ContentType, &report.Data)
+		if errors.Is(err, sql.ErrNoRows) {
+			return jobs.ErrJobNotFound
+		}
+		return queryError("select_job_report", err)
+	})
+	if err != nil {
+		if !errors.Is(err, jobs.ErrJobNotFound) {
+			log.Error("error receiving job report", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return jobs.Report{}, err
+	}
+	if len(report.Data) == 0 {
+		return jobs.Report{}, jobs.ErrReportNotFound
+	}
+	return report, nil
+}
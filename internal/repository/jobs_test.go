@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+	"wallet-service/internal/jobs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jobCols = []string{"id", "kind", "state", "attempts", "owner", "total", "processed", "failed", "error",
+	"created_at", "updated_at", "finished_at", "has_report", "payload", "checkpoint", "report_type"}
+
+func TestSaveJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	rec := jobs.Record{
+		Job:        jobs.Job{ID: uuid.New(), State: jobs.StateRunning, Attempts: 1, Owner: "a", Processed: 10},
+		Checkpoint: json.RawMessage(`{"next":10}`),
+		ReportType: "text/csv",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE jobs SET .* WHERE id = \$1 AND owner = \$2`).
+		WithArgs(rec.ID, "a", jobs.StateRunning, 1, "a", int64(0), int64(10), int64(0), []byte(`{"next":10}`), "", "text/csv", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO job_report_parts`).
+		WithArgs(rec.ID, []byte("a,b\n")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SaveJob(context.Background(), "a", rec, []byte("a,b\n")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveJob_LeaseLost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE jobs SET`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.SaveJob(context.Background(), "a", jobs.Record{Job: jobs.Job{ID: uuid.New(), Owner: "a"}}, []byte("ignored"))
+	assert.ErrorIs(t, err, jobs.ErrLeaseLost)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	staleBefore := created.Add(time.Hour)
+
+	mock.ExpectQuery(`UPDATE jobs SET owner = \$1, state = 'running', attempts = attempts \+ 1.*FOR UPDATE SKIP LOCKED.*RETURNING`).
+		WithArgs("b", sqlmock.AnyArg(), staleBefore, 16).
+		WillReturnRows(sqlmock.NewRows(jobCols).AddRow(
+			id, "operations.bulk", "running", 2, "b", 5, 3, 1, "", created, created, nil, true,
+			[]byte(`{"operations":[]}`), []byte(`{"next":3}`), "text/csv",
+		))
+
+	recs, err := repo.ClaimJobs(context.Background(), "b", staleBefore, 16)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, id, recs[0].ID)
+	assert.Equal(t, jobs.StateRunning, recs[0].State)
+	assert.Equal(t, 2, recs[0].Attempts)
+	assert.True(t, recs[0].HasReport)
+	assert.Nil(t, recs[0].FinishedAt)
+	assert.JSONEq(t, `{"next":3}`, string(recs[0].Checkpoint))
+	assert.Equal(t, "text/csv", recs[0].ReportType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJob_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	mock.ExpectQuery(`SELECT .* FROM jobs WHERE id = \$1`).WillReturnRows(sqlmock.NewRows(jobCols))
+
+	_, err = repo.GetJob(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+}
+
+func TestJobReport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	mock.ExpectQuery(`SELECT report_type,\s+\(SELECT string_agg`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"report_type", "data"}).AddRow("text/csv", []byte("a\nb\n")))
+	mock.ExpectQuery(`SELECT report_type`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"report_type", "data"}).AddRow("", nil))
+
+	report, err := repo.JobReport(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.Report{ContentType: "text/csv", Data: []byte("a\nb\n")}, report)
+
+	_, err = repo.JobReport(context.Background(), id)
+	assert.ErrorIs(t, err, jobs.ErrReportNotFound)
+}
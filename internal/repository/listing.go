@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ListWallets returns up to limit wallets matching f that follow after in
+// the order given by sort. Pass the zero cursor to start from the
+// beginning. Pages are keyset-paginated on the sort key and id.
+func (r *WalletRepository) ListWallets(ctx context.Context, f models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, error) {
+	op := "repository.ListWallets"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	where, args := walletFilterClause(f, []any{limit})
+	position, order, args := walletSortClause(sort, after, args)
+	query := `SELECT ` + walletColumns + ` FROM wallets
+	WHERE ` + position + ` AND ` + where + `
+	ORDER BY ` + order + `
+	LIMIT $1`
+
+	var wallets []models.Wallet
+	err := r.withReconnect(ctx, op, func() error {
+		var err error
+		wallets, err = scanWallets(r.reader(ctx).QueryContext(ctx, query, args...))
+		return queryError("select_wallets", err)
+	})
+	if err != nil {
+		log.Error("error listing wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// walletSortClause renders the keyset condition selecting the wallets
+// after the cursor and the ORDER BY list for s, numbering placeholders
+// after the given args. The condition is "TRUE" for the zero cursor.
+func walletSortClause(s models.WalletSort, after models.WalletCursor, args []any) (string, string, []any) {
+	cmp, dir := ">", ""
+	if s.Desc {
+		cmp, dir = "<", " DESC"
+	}
+	var (
+		col string
+		key any
+	)
+	switch s.Field {
+	case models.WalletSortBalance:
+		col, key = "balance", after.Balance
+	case models.WalletSortCreatedAt:
+		col, key = "created_at", after.CreatedAt.UTC()
+	}
+	order := "id" + dir
+	if col != "" {
+		order = col + dir + ", " + order
+	}
+
+	if after.ID == uuid.Nil {
+		return "TRUE", order, args
+	}
+	args = append(args, after.ID)
+	if col == "" {
+		return fmt.Sprintf("id %s $%d", cmp, len(args)), order, args
+	}
+	args = append(args, key)
+	return fmt.Sprintf("(%s, id) %s ($%d, $%d)", col, cmp, len(args), len(args)-1), order, args
+}
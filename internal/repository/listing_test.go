@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWallets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	after := uuid.New()
+	testID := uuid.New()
+	minBalance, maxBalance := int64(100), int64(500)
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM wallets\s+WHERE id > \$6 AND status = \$2 AND currency = \$3 AND balance >= \$4 AND balance <= \$5\s+ORDER BY id\s+LIMIT \$1`).
+		WithArgs(51, models.WalletStatusActive, "EUR", minBalance, maxBalance, after).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(testID, 200, now, now, 1)...))
+
+	wallets, err := repo.ListWallets(context.Background(), models.WalletFilter{
+		Status:     models.WalletStatusActive,
+		Currency:   "EUR",
+		MinBalance: &minBalance,
+		MaxBalance: &maxBalance,
+	}, models.WalletSort{}, models.WalletCursor{ID: after}, 51)
+
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	assert.Equal(t, testID, wallets[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWallets_Sorted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	after := models.WalletCursor{ID: uuid.New(), Balance: 500, CreatedAt: time.Now()}
+	minBalance := int64(100)
+
+	mock.ExpectQuery(`FROM wallets\s+WHERE TRUE AND balance >= \$2\s+ORDER BY balance DESC, id DESC\s+LIMIT \$1`).
+		WithArgs(21, minBalance).
+		WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`FROM wallets\s+WHERE \(balance, id\) < \(\$4, \$3\) AND balance >= \$2\s+ORDER BY balance DESC, id DESC\s+LIMIT \$1`).
+		WithArgs(21, minBalance, after.ID, after.Balance).
+		WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`FROM wallets\s+WHERE \(created_at, id\) > \(\$3, \$2\) AND TRUE\s+ORDER BY created_at, id\s+LIMIT \$1`).
+		WithArgs(21, after.ID, after.CreatedAt.UTC()).
+		WillReturnRows(sqlmock.NewRows(walletCols))
+
+	byBalance := models.WalletSort{Field: models.WalletSortBalance, Desc: true}
+	_, err = repo.ListWallets(context.Background(), models.WalletFilter{MinBalance: &minBalance}, byBalance, models.WalletCursor{}, 21)
+	require.NoError(t, err)
+	_, err = repo.ListWallets(context.Background(), models.WalletFilter{MinBalance: &minBalance}, byBalance, after, 21)
+	require.NoError(t, err)
+	_, err = repo.ListWallets(context.Background(), models.WalletFilter{}, models.WalletSort{Field: models.WalletSortCreatedAt}, after, 21)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchWallets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM wallets\s+WHERE id > \$2 AND \(id::text LIKE \$1 OR owner_id::text LIKE \$1 OR lower\(label\) LIKE \$1 OR lower\(tenant\) LIKE \$1\)\s+ORDER BY id\s+LIMIT \$3`).
+		WithArgs(`acme\_50\%%`, uuid.Nil, 21).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(testID, 200, now, now, 1)...))
+
+	wallets, err := repo.SearchWallets(context.Background(), "ACME_50%", uuid.Nil, 21)
+
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	assert.Equal(t, testID, wallets[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
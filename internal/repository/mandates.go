@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMandateNotFound      = errors.New("mandate not found")
+	ErrMandateRevoked       = errors.New("mandate is revoked")
+	ErrMandateCounterparty  = errors.New("counterparty does not match mandate")
+	ErrMandateLimitExceeded = errors.New("mandate limit exceeded")
+)
+
+const mandateColumns = `id, wallet_id, counterparty, amount_limit, max_debits, period, status, created_at, revoked_at`
+
+func scanMandate(row rowScanner, m *models.Mandate) error {
+	var revokedAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.WalletID, &m.Counterparty, &m.Limit, &m.MaxDebits, &m.Period, &m.Status,
+		utc(&m.CreatedAt), &revokedAt); err != nil {
+		return err
+	}
+	m.RevokedAt = utcPtr(revokedAt)
+	return nil
+}
+
+func (r *WalletRepository) CreateMandate(ctx context.Context, m models.Mandate) (*models.Mandate, error) {
+	op := "repository.CreateMandate"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", m.WalletID.String()))
+
+	query := `INSERT INTO mandates (id, wallet_id, counterparty, amount_limit, max_debits, period, status, created_at)
+	SELECT $1, id, $3, $4, $5, $6, $7, $8 FROM wallets WHERE id = $2
+	RETURNING ` + mandateColumns
+
+	mandate := &models.Mandate{}
+	err := r.withReconnect(ctx, op, func() error {
+		return scanMandate(r.conn(ctx).QueryRowContext(ctx, query,
+			m.ID,
+			m.WalletID,
+			m.Counterparty,
+			m.Limit,
+			m.MaxDebits,
+			m.Period,
+			m.Status,
+			m.CreatedAt,
+		), mandate)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("wallet not found")
+			return nil, ErrWalletNotFound
+		}
+		log.Error("error creating mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return mandate, nil
+}
+
+func (r *WalletRepository) GetMandate(ctx context.Context, id uuid.UUID) (*models.Mandate, error) {
+	op := "repository.GetMandate"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("mandate_id", id.String()))
+
+	query := `SELECT ` + mandateColumns + ` FROM mandates WHERE id = $1`
+	mandate := &models.Mandate{}
+	err := r.withReconnect(ctx, op, func() error {
+		return scanMandate(r.reader(ctx).QueryRowContext(ctx, query, id), mandate)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("mandate not found")
+			return nil, ErrMandateNotFound
+		}
+		log.Error("error receiving mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// ListMandates returns all mandates of a wallet, newest first.
+func (r *WalletRepository) ListMandates(ctx context.Context, walletID uuid.UUID) ([]models.Mandate, error) {
+	op := "repository.ListMandates"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT ` + mandateColumns + ` FROM mandates WHERE wallet_id = $1 ORDER BY created_at DESC`
+
+	mandates := []models.Mandate{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, walletID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		mandates = mandates[:0]
+		for rows.Next() {
+			var m models.Mandate
+			if err := scanMandate(rows, &m); err != nil {
+				return err
+			}
+			mandates = append(mandates, m)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing mandates", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return mandates, nil
+}
+
+// RevokeMandate revokes an active mandate of the wallet. Revoking an already
+// revoked mandate is a no-op that returns it unchanged.
+func (r *WalletRepository) RevokeMandate(ctx context.Context, walletID, mandateID uuid.UUID, at time.Time) (*models.Mandate, error) {
+	op := "repository.RevokeMandate"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()),
+		slog.String("mandate_id", mandateID.String()))
+
+	query := `UPDATE mandates SET status = $1, revoked_at = COALESCE(revoked_at, $2)
+	WHERE id = $3 AND wallet_id = $4
+	RETURNING ` + mandateColumns
+
+	mandate := &models.Mandate{}
+	err := r.withReconnect(ctx, op, func() error {
+		return scanMandate(r.conn(ctx).QueryRowContext(ctx, query, models.MandateStatusRevoked, at, mandateID, walletID), mandate)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("mandate not found")
+			return nil, ErrMandateNotFound
+		}
+		log.Error("error revoking mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// DebitMandate withdraws debit.Amount from the mandate's wallet on behalf of
+// debit.Counterparty. The mandate row is locked for the whole transaction so
+// concurrent debits can't together exceed the limits of the period that
+// started at periodStart.
+func (r *WalletRepository) DebitMandate(ctx context.Context, debit models.MandateDebit, periodStart time.Time) (*models.MandateDebit, error) {
+	var result *models.MandateDebit
+	err := r.withReconnect(ctx, "repository.DebitMandate", func() error {
+		var err error
+		result, err = r.debitMandate(ctx, debit, periodStart)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) debitMandate(ctx context.Context, debit models.MandateDebit, periodStart time.Time) (_ *models.MandateDebit, err error) {
+	op := "repository.DebitMandate"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("mandate_id", debit.MandateID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("begin", err)
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	mandate := models.Mandate{}
+	err = scanMandate(tx.QueryRowContext(ctx,
+		`SELECT `+mandateColumns+` FROM mandates WHERE id = $1 FOR UPDATE`, debit.MandateID), &mandate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("mandate not found")
+			return nil, ErrMandateNotFound
+		}
+		log.Error("error receiving mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("select_mandate_for_update", err)
+	}
+	if mandate.Status != models.MandateStatusActive {
+		log.Warn("debit on revoked mandate rejected")
+		return nil, ErrMandateRevoked
+	}
+	if mandate.Counterparty != debit.Counterparty {
+		log.Warn("debit by foreign counterparty rejected", slog.String("counterparty", debit.Counterparty))
+		return nil, ErrMandateCounterparty
+	}
+
+	var total int64
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0), COUNT(*) FROM mandate_debits
+	WHERE mandate_id = $1 AND created_at >= $2`, mandate.ID, periodStart).Scan(&total, &count)
+	if err != nil {
+		log.Error("error summing mandate debits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("sum_mandate_debits", err)
+	}
+	if total+debit.Amount > mandate.Limit || (mandate.MaxDebits > 0 && count >= mandate.MaxDebits) {
+		log.Warn("mandate limit exceeded", slog.Int64("period_total", total), slog.Int("period_debits", count))
+		return nil, ErrMandateLimitExceeded
+	}
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, mandate.WalletID), &wallet)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWalletNotFound
+		}
+		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("select_wallet_for_update", err)
+	}
+
+	updated, err := r.applyOperation(ctx, tx, log, &wallet, debit.Amount, models.OperationTypeWithdraw)
+	if err != nil {
+		return nil, err
+	}
+
+	result := debit
+	result.WalletID = updated.ID
+	result.Balance = updated.Balance
+	result.Version = updated.Version
+	_, err = tx.ExecContext(ctx, `INSERT INTO mandate_debits (id, mandate_id, wallet_id, counterparty, amount, balance, version, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		result.ID,
+		result.MandateID,
+		result.WalletID,
+		result.Counterparty,
+		result.Amount,
+		result.Balance,
+		result.Version,
+		result.CreatedAt,
+	)
+	if err != nil {
+		log.Error("error recording mandate debit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("insert_mandate_debit", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("commit", err)
+	}
+	return &result, nil
+}
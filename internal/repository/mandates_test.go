@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mandateCols = []string{"id", "wallet_id", "counterparty", "amount_limit", "max_debits", "period", "status", "created_at", "revoked_at"}
+
+func TestDebitMandate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	mandateID, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+	periodStart := now.Truncate(24 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM mandates WHERE id = \$1 FOR UPDATE`).WithArgs(mandateID).
+		WillReturnRows(sqlmock.NewRows(mandateCols).
+			AddRow(mandateID, walletID, "acme", 100, 0, "DAY", "ACTIVE", now, nil))
+	mock.ExpectQuery(`FROM mandate_debits`).WithArgs(mandateID, periodStart).
+		WillReturnRows(sqlmock.NewRows([]string{"sum", "count"}).AddRow(60, 2))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 500, now, now, 4)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(460, sqlmock.AnyArg(), walletID, 4, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(walletID, 460, now, now, 5)...))
+	// The debit is a withdrawal, counted against withdrawal limits.
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(walletID, 5, 460, models.OperationTypeWithdraw, 40, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO mandate_debits`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	debit, err := repo.DebitMandate(context.Background(), models.MandateDebit{
+		ID:           uuid.New(),
+		MandateID:    mandateID,
+		Counterparty: "acme",
+		Amount:       40,
+		CreatedAt:    now,
+	}, periodStart)
+
+	require.NoError(t, err)
+	assert.Equal(t, walletID, debit.WalletID)
+	assert.Equal(t, int64(460), debit.Balance)
+	assert.Equal(t, 5, debit.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDebitMandate_Rejected(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		status       string
+		counterparty string
+		maxDebits    int
+		amount       int64
+		wantErr      error
+	}{
+		{name: "revoked", status: "REVOKED", counterparty: "acme", amount: 10, wantErr: ErrMandateRevoked},
+		{name: "foreign counterparty", status: "ACTIVE", counterparty: "other", amount: 10, wantErr: ErrMandateCounterparty},
+		{name: "amount over limit", status: "ACTIVE", counterparty: "acme", amount: 41, wantErr: ErrMandateLimitExceeded},
+		{name: "too many debits", status: "ACTIVE", counterparty: "acme", maxDebits: 2, amount: 10, wantErr: ErrMandateLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			repo := NewWalletRepository(db, log)
+			mandateID := uuid.New()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`FROM mandates`).WithArgs(mandateID).
+				WillReturnRows(sqlmock.NewRows(mandateCols).
+					AddRow(mandateID, uuid.New(), "acme", 100, tt.maxDebits, "DAY", tt.status, now, nil))
+			if tt.status == "ACTIVE" && tt.counterparty == "acme" {
+				mock.ExpectQuery(`FROM mandate_debits`).
+					WillReturnRows(sqlmock.NewRows([]string{"sum", "count"}).AddRow(60, 2))
+			}
+			mock.ExpectRollback()
+
+			_, err = repo.DebitMandate(context.Background(), models.MandateDebit{
+				ID:           uuid.New(),
+				MandateID:    mandateID,
+				Counterparty: tt.counterparty,
+				Amount:       tt.amount,
+				CreatedAt:    now,
+			}, now)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
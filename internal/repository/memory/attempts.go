@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+)
+
+func (r *Repository) RecordOperationAttempts(_ context.Context, attempts []models.OperationAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, attempts...)
+	return nil
+}
+
+func (r *Repository) ListOperationAttempts(_ context.Context, operationID string) ([]models.OperationAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts := []models.OperationAttempt{}
+	for _, a := range r.attempts {
+		if a.OperationID == operationID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *Repository) PurgeOperationAttempts(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.attempts)
+	r.attempts = slices.DeleteFunc(r.attempts, func(a models.OperationAttempt) bool {
+		return a.StartedAt.Before(before)
+	})
+	return int64(n - len(r.attempts)), nil
+}
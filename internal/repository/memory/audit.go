@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) AppendAuditEvent(_ context.Context, e models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.OccurredAt = e.OccurredAt.UTC()
+	r.audit = append(r.audit, e)
+	return nil
+}
+
+func (r *Repository) ListAuditEvents(_ context.Context, walletID uuid.UUID, after models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []models.AuditEvent{}
+	for _, e := range r.audit {
+		if e.WalletID.Valid && e.WalletID.UUID == walletID && compareAuditEvents(e, after) > 0 {
+			events = append(events, e)
+		}
+	}
+	slices.SortFunc(events, compareAuditEvents)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// compareAuditEvents orders events like the Postgres repository does: by
+// time and id.
+func compareAuditEvents(a, b models.AuditEvent) int {
+	if c := a.OccurredAt.Compare(b.OccurredAt); c != 0 {
+		return c
+	}
+	return slices.Compare(a.ID[:], b.ID[:])
+}
+
+// PurgeAuditEvents deletes the events of whole months ended by before,
+// except those of wallets on legal hold.
+func (r *Repository) PurgeAuditEvents(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	r.audit = slices.DeleteFunc(r.audit, func(e models.AuditEvent) bool {
+		at := e.OccurredAt.UTC()
+		month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if month.AddDate(0, 1, 0).After(before) {
+			return false
+		}
+		if _, held := r.holds[e.WalletID.UUID]; e.WalletID.Valid && held {
+			return false
+		}
+		deleted++
+		return true
+	})
+	return deleted, nil
+}
+
+func (r *Repository) PlaceLegalHold(_ context.Context, h models.LegalHold) (*models.LegalHold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.wallets[h.WalletID]; !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if _, ok := r.holds[h.WalletID]; ok {
+		return nil, repository.ErrLegalHoldExists
+	}
+	h.PlacedAt = h.PlacedAt.UTC()
+	r.holds[h.WalletID] = h
+	return &h, nil
+}
+
+func (r *Repository) GetLegalHold(_ context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.holds[walletID]
+	if !ok {
+		return nil, repository.ErrLegalHoldNotFound
+	}
+	return &h, nil
+}
+
+func (r *Repository) ReleaseLegalHold(_ context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.holds[walletID]
+	if !ok {
+		return nil, repository.ErrLegalHoldNotFound
+	}
+	delete(r.holds, walletID)
+	return &h, nil
+}
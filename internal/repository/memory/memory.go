@@ -0,0 +1,777 @@
+// Package memory is an in-memory implementation of the wallet repository
+// for environments without Postgres, such as the mock server. It covers
+// wallets, operations, history, bulk status changes, audit events, legal
+// holds, operation attempts, webhooks, the outbox and snapshots; the
+// remaining features report ErrNotSupported.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotSupported = errors.New("not supported by the in-memory repository")
+
+type Repository struct {
+	// txMu admits one WithinTx transaction at a time.
+	txMu     sync.Mutex
+	mu       sync.Mutex
+	wallets  map[uuid.UUID]*models.Wallet
+	versions map[uuid.UUID][]models.WalletVersion
+	// snapshots are kept in version order.
+	snapshots map[uuid.UUID][]models.WalletSnapshot
+	hits      []models.ScreeningHit
+	audit     []models.AuditEvent
+	attempts  []models.OperationAttempt
+	holds     map[uuid.UUID]models.LegalHold
+	webhooks  map[uuid.UUID]models.WebhookSubscription
+	// deliveries are kept in the order they were queued.
+	deliveries []models.WebhookDelivery
+	delivered  []models.WebhookDeliveryAttempt
+	// outbox is kept in Seq order; relayMu admits one RelayOutbox at a
+	// time.
+	outbox    []models.OutboxEvent
+	outboxSeq int64
+	relayMu   sync.Mutex
+	// shardTransfers are those this repository is the source shard of;
+	// shardClaims the outcomes of those it is the target shard of.
+	shardTransfers map[uuid.UUID]models.ShardTransfer
+	shardClaims    map[uuid.UUID]models.ShardTransferStatus
+}
+
+func New() *Repository {
+	return &Repository{
+		wallets:   make(map[uuid.UUID]*models.Wallet),
+		versions:  make(map[uuid.UUID][]models.WalletVersion),
+		snapshots: make(map[uuid.UUID][]models.WalletSnapshot),
+		holds:     make(map[uuid.UUID]models.LegalHold),
+		webhooks:  make(map[uuid.UUID]models.WebhookSubscription),
+
+		shardTransfers: make(map[uuid.UUID]models.ShardTransfer),
+		shardClaims:    make(map[uuid.UUID]models.ShardTransferStatus),
+	}
+}
+
+func (r *Repository) CreateWallet(_ context.Context, id uuid.UUID, params models.CreateWalletRequest) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	w := &models.Wallet{
+		ID:        id,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+		OwnerID:   params.OwnerID,
+		Currency:  params.Currency,
+		Status:    models.WalletStatusActive,
+		Label:     params.Label,
+		Tenant:    params.Tenant,
+	}
+	r.wallets[id] = w
+	r.versions[id] = []models.WalletVersion{{WalletID: id, Version: 1, OperationType: models.OperationTypeCreate, CreatedAt: now}}
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) CreateWallets(ctx context.Context, ids []uuid.UUID, params models.CreateWalletRequest) error {
+	for _, id := range ids {
+		if _, err := r.CreateWallet(ctx, id, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) GetWalletBalance(_ context.Context, id uuid.UUID) (*models.WalletBalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	return &models.WalletBalance{WalletID: id, Balance: w.Balance, Available: w.AvailableBalance()}, nil
+}
+
+func (r *Repository) UpdateWalletBalance(_ context.Context, id uuid.UUID, amount int64, opType models.OperationType) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	updated, err := apply(*w, amount, opType)
+	if err != nil {
+		return nil, err
+	}
+	r.commit(updated, amount, opType)
+	return &updated, nil
+}
+
+type txKey struct{ repo *Repository }
+
+// WithinWalletTx is WithinTx.
+func (r *Repository) WithinWalletTx(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return r.WithinTx(ctx, fn)
+}
+
+// WithinTx runs fn and, if it fails, restores the wallets as they were
+// before. Transactions run one at a time, but calls made outside of one
+// aren't isolated from it: rolling back also reverts their changes, which
+// is good enough for the environments this repository serves.
+func (r *Repository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{r}) != nil {
+		return fn(ctx)
+	}
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.Lock()
+	wallets := make(map[uuid.UUID]*models.Wallet, len(r.wallets))
+	for id, w := range r.wallets {
+		copied := *w
+		wallets[id] = &copied
+	}
+	versions := make(map[uuid.UUID][]models.WalletVersion, len(r.versions))
+	for id, v := range r.versions {
+		versions[id] = slices.Clone(v)
+	}
+	snapshots := make(map[uuid.UUID][]models.WalletSnapshot, len(r.snapshots))
+	for id, s := range r.snapshots {
+		snapshots[id] = slices.Clone(s)
+	}
+	hits := slices.Clone(r.hits)
+	audit := slices.Clone(r.audit)
+	holds := maps.Clone(r.holds)
+	outbox := slices.Clone(r.outbox)
+	shardTransfers, shardClaims := maps.Clone(r.shardTransfers), maps.Clone(r.shardClaims)
+	r.mu.Unlock()
+
+	if err := fn(context.WithValue(ctx, txKey{r}, struct{}{})); err != nil {
+		r.mu.Lock()
+		r.wallets, r.versions, r.snapshots = wallets, versions, snapshots
+		r.hits, r.audit, r.holds, r.outbox = hits, audit, holds, outbox
+		r.shardTransfers, r.shardClaims = shardTransfers, shardClaims
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// apply returns w after the operation, following the ledger rules.
+func apply(w models.Wallet, amount int64, opType models.OperationType) (models.Wallet, error) {
+	change, err := ledger.Apply(w, amount, opType)
+	if err != nil {
+		return w, err
+	}
+	w.Balance, w.PromoBalance = change.Balance, change.PromoBalance
+	w.Version++
+	w.UpdatedAt = time.Now().UTC()
+	return w, nil
+}
+
+// commit stores w and its new version. r.mu must be held.
+func (r *Repository) commit(w models.Wallet, amount int64, opType models.OperationType) {
+	r.wallets[w.ID] = &w
+	r.versions[w.ID] = append(r.versions[w.ID], models.WalletVersion{
+		WalletID:      w.ID,
+		Version:       w.Version,
+		Balance:       w.Balance,
+		OperationType: opType,
+		Amount:        amount,
+		CreatedAt:     w.UpdatedAt,
+	})
+}
+
+func (r *Repository) ApplyAtomic(_ context.Context, steps []models.WalletOperation) ([]models.AtomicStepResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	staged := make(map[uuid.UUID]models.Wallet)
+	results := make([]models.AtomicStepResult, 0, len(steps))
+	for i, step := range steps {
+		w, ok := staged[step.WalletID]
+		if !ok {
+			stored, found := r.wallets[step.WalletID]
+			if !found {
+				return nil, &repository.StepError{Index: i, Err: repository.ErrWalletNotFound}
+			}
+			w = *stored
+		}
+		updated, err := apply(w, step.Amount, step.OperationType)
+		if err != nil {
+			return nil, &repository.StepError{Index: i, Err: err}
+		}
+		staged[step.WalletID] = updated
+		results = append(results, models.AtomicStepResult{
+			Index:         i,
+			WalletID:      updated.ID,
+			OperationType: step.OperationType,
+			Amount:        step.Amount,
+			Balance:       updated.Balance,
+			Version:       updated.Version,
+		})
+	}
+	for i, step := range steps {
+		w := *r.wallets[step.WalletID]
+		w.Balance, w.Version = results[i].Balance, results[i].Version
+		w.UpdatedAt = staged[step.WalletID].UpdatedAt
+		r.commit(w, step.Amount, step.OperationType)
+	}
+	return results, nil
+}
+
+func (r *Repository) Transfer(_ context.Context, fromID, toID uuid.UUID, amount int64) (*models.Wallet, *models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	from, ok := r.wallets[fromID]
+	if !ok {
+		return nil, nil, repository.ErrWalletNotFound
+	}
+	to, ok := r.wallets[toID]
+	if !ok || fromID == toID {
+		return nil, nil, repository.ErrWalletNotFound
+	}
+	if from.Currency != to.Currency {
+		return nil, nil, repository.ErrCurrencyMismatch
+	}
+	debited, err := apply(*from, amount, models.OperationTypeWithdraw)
+	if err != nil {
+		return nil, nil, err
+	}
+	credited, err := apply(*to, amount, models.OperationTypeDeposit)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.commit(debited, amount, models.OperationTypeWithdraw)
+	r.commit(credited, amount, models.OperationTypeDeposit)
+	return &debited, &credited, nil
+}
+
+// sorted returns copies of the wallets matching keep in id order. r.mu must
+// be held.
+func (r *Repository) sorted(keep func(*models.Wallet) bool) []models.Wallet {
+	var wallets []models.Wallet
+	for _, w := range r.wallets {
+		if keep(w) {
+			wallets = append(wallets, *w)
+		}
+	}
+	slices.SortFunc(wallets, func(a, b models.Wallet) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	return wallets
+}
+
+func (r *Repository) ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error {
+	r.mu.Lock()
+	wallets := r.sorted(func(*models.Wallet) bool { return true })
+	r.mu.Unlock()
+
+	for len(wallets) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := min(batchSize, len(wallets))
+		if err := fn(wallets[:n]); err != nil {
+			return err
+		}
+		wallets = wallets[n:]
+	}
+	return nil
+}
+
+func (r *Repository) BalanceSummary(_ context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summary := &models.BalanceSummary{From: from, To: to}
+	for _, w := range r.wallets {
+		summary.WalletCount++
+		summary.TotalBalance += w.Balance
+		if !w.CreatedAt.Before(from) && w.CreatedAt.Before(to) {
+			summary.CreatedInRange++
+		}
+	}
+	return summary, nil
+}
+
+func (r *Repository) GetWalletVersions(_ context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.versions[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	return slices.Clone(versions), nil
+}
+
+func (r *Repository) WalletVersionsAfter(_ context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.versions[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	after := []models.WalletVersion{}
+	for _, v := range versions {
+		if v.Version > afterVersion && len(after) < limit {
+			after = append(after, v)
+		}
+	}
+	return after, nil
+}
+
+// ListTransactions leaves Transaction.ID zero: the in-memory ledger has no
+// global sequence numbers.
+func (r *Repository) ListTransactions(_ context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.versions[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	transactions := []models.Transaction{}
+	for i := len(versions) - 1; i >= 0 && len(transactions) < limit; i-- {
+		v := versions[i]
+		if v.OperationType == models.OperationTypeCreate || (beforeVersion > 0 && v.Version >= beforeVersion) {
+			continue
+		}
+		transactions = append(transactions, models.Transaction{
+			WalletID:      v.WalletID,
+			Version:       v.Version,
+			OperationType: v.OperationType,
+			Amount:        v.Amount,
+			Balance:       v.Balance,
+			CreatedAt:     v.CreatedAt,
+		})
+	}
+	return transactions, nil
+}
+
+// ArchiveTransactions returns a wallet's transactions after afterVersion,
+// oldest first. Notes aren't kept in memory, so they have none.
+func (r *Repository) ArchiveTransactions(_ context.Context, walletID uuid.UUID, afterVersion, limit int) ([]models.ArchivedTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var transactions []models.ArchivedTransaction
+	for _, v := range r.versions[walletID] {
+		if v.OperationType == models.OperationTypeCreate || v.Version <= afterVersion {
+			continue
+		}
+		if len(transactions) == limit {
+			break
+		}
+		transactions = append(transactions, models.ArchivedTransaction{Transaction: models.Transaction{
+			WalletID:      v.WalletID,
+			Version:       v.Version,
+			OperationType: v.OperationType,
+			Amount:        v.Amount,
+			Balance:       v.Balance,
+			CreatedAt:     v.CreatedAt,
+		}})
+	}
+	return transactions, nil
+}
+
+func (r *Repository) ListWalletVersions(_ context.Context, from, to time.Time) ([]models.WalletVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var versions []models.WalletVersion
+	for _, history := range r.versions {
+		for _, v := range history {
+			if v.OperationType != models.OperationTypeCreate && !v.CreatedAt.Before(from) && v.CreatedAt.Before(to) {
+				versions = append(versions, v)
+			}
+		}
+	}
+	slices.SortFunc(versions, compareVersions)
+	return versions, nil
+}
+
+// compareVersions orders versions like the Postgres repository does: by
+// creation time, wallet and version.
+func compareVersions(a, b models.WalletVersion) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.WalletID[:], b.WalletID[:]); c != 0 {
+		return c
+	}
+	return a.Version - b.Version
+}
+
+func (r *Repository) OwnerBalances(_ context.Context, ownerID uuid.UUID) ([]models.CurrencyBalance, error) {
+	return r.currencyBalances(func(w *models.Wallet) bool {
+		return w.OwnerID.Valid && w.OwnerID.UUID == ownerID
+	}), nil
+}
+
+func (r *Repository) TenantBalances(_ context.Context, tenant string) ([]models.CurrencyBalance, error) {
+	return r.currencyBalances(func(w *models.Wallet) bool {
+		return w.Tenant == tenant
+	}), nil
+}
+
+func (r *Repository) currencyBalances(include func(*models.Wallet) bool) []models.CurrencyBalance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byCurrency := make(map[string]*models.CurrencyBalance)
+	balances := []models.CurrencyBalance{}
+	for _, w := range r.wallets {
+		if !include(w) {
+			continue
+		}
+		b, ok := byCurrency[w.Currency]
+		if !ok {
+			b = &models.CurrencyBalance{Currency: w.Currency}
+			byCurrency[w.Currency] = b
+		}
+		b.Balance += w.Balance
+		b.WalletCount++
+	}
+	for _, b := range byCurrency {
+		balances = append(balances, *b)
+	}
+	slices.SortFunc(balances, func(a, b models.CurrencyBalance) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	return balances
+}
+
+// CurrencyTotals sums balances and recorded movements per currency, leaving
+// out closed wallets like the Postgres repository does.
+func (r *Repository) CurrencyTotals(context.Context) ([]models.CurrencyTotals, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byCurrency := make(map[string]*models.CurrencyTotals)
+	for id, w := range r.wallets {
+		if w.Status == models.WalletStatusClosed {
+			continue
+		}
+		t, ok := byCurrency[w.Currency]
+		if !ok {
+			t = &models.CurrencyTotals{Currency: w.Currency}
+			byCurrency[w.Currency] = t
+		}
+		if w.Label == models.SuspenseWalletLabel {
+			t.SystemBalance += w.Balance
+		} else {
+			t.Balance += w.Balance
+		}
+		for _, v := range r.versions[id] {
+			switch {
+			case slices.Contains(ledger.Credits, v.OperationType):
+				t.Credits += v.Amount
+			case slices.Contains(ledger.Debits, v.OperationType):
+				t.Debits += v.Amount
+			}
+		}
+	}
+	totals := []models.CurrencyTotals{}
+	for _, t := range byCurrency {
+		totals = append(totals, *t)
+	}
+	slices.SortFunc(totals, func(a, b models.CurrencyTotals) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	return totals, nil
+}
+
+func matches(w *models.Wallet, f models.WalletFilter) bool {
+	return (!f.OwnerID.Valid || w.OwnerID == f.OwnerID) &&
+		(f.Label == "" || w.Label == f.Label) &&
+		(f.Tenant == "" || w.Tenant == f.Tenant) &&
+		(f.Status == "" || w.Status == f.Status) &&
+		(f.Currency == "" || w.Currency == f.Currency) &&
+		(f.MinBalance == nil || w.Balance >= *f.MinBalance) &&
+		(f.MaxBalance == nil || w.Balance <= *f.MaxBalance) &&
+		(f.CreatedAfter == nil || !w.CreatedAt.Before(*f.CreatedAfter)) &&
+		(f.CreatedBefore == nil || w.CreatedAt.Before(*f.CreatedBefore))
+}
+
+// ListWallets returns up to limit wallets matching f that follow after in
+// the order given by sort.
+func (r *Repository) ListWallets(_ context.Context, f models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var wallets []models.Wallet
+	for _, w := range r.wallets {
+		if sort.After(*w, after) && matches(w, f) {
+			wallets = append(wallets, *w)
+		}
+	}
+	slices.SortFunc(wallets, sort.Compare)
+	if len(wallets) > limit {
+		wallets = wallets[:limit]
+	}
+	return wallets, nil
+}
+
+// SearchWallets returns up to limit wallets with ids greater than after, in
+// id order, whose id, owner id, label or tenant starts with prefix,
+// ignoring case.
+func (r *Repository) SearchWallets(_ context.Context, prefix string, after uuid.UUID, limit int) ([]models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prefix = strings.ToLower(prefix)
+	hasPrefix := func(s string) bool { return strings.HasPrefix(strings.ToLower(s), prefix) }
+	wallets := r.sorted(func(w *models.Wallet) bool {
+		return bytes.Compare(w.ID[:], after[:]) > 0 && (hasPrefix(w.ID.String()) || (w.OwnerID.Valid && hasPrefix(w.OwnerID.UUID.String())) ||
+			hasPrefix(w.Label) || hasPrefix(w.Tenant))
+	})
+	if len(wallets) > limit {
+		wallets = wallets[:limit]
+	}
+	return wallets, nil
+}
+
+func (r *Repository) CountWalletsToSetStatus(_ context.Context, f models.WalletFilter, status models.WalletStatus) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for _, w := range r.wallets {
+		if w.Status != status && matches(w, f) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *Repository) SetWalletsStatus(_ context.Context, f models.WalletFilter, status models.WalletStatus, batchSize int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := r.sorted(func(w *models.Wallet) bool { return w.Status != status && matches(w, f) })
+	if len(batch) > batchSize {
+		batch = batch[:batchSize]
+	}
+	now := time.Now().UTC()
+	for _, w := range batch {
+		stored := r.wallets[w.ID]
+		stored.Status = status
+		stored.UpdatedAt = now
+	}
+	return int64(len(batch)), nil
+}
+
+func (r *Repository) FlagDormantWallets(_ context.Context, idleSince time.Time, batchSize int) ([]models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := r.sorted(func(w *models.Wallet) bool {
+		return w.Status == models.WalletStatusActive && w.UpdatedAt.Before(idleSince)
+	})
+	if len(batch) > batchSize {
+		batch = batch[:batchSize]
+	}
+	now := time.Now().UTC()
+	for i, w := range batch {
+		stored := r.wallets[w.ID]
+		stored.Status = models.WalletStatusDormant
+		stored.UpdatedAt = now
+		batch[i] = *stored
+	}
+	return batch, nil
+}
+
+func (r *Repository) ReactivateWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if w.Status != models.WalletStatusDormant {
+		return nil, repository.ErrWalletNotDormant
+	}
+	w.Status = models.WalletStatusActive
+	w.UpdatedAt = time.Now().UTC()
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) FreezeWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.setWalletStatus(id, models.WalletStatusFrozen, models.WalletStatusActive, models.WalletStatusDormant)
+}
+
+func (r *Repository) UnfreezeWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.setWalletStatus(id, models.WalletStatusActive, models.WalletStatusFrozen)
+}
+
+func (r *Repository) setWalletStatus(id uuid.UUID, status models.WalletStatus, from ...models.WalletStatus) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	switch {
+	case !ok:
+		return nil, repository.ErrWalletNotFound
+	case slices.Contains(from, w.Status):
+	case w.Status == models.WalletStatusClosed:
+		return nil, repository.ErrWalletClosed
+	case w.Status == models.WalletStatusFrozen:
+		return nil, repository.ErrWalletFrozen
+	default:
+		return nil, repository.ErrWalletNotFrozen
+	}
+	w.Status = status
+	w.UpdatedAt = time.Now().UTC()
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) SetCreditLimit(_ context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	switch {
+	case !ok:
+		return nil, repository.ErrWalletNotFound
+	case w.Balance+limit-w.HeldBalance < 0:
+		return nil, repository.ErrCreditLimitTooLow
+	}
+	w.CreditLimit = limit
+	w.UpdatedAt = time.Now().UTC()
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) CloseWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	switch {
+	case !ok:
+		return nil, repository.ErrWalletNotFound
+	case w.Status == models.WalletStatusClosed:
+		return nil, repository.ErrWalletClosed
+	case w.Balance != 0 || w.HeldBalance != 0:
+		return nil, repository.ErrWalletNotEmpty
+	}
+	w.Status = models.WalletStatusClosed
+	w.UpdatedAt = time.Now().UTC()
+	copied := *w
+	return &copied, nil
+}
+
+// RestoreWallet reopens a closed wallet; the in-memory repository never
+// purges them.
+func (r *Repository) RestoreWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[id]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if w.Status != models.WalletStatusClosed {
+		return nil, repository.ErrWalletNotClosed
+	}
+	w.Status = models.WalletStatusActive
+	w.UpdatedAt = time.Now().UTC()
+	copied := *w
+	return &copied, nil
+}
+
+func (r *Repository) RecordScreeningHit(_ context.Context, hit models.ScreeningHit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hits = append(r.hits, hit)
+	return nil
+}
+
+func (r *Repository) WipeTenant(_ context.Context, tenant string) (int64, error) {
+	if tenant == "" {
+		return 0, errors.New("refusing to wipe the default tenant")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, w := range r.wallets {
+		if w.Tenant == tenant {
+			delete(r.wallets, id)
+			delete(r.versions, id)
+			delete(r.snapshots, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ListEvents numbers versions in creation order across wallets; offsets are
+// stable only while no wallet is wiped.
+func (r *Repository) ListEvents(_ context.Context, after int64, until time.Time, limit int) ([]models.LedgerEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var all []models.WalletVersion
+	for _, history := range r.versions {
+		for _, v := range history {
+			if v.OperationType != models.OperationTypeCreate {
+				all = append(all, v)
+			}
+		}
+	}
+	slices.SortFunc(all, compareVersions)
+
+	events := []models.LedgerEvent{}
+	for i, v := range all {
+		offset := int64(i + 1)
+		if offset <= after || v.CreatedAt.After(until) {
+			continue
+		}
+		if len(events) == limit {
+			break
+		}
+		events = append(events, models.LedgerEvent{
+			Offset:        offset,
+			WalletID:      v.WalletID,
+			Version:       v.Version,
+			OperationType: v.OperationType,
+			Amount:        v.Amount,
+			Balance:       v.Balance,
+			CreatedAt:     v.CreatedAt,
+		})
+	}
+	return events, nil
+}
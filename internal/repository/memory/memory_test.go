@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ service.WalletRepository = (*Repository)(nil)
+
+func TestRepository_UpdateWalletBalance(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	w, err := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+
+	w, err = r.UpdateWalletBalance(ctx, w.ID, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), w.Balance)
+	assert.Equal(t, 2, w.Version)
+
+	_, err = r.UpdateWalletBalance(ctx, w.ID, 500, models.OperationTypeWithdraw)
+	assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+
+	versions, err := r.GetWalletVersions(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+}
+
+func TestRepository_WithinTx(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	kept, err := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+
+	var created uuid.UUID
+	err = r.WithinTx(ctx, func(ctx context.Context) error {
+		w, err := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+		require.NoError(t, err)
+		created = w.ID
+		_, err = r.UpdateWalletBalance(ctx, kept.ID, 100, models.OperationTypeDeposit)
+		require.NoError(t, err)
+		_, err = r.UpdateWalletBalance(ctx, w.ID, 1, models.OperationTypeWithdraw)
+		return err
+	})
+	assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+
+	_, err = r.GetWallet(ctx, created)
+	assert.ErrorIs(t, err, repository.ErrWalletNotFound)
+	w, err := r.GetWallet(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Zero(t, w.Balance)
+	versions, err := r.GetWalletVersions(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestRepository_ApplyAtomic_AllOrNothing(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	a, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	b, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	_, err := r.UpdateWalletBalance(ctx, a.ID, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+
+	_, err = r.ApplyAtomic(ctx, []models.WalletOperation{
+		{WalletID: b.ID, OperationType: models.OperationTypeDeposit, Amount: 50},
+		{WalletID: a.ID, OperationType: models.OperationTypeWithdraw, Amount: 500},
+	})
+
+	var stepErr *repository.StepError
+	require.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, 1, stepErr.Index)
+	unchanged, _ := r.GetWallet(ctx, b.ID)
+	assert.Equal(t, int64(0), unchanged.Balance)
+
+	results, err := r.ApplyAtomic(ctx, []models.WalletOperation{
+		{WalletID: a.ID, OperationType: models.OperationTypeWithdraw, Amount: 30},
+		{WalletID: b.ID, OperationType: models.OperationTypeDeposit, Amount: 30},
+		{WalletID: a.ID, OperationType: models.OperationTypeWithdraw, Amount: 20},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), results[2].Balance)
+	final, _ := r.GetWallet(ctx, a.ID)
+	assert.Equal(t, 4, final.Version)
+}
+
+func TestRepository_ListWallets(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	for i, currency := range []string{"USD", "EUR", "USD", "USD"} {
+		w, err := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: currency})
+		require.NoError(t, err)
+		_, err = r.UpdateWalletBalance(ctx, w.ID, 100+int64(i%3), models.OperationTypeDeposit)
+		require.NoError(t, err)
+	}
+
+	filter := models.WalletFilter{Currency: "USD"}
+	first, err := r.ListWallets(ctx, filter, models.WalletSort{}, models.WalletCursor{}, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+
+	rest, err := r.ListWallets(ctx, filter, models.WalletSort{}, models.CursorAt(first[1]), 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "USD", rest[0].Currency)
+	assert.NotContains(t, []uuid.UUID{first[0].ID, first[1].ID}, rest[0].ID)
+
+	// Balances are 100, 101, 102 and 100; ties are ordered by id.
+	byBalance := models.WalletSort{Field: models.WalletSortBalance, Desc: true}
+	top, err := r.ListWallets(ctx, models.WalletFilter{}, byBalance, models.WalletCursor{}, 2)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, []int64{102, 101}, []int64{top[0].Balance, top[1].Balance})
+	rest, err = r.ListWallets(ctx, models.WalletFilter{}, byBalance, models.CursorAt(top[1]), 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 2)
+	assert.Equal(t, int64(100), rest[1].Balance)
+	assert.Positive(t, bytes.Compare(rest[0].ID[:], rest[1].ID[:]))
+
+	minBalance := int64(103)
+	none, err := r.ListWallets(ctx, models.WalletFilter{MinBalance: &minBalance}, models.WalletSort{}, models.WalletCursor{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestRepository_Transfer(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	a, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	b, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	c, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "EUR"})
+	_, err := r.UpdateWalletBalance(ctx, a.ID, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+
+	from, to, err := r.Transfer(ctx, a.ID, b.ID, 30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), from.Balance)
+	assert.Equal(t, int64(30), to.Balance)
+
+	_, _, err = r.Transfer(ctx, a.ID, c.ID, 10)
+	assert.ErrorIs(t, err, repository.ErrCurrencyMismatch)
+	_, _, err = r.Transfer(ctx, a.ID, b.ID, 71)
+	assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+	unchanged, _ := r.GetWallet(ctx, b.ID)
+	assert.Equal(t, int64(30), unchanged.Balance)
+}
+
+func TestRepository_CurrencyTotals(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	a, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	b, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	c, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "EUR"})
+	_, err := r.UpdateWalletBalance(ctx, a.ID, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+	_, err = r.UpdateWalletBalance(ctx, c.ID, 40, models.OperationTypePromoCredit)
+	require.NoError(t, err)
+	_, _, err = r.Transfer(ctx, a.ID, b.ID, 30)
+	require.NoError(t, err)
+
+	totals, err := r.CurrencyTotals(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CurrencyTotals{
+		{Currency: "EUR", Balance: 40, Credits: 40},
+		{Currency: "USD", Balance: 100, Credits: 130, Debits: 30},
+	}, totals)
+	for _, total := range totals {
+		assert.Zero(t, total.Discrepancy(), total.Currency)
+	}
+}
+
+func TestRepository_ListTransactions(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	w, _ := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	for _, amount := range []int64{10, 20, 30} {
+		_, err := r.UpdateWalletBalance(ctx, w.ID, amount, models.OperationTypeDeposit)
+		require.NoError(t, err)
+	}
+
+	page, err := r.ListTransactions(ctx, w.ID, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, int64(30), page[0].Amount)
+	assert.Equal(t, int64(60), page[0].Balance)
+
+	page, err = r.ListTransactions(ctx, w.ID, page[1].Version, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, int64(10), page[0].Amount)
+
+	_, err = r.ListTransactions(ctx, uuid.New(), 0, 2)
+	assert.ErrorIs(t, err, repository.ErrWalletNotFound)
+}
+
+func TestRepository_PurgeAuditEvents(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	held, err := r.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	_, err = r.PlaceLegalHold(ctx, models.LegalHold{WalletID: held.ID, Reason: "case 42"})
+	require.NoError(t, err)
+	_, err = r.PlaceLegalHold(ctx, models.LegalHold{WalletID: held.ID, Reason: "case 43"})
+	assert.ErrorIs(t, err, repository.ErrLegalHoldExists)
+
+	april := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+	other := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	for _, e := range []models.AuditEvent{
+		{ID: uuid.New(), WalletID: uuid.NullUUID{UUID: held.ID, Valid: true}, Action: models.AuditWalletFrozen, OccurredAt: april},
+		{ID: uuid.New(), WalletID: other, Action: models.AuditWalletFrozen, OccurredAt: april},
+		{ID: uuid.New(), WalletID: other, Action: models.AuditWalletUnfrozen, OccurredAt: april.AddDate(0, 1, 0)},
+	} {
+		require.NoError(t, r.AppendAuditEvent(ctx, e))
+	}
+
+	// Only April has ended, and the held wallet's event in it is kept.
+	deleted, err := r.PurgeAuditEvents(ctx, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	events, err := r.ListAuditEvents(ctx, other.UUID, models.AuditEvent{}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditWalletUnfrozen, events[0].Action)
+	events, err = r.ListAuditEvents(ctx, held.ID, models.AuditEvent{}, 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
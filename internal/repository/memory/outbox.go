@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+)
+
+func (r *Repository) Outbox() repository.OutboxStore { return outboxStore{r} }
+
+type outboxStore struct{ r *Repository }
+
+func (s outboxStore) AppendOutboxEvent(_ context.Context, e models.OutboxEvent) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	s.r.outboxSeq++
+	e.Seq, e.CreatedAt, e.PublishedAt = s.r.outboxSeq, e.CreatedAt.UTC(), nil
+	e.Payload = slices.Clone(e.Payload)
+	s.r.outbox = append(s.r.outbox, e)
+	return nil
+}
+
+// RelayOutbox hands up to limit unpublished events, oldest first, to
+// publish and marks them published if it returns nil. Relays run one at a
+// time; the repository isn't locked while publish runs.
+func (r *Repository) RelayOutbox(_ context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	r.relayMu.Lock()
+	defer r.relayMu.Unlock()
+
+	r.mu.Lock()
+	var events []models.OutboxEvent
+	for _, e := range r.outbox {
+		if len(events) == limit {
+			break
+		}
+		if e.PublishedAt == nil {
+			events = append(events, e)
+		}
+	}
+	r.mu.Unlock()
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for i := range r.outbox {
+		if r.outbox[i].PublishedAt == nil && r.outbox[i].Seq <= events[len(events)-1].Seq {
+			r.outbox[i].PublishedAt = &now
+		}
+	}
+	return len(events), nil
+}
+
+func (r *Repository) PurgeOutbox(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.outbox)
+	r.outbox = slices.DeleteFunc(r.outbox, func(e models.OutboxEvent) bool {
+		return e.PublishedAt != nil && e.PublishedAt.Before(before)
+	})
+	return int64(n - len(r.outbox)), nil
+}
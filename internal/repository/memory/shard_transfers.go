@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) ShardTransfers() repository.ShardTransferStore { return shardTransferStore{r} }
+
+type shardTransferStore struct{ r *Repository }
+
+func (s shardTransferStore) RecordShardTransfer(_ context.Context, t models.ShardTransfer) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	t.Status, t.CreatedAt, t.SettledAt = models.ShardTransferStatusPending, t.CreatedAt.UTC(), nil
+	s.r.shardTransfers[t.ID] = t
+	return nil
+}
+
+func (s shardTransferStore) ClaimShardTransfer(_ context.Context, id uuid.UUID, outcome models.ShardTransferStatus, _ time.Time) (models.ShardTransferStatus, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	if claimed, ok := s.r.shardClaims[id]; ok {
+		return claimed, nil
+	}
+	s.r.shardClaims[id] = outcome
+	return "", nil
+}
+
+func (s shardTransferStore) SettleShardTransfer(_ context.Context, id uuid.UUID, status models.ShardTransferStatus, at time.Time) (bool, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	t, ok := s.r.shardTransfers[id]
+	if !ok || t.Status != models.ShardTransferStatusPending {
+		return false, nil
+	}
+	at = at.UTC()
+	t.Status, t.SettledAt = status, &at
+	s.r.shardTransfers[id] = t
+	return true, nil
+}
+
+func (s shardTransferStore) PendingShardTransfers(_ context.Context, before time.Time, limit int) ([]models.ShardTransfer, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	var pending []models.ShardTransfer
+	for _, t := range s.r.shardTransfers {
+		if t.Status == models.ShardTransferStatusPending && t.CreatedAt.Before(before) {
+			pending = append(pending, t)
+		}
+	}
+	slices.SortFunc(pending, func(a, b models.ShardTransfer) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) Snapshots() repository.SnapshotStore { return snapshotStore{r} }
+
+type snapshotStore struct{ r *Repository }
+
+func (s snapshotStore) LatestSnapshot(_ context.Context, walletID uuid.UUID) (*models.WalletSnapshot, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	snapshots := s.r.snapshots[walletID]
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	latest := snapshots[len(snapshots)-1]
+	return &latest, nil
+}
+
+func (s snapshotStore) VersionsAfter(_ context.Context, walletID uuid.UUID, version int) ([]models.WalletVersion, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	var versions []models.WalletVersion
+	for _, v := range s.r.versions[walletID] {
+		if v.Version > version {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+func (s snapshotStore) SaveSnapshot(_ context.Context, sn models.WalletSnapshot) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	snapshots := s.r.snapshots[sn.WalletID]
+	i, found := slices.BinarySearchFunc(snapshots, sn.Version, func(s models.WalletSnapshot, version int) int {
+		return s.Version - version
+	})
+	if !found {
+		sn.CreatedAt = sn.CreatedAt.UTC()
+		s.r.snapshots[sn.WalletID] = slices.Insert(snapshots, i, sn)
+	}
+	return nil
+}
+
+func (s snapshotStore) RestoreBalances(_ context.Context, sn models.WalletSnapshot) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	w, ok := s.r.wallets[sn.WalletID]
+	if !ok {
+		return repository.ErrWalletNotFound
+	}
+	if w.Version != sn.Version {
+		return repository.ErrConcurrentModification
+	}
+	restored := *w
+	restored.Balance, restored.PromoBalance = sn.Balance, sn.PromoBalance
+	s.r.wallets[sn.WalletID] = &restored
+	return nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) Wallets() repository.WalletStore { return walletStore{r} }
+
+func (r *Repository) Versions() repository.VersionStore { return versionStore{r} }
+
+func (r *Repository) Promos() repository.PromoStore { return promoStore{} }
+
+type walletStore struct{ r *Repository }
+
+// LockWallet reads a wallet. Nothing is locked: WithinTx runs one
+// transaction at a time, and SaveBalance checks the version.
+func (s walletStore) LockWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return s.r.GetWallet(ctx, id)
+}
+
+func (s walletStore) SaveBalance(_ context.Context, w *models.Wallet, change ledger.Change) (*models.Wallet, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	stored, ok := s.r.wallets[w.ID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if stored.Version != w.Version {
+		return nil, repository.ErrConcurrentModification
+	}
+	updated := *stored
+	updated.Balance, updated.PromoBalance = change.Balance, change.PromoBalance
+	updated.Version++
+	updated.UpdatedAt = time.Now().UTC()
+	s.r.wallets[w.ID] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+type versionStore struct{ r *Repository }
+
+func (s versionStore) AppendVersion(_ context.Context, v models.WalletVersion) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	s.r.versions[v.WalletID] = append(s.r.versions[v.WalletID], v)
+	return nil
+}
+
+func (s versionStore) WithdrawnSince(_ context.Context, walletID uuid.UUID, since time.Time) (int64, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	var total int64
+	for _, v := range s.r.versions[walletID] {
+		if v.OperationType == models.OperationTypeWithdraw && !v.CreatedAt.Before(since) {
+			total += v.Amount
+		}
+	}
+	return total, nil
+}
+
+type promoStore struct{}
+
+func (promoStore) ConsumePromoCredits(context.Context, uuid.UUID, int64) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) CreateMandate(context.Context, models.Mandate) (*models.Mandate, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) GetMandate(context.Context, uuid.UUID) (*models.Mandate, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListMandates(context.Context, uuid.UUID) ([]models.Mandate, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) RevokeMandate(context.Context, uuid.UUID, uuid.UUID, time.Time) (*models.Mandate, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) DebitMandate(context.Context, models.MandateDebit, time.Time) (*models.MandateDebit, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) GrantPromo(context.Context, models.PromoCredit) (*models.Wallet, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListPromoCredits(context.Context, uuid.UUID) ([]models.PromoCredit, error) {
+	return nil, ErrNotSupported
+}
+
+// DuePromoCredits reports no credits, so scheduled expiry is a no-op.
+func (r *Repository) DuePromoCredits(context.Context, time.Time, int) ([]models.PromoCredit, error) {
+	return nil, nil
+}
+
+func (r *Repository) ExpirePromoCredit(context.Context, uuid.UUID, time.Time) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (r *Repository) AccrueReward(context.Context, models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+	return nil, false, ErrNotSupported
+}
+
+func (r *Repository) ListRewardAccruals(context.Context, uuid.UUID) ([]models.RewardAccrual, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ReceiveToSuspense(context.Context, models.SuspenseCase) (*models.SuspenseCase, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListSuspenseCases(context.Context, models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ResolveSuspenseCase(context.Context, uuid.UUID, uuid.UUID, string, time.Time) (*models.SuspenseCase, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) OpenDispute(context.Context, models.Dispute) (*models.Dispute, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) GetDispute(context.Context, uuid.UUID) (*models.Dispute, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListDisputes(context.Context, models.DisputeStatus) ([]models.Dispute, error) {
+	return nil, ErrNotSupported
+}
+
+// DueDisputes reports no disputes, so scheduled expiry is a no-op.
+func (r *Repository) DueDisputes(context.Context, time.Time, int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) CloseDispute(context.Context, uuid.UUID, models.DisputeStatus, string, time.Time) (*models.Dispute, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) PlaceHold(context.Context, models.FundsHold) (*models.FundsHold, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListHolds(context.Context, uuid.UUID) ([]models.FundsHold, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) CaptureHold(context.Context, uuid.UUID, uuid.UUID, int64, time.Time) (*models.FundsHold, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ReleaseHold(context.Context, uuid.UUID, uuid.UUID, time.Time) (*models.FundsHold, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) AddTransactionNote(context.Context, models.TransactionNote) (*models.TransactionNote, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Repository) ListTransactionNotes(context.Context, int64) ([]models.TransactionNote, error) {
+	return nil, ErrNotSupported
+}
+
+// WalletsToPurge reports no wallets, so the scheduled purge is a no-op.
+func (r *Repository) WalletsToPurge(context.Context, time.Time, int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *Repository) DeleteTransactions(context.Context, uuid.UUID, int, int) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (r *Repository) MarkWalletPurged(context.Context, uuid.UUID) error {
+	return ErrNotSupported
+}
+
+func (r *Repository) ImportWallet(context.Context, uuid.UUID, models.ImportWalletRequest) (*models.Wallet, error) {
+	return nil, ErrNotSupported
+}
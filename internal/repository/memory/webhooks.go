@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *Repository) CreateWebhookSubscription(_ context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s = *copyWebhookSubscription(s)
+	s.CreatedAt, s.UpdatedAt = s.CreatedAt.UTC(), s.UpdatedAt.UTC()
+	r.webhooks[s.ID] = s
+	return copyWebhookSubscription(s), nil
+}
+
+func (r *Repository) GetWebhookSubscription(_ context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.webhooks[id]
+	if !ok {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	return copyWebhookSubscription(s), nil
+}
+
+func (r *Repository) ListWebhookSubscriptions(context.Context) ([]models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subs := make([]models.WebhookSubscription, 0, len(r.webhooks))
+	for _, s := range r.webhooks {
+		subs = append(subs, *copyWebhookSubscription(s))
+	}
+	slices.SortFunc(subs, func(a, b models.WebhookSubscription) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return subs, nil
+}
+
+func (r *Repository) UpdateWebhookSubscription(_ context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.webhooks[s.ID]
+	if !ok {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	cur.URL = s.URL
+	if s.Secret != "" {
+		cur.Secret = s.Secret
+	}
+	cur.EventTypes = slices.Clone(s.EventTypes)
+	cur.Filter = s.Filter
+	cur.Filter.OperationTypes = slices.Clone(s.Filter.OperationTypes)
+	cur.UpdatedAt = s.UpdatedAt.UTC()
+	r.webhooks[s.ID] = cur
+	return copyWebhookSubscription(cur), nil
+}
+
+func (r *Repository) PauseWebhookSubscription(_ context.Context, id uuid.UUID, paused bool, updatedAt time.Time) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.webhooks[id]
+	if !ok {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	cur.Paused = paused
+	cur.UpdatedAt = updatedAt.UTC()
+	r.webhooks[id] = cur
+	return copyWebhookSubscription(cur), nil
+}
+
+func (r *Repository) DeleteWebhookSubscription(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return repository.ErrWebhookSubscriptionNotFound
+	}
+	delete(r.webhooks, id)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d models.WebhookDelivery) bool { return d.SubscriptionID == id })
+	r.delivered = slices.DeleteFunc(r.delivered, func(a models.WebhookDeliveryAttempt) bool { return a.SubscriptionID == id })
+	return nil
+}
+
+func copyWebhookSubscription(s models.WebhookSubscription) *models.WebhookSubscription {
+	s.EventTypes = slices.Clone(s.EventTypes)
+	s.Filter.OperationTypes = slices.Clone(s.Filter.OperationTypes)
+	return &s
+}
+
+func (r *Repository) EnqueueWebhookEvent(_ context.Context, e models.WebhookEvent) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for _, s := range r.webhooks {
+		if !slices.Contains(s.EventTypes, e.Type) || !s.Filter.Matches(e) || slices.ContainsFunc(r.deliveries, func(d models.WebhookDelivery) bool {
+			return d.EventID == e.ID && d.SubscriptionID == s.ID
+		}) {
+			continue
+		}
+		r.deliveries = append(r.deliveries, models.WebhookDelivery{
+			EventID:        e.ID,
+			SubscriptionID: s.ID,
+			EventType:      e.Type,
+			Payload:        slices.Clone(e.Payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  e.CreatedAt.UTC(),
+			CreatedAt:      e.CreatedAt.UTC(),
+		})
+		n++
+	}
+	return n, nil
+}
+
+func (r *Repository) ClaimWebhookDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	claimed := []models.WebhookDelivery{}
+	for i := range r.deliveries {
+		d := &r.deliveries[i]
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status != models.WebhookDeliveryPending || d.NextAttemptAt.After(now) || r.webhooks[d.SubscriptionID].Paused {
+			continue
+		}
+		d.NextAttemptAt = now.Add(lease).UTC()
+		c := *d
+		c.URL, c.Secret = r.webhooks[d.SubscriptionID].URL, r.webhooks[d.SubscriptionID].Secret
+		claimed = append(claimed, c)
+	}
+	return claimed, nil
+}
+
+func (r *Repository) RecordWebhookDeliveryAttempt(_ context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.deliveries, func(d models.WebhookDelivery) bool {
+		return d.EventID == a.EventID && d.SubscriptionID == a.SubscriptionID
+	})
+	if i < 0 {
+		return nil
+	}
+	d := &r.deliveries[i]
+	d.Attempts, d.Status, d.NextAttemptAt, d.LastError = a.Attempt, status, nextAttemptAt.UTC(), a.Error
+	d.DeliveredAt = nil
+	if status == models.WebhookDeliveryDelivered {
+		at := a.AttemptedAt.UTC()
+		d.DeliveredAt = &at
+	}
+	r.delivered = append(r.delivered, a)
+	return nil
+}
+
+func (r *Repository) ListWebhookDeliveryAttempts(_ context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts := []models.WebhookDeliveryAttempt{}
+	for i := len(r.delivered) - 1; i >= 0 && len(attempts) < limit; i-- {
+		if r.delivered[i].SubscriptionID == subscriptionID {
+			attempts = append(attempts, r.delivered[i])
+		}
+	}
+	return attempts, nil
+}
+
+func (r *Repository) WebhookSubscriptionStats(_ context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscriptionStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := models.WebhookSubscriptionStats{SubscriptionID: subscriptionID}
+	for _, d := range r.deliveries {
+		if d.SubscriptionID != subscriptionID {
+			continue
+		}
+		switch d.Status {
+		case models.WebhookDeliveryPending:
+			stats.Pending++
+		case models.WebhookDeliveryDelivered:
+			stats.Delivered++
+			if stats.LastDeliveredAt == nil || d.DeliveredAt.After(*stats.LastDeliveredAt) {
+				stats.LastDeliveredAt = d.DeliveredAt
+			}
+		case models.WebhookDeliveryFailed:
+			stats.Failed++
+		}
+	}
+	var total float64
+	for _, a := range r.delivered {
+		if a.SubscriptionID != subscriptionID {
+			continue
+		}
+		stats.Attempts++
+		if a.Error != "" {
+			stats.FailedAttempts++
+		}
+		total += a.DurationMs
+		if stats.LastAttemptAt == nil || a.AttemptedAt.After(*stats.LastAttemptAt) {
+			at := a.AttemptedAt.UTC()
+			stats.LastAttemptAt = &at
+		}
+	}
+	if stats.Attempts > 0 {
+		stats.AvgDurationMs = total / float64(stats.Attempts)
+	}
+	return &stats, nil
+}
+
+func (r *Repository) PurgeWebhookDeliveries(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type key struct{ event, sub uuid.UUID }
+	purged := make(map[key]bool)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d models.WebhookDelivery) bool {
+		if d.Status == models.WebhookDeliveryPending || !d.CreatedAt.Before(before) {
+			return false
+		}
+		purged[key{d.EventID, d.SubscriptionID}] = true
+		return true
+	})
+	r.delivered = slices.DeleteFunc(r.delivered, func(a models.WebhookDeliveryAttempt) bool {
+		return purged[key{a.EventID, a.SubscriptionID}]
+	})
+	return int64(len(purged)), nil
+}
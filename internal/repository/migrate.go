@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// migrationLockKey is the Postgres advisory lock serializing schema setup
+// across replicas.
+const migrationLockKey int64 = 0x77616c6c6574 // "wallet"
+
+// CreateTabeIfNotExists brings the schema up to date. Replicas starting
+// together would race on the DDL, so the whole setup runs in one
+// transaction holding an advisory lock: the first replica applies it, the
+// others wait for the lock and then find nothing left to do. A transaction
+// level lock is used so that this also works through PgBouncer.
+func (r *WalletRepository) CreateTabeIfNotExists(ctx context.Context) error {
+	op := "repository.CreateTabeIfNotExists"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(op, queryError("begin", err))
+	}
+	defer tx.Rollback()
+
+	begin := time.Now()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		log.Error("failed to acquire migration lock", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return wrapError(op, queryError("migration_lock", err))
+	}
+	log.Info("migration lock acquired", slog.Duration("waited", time.Since(begin)))
+
+	if err := createTables(ctx, tx); err != nil {
+		log.Error("failed to create schema", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return wrapError(op, queryError("create_schema", err))
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapError(op, queryError("commit", err))
+	}
+	log.Info("schema up to date", slog.Duration("elapsed", time.Since(begin)))
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTabeIfNotExists_LocksFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(migrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS wallets`).WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	err = repo.CreateTabeIfNotExists(context.Background())
+
+	var repoErr *RepoError
+	require.ErrorAs(t, err, &repoErr)
+	assert.Equal(t, "create_schema", repoErr.Query)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTabeIfNotExists_LockTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnError(context.DeadlineExceeded)
+	mock.ExpectRollback()
+
+	err = repo.CreateTabeIfNotExists(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, KindTimeout, Classify(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"wallet-service/internal/models"
+)
+
+const transactionNoteColumns = `id, seq, author, body, attachments, created_at`
+
+func scanTransactionNote(row rowScanner, n *models.TransactionNote) error {
+	var attachments []byte
+	if err := row.Scan(&n.ID, &n.TransactionID, &n.Author, &n.Body, &attachments, utc(&n.CreatedAt)); err != nil {
+		return err
+	}
+	return json.Unmarshal(attachments, &n.Attachments)
+}
+
+// AddTransactionNote stores n for the transaction at ledger offset
+// n.TransactionID. The wallet version itself is never modified.
+func (r *WalletRepository) AddTransactionNote(ctx context.Context, n models.TransactionNote) (*models.TransactionNote, error) {
+	op := "repository.AddTransactionNote"
+	log := r.logger(ctx).With(slog.String("op", op), slog.Int64("transaction_id", n.TransactionID))
+
+	attachments, err := json.Marshal(n.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	query := `INSERT INTO transaction_notes (` + transactionNoteColumns + `)
+	SELECT $1, seq, $3, $4, $5, $6 FROM wallet_versions WHERE seq = $2
+	RETURNING ` + transactionNoteColumns
+
+	var result models.TransactionNote
+	err = r.withReconnect(ctx, op, func() error {
+		err := scanTransactionNote(r.conn(ctx).QueryRowContext(ctx, query,
+			n.ID, n.TransactionID, n.Author, n.Body, attachments, n.CreatedAt.UTC()), &result)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransactionNotFound
+		}
+		return queryError("insert_transaction_note", err)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrTransactionNotFound) {
+			log.Error("error adding transaction note", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTransactionNotes returns the notes of the transaction at ledger
+// offset transactionID, oldest first.
+func (r *WalletRepository) ListTransactionNotes(ctx context.Context, transactionID int64) ([]models.TransactionNote, error) {
+	op := "repository.ListTransactionNotes"
+	log := r.logger(ctx).With(slog.String("op", op), slog.Int64("transaction_id", transactionID))
+
+	query := `SELECT ` + transactionNoteColumns + ` FROM transaction_notes
+	WHERE seq = $1
+	ORDER BY created_at, id`
+
+	notes := []models.TransactionNote{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, transactionID)
+		if err != nil {
+			return queryError("select_transaction_notes", err)
+		}
+		defer rows.Close()
+
+		notes = notes[:0]
+		for rows.Next() {
+			var n models.TransactionNote
+			if err := scanTransactionNote(rows, &n); err != nil {
+				return err
+			}
+			notes = append(notes, n)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing transaction notes", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return notes, nil
+}
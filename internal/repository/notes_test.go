@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var transactionNoteCols = []string{"id", "seq", "author", "body", "attachments", "created_at"}
+
+func TestAddTransactionNote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`INSERT INTO transaction_notes .+ SELECT \$1, seq, \$3, \$4, \$5, \$6 FROM wallet_versions WHERE seq = \$2`).
+		WithArgs(id, int64(42), "alice", "customer called", []byte(`["s3://tickets/1.pdf"]`), now).
+		WillReturnRows(sqlmock.NewRows(transactionNoteCols).
+			AddRow(id, 42, "alice", "customer called", []byte(`["s3://tickets/1.pdf"]`), now))
+
+	n, err := repo.AddTransactionNote(context.Background(), models.TransactionNote{
+		ID: id, TransactionID: 42, Author: "alice", Body: "customer called",
+		Attachments: []string{"s3://tickets/1.pdf"}, CreatedAt: now,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), n.TransactionID)
+	assert.Equal(t, []string{"s3://tickets/1.pdf"}, n.Attachments)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddTransactionNote_UnknownTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	mock.ExpectQuery(`INSERT INTO transaction_notes`).WillReturnRows(sqlmock.NewRows(transactionNoteCols))
+
+	_, err = repo.AddTransactionNote(context.Background(), models.TransactionNote{ID: uuid.New(), TransactionID: 7, CreatedAt: time.Now()})
+
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
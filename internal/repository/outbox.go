@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// outboxLockKey is the Postgres advisory lock held while relaying the
+// outbox.
+const outboxLockKey int64 = 0x6f7574626f78 // "outbox"
+
+type outboxStore struct{ r *WalletRepository }
+
+func (s outboxStore) AppendOutboxEvent(ctx context.Context, e models.OutboxEvent) error {
+	query := `INSERT INTO outbox (id, wallet_id, event_type, payload, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	return s.r.withReconnect(ctx, "repository.AppendOutboxEvent", func() error {
+		_, err := s.r.conn(ctx).ExecContext(ctx, query, e.ID, e.WalletID, e.Type, e.Payload, e.CreatedAt.UTC())
+		return queryError("insert_outbox_event", err)
+	})
+}
+
+// RelayOutbox hands up to limit unpublished outbox events, oldest first, to
+// publish and marks them published if it returns nil. It runs in one
+// transaction holding an advisory lock, so only one instance relays at a
+// time and each wallet's events go out in the order they were written;
+// while another instance holds the lock it returns 0 without calling
+// publish. Events whose publication isn't recorded, because the commit
+// failed, are handed out again by the next call.
+func (r *WalletRepository) RelayOutbox(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	op := "repository.RelayOutbox"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	tx, err := r.beginTx(ctx, nil)
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, wrapError(op, queryError("begin", err))
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		return 0, wrapError(op, queryError("outbox_lock", err))
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	query := `SELECT seq, id, wallet_id, event_type, payload, created_at
+	FROM outbox WHERE published_at IS NULL
+	ORDER BY seq
+	LIMIT $1`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, wrapError(op, queryError("select_outbox_events", err))
+	}
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var e models.OutboxEvent
+		if err := rows.Scan(&e.Seq, &e.ID, &e.WalletID, &e.Type, &e.Payload, utc(&e.CreatedAt)); err != nil {
+			rows.Close()
+			return 0, wrapError(op, queryError("select_outbox_events", err))
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, wrapError(op, queryError("select_outbox_events", err))
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+
+	seqs := make([]int64, len(events))
+	for i, e := range events {
+		seqs[i] = e.Seq
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE outbox SET published_at = $2 WHERE seq = ANY($1)`, pq.Array(seqs), time.Now().UTC()); err != nil {
+		log.Error("error marking outbox events published", slog.Int("events", len(events)),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, wrapError(op, queryError("update_outbox_published", err))
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error("error marking outbox events published", slog.Int("events", len(events)),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, wrapError(op, queryError("commit", err))
+	}
+	return len(events), nil
+}
+
+// PurgeOutbox deletes outbox events published before before.
+func (r *WalletRepository) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+	op := "repository.PurgeOutbox"
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM outbox WHERE published_at < $1`, before.UTC())
+		if err != nil {
+			return queryError("purge_outbox", err)
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error purging outbox", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
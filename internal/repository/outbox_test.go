@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayOutbox_PublishesAndMarks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id, walletID, now := uuid.New(), uuid.New(), time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).WithArgs(outboxLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT seq, id, wallet_id, event_type, payload, created_at\s+FROM outbox WHERE published_at IS NULL`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "id", "wallet_id", "event_type", "payload", "created_at"}).
+			AddRow(int64(7), id, walletID, "balance.updated", []byte(`{}`), now))
+	mock.ExpectExec(`UPDATE outbox SET published_at = \$2 WHERE seq = ANY\(\$1\)`).
+		WithArgs("{7}", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var published []models.OutboxEvent
+	n, err := repo.RelayOutbox(context.Background(), 10, func(events []models.OutboxEvent) error {
+		published = events
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []models.OutboxEvent{{Seq: 7, ID: id, WalletID: walletID, Type: "balance.updated", Payload: []byte(`{}`), CreatedAt: now}}, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutbox_LockedElsewhere(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	n, err := repo.RelayOutbox(context.Background(), 10, func([]models.OutboxEvent) error {
+		t.Fatal("publish called without the lock")
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutbox_PublishFailureLeavesEventsPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	boom := errors.New("broker down")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`FROM outbox WHERE published_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "id", "wallet_id", "event_type", "payload", "created_at"}).
+			AddRow(int64(1), uuid.New(), uuid.New(), "balance.updated", []byte(`{}`), time.Now()))
+	mock.ExpectRollback()
+
+	_, err = repo.RelayOutbox(context.Background(), 10, func([]models.OutboxEvent) error { return boom })
+
+	assert.ErrorIs(t, err, boom)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
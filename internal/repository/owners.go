@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// OwnerBalances sums the balances of all the owner's wallets per currency in
+// a single aggregate query. An owner without wallets yields an empty slice.
+func (r *WalletRepository) OwnerBalances(ctx context.Context, ownerID uuid.UUID) ([]models.CurrencyBalance, error) {
+	op := "repository.OwnerBalances"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("owner_id", ownerID.String()))
+
+	query := `SELECT currency, COALESCE(SUM(balance), 0), COUNT(*)
+	FROM wallets
+	WHERE owner_id = $1
+	GROUP BY currency
+	ORDER BY currency`
+
+	balances, err := r.currencyBalances(ctx, op, query, ownerID)
+	if err != nil {
+		log.Error("error aggregating owner balances", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return balances, nil
+}
+
+// TenantBalances sums the balances of all the tenant's wallets per currency,
+// like OwnerBalances.
+func (r *WalletRepository) TenantBalances(ctx context.Context, tenant string) ([]models.CurrencyBalance, error) {
+	op := "repository.TenantBalances"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("tenant", tenant))
+
+	query := `SELECT currency, COALESCE(SUM(balance), 0), COUNT(*)
+	FROM wallets
+	WHERE tenant = $1
+	GROUP BY currency
+	ORDER BY currency`
+
+	balances, err := r.currencyBalances(ctx, op, query, tenant)
+	if err != nil {
+		log.Error("error aggregating tenant balances", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return balances, nil
+}
+
+// currencyBalances runs a per-currency aggregate query. A single statement
+// reads one snapshot, so the totals are consistent with each other.
+func (r *WalletRepository) currencyBalances(ctx context.Context, op, query string, args ...any) ([]models.CurrencyBalance, error) {
+	balances := []models.CurrencyBalance{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		balances = balances[:0]
+		for rows.Next() {
+			var b models.CurrencyBalance
+			if err := rows.Scan(&b.Currency, &b.Balance, &b.WalletCount); err != nil {
+				return err
+			}
+			balances = append(balances, b)
+		}
+		return rows.Err()
+	})
+	return balances, err
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	ownerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT currency, COALESCE\(SUM\(balance\), 0\), COUNT\(\*\)\s+FROM wallets\s+WHERE owner_id = \$1\s+GROUP BY currency`).
+		WithArgs(ownerID).
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "sum", "count"}).
+			AddRow("EUR", 300, 1).
+			AddRow("USD", 1200, 2))
+
+	balances, err := repo.OwnerBalances(context.Background(), ownerID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.CurrencyBalance{
+		{Currency: "EUR", Balance: 300, WalletCount: 1},
+		{Currency: "USD", Balance: 1200, WalletCount: 2},
+	}, balances)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenantBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectQuery(`SELECT currency, COALESCE\(SUM\(balance\), 0\), COUNT\(\*\)\s+FROM wallets\s+WHERE tenant = \$1\s+GROUP BY currency`).
+		WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "sum", "count"}).
+			AddRow("USD", 700, 3))
+
+	balances, err := repo.TenantBalances(context.Background(), "acme")
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.CurrencyBalance{{Currency: "USD", Balance: 700, WalletCount: 3}}, balances)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrPgBouncerIncompatible = errors.New("connection is not compatible with pgbouncer transaction pooling")
+
+// pgBouncerProbes is how many connections ProbePgBouncer probes on.
+const pgBouncerProbes = 3
+
+// ProbePgBouncer runs parameterized statements outside of transactions, on
+// several connections held open at once, to verify that queries don't rely
+// on server-side prepared statements. Under transaction pooling an
+// autocommit statement's prepare and execute may be served by different
+// backends, and the statement prepared on one doesn't exist on the other;
+// inside a transaction they would always share a backend and the probe
+// would pass regardless.
+func ProbePgBouncer(ctx context.Context, db *sql.DB) error {
+	conns := make([]*sql.Conn, 0, pgBouncerProbes)
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	for i := 0; i < pgBouncerProbes; i++ {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPgBouncerIncompatible, err)
+		}
+		conns = append(conns, c)
+
+		var got int
+		err = c.QueryRowContext(ctx, `SELECT $1::int`, i).Scan(&got)
+		if err == nil && got != i {
+			err = fmt.Errorf("unexpected probe result %d", got)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPgBouncerIncompatible, err)
+		}
+	}
+	return nil
+}
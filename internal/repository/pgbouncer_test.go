@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbePgBouncer_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Autocommit statements, outside of any transaction.
+	for i := 0; i < pgBouncerProbes; i++ {
+		mock.ExpectQuery(`SELECT \$1::int`).WithArgs(i).
+			WillReturnRows(sqlmock.NewRows([]string{"int4"}).AddRow(i))
+	}
+
+	require.NoError(t, ProbePgBouncer(context.Background(), db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProbePgBouncer_PreparedStatementMissing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT \$1::int`).WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"int4"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \$1::int`).WithArgs(1).
+		WillReturnError(errors.New(`prepared statement "1" does not exist`))
+
+	err = ProbePgBouncer(context.Background(), db)
+	require.ErrorIs(t, err, ErrPgBouncerIncompatible)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
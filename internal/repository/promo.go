@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const promoCreditColumns = `id, wallet_id, amount, remaining, expires_at, created_at, expired_at`
+
+func scanPromoCredit(row rowScanner, c *models.PromoCredit) error {
+	var expiredAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.WalletID, &c.Amount, &c.Remaining, utc(&c.ExpiresAt), utc(&c.CreatedAt), &expiredAt); err != nil {
+		return err
+	}
+	c.ExpiredAt = utcPtr(expiredAt)
+	return nil
+}
+
+// consumePromoCredits spends amount of the wallet's unexpired promo credits,
+// soonest expiring first.
+func consumePromoCredits(ctx context.Context, tx querier, walletID uuid.UUID, amount int64) error {
+	query := `WITH ordered AS (
+		SELECT id, remaining,
+			SUM(remaining) OVER (ORDER BY expires_at, id) - remaining AS spent_before
+		FROM promo_credits
+		WHERE wallet_id = $1 AND expired_at IS NULL AND remaining > 0
+	)
+	UPDATE promo_credits p
+	SET remaining = p.remaining - LEAST(o.remaining, $2 - o.spent_before)
+	FROM ordered o
+	WHERE p.id = o.id AND o.spent_before < $2`
+
+	_, err := tx.ExecContext(ctx, query, walletID, amount)
+	return err
+}
+
+// GrantPromo credits a promotional amount to the wallet. The credit counts
+// towards the balance until it is spent or expires.
+func (r *WalletRepository) GrantPromo(ctx context.Context, credit models.PromoCredit) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := r.withReconnect(ctx, "repository.GrantPromo", func() error {
+		var err error
+		wallet, err = r.grantPromo(ctx, credit)
+		return err
+	})
+	return wallet, err
+}
+
+func (r *WalletRepository) grantPromo(ctx context.Context, credit models.PromoCredit) (_ *models.Wallet, err error) {
+	op := "repository.GrantPromo"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", credit.WalletID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, credit.WalletID), &wallet)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("wallet not found")
+			return nil, ErrWalletNotFound
+		}
+		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	updated, err := r.applyOperation(ctx, tx, log, &wallet, credit.Amount, models.OperationTypePromoCredit)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO promo_credits (id, wallet_id, amount, remaining, expires_at, created_at)
+	VALUES ($1, $2, $3, $3, $4, $5)`,
+		credit.ID,
+		credit.WalletID,
+		credit.Amount,
+		credit.ExpiresAt,
+		credit.CreatedAt,
+	)
+	if err != nil {
+		log.Error("error recording promo credit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListPromoCredits returns the wallet's promo credits, soonest expiring
+// first.
+func (r *WalletRepository) ListPromoCredits(ctx context.Context, walletID uuid.UUID) ([]models.PromoCredit, error) {
+	op := "repository.ListPromoCredits"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT ` + promoCreditColumns + ` FROM promo_credits WHERE wallet_id = $1 ORDER BY expires_at, id`
+
+	credits := []models.PromoCredit{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, walletID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		credits = credits[:0]
+		for rows.Next() {
+			var c models.PromoCredit
+			if err := scanPromoCredit(rows, &c); err != nil {
+				return err
+			}
+			credits = append(credits, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing promo credits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return credits, nil
+}
+
+// DuePromoCredits returns up to limit credits that expired by now but
+// haven't been processed yet, soonest expired first.
+func (r *WalletRepository) DuePromoCredits(ctx context.Context, now time.Time, limit int) ([]models.PromoCredit, error) {
+	op := "repository.DuePromoCredits"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `SELECT ` + promoCreditColumns + ` FROM promo_credits WHERE expired_at IS NULL AND expires_at <= $1 ORDER BY expires_at LIMIT $2`
+
+	var credits []models.PromoCredit
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, now, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		credits = credits[:0]
+		for rows.Next() {
+			var c models.PromoCredit
+			if err := scanPromoCredit(rows, &c); err != nil {
+				return err
+			}
+			credits = append(credits, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing due promo credits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return credits, nil
+}
+
+// ExpirePromoCredit removes the unspent part of a due credit from its
+// wallet, recording it in the wallet's history as a PROMO_EXPIRY operation.
+// It returns the amount removed; a credit expired concurrently yields 0.
+func (r *WalletRepository) ExpirePromoCredit(ctx context.Context, id uuid.UUID, now time.Time) (int64, error) {
+	var expired int64
+	err := r.withReconnect(ctx, "repository.ExpirePromoCredit", func() error {
+		var err error
+		expired, err = r.expirePromoCredit(ctx, id, now)
+		return err
+	})
+	return expired, err
+}
+
+func (r *WalletRepository) expirePromoCredit(ctx context.Context, id uuid.UUID, now time.Time) (_ int64, err error) {
+	op := "repository.ExpirePromoCredit"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("credit_id", id.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	var walletID uuid.UUID
+	var remaining int64
+	err = tx.QueryRowContext(ctx, `SELECT wallet_id, remaining FROM promo_credits
+	WHERE id = $1 AND expired_at IS NULL AND expires_at <= $2 FOR UPDATE`, id, now).Scan(&walletID, &remaining)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		log.Error("error receiving promo credit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+
+	var expired int64
+	if remaining > 0 {
+		wallet := models.Wallet{}
+		err = scanWallet(tx.QueryRowContext(ctx,
+			`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, walletID), &wallet)
+		if err != nil {
+			log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return 0, err
+		}
+		before := wallet.PromoBalance
+		updated, err := r.applyOperation(ctx, tx, log, &wallet, remaining, models.OperationTypePromoExpiry)
+		if err != nil {
+			return 0, err
+		}
+		expired = before - updated.PromoBalance
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE promo_credits SET remaining = 0, expired_at = $2 WHERE id = $1`, id, now)
+	if err != nil {
+		log.Error("error marking promo credit expired", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return expired, nil
+}
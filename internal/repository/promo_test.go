@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promoCol = slices.Index(walletCols, "promo_balance")
+
+func TestUpdateWalletBalance_ConsumesPromoFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	now := time.Now()
+
+	row := walletRow(testID, 100, now, now, 1)
+	row[promoCol] = int64(30)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectQuery(`UPDATE wallets SET balance = \$1, promo_balance = \$5`).WithArgs(80, sqlmock.AnyArg(), testID, 1, 10).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(testID, 80, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE promo_credits p`).WithArgs(testID, 20).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err = repo.UpdateWalletBalance(context.Background(), testID, 20, "WITHDRAW")
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpirePromoCredit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	creditID, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	row := walletRow(walletID, 100, now, now, 3)
+	row[promoCol] = int64(30)
+	after := walletRow(walletID, 75, now, now, 4)
+	after[promoCol] = int64(5)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM promo_credits`).WithArgs(creditID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "remaining"}).AddRow(walletID, 25))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1 FOR UPDATE`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(row...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(75, sqlmock.AnyArg(), walletID, 3, 5).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(after...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WithArgs(walletID, 4, 75, "PROMO_EXPIRY", 25, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE promo_credits SET remaining = 0`).WithArgs(creditID, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	expired, err := repo.ExpirePromoCredit(context.Background(), creditID, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpirePromoCredit_AlreadyExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	creditID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM promo_credits`).WithArgs(creditID, now).WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectRollback()
+
+	expired, err := repo.ExpirePromoCredit(context.Background(), creditID, now)
+
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
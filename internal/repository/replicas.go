@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	lag     atomic.Int64 // nanoseconds
+	healthy atomic.Bool
+}
+
+// ReplicaStatus is the last observed state of a read replica.
+type ReplicaStatus struct {
+	Name        string        `json:"name"`
+	Lag         time.Duration `json:"lag"`
+	Healthy     bool          `json:"healthy"`
+	AcceptsRead bool          `json:"acceptsReads"`
+}
+
+// WithReplicas routes read-only queries to the given replicas as long as
+// their replication lag stays within maxLag; otherwise reads fall back to
+// the primary. Replicas start out unused until the first lag probe (see
+// MonitorReplicas) reports them healthy.
+func WithReplicas(replicas []*sql.DB, maxLag time.Duration) Option {
+	return func(r *WalletRepository) {
+		for i, db := range replicas {
+			r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("replica-%d", i), db: db})
+		}
+		r.maxReplicaLag = maxLag
+	}
+}
+
+// reader returns the transaction bound to ctx, whose own writes a replica
+// can't see, or else a replica within the staleness bound, round-robin, or
+// the primary when none qualifies.
+func (r *WalletRepository) reader(ctx context.Context) querier {
+	if amb, ok := r.ambient(ctx); ok {
+		return amb.tx
+	}
+	n := len(r.replicas)
+	if n == 0 {
+		return r.db
+	}
+	start := int(r.nextReplica.Add(1))
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.healthy.Load() && time.Duration(rep.lag.Load()) <= r.maxReplicaLag {
+			return rep.db
+		}
+	}
+	return r.db
+}
+
+// MonitorReplicas probes replica lag every interval until ctx is done.
+func (r *WalletRepository) MonitorReplicas(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.probeReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *WalletRepository) probeReplicas(ctx context.Context) {
+	// An idle primary produces no new WAL, so the last replay timestamp ages
+	// although the replica is fully caught up; report zero lag in that case.
+	query := `SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+	for _, rep := range r.replicas {
+		var lagSeconds float64
+		err := rep.db.QueryRowContext(ctx, query).Scan(&lagSeconds)
+		if err != nil {
+			if rep.healthy.Swap(false) {
+				r.logger(ctx).Warn("replica unavailable, routing reads to primary", slog.String("replica", rep.name),
+					slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			}
+			continue
+		}
+
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		prev := time.Duration(rep.lag.Swap(int64(lag)))
+		rep.healthy.Store(true)
+		if lag > r.maxReplicaLag && prev <= r.maxReplicaLag {
+			r.logger(ctx).Warn("replica lag above threshold, routing reads to primary", slog.String("replica", rep.name),
+				slog.Duration("lag", lag), slog.Duration("max_lag", r.maxReplicaLag))
+		}
+	}
+}
+
+// ReplicaStatuses reports the last probe result of every replica.
+func (r *WalletRepository) ReplicaStatuses() []ReplicaStatus {
+	out := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		lag := time.Duration(rep.lag.Load())
+		healthy := rep.healthy.Load()
+		out = append(out, ReplicaStatus{
+			Name:        rep.name,
+			Lag:         lag,
+			Healthy:     healthy,
+			AcceptsRead: healthy && lag <= r.maxReplicaLag,
+		})
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplicaMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func TestReader_RoutesByLag(t *testing.T) {
+	primary, _ := newReplicaMock(t)
+	fresh, freshMock := newReplicaMock(t)
+	stale, staleMock := newReplicaMock(t)
+
+	repo := NewWalletRepository(primary, log, WithReplicas([]*sql.DB{fresh, stale}, 5*time.Second))
+
+	assert.Same(t, primary, repo.reader(context.Background()), "replicas are unused before the first probe")
+
+	freshMock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.5))
+	staleMock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(30.0))
+	repo.probeReplicas(context.Background())
+
+	for i := 0; i < 4; i++ {
+		assert.Same(t, fresh, repo.reader(context.Background()))
+	}
+	statuses := repo.ReplicaStatuses()
+	assert.True(t, statuses[0].AcceptsRead)
+	assert.False(t, statuses[1].AcceptsRead)
+	assert.Equal(t, 30*time.Second, statuses[1].Lag)
+
+	freshMock.ExpectQuery(`SELECT CASE`).WillReturnError(errors.New("connection refused"))
+	staleMock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(30.0))
+	repo.probeReplicas(context.Background())
+
+	assert.Same(t, primary, repo.reader(context.Background()))
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrRetryable marks transaction failures that are safe to retry from
+// scratch: serialization failures, deadlocks and lost optimistic version
+// checks. Anything not matching it is permanent.
+var ErrRetryable = errors.New("retryable transaction conflict")
+
+// isRetryable reports whether err is a transaction conflict that Postgres
+// resolved by aborting one of the participants.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization_failure
+			"40P01": // deadlock_detected
+			return true
+		}
+	}
+	return false
+}
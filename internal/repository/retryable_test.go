@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, isRetryable(&pq.Error{Code: "40P01"}))
+	assert.False(t, isRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, isRetryable(errors.New("boom")))
+	assert.False(t, isRetryable(nil))
+	assert.ErrorIs(t, ErrConcurrentModification, ErrRetryable)
+}
+
+func TestUpdateWalletBalance_SerializationFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 100, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).
+		WithArgs(150, sqlmock.AnyArg(), testID, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 150, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit().WillReturnError(&pq.Error{Code: "40001"})
+
+	_, err = repo.UpdateWalletBalance(context.Background(), testID, 50, models.OperationTypeDeposit)
+
+	require.ErrorIs(t, err, ErrRetryable)
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	assert.Equal(t, pq.ErrorCode("40001"), pqErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetWallet_PermanentErrorNotRetryable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).WillReturnError(&pq.Error{Code: "42501"})
+
+	_, err = repo.GetWallet(context.Background(), testID)
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRetryable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// AccrueReward credits accrual.Amount to the rewards sub-wallet of
+// accrual.WalletID, creating the sub-wallet on first use. Accruals are
+// keyed by transaction ID: accruing the same transaction again is a no-op
+// that returns false.
+func (r *WalletRepository) AccrueReward(ctx context.Context, accrual models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+	var result *models.RewardAccrual
+	err := r.withReconnect(ctx, "repository.AccrueReward", func() error {
+		var err error
+		result, err = r.accrueReward(ctx, accrual)
+		return err
+	})
+	return result, result != nil, err
+}
+
+func (r *WalletRepository) accrueReward(ctx context.Context, accrual models.RewardAccrual) (_ *models.RewardAccrual, err error) {
+	op := "repository.AccrueReward"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", accrual.WalletID.String()),
+		slog.String("transaction_id", accrual.TransactionID))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM reward_accruals WHERE transaction_id = $1)`,
+		accrual.TransactionID).Scan(&exists)
+	if err != nil {
+		log.Error("error checking reward accrual", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if exists {
+		log.Debug("reward already accrued")
+		return nil, nil
+	}
+
+	rewardsID, err := r.rewardsWallet(ctx, tx, accrual)
+	if err != nil {
+		log.Error("error resolving rewards wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, rewardsID), &wallet)
+	if err != nil {
+		log.Error("error receiving rewards wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if _, err := r.applyOperation(ctx, tx, log, &wallet, accrual.Amount, models.OperationTypeReward); err != nil {
+		return nil, err
+	}
+
+	accrual.RewardsWalletID = rewardsID
+	_, err = tx.ExecContext(ctx, `INSERT INTO reward_accruals (transaction_id, wallet_id, rewards_wallet_id, rule, amount, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`,
+		accrual.TransactionID,
+		accrual.WalletID,
+		accrual.RewardsWalletID,
+		accrual.Rule,
+		accrual.Amount,
+		accrual.CreatedAt,
+	)
+	if err != nil {
+		log.Error("error recording reward accrual", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return &accrual, nil
+}
+
+// rewardsWallet returns the id of the wallet's rewards sub-wallet, creating
+// it with the owner, currency and tenant of the wallet if needed.
+func (r *WalletRepository) rewardsWallet(ctx context.Context, tx querier, accrual models.RewardAccrual) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT rewards_wallet_id FROM reward_wallets WHERE wallet_id = $1`,
+		accrual.WalletID).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	id = uuid.New()
+	res, err := tx.ExecContext(ctx, `INSERT INTO wallets (id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant)
+	SELECT $1, 0, $2, $2, 1, owner_id, currency, $3, $4, tenant FROM wallets WHERE id = $5`,
+		id, accrual.CreatedAt, models.WalletStatusActive, models.RewardsWalletLabel, accrual.WalletID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return uuid.Nil, err
+	} else if n == 0 {
+		return uuid.Nil, ErrWalletNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO reward_wallets (wallet_id, rewards_wallet_id) VALUES ($1, $2)`,
+		accrual.WalletID, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// ListRewardAccruals returns the wallet's accruals, newest first.
+func (r *WalletRepository) ListRewardAccruals(ctx context.Context, walletID uuid.UUID) ([]models.RewardAccrual, error) {
+	op := "repository.ListRewardAccruals"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT transaction_id, wallet_id, rewards_wallet_id, rule, amount, created_at
+	FROM reward_accruals WHERE wallet_id = $1 ORDER BY created_at DESC`
+
+	accruals := []models.RewardAccrual{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, walletID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		accruals = accruals[:0]
+		for rows.Next() {
+			var a models.RewardAccrual
+			if err := rows.Scan(&a.TransactionID, &a.WalletID, &a.RewardsWalletID, &a.Rule, &a.Amount, utc(&a.CreatedAt)); err != nil {
+				return err
+			}
+			accruals = append(accruals, a)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing reward accruals", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return accruals, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueReward_CreatesRewardsWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	walletID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs("tx-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT rewards_wallet_id FROM reward_wallets`).WithArgs(walletID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec(`INSERT INTO wallets`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO reward_wallets`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(uuid.New(), 0, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(15, sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(uuid.New(), 15, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO reward_accruals`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	accrual, accrued, err := repo.AccrueReward(context.Background(), models.RewardAccrual{
+		TransactionID: "tx-1",
+		WalletID:      walletID,
+		Rule:          "base",
+		Amount:        15,
+		CreatedAt:     now,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, accrued)
+	assert.NotEqual(t, uuid.Nil, accrual.RewardsWalletID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccrueReward_AlreadyAccrued(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs("tx-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, accrued, err := repo.AccrueReward(context.Background(), models.RewardAccrual{TransactionID: "tx-1", WalletID: uuid.New(), Amount: 15})
+
+	require.NoError(t, err)
+	assert.False(t, accrued)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+)
+
+// tenantWalletIDs selects the wallets of the tenant bound to $1.
+const tenantWalletIDs = `SELECT id FROM wallets WHERE tenant = $1`
+
+// wipeTenantQueries delete everything that references the tenant's wallets,
+// dependants first.
+var wipeTenantQueries = []string{
+	`DELETE FROM mandate_debits WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM mandates WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM disputes WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM holds WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM reward_accruals WHERE wallet_id IN (` + tenantWalletIDs + `) OR rewards_wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM reward_wallets WHERE wallet_id IN (` + tenantWalletIDs + `) OR rewards_wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM suspense_cases WHERE suspense_wallet_id IN (` + tenantWalletIDs + `) OR target_wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM suspense_wallets WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM promo_credits WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM wallet_versions WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+	`DELETE FROM wallet_snapshots WHERE wallet_id IN (` + tenantWalletIDs + `)`,
+}
+
+// WipeTenant deletes all wallets of a tenant together with their history
+// and returns the number of wallets deleted. The default tenant can't be
+// wiped.
+func (r *WalletRepository) WipeTenant(ctx context.Context, tenant string) (int64, error) {
+	op := "repository.WipeTenant"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("tenant", tenant))
+
+	if tenant == "" {
+		return 0, errors.New("refusing to wipe the default tenant")
+	}
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		tx, err := r.beginTx(ctx, &sql.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, q := range wipeTenantQueries {
+			if _, err := tx.ExecContext(ctx, q, tenant); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM wallets WHERE tenant = $1`, tenant)
+		if err != nil {
+			return err
+		}
+		if n, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.Error("error wiping tenant", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
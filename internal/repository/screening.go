@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"wallet-service/internal/models"
+)
+
+// RecordScreeningHit stores a denylist match for compliance review.
+func (r *WalletRepository) RecordScreeningHit(ctx context.Context, hit models.ScreeningHit) error {
+	op := "repository.RecordScreeningHit"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("subject_id", hit.SubjectID))
+
+	query := `INSERT INTO screening_hits (id, subject_kind, subject_id, list, reason, action, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, query,
+			hit.ID,
+			hit.SubjectKind,
+			hit.SubjectID,
+			hit.List,
+			hit.Reason,
+			hit.Action,
+			hit.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		log.Error("error recording screening hit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// likeEscaper escapes the LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchWallets returns up to limit wallets with ids greater than after, in
+// id order, whose id, owner id, label or tenant starts with prefix,
+// ignoring case. Each of those has
+// a text_pattern_ops expression index, so the prefix match is an index
+// range scan.
+func (r *WalletRepository) SearchWallets(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]models.Wallet, error) {
+	op := "repository.SearchWallets"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	pattern := likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+	query := `SELECT ` + walletColumns + ` FROM wallets
+	WHERE id > $2 AND (id::text LIKE $1 OR owner_id::text LIKE $1 OR lower(label) LIKE $1 OR lower(tenant) LIKE $1)
+	ORDER BY id
+	LIMIT $3`
+
+	var wallets []models.Wallet
+	err := r.withReconnect(ctx, op, func() error {
+		var err error
+		wallets, err = scanWallets(r.reader(ctx).QueryContext(ctx, query, pattern, after, limit))
+		return queryError("search_wallets", err)
+	})
+	if err != nil {
+		log.Error("error searching wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return wallets, nil
+}
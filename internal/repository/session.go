@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"wallet-service/internal/requestid"
+)
+
+// maxApplicationName is how much of application_name Postgres keeps.
+const maxApplicationName = 63
+
+// tagTx sets application_name for the rest of tx to the request id and
+// operation in ctx, so that a slow or blocked query in pg_stat_activity can
+// be traced back to the request it runs for. The setting is local to the
+// transaction, so a pooled connection, or a PgBouncer backend, goes back to
+// its own name once tx ends. Outside of requests tx is left alone.
+func tagTx(ctx context.Context, tx querier) error {
+	name, ok := applicationName(ctx)
+	if !ok {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `SELECT set_config('application_name', $1, true)`, name)
+	return queryError("set_application_name", err)
+}
+
+// applicationName returns "<request id> <operation>" for the request in
+// ctx. The request id, which logs are searched by, comes first; an
+// operation that doesn't fit keeps its end, which tells routes apart.
+func applicationName(ctx context.Context) (string, bool) {
+	id, ok := requestid.IDFrom(ctx)
+	if !ok {
+		return "", false
+	}
+	if len(id) >= maxApplicationName {
+		return id[:maxApplicationName], true
+	}
+	op, ok := requestid.OperationFrom(ctx)
+	if !ok || op == "" {
+		return id, true
+	}
+	if room := maxApplicationName - len(id) - 1; len(op) > room {
+		op = op[len(op)-room:]
+	}
+	return id + " " + op, true
+}
@@ -0,0 +1,135 @@
+package shard
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// inflight tracks the cross-shard transfers of this process that have
+// debited their source but not yet credited their target, so aggregates
+// can count the amount where a single database would: on the source,
+// until the credit commits.
+type inflight struct {
+	// steps is held shared by every saga step and exclusively while an
+	// aggregate reads the shards, so the shards are read between steps
+	// and pending describes exactly what they contain.
+	steps sync.RWMutex
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]pendingTransfer
+}
+
+type pendingTransfer struct {
+	from   models.Wallet
+	amount int64
+}
+
+func (f *inflight) debited(id uuid.UUID, from models.Wallet, amount int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending[id] = pendingTransfer{from: from, amount: amount}
+}
+
+// settled forgets a transfer once it was credited or refunded.
+func (f *inflight) settled(id uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, id)
+}
+
+// OwnerBalances sums the owner's wallets on every shard. See aggregate.
+func (r *Router) OwnerBalances(ctx context.Context, ownerID uuid.UUID) ([]models.CurrencyBalance, error) {
+	return r.aggregate(func(w models.Wallet) bool {
+		return w.OwnerID.Valid && w.OwnerID.UUID == ownerID
+	}, func(s service.WalletRepository) ([]models.CurrencyBalance, error) {
+		return s.OwnerBalances(ctx, ownerID)
+	})
+}
+
+// TenantBalances sums the tenant's wallets on every shard. See aggregate.
+func (r *Router) TenantBalances(ctx context.Context, tenant string) ([]models.CurrencyBalance, error) {
+	return r.aggregate(func(w models.Wallet) bool {
+		return w.Tenant == tenant
+	}, func(s service.WalletRepository) ([]models.CurrencyBalance, error) {
+		return s.TenantBalances(ctx, tenant)
+	})
+}
+
+// CurrencyTotals adds up the totals of every shard. A cross-shard transfer
+// moves money on each shard in a transaction of its own, recorded with the
+// balance it changes, so every shard's totals agree on their own and no
+// transfer needs reconciling.
+func (r *Router) CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error) {
+	totals, err := gather(r, func(s service.WalletRepository) ([]models.CurrencyTotals, error) {
+		return s.CurrencyTotals(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(totals, func(a, b models.CurrencyTotals) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	merged := []models.CurrencyTotals{}
+	for _, t := range totals {
+		if n := len(merged); n > 0 && merged[n-1].Currency == t.Currency {
+			m := &merged[n-1]
+			m.Balance += t.Balance
+			m.SystemBalance += t.SystemBalance
+			m.Credits += t.Credits
+			m.Debits += t.Debits
+			continue
+		}
+		merged = append(merged, t)
+	}
+	return merged, nil
+}
+
+// aggregate merges per-currency totals read from every shard. Each shard's
+// totals come from one snapshot, but the shards are read one after another,
+// so a cross-shard transfer could be seen debited on one shard and not yet
+// credited on the other, or credited but not debited. To rule that out the
+// shards are read while no transfer step runs, and the transfers debited
+// but not credited at that point are reconciled by adding their amount back
+// to the wallet they left when include selects it. Transfers run by other
+// processes aren't known here and may still be counted half-done.
+func (r *Router) aggregate(include func(models.Wallet) bool, fn func(service.WalletRepository) ([]models.CurrencyBalance, error)) ([]models.CurrencyBalance, error) {
+	r.transfers.steps.Lock()
+	defer r.transfers.steps.Unlock()
+
+	balances, err := gather(r, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	r.transfers.mu.Lock()
+	for _, p := range r.transfers.pending {
+		if include(p.from) {
+			balances = append(balances, models.CurrencyBalance{Currency: p.from.Currency, Balance: p.amount})
+		}
+	}
+	r.transfers.mu.Unlock()
+
+	return mergeBalances(balances), nil
+}
+
+func mergeBalances(balances []models.CurrencyBalance) []models.CurrencyBalance {
+	slices.SortStableFunc(balances, func(a, b models.CurrencyBalance) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	merged := []models.CurrencyBalance{}
+	for _, b := range balances {
+		if n := len(merged); n > 0 && merged[n-1].Currency == b.Currency {
+			merged[n-1].Balance += b.Balance
+			merged[n-1].WalletCount += b.WalletCount
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
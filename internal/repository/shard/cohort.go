@@ -0,0 +1,92 @@
+// Package shard spreads wallets over several Postgres databases. Every
+// wallet belongs to one of Cohorts cohorts, derived from its id, and a Map
+// assigns each cohort to a shard. Rebalancing moves whole cohorts between
+// shards by editing the map, so the cohort of a wallet never changes.
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Cohorts is the fixed number of cohorts. It bounds how finely wallets can
+// be rebalanced and can't change once wallets exist.
+const Cohorts = 1024
+
+// Cohort returns the cohort of a wallet.
+func Cohort(id uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write(id[:])
+	return int(h.Sum32() % Cohorts)
+}
+
+// Map assigns every cohort to a shard index.
+type Map [Cohorts]int
+
+// EvenMap splits the cohorts into contiguous, equally sized ranges, one per
+// shard.
+func EvenMap(shards int) Map {
+	var m Map
+	for c := range m {
+		m[c] = c * shards / Cohorts
+	}
+	return m
+}
+
+// ParseMap reads a map of the form "0-511:0,512-1023:1", cohort ranges
+// (inclusive) to shard indexes. Every cohort must be assigned exactly once
+// to a shard below shards.
+func ParseMap(spec string, shards int) (Map, error) {
+	var m Map
+	var assigned [Cohorts]bool
+	for _, part := range strings.Split(spec, ",") {
+		cohorts, shard, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return m, fmt.Errorf("shard map entry %q: expected <cohorts>:<shard>", part)
+		}
+		idx, err := strconv.Atoi(shard)
+		if err != nil || idx < 0 || idx >= shards {
+			return m, fmt.Errorf("shard map entry %q: shard must be between 0 and %d", part, shards-1)
+		}
+		lo, hi, err := parseRange(cohorts)
+		if err != nil {
+			return m, fmt.Errorf("shard map entry %q: %w", part, err)
+		}
+		for c := lo; c <= hi; c++ {
+			if assigned[c] {
+				return m, fmt.Errorf("shard map: cohort %d assigned twice", c)
+			}
+			assigned[c] = true
+			m[c] = idx
+		}
+	}
+	for c, ok := range assigned {
+		if !ok {
+			return m, fmt.Errorf("shard map: cohort %d not assigned", c)
+		}
+	}
+	return m, nil
+}
+
+func parseRange(s string) (int, int, error) {
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(loStr)
+	if err != nil {
+		return 0, 0, errors.New("invalid cohort")
+	}
+	hi := lo
+	if isRange {
+		if hi, err = strconv.Atoi(hiStr); err != nil {
+			return 0, 0, errors.New("invalid cohort")
+		}
+	}
+	if lo < 0 || hi >= Cohorts || lo > hi {
+		return 0, 0, fmt.Errorf("cohorts must be between 0 and %d", Cohorts-1)
+	}
+	return lo, hi, nil
+}
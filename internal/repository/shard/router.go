@@ -0,0 +1,611 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrCrossShard rejects requests that would need a transaction
+	// spanning shards.
+	ErrCrossShard = errors.New("request spans several shards")
+	// ErrNotSharded is returned by features whose ids aren't unique across
+	// shards, such as ledger sequence numbers.
+	ErrNotSharded = errors.New("not supported with sharding")
+)
+
+// Router is a service.WalletRepository spreading wallets over shards.
+// Calls about one wallet go to the wallet's shard; data belonging to a
+// wallet (versions, mandates, promo credits, disputes, ...) lives next to
+// it. Listings fan out to every shard and merge the results in the order a
+// single database would return them. Shard 0 is the home shard for data
+// that belongs to no wallet, such as screening hits.
+type Router struct {
+	shards    []Shard
+	cohorts   Map
+	log       *slog.Logger
+	transfers inflight
+}
+
+var _ service.WalletRepository = (*Router)(nil)
+
+// Shard is the repository of one shard. Besides wallets it records the
+// cross-shard transfers it takes part in, see Transfer.
+type Shard interface {
+	service.WalletRepository
+	ShardTransfers() repository.ShardTransferStore
+}
+
+func NewRouter(shards []Shard, cohorts Map, log *slog.Logger) *Router {
+	return &Router{shards: shards, cohorts: cohorts, log: log, transfers: inflight{pending: make(map[uuid.UUID]pendingTransfer)}}
+}
+
+// For returns the shard index of a wallet.
+func (r *Router) For(walletID uuid.UUID) int {
+	return r.cohorts[Cohort(walletID)]
+}
+
+func (r *Router) shard(walletID uuid.UUID) service.WalletRepository {
+	return r.shards[r.For(walletID)]
+}
+
+// find calls fn on every shard until one doesn't report notFound, for
+// entities whose id doesn't reveal their wallet.
+func find[T any](r *Router, notFound error, fn func(service.WalletRepository) (T, error)) (T, error) {
+	for _, s := range r.shards {
+		v, err := fn(s)
+		if !errors.Is(err, notFound) {
+			return v, err
+		}
+	}
+	var zero T
+	return zero, notFound
+}
+
+// gather calls fn on every shard and concatenates the results.
+func gather[T any](r *Router, fn func(service.WalletRepository) ([]T, error)) ([]T, error) {
+	var all []T
+	for i, s := range r.shards {
+		items, err := fn(s)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		all = append(all, items...)
+	}
+	return all, nil
+}
+
+// sum calls fn on every shard and adds up the results.
+func (r *Router) sum(fn func(service.WalletRepository) (int64, error)) (int64, error) {
+	var total int64
+	for i, s := range r.shards {
+		n, err := fn(s)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return total, nil
+}
+
+func compareWallets(a, b models.Wallet) int {
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+func (r *Router) CreateWallet(ctx context.Context, id uuid.UUID, req models.CreateWalletRequest) (*models.Wallet, error) {
+	return r.shard(id).CreateWallet(ctx, id, req)
+}
+
+// CreateWallets creates each shard's share of ids on it. Unlike on a single
+// database, a failing shard doesn't undo the wallets created on the others.
+func (r *Router) CreateWallets(ctx context.Context, ids []uuid.UUID, req models.CreateWalletRequest) error {
+	byShard := make(map[int][]uuid.UUID)
+	for _, id := range ids {
+		i := r.For(id)
+		byShard[i] = append(byShard[i], id)
+	}
+	for i, s := range r.shards {
+		if len(byShard[i]) == 0 {
+			continue
+		}
+		if err := s.CreateWallets(ctx, byShard[i], req); err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *Router) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).GetWallet(ctx, id)
+}
+
+func (r *Router) GetWalletBalance(ctx context.Context, id uuid.UUID) (*models.WalletBalance, error) {
+	return r.shard(id).GetWalletBalance(ctx, id)
+}
+
+func (r *Router) UpdateWalletBalance(ctx context.Context, id uuid.UUID, amount int64, operation models.OperationType) (*models.Wallet, error) {
+	return r.shard(id).UpdateWalletBalance(ctx, id, amount, operation)
+}
+
+// WithinWalletTx runs fn in a transaction on the shard of walletID only,
+// which is all a unit of work about one wallet needs.
+func (r *Router) WithinWalletTx(ctx context.Context, walletID uuid.UUID, fn func(ctx context.Context) error) error {
+	return r.shard(walletID).WithinTx(ctx, fn)
+}
+
+// WithinTx runs fn in a transaction on every shard, so calls about wallets
+// on any of them can take part. The shards commit one after another, and a
+// shard failing to commit can't undo the commits before it: calls that
+// must be applied all-or-nothing belong to wallets of one shard. Units of
+// work about one wallet use WithinWalletTx instead.
+func (r *Router) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	for i := len(r.shards) - 1; i >= 0; i-- {
+		next, s := fn, r.shards[i]
+		fn = func(ctx context.Context) error {
+			return s.WithinTx(ctx, next)
+		}
+	}
+	return fn(ctx)
+}
+
+// ExportWallets exports the shards one after another; each shard's part is
+// a consistent snapshot, but the shards are read at different times.
+func (r *Router) ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error {
+	for i, s := range r.shards {
+		if err := s.ExportWallets(ctx, batchSize, fn); err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *Router) BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	total := &models.BalanceSummary{From: from, To: to}
+	for i, s := range r.shards {
+		summary, err := s.BalanceSummary(ctx, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		total.WalletCount += summary.WalletCount
+		total.TotalBalance += summary.TotalBalance
+		total.CreatedInRange += summary.CreatedInRange
+	}
+	return total, nil
+}
+
+func (r *Router) GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	return r.shard(id).GetWalletVersions(ctx, id)
+}
+
+func (r *Router) WalletVersionsAfter(ctx context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error) {
+	return r.shard(id).WalletVersionsAfter(ctx, id, afterVersion, limit)
+}
+
+func (r *Router) ListWalletVersions(ctx context.Context, from, to time.Time) ([]models.WalletVersion, error) {
+	versions, err := gather(r, func(s service.WalletRepository) ([]models.WalletVersion, error) {
+		return s.ListWalletVersions(ctx, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(versions, func(a, b models.WalletVersion) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		if c := bytes.Compare(a.WalletID[:], b.WalletID[:]); c != 0 {
+			return c
+		}
+		return a.Version - b.Version
+	})
+	return versions, nil
+}
+
+// ListWallets asks every shard for a full page and keeps the first limit
+// wallets in the order of sort, so keyset pagination works as it does on a
+// single database.
+func (r *Router) ListWallets(ctx context.Context, f models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, error) {
+	wallets, err := gather(r, func(s service.WalletRepository) ([]models.Wallet, error) {
+		return s.ListWallets(ctx, f, sort, after, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(wallets, sort.Compare)
+	return wallets[:min(limit, len(wallets))], nil
+}
+
+func (r *Router) SearchWallets(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]models.Wallet, error) {
+	wallets, err := gather(r, func(s service.WalletRepository) ([]models.Wallet, error) {
+		return s.SearchWallets(ctx, prefix, after, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(wallets, compareWallets)
+	return wallets[:min(limit, len(wallets))], nil
+}
+
+func (r *Router) CountWalletsToSetStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.CountWalletsToSetStatus(ctx, f, status)
+	})
+}
+
+func (r *Router) SetWalletsStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus, batchSize int) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.SetWalletsStatus(ctx, f, status, batchSize)
+	})
+}
+
+// FlagDormantWallets flags up to batchSize wallets on each shard, so it may
+// return more than batchSize in total; a shorter result still means every
+// shard is done.
+func (r *Router) FlagDormantWallets(ctx context.Context, idleSince time.Time, batchSize int) ([]models.Wallet, error) {
+	return gather(r, func(s service.WalletRepository) ([]models.Wallet, error) {
+		return s.FlagDormantWallets(ctx, idleSince, batchSize)
+	})
+}
+
+func (r *Router) ReactivateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).ReactivateWallet(ctx, id)
+}
+
+func (r *Router) FreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).FreezeWallet(ctx, id)
+}
+
+func (r *Router) UnfreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).UnfreezeWallet(ctx, id)
+}
+
+func (r *Router) SetCreditLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	return r.shard(id).SetCreditLimit(ctx, id, limit)
+}
+
+func (r *Router) CloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).CloseWallet(ctx, id)
+}
+
+func (r *Router) RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return r.shard(id).RestoreWallet(ctx, id)
+}
+
+func (r *Router) ImportWallet(ctx context.Context, id uuid.UUID, req models.ImportWalletRequest) (*models.Wallet, error) {
+	return r.shard(id).ImportWallet(ctx, id, req)
+}
+
+// WalletsToPurge returns up to limit wallets from each shard.
+func (r *Router) WalletsToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	return gather(r, func(s service.WalletRepository) ([]uuid.UUID, error) {
+		return s.WalletsToPurge(ctx, closedBefore, limit)
+	})
+}
+
+func (r *Router) ArchiveTransactions(ctx context.Context, walletID uuid.UUID, afterVersion, limit int) ([]models.ArchivedTransaction, error) {
+	return r.shard(walletID).ArchiveTransactions(ctx, walletID, afterVersion, limit)
+}
+
+func (r *Router) DeleteTransactions(ctx context.Context, walletID uuid.UUID, throughVersion, limit int) (int64, error) {
+	return r.shard(walletID).DeleteTransactions(ctx, walletID, throughVersion, limit)
+}
+
+func (r *Router) MarkWalletPurged(ctx context.Context, id uuid.UUID) error {
+	return r.shard(id).MarkWalletPurged(ctx, id)
+}
+
+// AppendAuditEvent records e on the shard of its wallet; events about no
+// wallet in particular go to shard 0.
+func (r *Router) AppendAuditEvent(ctx context.Context, e models.AuditEvent) error {
+	if !e.WalletID.Valid {
+		return r.shards[0].AppendAuditEvent(ctx, e)
+	}
+	return r.shard(e.WalletID.UUID).AppendAuditEvent(ctx, e)
+}
+
+func (r *Router) ListAuditEvents(ctx context.Context, walletID uuid.UUID, after models.AuditEvent, limit int) ([]models.AuditEvent, error) {
+	return r.shard(walletID).ListAuditEvents(ctx, walletID, after, limit)
+}
+
+func (r *Router) PurgeAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.PurgeAuditEvents(ctx, before)
+	})
+}
+
+func (r *Router) PlaceLegalHold(ctx context.Context, h models.LegalHold) (*models.LegalHold, error) {
+	return r.shard(h.WalletID).PlaceLegalHold(ctx, h)
+}
+
+func (r *Router) GetLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	return r.shard(walletID).GetLegalHold(ctx, walletID)
+}
+
+func (r *Router) ReleaseLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error) {
+	return r.shard(walletID).ReleaseLegalHold(ctx, walletID)
+}
+
+func (r *Router) RecordScreeningHit(ctx context.Context, hit models.ScreeningHit) error {
+	return r.shards[0].RecordScreeningHit(ctx, hit)
+}
+
+// Operation attempts belong to requests, not wallets, and are kept on the
+// first shard like screening hits.
+func (r *Router) RecordOperationAttempts(ctx context.Context, attempts []models.OperationAttempt) error {
+	return r.shards[0].RecordOperationAttempts(ctx, attempts)
+}
+
+func (r *Router) ListOperationAttempts(ctx context.Context, operationID string) ([]models.OperationAttempt, error) {
+	return r.shards[0].ListOperationAttempts(ctx, operationID)
+}
+
+func (r *Router) PurgeOperationAttempts(ctx context.Context, before time.Time) (int64, error) {
+	return r.shards[0].PurgeOperationAttempts(ctx, before)
+}
+
+// Webhook subscriptions and their deliveries are service-wide and kept on
+// the first shard.
+func (r *Router) CreateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	return r.shards[0].CreateWebhookSubscription(ctx, s)
+}
+
+func (r *Router) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return r.shards[0].GetWebhookSubscription(ctx, id)
+}
+
+func (r *Router) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return r.shards[0].ListWebhookSubscriptions(ctx)
+}
+
+func (r *Router) UpdateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	return r.shards[0].UpdateWebhookSubscription(ctx, s)
+}
+
+func (r *Router) PauseWebhookSubscription(ctx context.Context, id uuid.UUID, paused bool, updatedAt time.Time) (*models.WebhookSubscription, error) {
+	return r.shards[0].PauseWebhookSubscription(ctx, id, paused, updatedAt)
+}
+
+func (r *Router) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	return r.shards[0].DeleteWebhookSubscription(ctx, id)
+}
+
+func (r *Router) EnqueueWebhookEvent(ctx context.Context, e models.WebhookEvent) (int64, error) {
+	return r.shards[0].EnqueueWebhookEvent(ctx, e)
+}
+
+func (r *Router) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	return r.shards[0].ClaimWebhookDeliveries(ctx, now, lease, limit)
+}
+
+func (r *Router) RecordWebhookDeliveryAttempt(ctx context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	return r.shards[0].RecordWebhookDeliveryAttempt(ctx, a, status, nextAttemptAt)
+}
+
+func (r *Router) ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error) {
+	return r.shards[0].ListWebhookDeliveryAttempts(ctx, subscriptionID, limit)
+}
+
+func (r *Router) WebhookSubscriptionStats(ctx context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscriptionStats, error) {
+	return r.shards[0].WebhookSubscriptionStats(ctx, subscriptionID)
+}
+
+func (r *Router) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	return r.shards[0].PurgeWebhookDeliveries(ctx, before)
+}
+
+// Each shard keeps the outbox of its wallets, so relaying them one after
+// another still publishes every wallet's events in order.
+func (r *Router) RelayOutbox(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	n, err := r.sum(func(s service.WalletRepository) (int64, error) {
+		n, err := s.RelayOutbox(ctx, limit, publish)
+		return int64(n), err
+	})
+	return int(n), err
+}
+
+func (r *Router) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.PurgeOutbox(ctx, before)
+	})
+}
+
+// ApplyAtomic runs on the shard of the involved wallets; requests touching
+// wallets on different shards are rejected with ErrCrossShard, as they
+// can't be applied all-or-nothing.
+func (r *Router) ApplyAtomic(ctx context.Context, steps []models.WalletOperation) ([]models.AtomicStepResult, error) {
+	if len(steps) == 0 {
+		return r.shards[0].ApplyAtomic(ctx, steps)
+	}
+	idx := r.For(steps[0].WalletID)
+	for _, step := range steps[1:] {
+		if r.For(step.WalletID) != idx {
+			return nil, ErrCrossShard
+		}
+	}
+	return r.shards[idx].ApplyAtomic(ctx, steps)
+}
+
+func (r *Router) ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, error) {
+	return r.shard(walletID).ListTransactions(ctx, walletID, beforeVersion, limit)
+}
+
+func (r *Router) CreateMandate(ctx context.Context, m models.Mandate) (*models.Mandate, error) {
+	return r.shard(m.WalletID).CreateMandate(ctx, m)
+}
+
+func (r *Router) GetMandate(ctx context.Context, id uuid.UUID) (*models.Mandate, error) {
+	return find(r, repository.ErrMandateNotFound, func(s service.WalletRepository) (*models.Mandate, error) {
+		return s.GetMandate(ctx, id)
+	})
+}
+
+func (r *Router) ListMandates(ctx context.Context, walletID uuid.UUID) ([]models.Mandate, error) {
+	return r.shard(walletID).ListMandates(ctx, walletID)
+}
+
+func (r *Router) RevokeMandate(ctx context.Context, walletID, mandateID uuid.UUID, at time.Time) (*models.Mandate, error) {
+	return r.shard(walletID).RevokeMandate(ctx, walletID, mandateID, at)
+}
+
+func (r *Router) DebitMandate(ctx context.Context, debit models.MandateDebit, periodStart time.Time) (*models.MandateDebit, error) {
+	return find(r, repository.ErrMandateNotFound, func(s service.WalletRepository) (*models.MandateDebit, error) {
+		return s.DebitMandate(ctx, debit, periodStart)
+	})
+}
+
+func (r *Router) GrantPromo(ctx context.Context, credit models.PromoCredit) (*models.Wallet, error) {
+	return r.shard(credit.WalletID).GrantPromo(ctx, credit)
+}
+
+func (r *Router) ListPromoCredits(ctx context.Context, walletID uuid.UUID) ([]models.PromoCredit, error) {
+	return r.shard(walletID).ListPromoCredits(ctx, walletID)
+}
+
+func (r *Router) DuePromoCredits(ctx context.Context, now time.Time, limit int) ([]models.PromoCredit, error) {
+	credits, err := gather(r, func(s service.WalletRepository) ([]models.PromoCredit, error) {
+		return s.DuePromoCredits(ctx, now, limit)
+	})
+	slices.SortStableFunc(credits, func(a, b models.PromoCredit) int {
+		return a.ExpiresAt.Compare(b.ExpiresAt)
+	})
+	return credits[:min(limit, len(credits))], err
+}
+
+// ExpirePromoCredit expires the credit on whichever shard holds it; the
+// others find nothing to expire.
+func (r *Router) ExpirePromoCredit(ctx context.Context, id uuid.UUID, now time.Time) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.ExpirePromoCredit(ctx, id, now)
+	})
+}
+
+func (r *Router) AccrueReward(ctx context.Context, accrual models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+	return r.shard(accrual.WalletID).AccrueReward(ctx, accrual)
+}
+
+func (r *Router) ListRewardAccruals(ctx context.Context, walletID uuid.UUID) ([]models.RewardAccrual, error) {
+	return r.shard(walletID).ListRewardAccruals(ctx, walletID)
+}
+
+func (r *Router) ReceiveToSuspense(ctx context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+	return r.shard(c.SuspenseWalletID).ReceiveToSuspense(ctx, c)
+}
+
+func (r *Router) ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	cases, err := gather(r, func(s service.WalletRepository) ([]models.SuspenseCase, error) {
+		return s.ListSuspenseCases(ctx, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(cases, func(a, b models.SuspenseCase) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return cases, nil
+}
+
+// ResolveSuspenseCase releases a case into a wallet on the same shard as
+// the suspense wallet holding it; other targets are rejected with
+// ErrCrossShard.
+func (r *Router) ResolveSuspenseCase(ctx context.Context, id, targetID uuid.UUID, note string, at time.Time) (*models.SuspenseCase, error) {
+	for i, s := range r.shards {
+		c, err := s.ResolveSuspenseCase(ctx, id, targetID, note, at)
+		switch {
+		case errors.Is(err, repository.ErrSuspenseCaseNotFound):
+			continue
+		case errors.Is(err, repository.ErrWalletNotFound) && r.For(targetID) != i:
+			return nil, ErrCrossShard
+		}
+		return c, err
+	}
+	return nil, repository.ErrSuspenseCaseNotFound
+}
+
+func (r *Router) OpenDispute(ctx context.Context, d models.Dispute) (*models.Dispute, error) {
+	return r.shard(d.WalletID).OpenDispute(ctx, d)
+}
+
+func (r *Router) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	return find(r, repository.ErrDisputeNotFound, func(s service.WalletRepository) (*models.Dispute, error) {
+		return s.GetDispute(ctx, id)
+	})
+}
+
+func (r *Router) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]models.Dispute, error) {
+	disputes, err := gather(r, func(s service.WalletRepository) ([]models.Dispute, error) {
+		return s.ListDisputes(ctx, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(disputes, func(a, b models.Dispute) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return disputes, nil
+}
+
+func (r *Router) DueDisputes(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	ids, err := gather(r, func(s service.WalletRepository) ([]uuid.UUID, error) {
+		return s.DueDisputes(ctx, now, limit)
+	})
+	return ids[:min(limit, len(ids))], err
+}
+
+func (r *Router) CloseDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, note string, at time.Time) (*models.Dispute, error) {
+	return find(r, repository.ErrDisputeNotFound, func(s service.WalletRepository) (*models.Dispute, error) {
+		return s.CloseDispute(ctx, id, status, note, at)
+	})
+}
+
+func (r *Router) PlaceHold(ctx context.Context, h models.FundsHold) (*models.FundsHold, error) {
+	return r.shard(h.WalletID).PlaceHold(ctx, h)
+}
+
+func (r *Router) ListHolds(ctx context.Context, walletID uuid.UUID) ([]models.FundsHold, error) {
+	return r.shard(walletID).ListHolds(ctx, walletID)
+}
+
+func (r *Router) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, at time.Time) (*models.FundsHold, error) {
+	return r.shard(walletID).CaptureHold(ctx, walletID, holdID, amount, at)
+}
+
+func (r *Router) ReleaseHold(ctx context.Context, walletID, holdID uuid.UUID, at time.Time) (*models.FundsHold, error) {
+	return r.shard(walletID).ReleaseHold(ctx, walletID, holdID, at)
+}
+
+// AddTransactionNote isn't supported: transactions are identified by their
+// shard's ledger sequence number, which isn't unique across shards.
+func (r *Router) AddTransactionNote(context.Context, models.TransactionNote) (*models.TransactionNote, error) {
+	return nil, ErrNotSharded
+}
+
+func (r *Router) ListTransactionNotes(context.Context, int64) ([]models.TransactionNote, error) {
+	return nil, ErrNotSharded
+}
+
+func (r *Router) WipeTenant(ctx context.Context, tenant string) (int64, error) {
+	return r.sum(func(s service.WalletRepository) (int64, error) {
+		return s.WipeTenant(ctx, tenant)
+	})
+}
+
+// ListEvents isn't supported: event offsets are per-shard sequence numbers
+// and don't merge into one replayable stream.
+func (r *Router) ListEvents(context.Context, int64, time.Time, int) ([]models.LedgerEvent, error) {
+	return nil, ErrNotSharded
+}
@@ -0,0 +1,288 @@
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrCompensationFailed means a cross-shard transfer debited the source
+// wallet, couldn't credit the target and couldn't refund the source
+// either. The transfer stays pending for RecoverTransfers to finish.
+var ErrCompensationFailed = errors.New("transfer compensation failed")
+
+var (
+	// errTransferCredited means the target shard already credited a
+	// transfer.
+	errTransferCredited = errors.New("transfer already credited")
+	// errTransferCompensated means the target shard already gave a
+	// transfer up to be refunded, so it can't be credited any more.
+	errTransferCompensated = errors.New("transfer already compensated")
+)
+
+const (
+	// compensationAttempts bounds the retries of a refund.
+	compensationAttempts = 3
+	// recoveryDelay is how long a transfer stays pending before
+	// RecoverTransfers takes it over from the saga running it.
+	recoveryDelay = time.Minute
+	// recoveryBatch bounds the transfers RecoverTransfers finishes per
+	// shard and call.
+	recoveryBatch = 100
+)
+
+// Transfer moves funds in one transaction when both wallets live on the
+// same shard. Across shards it runs a saga instead: debit the source on its
+// shard, recording the transfer as pending in the same transaction, then
+// credit the target on its own; if the credit fails the debit is
+// compensated with a reversal credit to the source. Each step is atomic,
+// but between them the amount is in flight and visible in neither balance
+// (aggregates still count it, see aggregate). A transfer a crash leaves
+// pending is finished by RecoverTransfers; the target shard records
+// whether each transfer was credited or given up on, so it is never both
+// credited and refunded.
+//
+// Within a unit of work of WithinTx, the steps commit with it instead,
+// with the caveats of WithinTx.
+func (r *Router) Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (*models.Wallet, *models.Wallet, error) {
+	fromShard, toShard := r.For(fromID), r.For(toID)
+	if fromShard == toShard {
+		return r.shards[fromShard].Transfer(ctx, fromID, toID, amount)
+	}
+
+	op := "shard.Transfer"
+	log := r.log.With(slog.String("op", op), slog.String("from_wallet_id", fromID.String()), slog.String("to_wallet_id", toID.String()),
+		slog.Int64("amount", amount), slog.Int("from_shard", fromShard), slog.Int("to_shard", toShard))
+
+	// Reject what the single-shard transfer would before moving anything.
+	from, err := r.shards[fromShard].GetWallet(ctx, fromID)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := r.shards[toShard].GetWallet(ctx, toID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from.Currency != to.Currency {
+		return nil, nil, repository.ErrCurrencyMismatch
+	}
+	if to.Status == models.WalletStatusFrozen {
+		return nil, nil, repository.ErrWalletFrozen
+	}
+	if to.Status == models.WalletStatusClosed {
+		return nil, nil, repository.ErrWalletClosed
+	}
+
+	// Each step holds transfers.steps shared and records its outcome before
+	// releasing it, so aggregates see the shards and the in-flight ledger
+	// agree.
+	t := models.ShardTransfer{ID: uuid.New(), FromWalletID: fromID, ToWalletID: toID, Amount: amount, CreatedAt: time.Now().UTC()}
+	log = log.With(slog.String("transfer_id", t.ID.String()))
+	r.transfers.steps.RLock()
+	debited, err := r.debit(ctx, t)
+	if err == nil {
+		r.transfers.debited(t.ID, *from, amount)
+	}
+	r.transfers.steps.RUnlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Info("cross-shard transfer debited", slog.Int("from_version", debited.Version))
+
+	r.transfers.steps.RLock()
+	credited, err := r.credit(ctx, t)
+	if err == nil {
+		r.transfers.settled(t.ID)
+	}
+	r.transfers.steps.RUnlock()
+	if err == nil {
+		log.Info("cross-shard transfer credited", slog.Int("to_version", credited.Version))
+		return debited, credited, nil
+	}
+	log.Warn("cross-shard transfer credit failed, compensating", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+
+	cerr := r.compensate(context.WithoutCancel(ctx), t)
+	if errors.Is(cerr, errTransferCredited) {
+		// The credit committed although it reported a failure.
+		log.Warn("cross-shard transfer credited after all")
+		if credited, gerr := r.shards[toShard].GetWallet(ctx, toID); gerr == nil {
+			return debited, credited, nil
+		}
+		return nil, nil, err
+	}
+	if cerr != nil {
+		// The transfer stays in the in-flight ledger, so aggregates keep
+		// counting the amount with the source wallet it is owed to.
+		log.Error("cross-shard transfer compensation failed, amount in flight", slog.Int("from_version", debited.Version),
+			slog.Attr{Key: "error", Value: slog.StringValue(cerr.Error())})
+		// Not wrapped with %w: the debit happened, so the caller must not
+		// retry the transfer as if nothing had.
+		return nil, nil, fmt.Errorf("%w: credit: %v; refund: %v", ErrCompensationFailed, err, cerr)
+	}
+	log.Info("cross-shard transfer compensated")
+	return nil, nil, err
+}
+
+// RecoverTransfers finishes the cross-shard transfers a saga left pending
+// for longer than recoveryDelay, such as when the process running it
+// crashed between the debit and the credit: each is credited to its target
+// or, if the target can't take it any more, refunded to its source. A
+// transfer whose step fails for a reason that may pass stays pending for
+// the next call. Run at startup and periodically.
+func (r *Router) RecoverTransfers(ctx context.Context) error {
+	op := "shard.RecoverTransfers"
+	log := r.log.With(slog.String("op", op))
+
+	before := time.Now().Add(-recoveryDelay)
+	var errs []error
+	for i, s := range r.shards {
+		pending, err := s.ShardTransfers().PendingShardTransfers(ctx, before, recoveryBatch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+			continue
+		}
+		for _, t := range pending {
+			if err := r.recoverTransfer(ctx, t, log); err != nil {
+				errs = append(errs, fmt.Errorf("shard %d: transfer %s: %w", i, t.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) recoverTransfer(ctx context.Context, t models.ShardTransfer, log *slog.Logger) error {
+	log = log.With(slog.String("transfer_id", t.ID.String()), slog.String("from_wallet_id", t.FromWalletID.String()),
+		slog.String("to_wallet_id", t.ToWalletID.String()), slog.Int64("amount", t.Amount))
+
+	r.transfers.steps.RLock()
+	_, err := r.credit(ctx, t)
+	if err == nil {
+		r.transfers.settled(t.ID)
+	}
+	r.transfers.steps.RUnlock()
+	if err == nil {
+		log.Info("pending cross-shard transfer credited")
+		return nil
+	}
+	if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletFrozen) &&
+		!errors.Is(err, repository.ErrWalletClosed) && !errors.Is(err, errTransferCompensated) {
+		log.Warn("pending cross-shard transfer credit failed, left pending", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	log.Warn("pending cross-shard transfer can't be credited, compensating", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+
+	switch err := r.compensate(ctx, t); {
+	case errors.Is(err, errTransferCredited):
+		log.Info("pending cross-shard transfer credited")
+	case err != nil:
+		log.Error("pending cross-shard transfer compensation failed, left pending", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	default:
+		log.Info("pending cross-shard transfer compensated")
+	}
+	return nil
+}
+
+// debit withdraws the transfer from its source and records it as pending
+// there, in one transaction.
+func (r *Router) debit(ctx context.Context, t models.ShardTransfer) (*models.Wallet, error) {
+	source := r.shards[r.For(t.FromWalletID)]
+
+	var debited *models.Wallet
+	err := source.WithinWalletTx(ctx, t.FromWalletID, func(ctx context.Context) error {
+		var err error
+		if debited, err = source.UpdateWalletBalance(ctx, t.FromWalletID, t.Amount, models.OperationTypeWithdraw); err != nil {
+			return err
+		}
+		return source.ShardTransfers().RecordShardTransfer(ctx, t)
+	})
+	return debited, err
+}
+
+// credit deposits the transfer to its target and claims it as completed
+// there, in one transaction, then settles it on the source. A transfer
+// credited before is only settled, and the wallet returned is nil.
+func (r *Router) credit(ctx context.Context, t models.ShardTransfer) (*models.Wallet, error) {
+	source, target := r.shards[r.For(t.FromWalletID)], r.shards[r.For(t.ToWalletID)]
+
+	// The deposit comes first so that, when the transaction is joined
+	// rather than its own, a failed deposit leaves no claim behind.
+	var credited *models.Wallet
+	err := target.WithinWalletTx(ctx, t.ToWalletID, func(ctx context.Context) error {
+		var err error
+		if credited, err = target.UpdateWalletBalance(ctx, t.ToWalletID, t.Amount, models.OperationTypeDeposit); err != nil {
+			return err
+		}
+		before, err := target.ShardTransfers().ClaimShardTransfer(ctx, t.ID, models.ShardTransferStatusCompleted, time.Now())
+		switch {
+		case err != nil:
+			return err
+		case before == models.ShardTransferStatusCompleted:
+			return errTransferCredited
+		case before != "":
+			return errTransferCompensated
+		}
+		return nil
+	})
+	if errors.Is(err, errTransferCredited) {
+		credited, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := source.ShardTransfers().SettleShardTransfer(ctx, t.ID, models.ShardTransferStatusCompleted, time.Now()); err != nil {
+		// The claim keeps RecoverTransfers from crediting it again.
+		r.log.Warn("cross-shard transfer credited but not settled", slog.String("transfer_id", t.ID.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return credited, nil
+}
+
+// compensate refunds the transfer to its source once its target gave it
+// up. It fails with errTransferCredited, after settling the transfer, if
+// the target credited it after all.
+func (r *Router) compensate(ctx context.Context, t models.ShardTransfer) error {
+	r.transfers.steps.RLock()
+	defer r.transfers.steps.RUnlock()
+
+	var err error
+	for range compensationAttempts {
+		if err = r.refund(ctx, t); err == nil || errors.Is(err, errTransferCredited) {
+			r.transfers.settled(t.ID)
+			return err
+		}
+	}
+	return err
+}
+
+func (r *Router) refund(ctx context.Context, t models.ShardTransfer) error {
+	source, target := r.shards[r.For(t.FromWalletID)], r.shards[r.For(t.ToWalletID)]
+
+	before, err := target.ShardTransfers().ClaimShardTransfer(ctx, t.ID, models.ShardTransferStatusCompensated, time.Now())
+	if err != nil {
+		return err
+	}
+	if before == models.ShardTransferStatusCompleted {
+		if _, err := source.ShardTransfers().SettleShardTransfer(ctx, t.ID, models.ShardTransferStatusCompleted, time.Now()); err != nil {
+			return err
+		}
+		return errTransferCredited
+	}
+
+	// Settling first makes sure a transfer is refunded once.
+	return source.WithinWalletTx(ctx, t.FromWalletID, func(ctx context.Context) error {
+		settled, err := source.ShardTransfers().SettleShardTransfer(ctx, t.ID, models.ShardTransferStatusCompensated, time.Now())
+		if err != nil || !settled {
+			return err
+		}
+		_, err = source.UpdateWalletBalance(ctx, t.FromWalletID, t.Amount, models.OperationTypeReversalCredit)
+		return err
+	})
+}
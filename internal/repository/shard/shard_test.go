@@ -0,0 +1,441 @@
+package shard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestCohort(t *testing.T) {
+	id := uuid.New()
+	assert.Equal(t, Cohort(id), Cohort(id))
+
+	seen := make(map[int]bool)
+	for range 5000 {
+		c := Cohort(uuid.New())
+		require.GreaterOrEqual(t, c, 0)
+		require.Less(t, c, Cohorts)
+		seen[c] = true
+	}
+	// 5000 random ids leave a cohort empty with probability ~1e-2 each
+	// under a broken hash; a sound one covers nearly all of them.
+	assert.Greater(t, len(seen), Cohorts*95/100)
+}
+
+func TestEvenMap(t *testing.T) {
+	m := EvenMap(4)
+	assert.Equal(t, 0, m[0])
+	assert.Equal(t, 0, m[255])
+	assert.Equal(t, 1, m[256])
+	assert.Equal(t, 3, m[Cohorts-1])
+
+	single := EvenMap(1)
+	for _, s := range single {
+		require.Zero(t, s)
+	}
+}
+
+func TestParseMap(t *testing.T) {
+	m, err := ParseMap("0-511:1, 512-1022:0, 1023:1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, m[0])
+	assert.Equal(t, 0, m[512])
+	assert.Equal(t, 0, m[1022])
+	assert.Equal(t, 1, m[1023])
+
+	for _, spec := range []string{
+		"0-1023",             // no shard
+		"0-1023:2",           // shard out of range
+		"0-511:0",            // cohorts missing
+		"0-600:0,500-1023:1", // overlap
+		"0-1024:0",           // cohort out of range
+		"511-0:0",            // reversed range
+		"a-b:0",
+	} {
+		_, err := ParseMap(spec, 2)
+		assert.Error(t, err, spec)
+	}
+}
+
+// idOn returns a new wallet id routed to shard.
+func idOn(t *testing.T, r *Router, shard int) uuid.UUID {
+	t.Helper()
+	for range 10000 {
+		if id := uuid.New(); r.For(id) == shard {
+			return id
+		}
+	}
+	t.Fatalf("no id found for shard %d", shard)
+	return uuid.Nil
+}
+
+func newRouter(shards ...Shard) *Router {
+	return NewRouter(shards, EvenMap(len(shards)), log)
+}
+
+func TestRouter_RoutesByWallet(t *testing.T) {
+	a, b := memory.New(), memory.New()
+	r := newRouter(a, b)
+	ctx := context.Background()
+
+	id := idOn(t, r, 1)
+	_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+
+	_, err = a.GetWallet(ctx, id)
+	assert.ErrorIs(t, err, repository.ErrWalletNotFound)
+	_, err = b.GetWallet(ctx, id)
+	assert.NoError(t, err)
+
+	w, err := r.UpdateWalletBalance(ctx, id, 50, models.OperationTypeDeposit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), w.Balance)
+}
+
+func TestRouter_MergesListings(t *testing.T) {
+	r := newRouter(memory.New(), memory.New(), memory.New())
+	ctx := context.Background()
+	owner := uuid.New()
+
+	var ids []uuid.UUID
+	for shard := range 3 {
+		for range 3 {
+			id := idOn(t, r, shard)
+			_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{OwnerID: uuid.NullUUID{UUID: owner, Valid: true}, Currency: "USD"})
+			require.NoError(t, err)
+			_, err = r.UpdateWalletBalance(ctx, id, 10, models.OperationTypeDeposit)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+	}
+
+	var listed []uuid.UUID
+	var after models.WalletCursor
+	for {
+		page, err := r.ListWallets(ctx, models.WalletFilter{}, models.WalletSort{}, after, 4)
+		require.NoError(t, err)
+		for i, w := range page {
+			if i > 0 {
+				assert.Less(t, page[i-1].ID.String(), w.ID.String())
+			}
+			listed = append(listed, w.ID)
+		}
+		if len(page) < 4 {
+			break
+		}
+		after = models.CursorAt(page[len(page)-1])
+	}
+	assert.ElementsMatch(t, ids, listed)
+
+	balances, err := r.OwnerBalances(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CurrencyBalance{{Currency: "USD", Balance: 90, WalletCount: 9}}, balances)
+
+	count, err := r.CountWalletsToSetStatus(ctx, models.WalletFilter{OwnerID: uuid.NullUUID{UUID: owner, Valid: true}}, models.WalletStatusFrozen)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), count)
+}
+
+func TestRouter_ApplyAtomicCrossShard(t *testing.T) {
+	r := newRouter(memory.New(), memory.New())
+	_, err := r.ApplyAtomic(context.Background(), []models.WalletOperation{
+		{WalletID: idOn(t, r, 0), OperationType: models.OperationTypeWithdraw, Amount: 1},
+		{WalletID: idOn(t, r, 1), OperationType: models.OperationTypeDeposit, Amount: 1},
+	})
+	assert.ErrorIs(t, err, ErrCrossShard)
+}
+
+// failingDeposits is a shard whose deposits fail, to exercise compensation.
+type failingDeposits struct {
+	*memory.Repository
+}
+
+var errShardDown = errors.New("shard down")
+
+func (f failingDeposits) UpdateWalletBalance(ctx context.Context, id uuid.UUID, amount int64, op models.OperationType) (*models.Wallet, error) {
+	if op == models.OperationTypeDeposit {
+		return nil, errShardDown
+	}
+	return f.Repository.UpdateWalletBalance(ctx, id, amount, op)
+}
+
+// failingRefunds is a shard whose reversal credits fail, so a compensation
+// can't complete.
+type failingRefunds struct {
+	*memory.Repository
+}
+
+func (f failingRefunds) UpdateWalletBalance(ctx context.Context, id uuid.UUID, amount int64, op models.OperationType) (*models.Wallet, error) {
+	if op == models.OperationTypeReversalCredit {
+		return nil, errShardDown
+	}
+	return f.Repository.UpdateWalletBalance(ctx, id, amount, op)
+}
+
+func TestRouter_AggregatesCountInFlightTransfers(t *testing.T) {
+	ctx := context.Background()
+	r := newRouter(failingRefunds{memory.New()}, failingDeposits{memory.New()})
+	owner := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	from, to := idOn(t, r, 0), idOn(t, r, 1)
+	for _, id := range []uuid.UUID{from, to} {
+		_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{OwnerID: owner, Currency: "USD", Tenant: "acme"})
+		require.NoError(t, err)
+	}
+	// The target shard can't take deposits, so fund the source directly.
+	_, err := r.UpdateWalletBalance(ctx, from, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+
+	_, _, err = r.Transfer(ctx, from, to, 40)
+	require.ErrorIs(t, err, ErrCompensationFailed)
+
+	w, _ := r.GetWallet(ctx, from)
+	assert.Equal(t, int64(60), w.Balance)
+
+	want := []models.CurrencyBalance{{Currency: "USD", Balance: 100, WalletCount: 2}}
+	balances, err := r.OwnerBalances(ctx, owner.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, want, balances)
+	balances, err = r.TenantBalances(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, want, balances)
+	balances, err = r.TenantBalances(ctx, "other")
+	require.NoError(t, err)
+	assert.Empty(t, balances)
+}
+
+func TestRouter_TransferAcrossShards(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T, target Shard) (*Router, uuid.UUID, uuid.UUID) {
+		r := newRouter(memory.New(), target)
+		from, to := idOn(t, r, 0), idOn(t, r, 1)
+		for _, id := range []uuid.UUID{from, to} {
+			_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "USD"})
+			require.NoError(t, err)
+		}
+		_, err := r.UpdateWalletBalance(ctx, from, 100, models.OperationTypeDeposit)
+		require.NoError(t, err)
+		return r, from, to
+	}
+
+	t.Run("success", func(t *testing.T) {
+		r, from, to := setup(t, memory.New())
+
+		debited, credited, err := r.Transfer(ctx, from, to, 40)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), debited.Balance)
+		assert.Equal(t, int64(40), credited.Balance)
+
+		// Each shard records its side of the transfer, so the merged
+		// totals still add up.
+		totals, err := r.CurrencyTotals(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.CurrencyTotals{{Currency: "USD", Balance: 100, Credits: 140, Debits: 40}}, totals)
+	})
+
+	t.Run("insufficient funds moves nothing", func(t *testing.T) {
+		r, from, to := setup(t, memory.New())
+
+		_, _, err := r.Transfer(ctx, from, to, 101)
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		w, _ := r.GetWallet(ctx, to)
+		assert.Zero(t, w.Balance)
+	})
+
+	t.Run("failed credit is compensated", func(t *testing.T) {
+		target := failingDeposits{memory.New()}
+		r, from, to := setup(t, target)
+
+		_, _, err := r.Transfer(ctx, from, to, 40)
+
+		assert.ErrorIs(t, err, errShardDown)
+		w, _ := r.GetWallet(ctx, from)
+		assert.Equal(t, int64(100), w.Balance)
+		versions, _ := r.GetWalletVersions(ctx, from)
+		assert.Equal(t, models.OperationTypeReversalCredit, versions[len(versions)-1].OperationType)
+	})
+
+	t.Run("currency mismatch", func(t *testing.T) {
+		r := newRouter(memory.New(), memory.New())
+		from, to := idOn(t, r, 0), idOn(t, r, 1)
+		_, err := r.CreateWallet(ctx, from, models.CreateWalletRequest{Currency: "USD"})
+		require.NoError(t, err)
+		_, err = r.CreateWallet(ctx, to, models.CreateWalletRequest{Currency: "EUR"})
+		require.NoError(t, err)
+
+		_, _, err = r.Transfer(ctx, from, to, 1)
+		assert.ErrorIs(t, err, repository.ErrCurrencyMismatch)
+	})
+}
+
+// lostCommits is a shard whose transactions commit but report a failure,
+// as when the connection drops before the commit is acknowledged.
+type lostCommits struct {
+	*memory.Repository
+}
+
+func (l lostCommits) WithinWalletTx(ctx context.Context, walletID uuid.UUID, fn func(ctx context.Context) error) error {
+	if err := l.Repository.WithinWalletTx(ctx, walletID, fn); err != nil {
+		return err
+	}
+	return errShardDown
+}
+
+func TestRouter_TransferCreditedDespiteReportedFailure(t *testing.T) {
+	ctx := context.Background()
+	r := newRouter(memory.New(), lostCommits{memory.New()})
+	from, to := idOn(t, r, 0), idOn(t, r, 1)
+	for _, id := range []uuid.UUID{from, to} {
+		_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "USD"})
+		require.NoError(t, err)
+	}
+	_, err := r.UpdateWalletBalance(ctx, from, 100, models.OperationTypeDeposit)
+	require.NoError(t, err)
+
+	debited, credited, err := r.Transfer(ctx, from, to, 40)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), debited.Balance)
+	assert.Equal(t, int64(40), credited.Balance, "the credit isn't refunded")
+	w, _ := r.GetWallet(ctx, from)
+	assert.Equal(t, int64(60), w.Balance)
+}
+
+// crashAfterDebit runs the first step of a cross-shard transfer only, as
+// a process crashing before the credit would, long enough ago for
+// RecoverTransfers to take it over.
+func crashAfterDebit(t *testing.T, r *Router, from, to uuid.UUID, amount int64) models.ShardTransfer {
+	t.Helper()
+	transfer := models.ShardTransfer{ID: uuid.New(), FromWalletID: from, ToWalletID: to, Amount: amount, CreatedAt: time.Now().Add(-2 * recoveryDelay)}
+	_, err := r.debit(context.Background(), transfer)
+	require.NoError(t, err)
+	return transfer
+}
+
+func TestRouter_RecoverTransfers(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*memory.Repository, *memory.Repository, uuid.UUID, uuid.UUID) {
+		source, target := memory.New(), memory.New()
+		r := newRouter(source, target)
+		from, to := idOn(t, r, 0), idOn(t, r, 1)
+		for _, id := range []uuid.UUID{from, to} {
+			_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "USD"})
+			require.NoError(t, err)
+		}
+		_, err := r.UpdateWalletBalance(ctx, from, 100, models.OperationTypeDeposit)
+		require.NoError(t, err)
+		return source, target, from, to
+	}
+	pending := func(t *testing.T, source *memory.Repository) []models.ShardTransfer {
+		t.Helper()
+		transfers, err := source.ShardTransfers().PendingShardTransfers(ctx, time.Now(), 10)
+		require.NoError(t, err)
+		return transfers
+	}
+
+	t.Run("credits the target", func(t *testing.T) {
+		source, target, from, to := setup(t)
+		crashAfterDebit(t, newRouter(source, target), from, to, 40)
+		require.Len(t, pending(t, source), 1)
+
+		// A new process takes over the shards.
+		r := newRouter(source, target)
+		require.NoError(t, r.RecoverTransfers(ctx))
+		require.NoError(t, r.RecoverTransfers(ctx))
+
+		w, _ := r.GetWallet(ctx, from)
+		assert.Equal(t, int64(60), w.Balance)
+		w, _ = r.GetWallet(ctx, to)
+		assert.Equal(t, int64(40), w.Balance, "credited once")
+		assert.Empty(t, pending(t, source))
+	})
+
+	t.Run("refunds the source when the target can't take it", func(t *testing.T) {
+		source, target, from, to := setup(t)
+		crashAfterDebit(t, newRouter(source, target), from, to, 40)
+		_, err := target.FreezeWallet(ctx, to)
+		require.NoError(t, err)
+
+		r := newRouter(source, target)
+		require.NoError(t, r.RecoverTransfers(ctx))
+		require.NoError(t, r.RecoverTransfers(ctx))
+
+		w, _ := r.GetWallet(ctx, from)
+		assert.Equal(t, int64(100), w.Balance, "refunded once")
+		versions, _ := r.GetWalletVersions(ctx, from)
+		assert.Equal(t, models.OperationTypeReversalCredit, versions[len(versions)-1].OperationType)
+		w, _ = r.GetWallet(ctx, to)
+		assert.Zero(t, w.Balance)
+		assert.Empty(t, pending(t, source))
+	})
+
+	t.Run("leaves it pending while the target is down", func(t *testing.T) {
+		source, target, from, to := setup(t)
+		crashAfterDebit(t, newRouter(source, target), from, to, 40)
+
+		err := newRouter(source, failingDeposits{target}).RecoverTransfers(ctx)
+		assert.ErrorIs(t, err, errShardDown)
+		w, _ := source.GetWallet(ctx, from)
+		assert.Equal(t, int64(60), w.Balance, "not refunded")
+		require.Len(t, pending(t, source), 1)
+
+		r := newRouter(source, target)
+		require.NoError(t, r.RecoverTransfers(ctx))
+		w, _ = r.GetWallet(ctx, to)
+		assert.Equal(t, int64(40), w.Balance)
+		assert.Empty(t, pending(t, source))
+	})
+
+	t.Run("leaves recent transfers to their saga", func(t *testing.T) {
+		source, target, from, to := setup(t)
+		r := newRouter(source, target)
+		_, err := r.debit(ctx, models.ShardTransfer{ID: uuid.New(), FromWalletID: from, ToWalletID: to, Amount: 40, CreatedAt: time.Now()})
+		require.NoError(t, err)
+
+		require.NoError(t, r.RecoverTransfers(ctx))
+		w, _ := r.GetWallet(ctx, to)
+		assert.Zero(t, w.Balance)
+		assert.Len(t, pending(t, source), 1)
+	})
+}
+
+// countingTx is a shard that counts the transactions begun on it.
+type countingTx struct {
+	*memory.Repository
+	txs *int
+}
+
+func (c countingTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	*c.txs++
+	return c.Repository.WithinTx(ctx, fn)
+}
+
+func TestRouter_OperationsOnlyOpenTheirShard(t *testing.T) {
+	var txs [2]int
+	r := newRouter(countingTx{memory.New(), &txs[0]}, countingTx{memory.New(), &txs[1]})
+	s := service.NewWalletService(r, log)
+	ctx := context.Background()
+
+	id := idOn(t, r, 1)
+	_, err := r.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "USD"})
+	require.NoError(t, err)
+	w, err := s.ProcessOperation(ctx, models.WalletOperation{WalletID: id, OperationType: models.OperationTypeDeposit, Amount: 50})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(50), w.Balance)
+	assert.Equal(t, [2]int{0, 1}, txs)
+}
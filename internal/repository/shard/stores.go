@@ -0,0 +1,79 @@
+package shard
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Wallets returns a wallet store routing each call to the wallet's shard.
+func (r *Router) Wallets() repository.WalletStore { return walletStore{r} }
+
+// Versions returns a version store routing each call to the wallet's
+// shard.
+func (r *Router) Versions() repository.VersionStore { return versionStore{r} }
+
+// Promos returns a promo store routing each call to the wallet's shard.
+func (r *Router) Promos() repository.PromoStore { return promoStore{r} }
+
+// Outbox returns an outbox store writing each event to the shard of its
+// wallet, in the transaction of the wallet's change.
+func (r *Router) Outbox() repository.OutboxStore { return outboxStore{r} }
+
+// Snapshots returns a snapshot store routing each call to the wallet's
+// shard.
+func (r *Router) Snapshots() repository.SnapshotStore { return snapshotStore{r} }
+
+type walletStore struct{ r *Router }
+
+func (s walletStore) LockWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return s.r.shard(id).Wallets().LockWallet(ctx, id)
+}
+
+func (s walletStore) SaveBalance(ctx context.Context, w *models.Wallet, change ledger.Change) (*models.Wallet, error) {
+	return s.r.shard(w.ID).Wallets().SaveBalance(ctx, w, change)
+}
+
+type versionStore struct{ r *Router }
+
+func (s versionStore) AppendVersion(ctx context.Context, v models.WalletVersion) error {
+	return s.r.shard(v.WalletID).Versions().AppendVersion(ctx, v)
+}
+
+func (s versionStore) WithdrawnSince(ctx context.Context, walletID uuid.UUID, since time.Time) (int64, error) {
+	return s.r.shard(walletID).Versions().WithdrawnSince(ctx, walletID, since)
+}
+
+type promoStore struct{ r *Router }
+
+func (s promoStore) ConsumePromoCredits(ctx context.Context, walletID uuid.UUID, amount int64) error {
+	return s.r.shard(walletID).Promos().ConsumePromoCredits(ctx, walletID, amount)
+}
+
+type outboxStore struct{ r *Router }
+
+func (s outboxStore) AppendOutboxEvent(ctx context.Context, e models.OutboxEvent) error {
+	return s.r.shard(e.WalletID).Outbox().AppendOutboxEvent(ctx, e)
+}
+
+type snapshotStore struct{ r *Router }
+
+func (s snapshotStore) LatestSnapshot(ctx context.Context, walletID uuid.UUID) (*models.WalletSnapshot, error) {
+	return s.r.shard(walletID).Snapshots().LatestSnapshot(ctx, walletID)
+}
+
+func (s snapshotStore) VersionsAfter(ctx context.Context, walletID uuid.UUID, version int) ([]models.WalletVersion, error) {
+	return s.r.shard(walletID).Snapshots().VersionsAfter(ctx, walletID, version)
+}
+
+func (s snapshotStore) SaveSnapshot(ctx context.Context, sn models.WalletSnapshot) error {
+	return s.r.shard(sn.WalletID).Snapshots().SaveSnapshot(ctx, sn)
+}
+
+func (s snapshotStore) RestoreBalances(ctx context.Context, sn models.WalletSnapshot) error {
+	return s.r.shard(sn.WalletID).Snapshots().RestoreBalances(ctx, sn)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type shardTransferStore struct{ r *WalletRepository }
+
+func (s shardTransferStore) RecordShardTransfer(ctx context.Context, t models.ShardTransfer) error {
+	query := `INSERT INTO shard_transfers (id, from_wallet_id, to_wallet_id, amount, status, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	return s.r.withReconnect(ctx, "repository.RecordShardTransfer", func() error {
+		_, err := s.r.conn(ctx).ExecContext(ctx, query, t.ID, t.FromWalletID, t.ToWalletID, t.Amount, models.ShardTransferStatusPending, t.CreatedAt.UTC())
+		return queryError("insert_shard_transfer", err)
+	})
+}
+
+func (s shardTransferStore) ClaimShardTransfer(ctx context.Context, id uuid.UUID, outcome models.ShardTransferStatus, at time.Time) (models.ShardTransferStatus, error) {
+	insert := `INSERT INTO shard_transfer_claims (transfer_id, outcome, created_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (transfer_id) DO NOTHING`
+	query := `SELECT outcome FROM shard_transfer_claims WHERE transfer_id = $1`
+
+	var claimed models.ShardTransferStatus
+	err := s.r.withReconnect(ctx, "repository.ClaimShardTransfer", func() error {
+		res, err := s.r.conn(ctx).ExecContext(ctx, insert, id, outcome, at.UTC())
+		if err != nil {
+			return queryError("insert_shard_transfer_claim", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			claimed = ""
+			return nil
+		}
+		return queryError("select_shard_transfer_claim", s.r.conn(ctx).QueryRowContext(ctx, query, id).Scan(&claimed))
+	})
+	return claimed, err
+}
+
+func (s shardTransferStore) SettleShardTransfer(ctx context.Context, id uuid.UUID, status models.ShardTransferStatus, at time.Time) (bool, error) {
+	query := `UPDATE shard_transfers SET status = $1, settled_at = $2
+	WHERE id = $3 AND status = $4`
+
+	var settled bool
+	err := s.r.withReconnect(ctx, "repository.SettleShardTransfer", func() error {
+		res, err := s.r.conn(ctx).ExecContext(ctx, query, status, at.UTC(), id, models.ShardTransferStatusPending)
+		if err != nil {
+			return queryError("settle_shard_transfer", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		settled = n > 0
+		return nil
+	})
+	return settled, err
+}
+
+func (s shardTransferStore) PendingShardTransfers(ctx context.Context, before time.Time, limit int) ([]models.ShardTransfer, error) {
+	query := `SELECT id, from_wallet_id, to_wallet_id, amount, status, created_at, settled_at
+	FROM shard_transfers
+	WHERE status = $1 AND created_at < $2
+	ORDER BY created_at
+	LIMIT $3`
+
+	var transfers []models.ShardTransfer
+	err := s.r.withReconnect(ctx, "repository.PendingShardTransfers", func() error {
+		rows, err := s.r.conn(ctx).QueryContext(ctx, query, models.ShardTransferStatusPending, before.UTC(), limit)
+		if err != nil {
+			return queryError("select_shard_transfers", err)
+		}
+		defer rows.Close()
+
+		transfers = transfers[:0]
+		for rows.Next() {
+			var t models.ShardTransfer
+			var settledAt sql.NullTime
+			if err := rows.Scan(&t.ID, &t.FromWalletID, &t.ToWalletID, &t.Amount, &t.Status, utc(&t.CreatedAt), &settledAt); err != nil {
+				return queryError("select_shard_transfers", err)
+			}
+			t.SettledAt = utcPtr(settledAt)
+			transfers = append(transfers, t)
+		}
+		return queryError("select_shard_transfers", rows.Err())
+	})
+	return transfers, err
+}
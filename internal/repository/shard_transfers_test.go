@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardTransferStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	transfer := models.ShardTransfer{ID: uuid.New(), FromWalletID: uuid.New(), ToWalletID: uuid.New(), Amount: 40, CreatedAt: time.Now().UTC()}
+	cols := []string{"id", "from_wallet_id", "to_wallet_id", "amount", "status", "created_at", "settled_at"}
+
+	mock.ExpectExec(`INSERT INTO shard_transfers`).
+		WithArgs(transfer.ID, transfer.FromWalletID, transfer.ToWalletID, int64(40), models.ShardTransferStatusPending, transfer.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, from_wallet_id, to_wallet_id, amount, status, created_at, settled_at\s+FROM shard_transfers\s+WHERE status = \$1 AND created_at < \$2`).
+		WithArgs(models.ShardTransferStatusPending, transfer.CreatedAt.Add(time.Minute), 10).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(transfer.ID, transfer.FromWalletID, transfer.ToWalletID, 40, models.ShardTransferStatusPending, transfer.CreatedAt, nil))
+	mock.ExpectExec(`UPDATE shard_transfers SET status = \$1, settled_at = \$2\s+WHERE id = \$3 AND status = \$4`).
+		WithArgs(models.ShardTransferStatusCompleted, sqlmock.AnyArg(), transfer.ID, models.ShardTransferStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE shard_transfers SET status`).
+		WithArgs(models.ShardTransferStatusCompensated, sqlmock.AnyArg(), transfer.ID, models.ShardTransferStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	store := repo.ShardTransfers()
+	require.NoError(t, store.RecordShardTransfer(context.Background(), transfer))
+
+	pending, err := store.PendingShardTransfers(context.Background(), transfer.CreatedAt.Add(time.Minute), 10)
+	require.NoError(t, err)
+	transfer.Status = models.ShardTransferStatusPending
+	assert.Equal(t, []models.ShardTransfer{transfer}, pending)
+
+	settled, err := store.SettleShardTransfer(context.Background(), transfer.ID, models.ShardTransferStatusCompleted, time.Now())
+	require.NoError(t, err)
+	assert.True(t, settled)
+
+	settled, err = store.SettleShardTransfer(context.Background(), transfer.ID, models.ShardTransferStatusCompensated, time.Now())
+	require.NoError(t, err)
+	assert.False(t, settled, "a settled transfer isn't settled again")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShardTransferStore_ClaimShardTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+
+	mock.ExpectExec(`INSERT INTO shard_transfer_claims .+ ON CONFLICT \(transfer_id\) DO NOTHING`).
+		WithArgs(id, models.ShardTransferStatusCompleted, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO shard_transfer_claims`).
+		WithArgs(id, models.ShardTransferStatusCompensated, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT outcome FROM shard_transfer_claims WHERE transfer_id = \$1`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"outcome"}).AddRow(models.ShardTransferStatusCompleted))
+
+	before, err := repo.ShardTransfers().ClaimShardTransfer(context.Background(), id, models.ShardTransferStatusCompleted, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, before, "the first claim wins")
+
+	before, err = repo.ShardTransfers().ClaimShardTransfer(context.Background(), id, models.ShardTransferStatusCompensated, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.ShardTransferStatusCompleted, before)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type snapshotStore struct{ r *WalletRepository }
+
+func (s snapshotStore) LatestSnapshot(ctx context.Context, walletID uuid.UUID) (*models.WalletSnapshot, error) {
+	query := `SELECT wallet_id, version, balance, promo_balance, created_at
+	FROM wallet_snapshots
+	WHERE wallet_id = $1
+	ORDER BY version DESC
+	LIMIT 1`
+
+	var snapshot *models.WalletSnapshot
+	err := s.r.withReconnect(ctx, "repository.LatestSnapshot", func() error {
+		var sn models.WalletSnapshot
+		err := s.r.conn(ctx).QueryRowContext(ctx, query, walletID).
+			Scan(&sn.WalletID, &sn.Version, &sn.Balance, &sn.PromoBalance, utc(&sn.CreatedAt))
+		if errors.Is(err, sql.ErrNoRows) {
+			snapshot = nil
+			return nil
+		}
+		if err != nil {
+			return queryError("select_latest_snapshot", err)
+		}
+		snapshot = &sn
+		return nil
+	})
+	return snapshot, err
+}
+
+func (s snapshotStore) VersionsAfter(ctx context.Context, walletID uuid.UUID, version int) ([]models.WalletVersion, error) {
+	query := `SELECT wallet_id, version, balance, operation_type, amount, created_at
+	FROM wallet_versions
+	WHERE wallet_id = $1 AND version > $2
+	ORDER BY version`
+
+	var versions []models.WalletVersion
+	err := s.r.withReconnect(ctx, "repository.VersionsAfter", func() error {
+		rows, err := s.r.conn(ctx).QueryContext(ctx, query, walletID, version)
+		if err != nil {
+			return queryError("select_versions", err)
+		}
+		defer rows.Close()
+
+		versions = versions[:0]
+		for rows.Next() {
+			var v models.WalletVersion
+			if err := rows.Scan(&v.WalletID, &v.Version, &v.Balance, &v.OperationType, &v.Amount, utc(&v.CreatedAt)); err != nil {
+				return queryError("select_versions", err)
+			}
+			versions = append(versions, v)
+		}
+		return queryError("select_versions", rows.Err())
+	})
+	return versions, err
+}
+
+func (s snapshotStore) SaveSnapshot(ctx context.Context, sn models.WalletSnapshot) error {
+	query := `INSERT INTO wallet_snapshots (wallet_id, version, balance, promo_balance, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (wallet_id, version) DO NOTHING`
+
+	return s.r.withReconnect(ctx, "repository.SaveSnapshot", func() error {
+		_, err := s.r.conn(ctx).ExecContext(ctx, query, sn.WalletID, sn.Version, sn.Balance, sn.PromoBalance, sn.CreatedAt.UTC())
+		return queryError("insert_snapshot", err)
+	})
+}
+
+func (s snapshotStore) RestoreBalances(ctx context.Context, sn models.WalletSnapshot) error {
+	query := `UPDATE wallets SET balance = $1, promo_balance = $2
+	WHERE id = $3 AND version = $4`
+
+	return s.r.withReconnect(ctx, "repository.RestoreBalances", func() error {
+		res, err := s.r.conn(ctx).ExecContext(ctx, query, sn.Balance, sn.PromoBalance, sn.WalletID, sn.Version)
+		if err != nil {
+			return queryError("restore_balances", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrConcurrentModification
+		}
+		return nil
+	})
+}
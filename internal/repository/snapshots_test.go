@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now().UTC()
+	snapshotCols := []string{"wallet_id", "version", "balance", "promo_balance", "created_at"}
+	versionCols := []string{"wallet_id", "version", "balance", "operation_type", "amount", "created_at"}
+
+	mock.ExpectQuery(`SELECT .* FROM wallet_snapshots`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(snapshotCols))
+	mock.ExpectQuery(`SELECT .* FROM wallet_snapshots`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(id, 10, 500, 20, now))
+	mock.ExpectQuery(`SELECT .* FROM wallet_versions`).WithArgs(id, 10).
+		WillReturnRows(sqlmock.NewRows(versionCols).
+			AddRow(id, 11, 600, models.OperationTypeDeposit, 100, now).
+			AddRow(id, 12, 550, models.OperationTypeWithdraw, 50, now))
+	mock.ExpectExec(`INSERT INTO wallet_snapshots`).WithArgs(id, 12, 550, 0, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	none, err := repo.Snapshots().LatestSnapshot(context.Background(), id)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	snapshot, err := repo.Snapshots().LatestSnapshot(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WalletSnapshot{WalletID: id, Version: 10, Balance: 500, PromoBalance: 20, CreatedAt: now}, snapshot)
+
+	versions, err := repo.Snapshots().VersionsAfter(context.Background(), id, snapshot.Version)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 12, versions[1].Version)
+
+	err = repo.Snapshots().SaveSnapshot(context.Background(), models.WalletSnapshot{WalletID: id, Version: 12, Balance: 550, CreatedAt: now})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotStore_RestoreBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	s := models.WalletSnapshot{WalletID: uuid.New(), Version: 4, Balance: 70, PromoBalance: 5}
+
+	mock.ExpectExec(`UPDATE wallets SET balance`).WithArgs(70, 5, s.WalletID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE wallets SET balance`).WithArgs(70, 5, s.WalletID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Snapshots().RestoreBalances(context.Background(), s))
+	assert.ErrorIs(t, repo.Snapshots().RestoreBalances(context.Background(), s), ErrConcurrentModification)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
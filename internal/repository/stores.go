@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// TxManager runs units of work: the store calls fn makes with the context
+// it is given commit together or not at all.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// WalletStore reads and writes wallet rows for a unit of work.
+type WalletStore interface {
+	// LockWallet reads a wallet and, within a transaction, locks it until
+	// the transaction ends.
+	LockWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	// SaveBalance stores the balances of change on w and returns the
+	// wallet at its next version. It fails with ErrConcurrentModification
+	// if w has moved past w.Version since it was read.
+	SaveBalance(ctx context.Context, w *models.Wallet, change ledger.Change) (*models.Wallet, error)
+}
+
+// VersionStore records the history of wallets, one version per change.
+type VersionStore interface {
+	AppendVersion(ctx context.Context, v models.WalletVersion) error
+	// WithdrawnSince returns the total a wallet has withdrawn since since.
+	WithdrawnSince(ctx context.Context, walletID uuid.UUID, since time.Time) (int64, error)
+}
+
+// PromoStore tracks the promo credits making up wallets' promo balances.
+type PromoStore interface {
+	// ConsumePromoCredits uses up amount of a wallet's promo credits,
+	// those expiring first first.
+	ConsumePromoCredits(ctx context.Context, walletID uuid.UUID, amount int64) error
+}
+
+// OutboxStore records domain events in the transaction of the changes
+// they report, for the outbox relay to publish.
+type OutboxStore interface {
+	AppendOutboxEvent(ctx context.Context, e models.OutboxEvent) error
+}
+
+// SnapshotStore reads wallets' histories as event streams and keeps
+// snapshots of the state they add up to.
+type SnapshotStore interface {
+	// LatestSnapshot returns the wallet's most recent snapshot, or nil if
+	// it has none.
+	LatestSnapshot(ctx context.Context, walletID uuid.UUID) (*models.WalletSnapshot, error)
+	// VersionsAfter returns the wallet's stored versions after version,
+	// oldest first.
+	VersionsAfter(ctx context.Context, walletID uuid.UUID, version int) ([]models.WalletVersion, error)
+	SaveSnapshot(ctx context.Context, s models.WalletSnapshot) error
+	// RestoreBalances overwrites the balances of the wallet with those of
+	// s, a state replayed from its history, if the wallet is still at
+	// s.Version. It fails with ErrConcurrentModification otherwise.
+	RestoreBalances(ctx context.Context, s models.WalletSnapshot) error
+}
+
+// ShardTransferStore records transfers between wallets on different shards
+// so that a transfer interrupted between its debit and its credit can be
+// finished. The source shard keeps the transfer; the target shard keeps a
+// claim on how it ended.
+type ShardTransferStore interface {
+	// RecordShardTransfer stores t as pending. Called in the transaction
+	// of its debit.
+	RecordShardTransfer(ctx context.Context, t models.ShardTransfer) error
+	// ClaimShardTransfer records on the target shard that the transfer id
+	// ends with outcome unless it was claimed before, and returns the
+	// outcome claimed before, empty if none was. The credit claims
+	// COMPLETED in its own transaction, so a transfer is never both
+	// credited and refunded.
+	ClaimShardTransfer(ctx context.Context, id uuid.UUID, outcome models.ShardTransferStatus, at time.Time) (models.ShardTransferStatus, error)
+	// SettleShardTransfer moves a pending transfer to status and reports
+	// whether it was still pending.
+	SettleShardTransfer(ctx context.Context, id uuid.UUID, status models.ShardTransferStatus, at time.Time) (bool, error)
+	// PendingShardTransfers returns up to limit transfers created before
+	// before that are still pending, oldest first.
+	PendingShardTransfers(ctx context.Context, before time.Time, limit int) ([]models.ShardTransfer, error)
+}
+
+// Wallets returns the wallet store, which joins the transaction of
+// WithinTx.
+func (r *WalletRepository) Wallets() WalletStore { return walletStore{r} }
+
+// Versions returns the version store, which joins the transaction of
+// WithinTx.
+func (r *WalletRepository) Versions() VersionStore { return versionStore{r} }
+
+// Promos returns the promo store, which joins the transaction of WithinTx.
+func (r *WalletRepository) Promos() PromoStore { return promoStore{r} }
+
+// Outbox returns the outbox store, which joins the transaction of
+// WithinTx.
+func (r *WalletRepository) Outbox() OutboxStore { return outboxStore{r} }
+
+// Snapshots returns the snapshot store, which joins the transaction of
+// WithinTx.
+func (r *WalletRepository) Snapshots() SnapshotStore { return snapshotStore{r} }
+
+// ShardTransfers returns the shard transfer store, which joins the
+// transaction of WithinTx.
+func (r *WalletRepository) ShardTransfers() ShardTransferStore { return shardTransferStore{r} }
+
+type walletStore struct{ r *WalletRepository }
+
+func (s walletStore) LockWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := s.r.withReconnect(ctx, "repository.LockWallet", func() error {
+		var err error
+		wallet, err = lockWallet(ctx, s.r.conn(ctx), id)
+		return err
+	})
+	return wallet, err
+}
+
+func (s walletStore) SaveBalance(ctx context.Context, w *models.Wallet, change ledger.Change) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := s.r.withReconnect(ctx, "repository.SaveBalance", func() error {
+		var err error
+		wallet, err = saveBalance(ctx, s.r.conn(ctx), w, change)
+		return err
+	})
+	return wallet, err
+}
+
+type versionStore struct{ r *WalletRepository }
+
+func (s versionStore) AppendVersion(ctx context.Context, v models.WalletVersion) error {
+	return s.r.withReconnect(ctx, "repository.AppendVersion", func() error {
+		return appendVersion(ctx, s.r.conn(ctx), v)
+	})
+}
+
+func (s versionStore) WithdrawnSince(ctx context.Context, walletID uuid.UUID, since time.Time) (int64, error) {
+	query := `SELECT COALESCE(SUM(amount), 0)
+	FROM wallet_versions
+	WHERE wallet_id = $1 AND operation_type = $2 AND created_at >= $3`
+
+	var total int64
+	err := s.r.withReconnect(ctx, "repository.WithdrawnSince", func() error {
+		err := s.r.conn(ctx).QueryRowContext(ctx, query, walletID, models.OperationTypeWithdraw, since.UTC()).Scan(&total)
+		return queryError("sum_withdrawals", err)
+	})
+	return total, err
+}
+
+type promoStore struct{ r *WalletRepository }
+
+func (s promoStore) ConsumePromoCredits(ctx context.Context, walletID uuid.UUID, amount int64) error {
+	return s.r.withReconnect(ctx, "repository.ConsumePromoCredits", func() error {
+		return queryError("consume_promo_credits", consumePromoCredits(ctx, s.r.conn(ctx), walletID, amount))
+	})
+}
+
+func lockWallet(ctx context.Context, q querier, id uuid.UUID) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	err := scanWallet(q.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, id), wallet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, queryError("select_wallet_for_update", err)
+	}
+	return wallet, nil
+}
+
+func saveBalance(ctx context.Context, q querier, w *models.Wallet, change ledger.Change) (*models.Wallet, error) {
+	query := `UPDATE wallets SET balance = $1, promo_balance = $5, updated_at = $2, version = version + 1
+	WHERE id = $3 AND version = $4
+	RETURNING ` + walletColumns
+
+	updated := &models.Wallet{}
+	err := scanWallet(q.QueryRowContext(ctx, query, change.Balance, time.Now().UTC(), w.ID, w.Version, change.PromoBalance), updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConcurrentModification
+	}
+	if err != nil {
+		return nil, queryError("update_wallet_balance", err)
+	}
+	return updated, nil
+}
+
+func appendVersion(ctx context.Context, q querier, v models.WalletVersion) error {
+	query := `INSERT INTO wallet_versions (wallet_id, version, balance, operation_type, amount, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.ExecContext(ctx, query, v.WalletID, v.Version, v.Balance, v.OperationType, v.Amount, v.CreatedAt)
+	return queryError("insert_wallet_version", err)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 100, now, now, 3)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(70, sqlmock.AnyArg(), id, 3, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 70, now, now, 4)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(id, 4, 70, models.OperationTypeWithdraw, int64(30), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		w, err := repo.Wallets().LockWallet(ctx, id)
+		require.NoError(t, err)
+		change, err := ledger.Apply(*w, 30, models.OperationTypeWithdraw)
+		require.NoError(t, err)
+		updated, err := repo.Wallets().SaveBalance(ctx, w, change)
+		require.NoError(t, err)
+		assert.Equal(t, 4, updated.Version)
+		return repo.Versions().AppendVersion(ctx, models.WalletVersion{
+			WalletID: id, Version: updated.Version, Balance: updated.Balance,
+			OperationType: models.OperationTypeWithdraw, Amount: change.Amount, CreatedAt: updated.UpdatedAt,
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWalletStore_SaveBalanceConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	w := &models.Wallet{ID: uuid.New(), Balance: 100, Version: 3}
+
+	mock.ExpectQuery(`UPDATE wallets`).WillReturnRows(sqlmock.NewRows(walletCols))
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).WillReturnRows(sqlmock.NewRows(walletCols))
+
+	_, err = repo.Wallets().SaveBalance(context.Background(), w, ledger.Change{Balance: 150, Amount: 50})
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+	_, err = repo.Wallets().LockWallet(context.Background(), w.ID)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVersionStore_WithdrawnSince(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM wallet_versions`).
+		WithArgs(id, models.OperationTypeWithdraw, since).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(250))
+
+	total, err := repo.Versions().WithdrawnSince(context.Background(), id, since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+)
+
+// BalanceSummary aggregates wallet count and total balance, plus the number
+// of wallets created in [from, to).
+func (r *WalletRepository) BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	op := "repository.BalanceSummary"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `SELECT COUNT(*), COALESCE(SUM(balance), 0),
+	COUNT(*) FILTER (WHERE created_at >= $1 AND created_at < $2)
+	FROM wallets`
+
+	summary := &models.BalanceSummary{From: from, To: to}
+	err := r.withReconnect(ctx, op, func() error {
+		return r.reader(ctx).QueryRowContext(ctx, query, from, to).Scan(
+			&summary.WalletCount,
+			&summary.TotalBalance,
+			&summary.CreatedInRange,
+		)
+	})
+	if err != nil {
+		log.Error("error computing balance summary", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrSuspenseCaseNotFound = errors.New("suspense case not found")
+	ErrSuspenseCaseResolved = errors.New("suspense case already resolved")
+	ErrCurrencyMismatch     = errors.New("currency mismatch")
+)
+
+const suspenseCaseColumns = `id, suspense_wallet_id, amount, currency, reference, description, reason, status, created_at,
+	credit_version, resolved_at, target_wallet_id, release_version, target_version, resolution_note`
+
+func scanSuspenseCase(row rowScanner, c *models.SuspenseCase) error {
+	var resolvedAt sql.NullTime
+	var releaseVersion, targetVersion sql.NullInt64
+	if err := row.Scan(&c.ID, &c.SuspenseWalletID, &c.Amount, &c.Currency, &c.Reference, &c.Description, &c.Reason,
+		&c.Status, utc(&c.CreatedAt), &c.CreditVersion, &resolvedAt, &c.TargetWalletID, &releaseVersion, &targetVersion,
+		&c.ResolutionNote); err != nil {
+		return err
+	}
+	c.ResolvedAt, c.ReleaseVersion, c.TargetVersion = utcPtr(resolvedAt), nil, nil
+	if releaseVersion.Valid {
+		v := int(releaseVersion.Int64)
+		c.ReleaseVersion = &v
+	}
+	if targetVersion.Valid {
+		v := int(targetVersion.Int64)
+		c.TargetVersion = &v
+	}
+	return nil
+}
+
+// ReceiveToSuspense credits c.Amount to the suspense wallet of c.Currency,
+// creating the wallet on first use, and opens the case.
+func (r *WalletRepository) ReceiveToSuspense(ctx context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+	var result *models.SuspenseCase
+	err := r.withReconnect(ctx, "repository.ReceiveToSuspense", func() error {
+		var err error
+		result, err = r.receiveToSuspense(ctx, c)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) receiveToSuspense(ctx context.Context, c models.SuspenseCase) (_ *models.SuspenseCase, err error) {
+	op := "repository.ReceiveToSuspense"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("case_id", c.ID.String()), slog.String("reference", c.Reference))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	walletID, err := suspenseWallet(ctx, tx, c.Currency, c.CreatedAt)
+	if err != nil {
+		log.Error("error resolving suspense wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	wallet := models.Wallet{}
+	err = scanWallet(tx.QueryRowContext(ctx,
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1 FOR UPDATE`, walletID), &wallet)
+	if err != nil {
+		log.Error("error receiving suspense wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	updated, err := r.applyOperation(ctx, tx, log, &wallet, c.Amount, models.OperationTypeDeposit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SuspenseCase{}
+	err = scanSuspenseCase(tx.QueryRowContext(ctx, `INSERT INTO suspense_cases
+	(id, suspense_wallet_id, amount, currency, reference, description, reason, status, created_at, credit_version)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING `+suspenseCaseColumns,
+		c.ID,
+		walletID,
+		c.Amount,
+		c.Currency,
+		c.Reference,
+		c.Description,
+		c.Reason,
+		models.SuspenseCaseOpen,
+		c.CreatedAt,
+		updated.Version,
+	), result)
+	if err != nil {
+		log.Error("error opening suspense case", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
+
+// suspenseWallet returns the suspense wallet of the currency, creating it
+// if needed.
+func suspenseWallet(ctx context.Context, tx querier, currency string, now time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT wallet_id FROM suspense_wallets WHERE currency = $1`, currency).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	id = uuid.New()
+	_, err = tx.ExecContext(ctx, `INSERT INTO wallets (id, balance, created_at, updated_at, version, currency, status, label)
+	VALUES ($1, 0, $2, $2, 1, $3, $4, $5)`,
+		id, now, currency, models.WalletStatusActive, models.SuspenseWalletLabel)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO suspense_wallets (currency, wallet_id) VALUES ($1, $2)`, currency, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// ListSuspenseCases returns the cases with the given status, or all cases
+// for an empty status, oldest first.
+func (r *WalletRepository) ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	op := "repository.ListSuspenseCases"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("status", string(status)))
+
+	query := `SELECT ` + suspenseCaseColumns + ` FROM suspense_cases
+	WHERE $1 = '' OR status = $1
+	ORDER BY created_at, id`
+
+	cases := []models.SuspenseCase{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, status)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		cases = cases[:0]
+		for rows.Next() {
+			var c models.SuspenseCase
+			if err := scanSuspenseCase(rows, &c); err != nil {
+				return err
+			}
+			cases = append(cases, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing suspense cases", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return cases, nil
+}
+
+// ResolveSuspenseCase moves the funds of an open case from the suspense
+// wallet to targetID and closes the case, recording the versions of both
+// wallets produced by the move.
+func (r *WalletRepository) ResolveSuspenseCase(ctx context.Context, id, targetID uuid.UUID, note string, at time.Time) (*models.SuspenseCase, error) {
+	var result *models.SuspenseCase
+	err := r.withReconnect(ctx, "repository.ResolveSuspenseCase", func() error {
+		var err error
+		result, err = r.resolveSuspenseCase(ctx, id, targetID, note, at)
+		return err
+	})
+	return result, err
+}
+
+func (r *WalletRepository) resolveSuspenseCase(ctx context.Context, id, targetID uuid.UUID, note string, at time.Time) (_ *models.SuspenseCase, err error) {
+	op := "repository.ResolveSuspenseCase"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("case_id", id.String()), slog.String("wallet_id", targetID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	c := models.SuspenseCase{}
+	err = scanSuspenseCase(tx.QueryRowContext(ctx,
+		`SELECT `+suspenseCaseColumns+` FROM suspense_cases WHERE id = $1 FOR UPDATE`, id), &c)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSuspenseCaseNotFound
+		}
+		log.Error("error receiving suspense case", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	if c.Status != models.SuspenseCaseOpen {
+		return nil, ErrSuspenseCaseResolved
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`,
+		pq.Array([]string{c.SuspenseWalletID.String(), targetID.String()}))
+	wallets, err := scanWallets(rows, err)
+	if err != nil {
+		log.Error("error locking wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	var suspense, target *models.Wallet
+	for i := range wallets {
+		switch wallets[i].ID {
+		case c.SuspenseWalletID:
+			suspense = &wallets[i]
+		case targetID:
+			target = &wallets[i]
+		}
+	}
+	if target == nil || target.ID == c.SuspenseWalletID {
+		return nil, ErrWalletNotFound
+	}
+	if suspense == nil {
+		log.Error("suspense wallet missing")
+		return nil, ErrWalletNotFound
+	}
+	if target.Currency != c.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	released, err := r.applyOperation(ctx, tx, log, suspense, c.Amount, models.OperationTypeWithdraw)
+	if err != nil {
+		return nil, err
+	}
+	credited, err := r.applyOperation(ctx, tx, log, target, c.Amount, models.OperationTypeDeposit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SuspenseCase{}
+	err = scanSuspenseCase(tx.QueryRowContext(ctx, `UPDATE suspense_cases
+	SET status = $2, resolved_at = $3, target_wallet_id = $4, release_version = $5, target_version = $6, resolution_note = $7
+	WHERE id = $1
+	RETURNING `+suspenseCaseColumns,
+		id,
+		models.SuspenseCaseResolved,
+		at,
+		targetID,
+		released.Version,
+		credited.Version,
+		note,
+	), result)
+	if err != nil {
+		log.Error("error resolving suspense case", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return result, nil
+}
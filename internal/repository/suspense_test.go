@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var suspenseCaseCols = []string{"id", "suspense_wallet_id", "amount", "currency", "reference", "description", "reason", "status",
+	"created_at", "credit_version", "resolved_at", "target_wallet_id", "release_version", "target_version", "resolution_note"}
+
+func TestResolveSuspenseCase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	caseID, suspenseID, targetID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM suspense_cases WHERE id = \$1 FOR UPDATE`).WithArgs(caseID).
+		WillReturnRows(sqlmock.NewRows(suspenseCaseCols).
+			AddRow(caseID, suspenseID, 300, "USD", "REF1", "", "no_wallet", "OPEN", now, 4, nil, nil, nil, nil, ""))
+	mock.ExpectQuery(`WHERE id = ANY`).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(suspenseID, 1000, now, now, 4)...).
+			AddRow(walletRow(targetID, 0, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(700, sqlmock.AnyArg(), suspenseID, 4, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(suspenseID, 700, now, now, 5)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(300, sqlmock.AnyArg(), targetID, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(targetID, 300, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE suspense_cases`).WithArgs(caseID, "RESOLVED", now, targetID, 5, 2, "customer called").
+		WillReturnRows(sqlmock.NewRows(suspenseCaseCols).
+			AddRow(caseID, suspenseID, 300, "USD", "REF1", "", "no_wallet", "RESOLVED", now, 4, now, targetID, 5, 2, "customer called"))
+	mock.ExpectCommit()
+
+	c, err := repo.ResolveSuspenseCase(context.Background(), caseID, targetID, "customer called", now)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, *c.ReleaseVersion)
+	assert.Equal(t, 2, *c.TargetVersion)
+	assert.Equal(t, targetID, c.TargetWalletID.UUID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveSuspenseCase_AlreadyResolved(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	caseID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM suspense_cases`).WithArgs(caseID).
+		WillReturnRows(sqlmock.NewRows(suspenseCaseCols).
+			AddRow(caseID, uuid.New(), 300, "USD", "REF1", "", "no_wallet", "RESOLVED", now, 4, now, uuid.New(), 5, 2, ""))
+	mock.ExpectRollback()
+
+	_, err = repo.ResolveSuspenseCase(context.Background(), caseID, uuid.New(), "", now)
+
+	assert.ErrorIs(t, err, ErrSuspenseCaseResolved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// utcTime scans a timestamptz into t in UTC, whatever TimeZone the session
+// runs with.
+type utcTime struct {
+	t *time.Time
+}
+
+func utc(t *time.Time) utcTime {
+	return utcTime{t: t}
+}
+
+func (u utcTime) Scan(src any) error {
+	var nt sql.NullTime
+	if err := nt.Scan(src); err != nil {
+		return err
+	}
+	if !nt.Valid {
+		return errors.New("unexpected NULL timestamp")
+	}
+	*u.t = nt.Time.UTC()
+	return nil
+}
+
+// utcPtr returns the UTC time of a nullable timestamptz, nil when NULL.
+func utcPtr(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	t := nt.Time.UTC()
+	return &t
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// CurrencyTotals sums, per currency, the balances of all wallets and the
+// credits and debits recorded in their history. Closed wallets are left
+// out: they hold nothing, and purging removes their history. A single
+// statement reads one snapshot, so the sums describe the same moment.
+func (r *WalletRepository) CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error) {
+	op := "repository.CurrencyTotals"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `WITH balances AS (
+		SELECT currency,
+			COALESCE(SUM(balance) FILTER (WHERE label <> $2), 0) AS balance,
+			COALESCE(SUM(balance) FILTER (WHERE label = $2), 0) AS system_balance
+		FROM wallets
+		WHERE status <> $1
+		GROUP BY currency
+	), movements AS (
+		SELECT w.currency,
+			COALESCE(SUM(v.amount) FILTER (WHERE v.operation_type = ANY($3)), 0) AS credits,
+			COALESCE(SUM(v.amount) FILTER (WHERE v.operation_type = ANY($4)), 0) AS debits
+		FROM wallet_versions v
+		JOIN wallets w ON w.id = v.wallet_id
+		WHERE w.status <> $1
+		GROUP BY w.currency
+	)
+	SELECT COALESCE(b.currency, m.currency), COALESCE(b.balance, 0), COALESCE(b.system_balance, 0),
+		COALESCE(m.credits, 0), COALESCE(m.debits, 0)
+	FROM balances b
+	FULL JOIN movements m ON m.currency = b.currency
+	ORDER BY 1`
+
+	totals := []models.CurrencyTotals{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, models.WalletStatusClosed, models.SuspenseWalletLabel,
+			pq.Array(operationTypes(ledger.Credits)), pq.Array(operationTypes(ledger.Debits)))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		totals = totals[:0]
+		for rows.Next() {
+			var t models.CurrencyTotals
+			if err := rows.Scan(&t.Currency, &t.Balance, &t.SystemBalance, &t.Credits, &t.Debits); err != nil {
+				return err
+			}
+			totals = append(totals, t)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error computing currency totals", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return totals, nil
+}
+
+func operationTypes(ops []models.OperationType) []string {
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = string(op)
+	}
+	return names
+}
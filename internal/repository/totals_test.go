@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyTotals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+
+	mock.ExpectQuery(`WITH balances AS \(.+FULL JOIN movements m ON m.currency = b.currency`).
+		WithArgs(models.WalletStatusClosed, models.SuspenseWalletLabel, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "system_balance", "credits", "debits"}).
+			AddRow("EUR", 300, 0, 500, 200).
+			AddRow("USD", 1200, 50, 2000, 700))
+
+	totals, err := repo.CurrencyTotals(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.CurrencyTotals{
+		{Currency: "EUR", Balance: 300, Credits: 500, Debits: 200},
+		{Currency: "USD", Balance: 1200, SystemBalance: 50, Credits: 2000, Debits: 700},
+	}, totals)
+	assert.Zero(t, totals[0].Discrepancy())
+	assert.Equal(t, int64(-50), totals[1].Discrepancy())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ListTransactions returns up to limit balance changes of a wallet, newest
+// first, with versions below beforeVersion (0 for the newest). The history
+// is the wallet_versions ledger, written in the same transaction as every
+// balance change, so it can't miss or invent one. The range scan uses the
+// (wallet_id, version) primary key.
+func (r *WalletRepository) ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, error) {
+	op := "repository.ListTransactions"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	query := `SELECT seq, wallet_id, version, operation_type, amount, balance, created_at
+	FROM wallet_versions
+	WHERE wallet_id = $1 AND ($2 = 0 OR version < $2)
+	ORDER BY version DESC
+	LIMIT $3`
+
+	transactions := []models.Transaction{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, walletID, beforeVersion, limit)
+		if err != nil {
+			return queryError("select_transactions", err)
+		}
+		defer rows.Close()
+
+		transactions = transactions[:0]
+		for rows.Next() {
+			var t models.Transaction
+			if err := rows.Scan(&t.ID, &t.WalletID, &t.Version, &t.OperationType, &t.Amount, &t.Balance, utc(&t.CreatedAt)); err != nil {
+				return queryError("select_transactions", err)
+			}
+			transactions = append(transactions, t)
+		}
+		return queryError("select_transactions", rows.Err())
+	})
+	if err != nil {
+		log.Error("error listing transactions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	// An empty page may just be the end of the history; tell it apart from
+	// a wallet that doesn't exist.
+	if len(transactions) == 0 {
+		if _, err := r.GetWallet(ctx, walletID); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var transactionCols = []string{"seq", "wallet_id", "version", "operation_type", "amount", "balance", "created_at"}
+
+func TestListTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM wallet_versions\s+WHERE wallet_id = \$1 AND \(\$2 = 0 OR version < \$2\)\s+ORDER BY version DESC`).
+		WithArgs(id, 4, 2).
+		WillReturnRows(sqlmock.NewRows(transactionCols).
+			AddRow(31, id, 3, "WITHDRAW", 20, 80, now).
+			AddRow(12, id, 2, "DEPOSIT", 100, 100, now))
+
+	transactions, err := repo.ListTransactions(context.Background(), id, 4, 2)
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	assert.Equal(t, int64(31), transactions[0].ID)
+	assert.Equal(t, models.OperationTypeWithdraw, transactions[0].OperationType)
+	assert.Equal(t, int64(80), transactions[0].Balance)
+	assert.Equal(t, 2, transactions[1].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTransactions_UnknownWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+
+	mock.ExpectQuery(`FROM wallet_versions`).WillReturnRows(sqlmock.NewRows(transactionCols))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1`).WithArgs(id).WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.ListTransactions(context.Background(), id, 0, 10)
+
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Transfer withdraws amount from fromID and deposits it to toID in one
+// serializable transaction. Both rows are locked in id order, whichever
+// direction the money moves, so opposite transfers between the same pair
+// can't deadlock. The wallets must share a currency.
+func (r *WalletRepository) Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (*models.Wallet, *models.Wallet, error) {
+	var from, to *models.Wallet
+	err := r.withReconnect(ctx, "repository.Transfer", func() error {
+		var err error
+		from, to, err = r.transfer(ctx, fromID, toID, amount)
+		return err
+	})
+	return from, to, err
+}
+
+func (r *WalletRepository) transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (_ *models.Wallet, _ *models.Wallet, err error) {
+	op := "repository.Transfer"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("from_wallet_id", fromID.String()), slog.String("to_wallet_id", toID.String()))
+
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, nil, queryError("begin", err)
+	}
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`,
+		pq.Array([]string{fromID.String(), toID.String()}))
+	wallets, err := scanWallets(rows, err)
+	if err != nil {
+		log.Error("error locking wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, nil, queryError("lock_wallets", err)
+	}
+	var from, to *models.Wallet
+	for i := range wallets {
+		switch wallets[i].ID {
+		case fromID:
+			from = &wallets[i]
+		case toID:
+			to = &wallets[i]
+		}
+	}
+	if from == nil || to == nil || fromID == toID {
+		log.Warn("wallet not found")
+		return nil, nil, ErrWalletNotFound
+	}
+	if from.Currency != to.Currency {
+		log.Warn("transfer between currencies rejected", slog.String("from_currency", from.Currency), slog.String("to_currency", to.Currency))
+		return nil, nil, ErrCurrencyMismatch
+	}
+
+	debited, err := r.applyOperation(ctx, tx, log, from, amount, models.OperationTypeWithdraw)
+	if err != nil {
+		return nil, nil, err
+	}
+	credited, err := r.applyOperation(ctx, tx, log, to, amount, models.OperationTypeDeposit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, nil, queryError("commit", err)
+	}
+	return debited, credited, nil
+}
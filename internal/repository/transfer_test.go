@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	from, to := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE id = ANY\(\$1::uuid\[\]\) ORDER BY id FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(to, 5, now, now, 3)...).
+			AddRow(walletRow(from, 100, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(60, sqlmock.AnyArg(), from, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(from, 60, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE wallets`).WithArgs(45, sqlmock.AnyArg(), to, 3, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(to, 45, now, now, 4)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	debited, credited, err := repo.Transfer(context.Background(), from, to, 40)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), debited.Balance)
+	assert.Equal(t, int64(45), credited.Balance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransfer_Rejected(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	now := time.Now()
+	euro := walletRow(to, 0, now, now, 1)
+	euro[6] = "EUR"
+
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+		want error
+	}{
+		{name: "missing wallet", rows: sqlmock.NewRows(walletCols).AddRow(walletRow(from, 100, now, now, 1)...), want: ErrWalletNotFound},
+		{name: "currency mismatch", rows: sqlmock.NewRows(walletCols).AddRow(walletRow(from, 100, now, now, 1)...).AddRow(euro...), want: ErrCurrencyMismatch},
+		{name: "insufficient funds", rows: sqlmock.NewRows(walletCols).AddRow(walletRow(from, 10, now, now, 1)...).AddRow(walletRow(to, 0, now, now, 1)...), want: ErrInsufficientFunds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`FOR UPDATE`).WillReturnRows(tt.rows)
+			mock.ExpectRollback()
+
+			_, _, err = NewWalletRepository(db, log).Transfer(context.Background(), from, to, 40)
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// querier runs statements. *sql.DB, *sql.Tx and savepoints all qualify, so
+// a method can run on whatever conn hands it.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txn is a transaction begun by beginTx.
+type txn interface {
+	querier
+	Commit() error
+	Rollback() error
+}
+
+// txKey binds the transaction of WithinTx to a context. It is keyed by
+// repository so that shards, each a WalletRepository of its own, never
+// join one another's transactions.
+type txKey struct{ repo *WalletRepository }
+
+type ambientTx struct {
+	tx         *sql.Tx
+	savepoints int
+	// done is set once WithinTx returns; contexts that outlive it, such as
+	// those of effects run after the commit, fall back to the pool.
+	done atomic.Bool
+}
+
+// WithinTx runs fn in one serializable transaction: every repository call
+// fn makes with the context it is given runs in that transaction, which is
+// committed if fn returns nil and rolled back otherwise. Within a request,
+// the transaction's application_name names the request (see tagTx). Methods that open
+// a transaction of their own take a savepoint in it instead, so their
+// failures are still undone on their own. A WithinTx nested in another
+// joins the outer transaction.
+//
+// Failover reconnects and retries don't happen inside the transaction, as
+// a broken connection takes the transaction with it; they are left to the
+// caller of WithinTx.
+func (r *WalletRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := r.ambient(ctx); ok {
+		return fn(ctx)
+	}
+	op := "repository.WithinTx"
+
+	var tx *sql.Tx
+	err = r.withReconnect(ctx, op, func() error {
+		var err error
+		tx, err = r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+		if err != nil {
+			return queryError("begin", err)
+		}
+		if err := tagTx(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
+	amb := &ambientTx{tx: tx}
+	defer amb.done.Store(true)
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{r}, amb)); err != nil {
+		return err
+	}
+	return wrapError(op, queryError("commit", tx.Commit()))
+}
+
+// WithinWalletTx is WithinTx: a single database holds every wallet.
+func (r *WalletRepository) WithinWalletTx(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return r.WithinTx(ctx, fn)
+}
+
+// ambient returns the transaction WithinTx bound to ctx, if it is still
+// open.
+func (r *WalletRepository) ambient(ctx context.Context) (*ambientTx, bool) {
+	amb, ok := ctx.Value(txKey{r}).(*ambientTx)
+	if !ok || amb.done.Load() {
+		return nil, false
+	}
+	return amb, true
+}
+
+// conn returns the transaction bound to ctx, or the primary.
+func (r *WalletRepository) conn(ctx context.Context) querier {
+	if amb, ok := r.ambient(ctx); ok {
+		return amb.tx
+	}
+	return r.db
+}
+
+// beginTx begins a transaction with opts, or takes a savepoint in the
+// transaction bound to ctx, whose isolation then applies.
+func (r *WalletRepository) beginTx(ctx context.Context, opts *sql.TxOptions) (txn, error) {
+	amb, ok := r.ambient(ctx)
+	if !ok {
+		tx, err := r.db.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := tagTx(ctx, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		return tx, nil
+	}
+
+	amb.savepoints++
+	sp := &savepoint{querier: amb.tx, name: fmt.Sprintf("sp_%d", amb.savepoints)}
+	if _, err := amb.tx.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// savepoint stands in for a transaction nested in an ambient one.
+type savepoint struct {
+	querier
+	name string
+	done bool
+}
+
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(context.Background(), "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/requestid"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	// Both calls run in the one transaction; the deposit, which would
+	// begin its own, takes a savepoint instead.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO wallets`).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 0, now, now, 1)...))
+	mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 0, now, now, 1)...))
+	mock.ExpectQuery(`UPDATE wallets`).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 100, now, now, 2)...))
+	mock.ExpectExec(`INSERT INTO wallet_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "EUR"}); err != nil {
+			return err
+		}
+		_, err := repo.UpdateWalletBalance(ctx, id, 100, models.OperationTypeDeposit)
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTx_RollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO wallets`).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 0, now, now, 1)...))
+	mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(id, 0, now, now, 1)...))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	var stale context.Context
+	err = repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		stale = ctx
+		if _, err := repo.CreateWallet(ctx, id, models.CreateWalletRequest{Currency: "EUR"}); err != nil {
+			return err
+		}
+		_, err := repo.UpdateWalletBalance(ctx, id, 100, models.OperationTypeWithdraw)
+		return err
+	})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// A context outliving the transaction is served by the pool again.
+	assert.Same(t, db, repo.conn(stale))
+}
+
+func TestWithinTx_NamesSessionAfterRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	ctx := requestid.WithOperation(requestid.WithID(context.Background(), "req-1"), "POST /api/v1/wallets/{id}/operation")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('application_name', \$1, true\)`).
+		WithArgs("req-1 POST /api/v1/wallets/{id}/operation").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM wallets`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repo.conn(ctx).ExecContext(ctx, `DELETE FROM wallets WHERE id = $1`, id)
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplicationName(t *testing.T) {
+	_, ok := applicationName(context.Background())
+	assert.False(t, ok, "nothing to name outside of requests")
+
+	id := uuid.NewString()
+	ctx := requestid.WithID(context.Background(), id)
+	name, ok := applicationName(ctx)
+	require.True(t, ok)
+	assert.Equal(t, id, name)
+
+	name, _ = applicationName(requestid.WithOperation(ctx, "/wallet.v1.WalletService/ProcessOperation"))
+	assert.Len(t, name, maxApplicationName)
+	assert.Equal(t, id+" etService/ProcessOperation", name, "a long operation keeps its end")
+
+	long := strings.Repeat("x", 100)
+	name, _ = applicationName(requestid.WithOperation(requestid.WithID(context.Background(), long), "op"))
+	assert.Equal(t, long[:maxApplicationName], name)
+}
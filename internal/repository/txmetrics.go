@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Transaction outcomes and the reasons a transaction was rolled back, as
+// metric labels.
+const (
+	TxCommitted  = "committed"
+	TxRolledBack = "rolled_back"
+
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonConflict          = "conflict"
+	ReasonRejected          = "rejected"
+)
+
+// TxMetrics counts and times the repository's write transactions by
+// operation and outcome. Rolled back transactions carry a reason:
+// insufficient_funds, conflict for optimistic version conflicts and
+// serialization failures, rejected for other domain errors, or the
+// ErrorKind of a database failure.
+type TxMetrics struct {
+	total    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewTxMetrics() *TxMetrics {
+	return &TxMetrics{
+		total: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_db_transactions_total",
+			Help: "Database transactions by repository operation, outcome and rollback reason.",
+		}, []string{"op", "outcome", "reason"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_db_transaction_duration_seconds",
+			Help:    "Database transaction duration by repository operation and outcome.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"op", "outcome"}),
+	}
+}
+
+// WithTxMetrics records the repository's transactions in m.
+func WithTxMetrics(m *TxMetrics) Option {
+	return func(r *WalletRepository) {
+		r.txMetrics = m
+	}
+}
+
+// Describe and Collect make the metrics a prometheus.Collector.
+func (m *TxMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.total.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *TxMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.total.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// observe records a transaction of op begun at start that ended with err,
+// nil meaning it committed. It is meant to be deferred right after the
+// transaction begins, with the function's error result.
+func (m *TxMetrics) observe(op string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	outcome, reason := TxCommitted, ""
+	if err != nil {
+		// wrapError fills in the SQLSTATE the classification relies on; the
+		// caller's withReconnect would do the same to err.
+		outcome, reason = TxRolledBack, rollbackReason(wrapError(op, err))
+	}
+	m.total.WithLabelValues(op, outcome, reason).Inc()
+	m.duration.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+}
+
+func rollbackReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInsufficientFunds):
+		return ReasonInsufficientFunds
+	case errors.Is(err, ErrConcurrentModification), errors.Is(err, ErrRetryable):
+		return ReasonConflict
+	case isRejection(err):
+		return ReasonRejected
+	}
+	return string(Classify(err))
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxMetrics_RecordsRollbackReason(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	metrics := NewTxMetrics()
+	repo := NewWalletRepository(db, log, WithTxMetrics(metrics))
+	testID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 50, time.Now(), time.Now(), 1)...))
+	mock.ExpectRollback()
+
+	_, err = repo.UpdateWalletBalance(context.Background(), testID, 100, models.OperationTypeWithdraw)
+
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.total.WithLabelValues("repository.UpdateWalletBalance", TxRolledBack, ReasonInsufficientFunds)))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics, "wallet_db_transaction_duration_seconds"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollbackReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrInsufficientFunds, ReasonInsufficientFunds},
+		{ErrConcurrentModification, ReasonConflict},
+		{queryError("commit", &pq.Error{Code: "40001"}), ReasonConflict},
+		{ErrWalletFrozen, ReasonRejected},
+		{fmt.Errorf("step 2: %w", ErrWalletNotFound), ReasonRejected},
+		{queryError("insert", &pq.Error{Code: "23505"}), string(KindConstraint)},
+		{context.Canceled, string(KindCanceled)},
+		{errors.New("boom"), string(KindUnknown)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rollbackReason(wrapError("repository.Test", tt.err)), tt.err.Error())
+	}
+}
+
+func TestTxMetrics_NilIsNoop(t *testing.T) {
+	var m *TxMetrics
+	m.observe("repository.Transfer", time.Now(), nil)
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// GetWalletVersions returns the wallet's history ordered by version. The
+// creation state (version 1, zero balance) isn't stored as a row and is
+// derived from the wallet itself.
+func (r *WalletRepository) GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	op := "repository.GetWalletVersions"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	wallet, err := r.GetWallet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT wallet_id, version, balance, operation_type, amount, created_at
+	FROM wallet_versions
+	WHERE wallet_id = $1
+	ORDER BY version`
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, id)
+	if err != nil {
+		log.Error("error receiving wallet versions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, wrapError(op, queryError("select_versions", err))
+	}
+	defer rows.Close()
+
+	var versions []models.WalletVersion
+	for rows.Next() {
+		var v models.WalletVersion
+		if err := rows.Scan(&v.WalletID, &v.Version, &v.Balance, &v.OperationType, &v.Amount, utc(&v.CreatedAt)); err != nil {
+			log.Error("error scanning wallet version", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, wrapError(op, queryError("select_versions", err))
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(op, queryError("select_versions", err))
+	}
+
+	if len(versions) == 0 || versions[0].Version > 1 {
+		initial := models.WalletVersion{
+			WalletID:      wallet.ID,
+			Version:       1,
+			OperationType: models.OperationTypeCreate,
+			CreatedAt:     wallet.CreatedAt,
+		}
+		versions = append([]models.WalletVersion{initial}, versions...)
+	}
+	return versions, nil
+}
+
+// ListWalletVersions returns the versions of all wallets created by
+// operations in [from, to), oldest first.
+func (r *WalletRepository) ListWalletVersions(ctx context.Context, from, to time.Time) ([]models.WalletVersion, error) {
+	op := "repository.ListWalletVersions"
+	log := r.logger(ctx).With(slog.String("op", op))
+
+	query := `SELECT wallet_id, version, balance, operation_type, amount, created_at
+	FROM wallet_versions
+	WHERE created_at >= $1 AND created_at < $2
+	ORDER BY created_at, wallet_id, version`
+
+	versions := []models.WalletVersion{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, from, to)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		versions = versions[:0]
+		for rows.Next() {
+			var v models.WalletVersion
+			if err := rows.Scan(&v.WalletID, &v.Version, &v.Balance, &v.OperationType, &v.Amount, utc(&v.CreatedAt)); err != nil {
+				return err
+			}
+			versions = append(versions, v)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Error("error listing wallet versions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return versions, nil
+}
+
+// WalletVersionsAfter returns up to limit versions of the wallet after
+// afterVersion, oldest first. Like GetWalletVersions it derives version 1
+// from the wallet when that isn't stored. It reads from the primary, so
+// that a version is found as soon as it is committed.
+func (r *WalletRepository) WalletVersionsAfter(ctx context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error) {
+	op := "repository.WalletVersionsAfter"
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	query := `SELECT wallet_id, version, balance, operation_type, amount, created_at
+	FROM wallet_versions
+	WHERE wallet_id = $1 AND version > $2
+	ORDER BY version
+	LIMIT $3`
+
+	versions := []models.WalletVersion{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, id, afterVersion, limit)
+		if err != nil {
+			return queryError("select_versions", err)
+		}
+		defer rows.Close()
+
+		versions = versions[:0]
+		for rows.Next() {
+			var v models.WalletVersion
+			if err := rows.Scan(&v.WalletID, &v.Version, &v.Balance, &v.OperationType, &v.Amount, utc(&v.CreatedAt)); err != nil {
+				return queryError("select_versions", err)
+			}
+			versions = append(versions, v)
+		}
+		return queryError("select_versions", rows.Err())
+	})
+	if err != nil {
+		log.Error("error listing wallet versions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	// An empty page may just mean nothing changed; tell it apart from a
+	// wallet that doesn't exist.
+	if afterVersion >= 1 && len(versions) > 0 {
+		return versions, nil
+	}
+	wallet, err := r.GetWallet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if afterVersion < 1 && (len(versions) == 0 || versions[0].Version > 1) {
+		initial := models.WalletVersion{
+			WalletID:      wallet.ID,
+			Version:       1,
+			OperationType: models.OperationTypeCreate,
+			CreatedAt:     wallet.CreatedAt,
+		}
+		versions = append([]models.WalletVersion{initial}, versions...)
+		if len(versions) > limit {
+			versions = versions[:limit]
+		}
+	}
+	return versions, nil
+}
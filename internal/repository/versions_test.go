@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWalletVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	created := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(`^SELECT id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant, promo_balance, held_balance, credit_limit FROM wallets WHERE id = \$1$`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 70, created, time.Now(), 3)...))
+	mock.ExpectQuery(`FROM wallet_versions`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "version", "balance", "operation_type", "amount", "created_at"}).
+			AddRow(testID, 2, 100, "DEPOSIT", 100, time.Now()).
+			AddRow(testID, 3, 70, "WITHDRAW", 30, time.Now()))
+
+	versions, err := repo.GetWalletVersions(context.Background(), testID)
+
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, models.OperationTypeCreate, versions[0].OperationType)
+	assert.Equal(t, created.UTC(), versions[0].CreatedAt)
+	assert.Equal(t, int64(70), versions[2].Balance)
+	assert.Equal(t, models.OperationTypeWithdraw, versions[2].OperationType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetWalletVersions_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	mock.ExpectQuery(`^SELECT`).WithArgs(testID).WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err = repo.GetWalletVersions(context.Background(), testID)
+
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestListWalletVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(`FROM wallet_versions\s+WHERE created_at >= \$1 AND created_at < \$2`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_id", "version", "balance", "operation_type", "amount", "created_at"}).
+			AddRow(testID, 2, 100, "DEPOSIT", 100, from.Add(time.Hour)))
+
+	versions, err := repo.ListWalletVersions(context.Background(), from, to)
+
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, models.OperationTypeDeposit, versions[0].OperationType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWalletVersionsAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	created := time.Now().Add(-time.Hour)
+	versionCols := []string{"wallet_id", "version", "balance", "operation_type", "amount", "created_at"}
+
+	mock.ExpectQuery(`FROM wallet_versions\s+WHERE wallet_id = \$1 AND version > \$2`).
+		WithArgs(testID, 2, 10).
+		WillReturnRows(sqlmock.NewRows(versionCols).
+			AddRow(testID, 3, 70, "WITHDRAW", 30, time.Now()))
+
+	versions, err := repo.WalletVersionsAfter(context.Background(), testID, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 3, versions[0].Version)
+
+	// Replaying from the start derives version 1 from the wallet.
+	mock.ExpectQuery(`FROM wallet_versions`).
+		WithArgs(testID, 0, 10).
+		WillReturnRows(sqlmock.NewRows(versionCols))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).AddRow(walletRow(testID, 0, created, created, 1)...))
+
+	versions, err = repo.WalletVersionsAfter(context.Background(), testID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, models.OperationTypeCreate, versions[0].OperationType)
+	assert.Equal(t, created.UTC(), versions[0].CreatedAt)
+
+	// Nothing new on an unknown wallet is an error.
+	mock.ExpectQuery(`FROM wallet_versions`).
+		WithArgs(testID, 5, 10).
+		WillReturnRows(sqlmock.NewRows(versionCols))
+	mock.ExpectQuery(`FROM wallets WHERE id = \$1`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols))
+
+	_, err = repo.WalletVersionsAfter(context.Background(), testID, 5, 10)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,63 +4,117 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
+	"wallet-service/internal/ledger"
 	"wallet-service/internal/models"
+	"wallet-service/internal/requestid"
 
 	"github.com/google/uuid"
 )
 
 var (
 	ErrWalletNotFound         = errors.New("wallet not found")
-	ErrInsufficientFunds      = errors.New("insufficient funds")
-	ErrConcurrentModification = errors.New("concurrent modification detected")
-	ErrUnknownOperationType   = errors.New("unknown operation type")
+	ErrInsufficientFunds      = ledger.ErrInsufficientFunds
+	ErrConcurrentModification = fmt.Errorf("%w: concurrent modification detected", ErrRetryable)
+	ErrUnknownOperationType   = ledger.ErrUnknownOperationType
+	ErrWalletFrozen           = ledger.ErrWalletFrozen
+	ErrWalletClosed           = ledger.ErrWalletClosed
 )
 
+// walletColumns is the column list matching scanWallet.
+const walletColumns = `id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant, promo_balance, held_balance, credit_limit`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWallet(row rowScanner, w *models.Wallet) error {
+	return row.Scan(&w.ID, &w.Balance, utc(&w.CreatedAt), utc(&w.UpdatedAt), &w.Version, &w.OwnerID, &w.Currency,
+		&w.Status, &w.Label, &w.Tenant, &w.PromoBalance, &w.HeldBalance, &w.CreditLimit)
+}
+
 type WalletRepository struct {
 	db  *sql.DB
 	log *slog.Logger
+
+	reconnect ReconnectPolicy
+	failover  FailoverStats
+	flush     func()
+
+	replicas      []*replica
+	maxReplicaLag time.Duration
+	nextReplica   atomic.Uint64
+
+	txMetrics *TxMetrics
+
+	// auditPartitions holds the months whose audit_events partition is
+	// known to exist.
+	auditPartitions sync.Map
 }
 
-func NewWalletRepository(db *sql.DB, log *slog.Logger) *WalletRepository {
-	return &WalletRepository{
-		db:  db,
-		log: log,
+type Option func(*WalletRepository)
+
+// WithReconnectPolicy overrides the default failover reconnect policy.
+func WithReconnectPolicy(p ReconnectPolicy) Option {
+	return func(r *WalletRepository) {
+		r.reconnect = p
 	}
 }
 
-func (r *WalletRepository) CreateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+func NewWalletRepository(db *sql.DB, log *slog.Logger, opts ...Option) *WalletRepository {
+	r := &WalletRepository{
+		db:        db,
+		log:       log,
+		reconnect: defaultReconnectPolicy,
+	}
+	r.flush = r.flushPool
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *WalletRepository) CreateWallet(ctx context.Context, id uuid.UUID, params models.CreateWalletRequest) (*models.Wallet, error) {
 	op := "repository.CreateWallet"
-	log := r.log.With(slog.String("op", op), slog.String("wallet_id", id.String()))
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
 
 	wallet := &models.Wallet{
 		ID:        id,
 		Balance:   0,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 		Version:   1,
+		OwnerID:   params.OwnerID,
+		Currency:  params.Currency,
+		Status:    models.WalletStatusActive,
+		Label:     params.Label,
+		Tenant:    params.Tenant,
 	}
 
-	query := `INSERT INTO wallets (id, balance, created_at, updated_at, version) 
-				 VALUES ($1, $2, $3, $4, $5) 
-				 RETURNING id, balance, created_at, updated_at, version`
-
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
-		wallet.ID,
-		wallet.Balance,
-		wallet.CreatedAt,
-		wallet.UpdatedAt,
-		wallet.Version,
-	).Scan(
-		&wallet.ID,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-		&wallet.Version,
-	)
+	query := `INSERT INTO wallets (id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant) 
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+				 RETURNING ` + walletColumns
+
+	err := r.withReconnect(ctx, op, func() error {
+		return queryError("insert_wallet", scanWallet(r.conn(ctx).QueryRowContext(
+			ctx,
+			query,
+			wallet.ID,
+			wallet.Balance,
+			wallet.CreatedAt,
+			wallet.UpdatedAt,
+			wallet.Version,
+			wallet.OwnerID,
+			wallet.Currency,
+			wallet.Status,
+			wallet.Label,
+			wallet.Tenant,
+		), wallet))
+	})
 
 	if err != nil {
 		log.Error("unexpected error while creating wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
@@ -71,17 +125,13 @@ func (r *WalletRepository) CreateWallet(ctx context.Context, id uuid.UUID) (*mod
 
 func (r *WalletRepository) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
 	op := "repository.GetWallet"
-	log := r.log.With(slog.String("op", op), slog.String("wallet_id", id.String()))
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
 
-	query := `SELECT id, balance, created_at, updated_at, version FROM wallets WHERE id = $1`
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = $1`
 	wallet := &models.Wallet{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&wallet.ID,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-		&wallet.Version,
-	)
+	err := r.withReconnect(ctx, op, func() error {
+		return queryError("select_wallet", scanWallet(r.reader(ctx).QueryRowContext(ctx, query, id), wallet))
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Error("No rows returned", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
@@ -94,97 +144,724 @@ func (r *WalletRepository) GetWallet(ctx context.Context, id uuid.UUID) (*models
 	return wallet, nil
 }
 
+// GetWalletBalance reads only the balance of a wallet, for the hot balance
+// read path.
+func (r *WalletRepository) GetWalletBalance(ctx context.Context, id uuid.UUID) (*models.WalletBalance, error) {
+	op := "repository.GetWalletBalance"
+
+	balance := &models.WalletBalance{WalletID: id}
+	err := r.withReconnect(ctx, op, func() error {
+		return queryError("select_balance", r.reader(ctx).QueryRowContext(ctx,
+			`SELECT balance, balance + credit_limit - held_balance FROM wallets WHERE id = $1`, id).Scan(&balance.Balance, &balance.Available))
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWalletNotFound
+		}
+		r.logger(ctx).Error("error receiving wallet balance", slog.String("op", op), slog.String("wallet_id", id.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return balance, nil
+}
+
 func (r *WalletRepository) UpdateWalletBalance(ctx context.Context, id uuid.UUID, amount int64,
 	operation models.OperationType) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := r.withReconnect(ctx, "repository.UpdateWalletBalance", func() error {
+		var err error
+		wallet, err = r.updateWalletBalance(ctx, id, amount, operation)
+		return err
+	})
+	return wallet, err
+}
+
+func (r *WalletRepository) updateWalletBalance(ctx context.Context, id uuid.UUID, amount int64,
+	operation models.OperationType) (_ *models.Wallet, err error) {
 	op := "repository.UpdateWalletBalance"
-	log := r.log.With(slog.String("op", op), slog.String("wallet_id", id.String()))
+	log := r.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
 
 	log.Debug("Starting transaction")
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+	tx, err := r.beginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
 		log.Error("transaction start error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-		return nil, err
+		return nil, queryError("begin", err)
 	}
 
+	defer func(start time.Time) { r.txMetrics.observe(op, start, err) }(time.Now())
 	defer tx.Rollback()
 
-	query := `SELECT id, balance, created_at, updated_at, version FROM wallets WHERE id = $1 FOR UPDATE`
-
-	wallet := models.Wallet{}
-	err = tx.QueryRowContext(ctx, query, id).Scan(
-		&wallet.ID, &wallet.Balance, &wallet.CreatedAt, &wallet.UpdatedAt, &wallet.Version,
-	)
-
+	wallet, err := lockWallet(ctx, tx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Error("wallet not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-			return nil, ErrWalletNotFound
+		if errors.Is(err, ErrWalletNotFound) {
+			log.Error("wallet not found")
+			return nil, err
 		}
 		log.Error("error receiving wallet data", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, err
 	}
 
-	newBalance := wallet.Balance
-	switch operation {
-	case models.OperationTypeWithdraw:
-		if wallet.Balance < amount {
-			log.Error("insufficient funds to be debited")
-			return nil, ErrInsufficientFunds
-		}
-		newBalance -= amount
-	case models.OperationTypeDeposit:
-		newBalance += amount
-	default:
-		log.Error("unknown operation type")
-		return nil, ErrUnknownOperationType
-	}
-
-	updateQuery := `UPDATE wallets SET balance = $1, updated_at = $2, version = version + 1
-	WHERE id = $3 AND version = $4
-	RETURNING id, balance, created_at, updated_at, version`
-
-	updatedWallet := &models.Wallet{}
-	err = tx.QueryRowContext(
-		ctx,
-		updateQuery,
-		newBalance,
-		time.Now(),
-		id,
-		wallet.Version,
-	).Scan(
-		&updatedWallet.ID,
-		&updatedWallet.Balance,
-		&updatedWallet.CreatedAt,
-		&updatedWallet.UpdatedAt,
-		&updatedWallet.Version,
-	)
+	updatedWallet, err := r.applyOperation(ctx, tx, log, wallet, amount, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, queryError("commit", err)
+	}
+	return updatedWallet, nil
+}
 
+// applyOperation applies one operation to a wallet row already locked by
+// tx: it checks it against the ledger rules, stores the new balances and
+// records the version in the wallet's history.
+func (r *WalletRepository) applyOperation(ctx context.Context, tx querier, log *slog.Logger, wallet *models.Wallet,
+	amount int64, operation models.OperationType) (*models.Wallet, error) {
+	change, err := ledger.Apply(*wallet, amount, operation)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Error("detected competitive modification", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-			return nil, ErrConcurrentModification
+		log.Warn("operation rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	updatedWallet, err := saveBalance(ctx, tx, wallet, change)
+	if err != nil {
+		if errors.Is(err, ErrConcurrentModification) {
+			log.Error("detected competitive modification")
+			return nil, err
 		}
 		log.Error("Error updating the wallet balance", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		log.Error("transaction commit error", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	err = appendVersion(ctx, tx, models.WalletVersion{
+		WalletID:      updatedWallet.ID,
+		Version:       updatedWallet.Version,
+		Balance:       updatedWallet.Balance,
+		OperationType: operation,
+		Amount:        change.Amount,
+		CreatedAt:     updatedWallet.UpdatedAt,
+	})
+	if err != nil {
+		log.Error("error recording wallet version", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, err
 	}
+
+	if change.PromoSpent > 0 {
+		if err := consumePromoCredits(ctx, tx, wallet.ID, change.PromoSpent); err != nil {
+			log.Error("error consuming promo credits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, queryError("consume_promo_credits", err)
+		}
+	}
 	return updatedWallet, nil
 }
 
-func (r *WalletRepository) CreateTabeIfNotExists(ctx context.Context) error {
+// createTables creates the schema idempotently, mirroring the migrations.
+func createTables(ctx context.Context, tx *sql.Tx) error {
 	query := `CREATE TABLE IF NOT EXISTS wallets (
 					id UUID PRIMARY KEY,
 					balance BIGINT NOT NULL DEFAULT 0,
-					created_at TIMESTAMP NOT NULL,
-					updated_at TIMESTAMP NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					updated_at TIMESTAMPTZ NOT NULL,
 					version INTEGER NOT NULL DEFAULT 1
 				)`
-	_, err := r.db.ExecContext(ctx, query)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	columnsQuery := `ALTER TABLE wallets
+					ADD COLUMN IF NOT EXISTS owner_id UUID,
+					ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD',
+					ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ACTIVE',
+					ADD COLUMN IF NOT EXISTS label TEXT NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS promo_balance BIGINT NOT NULL DEFAULT 0,
+					ADD COLUMN IF NOT EXISTS held_balance BIGINT NOT NULL DEFAULT 0`
+	if _, err := tx.ExecContext(ctx, columnsQuery); err != nil {
+		return err
+	}
+
+	ownerIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_owner_id_currency_idx ON wallets (owner_id, currency)`
+	if _, err := tx.ExecContext(ctx, ownerIndexQuery); err != nil {
+		return err
+	}
+
+	versionsQuery := `CREATE TABLE IF NOT EXISTS wallet_versions (
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					version INTEGER NOT NULL,
+					balance BIGINT NOT NULL,
+					operation_type TEXT NOT NULL,
+					amount BIGINT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					PRIMARY KEY (wallet_id, version)
+				)`
+	if _, err := tx.ExecContext(ctx, versionsQuery); err != nil {
+		return err
+	}
+
+	seqQuery := `ALTER TABLE wallet_versions ADD COLUMN IF NOT EXISTS seq BIGSERIAL`
+	if _, err := tx.ExecContext(ctx, seqQuery); err != nil {
+		return err
+	}
+
+	seqIndexQuery := `CREATE UNIQUE INDEX IF NOT EXISTS wallet_versions_seq_idx ON wallet_versions (seq)`
+	if _, err := tx.ExecContext(ctx, seqIndexQuery); err != nil {
+		return err
+	}
+
+	screeningQuery := `CREATE TABLE IF NOT EXISTS screening_hits (
+					id UUID PRIMARY KEY,
+					subject_kind TEXT NOT NULL,
+					subject_id TEXT NOT NULL,
+					list TEXT NOT NULL,
+					reason TEXT NOT NULL,
+					action TEXT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL
+				)`
+	if _, err := tx.ExecContext(ctx, screeningQuery); err != nil {
+		return err
+	}
+
+	mandatesQuery := `CREATE TABLE IF NOT EXISTS mandates (
+					id UUID PRIMARY KEY,
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					counterparty TEXT NOT NULL,
+					amount_limit BIGINT NOT NULL,
+					max_debits INTEGER NOT NULL DEFAULT 0,
+					period TEXT NOT NULL,
+					status TEXT NOT NULL DEFAULT 'ACTIVE',
+					created_at TIMESTAMPTZ NOT NULL,
+					revoked_at TIMESTAMPTZ
+				)`
+	if _, err := tx.ExecContext(ctx, mandatesQuery); err != nil {
+		return err
+	}
+
+	mandateDebitsQuery := `CREATE TABLE IF NOT EXISTS mandate_debits (
+					id UUID PRIMARY KEY,
+					mandate_id UUID NOT NULL REFERENCES mandates (id),
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					counterparty TEXT NOT NULL,
+					amount BIGINT NOT NULL,
+					balance BIGINT NOT NULL,
+					version INTEGER NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL
+				)`
+	if _, err := tx.ExecContext(ctx, mandateDebitsQuery); err != nil {
+		return err
+	}
+
+	promoQuery := `CREATE TABLE IF NOT EXISTS promo_credits (
+					id UUID PRIMARY KEY,
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					amount BIGINT NOT NULL,
+					remaining BIGINT NOT NULL,
+					expires_at TIMESTAMPTZ NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					expired_at TIMESTAMPTZ
+				)`
+	if _, err := tx.ExecContext(ctx, promoQuery); err != nil {
+		return err
+	}
+
+	rewardWalletsQuery := `CREATE TABLE IF NOT EXISTS reward_wallets (
+					wallet_id UUID PRIMARY KEY REFERENCES wallets (id),
+					rewards_wallet_id UUID NOT NULL REFERENCES wallets (id)
+				)`
+	if _, err := tx.ExecContext(ctx, rewardWalletsQuery); err != nil {
+		return err
+	}
+
+	rewardAccrualsQuery := `CREATE TABLE IF NOT EXISTS reward_accruals (
+					transaction_id TEXT PRIMARY KEY,
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					rewards_wallet_id UUID NOT NULL REFERENCES wallets (id),
+					rule TEXT NOT NULL,
+					amount BIGINT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL
+				)`
+	if _, err := tx.ExecContext(ctx, rewardAccrualsQuery); err != nil {
+		return err
+	}
+
+	suspenseWalletsQuery := `CREATE TABLE IF NOT EXISTS suspense_wallets (
+					currency TEXT PRIMARY KEY,
+					wallet_id UUID NOT NULL REFERENCES wallets (id)
+				)`
+	if _, err := tx.ExecContext(ctx, suspenseWalletsQuery); err != nil {
+		return err
+	}
+
+	suspenseCasesQuery := `CREATE TABLE IF NOT EXISTS suspense_cases (
+					id UUID PRIMARY KEY,
+					suspense_wallet_id UUID NOT NULL REFERENCES wallets (id),
+					amount BIGINT NOT NULL,
+					currency TEXT NOT NULL,
+					reference TEXT NOT NULL,
+					description TEXT NOT NULL DEFAULT '',
+					reason TEXT NOT NULL,
+					status TEXT NOT NULL DEFAULT 'OPEN',
+					created_at TIMESTAMPTZ NOT NULL,
+					credit_version INTEGER NOT NULL,
+					resolved_at TIMESTAMPTZ,
+					target_wallet_id UUID REFERENCES wallets (id),
+					release_version INTEGER,
+					target_version INTEGER,
+					resolution_note TEXT NOT NULL DEFAULT ''
+				)`
+	if _, err := tx.ExecContext(ctx, suspenseCasesQuery); err != nil {
+		return err
+	}
+
+	disputesQuery := `CREATE TABLE IF NOT EXISTS disputes (
+					id UUID PRIMARY KEY,
+					wallet_id UUID NOT NULL REFERENCES wallets (id),
+					version INTEGER NOT NULL,
+					operation_type TEXT NOT NULL,
+					amount BIGINT NOT NULL,
+					hold_amount BIGINT NOT NULL,
+					reason TEXT NOT NULL,
+					status TEXT NOT NULL DEFAULT 'OPEN',
+					deadline TIMESTAMPTZ NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					resolved_at TIMESTAMPTZ,
+					reversal_version INTEGER,
+					note TEXT NOT NULL DEFAULT '',
+					UNIQUE (wallet_id, version)
+				)`
+	if _, err := tx.ExecContext(ctx, disputesQuery); err != nil {
+		return err
+	}
+
+	timestamptzQuery := `DO $$
+				DECLARE c RECORD;
+				BEGIN
+					FOR c IN SELECT table_name, column_name FROM information_schema.columns
+						WHERE table_schema = current_schema() AND data_type = 'timestamp without time zone'
+						AND table_name IN ('wallets', 'wallet_versions', 'screening_hits', 'mandates', 'mandate_debits',
+							'promo_credits', 'reward_accruals', 'suspense_cases', 'disputes') LOOP
+						EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE ''UTC''',
+							c.table_name, c.column_name, c.column_name);
+					END LOOP;
+				END $$`
+	if _, err := tx.ExecContext(ctx, timestamptzQuery); err != nil {
+		return err
+	}
+
+	constraintsQuery := `DO $$
+				DECLARE c RECORD;
+				BEGIN
+					FOR c IN SELECT * FROM (VALUES
+						('wallets', 'wallets_balance_check', 'CHECK (balance >= 0)'),
+						('wallets', 'wallets_promo_balance_check', 'CHECK (promo_balance >= 0 AND promo_balance <= balance)'),
+						('wallets', 'wallets_held_balance_check', 'CHECK (held_balance >= 0)'),
+						('wallets', 'wallets_version_check', 'CHECK (version >= 1)'),
+						('wallets', 'wallets_status_check', 'CHECK (status IN (''ACTIVE'', ''FROZEN'', ''DORMANT'', ''CLOSED''))'),
+						('wallets', 'wallets_currency_check', 'CHECK (currency ~ ''^[A-Z]{3}$'')'),
+						('wallet_versions', 'wallet_versions_amount_check', 'CHECK (amount >= 0)')
+					) AS t (tbl, name, def) LOOP
+						IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = c.name) THEN
+							EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I %s', c.tbl, c.name, c.def);
+						END IF;
+					END LOOP;
+				END $$`
+	if _, err := tx.ExecContext(ctx, constraintsQuery); err != nil {
+		return err
+	}
+
+	// Schemas created before dormancy or closure have a status check
+	// without DORMANT or CLOSED.
+	statusCheckQuery := `DO $$
+				BEGIN
+					IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'wallets_status_check'
+						AND pg_get_constraintdef(oid) LIKE '%CLOSED%') THEN
+						ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_status_check;
+						ALTER TABLE wallets ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'DORMANT', 'CLOSED'));
+					END IF;
+				END $$`
+	if _, err := tx.ExecContext(ctx, statusCheckQuery); err != nil {
+		return err
+	}
+
+	idleIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_active_updated_at_idx ON wallets (updated_at, id) WHERE status = 'ACTIVE'`
+	if _, err := tx.ExecContext(ctx, idleIndexQuery); err != nil {
+		return err
+	}
+
+	closureQuery := `ALTER TABLE wallets
+					ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ,
+					ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ`
+	if _, err := tx.ExecContext(ctx, closureQuery); err != nil {
+		return err
+	}
+
+	purgeIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_closed_at_idx ON wallets (closed_at) WHERE status = 'CLOSED' AND purged_at IS NULL`
+	if _, err := tx.ExecContext(ctx, purgeIndexQuery); err != nil {
+		return err
+	}
+
+	createdAtIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_created_at_id_idx ON wallets (created_at, id)`
+	if _, err := tx.ExecContext(ctx, createdAtIndexQuery); err != nil {
+		return err
+	}
+
+	versionsCreatedAtIndexQuery := `CREATE INDEX IF NOT EXISTS wallet_versions_created_at_idx ON wallet_versions (created_at, wallet_id, version)`
+	if _, err := tx.ExecContext(ctx, versionsCreatedAtIndexQuery); err != nil {
+		return err
+	}
+
+	statusIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_status_currency_id_idx ON wallets (status, currency, id)`
+	if _, err := tx.ExecContext(ctx, statusIndexQuery); err != nil {
+		return err
+	}
+
+	balanceIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_currency_balance_idx ON wallets (currency, balance)`
+	if _, err := tx.ExecContext(ctx, balanceIndexQuery); err != nil {
+		return err
+	}
+
+	searchIndexQueries := []string{
+		`CREATE INDEX IF NOT EXISTS wallets_id_text_idx ON wallets ((id::text) text_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS wallets_owner_id_text_idx ON wallets ((owner_id::text) text_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS wallets_label_lower_idx ON wallets ((lower(label)) text_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS wallets_tenant_lower_idx ON wallets ((lower(tenant)) text_pattern_ops)`,
+	}
+	for _, q := range searchIndexQueries {
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+
+	jobsQuery := `CREATE TABLE IF NOT EXISTS jobs (
+		id UUID PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload JSONB NOT NULL,
+		state TEXT NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		owner TEXT NOT NULL DEFAULT '',
+		total BIGINT NOT NULL DEFAULT 0,
+		processed BIGINT NOT NULL DEFAULT 0,
+		failed BIGINT NOT NULL DEFAULT 0,
+		checkpoint JSONB,
+		error TEXT NOT NULL DEFAULT '',
+		report_type TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ
+	)`
+	if _, err := tx.ExecContext(ctx, jobsQuery); err != nil {
+		return err
+	}
+
+	jobsUnfinishedIndexQuery := `CREATE INDEX IF NOT EXISTS jobs_unfinished_idx ON jobs (created_at) WHERE state IN ('queued', 'running')`
+	if _, err := tx.ExecContext(ctx, jobsUnfinishedIndexQuery); err != nil {
+		return err
+	}
+
+	jobsKindIndexQuery := `CREATE INDEX IF NOT EXISTS jobs_kind_created_at_idx ON jobs (kind, created_at DESC)`
+	if _, err := tx.ExecContext(ctx, jobsKindIndexQuery); err != nil {
+		return err
+	}
+
+	jobReportPartsQuery := `CREATE TABLE IF NOT EXISTS job_report_parts (
+		job_id UUID NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+		seq INT NOT NULL,
+		data BYTEA NOT NULL,
+		PRIMARY KEY (job_id, seq)
+	)`
+	if _, err := tx.ExecContext(ctx, jobReportPartsQuery); err != nil {
+		return err
+	}
+
+	transactionNotesQuery := `CREATE TABLE IF NOT EXISTS transaction_notes (
+		id UUID PRIMARY KEY,
+		seq BIGINT NOT NULL REFERENCES wallet_versions (seq),
+		author TEXT NOT NULL,
+		body TEXT NOT NULL,
+		attachments JSONB NOT NULL DEFAULT '[]',
+		created_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, transactionNotesQuery); err != nil {
+		return err
+	}
+
+	transactionNotesIndexQuery := `CREATE INDEX IF NOT EXISTS transaction_notes_seq_idx ON transaction_notes (seq, created_at)`
+	if _, err := tx.ExecContext(ctx, transactionNotesIndexQuery); err != nil {
+		return err
+	}
+
+	idempotencyKeysQuery := `CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		status INT,
+		content_type TEXT NOT NULL DEFAULT '',
+		body BYTEA NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, idempotencyKeysQuery); err != nil {
+		return err
+	}
+
+	idempotencyKeysIndexQuery := `CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at)`
+	if _, err := tx.ExecContext(ctx, idempotencyKeysIndexQuery); err != nil {
+		return err
+	}
+
+	apiKeysQuery := `CREATE TABLE IF NOT EXISTS api_keys (
+		hash TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		routes TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		revoked_at TIMESTAMPTZ
+	)`
+	if _, err := tx.ExecContext(ctx, apiKeysQuery); err != nil {
+		return err
+	}
+
+	apiKeyRoleQuery := `ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'`
+	if _, err := tx.ExecContext(ctx, apiKeyRoleQuery); err != nil {
+		return err
+	}
+
+	walletImportsQuery := `CREATE TABLE IF NOT EXISTS wallet_imports (
+		external_id TEXT PRIMARY KEY,
+		wallet_id UUID NOT NULL UNIQUE REFERENCES wallets (id),
+		metadata JSONB NOT NULL DEFAULT '{}',
+		imported_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, walletImportsQuery); err != nil {
+		return err
+	}
+
+	balanceSortIndexQuery := `CREATE INDEX IF NOT EXISTS wallets_balance_id_idx ON wallets (balance, id)`
+	if _, err := tx.ExecContext(ctx, balanceSortIndexQuery); err != nil {
+		return err
+	}
+
+	auditEventsQuery := `CREATE TABLE IF NOT EXISTS audit_events (
+		id UUID NOT NULL,
+		wallet_id UUID,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		occurred_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (occurred_at, id)
+	) PARTITION BY RANGE (occurred_at)`
+	if _, err := tx.ExecContext(ctx, auditEventsQuery); err != nil {
+		return err
+	}
+
+	auditEventsIndexQuery := `CREATE INDEX IF NOT EXISTS audit_events_wallet_id_idx ON audit_events (wallet_id, occurred_at, id)`
+	if _, err := tx.ExecContext(ctx, auditEventsIndexQuery); err != nil {
+		return err
+	}
+
+	legalHoldsQuery := `CREATE TABLE IF NOT EXISTS legal_holds (
+		wallet_id UUID PRIMARY KEY,
+		reason TEXT NOT NULL,
+		placed_by TEXT NOT NULL DEFAULT '',
+		placed_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, legalHoldsQuery); err != nil {
+		return err
+	}
+
+	holdsQuery := `CREATE TABLE IF NOT EXISTS holds (
+		id UUID PRIMARY KEY,
+		wallet_id UUID NOT NULL REFERENCES wallets (id),
+		amount BIGINT NOT NULL CHECK (amount > 0),
+		reference TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'PENDING',
+		captured_amount BIGINT NOT NULL DEFAULT 0,
+		capture_version INTEGER,
+		created_at TIMESTAMPTZ NOT NULL,
+		resolved_at TIMESTAMPTZ
+	)`
+	if _, err := tx.ExecContext(ctx, holdsQuery); err != nil {
+		return err
+	}
+
+	holdsIndexQuery := `CREATE INDEX IF NOT EXISTS holds_wallet_id_idx ON holds (wallet_id, created_at)`
+	if _, err := tx.ExecContext(ctx, holdsIndexQuery); err != nil {
+		return err
+	}
+
+	attemptsQuery := `CREATE TABLE IF NOT EXISTS operation_attempts (
+		operation_id TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		op TEXT NOT NULL,
+		error_class TEXT NOT NULL DEFAULT '',
+		duration_ms DOUBLE PRECISION NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, attemptsQuery); err != nil {
+		return err
+	}
+
+	attemptsIndexQuery := `CREATE INDEX IF NOT EXISTS operation_attempts_operation_id_idx ON operation_attempts (operation_id, started_at)`
+	if _, err := tx.ExecContext(ctx, attemptsIndexQuery); err != nil {
+		return err
+	}
+
+	attemptsPurgeIndexQuery := `CREATE INDEX IF NOT EXISTS operation_attempts_started_at_idx ON operation_attempts (started_at)`
+	if _, err := tx.ExecContext(ctx, attemptsPurgeIndexQuery); err != nil {
+		return err
+	}
+
+	webhookSubscriptionsQuery := `CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id UUID PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL DEFAULT '',
+		event_types TEXT[] NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`
+	if _, err := tx.ExecContext(ctx, webhookSubscriptionsQuery); err != nil {
+		return err
+	}
+
+	webhookDeliveriesQuery := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		event_id UUID NOT NULL,
+		subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+		event_type TEXT NOT NULL,
+		payload BYTEA NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		delivered_at TIMESTAMPTZ,
+		PRIMARY KEY (event_id, subscription_id)
+	)`
+	if _, err := tx.ExecContext(ctx, webhookDeliveriesQuery); err != nil {
+		return err
+	}
+
+	webhookDeliveriesDueIndexQuery := `CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING'`
+	if _, err := tx.ExecContext(ctx, webhookDeliveriesDueIndexQuery); err != nil {
+		return err
+	}
+
+	webhookDeliveryAttemptsQuery := `CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+		event_id UUID NOT NULL,
+		subscription_id UUID NOT NULL,
+		event_type TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		duration_ms DOUBLE PRECISION NOT NULL,
+		attempted_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (event_id, subscription_id, attempt),
+		FOREIGN KEY (event_id, subscription_id) REFERENCES webhook_deliveries (event_id, subscription_id) ON DELETE CASCADE
+	)`
+	if _, err := tx.ExecContext(ctx, webhookDeliveryAttemptsQuery); err != nil {
+		return err
+	}
+
+	webhookDeliveryAttemptsIndexQuery := `CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_subscription_idx ON webhook_delivery_attempts (subscription_id, attempted_at)`
+	if _, err := tx.ExecContext(ctx, webhookDeliveryAttemptsIndexQuery); err != nil {
+		return err
+	}
+
+	outboxQuery := `CREATE TABLE IF NOT EXISTS outbox (
+		seq BIGSERIAL PRIMARY KEY,
+		id UUID NOT NULL UNIQUE,
+		wallet_id UUID NOT NULL,
+		event_type TEXT NOT NULL,
+		payload BYTEA NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		published_at TIMESTAMPTZ
+	)`
+	if _, err := tx.ExecContext(ctx, outboxQuery); err != nil {
+		return err
+	}
+
+	outboxUnpublishedIndexQuery := `CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (seq) WHERE published_at IS NULL`
+	if _, err := tx.ExecContext(ctx, outboxUnpublishedIndexQuery); err != nil {
+		return err
+	}
+
+	outboxPublishedIndexQuery := `CREATE INDEX IF NOT EXISTS outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL`
+	if _, err := tx.ExecContext(ctx, outboxPublishedIndexQuery); err != nil {
+		return err
+	}
+
+	snapshotsQuery := `CREATE TABLE IF NOT EXISTS wallet_snapshots (
+		wallet_id UUID NOT NULL REFERENCES wallets (id),
+		version INTEGER NOT NULL,
+		balance BIGINT NOT NULL,
+		promo_balance BIGINT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (wallet_id, version)
+	)`
+	if _, err := tx.ExecContext(ctx, snapshotsQuery); err != nil {
+		return err
+	}
+
+	withdrawalsIndexQuery := `CREATE INDEX IF NOT EXISTS wallet_versions_withdrawals_idx ON wallet_versions (wallet_id, created_at) WHERE operation_type = 'WITHDRAW'`
+	if _, err := tx.ExecContext(ctx, withdrawalsIndexQuery); err != nil {
+		return err
+	}
+
+	webhookFiltersQuery := `ALTER TABLE webhook_subscriptions
+					ADD COLUMN IF NOT EXISTS operation_types TEXT[] NOT NULL DEFAULT '{}',
+					ADD COLUMN IF NOT EXISTS min_amount BIGINT NOT NULL DEFAULT 0,
+					ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT false`
+	if _, err := tx.ExecContext(ctx, webhookFiltersQuery); err != nil {
+		return err
+	}
+
+	// Schemas created before credit limits check that balances, and the
+	// balances versions record, aren't negative.
+	creditLimitQuery := `DO $$
+				BEGIN
+					ALTER TABLE wallets ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0;
+					IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'wallets_balance_check'
+						AND pg_get_constraintdef(oid) LIKE '%credit_limit%') THEN
+						ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_check,
+							DROP CONSTRAINT IF EXISTS wallets_promo_balance_check,
+							DROP CONSTRAINT IF EXISTS wallets_credit_limit_check;
+						ALTER TABLE wallets ADD CONSTRAINT wallets_credit_limit_check CHECK (credit_limit >= 0),
+							ADD CONSTRAINT wallets_balance_check CHECK (balance + credit_limit >= 0),
+							ADD CONSTRAINT wallets_promo_balance_check CHECK (promo_balance >= 0 AND promo_balance <= GREATEST(balance, 0));
+					END IF;
+					ALTER TABLE wallet_versions DROP CONSTRAINT IF EXISTS wallet_versions_balance_check;
+				END $$`
+	if _, err := tx.ExecContext(ctx, creditLimitQuery); err != nil {
+		return err
+	}
+
+	shardTransfersQuery := `CREATE TABLE IF NOT EXISTS shard_transfers (
+		id UUID PRIMARY KEY,
+		from_wallet_id UUID NOT NULL REFERENCES wallets (id),
+		to_wallet_id UUID NOT NULL,
+		amount BIGINT NOT NULL CHECK (amount > 0),
+		status TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		settled_at TIMESTAMPTZ
+	)`
+	if _, err := tx.ExecContext(ctx, shardTransfersQuery); err != nil {
+		return err
+	}
+
+	shardTransfersPendingIndexQuery := `CREATE INDEX IF NOT EXISTS shard_transfers_pending_idx ON shard_transfers (created_at) WHERE status = 'PENDING'`
+	if _, err := tx.ExecContext(ctx, shardTransfersPendingIndexQuery); err != nil {
+		return err
+	}
+
+	shardTransferClaimsQuery := `CREATE TABLE IF NOT EXISTS shard_transfer_claims (
+		transfer_id UUID PRIMARY KEY,
+		outcome TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`
+	_, err := tx.ExecContext(ctx, shardTransferClaimsQuery)
 	return err
 }
+
+// logger returns the repository's logger with the id of the request in
+// ctx.
+func (r *WalletRepository) logger(ctx context.Context) *slog.Logger {
+	return requestid.Logger(ctx, r.log)
+}
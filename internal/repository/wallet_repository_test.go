@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"log/slog"
 	"os"
@@ -18,6 +19,13 @@ import (
 
 var log = slog.New(slog.NewTextHandler(os.Stdin, &slog.HandlerOptions{Level: slog.LevelInfo}))
 
+var walletCols = []string{"id", "balance", "created_at", "updated_at", "version", "owner_id", "currency", "status", "label", "tenant", "promo_balance", "held_balance", "credit_limit"}
+
+// walletRow fills the walletCols row of an active, unowned USD wallet.
+func walletRow(id uuid.UUID, balance, createdAt, updatedAt, version any) []driver.Value {
+	return []driver.Value{id, balance, createdAt, updatedAt, version, uuid.NullUUID{}, "USD", "ACTIVE", "", "", 0, 0, 0}
+}
+
 func TestWalletRepository_CreateWallet_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -35,13 +43,18 @@ func TestWalletRepository_CreateWallet_Success(t *testing.T) {
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			1,
+			uuid.NullUUID{},
+			"USD",
+			models.WalletStatusActive,
+			"",
+			"",
 		).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-				AddRow(testID, 0, now, now, 1),
+			sqlmock.NewRows(walletCols).
+				AddRow(walletRow(testID, 0, now, now, 1)...),
 		)
 
-	wallet, err := repo.CreateWallet(ctx, testID)
+	wallet, err := repo.CreateWallet(ctx, testID, models.CreateWalletRequest{Currency: "USD"})
 
 	require.NoError(t, err)
 	assert.NotNil(t, wallet)
@@ -68,7 +81,7 @@ func TestWalletRepository_CreateWallet_DBError(t *testing.T) {
 	mock.ExpectQuery(`^INSERT INTO wallets`).
 		WillReturnError(sql.ErrConnDone)
 
-	wallet, err := repo.CreateWallet(context.Background(), testID)
+	wallet, err := repo.CreateWallet(context.Background(), testID, models.CreateWalletRequest{})
 
 	// Проверки
 	require.Error(t, err)
@@ -90,7 +103,7 @@ func TestWalletRepository_CreateWallet_ContextCanceled(t *testing.T) {
 	mock.ExpectQuery(`^INSERT INTO wallets`).
 		WillReturnError(context.Canceled)
 
-	wallet, err := repo.CreateWallet(ctx, uuid.New())
+	wallet, err := repo.CreateWallet(ctx, uuid.New(), models.CreateWalletRequest{})
 
 	require.Error(t, err)
 	assert.Nil(t, wallet)
@@ -107,11 +120,11 @@ func TestWalletRepository_GetWallet_Success(t *testing.T) {
 	now := time.Now().UTC()
 
 	// Мокируем успешный SELECT
-	mock.ExpectQuery(`^SELECT id, balance, created_at, updated_at, version FROM wallets WHERE id = \$1$`).
+	mock.ExpectQuery(`^SELECT id, balance, created_at, updated_at, version, owner_id, currency, status, label, tenant, promo_balance, held_balance, credit_limit FROM wallets WHERE id = \$1$`).
 		WithArgs(testID).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-				AddRow(testID, 100, now, now, 2),
+			sqlmock.NewRows(walletCols).
+				AddRow(walletRow(testID, 100, now, now, 2)...),
 		)
 
 	wallet, err := repo.GetWallet(context.Background(), testID)
@@ -143,6 +156,46 @@ func TestWalletRepository_GetWallet_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestWalletRepository_GetWalletBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+
+	mock.ExpectQuery(`^SELECT balance, balance \+ credit_limit - held_balance FROM wallets WHERE id = \$1$`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "available"}).AddRow(1500, 1200))
+
+	balance, err := repo.GetWalletBalance(context.Background(), testID)
+
+	require.NoError(t, err)
+	assert.Equal(t, testID, balance.WalletID)
+	assert.Equal(t, int64(1500), balance.Balance)
+	assert.Equal(t, int64(1200), balance.Available)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWalletRepository_GetWalletBalance_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+
+	mock.ExpectQuery(`^SELECT balance`).
+		WithArgs(testID).
+		WillReturnError(sql.ErrNoRows)
+
+	balance, err := repo.GetWalletBalance(context.Background(), testID)
+
+	require.ErrorIs(t, err, ErrWalletNotFound)
+	assert.Nil(t, balance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestWalletRepository_GetWallet_DBError(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -199,17 +252,21 @@ func TestUpdateWalletBalance_DepositSuccess(t *testing.T) {
 	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
 		WithArgs(testID).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-				AddRow(testID, initialBalance, time.Now(), time.Now(), 1),
+			sqlmock.NewRows(walletCols).
+				AddRow(walletRow(testID, initialBalance, time.Now(), time.Now(), 1)...),
 		)
 
 	mock.ExpectQuery(`UPDATE wallets`).
-		WithArgs(initialBalance+depositAmount, sqlmock.AnyArg(), testID, 1).
+		WithArgs(initialBalance+depositAmount, sqlmock.AnyArg(), testID, 1, 0).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-				AddRow(testID, initialBalance+depositAmount, time.Now(), time.Now(), 2),
+			sqlmock.NewRows(walletCols).
+				AddRow(walletRow(testID, initialBalance+depositAmount, time.Now(), time.Now(), 2)...),
 		)
 
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(testID, 2, initialBalance+depositAmount, models.OperationTypeDeposit, int64(depositAmount), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
 	mock.ExpectCommit()
 
 	result, err := repo.UpdateWalletBalance(
@@ -238,16 +295,20 @@ func TestUpdateWalletBalance_WithdrawSuccess(t *testing.T) {
 
 	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
 		WithArgs(testID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-			AddRow(testID, initialBalance, time.Now(), time.Now(), 1),
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, initialBalance, time.Now(), time.Now(), 1)...),
 		)
 
 	mock.ExpectQuery(`UPDATE wallets`).
-		WithArgs(initialBalance-withdrawAmount, sqlmock.AnyArg(), testID, 1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-			AddRow(testID, initialBalance-withdrawAmount, time.Now(), time.Now(), 2),
+		WithArgs(initialBalance-withdrawAmount, sqlmock.AnyArg(), testID, 1, 0).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, initialBalance-withdrawAmount, time.Now(), time.Now(), 2)...),
 		)
 
+	mock.ExpectExec(`INSERT INTO wallet_versions`).
+		WithArgs(testID, 2, initialBalance-withdrawAmount, models.OperationTypeWithdraw, int64(withdrawAmount), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
 	mock.ExpectCommit()
 
 	result, err := repo.UpdateWalletBalance(
@@ -275,8 +336,8 @@ func TestUpdateWalletBalance_InsufficientFunds(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectQuery(`SELECT .* FOR UPDATE`).
 		WithArgs(testID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "created_at", "updated_at", "version"}).
-			AddRow(testID, initialBalance, time.Now(), time.Now(), 1))
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, initialBalance, time.Now(), time.Now(), 1)...))
 
 	_, err := repo.UpdateWalletBalance(
 		context.Background(),
@@ -289,3 +350,25 @@ func TestUpdateWalletBalance_InsufficientFunds(t *testing.T) {
 	require.ErrorIs(t, err, ErrInsufficientFunds)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestWalletRepository_GetWallet_ReturnsUTC(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	testID := uuid.New()
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	mock.ExpectQuery(`^SELECT`).
+		WithArgs(testID).
+		WillReturnRows(sqlmock.NewRows(walletCols).
+			AddRow(walletRow(testID, 100, created, created, 1)...))
+
+	wallet, err := repo.GetWallet(context.Background(), testID)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, wallet.CreatedAt.Location())
+	assert.Equal(t, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), wallet.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// EnqueueWebhookEvent queues e for delivery to every subscription of its
+// type whose filter it passes, paused or not, and returns the number of
+// deliveries queued. Queueing an event twice is a no-op.
+func (r *WalletRepository) EnqueueWebhookEvent(ctx context.Context, e models.WebhookEvent) (int64, error) {
+	op := "repository.EnqueueWebhookEvent"
+
+	query := `INSERT INTO webhook_deliveries (event_id, subscription_id, event_type, payload, status, next_attempt_at, created_at)
+	SELECT $1, id, $2, $3, 'PENDING', $4, $4 FROM webhook_subscriptions WHERE $2 = ANY (event_types)
+		AND ($5 = '' OR ((cardinality(operation_types) = 0 OR $5 = ANY (operation_types)) AND $6 >= min_amount))
+	ON CONFLICT DO NOTHING`
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, query, e.ID, e.Type, e.Payload, e.CreatedAt.UTC(), string(e.OperationType), e.Amount)
+		if err != nil {
+			return queryError("insert_webhook_deliveries", err)
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error enqueueing webhook event", slog.String("op", op), slog.String("event_id", e.ID.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries due at now
+// to subscriptions that aren't paused, oldest first, and postpones them to
+// now+lease so that other instances skip them while they are attempted. A
+// delivery whose attempt is never recorded is retried once the lease is
+// over.
+func (r *WalletRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	op := "repository.ClaimWebhookDeliveries"
+
+	query := `WITH due AS (
+		SELECT d.event_id, d.subscription_id FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = 'PENDING' AND d.next_attempt_at <= $1 AND NOT s.paused
+		ORDER BY d.next_attempt_at
+		LIMIT $3
+		FOR UPDATE OF d SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d SET next_attempt_at = $2
+	FROM due, webhook_subscriptions s
+	WHERE d.event_id = due.event_id AND d.subscription_id = due.subscription_id AND s.id = d.subscription_id
+	RETURNING d.event_id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+		d.last_error, d.created_at, d.delivered_at, s.url, s.secret`
+
+	deliveries := []models.WebhookDelivery{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.conn(ctx).QueryContext(ctx, query, now.UTC(), now.Add(lease).UTC(), limit)
+		if err != nil {
+			return queryError("claim_webhook_deliveries", err)
+		}
+		defer rows.Close()
+
+		deliveries = deliveries[:0]
+		for rows.Next() {
+			var d models.WebhookDelivery
+			var deliveredAt sql.NullTime
+			if err := rows.Scan(&d.EventID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+				utc(&d.NextAttemptAt), &d.LastError, utc(&d.CreatedAt), &deliveredAt, &d.URL, &d.Secret); err != nil {
+				return queryError("claim_webhook_deliveries", err)
+			}
+			d.DeliveredAt = utcPtr(deliveredAt)
+			deliveries = append(deliveries, d)
+		}
+		return queryError("claim_webhook_deliveries", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error claiming webhook deliveries", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt logs attempt a and moves its delivery to
+// status: DELIVERED, FAILED for good, or PENDING to be retried at
+// nextAttemptAt.
+func (r *WalletRepository) RecordWebhookDeliveryAttempt(ctx context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	op := "repository.RecordWebhookDeliveryAttempt"
+
+	query := `WITH attempt AS (
+		INSERT INTO webhook_delivery_attempts (event_id, subscription_id, event_type, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	)
+	UPDATE webhook_deliveries
+	SET attempts = $4, status = $9, next_attempt_at = $10, last_error = $6,
+		delivered_at = CASE WHEN $9::text = 'DELIVERED' THEN $8::timestamptz END
+	WHERE event_id = $1 AND subscription_id = $2`
+
+	err := r.withReconnect(ctx, op, func() error {
+		_, err := r.conn(ctx).ExecContext(ctx, query, a.EventID, a.SubscriptionID, a.EventType, a.Attempt, a.StatusCode,
+			a.Error, a.DurationMs, a.AttemptedAt.UTC(), string(status), nextAttemptAt.UTC())
+		return queryError("record_webhook_delivery_attempt", err)
+	})
+	if err != nil {
+		r.logger(ctx).Error("error recording webhook delivery attempt", slog.String("op", op),
+			slog.String("event_id", a.EventID.String()), slog.String("subscription_id", a.SubscriptionID.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	return nil
+}
+
+// ListWebhookDeliveryAttempts returns the latest limit delivery attempts
+// of a subscription, newest first.
+func (r *WalletRepository) ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error) {
+	op := "repository.ListWebhookDeliveryAttempts"
+
+	query := `SELECT event_id, subscription_id, event_type, attempt, status_code, error, duration_ms, attempted_at
+	FROM webhook_delivery_attempts WHERE subscription_id = $1
+	ORDER BY attempted_at DESC, attempt DESC
+	LIMIT $2`
+
+	attempts := []models.WebhookDeliveryAttempt{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query, subscriptionID, limit)
+		if err != nil {
+			return queryError("select_webhook_delivery_attempts", err)
+		}
+		defer rows.Close()
+
+		attempts = attempts[:0]
+		for rows.Next() {
+			var a models.WebhookDeliveryAttempt
+			if err := rows.Scan(&a.EventID, &a.SubscriptionID, &a.EventType, &a.Attempt, &a.StatusCode, &a.Error,
+				&a.DurationMs, utc(&a.AttemptedAt)); err != nil {
+				return queryError("select_webhook_delivery_attempts", err)
+			}
+			attempts = append(attempts, a)
+		}
+		return queryError("select_webhook_delivery_attempts", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error listing webhook delivery attempts", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// WebhookSubscriptionStats sums up the deliveries to a subscription and
+// their attempts.
+func (r *WalletRepository) WebhookSubscriptionStats(ctx context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscriptionStats, error) {
+	op := "repository.WebhookSubscriptionStats"
+
+	query := `SELECT d.pending, d.delivered, d.failed, d.last_delivered_at,
+		a.attempts, a.failed_attempts, a.avg_duration_ms, a.last_attempt_at
+	FROM (
+		SELECT COUNT(*) FILTER (WHERE status = 'PENDING') AS pending,
+			COUNT(*) FILTER (WHERE status = 'DELIVERED') AS delivered,
+			COUNT(*) FILTER (WHERE status = 'FAILED') AS failed,
+			MAX(delivered_at) AS last_delivered_at
+		FROM webhook_deliveries WHERE subscription_id = $1
+	) d, (
+		SELECT COUNT(*) AS attempts,
+			COUNT(*) FILTER (WHERE error <> '') AS failed_attempts,
+			COALESCE(AVG(duration_ms), 0) AS avg_duration_ms,
+			MAX(attempted_at) AS last_attempt_at
+		FROM webhook_delivery_attempts WHERE subscription_id = $1
+	) a`
+
+	stats := models.WebhookSubscriptionStats{SubscriptionID: subscriptionID}
+	err := r.withReconnect(ctx, op, func() error {
+		var lastDelivered, lastAttempt sql.NullTime
+		err := r.reader(ctx).QueryRowContext(ctx, query, subscriptionID).Scan(&stats.Pending, &stats.Delivered, &stats.Failed,
+			&lastDelivered, &stats.Attempts, &stats.FailedAttempts, &stats.AvgDurationMs, &lastAttempt)
+		if err != nil {
+			return queryError("select_webhook_subscription_stats", err)
+		}
+		stats.LastDeliveredAt, stats.LastAttemptAt = utcPtr(lastDelivered), utcPtr(lastAttempt)
+		return nil
+	})
+	if err != nil {
+		r.logger(ctx).Error("error summing up webhook deliveries", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// PurgeWebhookDeliveries deletes delivered and failed deliveries created
+// before before, and their attempts.
+func (r *WalletRepository) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	op := "repository.PurgeWebhookDeliveries"
+
+	var n int64
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE status <> 'PENDING' AND created_at < $1`, before.UTC())
+		if err != nil {
+			return queryError("purge_webhook_deliveries", err)
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		r.logger(ctx).Error("error purging webhook deliveries", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+
+const webhookSubscriptionColumns = `id, url, secret, event_types, operation_types, min_amount, paused, created_at, updated_at`
+
+func scanWebhookSubscription(row rowScanner, s *models.WebhookSubscription) error {
+	var operationTypes []string
+	err := row.Scan(&s.ID, &s.URL, &s.Secret, pq.Array(&s.EventTypes), pq.Array(&operationTypes), &s.Filter.MinAmount,
+		&s.Paused, utc(&s.CreatedAt), utc(&s.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	s.Filter.OperationTypes = nil
+	for _, t := range operationTypes {
+		s.Filter.OperationTypes = append(s.Filter.OperationTypes, models.OperationType(t))
+	}
+	return nil
+}
+
+// operationTypesArray converts the operation types of a webhook filter for
+// the operation_types column.
+func operationTypesArray(types []models.OperationType) any {
+	a := make([]string, len(types))
+	for i, t := range types {
+		a[i] = string(t)
+	}
+	return pq.Array(a)
+}
+
+func (r *WalletRepository) CreateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	op := "repository.CreateWebhookSubscription"
+
+	query := `INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING ` + webhookSubscriptionColumns
+
+	var result models.WebhookSubscription
+	err := r.withReconnect(ctx, op, func() error {
+		return queryError("insert_webhook_subscription", scanWebhookSubscription(r.conn(ctx).QueryRowContext(ctx, query,
+			s.ID, s.URL, s.Secret, pq.Array(s.EventTypes), operationTypesArray(s.Filter.OperationTypes), s.Filter.MinAmount,
+			s.Paused, s.CreatedAt.UTC(), s.UpdatedAt.UTC()), &result))
+	})
+	if err != nil {
+		r.logger(ctx).Error("error creating webhook subscription", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *WalletRepository) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	op := "repository.GetWebhookSubscription"
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	var result models.WebhookSubscription
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWebhookSubscription(r.reader(ctx).QueryRowContext(ctx, query, id), &result)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return queryError("select_webhook_subscription", err)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+			r.logger(ctx).Error("error receiving webhook subscription", slog.String("op", op),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListWebhookSubscriptions returns all subscriptions, oldest first.
+func (r *WalletRepository) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	op := "repository.ListWebhookSubscriptions"
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at, id`
+
+	subs := []models.WebhookSubscription{}
+	err := r.withReconnect(ctx, op, func() error {
+		rows, err := r.reader(ctx).QueryContext(ctx, query)
+		if err != nil {
+			return queryError("select_webhook_subscriptions", err)
+		}
+		defer rows.Close()
+
+		subs = subs[:0]
+		for rows.Next() {
+			var s models.WebhookSubscription
+			if err := scanWebhookSubscription(rows, &s); err != nil {
+				return queryError("select_webhook_subscriptions", err)
+			}
+			subs = append(subs, s)
+		}
+		return queryError("select_webhook_subscriptions", rows.Err())
+	})
+	if err != nil {
+		r.logger(ctx).Error("error listing webhook subscriptions", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+	return subs, nil
+}
+
+// UpdateWebhookSubscription replaces the URL, event types and filter of
+// s.ID, and its secret unless s.Secret is empty. Whether it is paused is
+// left alone.
+func (r *WalletRepository) UpdateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	op := "repository.UpdateWebhookSubscription"
+
+	query := `UPDATE webhook_subscriptions
+	SET url = $2, secret = COALESCE(NULLIF($3, ''), secret), event_types = $4, operation_types = $5, min_amount = $6, updated_at = $7
+	WHERE id = $1
+	RETURNING ` + webhookSubscriptionColumns
+
+	var result models.WebhookSubscription
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWebhookSubscription(r.conn(ctx).QueryRowContext(ctx, query,
+			s.ID, s.URL, s.Secret, pq.Array(s.EventTypes), operationTypesArray(s.Filter.OperationTypes), s.Filter.MinAmount,
+			s.UpdatedAt.UTC()), &result)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return queryError("update_webhook_subscription", err)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+			r.logger(ctx).Error("error updating webhook subscription", slog.String("op", op),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PauseWebhookSubscription pauses subscription id, or resumes it unless
+// paused, and returns it.
+func (r *WalletRepository) PauseWebhookSubscription(ctx context.Context, id uuid.UUID, paused bool, updatedAt time.Time) (*models.WebhookSubscription, error) {
+	op := "repository.PauseWebhookSubscription"
+
+	query := `UPDATE webhook_subscriptions SET paused = $2, updated_at = $3
+	WHERE id = $1
+	RETURNING ` + webhookSubscriptionColumns
+
+	var result models.WebhookSubscription
+	err := r.withReconnect(ctx, op, func() error {
+		err := scanWebhookSubscription(r.conn(ctx).QueryRowContext(ctx, query, id, paused, updatedAt.UTC()), &result)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return queryError("pause_webhook_subscription", err)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+			r.logger(ctx).Error("error pausing webhook subscription", slog.String("op", op),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *WalletRepository) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	op := "repository.DeleteWebhookSubscription"
+
+	err := r.withReconnect(ctx, op, func() error {
+		res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+		if err != nil {
+			return queryError("delete_webhook_subscription", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrWebhookSubscriptionNotFound) {
+		r.logger(ctx).Error("error deleting webhook subscription", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookSubscriptionCols = []string{"id", "url", "secret", "event_types", "operation_types", "min_amount", "paused", "created_at", "updated_at"}
+
+func TestUpdateWebhookSubscription_KeepsSecretWhenEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE webhook_subscriptions\s+SET url = \$2, secret = COALESCE\(NULLIF\(\$3, ''\), secret\)`).
+		WithArgs(id, "https://example.com/hook", "", pq.Array([]string{"wallet.created"}), pq.Array([]string{}), int64(0), now.UTC()).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionCols).
+			AddRow(id, "https://example.com/hook", "old", "{wallet.created}", "{}", 0, false, now, now))
+
+	s, err := repo.UpdateWebhookSubscription(context.Background(), models.WebhookSubscription{
+		ID: id, URL: "https://example.com/hook", EventTypes: []string{"wallet.created"}, UpdatedAt: now,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "old", s.Secret)
+	assert.Equal(t, []string{"wallet.created"}, s.EventTypes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteWebhookSubscription_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM webhook_subscriptions WHERE id = \$1`).WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.DeleteWebhookSubscription(context.Background(), id)
+
+	assert.ErrorIs(t, err, ErrWebhookSubscriptionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueueWebhookEvent_FansOutToSubscriptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	e := models.WebhookEvent{ID: uuid.New(), Type: "wallet.created", Payload: []byte(`{}`), CreatedAt: time.Now()}
+
+	mock.ExpectExec(`INSERT INTO webhook_deliveries .+ FROM webhook_subscriptions WHERE \$2 = ANY \(event_types\)`).
+		WithArgs(e.ID, "wallet.created", e.Payload, e.CreatedAt.UTC(), "", int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repo.EnqueueWebhookEvent(context.Background(), e)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueueWebhookEvent_PassesOperationToFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	e := models.WebhookEvent{ID: uuid.New(), Type: "balance.updated", Payload: []byte(`{}`), CreatedAt: time.Now(),
+		OperationType: models.OperationTypeWithdraw, Amount: 500}
+
+	mock.ExpectExec(`INSERT INTO webhook_deliveries .+ \$5 = ANY \(operation_types\)\) AND \$6 >= min_amount`).
+		WithArgs(e.ID, "balance.updated", e.Payload, e.CreatedAt.UTC(), "WITHDRAW", int64(500)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := repo.EnqueueWebhookEvent(context.Background(), e)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPauseWebhookSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE webhook_subscriptions SET paused = \$2, updated_at = \$3\s+WHERE id = \$1`).
+		WithArgs(id, true, now.UTC()).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionCols).
+			AddRow(id, "https://example.com/hook", "s", "{balance.updated}", "{DEPOSIT}", 100, true, now, now))
+
+	s, err := repo.PauseWebhookSubscription(context.Background(), id, true, now)
+
+	require.NoError(t, err)
+	assert.True(t, s.Paused)
+	assert.Equal(t, []models.OperationType{models.OperationTypeDeposit}, s.Filter.OperationTypes)
+	assert.Equal(t, int64(100), s.Filter.MinAmount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPauseWebhookSubscription_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+
+	mock.ExpectQuery(`UPDATE webhook_subscriptions SET paused`).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionCols))
+
+	_, err = repo.PauseWebhookSubscription(context.Background(), id, false, time.Now())
+
+	assert.ErrorIs(t, err, ErrWebhookSubscriptionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookSubscriptionStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT d.pending, d.delivered, d.failed, d.last_delivered_at`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"pending", "delivered", "failed", "last_delivered_at",
+			"attempts", "failed_attempts", "avg_duration_ms", "last_attempt_at"}).
+			AddRow(1, 5, 2, now, 11, 6, 42.5, now))
+
+	stats, err := repo.WebhookSubscriptionStats(context.Background(), id)
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookSubscriptionStats{SubscriptionID: id, Pending: 1, Delivered: 5, Failed: 2,
+		Attempts: 11, FailedAttempts: 6, AvgDurationMs: 42.5, LastAttemptAt: utcPtr(sql.NullTime{Time: now, Valid: true}),
+		LastDeliveredAt: utcPtr(sql.NullTime{Time: now, Valid: true})}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordWebhookDeliveryAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWalletRepository(db, log)
+	now := time.Now()
+	a := models.WebhookDeliveryAttempt{EventID: uuid.New(), SubscriptionID: uuid.New(), EventType: "wallet.created",
+		Attempt: 2, StatusCode: 502, Error: "webhook returned 502 Bad Gateway", DurationMs: 12.5, AttemptedAt: now}
+	next := now.Add(time.Minute)
+
+	mock.ExpectExec(`INSERT INTO webhook_delivery_attempts .+ UPDATE webhook_deliveries`).
+		WithArgs(a.EventID, a.SubscriptionID, a.EventType, 2, 502, a.Error, 12.5, now.UTC(), "PENDING", next.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.RecordWebhookDeliveryAttempt(context.Background(), a, models.WebhookDeliveryPending, next)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+// Package requestid carries the id of the request being served, and the
+// operation it invokes, through the context, so that every log line written
+// and every database transaction run on its behalf, from the API down to
+// the repository, can be correlated.
+package requestid
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header, and lowercased the gRPC metadata key, that
+// carries request ids in and out.
+const Header = "X-Request-ID"
+
+// MaxLength bounds request ids accepted from callers.
+const MaxLength = 128
+
+type idKey struct{}
+
+type operationKey struct{}
+
+// New returns a fresh request id.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a caller-supplied id may be used as is: not empty,
+// at most MaxLength long and printable ASCII only, so it can't forge log
+// lines or response headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithID stores the request id in ctx.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// IDFrom returns the request id stored by WithID, if any.
+func IDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok
+}
+
+// WithOperation stores the name of the operation being served in ctx: the
+// route pattern of an HTTP request or the method of a gRPC call.
+func WithOperation(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+// OperationFrom returns the operation name stored by WithOperation, if any.
+func OperationFrom(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(operationKey{}).(string)
+	return name, ok
+}
+
+// Logger returns log with the request id in ctx attached as "request_id",
+// or log itself outside of requests.
+func Logger(ctx context.Context, log *slog.Logger) *slog.Logger {
+	if id, ok := IDFrom(ctx); ok {
+		return log.With(slog.String("request_id", id))
+	}
+	return log
+}
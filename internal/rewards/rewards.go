@@ -0,0 +1,106 @@
+package rewards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+	"wallet-service/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// Transaction is a completed wallet operation as seen by an Engine. ID is
+// unique per operation; accruals are keyed by it so each transaction earns
+// at most one reward.
+type Transaction struct {
+	ID            string
+	WalletID      uuid.UUID
+	OperationType string
+	Amount        int64
+	Category      string
+	At            time.Time
+}
+
+// Reward is an amount to credit to the rewards sub-wallet and the rule that
+// produced it.
+type Reward struct {
+	Rule   string
+	Amount int64
+}
+
+// Engine decides the reward a transaction earns, if any.
+type Engine interface {
+	Reward(tx Transaction) (Reward, bool)
+}
+
+// Rule pays BasisPoints/10000 of the amount of matching transactions,
+// rounded down and capped at Cap per transaction (0 for no cap). Empty
+// Operations or Categories match everything.
+type Rule struct {
+	Name        string   `json:"name"`
+	BasisPoints int64    `json:"basisPoints"`
+	Cap         int64    `json:"cap"`
+	Operations  []string `json:"operations"`
+	Categories  []string `json:"categories"`
+}
+
+func (r Rule) matches(tx Transaction) bool {
+	if len(r.Operations) > 0 && !slices.Contains(r.Operations, tx.OperationType) {
+		return false
+	}
+	if len(r.Categories) > 0 && !slices.Contains(r.Categories, tx.Category) {
+		return false
+	}
+	return true
+}
+
+// Rules is an Engine applying the first matching rule.
+type Rules []Rule
+
+func (rs Rules) Reward(tx Transaction) (Reward, bool) {
+	for _, r := range rs {
+		if !r.matches(tx) {
+			continue
+		}
+		amount, err := money.MulDiv(tx.Amount, r.BasisPoints, money.BasisPoints, money.RoundDown)
+		if err != nil {
+			return Reward{}, false
+		}
+		if r.Cap > 0 && amount > r.Cap {
+			amount = r.Cap
+		}
+		if amount <= 0 {
+			return Reward{}, false
+		}
+		return Reward{Rule: r.Name, Amount: amount}, true
+	}
+	return Reward{}, false
+}
+
+// LoadRules reads a JSON file of the form {"rules": [...]}.
+func LoadRules(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules Rules `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rewards file: %w", err)
+	}
+	for i, r := range file.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rewards file: rule %d has no name", i)
+		}
+		if r.BasisPoints <= 0 || r.BasisPoints > 10000 {
+			return nil, fmt.Errorf("rewards file: rule %q: basisPoints must be in (0, 10000]", r.Name)
+		}
+		if r.Cap < 0 {
+			return nil, fmt.Errorf("rewards file: rule %q: cap must not be negative", r.Name)
+		}
+	}
+	return file.Rules, nil
+}
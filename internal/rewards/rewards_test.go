@@ -0,0 +1,50 @@
+package rewards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Reward(t *testing.T) {
+	rules := Rules{
+		{Name: "groceries", BasisPoints: 300, Cap: 50, Operations: []string{"WITHDRAW"}, Categories: []string{"groceries"}},
+		{Name: "base", BasisPoints: 100, Operations: []string{"WITHDRAW"}},
+	}
+
+	tests := []struct {
+		name string
+		tx   Transaction
+		want Reward
+		ok   bool
+	}{
+		{name: "category rule", tx: Transaction{OperationType: "WITHDRAW", Category: "groceries", Amount: 1000}, want: Reward{Rule: "groceries", Amount: 30}, ok: true},
+		{name: "capped", tx: Transaction{OperationType: "WITHDRAW", Category: "groceries", Amount: 10000}, want: Reward{Rule: "groceries", Amount: 50}, ok: true},
+		{name: "fallback rule", tx: Transaction{OperationType: "WITHDRAW", Category: "travel", Amount: 1000}, want: Reward{Rule: "base", Amount: 10}, ok: true},
+		{name: "rounds down to nothing", tx: Transaction{OperationType: "WITHDRAW", Amount: 99}},
+		{name: "no matching operation", tx: Transaction{OperationType: "DEPOSIT", Amount: 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rules.Reward(tt.tx)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rewards.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"name": "base", "basisPoints": 100, "cap": 500}]}`), 0o600))
+
+	rules, err := LoadRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, Rules{{Name: "base", BasisPoints: 100, Cap: 500}}, rules)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"name": "base", "basisPoints": 20000}]}`), 0o600))
+	_, err = LoadRules(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+// Package sandbox lets integrators exercise error handling against sandbox
+// tenants: operations with magic amounts on their wallets behave
+// deterministically instead of touching the ledger as usual.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrProviderFailure = errors.New("sandbox: simulated provider failure")
+
+// Magic operation amounts, in minor units, recognised on sandbox tenants.
+const (
+	AmountInsufficientFunds int64 = 40200
+	AmountDelay             int64 = 40800
+	AmountProviderFailure   int64 = 50300
+)
+
+type Behavior int
+
+const (
+	BehaviorNone Behavior = iota
+	// BehaviorInsufficientFunds rejects the operation as if the wallet had
+	// no funds, whatever its balance.
+	BehaviorInsufficientFunds
+	// BehaviorDelay processes the operation normally after Sandbox.Delay.
+	BehaviorDelay
+	// BehaviorProviderFailure fails the operation with ErrProviderFailure.
+	BehaviorProviderFailure
+)
+
+// BehaviorOf returns the behavior triggered by amount.
+func BehaviorOf(amount int64) Behavior {
+	switch amount {
+	case AmountInsufficientFunds:
+		return BehaviorInsufficientFunds
+	case AmountDelay:
+		return BehaviorDelay
+	case AmountProviderFailure:
+		return BehaviorProviderFailure
+	default:
+		return BehaviorNone
+	}
+}
+
+// Sandbox is the set of sandbox tenants.
+type Sandbox struct {
+	tenants []string
+	delay   time.Duration
+}
+
+// New returns a sandbox for the given tenants. The empty (default) tenant
+// is never a sandbox tenant.
+func New(tenants []string, delay time.Duration) *Sandbox {
+	s := &Sandbox{delay: delay}
+	for _, t := range tenants {
+		if t != "" && !slices.Contains(s.tenants, t) {
+			s.tenants = append(s.tenants, t)
+		}
+	}
+	return s
+}
+
+func (s *Sandbox) Enabled(tenant string) bool {
+	return tenant != "" && slices.Contains(s.tenants, tenant)
+}
+
+func (s *Sandbox) Tenants() []string {
+	return slices.Clone(s.tenants)
+}
+
+func (s *Sandbox) Delay() time.Duration {
+	return s.delay
+}
+
+// Wait sleeps for the configured delay or until ctx is done.
+func (s *Sandbox) Wait(ctx context.Context) error {
+	t := time.NewTimer(s.delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
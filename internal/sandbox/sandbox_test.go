@@ -0,0 +1,33 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBehaviorOf(t *testing.T) {
+	assert.Equal(t, BehaviorInsufficientFunds, BehaviorOf(AmountInsufficientFunds))
+	assert.Equal(t, BehaviorDelay, BehaviorOf(AmountDelay))
+	assert.Equal(t, BehaviorProviderFailure, BehaviorOf(AmountProviderFailure))
+	assert.Equal(t, BehaviorNone, BehaviorOf(100))
+}
+
+func TestSandbox_Enabled(t *testing.T) {
+	s := New([]string{"acme-test", "", "acme-test"}, 0)
+
+	assert.True(t, s.Enabled("acme-test"))
+	assert.False(t, s.Enabled("acme"))
+	assert.False(t, s.Enabled(""))
+	assert.Equal(t, []string{"acme-test"}, s.Tenants())
+}
+
+func TestSandbox_WaitHonoursContext(t *testing.T) {
+	s := New(nil, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, s.Wait(ctx), context.Canceled)
+}
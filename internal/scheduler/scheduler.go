@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule computes the next run time strictly after the given instant.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Every runs a job at a fixed interval.
+type Every time.Duration
+
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// Daily runs a job once a day at Hour:Minute UTC.
+type Daily struct {
+	Hour, Minute int
+}
+
+func (d Daily) Next(after time.Time) time.Time {
+	after = after.UTC()
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.Hour, d.Minute, 0, 0, time.UTC)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Monthly runs a job on Day of every month at Hour:Minute UTC. Days past
+// the end of a short month fall on its last day.
+type Monthly struct {
+	Day, Hour, Minute int
+}
+
+func (m Monthly) Next(after time.Time) time.Time {
+	after = after.UTC()
+	for i := 0; ; i++ {
+		first := time.Date(after.Year(), after.Month()+time.Month(i), 1, m.Hour, m.Minute, 0, 0, time.UTC)
+		day := m.Day
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		next := first.AddDate(0, 0, day-1)
+		if next.After(after) {
+			return next
+		}
+	}
+}
+
+// Parse understands "every <duration>", "daily HH:MM" and
+// "monthly <day> HH:MM".
+func Parse(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, ErrInvalidSchedule
+	}
+
+	switch {
+	case fields[0] == "every" && len(fields) == 2:
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+		}
+		return Every(d), nil
+	case fields[0] == "daily" && len(fields) == 2:
+		h, m, err := parseClock(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+		}
+		return Daily{Hour: h, Minute: m}, nil
+	case fields[0] == "monthly" && len(fields) == 3:
+		day, err := strconv.Atoi(fields[1])
+		if err != nil || day < 1 || day > 31 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+		}
+		h, m, err := parseClock(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+		}
+		return Monthly{Day: day, Hour: h, Minute: m}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+}
+
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       func(context.Context) error
+}
+
+// JobStatus is the outcome of a job's most recent run.
+type JobStatus struct {
+	Name      string    `json:"name"`
+	LastRun   time.Time `json:"lastRun,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+	NextRun   time.Time `json:"nextRun"`
+}
+
+// Scheduler runs registered jobs in their own goroutines. A job never
+// overlaps with itself: the next run is computed after the previous one
+// finishes.
+type Scheduler struct {
+	log  *slog.Logger
+	jobs []job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	now    func() time.Time
+
+	mu       sync.Mutex
+	statuses map[string]*JobStatus
+}
+
+func New(log *slog.Logger) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		now:      time.Now,
+		statuses: make(map[string]*JobStatus),
+	}
+}
+
+// Add registers a job. It must be called before Start.
+func (s *Scheduler) Add(name string, schedule Schedule, fn func(context.Context) error) {
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, fn: fn})
+	s.statuses[name] = &JobStatus{Name: name}
+}
+
+// Status returns a snapshot of every job's last outcome, in registration
+// order.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, *s.statuses[j.name])
+	}
+	return out
+}
+
+func (s *Scheduler) record(name string, update func(*JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.statuses[name])
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.run(ctx, j)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, j job) {
+	defer s.wg.Done()
+	log := s.log.With(slog.String("job", j.name))
+
+	for {
+		next := j.schedule.Next(s.now())
+		s.record(j.name, func(st *JobStatus) { st.NextRun = next })
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		err := j.fn(ctx)
+		s.record(j.name, func(st *JobStatus) {
+			st.LastRun = start
+			st.LastError = ""
+			if err != nil {
+				st.LastError = err.Error()
+			}
+		})
+		if err != nil {
+			log.Error("scheduled job failed", slog.Duration("duration", time.Since(start)), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			continue
+		}
+		log.Info("scheduled job completed", slog.Duration("duration", time.Since(start)))
+	}
+}
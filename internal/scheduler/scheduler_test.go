@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Schedule
+	}{
+		{"every 1h", Every(time.Hour)},
+		{"daily 06:30", Daily{Hour: 6, Minute: 30}},
+		{"monthly 1 00:05", Monthly{Day: 1, Minute: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, bad := range []string{"", "hourly", "every -1s", "daily 25:00", "monthly 32 00:00"} {
+		_, err := Parse(bad)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, bad)
+	}
+}
+
+func TestDaily_Next(t *testing.T) {
+	d := Daily{Hour: 6}
+	base := time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), d.Next(base))
+	assert.Equal(t, time.Date(2024, 3, 11, 6, 0, 0, 0, time.UTC), d.Next(base.Add(time.Hour)))
+}
+
+func TestMonthly_Next_ClampsShortMonths(t *testing.T) {
+	m := Monthly{Day: 31}
+	base := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), m.Next(base))
+}
+
+func TestScheduler_RunsAndStops(t *testing.T) {
+	s := New(slog.Default())
+	var runs atomic.Int32
+	s.Add("tick", Every(5*time.Millisecond), func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	s.Start(context.Background())
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+	s.Stop()
+
+	after := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, after, runs.Load())
+}
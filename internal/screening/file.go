@@ -0,0 +1,63 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileDenylist matches subjects against a local list. Each line holds an
+// identifier, optionally prefixed with its kind ("owner:<id>",
+// "wallet:<id>") and followed by a reason after whitespace; unprefixed
+// entries match any kind. Blank lines and lines starting with # are ignored.
+type FileDenylist struct {
+	name    string
+	entries map[Subject]string
+}
+
+func LoadFileDenylist(path string) (*FileDenylist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := &FileDenylist{name: path, entries: make(map[Subject]string)}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, reason, _ := strings.Cut(line, " ")
+		var s Subject
+		if kind, rest, ok := strings.Cut(id, ":"); ok {
+			switch Kind(kind) {
+			case KindOwner, KindWallet:
+				s = Subject{Kind: Kind(kind), ID: strings.ToLower(rest)}
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown kind %q", path, lineNo, kind)
+			}
+		} else {
+			s = Subject{ID: strings.ToLower(id)}
+		}
+		d.entries[s] = strings.TrimSpace(reason)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *FileDenylist) Screen(_ context.Context, s Subject) (Result, error) {
+	id := strings.ToLower(s.ID)
+	for _, key := range []Subject{{Kind: s.Kind, ID: id}, {ID: id}} {
+		if reason, ok := d.entries[key]; ok {
+			return Result{Match: true, List: d.name, Reason: reason}, nil
+		}
+	}
+	return Result{}, nil
+}
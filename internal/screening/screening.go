@@ -0,0 +1,49 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+)
+
+type Kind string
+
+const (
+	KindOwner  Kind = "owner"
+	KindWallet Kind = "wallet"
+)
+
+// Subject is an identifier checked against denylists.
+type Subject struct {
+	Kind Kind   `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// Result is the verdict for one subject. List names the denylist that
+// matched.
+type Result struct {
+	Match  bool   `json:"match"`
+	List   string `json:"list,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Screener checks subjects against an external denylist. An error means the
+// list could not be consulted, not that the subject is clean.
+type Screener interface {
+	Screen(ctx context.Context, s Subject) (Result, error)
+}
+
+// Chain consults screeners in order and stops at the first match.
+type Chain []Screener
+
+func (c Chain) Screen(ctx context.Context, s Subject) (Result, error) {
+	for i, sc := range c {
+		res, err := sc.Screen(ctx, s)
+		if err != nil {
+			return Result{}, fmt.Errorf("screener %d: %w", i, err)
+		}
+		if res.Match {
+			return res, nil
+		}
+	}
+	return Result{}, nil
+}
@@ -0,0 +1,76 @@
+package screening
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	require.NoError(t, os.WriteFile(path, []byte(`# sanctions
+owner:AAAA-1 OFAC SDN
+bbbb-2
+`), 0o600))
+
+	d, err := LoadFileDenylist(path)
+	require.NoError(t, err)
+
+	res, err := d.Screen(context.Background(), Subject{Kind: KindOwner, ID: "aaaa-1"})
+	require.NoError(t, err)
+	assert.True(t, res.Match)
+	assert.Equal(t, "OFAC SDN", res.Reason)
+
+	res, _ = d.Screen(context.Background(), Subject{Kind: KindWallet, ID: "aaaa-1"})
+	assert.False(t, res.Match)
+
+	res, _ = d.Screen(context.Background(), Subject{Kind: KindWallet, ID: "BBBB-2"})
+	assert.True(t, res.Match)
+}
+
+func TestFileDenylist_UnknownKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("tenant:x\n"), 0o600))
+
+	_, err := LoadFileDenylist(path)
+	assert.ErrorContains(t, err, "unknown kind")
+}
+
+func TestWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var s Subject
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+		json.NewEncoder(w).Encode(Result{Match: s.ID == "bad", Reason: "pep"})
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL, "secret", time.Second)
+
+	res, err := wh.Screen(context.Background(), Subject{Kind: KindOwner, ID: "bad"})
+	require.NoError(t, err)
+	assert.True(t, res.Match)
+	assert.Equal(t, srv.URL, res.List)
+
+	res, err = wh.Screen(context.Background(), Subject{Kind: KindOwner, ID: "good"})
+	require.NoError(t, err)
+	assert.False(t, res.Match)
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := NewWebhook(srv.URL, "", time.Second).Screen(context.Background(), Subject{ID: "x"})
+	assert.Error(t, err)
+}
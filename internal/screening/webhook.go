@@ -0,0 +1,59 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook asks an external screening service. It POSTs the subject as JSON
+// and expects a Result-shaped JSON response with status 200.
+type Webhook struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewWebhook(url, token string, timeout time.Duration) *Webhook {
+	return &Webhook{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *Webhook) Screen(ctx context.Context, s Subject) (Result, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("screening webhook returned %s", resp.Status)
+	}
+
+	var res Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Result{}, fmt.Errorf("decode screening response: %w", err)
+	}
+	if res.Match && res.List == "" {
+		res.List = w.url
+	}
+	return res, nil
+}
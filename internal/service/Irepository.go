@@ -2,13 +2,110 @@ package service
 
 import (
 	"context"
+	"time"
 	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+// UnitOfWork is what the service composes atomic operations from: the
+// store calls made with the context WithinTx hands out share its
+// transaction. WithinWalletTx is WithinTx for units of work about one
+// wallet, which a sharded repository runs on the wallet's shard alone.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	WithinWalletTx(ctx context.Context, walletID uuid.UUID, fn func(ctx context.Context) error) error
+	Wallets() repository.WalletStore
+	Versions() repository.VersionStore
+	Promos() repository.PromoStore
+	Outbox() repository.OutboxStore
+	Snapshots() repository.SnapshotStore
+}
+
 type WalletRepository interface {
-	CreateWallet(context.Context, uuid.UUID) (*models.Wallet, error)
+	UnitOfWork
+	CreateWallet(context.Context, uuid.UUID, models.CreateWalletRequest) (*models.Wallet, error)
+	CreateWallets(context.Context, []uuid.UUID, models.CreateWalletRequest) error
 	GetWallet(context.Context, uuid.UUID) (*models.Wallet, error)
+	GetWalletBalance(ctx context.Context, id uuid.UUID) (*models.WalletBalance, error)
 	UpdateWalletBalance(context.Context, uuid.UUID, int64, models.OperationType) (*models.Wallet, error)
+	ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error
+	BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error)
+	GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error)
+	ListWalletVersions(ctx context.Context, from, to time.Time) ([]models.WalletVersion, error)
+	WalletVersionsAfter(ctx context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error)
+	OwnerBalances(ctx context.Context, ownerID uuid.UUID) ([]models.CurrencyBalance, error)
+	TenantBalances(ctx context.Context, tenant string) ([]models.CurrencyBalance, error)
+	CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error)
+	ListWallets(ctx context.Context, f models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, error)
+	SearchWallets(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]models.Wallet, error)
+	CountWalletsToSetStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus) (int64, error)
+	SetWalletsStatus(ctx context.Context, f models.WalletFilter, status models.WalletStatus, batchSize int) (int64, error)
+	FlagDormantWallets(ctx context.Context, idleSince time.Time, batchSize int) ([]models.Wallet, error)
+	ReactivateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	FreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	UnfreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	SetCreditLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error)
+	CloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	ImportWallet(ctx context.Context, id uuid.UUID, req models.ImportWalletRequest) (*models.Wallet, error)
+	WalletsToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error)
+	ArchiveTransactions(ctx context.Context, walletID uuid.UUID, afterVersion, limit int) ([]models.ArchivedTransaction, error)
+	DeleteTransactions(ctx context.Context, walletID uuid.UUID, throughVersion, limit int) (int64, error)
+	MarkWalletPurged(ctx context.Context, id uuid.UUID) error
+	AppendAuditEvent(ctx context.Context, e models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, walletID uuid.UUID, after models.AuditEvent, limit int) ([]models.AuditEvent, error)
+	PurgeAuditEvents(ctx context.Context, before time.Time) (int64, error)
+	PlaceLegalHold(ctx context.Context, h models.LegalHold) (*models.LegalHold, error)
+	GetLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error)
+	ReleaseLegalHold(ctx context.Context, walletID uuid.UUID) (*models.LegalHold, error)
+	RecordScreeningHit(ctx context.Context, hit models.ScreeningHit) error
+	RecordOperationAttempts(ctx context.Context, attempts []models.OperationAttempt) error
+	ListOperationAttempts(ctx context.Context, operationID string) ([]models.OperationAttempt, error)
+	PurgeOperationAttempts(ctx context.Context, before time.Time) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, s models.WebhookSubscription) (*models.WebhookSubscription, error)
+	PauseWebhookSubscription(ctx context.Context, id uuid.UUID, paused bool, updatedAt time.Time) (*models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error
+	EnqueueWebhookEvent(ctx context.Context, e models.WebhookEvent) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error
+	ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error)
+	WebhookSubscriptionStats(ctx context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscriptionStats, error)
+	PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+	RelayOutbox(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error)
+	PurgeOutbox(ctx context.Context, before time.Time) (int64, error)
+	ApplyAtomic(ctx context.Context, steps []models.WalletOperation) ([]models.AtomicStepResult, error)
+	ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, error)
+	Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (from, to *models.Wallet, err error)
+	CreateMandate(ctx context.Context, m models.Mandate) (*models.Mandate, error)
+	GetMandate(ctx context.Context, id uuid.UUID) (*models.Mandate, error)
+	ListMandates(ctx context.Context, walletID uuid.UUID) ([]models.Mandate, error)
+	RevokeMandate(ctx context.Context, walletID, mandateID uuid.UUID, at time.Time) (*models.Mandate, error)
+	DebitMandate(ctx context.Context, debit models.MandateDebit, periodStart time.Time) (*models.MandateDebit, error)
+	GrantPromo(ctx context.Context, credit models.PromoCredit) (*models.Wallet, error)
+	ListPromoCredits(ctx context.Context, walletID uuid.UUID) ([]models.PromoCredit, error)
+	DuePromoCredits(ctx context.Context, now time.Time, limit int) ([]models.PromoCredit, error)
+	ExpirePromoCredit(ctx context.Context, id uuid.UUID, now time.Time) (int64, error)
+	AccrueReward(ctx context.Context, accrual models.RewardAccrual) (*models.RewardAccrual, bool, error)
+	ListRewardAccruals(ctx context.Context, walletID uuid.UUID) ([]models.RewardAccrual, error)
+	ReceiveToSuspense(ctx context.Context, c models.SuspenseCase) (*models.SuspenseCase, error)
+	ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error)
+	ResolveSuspenseCase(ctx context.Context, id, targetID uuid.UUID, note string, at time.Time) (*models.SuspenseCase, error)
+	OpenDispute(ctx context.Context, d models.Dispute) (*models.Dispute, error)
+	AddTransactionNote(ctx context.Context, n models.TransactionNote) (*models.TransactionNote, error)
+	ListTransactionNotes(ctx context.Context, transactionID int64) ([]models.TransactionNote, error)
+	GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	ListDisputes(ctx context.Context, status models.DisputeStatus) ([]models.Dispute, error)
+	DueDisputes(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	CloseDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, note string, at time.Time) (*models.Dispute, error)
+	PlaceHold(ctx context.Context, h models.FundsHold) (*models.FundsHold, error)
+	ListHolds(ctx context.Context, walletID uuid.UUID) ([]models.FundsHold, error)
+	CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, at time.Time) (*models.FundsHold, error)
+	ReleaseHold(ctx context.Context, walletID, holdID uuid.UUID, at time.Time) (*models.FundsHold, error)
+	WipeTenant(ctx context.Context, tenant string) (int64, error)
+	ListEvents(ctx context.Context, after int64, until time.Time, limit int) ([]models.LedgerEvent, error)
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxAtomicSteps bounds the size of an atomic request, which holds row
+// locks on every involved wallet until it commits.
+const MaxAtomicSteps = 20
+
+// MaxBatchOperations bounds a batch request. Batches carry payroll-style
+// runs of independent operations, so they may be larger than atomic
+// requests, but they too lock every wallet they touch until they commit.
+const MaxBatchOperations = 500
+
+// ProcessAtomic applies the steps of req in order in a single transaction,
+// so that e.g. a withdrawal, the matching deposit and a fee either all
+// happen or none does. Limits, screening and operation hooks apply to every
+// step as they do to single operations.
+func (s *WalletService) ProcessAtomic(ctx context.Context, req models.AtomicRequest) (*models.AtomicReceipt, error) {
+	op := "service.ProcessAtomic"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.Int("steps", len(req.Steps))))
+
+	if len(req.Steps) == 0 || len(req.Steps) > MaxAtomicSteps {
+		log.Warn("invalid number of steps")
+		return nil, fmt.Errorf("%w: between 1 and %d steps required", ErrInvalidInput, MaxAtomicSteps)
+	}
+	return s.applySteps(ctx, req.Steps, log)
+}
+
+// ProcessBatch applies a batch of deposits and withdrawals, e.g. a payroll
+// run, all-or-nothing like ProcessAtomic. The receipt has one result per
+// operation, in order.
+func (s *WalletService) ProcessBatch(ctx context.Context, req models.BatchRequest) (*models.AtomicReceipt, error) {
+	op := "service.ProcessBatch"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.Int("operations", len(req.Operations))))
+
+	if len(req.Operations) == 0 || len(req.Operations) > MaxBatchOperations {
+		log.Warn("invalid number of operations")
+		return nil, fmt.Errorf("%w: between 1 and %d operations required", ErrInvalidInput, MaxBatchOperations)
+	}
+	for i, operation := range req.Operations {
+		if operation.OperationType != models.OperationTypeDeposit && operation.OperationType != models.OperationTypeWithdraw {
+			log.Warn("invalid operation", slog.Int("step", i), slog.String("operation_type", string(operation.OperationType)))
+			return nil, fmt.Errorf("%w: step %d: only deposits and withdrawals can be batched", ErrInvalidInput, i)
+		}
+	}
+	return s.applySteps(ctx, req.Operations, log)
+}
+
+// applySteps validates steps, reserves their limits and applies them in one
+// transaction.
+func (s *WalletService) applySteps(ctx context.Context, steps []models.WalletOperation, log *slog.Logger) (*models.AtomicReceipt, error) {
+	for i, step := range steps {
+		if err := validateOperation(step); err != nil {
+			log.Warn("invalid step", slog.Int("step", i), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, fmt.Errorf("%w: step %d: %v", ErrInvalidInput, i, err)
+		}
+	}
+
+	var releases []func()
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	if s.limiter != nil {
+		scope := limits.ScopeFrom(ctx)
+		for i, step := range steps {
+			release, err := s.limiter.Reserve(scope, step.Amount)
+			if err != nil {
+				releaseAll()
+				log.Warn("atomic request rejected by limits", slog.Int("step", i), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				return nil, fmt.Errorf("step %d: %w", i, err)
+			}
+			releases = append(releases, release)
+		}
+	}
+
+	receipt, err := s.processAtomic(ctx, steps, log)
+	if err != nil {
+		releaseAll()
+	}
+	return receipt, err
+}
+
+func (s *WalletService) processAtomic(ctx context.Context, steps []models.WalletOperation, log *slog.Logger) (*models.AtomicReceipt, error) {
+	for i, step := range steps {
+		if err := s.screenOperation(ctx, step); err != nil {
+			if errors.Is(err, repository.ErrWalletNotFound) {
+				return nil, &repository.StepError{Index: i, Err: err}
+			}
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		if err := s.checkDormancy(ctx, step); err != nil {
+			if errors.Is(err, repository.ErrWalletNotFound) {
+				return nil, &repository.StepError{Index: i, Err: err}
+			}
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+	for i, step := range steps {
+		if err := s.runBeforeHooks(ctx, step, log.With(slog.Int("step", i))); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+
+	var results []models.AtomicStepResult
+	err := s.retry(ctx, "service.ProcessAtomic", func() error {
+		return s.withLimitedWithdrawals(ctx, steps, func(ctx context.Context) error {
+			var err error
+			if results, err = s.repo.ApplyAtomic(ctx, steps); err != nil {
+				return err
+			}
+			for i, result := range results {
+				wallet := &models.Wallet{ID: result.WalletID, Balance: result.Balance, Version: result.Version, UpdatedAt: time.Now().UTC()}
+				if err := s.appendOutbox(ctx, wallet, steps[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		var stepErr *repository.StepError
+		switch {
+		case errors.Is(err, repository.ErrRetryable):
+			return nil, fmt.Errorf("failed to apply atomic request after multiple retries: %w", err)
+		case errors.As(err, &stepErr):
+			log.Warn("atomic request rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to apply atomic request: %w", err)
+	}
+
+	receipt := &models.AtomicReceipt{
+		ID:        uuid.New(),
+		Steps:     results,
+		CreatedAt: time.Now().UTC(),
+	}
+	log.Info("atomic request applied", slog.String("receipt_id", receipt.ID.String()))
+	wallets := make([]*models.Wallet, len(results))
+	for i, result := range results {
+		wallets[i] = &models.Wallet{ID: result.WalletID, Balance: result.Balance, Version: result.Version, UpdatedAt: receipt.CreatedAt}
+		s.publishBalance(ctx, wallets[i], steps[i])
+	}
+	afterCommit(ctx, func() {
+		for i, wallet := range wallets {
+			// Step results don't carry held balances, so the cache has
+			// to read the available balance afresh.
+			s.balances.forget(wallet.ID)
+			s.accrueReward(ctx, wallet, steps[i])
+			s.runAfterHooks(ctx, wallet, steps[i])
+		}
+	})
+	return receipt, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/requestid"
+)
+
+// WithAttemptHistory records every attempt of requests that change wallets,
+// under their request id, and keeps the records for retention. Without it
+// retries leave no trace once the request returns.
+func WithAttemptHistory(retention time.Duration) Option {
+	return func(s *WalletService) {
+		s.attemptRetention = retention
+	}
+}
+
+// retry runs fn like the package-level retry and, with WithAttemptHistory,
+// records each attempt of op under the id of the request in ctx. Attempts
+// inside a WithinTx transaction are recorded by the transaction's retry.
+func (s *WalletService) retry(ctx context.Context, op string, fn func() error) error {
+	id, ok := requestid.IDFrom(ctx)
+	if s.attemptRetention <= 0 || !ok || inTx(ctx) {
+		return retry(ctx, s.retryBudget, fn)
+	}
+
+	var attempts []models.OperationAttempt
+	err := retry(ctx, s.retryBudget, func() error {
+		start := time.Now()
+		err := fn()
+		attempts = append(attempts, models.OperationAttempt{
+			OperationID: id,
+			Attempt:     len(attempts) + 1,
+			Op:          op,
+			ErrorClass:  attemptErrorClass(err),
+			DurationMs:  float64(time.Since(start).Microseconds()) / 1000,
+			StartedAt:   start.UTC(),
+		})
+		return err
+	})
+	// The request's outcome doesn't depend on its history being kept, so
+	// a failure to record it is only logged.
+	if recErr := s.repo.RecordOperationAttempts(context.WithoutCancel(ctx), attempts); recErr != nil {
+		s.logger(ctx).Warn("failed to record operation attempts", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(recErr.Error())})
+	}
+	return err
+}
+
+// attemptErrorClass is the repository.ErrorKind of a failed database call
+// or retryable conflict, "rejected" for requests refused on their merits,
+// such as insufficient funds, and empty for success.
+func attemptErrorClass(err error) string {
+	var repoErr *repository.RepoError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &repoErr):
+		return string(repoErr.Kind())
+	case errors.Is(err, repository.ErrRetryable):
+		return string(repository.KindConflict)
+	}
+	return "rejected"
+}
+
+// ListOperationAttempts returns the recorded attempts of the request with
+// id operationID, oldest first.
+func (s *WalletService) ListOperationAttempts(ctx context.Context, operationID string) ([]models.OperationAttempt, error) {
+	if !requestid.Valid(operationID) {
+		return nil, fmt.Errorf("%w: invalid operation id", ErrInvalidInput)
+	}
+	attempts, err := s.repo.ListOperationAttempts(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operation attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// PurgeOperationAttempts deletes attempts older than the retention given
+// to WithAttemptHistory.
+func (s *WalletService) PurgeOperationAttempts(ctx context.Context) error {
+	if s.attemptRetention <= 0 {
+		return nil
+	}
+	op := "service.PurgeOperationAttempts"
+
+	deleted, err := s.repo.PurgeOperationAttempts(ctx, time.Now().Add(-s.attemptRetention))
+	if err != nil {
+		return fmt.Errorf("failed to purge operation attempts: %w", err)
+	}
+	if deleted > 0 {
+		s.logger(ctx).Info("operation attempts purged", slog.String("op", op), slog.Int64("count", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// auditPageSize is the number of transactions or audit events
+// ExportWalletRecords reads at once.
+const auditPageSize = 500
+
+// WithAuditRetention purges audit events older than retention, except
+// those of wallets on legal hold. Without it audit events are kept.
+func WithAuditRetention(retention time.Duration) Option {
+	return func(s *WalletService) {
+		s.auditRetention = retention
+	}
+}
+
+// audit records action on a wallet, taken by the subject in ctx. Within a
+// transaction the event commits or rolls back with the action.
+func (s *WalletService) audit(ctx context.Context, walletID uuid.UUID, action, detail string) error {
+	e := models.AuditEvent{
+		ID:         uuid.New(),
+		WalletID:   uuid.NullUUID{UUID: walletID, Valid: walletID != uuid.Nil},
+		Action:     action,
+		Actor:      actor(ctx),
+		Detail:     detail,
+		OccurredAt: time.Now().UTC(),
+	}
+	if err := s.repo.AppendAuditEvent(ctx, e); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// actor names the subject in ctx for the audit log, prefixing API keys and
+// OAuth2 clients so they can't be mistaken for users. Callers with the static admin token and
+// scheduled jobs have no subject and leave it empty.
+func actor(ctx context.Context) string {
+	sub, ok := auth.SubjectFrom(ctx)
+	switch {
+	case !ok:
+		return ""
+	case sub.APIKey:
+		return "api_key:" + sub.ID
+	case sub.Client:
+		return "oauth_client:" + sub.ID
+	}
+	return sub.ID
+}
+
+// PlaceLegalHold exempts a wallet's transactions and audit events from
+// retention purges until the hold is released. reason names the matter the
+// records are kept for.
+func (s *WalletService) PlaceLegalHold(ctx context.Context, id uuid.UUID, reason string) (*models.LegalHold, error) {
+	op := "service.PlaceLegalHold"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+	var hold *models.LegalHold
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		hold, err = s.repo.PlaceLegalHold(ctx, models.LegalHold{
+			WalletID: id,
+			Reason:   reason,
+			PlacedBy: actor(ctx),
+			PlacedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditLegalHoldPlaced, reason)
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrLegalHoldExists) {
+			log.Error("failed to place legal hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to place legal hold: %w", err)
+	}
+	log.Info("legal hold placed")
+	return hold, nil
+}
+
+// GetLegalHold returns the legal hold on a wallet, or
+// repository.ErrLegalHoldNotFound.
+func (s *WalletService) GetLegalHold(ctx context.Context, id uuid.UUID) (*models.LegalHold, error) {
+	hold, err := s.repo.GetLegalHold(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	return hold, nil
+}
+
+// ReleaseLegalHold lifts the legal hold on a wallet. Its records are
+// purged with the next retention run they are due for.
+func (s *WalletService) ReleaseLegalHold(ctx context.Context, id uuid.UUID) (*models.LegalHold, error) {
+	op := "service.ReleaseLegalHold"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var hold *models.LegalHold
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if hold, err = s.repo.ReleaseLegalHold(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditLegalHoldReleased, hold.Reason)
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrLegalHoldNotFound) {
+			log.Error("failed to release legal hold", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to release legal hold: %w", err)
+	}
+	log.Info("legal hold released")
+	return hold, nil
+}
+
+// PurgeAuditEvents deletes the audit events that are past retention and
+// not on legal hold. It is meant to run periodically and does nothing
+// unless WithAuditRetention is set.
+func (s *WalletService) PurgeAuditEvents(ctx context.Context) error {
+	if s.auditRetention <= 0 {
+		return nil
+	}
+	op := "service.PurgeAuditEvents"
+
+	deleted, err := s.repo.PurgeAuditEvents(ctx, time.Now().Add(-s.auditRetention))
+	if err != nil {
+		return fmt.Errorf("failed to purge audit events: %w", err)
+	}
+	if deleted > 0 {
+		s.logger(ctx).Info("audit events purged", slog.String("op", op), slog.Int64("count", deleted))
+	}
+	return nil
+}
+
+// exportRecord is a line of ExportWalletRecords: Type says what Data holds.
+type exportRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// ExportWalletRecords writes everything kept on a wallet, as answer to a
+// subpoena, to w as NDJSON: the wallet, its legal hold if any, its
+// transactions and its audit events, oldest first. The export itself is
+// recorded in the audit log before anything is written. Transactions of a
+// purged wallet are in its archives in object storage, not in the export.
+func (s *WalletService) ExportWalletRecords(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	op := "service.ExportWalletRecords"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	wallet, err := s.repo.GetWallet(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to export records: %w", err)
+	}
+	hold, err := s.repo.GetLegalHold(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrLegalHoldNotFound) {
+		return fmt.Errorf("failed to export records: %w", err)
+	}
+	if err := s.audit(ctx, id, models.AuditRecordsExported, ""); err != nil {
+		return fmt.Errorf("failed to export records: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	err = s.writeWalletRecords(ctx, enc, wallet, hold)
+	if err != nil {
+		log.Error("failed to export records", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to export records: %w", err)
+	}
+	log.Info("records exported")
+	return nil
+}
+
+func (s *WalletService) writeWalletRecords(ctx context.Context, enc *json.Encoder, wallet *models.Wallet, hold *models.LegalHold) error {
+	if err := enc.Encode(exportRecord{Type: "wallet", Data: wallet}); err != nil {
+		return err
+	}
+	if hold != nil {
+		if err := enc.Encode(exportRecord{Type: "legal_hold", Data: hold}); err != nil {
+			return err
+		}
+	}
+
+	for after := 0; ; {
+		batch, err := s.repo.ArchiveTransactions(ctx, wallet.ID, after, auditPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read transactions: %w", err)
+		}
+		for i := range batch {
+			if err := enc.Encode(exportRecord{Type: "transaction", Data: &batch[i]}); err != nil {
+				return err
+			}
+		}
+		if len(batch) < auditPageSize {
+			break
+		}
+		after = batch[len(batch)-1].Version
+	}
+
+	for after := (models.AuditEvent{}); ; {
+		batch, err := s.repo.ListAuditEvents(ctx, wallet.ID, after, auditPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read audit events: %w", err)
+		}
+		for i := range batch {
+			if err := enc.Encode(exportRecord{Type: "audit_event", Data: &batch[i]}); err != nil {
+				return err
+			}
+		}
+		if len(batch) < auditPageSize {
+			break
+		}
+		after = batch[len(batch)-1]
+	}
+	return nil
+}
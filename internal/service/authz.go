@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrForbidden is returned when the caller's role doesn't allow acting on a
+// wallet or owner.
+var ErrForbidden = errors.New("forbidden")
+
+// AuthorizeWallet returns ErrForbidden unless the caller in ctx may act on
+// wallet id. Only users are restricted, to the wallets they own; callers
+// without a subject, admins and API keys may act on every wallet. Unknown
+// wallets pass, so that the caller reports them as not found.
+func (s *WalletService) AuthorizeWallet(ctx context.Context, id uuid.UUID) error {
+	sub, ok := auth.SubjectFrom(ctx)
+	if !ok || !sub.Restricted() {
+		return nil
+	}
+	wallet, err := s.GetWallet(ctx, id)
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		return nil
+	case err != nil:
+		return err
+	case !sub.Owns(wallet.OwnerID):
+		withSubject(ctx, s.logger(ctx)).Warn("access to wallet denied", slog.String("op", "service.AuthorizeWallet"),
+			slog.String("wallet_id", id.String()))
+		return fmt.Errorf("%w: wallet %s belongs to another owner", ErrForbidden, id)
+	}
+	return nil
+}
+
+// AuthorizeOwner returns ErrForbidden if the caller in ctx is a user other
+// than ownerID.
+func (s *WalletService) AuthorizeOwner(ctx context.Context, ownerID uuid.UUID) error {
+	sub, ok := auth.SubjectFrom(ctx)
+	if ok && sub.Restricted() && !sub.Owns(uuid.NullUUID{UUID: ownerID, Valid: true}) {
+		return fmt.Errorf("%w: owner %s is not the caller", ErrForbidden, ownerID)
+	}
+	return nil
+}
+
+// AuthorizeNewWallet makes users the owner of the wallet req creates and
+// returns ErrForbidden if they ask for another owner, or can't own wallets
+// because their subject isn't an owner id.
+func (s *WalletService) AuthorizeNewWallet(ctx context.Context, req *models.CreateWalletRequest) error {
+	sub, ok := auth.SubjectFrom(ctx)
+	if !ok || !sub.Restricted() {
+		return nil
+	}
+	if !req.OwnerID.Valid {
+		id, err := uuid.Parse(sub.ID)
+		if err != nil {
+			return fmt.Errorf("%w: subject %q can't own wallets", ErrForbidden, sub.ID)
+		}
+		req.OwnerID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if !sub.Owns(req.OwnerID) {
+		return fmt.Errorf("%w: users can only create wallets they own", ErrForbidden)
+	}
+	return nil
+}
+
+// AuthorizeWalletFilter limits users to listing the wallets they own by
+// setting filter's owner to them. It returns ErrForbidden if they ask for
+// another owner or their subject isn't an owner id.
+func (s *WalletService) AuthorizeWalletFilter(ctx context.Context, filter *models.WalletFilter) error {
+	sub, ok := auth.SubjectFrom(ctx)
+	if !ok || !sub.Restricted() {
+		return nil
+	}
+	id, err := uuid.Parse(sub.ID)
+	if err != nil {
+		return fmt.Errorf("%w: subject %q doesn't own wallets", ErrForbidden, sub.ID)
+	}
+	if filter.OwnerID.Valid && filter.OwnerID.UUID != id {
+		return fmt.Errorf("%w: users can only list wallets they own", ErrForbidden)
+	}
+	filter.OwnerID = uuid.NullUUID{UUID: id, Valid: true}
+	return nil
+}
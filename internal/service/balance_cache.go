@@ -0,0 +1,165 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// BalanceCacheStats counts lookups of the hot balance cache.
+type BalanceCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// WithBalanceCache keeps up to size wallet balances in memory for at most
+// ttl, so reads of hot wallets skip the database round trip. Operations
+// processed by this instance update their entries right away; those of
+// other instances show up once the entry expires, so ttl bounds how stale
+// a balance may be. A size or ttl of zero disables the cache.
+func WithBalanceCache(size int, ttl time.Duration) Option {
+	return func(s *WalletService) {
+		if size > 0 && ttl > 0 {
+			s.balances = newBalanceCache(size, ttl)
+		}
+	}
+}
+
+type balanceEntry struct {
+	balance models.WalletBalance
+	expires time.Time
+}
+
+// balanceCache is an LRU of wallet balances with a TTL. A nil cache is
+// valid and caches nothing.
+type balanceCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // most recently used first
+	entries map[uuid.UUID]*list.Element
+	writes  uint64
+	hits    uint64
+	misses  uint64
+}
+
+func newBalanceCache(size int, ttl time.Duration) *balanceCache {
+	return &balanceCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[uuid.UUID]*list.Element, size),
+	}
+}
+
+func (c *balanceCache) get(id uuid.UUID) (models.WalletBalance, bool) {
+	if c == nil {
+		return models.WalletBalance{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[id]
+	if ok && c.now().Before(el.Value.(*balanceEntry).expires) {
+		c.order.MoveToFront(el)
+		c.hits++
+		return el.Value.(*balanceEntry).balance, true
+	}
+	if ok {
+		c.remove(el)
+	}
+	c.misses++
+	return models.WalletBalance{}, false
+}
+
+// generation returns a token to pass to fill once a balance read for the
+// database completes.
+func (c *balanceCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writes
+}
+
+// fill caches a balance read from the database unless a write went through
+// since gen was taken: the read may predate it and would hide it for a
+// whole ttl.
+func (c *balanceCache) fill(balance models.WalletBalance, gen uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writes != gen {
+		return
+	}
+	c.set(balance)
+}
+
+// put records the balance of a wallet this instance just wrote.
+func (c *balanceCache) put(w *models.Wallet) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	c.set(models.WalletBalance{WalletID: w.ID, Balance: w.Balance, Available: w.AvailableBalance()})
+}
+
+// forget drops a wallet whose balance changed without this instance
+// learning the new one.
+func (c *balanceCache) forget(id uuid.UUID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	if el, ok := c.entries[id]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *balanceCache) set(balance models.WalletBalance) {
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[balance.WalletID]; ok {
+		e := el.Value.(*balanceEntry)
+		e.balance, e.expires = balance, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	for c.order.Len() >= c.size {
+		c.remove(c.order.Back())
+	}
+	c.entries[balance.WalletID] = c.order.PushFront(&balanceEntry{balance: balance, expires: expires})
+}
+
+func (c *balanceCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*balanceEntry).balance.WalletID)
+}
+
+func (c *balanceCache) stats() BalanceCacheStats {
+	if c == nil {
+		return BalanceCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return BalanceCacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// BalanceCacheStats reports hits and misses of the hot balance cache; all
+// zero when it is disabled.
+func (s *WalletService) BalanceCacheStats() BalanceCacheStats {
+	return s.balances.stats()
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxBulkOperations     = 100000
+	DefaultBulkWorkers    = 8
+	BulkOperationsJobKind = "operations.bulk"
+
+	// bulkOperationsChunk is the number of operations between checkpoints
+	// of a bulk operations job.
+	bulkOperationsChunk = 1000
+)
+
+// WithBulkWorkers sets how many operations of a bulk job run concurrently.
+func WithBulkWorkers(n int) Option {
+	return func(s *WalletService) {
+		if n > 0 {
+			s.bulkWorkers = n
+		}
+	}
+}
+
+type bulkOperationsPayload struct {
+	Operations []models.WalletOperation `json:"operations"`
+	Scope      string                   `json:"scope"`
+}
+
+// bulkOperationsCheckpoint tracks a bulk operations job chunk by chunk.
+// Operations before Next are in the report; those in [Next, Dispatched)
+// were being processed when the job was interrupted and may or may not
+// have been applied.
+type bulkOperationsCheckpoint struct {
+	Next       int   `json:"next"`
+	Dispatched int   `json:"dispatched"`
+	Processed  int64 `json:"processed"`
+	Failed     int64 `json:"failed"`
+}
+
+type bulkOperationResult struct {
+	wallet *models.Wallet
+	err    error
+}
+
+// StartBulkOperations processes ops in a background job, each one exactly
+// as ProcessOperation would, and responds with the job to poll. Failed
+// operations don't stop the job; their errors end up in the job's CSV
+// report. The limit scope of ctx applies to every operation.
+func (s *WalletService) StartBulkOperations(ctx context.Context, ops []models.WalletOperation) (jobs.Job, error) {
+	op := "service.StartBulkOperations"
+	log := s.logger(ctx).With(slog.String("op", op), slog.Int("operations", len(ops)))
+
+	if len(ops) == 0 || len(ops) > MaxBulkOperations {
+		return jobs.Job{}, fmt.Errorf("%w: between 1 and %d operations required", ErrInvalidInput, MaxBulkOperations)
+	}
+
+	job, err := s.jobs.Submit(ctx, BulkOperationsJobKind, bulkOperationsPayload{Operations: ops, Scope: limits.ScopeFrom(ctx)})
+	if err != nil {
+		log.Error("failed to start bulk operations job", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return jobs.Job{}, fmt.Errorf("failed to start bulk operations job: %w", err)
+	}
+	log.Info("bulk operations job started", slog.String("job_id", job.ID.String()))
+	return job, nil
+}
+
+// runBulkOperations is the handler of bulk operations jobs. The report and
+// checkpoint are saved after every chunk. A resumed job doesn't replay the
+// chunk it was interrupted in, since its operations may have been applied;
+// those not reported yet are marked unknown.
+func (s *WalletService) runBulkOperations(ctx context.Context, payload json.RawMessage, p *jobs.Progress) error {
+	var req bulkOperationsPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	ops := req.Operations
+	ctx = limits.WithScope(ctx, req.Scope)
+	p.SetTotal(int64(len(ops)))
+
+	var cp bulkOperationsCheckpoint
+	resumed, err := p.Checkpoint(&cp)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	if !resumed {
+		header, err := csvRows([][]string{{"index", "walletId", "operationType", "amount", "status", "version", "balance", "error"}})
+		if err != nil {
+			return fmt.Errorf("failed to build report: %w", err)
+		}
+		p.AppendReport("text/csv", header)
+	}
+	p.Restore(cp.Processed, cp.Failed)
+
+	if cp.Dispatched > cp.Next {
+		rows := make([][]string, 0, cp.Dispatched-cp.Next)
+		for i := cp.Next; i < cp.Dispatched; i++ {
+			rows = append(rows, bulkOperationRow(i, ops[i], "unknown", "interrupted while in progress; not retried"))
+		}
+		if err := s.appendBulkReport(p, rows); err != nil {
+			return err
+		}
+		p.Fail(int64(len(rows)))
+		cp.Failed += int64(len(rows))
+		cp.Processed += int64(len(rows))
+		cp.Next = cp.Dispatched
+	}
+
+	for cp.Next < len(ops) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := ops[cp.Next:min(cp.Next+bulkOperationsChunk, len(ops))]
+		cp.Dispatched = cp.Next + len(chunk)
+		if err := p.Save(ctx, cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+
+		results := s.processBulkChunk(ctx, chunk, p)
+		rows := make([][]string, 0, len(results))
+		for i, res := range results {
+			status, msg := "succeeded", ""
+			if res.err != nil {
+				status, msg = "failed", res.err.Error()
+				cp.Failed++
+			}
+			row := bulkOperationRow(cp.Next+i, chunk[i], status, msg)
+			if res.err == nil {
+				row[5], row[6] = strconv.Itoa(res.wallet.Version), strconv.FormatInt(res.wallet.Balance, 10)
+			}
+			rows = append(rows, row)
+		}
+		if err := s.appendBulkReport(p, rows); err != nil {
+			return err
+		}
+		cp.Processed += int64(len(results))
+		cp.Next += len(results)
+		cp.Dispatched = cp.Next
+		// On cancellation the final save of the job persists the checkpoint.
+		if err := p.Save(ctx, cp); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+// processBulkChunk runs chunk on the worker pool. Once ctx is done no more
+// operations are handed out, so the results cover a prefix of chunk.
+func (s *WalletService) processBulkChunk(ctx context.Context, chunk []models.WalletOperation, p *jobs.Progress) []bulkOperationResult {
+	results := make([]bulkOperationResult, len(chunk))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.bulkWorkers, len(chunk)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				wallet, err := s.ProcessOperation(ctx, chunk[i])
+				results[i] = bulkOperationResult{wallet: wallet, err: err}
+				if err != nil {
+					p.Fail(1)
+					continue
+				}
+				p.Add(1)
+			}
+		}()
+	}
+
+	dispatched := 0
+feed:
+	for i := range chunk {
+		select {
+		case next <- i:
+			dispatched++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results[:dispatched]
+}
+
+func (s *WalletService) appendBulkReport(p *jobs.Progress, rows [][]string) error {
+	data, err := csvRows(rows)
+	if err != nil {
+		return fmt.Errorf("failed to build report: %w", err)
+	}
+	p.AppendReport("text/csv", data)
+	return nil
+}
+
+// GetBulkOperationsJob returns a bulk operations job; other kinds of jobs
+// are reported as not found.
+func (s *WalletService) GetBulkOperationsJob(ctx context.Context, id uuid.UUID) (jobs.Job, error) {
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	if job.Kind != BulkOperationsJobKind {
+		return jobs.Job{}, jobs.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// GetBulkOperationsReport returns the results report of a bulk operations
+// job, with one CSV row per operation in input order.
+func (s *WalletService) GetBulkOperationsReport(ctx context.Context, id uuid.UUID) (jobs.Report, error) {
+	if _, err := s.GetBulkOperationsJob(ctx, id); err != nil {
+		return jobs.Report{}, err
+	}
+	return s.jobs.Report(ctx, id)
+}
+
+func bulkOperationRow(i int, op models.WalletOperation, status, msg string) []string {
+	return []string{strconv.Itoa(i), op.WalletID.String(), string(op.OperationType), strconv.FormatInt(op.Amount, 10), status, "", "", msg}
+}
+
+func csvRows(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/maintenance"
+	"wallet-service/internal/models"
+)
+
+// BulkStatusBatchSize is the number of wallets updated per statement by a
+// bulk status change.
+const BulkStatusBatchSize = 500
+
+const (
+	FreezeWalletsJobKind   = "wallets.freeze"
+	UnfreezeWalletsJobKind = "wallets.unfreeze"
+)
+
+var ErrFilterRequired = errors.New("filter must restrict at least one attribute")
+
+// SetWalletsStatus freezes or unfreezes every wallet matching filter in a
+// background job and returns the job right away; its progress is available
+// through GetJob. An empty filter is rejected so that a malformed request
+// can't freeze the whole book.
+//
+// Unfreezing lifts compliance holds in bulk and is therefore subject to the
+// maintenance gate: outside a window it is rejected or queued depending on
+// the gate's policy. Freezing is protective and always runs immediately.
+func (s *WalletService) SetWalletsStatus(ctx context.Context, filter models.WalletFilter, status models.WalletStatus) (jobs.Job, error) {
+	op := "service.SetWalletsStatus"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("status", string(status)))
+
+	if filter.IsEmpty() {
+		return jobs.Job{}, ErrFilterRequired
+	}
+	if status != models.WalletStatusActive && status != models.WalletStatusFrozen {
+		return jobs.Job{}, ErrInvalidInput
+	}
+
+	kind := FreezeWalletsJobKind
+	if status == models.WalletStatusActive {
+		kind = UnfreezeWalletsJobKind
+	}
+	if kind == UnfreezeWalletsJobKind && s.gate != nil && s.gate.Policy() == maintenance.PolicyReject {
+		if err := s.gate.Allow(kind); err != nil {
+			log.Warn("bulk status change rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return jobs.Job{}, err
+		}
+	}
+
+	job, err := s.jobs.Submit(ctx, kind, setWalletsStatusPayload{Filter: filter, Status: status})
+	if err != nil {
+		log.Error("failed to start bulk status change", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return jobs.Job{}, fmt.Errorf("failed to start bulk status change: %w", err)
+	}
+	log.Info("bulk status change started", slog.String("job_id", job.ID.String()))
+	return job, nil
+}
+
+type setWalletsStatusPayload struct {
+	Filter models.WalletFilter `json:"filter"`
+	Status models.WalletStatus `json:"status"`
+}
+
+// setWalletsStatusCheckpoint counts the wallets updated so far. Batches
+// only touch wallets not yet in the target status, so a resumed job simply
+// carries on.
+type setWalletsStatusCheckpoint struct {
+	Updated int64 `json:"updated"`
+}
+
+// runSetWalletsStatus is the handler of freeze and unfreeze jobs.
+func (s *WalletService) runSetWalletsStatus(ctx context.Context, payload json.RawMessage, p *jobs.Progress) error {
+	var req setWalletsStatusPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if req.Status == models.WalletStatusActive && s.gate != nil {
+		p.Queued()
+		if err := s.gate.Await(ctx, UnfreezeWalletsJobKind); err != nil {
+			return err
+		}
+		p.Running()
+	}
+
+	var cp setWalletsStatusCheckpoint
+	if _, err := p.Checkpoint(&cp); err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	p.Restore(cp.Updated, 0)
+
+	remaining, err := s.repo.CountWalletsToSetStatus(ctx, req.Filter, req.Status)
+	if err != nil {
+		return fmt.Errorf("failed to count wallets: %w", err)
+	}
+	p.SetTotal(cp.Updated + remaining)
+
+	for {
+		n, err := s.repo.SetWalletsStatus(ctx, req.Filter, req.Status, BulkStatusBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to update wallet status: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		p.Add(n)
+		cp.Updated += n
+		if err := p.Save(ctx, cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+}
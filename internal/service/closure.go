@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// PurgeBatchSize is the number of transactions PurgeClosedWallets archives
+// or deletes per statement, and the number of wallets it picks up at once.
+const PurgeBatchSize = 1000
+
+// ErrArchiveMismatch means an archive read back from object storage
+// differs from what was written, so the transactions were kept.
+var ErrArchiveMismatch = errors.New("archive verification failed")
+
+// WithPurge purges wallets closed for longer than recoveryWindow: their
+// transactions are archived to the object store (see WithObjectStore), the
+// archive is read back and verified, and only then are the transactions
+// deleted. Until then a closed wallet can be restored.
+func WithPurge(recoveryWindow time.Duration) Option {
+	return func(s *WalletService) {
+		s.recoveryWindow = recoveryWindow
+	}
+}
+
+// CloseWallet closes an empty wallet. It takes no further operations, but
+// can be restored until it is purged.
+func (s *WalletService) CloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.CloseWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var wallet *models.Wallet
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if wallet, err = s.repo.CloseWallet(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletClosed, "")
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletClosed) &&
+			!errors.Is(err, repository.ErrWalletNotEmpty) {
+			log.Error("failed to close wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to close wallet: %w", err)
+	}
+	log.Info("wallet closed")
+	return wallet, nil
+}
+
+// ForceCloseWallet closes a wallet like CloseWallet, sweeping its balance
+// off with a withdrawal first, in the same transaction. Held funds can't be
+// swept, so a wallet with holds still fails with ErrWalletNotEmpty.
+func (s *WalletService) ForceCloseWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.ForceCloseWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var swept, closed *models.Wallet
+	var sweep models.WalletOperation
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		swept = nil
+		wallet, err := s.repo.Wallets().LockWallet(ctx, id)
+		if err != nil {
+			return err
+		}
+		if wallet.Balance > 0 && wallet.HeldBalance == 0 && wallet.Status != models.WalletStatusClosed {
+			sweep = models.WalletOperation{WalletID: id, OperationType: models.OperationTypeWithdraw, Amount: wallet.Balance}
+			// The sweep isn't a withdrawal the owner asked for, so it
+			// isn't held to the withdrawal limits.
+			if swept, err = s.applyOperation(ctx, sweep, false); err != nil {
+				return err
+			}
+		}
+		if closed, err = s.repo.CloseWallet(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletClosed, "force")
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletClosed) &&
+			!errors.Is(err, repository.ErrWalletNotEmpty) && !errors.Is(err, repository.ErrWalletFrozen) {
+			log.Error("failed to close wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to close wallet: %w", err)
+	}
+	if swept != nil {
+		log.Info("wallet balance swept", slog.Int64("amount", sweep.Amount))
+		s.publishBalance(ctx, swept, sweep)
+		afterCommit(ctx, func() {
+			s.balances.put(swept)
+			s.runAfterHooks(ctx, swept, sweep)
+		})
+	}
+	log.Info("wallet closed")
+	return closed, nil
+}
+
+// RestoreWallet reopens a closed wallet within the recovery window.
+func (s *WalletService) RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.RestoreWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var wallet *models.Wallet
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if wallet, err = s.repo.RestoreWallet(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletRestored, "")
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletNotClosed) &&
+			!errors.Is(err, repository.ErrWalletPurged) {
+			log.Error("failed to restore wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to restore wallet: %w", err)
+	}
+	log.Info("wallet restored")
+	return wallet, nil
+}
+
+// PurgeClosedWallets purges the transactions of wallets whose recovery
+// window has passed. It is meant to run periodically and does nothing
+// unless WithPurge is set. A wallet whose purge fails is left as it is and
+// retried on the next run; wallets on legal hold are skipped.
+func (s *WalletService) PurgeClosedWallets(ctx context.Context) error {
+	if s.recoveryWindow <= 0 {
+		return nil
+	}
+	op := "service.PurgeClosedWallets"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if s.store == nil {
+		return storage.ErrNotConfigured
+	}
+
+	ids, err := s.repo.WalletsToPurge(ctx, time.Now().Add(-s.recoveryWindow), PurgeBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list wallets to purge: %w", err)
+	}
+	var errs []error
+	for _, id := range ids {
+		err := s.purgeWallet(ctx, id)
+		if errors.Is(err, repository.ErrWalletOnLegalHold) {
+			log.Info("wallet on legal hold not purged", slog.String("wallet_id", id.String()))
+			continue
+		}
+		if err != nil {
+			log.Error("failed to purge wallet", slog.String("wallet_id", id.String()),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			errs = append(errs, fmt.Errorf("wallet %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// purgeWallet archives the remaining transactions of a closed wallet, then
+// deletes them and marks the wallet purged. The archive is named after the
+// first version it holds, so a purge interrupted after deleting some
+// transactions writes the rest to a new archive instead of overwriting the
+// earlier one.
+func (s *WalletService) purgeWallet(ctx context.Context, id uuid.UUID) error {
+	log := s.logger(ctx).With(slog.String("op", "service.purgeWallet"), slog.String("wallet_id", id.String()))
+
+	batch, err := s.repo.ArchiveTransactions(ctx, id, 0, PurgeBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to read transactions: %w", err)
+	}
+	if len(batch) > 0 {
+		key := fmt.Sprintf("archives/wallets/%s/transactions-from-%d.ndjson", id, batch[0].Version)
+		last, count, err := s.archiveTransactions(ctx, id, key, batch)
+		if err != nil {
+			return err
+		}
+		log.Info("transactions archived", slog.String("key", key), slog.Int("count", count))
+
+		var deleted int64
+		for {
+			n, err := s.repo.DeleteTransactions(ctx, id, last, PurgeBatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to delete transactions: %w", err)
+			}
+			if n == 0 {
+				break
+			}
+			deleted += n
+		}
+		log.Info("archived transactions deleted", slog.Int64("count", deleted))
+	}
+
+	err = s.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkWalletPurged(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletPurged, "")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark wallet purged: %w", err)
+	}
+	log.Info("wallet purged")
+	return nil
+}
+
+// archiveTransactions uploads the wallet's transactions, starting with
+// batch, as NDJSON under key and reads the object back to check that it
+// holds exactly what was written. It returns the last archived version and
+// the number of transactions. Transactions can't be added to a closed
+// wallet, so the archive is complete.
+func (s *WalletService) archiveTransactions(ctx context.Context, id uuid.UUID, key string, batch []models.ArchivedTransaction) (int, int, error) {
+	var last, count int
+	written := sha256.New()
+	pr, pw := io.Pipe()
+	go func() {
+		enc := json.NewEncoder(io.MultiWriter(pw, written))
+		for {
+			for i := range batch {
+				if err := enc.Encode(&batch[i]); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+			count += len(batch)
+			last = batch[len(batch)-1].Version
+			if len(batch) < PurgeBatchSize {
+				break
+			}
+			var err error
+			if batch, err = s.repo.ArchiveTransactions(ctx, id, last, PurgeBatchSize); err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to read transactions: %w", err))
+				return
+			}
+			if len(batch) == 0 {
+				break
+			}
+		}
+		pw.Close()
+	}()
+	if err := s.store.Put(ctx, key, pr, "application/x-ndjson"); err != nil {
+		pr.CloseWithError(err)
+		return 0, 0, fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	body, err := s.store.Get(ctx, key)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read back archive: %w", err)
+	}
+	defer body.Close()
+	read := sha256.New()
+	lines := bufio.NewScanner(io.TeeReader(body, read))
+	lines.Buffer(nil, 1<<20)
+	stored := 0
+	for lines.Scan() {
+		stored++
+	}
+	if err := lines.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to read back archive: %w", err)
+	}
+	if stored != count || !bytes.Equal(read.Sum(nil), written.Sum(nil)) {
+		return 0, 0, fmt.Errorf("%w: %s holds %d of %d transactions or differs in content", ErrArchiveMismatch, key, stored, count)
+	}
+	return last, count, nil
+}
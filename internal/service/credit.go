@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// SetCreditLimit lets withdrawals take a wallet's balance down to -limit;
+// zero allows no overdraft. A lower limit must still cover what the wallet
+// owes and holds, or it fails with repository.ErrCreditLimitTooLow. The
+// change is recorded in the audit log.
+func (s *WalletService) SetCreditLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	op := "service.SetCreditLimit"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: credit limit must not be negative", ErrInvalidInput)
+	}
+
+	var wallet *models.Wallet
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.Wallets().LockWallet(ctx, id)
+		if err != nil {
+			return err
+		}
+		if wallet, err = s.repo.SetCreditLimit(ctx, id, limit); err != nil {
+			return err
+		}
+		afterCommit(ctx, func() { s.balances.forget(id) })
+		return s.audit(ctx, id, models.AuditCreditLimitSet, fmt.Sprintf("%d -> %d", current.CreditLimit, limit))
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrCreditLimitTooLow) {
+			log.Error("failed to set credit limit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to set credit limit: %w", err)
+	}
+	log.Info("credit limit set", slog.Int64("credit_limit", limit))
+	return wallet, nil
+}
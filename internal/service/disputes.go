@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultDisputeWindow is how long a dispute stays open before it
+	// expires and its hold is released.
+	DefaultDisputeWindow = 30 * 24 * time.Hour
+
+	// DisputeExpiryBatchSize is the number of due disputes ExpireDisputes
+	// fetches at a time.
+	DisputeExpiryBatchSize = 100
+)
+
+// Webhook event types of the dispute lifecycle.
+const (
+	EventDisputeOpened   = "dispute.opened"
+	EventDisputeReversed = "dispute.reversed"
+	EventDisputeReleased = "dispute.released"
+	EventDisputeExpired  = "dispute.expired"
+)
+
+// WithDisputeWindow sets the deadline of new disputes.
+func WithDisputeWindow(window time.Duration) Option {
+	return func(s *WalletService) {
+		s.disputeWindow = window
+	}
+}
+
+// WithNotifier sends lifecycle events to n.
+func WithNotifier(n webhook.Notifier) Option {
+	return func(s *WalletService) {
+		s.notifier = n
+	}
+}
+
+// notify delivers an event in the background of a committed change, so
+// delivery failures are only logged. Within a WithinTx transaction the
+// event waits for the commit.
+func (s *WalletService) notify(ctx context.Context, typ string, data any) {
+	if s.notifier == nil {
+		return
+	}
+	afterCommit(ctx, func() {
+		e := webhook.NewEvent(typ, data)
+		if err := s.notifier.Notify(context.WithoutCancel(ctx), e); err != nil {
+			s.logger(ctx).Error("failed to deliver webhook", slog.String("event_id", e.ID.String()), slog.String("type", typ),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+	})
+}
+
+// OpenDispute disputes a wallet operation. A disputed deposit is held on
+// the wallet until the dispute is resolved or expires.
+func (s *WalletService) OpenDispute(ctx context.Context, req models.OpenDisputeRequest) (*models.Dispute, error) {
+	op := "service.OpenDispute"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", req.WalletID.String()), slog.Int("version", req.Version))
+
+	if req.WalletID == uuid.Nil || req.Version < 2 {
+		return nil, fmt.Errorf("%w: walletId and a version of an operation are required", ErrInvalidInput)
+	}
+	if req.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	d, err := s.repo.OpenDispute(ctx, models.Dispute{
+		ID:        uuid.New(),
+		WalletID:  req.WalletID,
+		Version:   req.Version,
+		Reason:    req.Reason,
+		Deadline:  now.Add(s.disputeWindow),
+		CreatedAt: now,
+	})
+	if err != nil {
+		if isDisputeRejection(err) {
+			log.Warn("dispute rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("failed to open dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to open dispute: %w", err)
+	}
+	log.Info("dispute opened", slog.String("dispute_id", d.ID.String()), slog.Int64("hold", d.HoldAmount))
+	s.notify(ctx, EventDisputeOpened, d)
+	s.publish(ctx, EventDisputeOpened, d)
+	return d, nil
+}
+
+func (s *WalletService) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	d, err := s.repo.GetDispute(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDisputeNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve dispute: %w", err)
+	}
+	return d, nil
+}
+
+func (s *WalletService) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]models.Dispute, error) {
+	switch status {
+	case "", models.DisputeStatusOpen, models.DisputeStatusReversed, models.DisputeStatusReleased, models.DisputeStatusExpired:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, status)
+	}
+	disputes, err := s.repo.ListDisputes(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	return disputes, nil
+}
+
+// ResolveDispute closes an open dispute either by reversing the disputed
+// operation or by releasing the hold and keeping it.
+func (s *WalletService) ResolveDispute(ctx context.Context, id uuid.UUID, req models.ResolveDisputeRequest) (*models.Dispute, error) {
+	op := "service.ResolveDispute"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("dispute_id", id.String()), slog.String("outcome", req.Outcome))
+
+	var status models.DisputeStatus
+	var event string
+	switch req.Outcome {
+	case models.DisputeOutcomeReverse:
+		status, event = models.DisputeStatusReversed, EventDisputeReversed
+	case models.DisputeOutcomeRelease:
+		status, event = models.DisputeStatusReleased, EventDisputeReleased
+	default:
+		return nil, fmt.Errorf("%w: outcome must be %s or %s", ErrInvalidInput, models.DisputeOutcomeReverse, models.DisputeOutcomeRelease)
+	}
+
+	var d *models.Dispute
+	closeDispute := func(ctx context.Context) ([]models.WalletOperation, error) {
+		var err error
+		if d, err = s.repo.CloseDispute(ctx, id, status, req.Note, time.Now().UTC()); err != nil || d.ReversalVersion == nil {
+			return nil, err
+		}
+		return []models.WalletOperation{{WalletID: d.WalletID, OperationType: disputeReversal(d.OperationType), Amount: d.Amount}}, nil
+	}
+	var err error
+	if s.outbox != nil && status == models.DisputeStatusReversed {
+		// The reversal is written with its event on the shard of the
+		// disputed wallet.
+		var disputed *models.Dispute
+		if disputed, err = s.repo.GetDispute(ctx, id); err == nil {
+			err = s.withBalanceEvents(ctx, disputed.WalletID, closeDispute)
+		}
+	} else {
+		_, err = closeDispute(ctx)
+	}
+	if err != nil {
+		if isDisputeRejection(err) {
+			log.Warn("dispute resolution rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("failed to resolve dispute", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to resolve dispute: %w", err)
+	}
+	log.Info("dispute resolved")
+	s.notify(ctx, event, d)
+	s.publish(ctx, event, d)
+	return d, nil
+}
+
+// ExpireDisputes closes open disputes past their deadline, releasing their
+// holds.
+func (s *WalletService) ExpireDisputes(ctx context.Context) error {
+	op := "service.ExpireDisputes"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	now := time.Now().UTC()
+	expired := 0
+	for {
+		ids, err := s.repo.DueDisputes(ctx, now, DisputeExpiryBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list due disputes: %w", err)
+		}
+		for _, id := range ids {
+			d, err := s.repo.CloseDispute(ctx, id, models.DisputeStatusExpired, "deadline passed", now)
+			if errors.Is(err, repository.ErrDisputeClosed) {
+				continue
+			}
+			if err != nil {
+				log.Error("failed to expire dispute", slog.String("dispute_id", id.String()), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				return fmt.Errorf("failed to expire dispute %s: %w", id, err)
+			}
+			expired++
+			s.notify(ctx, EventDisputeExpired, d)
+			s.publish(ctx, EventDisputeExpired, d)
+		}
+		if len(ids) < DisputeExpiryBatchSize {
+			break
+		}
+	}
+
+	if expired > 0 {
+		log.Info("disputes expired", slog.Int("count", expired))
+	}
+	return nil
+}
+
+// disputeReversal is the operation reversing a disputed one of type
+// disputed.
+func disputeReversal(disputed models.OperationType) models.OperationType {
+	if disputed == models.OperationTypeWithdraw {
+		return models.OperationTypeReversalCredit
+	}
+	return models.OperationTypeReversalDebit
+}
+
+func isDisputeRejection(err error) bool {
+	return errors.Is(err, repository.ErrWalletNotFound) ||
+		errors.Is(err, repository.ErrTransactionNotFound) ||
+		errors.Is(err, repository.ErrNotDisputable) ||
+		errors.Is(err, repository.ErrDisputeExists) ||
+		errors.Is(err, repository.ErrDisputeNotFound) ||
+		errors.Is(err, repository.ErrDisputeClosed) ||
+		errors.Is(err, repository.ErrInsufficientFunds) ||
+		errors.Is(err, repository.ErrWalletFrozen)
+}
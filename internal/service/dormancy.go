@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// DormancyBatchSize is the number of wallets FlagDormantWallets flags per
+// statement.
+const DormancyBatchSize = 500
+
+// Webhook event types of the dormancy lifecycle.
+const (
+	EventWalletDormant     = "wallet.dormant"
+	EventWalletReactivated = "wallet.reactivated"
+)
+
+// ErrReactivationRequired rejects a large withdrawal from a dormant wallet.
+var ErrReactivationRequired = errors.New("dormant wallet must be reactivated")
+
+type dormancyPolicy struct {
+	idle      time.Duration
+	threshold int64
+	notify    bool
+}
+
+// WithDormancy flags wallets without changes for idle as dormant (see
+// FlagDormantWallets). Withdrawals of at least reactivationThreshold from a
+// dormant wallet are rejected until it is reactivated; smaller ones and
+// deposits go through. With notify, owners are told through the notifier
+// (see WithNotifier) when their wallet turns dormant or is reactivated.
+func WithDormancy(idle time.Duration, reactivationThreshold int64, notify bool) Option {
+	return func(s *WalletService) {
+		s.dormancy = &dormancyPolicy{idle: idle, threshold: reactivationThreshold, notify: notify}
+	}
+}
+
+// FlagDormantWallets marks active wallets idle for longer than the dormancy
+// period as dormant. It is meant to run periodically and does nothing
+// unless WithDormancy is set.
+func (s *WalletService) FlagDormantWallets(ctx context.Context) error {
+	if s.dormancy == nil {
+		return nil
+	}
+	op := "service.FlagDormantWallets"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	idleSince := time.Now().Add(-s.dormancy.idle)
+	flagged := 0
+	for {
+		wallets, err := s.repo.FlagDormantWallets(ctx, idleSince, DormancyBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to flag dormant wallets: %w", err)
+		}
+		for i := range wallets {
+			s.publish(ctx, EventWalletDormant, &wallets[i])
+			if s.dormancy.notify {
+				s.notify(ctx, EventWalletDormant, &wallets[i])
+			}
+		}
+		flagged += len(wallets)
+		if len(wallets) < DormancyBatchSize {
+			break
+		}
+	}
+
+	if flagged > 0 {
+		log.Info("wallets flagged dormant", slog.Int("count", flagged))
+	}
+	return nil
+}
+
+// ReactivateWallet returns a dormant wallet to active, lifting the
+// restriction on large withdrawals.
+func (s *WalletService) ReactivateWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.ReactivateWallet"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	wallet, err := s.repo.ReactivateWallet(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) || errors.Is(err, repository.ErrWalletNotDormant) {
+			log.Warn("wallet not reactivated", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		} else {
+			log.Error("failed to reactivate wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to reactivate wallet: %w", err)
+	}
+	log.Info("wallet reactivated")
+	s.publish(ctx, EventWalletReactivated, wallet)
+	if s.dormancy != nil && s.dormancy.notify {
+		s.notify(ctx, EventWalletReactivated, wallet)
+	}
+	return wallet, nil
+}
+
+// checkDormancy rejects withdrawals of at least the reactivation threshold
+// from dormant wallets.
+func (s *WalletService) checkDormancy(ctx context.Context, operation models.WalletOperation) error {
+	if s.dormancy == nil || operation.OperationType != models.OperationTypeWithdraw || operation.Amount < s.dormancy.threshold {
+		return nil
+	}
+	wallet, err := s.repo.GetWallet(ctx, operation.WalletID)
+	if err != nil {
+		return err
+	}
+	if wallet.Status == models.WalletStatusDormant {
+		s.logger(ctx).Warn("withdrawal from dormant wallet rejected", slog.String("op", "service.checkDormancy"),
+			slog.String("wallet_id", wallet.ID.String()), slog.Int64("amount", operation.Amount))
+		return ErrReactivationRequired
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// EventSourcingPolicy configures event-sourced wallet state: a snapshot of
+// a wallet is saved once an operation finds SnapshotEvery versions to
+// replay since the last one.
+type EventSourcingPolicy struct {
+	SnapshotEvery int
+}
+
+// WithEventSourcing makes the wallets' histories the source of their
+// balances. ProcessOperation replays a wallet's versions, from its latest
+// snapshot, to find the balances an operation applies to; the stored
+// balances become a projection of the history, updated in the same
+// transaction and corrected if they have drifted. Transfers, atomic
+// operations and captures append to the history but apply to the stored
+// balances.
+func WithEventSourcing(p EventSourcingPolicy) Option {
+	return func(s *WalletService) {
+		s.eventSourcing = &p
+	}
+}
+
+// replayWallet sets the balances of wallet, locked in the transaction of
+// ctx, to those its history adds up to, and returns how many versions it
+// replayed since the latest snapshot. It does nothing without
+// WithEventSourcing.
+func (s *WalletService) replayWallet(ctx context.Context, wallet *models.Wallet) (int, error) {
+	if s.eventSourcing == nil {
+		return 0, nil
+	}
+	start, err := s.repo.Snapshots().LatestSnapshot(ctx, wallet.ID)
+	if err != nil {
+		return 0, err
+	}
+	if start == nil {
+		start = &models.WalletSnapshot{WalletID: wallet.ID, Version: 1}
+	}
+	versions, err := s.repo.Snapshots().VersionsAfter(ctx, wallet.ID, start.Version)
+	if err != nil {
+		return 0, err
+	}
+	replayed, err := ledger.Replay(*start, versions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay wallet %s: %w", wallet.ID, err)
+	}
+	if replayed.Version != wallet.Version {
+		return 0, fmt.Errorf("failed to replay wallet %s: %w: history ends at version %d of %d",
+			wallet.ID, ledger.ErrHistoryGap, replayed.Version, wallet.Version)
+	}
+
+	if replayed.Balance != wallet.Balance || replayed.PromoBalance != wallet.PromoBalance {
+		s.logger(ctx).Warn("wallet balances drifted from history", slog.String("wallet_id", wallet.ID.String()),
+			slog.Int64("stored", wallet.Balance), slog.Int64("replayed", replayed.Balance))
+		wallet.Balance, wallet.PromoBalance = replayed.Balance, replayed.PromoBalance
+	}
+	return len(versions), nil
+}
+
+// snapshotWallet saves a snapshot of wallet, just updated in the
+// transaction of ctx, if it is sinceSnapshot versions past its latest one.
+func (s *WalletService) snapshotWallet(ctx context.Context, wallet *models.Wallet, sinceSnapshot int) error {
+	if s.eventSourcing == nil || sinceSnapshot < s.eventSourcing.SnapshotEvery {
+		return nil
+	}
+	return s.repo.Snapshots().SaveSnapshot(ctx, models.WalletSnapshot{
+		WalletID:     wallet.ID,
+		Version:      wallet.Version,
+		Balance:      wallet.Balance,
+		PromoBalance: wallet.PromoBalance,
+		CreatedAt:    wallet.UpdatedAt,
+	})
+}
+
+// RebuildWallet replays a wallet's whole history, ignoring snapshots, and
+// compares the state it adds up to with the stored balances. With repair,
+// balances that don't match are overwritten with the replayed ones and the
+// repair is recorded in the audit log. A history that doesn't reach the
+// wallet's version, because its transactions were purged, can't be
+// replayed and fails with ledger.ErrHistoryGap.
+func (s *WalletService) RebuildWallet(ctx context.Context, id uuid.UUID, repair bool) (*models.WalletRebuild, error) {
+	op := "service.RebuildWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var rebuild *models.WalletRebuild
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.repo.Wallets().LockWallet(ctx, id)
+		if err != nil {
+			return err
+		}
+		versions, err := s.repo.Snapshots().VersionsAfter(ctx, id, 0)
+		if err != nil {
+			return err
+		}
+		replayed, err := ledger.Replay(models.WalletSnapshot{WalletID: id, Version: 1}, versions)
+		if err != nil {
+			return err
+		}
+		if replayed.Version != wallet.Version {
+			return fmt.Errorf("%w: history ends at version %d of %d", ledger.ErrHistoryGap, replayed.Version, wallet.Version)
+		}
+		replayed.CreatedAt = time.Now().UTC()
+
+		rebuild = &models.WalletRebuild{
+			WalletID: id,
+			Stored: models.WalletSnapshot{
+				WalletID:     id,
+				Version:      wallet.Version,
+				Balance:      wallet.Balance,
+				PromoBalance: wallet.PromoBalance,
+				CreatedAt:    wallet.UpdatedAt,
+			},
+			Replayed:   replayed,
+			Events:     len(versions),
+			Consistent: replayed.Balance == wallet.Balance && replayed.PromoBalance == wallet.PromoBalance,
+		}
+		if rebuild.Consistent || !repair {
+			return nil
+		}
+		if err := s.repo.Snapshots().RestoreBalances(ctx, replayed); err != nil {
+			return err
+		}
+		rebuild.Repaired = true
+		afterCommit(ctx, func() { s.balances.forget(id) })
+		detail := fmt.Sprintf("balance %d -> %d, promo balance %d -> %d",
+			wallet.Balance, replayed.Balance, wallet.PromoBalance, replayed.PromoBalance)
+		return s.audit(ctx, id, models.AuditBalancesRebuilt, detail)
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) {
+			log.Error("failed to rebuild wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to rebuild wallet: %w", err)
+	}
+
+	if rebuild.Repaired {
+		log.Warn("wallet balances rebuilt from history",
+			slog.Int64("stored", rebuild.Stored.Balance), slog.Int64("replayed", rebuild.Replayed.Balance))
+	} else if !rebuild.Consistent {
+		log.Warn("wallet balances don't match history",
+			slog.Int64("stored", rebuild.Stored.Balance), slog.Int64("replayed", rebuild.Replayed.Balance))
+	}
+	return rebuild, nil
+}
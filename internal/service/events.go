@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+)
+
+const (
+	// EventSettleDelay holds back the newest events so that transactions
+	// still committing can't land behind a reader's offset.
+	EventSettleDelay = 5 * time.Second
+
+	DefaultEventExportLimit = 10000
+	MaxEventExportLimit     = 100000
+	eventBatchSize          = 1000
+)
+
+// ExportEvents streams up to limit ledger events with an offset greater
+// than after to fn in batches, and returns the offset to resume from: that
+// of the last event passed to fn, or after if there was none. limit is
+// clamped to (0, MaxEventExportLimit].
+func (s *WalletService) ExportEvents(ctx context.Context, after int64, limit int, fn func([]models.LedgerEvent) error) (int64, error) {
+	op := "service.ExportEvents"
+	log := s.logger(ctx).With(slog.String("op", op), slog.Int64("after", after))
+
+	if after < 0 {
+		return after, fmt.Errorf("%w: offset must not be negative", ErrInvalidInput)
+	}
+	if limit <= 0 {
+		limit = DefaultEventExportLimit
+	}
+	if limit > MaxEventExportLimit {
+		limit = MaxEventExportLimit
+	}
+
+	until := time.Now().UTC().Add(-EventSettleDelay)
+	next := after
+	for exported := 0; exported < limit; {
+		batch, err := s.repo.ListEvents(ctx, next, until, min(eventBatchSize, limit-exported))
+		if err != nil {
+			log.Error("event export failed", slog.Int64("next", next), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return next, fmt.Errorf("failed to export events: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := fn(batch); err != nil {
+			return next, err
+		}
+		next = batch[len(batch)-1].Offset
+		exported += len(batch)
+	}
+
+	log.Info("events exported", slog.Int64("next", next))
+	return next, nil
+}
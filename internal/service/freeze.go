@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// FreezeWallet places a fraud or compliance hold on a wallet: operations on
+// it are rejected with repository.ErrWalletFrozen until it is unfrozen.
+// Unlike SetWalletsStatus it acts on one wallet at once, outside of any
+// maintenance window. The freeze is recorded in the audit log.
+func (s *WalletService) FreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.FreezeWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var wallet *models.Wallet
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if wallet, err = s.repo.FreezeWallet(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletFrozen, "")
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletFrozen) &&
+			!errors.Is(err, repository.ErrWalletClosed) {
+			log.Error("failed to freeze wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to freeze wallet: %w", err)
+	}
+	log.Info("wallet frozen")
+	return wallet, nil
+}
+
+// UnfreezeWallet lifts the hold FreezeWallet placed on a wallet.
+func (s *WalletService) UnfreezeWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	op := "service.UnfreezeWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String())))
+
+	var wallet *models.Wallet
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if wallet, err = s.repo.UnfreezeWallet(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, id, models.AuditWalletUnfrozen, "")
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletNotFound) && !errors.Is(err, repository.ErrWalletNotFrozen) &&
+			!errors.Is(err, repository.ErrWalletClosed) {
+			log.Error("failed to unfreeze wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to unfreeze wallet: %w", err)
+	}
+	log.Info("wallet unfrozen")
+	return wallet, nil
+}
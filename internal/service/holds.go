@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// PlaceHold reserves part of a wallet's available balance, e.g. for a card
+// authorization. The hold is screened like a withdrawal of its amount,
+// since capturing it later withdraws without further checks.
+func (s *WalletService) PlaceHold(ctx context.Context, walletID uuid.UUID, req models.PlaceHoldRequest) (*models.FundsHold, error) {
+	op := "service.PlaceHold"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String())))
+
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrAmountMustBePositive)
+	}
+	withdrawal := models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: req.Amount}
+	if err := s.screenOperation(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+	if err := s.checkDormancy(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+
+	var hold *models.FundsHold
+	err := s.retry(ctx, op, func() error {
+		var err error
+		hold, err = s.repo.PlaceHold(ctx, models.FundsHold{
+			ID:        uuid.New(),
+			WalletID:  walletID,
+			Amount:    req.Amount,
+			Reference: req.Reference,
+			CreatedAt: time.Now().UTC(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, holdError(log, "failed to place hold", err)
+	}
+	log.Info("hold placed", slog.String("hold_id", hold.ID.String()), slog.Int64("amount", hold.Amount))
+	afterCommit(ctx, func() { s.balances.forget(walletID) })
+	return hold, nil
+}
+
+func (s *WalletService) ListHolds(ctx context.Context, walletID uuid.UUID) ([]models.FundsHold, error) {
+	holds, err := s.repo.ListHolds(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holds: %w", err)
+	}
+	return holds, nil
+}
+
+// CaptureHold withdraws req.Amount of a pending hold, or all of it for a
+// zero amount, and releases the rest. The withdrawal counts against the
+// withdrawal limits.
+func (s *WalletService) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, req models.CaptureHoldRequest) (*models.FundsHold, error) {
+	op := "service.CaptureHold"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()),
+		slog.String("hold_id", holdID.String())))
+
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("%w: amount must not be negative", ErrInvalidInput)
+	}
+
+	var hold *models.FundsHold
+	err := s.retry(ctx, op, func() error {
+		amount := func(ctx context.Context) (int64, error) { return s.captureAmount(ctx, walletID, holdID, req.Amount) }
+		return s.withWithdrawalLimit(ctx, walletID, amount, func(ctx context.Context) error {
+			return s.withBalanceEvents(ctx, walletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+				var err error
+				if hold, err = s.repo.CaptureHold(ctx, walletID, holdID, req.Amount, time.Now().UTC()); err != nil {
+					return nil, err
+				}
+				return []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: hold.CapturedAmount}}, nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, holdError(log, "failed to capture hold", err)
+	}
+	log.Info("hold captured", slog.Int64("amount", hold.CapturedAmount))
+	afterCommit(ctx, func() { s.balances.forget(walletID) })
+	return hold, nil
+}
+
+// ReleaseHold gives a pending hold back to the wallet's available balance.
+func (s *WalletService) ReleaseHold(ctx context.Context, walletID, holdID uuid.UUID) (*models.FundsHold, error) {
+	op := "service.ReleaseHold"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()),
+		slog.String("hold_id", holdID.String())))
+
+	var hold *models.FundsHold
+	err := s.retry(ctx, op, func() error {
+		var err error
+		hold, err = s.repo.ReleaseHold(ctx, walletID, holdID, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		return nil, holdError(log, "failed to release hold", err)
+	}
+	log.Info("hold released")
+	afterCommit(ctx, func() { s.balances.forget(walletID) })
+	return hold, nil
+}
+
+// captureAmount is what capturing amount of a hold withdraws: all of it
+// when amount is zero. Holds that can't be captured withdraw nothing and are
+// left for CaptureHold to reject.
+func (s *WalletService) captureAmount(ctx context.Context, walletID, holdID uuid.UUID, amount int64) (int64, error) {
+	if amount != 0 {
+		return amount, nil
+	}
+	holds, err := s.repo.ListHolds(ctx, walletID)
+	if err != nil {
+		return 0, err
+	}
+	for _, h := range holds {
+		if h.ID == holdID && h.Status == models.FundsHoldPending {
+			return h.Amount, nil
+		}
+	}
+	return 0, nil
+}
+
+// holdError returns rejections of a hold request as they are and wraps
+// other failures in msg.
+func holdError(log *slog.Logger, msg string, err error) error {
+	if isHoldRejection(err) {
+		log.Warn("hold request rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	log.Error(msg, slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func isHoldRejection(err error) bool {
+	return errors.Is(err, repository.ErrWalletNotFound) ||
+		errors.Is(err, repository.ErrHoldNotFound) ||
+		errors.Is(err, repository.ErrHoldResolved) ||
+		errors.Is(err, repository.ErrHoldExceeded) ||
+		errors.Is(err, repository.ErrInsufficientFunds) ||
+		errors.Is(err, repository.ErrWalletFrozen) ||
+		errors.Is(err, repository.ErrWalletClosed) ||
+		errors.Is(err, ErrLimitExceeded)
+}
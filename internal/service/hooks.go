@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/models"
+)
+
+// ErrOperationRejected wraps the error of a BeforeOperation hook that
+// refused an operation.
+var ErrOperationRejected = errors.New("operation rejected")
+
+// BeforeOperation is consulted before an operation is applied, e.g. for
+// risk checks or custom business rules. Hooks run in registration order
+// after validation, limits and screening have passed; the first error
+// stops the chain, the operation is not applied and the error is returned
+// wrapped in ErrOperationRejected. For atomic requests the hooks see every
+// step, and one rejected step rejects the whole request.
+type BeforeOperation interface {
+	BeforeOperation(ctx context.Context, operation models.WalletOperation) error
+}
+
+// AfterOperation observes an operation once it has been committed, e.g. for
+// notifications or metrics. Hooks run in registration order and can't
+// undo the operation, so they report nothing back; they must not block, as
+// they run before the caller gets its response.
+type AfterOperation interface {
+	AfterOperation(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation)
+}
+
+// BeforeOperationFunc adapts a function to BeforeOperation.
+type BeforeOperationFunc func(ctx context.Context, operation models.WalletOperation) error
+
+func (f BeforeOperationFunc) BeforeOperation(ctx context.Context, operation models.WalletOperation) error {
+	return f(ctx, operation)
+}
+
+// AfterOperationFunc adapts a function to AfterOperation.
+type AfterOperationFunc func(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation)
+
+func (f AfterOperationFunc) AfterOperation(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation) {
+	f(ctx, wallet, operation)
+}
+
+// WithBeforeOperation appends hooks run before every operation, atomic step
+// and transfer leg.
+func WithBeforeOperation(hooks ...BeforeOperation) Option {
+	return func(s *WalletService) {
+		s.beforeHooks = append(s.beforeHooks, hooks...)
+	}
+}
+
+// WithAfterOperation appends hooks run after every committed operation,
+// atomic step and transfer leg.
+func WithAfterOperation(hooks ...AfterOperation) Option {
+	return func(s *WalletService) {
+		s.afterHooks = append(s.afterHooks, hooks...)
+	}
+}
+
+func (s *WalletService) runBeforeHooks(ctx context.Context, operation models.WalletOperation, log *slog.Logger) error {
+	for i, hook := range s.beforeHooks {
+		if err := hook.BeforeOperation(ctx, operation); err != nil {
+			log.Warn("operation rejected by hook", slog.Int("hook", i), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return fmt.Errorf("%w: %w", ErrOperationRejected, err)
+		}
+	}
+	return nil
+}
+
+// runAfterHooks uses a context that outlives the request, as the operation
+// is already committed by the time the hooks run.
+func (s *WalletService) runAfterHooks(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation) {
+	ctx = context.WithoutCancel(ctx)
+	for _, hook := range s.afterHooks {
+		hook.AfterOperation(ctx, wallet, operation)
+	}
+}
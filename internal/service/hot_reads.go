@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMissCacheTTL is how long a wallet id that wasn't found is
+	// answered as missing without querying the database. It is kept short
+	// because a wallet read from a lagging replica right after its creation
+	// may be reported missing.
+	DefaultMissCacheTTL = time.Second
+
+	// maxMissCacheEntries bounds the memory enumeration traffic can pin.
+	maxMissCacheEntries = 100000
+)
+
+// WithMissCacheTTL sets how long missing wallet ids are remembered; zero
+// disables negative caching.
+func WithMissCacheTTL(ttl time.Duration) Option {
+	return func(s *WalletService) {
+		s.misses = newMissCache(ttl)
+	}
+}
+
+type flightCall[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+	dups int
+}
+
+// flightGroup collapses concurrent calls with the same key into one: later
+// callers wait for the result of the call in flight instead of issuing
+// their own.
+type flightGroup[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+// do runs fn for key unless a call for key is in flight, in which case it
+// waits for that call's result. A waiter whose own context is still live
+// runs fn itself when the shared call failed because its caller's context
+// ended. Calls within a WithinTx transaction run on their own, as they
+// may see writes nobody else may yet.
+func (g *flightGroup[K, V]) do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+		if isContextError(c.err) && ctx.Err() == nil {
+			return fn(ctx)
+		}
+		return c.val, c.err
+	}
+	c := &flightCall[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+	return c.val, c.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// missCache remembers wallet ids recently found missing.
+type missCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]time.Time
+}
+
+func newMissCache(ttl time.Duration) *missCache {
+	return &missCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (c *missCache) missing(id uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires, ok := c.entries[id]
+	if !ok {
+		return false
+	}
+	if !c.now().Before(expires) {
+		delete(c.entries, id)
+		return false
+	}
+	return true
+}
+
+func (c *missCache) add(id uuid.UUID) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxMissCacheEntries {
+		for k, expires := range c.entries {
+			if !now.Before(expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full: drop arbitrary entries rather than grow.
+		for k := range c.entries {
+			if len(c.entries) < maxMissCacheEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[id] = now.Add(c.ttl)
+}
+
+func (c *missCache) forget(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
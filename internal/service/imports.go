@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/screening"
+
+	"github.com/google/uuid"
+)
+
+// importNamespace derives the ids of imported wallets from their external
+// ids. The same external id always maps to the same wallet, and so to the
+// same shard, where duplicate imports are detected.
+var importNamespace = uuid.MustParse("6f1b3c1e-2d4a-5e8f-9a7b-0c1d2e3f4a5b")
+
+// ImportWallet migrates a wallet from a legacy ledger with its balance.
+// Importing the same ExternalID twice fails with
+// repository.ErrWalletAlreadyImported.
+func (s *WalletService) ImportWallet(ctx context.Context, req models.ImportWalletRequest) (*models.Wallet, error) {
+	op := "service.ImportWallet"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("external_id", req.ExternalID)))
+
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	switch {
+	case req.ExternalID == "":
+		return nil, fmt.Errorf("%w: externalId is required", ErrInvalidInput)
+	case !isCurrencyCode(req.Currency):
+		return nil, fmt.Errorf("%w: invalid currency %q", ErrInvalidInput, req.Currency)
+	case req.Balance < 0:
+		return nil, fmt.Errorf("%w: balance must not be negative", ErrInvalidInput)
+	case req.OpenedAt != nil && req.OpenedAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: openedAt is in the future", ErrInvalidInput)
+	}
+	if req.OwnerID.Valid {
+		if err := s.screen(ctx, "wallet.import", screening.Subject{Kind: screening.KindOwner, ID: req.OwnerID.UUID.String()}); err != nil {
+			return nil, err
+		}
+	}
+
+	id := uuid.NewSHA1(importNamespace, []byte(req.ExternalID))
+	var wallet *models.Wallet
+	err := s.withBalanceEvents(ctx, id, func(ctx context.Context) ([]models.WalletOperation, error) {
+		var err error
+		wallet, err = s.repo.ImportWallet(ctx, id, req)
+		return []models.WalletOperation{{WalletID: id, OperationType: models.OperationTypeOpeningBalance, Amount: req.Balance}}, err
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrWalletAlreadyImported) {
+			log.Error("failed to import wallet", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to import wallet: %w", err)
+	}
+	s.misses.forget(wallet.ID)
+	log.Info("wallet imported", slog.String("wallet_id", wallet.ID.String()), slog.Int64("balance", wallet.Balance))
+	return wallet, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"wallet-service/internal/jobs"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportWalletsJobKind = "wallets.export"
+	WipeSandboxJobKind   = "sandbox.wipe"
+	ReconcileJobKind     = "reconcile"
+)
+
+// registerJobs registers the handlers of the service's job kinds, so that
+// its jobs can be resumed after a restart.
+func (s *WalletService) registerJobs() {
+	s.jobs.Register(BulkOperationsJobKind, s.runBulkOperations)
+	s.jobs.Register(FreezeWalletsJobKind, s.runSetWalletsStatus)
+	s.jobs.Register(UnfreezeWalletsJobKind, s.runSetWalletsStatus)
+	s.jobs.Register(ExportWalletsJobKind, s.runExportWallets)
+	s.jobs.Register(WipeSandboxJobKind, s.runWipeSandbox)
+	s.jobs.Register(ReconcileJobKind, s.runReconcile)
+}
+
+// GetJob returns the progress of a background job started by the service.
+func (s *WalletService) GetJob(ctx context.Context, id uuid.UUID) (jobs.Job, error) {
+	return s.jobs.Get(ctx, id)
+}
+
+// ListJobs returns the most recent background jobs, of kind unless it is
+// empty, newest first.
+func (s *WalletService) ListJobs(ctx context.Context, kind string, limit int) ([]jobs.Job, error) {
+	return s.jobs.List(ctx, kind, limit)
+}
+
+// GetJobReport returns the report attached to a background job.
+func (s *WalletService) GetJobReport(ctx context.Context, id uuid.UUID) (jobs.Report, error) {
+	return s.jobs.Report(ctx, id)
+}
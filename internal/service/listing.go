@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ListWallets returns up to limit wallets matching filter with ids greater
+// than after, in id order, and whether more follow.
+func (s *WalletService) ListWallets(ctx context.Context, filter models.WalletFilter, after uuid.UUID, limit int) ([]models.Wallet, bool, error) {
+	return s.ListWalletsSorted(ctx, filter, models.WalletSort{}, models.WalletCursor{ID: after}, limit)
+}
+
+// ListWalletsSorted returns up to limit wallets matching filter that
+// follow after in the order given by sort, and whether more follow. The
+// cursor of the next page is models.CursorAt of the last wallet.
+func (s *WalletService) ListWalletsSorted(ctx context.Context, filter models.WalletFilter, sort models.WalletSort, after models.WalletCursor, limit int) ([]models.Wallet, bool, error) {
+	op := "service.ListWallets"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if err := validateWalletFilter(filter); err != nil {
+		return nil, false, err
+	}
+	switch sort.Field {
+	case "", models.WalletSortID, models.WalletSortBalance, models.WalletSortCreatedAt:
+	default:
+		return nil, false, fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, sort.Field)
+	}
+	if limit <= 0 {
+		return nil, false, fmt.Errorf("%w: limit must be positive", ErrInvalidInput)
+	}
+
+	wallets, err := s.repo.ListWallets(ctx, filter, sort, after, limit+1)
+	if err != nil {
+		log.Error("failed to list wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, false, fmt.Errorf("failed to list wallets: %w", err)
+	}
+	if len(wallets) > limit {
+		return wallets[:limit], true, nil
+	}
+	return wallets, false, nil
+}
+
+func validateWalletFilter(f models.WalletFilter) error {
+	if f.Status != "" && f.Status != models.WalletStatusActive && f.Status != models.WalletStatusFrozen && f.Status != models.WalletStatusDormant &&
+		f.Status != models.WalletStatusClosed {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidInput, f.Status)
+	}
+	if f.MinBalance != nil && f.MaxBalance != nil && *f.MinBalance > *f.MaxBalance {
+		return fmt.Errorf("%w: minBalance exceeds maxBalance", ErrInvalidInput)
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("%w: createdAfter must be before createdBefore", ErrInvalidInput)
+	}
+	return nil
+}
+
+// MinWalletSearchLength is the shortest query SearchWallets accepts; a
+// shorter prefix matches too much of the table to be useful.
+const MinWalletSearchLength = 3
+
+// SearchWallets returns up to limit wallets with ids greater than after
+// whose id, owner id, label or tenant starts with q, ignoring case, in id
+// order, and whether more follow.
+func (s *WalletService) SearchWallets(ctx context.Context, q string, after uuid.UUID, limit int) ([]models.Wallet, bool, error) {
+	op := "service.SearchWallets"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	q = strings.TrimSpace(q)
+	if len(q) < MinWalletSearchLength {
+		return nil, false, fmt.Errorf("%w: query must be at least %d characters", ErrInvalidInput, MinWalletSearchLength)
+	}
+	if limit <= 0 {
+		return nil, false, fmt.Errorf("%w: limit must be positive", ErrInvalidInput)
+	}
+
+	wallets, err := s.repo.SearchWallets(ctx, q, after, limit+1)
+	if err != nil {
+		log.Error("failed to search wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, false, fmt.Errorf("failed to search wallets: %w", err)
+	}
+	if len(wallets) > limit {
+		return wallets[:limit], true, nil
+	}
+	return wallets, false, nil
+}
+
+// ListTransactions returns a page of a wallet's balance changes, newest
+// first, starting below beforeVersion (0 for the newest), and whether more
+// follow.
+func (s *WalletService) ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]models.Transaction, bool, error) {
+	op := "service.ListTransactions"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	if limit <= 0 || beforeVersion < 0 {
+		return nil, false, fmt.Errorf("%w: invalid page", ErrInvalidInput)
+	}
+
+	transactions, err := s.repo.ListTransactions(ctx, walletID, beforeVersion, limit+1)
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
+			return nil, false, err
+		}
+		log.Error("failed to list transactions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, false, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	if len(transactions) > limit {
+		return transactions[:limit], true, nil
+	}
+	return transactions, false, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// CreateMandate authorizes a counterparty to debit the wallet within the
+// limits of req.
+func (s *WalletService) CreateMandate(ctx context.Context, walletID uuid.UUID, req models.CreateMandateRequest) (*models.Mandate, error) {
+	op := "service.CreateMandate"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String())))
+
+	if err := validateMandate(req); err != nil {
+		log.Warn("invalid mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	mandate, err := s.repo.CreateMandate(ctx, models.Mandate{
+		ID:           uuid.New(),
+		WalletID:     walletID,
+		Counterparty: req.Counterparty,
+		Limit:        req.Limit,
+		MaxDebits:    req.MaxDebits,
+		Period:       req.Period,
+		Status:       models.MandateStatusActive,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			return nil, err
+		}
+		log.Error("failed to create mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to create mandate: %w", err)
+	}
+	log.Info("mandate created", slog.String("mandate_id", mandate.ID.String()), slog.String("counterparty", mandate.Counterparty))
+	return mandate, nil
+}
+
+func (s *WalletService) ListMandates(ctx context.Context, walletID uuid.UUID) ([]models.Mandate, error) {
+	mandates, err := s.repo.ListMandates(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mandates: %w", err)
+	}
+	return mandates, nil
+}
+
+func (s *WalletService) RevokeMandate(ctx context.Context, walletID, mandateID uuid.UUID) (*models.Mandate, error) {
+	op := "service.RevokeMandate"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()), slog.String("mandate_id", mandateID.String())))
+
+	mandate, err := s.repo.RevokeMandate(ctx, walletID, mandateID, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrMandateNotFound) {
+			return nil, err
+		}
+		log.Error("failed to revoke mandate", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to revoke mandate: %w", err)
+	}
+	log.Info("mandate revoked")
+	return mandate, nil
+}
+
+// DebitMandate withdraws from the mandate's wallet on behalf of the
+// counterparty. Besides the mandate's own limits, the debit is subject to
+// the same operation limits, withdrawal limits and screening as a regular
+// withdrawal.
+func (s *WalletService) DebitMandate(ctx context.Context, mandateID uuid.UUID, req models.MandateDebitRequest) (*models.MandateDebit, error) {
+	op := "service.DebitMandate"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("mandate_id", mandateID.String()), slog.String("counterparty", req.Counterparty)))
+
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrAmountMustBePositive)
+	}
+	if req.Counterparty == "" {
+		return nil, fmt.Errorf("%w: counterparty is required", ErrInvalidInput)
+	}
+
+	mandate, err := s.repo.GetMandate(ctx, mandateID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMandateNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve mandate: %w", err)
+	}
+	periodStart, _ := mandate.Period.Start(time.Now().UTC())
+
+	release := func() {}
+	if s.limiter != nil {
+		release, err = s.limiter.Reserve(limits.ScopeFrom(ctx), req.Amount)
+		if err != nil {
+			log.Warn("mandate debit rejected by limits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+	}
+
+	debit, err := s.debitMandate(ctx, mandate, req, periodStart, log)
+	if err != nil {
+		release()
+	}
+	return debit, err
+}
+
+func (s *WalletService) debitMandate(ctx context.Context, mandate *models.Mandate, req models.MandateDebitRequest,
+	periodStart time.Time, log *slog.Logger) (*models.MandateDebit, error) {
+	withdrawal := models.WalletOperation{
+		WalletID:      mandate.WalletID,
+		OperationType: models.OperationTypeWithdraw,
+		Amount:        req.Amount,
+	}
+	if err := s.screenOperation(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+	if err := s.checkDormancy(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+
+	var debit *models.MandateDebit
+	err := s.retry(ctx, "service.DebitMandate", func() error {
+		amount := func(context.Context) (int64, error) { return req.Amount, nil }
+		return s.withWithdrawalLimit(ctx, mandate.WalletID, amount, func(ctx context.Context) error {
+			return s.withBalanceEvents(ctx, mandate.WalletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+				var err error
+				debit, err = s.repo.DebitMandate(ctx, models.MandateDebit{
+					ID:           uuid.New(),
+					MandateID:    mandate.ID,
+					Counterparty: req.Counterparty,
+					Amount:       req.Amount,
+					CreatedAt:    time.Now().UTC(),
+				}, periodStart)
+				return []models.WalletOperation{withdrawal}, err
+			})
+		})
+	})
+	switch {
+	case err == nil:
+		log.Info("mandate debit processed", slog.String("debit_id", debit.ID.String()), slog.Int64("amount", debit.Amount))
+		s.balances.forget(debit.WalletID)
+		s.accrueReward(ctx, &models.Wallet{ID: debit.WalletID, Version: debit.Version, UpdatedAt: debit.CreatedAt},
+			models.WalletOperation{WalletID: debit.WalletID, OperationType: models.OperationTypeWithdraw, Amount: debit.Amount})
+		return debit, nil
+	case errors.Is(err, repository.ErrMandateNotFound) ||
+		errors.Is(err, repository.ErrMandateRevoked) ||
+		errors.Is(err, repository.ErrMandateCounterparty) ||
+		errors.Is(err, repository.ErrMandateLimitExceeded) ||
+		errors.Is(err, repository.ErrWalletNotFound) ||
+		errors.Is(err, repository.ErrInsufficientFunds) ||
+		errors.Is(err, repository.ErrWalletFrozen) ||
+		errors.Is(err, repository.ErrWalletClosed) ||
+		errors.Is(err, ErrLimitExceeded):
+		log.Warn("mandate debit rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	case errors.Is(err, repository.ErrRetryable):
+		return nil, fmt.Errorf("failed to process mandate debit after multiple retries: %w", err)
+	default:
+		return nil, fmt.Errorf("failed to process mandate debit: %w", err)
+	}
+}
+
+func validateMandate(req models.CreateMandateRequest) error {
+	if req.Counterparty == "" {
+		return errors.New("counterparty is required")
+	}
+	if req.Limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	if req.MaxDebits < 0 {
+		return errors.New("maxDebits must not be negative")
+	}
+	if _, ok := req.Period.Start(time.Now().UTC()); !ok {
+		return fmt.Errorf("unknown period %q", req.Period)
+	}
+	return nil
+}
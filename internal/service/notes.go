@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxNoteLength bounds the body of a transaction note, in bytes.
+	MaxNoteLength = 4000
+	// MaxNoteAttachments bounds the attachment references of one note.
+	MaxNoteAttachments = 10
+)
+
+// AddTransactionNote annotates the transaction at ledger offset
+// transactionID. Notes are append-only and leave the transaction as is.
+func (s *WalletService) AddTransactionNote(ctx context.Context, transactionID int64, req models.AddTransactionNoteRequest) (*models.TransactionNote, error) {
+	op := "service.AddTransactionNote"
+	log := s.logger(ctx).With(slog.String("op", op), slog.Int64("transaction_id", transactionID))
+
+	req.Author = strings.TrimSpace(req.Author)
+	req.Body = strings.TrimSpace(req.Body)
+	switch {
+	case transactionID <= 0:
+		return nil, fmt.Errorf("%w: transaction id must be positive", ErrInvalidInput)
+	case req.Author == "":
+		return nil, fmt.Errorf("%w: author is required", ErrInvalidInput)
+	case req.Body == "" && len(req.Attachments) == 0:
+		return nil, fmt.Errorf("%w: body or attachments are required", ErrInvalidInput)
+	case len(req.Body) > MaxNoteLength:
+		return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrInvalidInput, MaxNoteLength)
+	case len(req.Attachments) > MaxNoteAttachments:
+		return nil, fmt.Errorf("%w: at most %d attachments", ErrInvalidInput, MaxNoteAttachments)
+	}
+	attachments := make([]string, 0, len(req.Attachments))
+	for _, a := range req.Attachments {
+		if a = strings.TrimSpace(a); a == "" {
+			return nil, fmt.Errorf("%w: empty attachment reference", ErrInvalidInput)
+		}
+		attachments = append(attachments, a)
+	}
+
+	n, err := s.repo.AddTransactionNote(ctx, models.TransactionNote{
+		ID:            uuid.New(),
+		TransactionID: transactionID,
+		Author:        req.Author,
+		Body:          req.Body,
+		Attachments:   attachments,
+		CreatedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			log.Warn("transaction not found")
+			return nil, err
+		}
+		log.Error("failed to add transaction note", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to add transaction note: %w", err)
+	}
+	log.Info("transaction note added", slog.String("note_id", n.ID.String()), slog.String("author", n.Author))
+	return n, nil
+}
+
+// ListTransactionNotes returns the notes of the transaction at ledger
+// offset transactionID, oldest first.
+func (s *WalletService) ListTransactionNotes(ctx context.Context, transactionID int64) ([]models.TransactionNote, error) {
+	if transactionID <= 0 {
+		return nil, fmt.Errorf("%w: transaction id must be positive", ErrInvalidInput)
+	}
+	notes, err := s.repo.ListTransactionNotes(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transaction notes: %w", err)
+	}
+	return notes, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+// OutboxPublisher publishes outbox events to a message broker, returning
+// once all of them have been acknowledged.
+type OutboxPublisher interface {
+	Publish(ctx context.Context, events []models.OutboxEvent) error
+}
+
+// OutboxPolicy configures the outbox relay. Each call of RelayOutbox
+// publishes BatchSize events at a time until it has caught up. Published
+// events are kept for Retention, forever if zero.
+type OutboxPolicy struct {
+	BatchSize int
+	Retention time.Duration
+}
+
+type outbox struct {
+	policy    OutboxPolicy
+	publisher OutboxPublisher
+}
+
+// WithOutbox writes a balance.updated event to the outbox for every
+// balance change, in the transaction of the change, so that an event exists
+// if and only if its change committed. RelayOutbox publishes the events
+// with publisher.
+func WithOutbox(p OutboxPolicy, publisher OutboxPublisher) Option {
+	return func(s *WalletService) {
+		s.outbox = &outbox{policy: p, publisher: publisher}
+	}
+}
+
+// withOutbox runs fn, which changes balances and records them with
+// appendOutbox, in one transaction if the outbox is enabled.
+func (s *WalletService) withOutbox(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.outbox == nil {
+		return fn(ctx)
+	}
+	return s.repo.WithinTx(ctx, fn)
+}
+
+// withBalanceEvents runs fn, which changes balances with a repository call
+// of its own, and writes a balance.updated event for each operation fn
+// reports applying, in one transaction on the shard of walletID if the
+// outbox is enabled. Operations of no amount changed nothing and get no
+// event.
+func (s *WalletService) withBalanceEvents(ctx context.Context, walletID uuid.UUID,
+	fn func(ctx context.Context) ([]models.WalletOperation, error)) error {
+	if s.outbox == nil {
+		_, err := fn(ctx)
+		return err
+	}
+	return s.repo.WithinWalletTx(ctx, walletID, func(ctx context.Context) error {
+		operations, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		for _, operation := range operations {
+			if operation.Amount == 0 {
+				continue
+			}
+			wallet, err := s.repo.GetWallet(ctx, operation.WalletID)
+			if err != nil {
+				return err
+			}
+			if err := s.appendOutbox(ctx, wallet, operation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// appendOutbox writes a balance.updated event for operation, applied to
+// wallet, to the outbox in the transaction of ctx. The payload is the
+// body webhook subscribers get for the same change.
+func (s *WalletService) appendOutbox(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation) error {
+	if s.outbox == nil {
+		return nil
+	}
+	e := webhook.NewEvent(EventBalanceUpdated, models.BalanceUpdate{Wallet: wallet, Operation: operation})
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.repo.Outbox().AppendOutboxEvent(ctx, models.OutboxEvent{
+		ID:        e.ID,
+		WalletID:  wallet.ID,
+		Type:      e.Type,
+		Payload:   payload,
+		CreatedAt: e.CreatedAt,
+	})
+}
+
+// RelayOutbox publishes the unpublished outbox events, oldest first, in
+// batches until none are left. A batch the broker doesn't acknowledge
+// stays in the outbox for the next call; one acknowledged but not recorded
+// as published is published again, so consumers must tolerate duplicates.
+// It is meant to run periodically and does nothing without WithOutbox.
+func (s *WalletService) RelayOutbox(ctx context.Context) error {
+	if s.outbox == nil {
+		return nil
+	}
+	op := "service.RelayOutbox"
+	p := s.outbox.policy
+
+	published := 0
+	for ctx.Err() == nil {
+		n, err := s.repo.RelayOutbox(ctx, p.BatchSize, func(events []models.OutboxEvent) error {
+			return s.outbox.publisher.Publish(ctx, events)
+		})
+		published += n
+		if err != nil {
+			return fmt.Errorf("failed to relay outbox: %w", err)
+		}
+		if n < p.BatchSize {
+			break
+		}
+	}
+	if published > 0 {
+		s.logger(ctx).Info("outbox events published", slog.String("op", op), slog.Int("published", published))
+	}
+	return nil
+}
+
+// PurgeOutbox deletes outbox events published longer than the retention
+// of WithOutbox ago. It is meant to run periodically.
+func (s *WalletService) PurgeOutbox(ctx context.Context) error {
+	if s.outbox == nil || s.outbox.policy.Retention <= 0 {
+		return nil
+	}
+	op := "service.PurgeOutbox"
+
+	n, err := s.repo.PurgeOutbox(ctx, time.Now().Add(-s.outbox.policy.Retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge outbox: %w", err)
+	}
+	if n > 0 {
+		s.logger(ctx).Info("outbox purged", slog.String("op", op), slog.Int64("deleted", n))
+	}
+	return nil
+}
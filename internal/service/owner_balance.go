@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOwnerBalanceCacheTTL bounds how stale an owner or tenant balance
+// may be.
+const DefaultOwnerBalanceCacheTTL = 2 * time.Second
+
+// AnyStaleness accepts aggregates as stale as the cache TTL allows.
+const AnyStaleness time.Duration = math.MaxInt64
+
+// aggregateCache keeps recently computed aggregates keyed by K. An entry is
+// fresh while it is younger than the TTL and the age the caller accepts.
+type aggregateCache[K comparable, V any] struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[K]cachedAggregate[V]
+}
+
+type cachedAggregate[V any] struct {
+	value V
+	asOf  time.Time
+}
+
+func newAggregateCache[K comparable, V any](ttl time.Duration) *aggregateCache[K, V] {
+	return &aggregateCache[K, V]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[K]cachedAggregate[V]),
+	}
+}
+
+func (c *aggregateCache[K, V]) get(key K, maxAge time.Duration) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	e, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	age := c.now().Sub(e.asOf)
+	if age >= c.ttl {
+		delete(c.entries, key)
+		return zero, false
+	}
+	if age > maxAge {
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *aggregateCache[K, V]) put(key K, v V, asOf time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedAggregate[V]{value: v, asOf: asOf}
+}
+
+// OwnerBalance returns the owner's balances summed per currency. Results are
+// cached for the configured TTL, so they may trail the latest operations by
+// that much.
+func (s *WalletService) OwnerBalance(ctx context.Context, ownerID uuid.UUID) (*models.OwnerBalance, error) {
+	return s.OwnerBalanceWithin(ctx, ownerID, AnyStaleness)
+}
+
+// OwnerBalanceWithin is OwnerBalance with a bound on staleness: a cached
+// result older than maxStaleness is recomputed, and zero always reads the
+// repository. AsOf is taken before the read, so it never overstates how
+// fresh the result is.
+func (s *WalletService) OwnerBalanceWithin(ctx context.Context, ownerID uuid.UUID, maxStaleness time.Duration) (*models.OwnerBalance, error) {
+	op := "service.OwnerBalance"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("owner_id", ownerID.String()))
+
+	if b, ok := s.ownerBalances.get(ownerID, maxStaleness); ok {
+		return b, nil
+	}
+
+	asOf := s.ownerBalances.now()
+	balances, err := s.repo.OwnerBalances(ctx, ownerID)
+	if err != nil {
+		log.Error("failed to aggregate owner balance", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to aggregate owner balance: %w", err)
+	}
+
+	b := &models.OwnerBalance{
+		OwnerID:  ownerID,
+		Balances: balances,
+		AsOf:     asOf,
+	}
+	s.ownerBalances.put(ownerID, b, asOf)
+	return b, nil
+}
+
+// TenantBalance returns the balances of all the tenant's wallets summed per
+// currency, cached and bounded like OwnerBalanceWithin.
+func (s *WalletService) TenantBalance(ctx context.Context, tenant string, maxStaleness time.Duration) (*models.TenantBalance, error) {
+	op := "service.TenantBalance"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("tenant", tenant))
+
+	if tenant == "" {
+		return nil, fmt.Errorf("%w: tenant is required", ErrInvalidInput)
+	}
+	if b, ok := s.tenantBalances.get(tenant, maxStaleness); ok {
+		return b, nil
+	}
+
+	asOf := s.tenantBalances.now()
+	balances, err := s.repo.TenantBalances(ctx, tenant)
+	if err != nil {
+		log.Error("failed to aggregate tenant balance", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to aggregate tenant balance: %w", err)
+	}
+
+	b := &models.TenantBalance{
+		Tenant:   tenant,
+		Balances: balances,
+		AsOf:     asOf,
+	}
+	s.tenantBalances.put(tenant, b, asOf)
+	return b, nil
+}
+
+// isCurrencyCode reports whether code looks like an ISO 4217 alphabetic code.
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
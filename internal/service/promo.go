@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// PromoExpiryBatchSize is the number of due credits ExpirePromoCredits
+// fetches at a time.
+const PromoExpiryBatchSize = 500
+
+// GrantPromo credits a promotional amount to the wallet that expires at
+// req.ExpiresAt unless spent before.
+func (s *WalletService) GrantPromo(ctx context.Context, walletID uuid.UUID, req models.GrantPromoRequest) (*models.Wallet, error) {
+	op := "service.GrantPromo"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", walletID.String()))
+
+	now := time.Now().UTC()
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrAmountMustBePositive)
+	}
+	if !req.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidInput)
+	}
+
+	var wallet *models.Wallet
+	err := s.withBalanceEvents(ctx, walletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+		var err error
+		wallet, err = s.repo.GrantPromo(ctx, models.PromoCredit{
+			ID:        uuid.New(),
+			WalletID:  walletID,
+			Amount:    req.Amount,
+			Remaining: req.Amount,
+			ExpiresAt: req.ExpiresAt.UTC(),
+			CreatedAt: now,
+		})
+		return []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypePromoCredit, Amount: req.Amount}}, err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) || errors.Is(err, repository.ErrWalletFrozen) {
+			log.Warn("promo credit rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("failed to grant promo credit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to grant promo credit: %w", err)
+	}
+	log.Info("promo credit granted", slog.Int64("amount", req.Amount), slog.Time("expires_at", req.ExpiresAt))
+	return wallet, nil
+}
+
+func (s *WalletService) ListPromoCredits(ctx context.Context, walletID uuid.UUID) ([]models.PromoCredit, error) {
+	credits, err := s.repo.ListPromoCredits(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo credits: %w", err)
+	}
+	return credits, nil
+}
+
+// ExpirePromoCredits removes the unspent part of every credit past its
+// expiry. Credits of frozen wallets are left for a later run.
+func (s *WalletService) ExpirePromoCredits(ctx context.Context) error {
+	op := "service.ExpirePromoCredits"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	now := time.Now().UTC()
+	var credits, skipped int
+	var total int64
+	for {
+		due, err := s.repo.DuePromoCredits(ctx, now, PromoExpiryBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list due promo credits: %w", err)
+		}
+
+		processed := 0
+		for _, credit := range due {
+			var amount int64
+			err := s.withBalanceEvents(ctx, credit.WalletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+				var err error
+				amount, err = s.repo.ExpirePromoCredit(ctx, credit.ID, now)
+				return []models.WalletOperation{{WalletID: credit.WalletID, OperationType: models.OperationTypePromoExpiry, Amount: amount}}, err
+			})
+			if errors.Is(err, repository.ErrWalletFrozen) {
+				skipped++
+				continue
+			}
+			if err != nil {
+				log.Error("failed to expire promo credit", slog.String("credit_id", credit.ID.String()), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				return fmt.Errorf("failed to expire promo credit %s: %w", credit.ID, err)
+			}
+			processed++
+			total += amount
+		}
+		credits += processed
+
+		if len(due) < PromoExpiryBatchSize || processed == 0 {
+			break
+		}
+	}
+
+	if credits > 0 || skipped > 0 {
+		log.Info("promo credits expired", slog.Int("credits", credits), slog.Int64("amount", total), slog.Int("skipped", skipped))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/models"
+	"wallet-service/internal/reconcile"
+)
+
+// ReconcileDateTolerance is how far apart the date of a settlement entry
+// and of an internal transaction may be for them to match by amount.
+const ReconcileDateTolerance = 48 * time.Hour
+
+// Reconcile matches settlement entries against the internal transactions of
+// [from, to). A zero from or to is derived from the entries' dates.
+// Transactions are identified as "<wallet id>:<version>".
+func (s *WalletService) Reconcile(ctx context.Context, entries []reconcile.Entry, from, to time.Time) (*reconcile.Report, error) {
+	op := "service.Reconcile"
+	log := s.logger(ctx).With(slog.String("op", op), slog.Int("entries", len(entries)))
+
+	from, to, err := reconcileWindow(entries, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.ListWalletVersions(ctx, from, to)
+	if err != nil {
+		log.Error("failed to list transactions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	txs := make([]reconcile.Transaction, 0, len(versions))
+	for _, v := range versions {
+		txs = append(txs, reconcile.Transaction{
+			ID:     transactionID(v.WalletID, v.Version),
+			Amount: signedAmount(v.OperationType, v.Amount),
+			Date:   v.CreatedAt,
+		})
+	}
+
+	report := reconcile.Reconcile(entries, txs, ReconcileDateTolerance)
+	log.Info("reconciliation completed",
+		slog.Int("matched", len(report.Matched)),
+		slog.Int("missing", len(report.Missing)),
+		slog.Int("unexpected", len(report.Unexpected)),
+	)
+	return &report, nil
+}
+
+// StartReconcile runs Reconcile as a background job; the report is
+// attached to the job as JSON. The window is validated up front.
+func (s *WalletService) StartReconcile(ctx context.Context, entries []reconcile.Entry, from, to time.Time) (jobs.Job, error) {
+	op := "service.StartReconcile"
+	log := s.logger(ctx).With(slog.String("op", op), slog.Int("entries", len(entries)))
+
+	from, to, err := reconcileWindow(entries, from, to)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	job, err := s.jobs.Submit(ctx, ReconcileJobKind, reconcilePayload{Entries: entries, From: from, To: to})
+	if err != nil {
+		log.Error("failed to start reconciliation", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return jobs.Job{}, fmt.Errorf("failed to start reconciliation: %w", err)
+	}
+	log.Info("reconciliation started", slog.String("job_id", job.ID.String()))
+	return job, nil
+}
+
+type reconcilePayload struct {
+	Entries []reconcile.Entry `json:"entries"`
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+}
+
+// runReconcile is the handler of reconciliation jobs. Reconciling is read
+// only, so a resumed job starts over.
+func (s *WalletService) runReconcile(ctx context.Context, payload json.RawMessage, p *jobs.Progress) error {
+	var req reconcilePayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	p.SetTotal(int64(len(req.Entries)))
+
+	report, err := s.Reconcile(ctx, req.Entries, req.From, req.To)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	p.Add(int64(len(req.Entries)))
+	p.AppendReport("application/json", data)
+	return nil
+}
+
+// reconcileWindow fills in a zero from or to from the entries' dates and
+// checks that the window isn't empty.
+func reconcileWindow(entries []reconcile.Entry, from, to time.Time) (time.Time, time.Time, error) {
+	if from.IsZero() || to.IsZero() {
+		if len(entries) == 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from and to are required for an empty file", ErrInvalidInput)
+		}
+		first, last := entries[0].Date, entries[0].Date
+		for _, e := range entries[1:] {
+			if e.Date.Before(first) {
+				first = e.Date
+			}
+			if e.Date.After(last) {
+				last = e.Date
+			}
+		}
+		if from.IsZero() {
+			from = first
+		}
+		if to.IsZero() {
+			to = last.AddDate(0, 0, 1)
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be before to", ErrInvalidInput)
+	}
+	return from, to, nil
+}
+
+// signedAmount is the effect of an operation on the wallet balance.
+func signedAmount(operation models.OperationType, amount int64) int64 {
+	switch operation {
+	case models.OperationTypeWithdraw, models.OperationTypePromoExpiry, models.OperationTypeReversalDebit:
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wallet-service/internal/repository"
+)
+
+const (
+	maxRetries   = 5
+	retryBackoff = 10 * time.Millisecond
+)
+
+// RetriesExhaustedError is a retryable failure, such as a concurrent
+// modification, that persisted through Attempts attempts. The caller may
+// well succeed by trying again later.
+type RetriesExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// retry runs fn until it succeeds, fails with an error that is not
+// repository.ErrRetryable, or maxRetries attempts have been made. Permanent
+// failures are returned after the first attempt; a retryable failure that
+// outlasts the retries, or that budget has no room to retry, is returned as
+// a *RetriesExhaustedError. Within a WithinTx transaction fn runs once, as
+// the conflict has aborted the transaction; WithinTx retries as a whole.
+func retry(ctx context.Context, budget *retryBudget, fn func() error) error {
+	if inTx(ctx) {
+		return fn()
+	}
+	backoff := retryBackoff
+	var err error
+	attempts := 0
+	budget.attempt()
+	for attempts < maxRetries {
+		attempts++
+		if err = fn(); !errors.Is(err, repository.ErrRetryable) {
+			return err
+		}
+		if attempts == maxRetries {
+			break
+		}
+		if !budget.allowRetry() {
+			return &RetriesExhaustedError{Attempts: attempts, Err: fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err)}
+		}
+
+		// exponential delay
+		select {
+		case <-ctx.Done():
+			return &RetriesExhaustedError{Attempts: attempts, Err: err}
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return &RetriesExhaustedError{Attempts: attempts, Err: err}
+}
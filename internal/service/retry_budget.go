@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted marks a retryable failure that wasn't retried
+// because too many attempts across all requests are already retries.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+const (
+	// DefaultRetryBudgetRatio is the share of attempts that may be retries.
+	DefaultRetryBudgetRatio = 0.2
+	// DefaultRetryBudgetWindow is how far back attempts are counted.
+	DefaultRetryBudgetWindow = 10 * time.Second
+	// DefaultRetryBudgetMinRetries are allowed per window regardless of the
+	// ratio, so that a quiet service still retries its few conflicts.
+	DefaultRetryBudgetMinRetries = 10
+
+	retryBudgetBuckets = 10
+)
+
+// WithRetryBudget caps the retries of concurrent modifications and other
+// retryable failures across all requests: over a rolling window, at most
+// minRetries plus ratio of all attempts may be retries. Past that a
+// retryable failure is returned at once instead of being retried, so that
+// during an incident retries don't multiply the load on the database. A
+// ratio of 1 or more lifts the cap.
+func WithRetryBudget(ratio float64, window time.Duration, minRetries int) Option {
+	return func(s *WalletService) {
+		s.retryBudget = newRetryBudget(ratio, window, minRetries)
+	}
+}
+
+type retryBucket struct {
+	slot     int64
+	attempts int64
+	retries  int64
+}
+
+// retryBudget counts attempts and retries in buckets covering the window.
+// A nil budget allows every retry.
+type retryBudget struct {
+	ratio      float64
+	minRetries int64
+	width      time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBucket
+}
+
+func newRetryBudget(ratio float64, window time.Duration, minRetries int) *retryBudget {
+	if ratio >= 1 {
+		return nil
+	}
+	width := window / retryBudgetBuckets
+	if width <= 0 {
+		width = DefaultRetryBudgetWindow / retryBudgetBuckets
+	}
+	return &retryBudget{
+		ratio:      ratio,
+		minRetries: int64(minRetries),
+		width:      width,
+		now:        time.Now,
+	}
+}
+
+// bucket returns the current bucket, reset if it last counted an earlier
+// slot. Callers hold mu.
+func (b *retryBudget) bucket() (*retryBucket, int64) {
+	slot := b.now().UnixNano() / int64(b.width)
+	bk := &b.buckets[slot%retryBudgetBuckets]
+	if bk.slot != slot {
+		*bk = retryBucket{slot: slot}
+	}
+	return bk, slot
+}
+
+// attempt counts the first attempt of a request.
+func (b *retryBudget) attempt() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bk, _ := b.bucket()
+	bk.attempts++
+}
+
+// allowRetry reports whether one more retry fits the budget and, if so,
+// counts it.
+func (b *retryBudget) allowRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bk, slot := b.bucket()
+	var attempts, retries int64
+	for _, other := range b.buckets {
+		if slot-other.slot < retryBudgetBuckets {
+			attempts += other.attempts
+			retries += other.retries
+		}
+	}
+	if float64(retries+1) > float64(b.minRetries)+b.ratio*float64(attempts+1) {
+		return false
+	}
+	bk.attempts++
+	bk.retries++
+	return true
+}
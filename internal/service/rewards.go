@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/rewards"
+
+	"github.com/google/uuid"
+)
+
+// WithRewards credits the cashback decided by engine for every completed
+// operation to the wallet's rewards sub-wallet.
+func WithRewards(engine rewards.Engine) Option {
+	return func(s *WalletService) {
+		s.rewards = engine
+	}
+}
+
+// transactionID identifies the operation that produced a wallet version.
+func transactionID(walletID uuid.UUID, version int) string {
+	return fmt.Sprintf("%s:%d", walletID, version)
+}
+
+// accrueReward lets the rewards engine observe a completed operation. The
+// operation has already been committed, so failures are only logged; the
+// accrual is idempotent per transaction and can be replayed.
+func (s *WalletService) accrueReward(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation) {
+	if s.rewards == nil || wallet.Label == models.RewardsWalletLabel {
+		return
+	}
+
+	tx := rewards.Transaction{
+		ID:            transactionID(wallet.ID, wallet.Version),
+		WalletID:      wallet.ID,
+		OperationType: string(operation.OperationType),
+		Amount:        operation.Amount,
+		Category:      operation.Category,
+		At:            wallet.UpdatedAt,
+	}
+	reward, ok := s.rewards.Reward(tx)
+	if !ok {
+		return
+	}
+
+	op := "service.accrueReward"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("transaction_id", tx.ID), slog.String("rule", reward.Rule))
+
+	// The reward is credited to the wallet's rewards sub-wallet, on the
+	// wallet's shard.
+	var accrued bool
+	err := s.withBalanceEvents(context.WithoutCancel(ctx), wallet.ID, func(ctx context.Context) ([]models.WalletOperation, error) {
+		var accrual *models.RewardAccrual
+		var err error
+		accrual, accrued, err = s.repo.AccrueReward(ctx, models.RewardAccrual{
+			TransactionID: tx.ID,
+			WalletID:      wallet.ID,
+			Rule:          reward.Rule,
+			Amount:        reward.Amount,
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil || !accrued {
+			return nil, err
+		}
+		return []models.WalletOperation{{WalletID: accrual.RewardsWalletID, OperationType: models.OperationTypeReward, Amount: accrual.Amount}}, nil
+	})
+	if err != nil {
+		log.Error("failed to accrue reward", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return
+	}
+	if accrued {
+		log.Info("reward accrued", slog.Int64("amount", reward.Amount))
+	}
+}
+
+func (s *WalletService) ListRewardAccruals(ctx context.Context, walletID uuid.UUID) ([]models.RewardAccrual, error) {
+	accruals, err := s.repo.ListRewardAccruals(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reward accruals: %w", err)
+	}
+	return accruals, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/models"
+	"wallet-service/internal/sandbox"
+)
+
+// WithSandbox makes magic amounts on wallets of sandbox tenants trigger
+// deterministic behaviors (see package sandbox).
+func WithSandbox(sb *sandbox.Sandbox) Option {
+	return func(s *WalletService) {
+		s.sandbox = sb
+	}
+}
+
+// sandboxOperation applies the behavior of a magic amount when the wallet
+// belongs to a sandbox tenant. Errors mirror those of real failures so
+// integrators see the responses they will get in production.
+func (s *WalletService) sandboxOperation(ctx context.Context, operation models.WalletOperation, log *slog.Logger) error {
+	if s.sandbox == nil {
+		return nil
+	}
+	behavior := sandbox.BehaviorOf(operation.Amount)
+	if behavior == sandbox.BehaviorNone {
+		return nil
+	}
+	wallet, err := s.repo.GetWallet(ctx, operation.WalletID)
+	if err != nil {
+		return err
+	}
+	if !s.sandbox.Enabled(wallet.Tenant) {
+		return nil
+	}
+
+	log = log.With(slog.String("tenant", wallet.Tenant), slog.Int("sandbox_behavior", int(behavior)))
+	switch behavior {
+	case sandbox.BehaviorInsufficientFunds:
+		log.Info("sandbox: simulating insufficient funds")
+		return ErrInvalidInput
+	case sandbox.BehaviorDelay:
+		log.Info("sandbox: delaying operation", slog.Duration("delay", s.sandbox.Delay()))
+		return s.sandbox.Wait(ctx)
+	case sandbox.BehaviorProviderFailure:
+		log.Info("sandbox: simulating provider failure")
+		return sandbox.ErrProviderFailure
+	}
+	return nil
+}
+
+// WipeSandbox deletes the wallets and history of all sandbox tenants.
+func (s *WalletService) WipeSandbox(ctx context.Context) error {
+	op := "service.WipeSandbox"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if s.sandbox == nil {
+		return nil
+	}
+	for _, tenant := range s.sandbox.Tenants() {
+		if err := s.wipeTenant(ctx, tenant, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartSandboxWipe runs WipeSandbox as a background job, which resumes
+// with the tenants not wiped yet if it is interrupted. It does nothing
+// without sandbox tenants.
+func (s *WalletService) StartSandboxWipe(ctx context.Context) error {
+	op := "service.StartSandboxWipe"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if s.sandbox == nil {
+		return nil
+	}
+	job, err := s.jobs.Submit(ctx, WipeSandboxJobKind, s.sandbox.Tenants())
+	if err != nil {
+		log.Error("failed to start sandbox wipe", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to start sandbox wipe: %w", err)
+	}
+	log.Info("sandbox wipe started", slog.String("job_id", job.ID.String()))
+	return nil
+}
+
+// wipeSandboxCheckpoint counts the tenants of the payload already wiped.
+type wipeSandboxCheckpoint struct {
+	Wiped int `json:"wiped"`
+}
+
+// runWipeSandbox is the handler of sandbox wipe jobs; the payload lists the
+// tenants as configured when the job was started.
+func (s *WalletService) runWipeSandbox(ctx context.Context, payload json.RawMessage, p *jobs.Progress) error {
+	log := s.logger(ctx).With(slog.String("op", "service.runWipeSandbox"))
+
+	var tenants []string
+	if err := json.Unmarshal(payload, &tenants); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	var cp wipeSandboxCheckpoint
+	if _, err := p.Checkpoint(&cp); err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	p.SetTotal(int64(len(tenants)))
+	p.Restore(int64(cp.Wiped), 0)
+
+	for _, tenant := range tenants[min(cp.Wiped, len(tenants)):] {
+		if err := s.wipeTenant(ctx, tenant, log); err != nil {
+			return err
+		}
+		p.Add(1)
+		cp.Wiped++
+		if err := p.Save(ctx, cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *WalletService) wipeTenant(ctx context.Context, tenant string, log *slog.Logger) error {
+	n, err := s.repo.WipeTenant(ctx, tenant)
+	if err != nil {
+		log.Error("failed to wipe sandbox tenant", slog.String("tenant", tenant), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to wipe sandbox tenant %s: %w", tenant, err)
+	}
+	log.Info("sandbox tenant wiped", slog.String("tenant", tenant), slog.Int64("wallets", n))
+	return nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/screening"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScreeningBlocked     = errors.New("blocked by denylist screening")
+	ErrScreeningUnavailable = errors.New("denylist screening unavailable")
+)
+
+// WithScreener screens owners on wallet creation, and wallets with their
+// owners on operations of at least largeOperation, against sc. Matches are
+// blocked and recorded; if sc can't be consulted the request fails rather
+// than going through unscreened.
+func WithScreener(sc screening.Screener, largeOperation int64) Option {
+	return func(s *WalletService) {
+		s.screener = sc
+		s.largeOperation = largeOperation
+	}
+}
+
+func (s *WalletService) screen(ctx context.Context, action string, subjects ...screening.Subject) error {
+	if s.screener == nil {
+		return nil
+	}
+	op := "service.screen"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("action", action))
+
+	for _, subject := range subjects {
+		res, err := s.screener.Screen(ctx, subject)
+		if err != nil {
+			log.Error("screening failed", slog.String("kind", string(subject.Kind)), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return fmt.Errorf("%w: %v", ErrScreeningUnavailable, err)
+		}
+		if !res.Match {
+			continue
+		}
+
+		log.Warn("denylist match, request blocked", slog.String("kind", string(subject.Kind)),
+			slog.String("subject_id", subject.ID), slog.String("list", res.List))
+		hit := models.ScreeningHit{
+			ID:          uuid.New(),
+			SubjectKind: string(subject.Kind),
+			SubjectID:   subject.ID,
+			List:        res.List,
+			Reason:      res.Reason,
+			Action:      action,
+			CreatedAt:   time.Now().UTC(),
+		}
+		if err := s.repo.RecordScreeningHit(ctx, hit); err != nil {
+			log.Error("failed to record screening hit", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return ErrScreeningBlocked
+	}
+	return nil
+}
+
+// screenOperation screens large operations by wallet and owner.
+func (s *WalletService) screenOperation(ctx context.Context, operation models.WalletOperation) error {
+	if s.screener == nil || operation.Amount < s.largeOperation {
+		return nil
+	}
+	wallet, err := s.repo.GetWallet(ctx, operation.WalletID)
+	if err != nil {
+		return err
+	}
+	subjects := []screening.Subject{{Kind: screening.KindWallet, ID: wallet.ID.String()}}
+	if wallet.OwnerID.Valid {
+		subjects = append(subjects, screening.Subject{Kind: screening.KindOwner, ID: wallet.OwnerID.UUID.String()})
+	}
+	return s.screen(ctx, "operation", subjects...)
+}
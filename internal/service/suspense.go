@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Reasons an inbound credit is put in suspense.
+const (
+	SuspenseReasonNoWallet         = "no_wallet"
+	SuspenseReasonWalletNotFound   = "wallet_not_found"
+	SuspenseReasonWalletFrozen     = "wallet_frozen"
+	SuspenseReasonCurrencyMismatch = "currency_mismatch"
+)
+
+// ReceiveInbound applies funds received from outside to their wallet.
+// Credits without a usable wallet (none given, unknown, frozen or in another
+// currency) land in the suspense wallet of their currency with an open case
+// instead of being rejected, since the money has already arrived.
+func (s *WalletService) ReceiveInbound(ctx context.Context, credit models.InboundCredit) (*models.InboundResult, error) {
+	op := "service.ReceiveInbound"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("reference", credit.Reference))
+
+	if credit.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrAmountMustBePositive)
+	}
+	if credit.Reference == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrInvalidInput)
+	}
+	if credit.Currency == "" {
+		credit.Currency = models.DefaultCurrency
+	}
+	if !isCurrencyCode(credit.Currency) {
+		return nil, fmt.Errorf("%w: invalid currency", ErrInvalidInput)
+	}
+
+	reason := SuspenseReasonNoWallet
+	if credit.WalletID.Valid {
+		wallet, err := s.repo.GetWallet(ctx, credit.WalletID.UUID)
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			reason = SuspenseReasonWalletNotFound
+		case err != nil:
+			return nil, fmt.Errorf("failed to retrieve wallet: %w", err)
+		case wallet.Status == models.WalletStatusFrozen:
+			reason = SuspenseReasonWalletFrozen
+		case wallet.Currency != credit.Currency:
+			reason = SuspenseReasonCurrencyMismatch
+		default:
+			wallet, err = s.processOperation(ctx, models.WalletOperation{
+				WalletID:      wallet.ID,
+				OperationType: models.OperationTypeDeposit,
+				Amount:        credit.Amount,
+			}, log)
+			if err == nil {
+				log.Info("inbound credit applied", slog.String("wallet_id", wallet.ID.String()))
+				return &models.InboundResult{Status: models.InboundCredited, Wallet: wallet}, nil
+			}
+			if !errors.Is(err, repository.ErrWalletFrozen) {
+				return nil, err
+			}
+			reason = SuspenseReasonWalletFrozen
+		}
+	}
+
+	c := &models.SuspenseCase{
+		ID:          uuid.New(),
+		Amount:      credit.Amount,
+		Currency:    credit.Currency,
+		Reference:   credit.Reference,
+		Description: credit.Description,
+		Reason:      reason,
+		CreatedAt:   time.Now().UTC(),
+	}
+	// The repository picks the suspense wallet, on the shard the case is
+	// routed to.
+	err := s.withBalanceEvents(ctx, c.SuspenseWalletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+		var err error
+		if c, err = s.repo.ReceiveToSuspense(ctx, *c); err != nil {
+			return nil, err
+		}
+		return []models.WalletOperation{{WalletID: c.SuspenseWalletID, OperationType: models.OperationTypeDeposit, Amount: c.Amount}}, nil
+	})
+	if err != nil {
+		log.Error("failed to put inbound credit in suspense", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to put inbound credit in suspense: %w", err)
+	}
+	log.Warn("inbound credit put in suspense", slog.String("case_id", c.ID.String()), slog.String("reason", reason))
+	return &models.InboundResult{Status: models.InboundSuspense, Case: c}, nil
+}
+
+func (s *WalletService) ListSuspenseCases(ctx context.Context, status models.SuspenseCaseStatus) ([]models.SuspenseCase, error) {
+	switch status {
+	case "", models.SuspenseCaseOpen, models.SuspenseCaseResolved:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, status)
+	}
+	cases, err := s.repo.ListSuspenseCases(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspense cases: %w", err)
+	}
+	return cases, nil
+}
+
+// ResolveSuspenseCase moves the funds of an open case to the wallet they
+// belong to.
+func (s *WalletService) ResolveSuspenseCase(ctx context.Context, id uuid.UUID, req models.ResolveSuspenseRequest) (*models.SuspenseCase, error) {
+	op := "service.ResolveSuspenseCase"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("case_id", id.String()), slog.String("wallet_id", req.WalletID.String()))
+
+	if req.WalletID == uuid.Nil {
+		return nil, fmt.Errorf("%w: walletId is required", ErrInvalidInput)
+	}
+
+	// A case can only be resolved to a wallet on its shard.
+	var c *models.SuspenseCase
+	err := s.withBalanceEvents(ctx, req.WalletID, func(ctx context.Context) ([]models.WalletOperation, error) {
+		var err error
+		if c, err = s.repo.ResolveSuspenseCase(ctx, id, req.WalletID, req.Note, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+		return []models.WalletOperation{
+			{WalletID: c.SuspenseWalletID, OperationType: models.OperationTypeWithdraw, Amount: c.Amount},
+			{WalletID: req.WalletID, OperationType: models.OperationTypeDeposit, Amount: c.Amount},
+		}, nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrSuspenseCaseNotFound) ||
+			errors.Is(err, repository.ErrSuspenseCaseResolved) ||
+			errors.Is(err, repository.ErrWalletNotFound) ||
+			errors.Is(err, repository.ErrWalletFrozen) ||
+			errors.Is(err, repository.ErrCurrencyMismatch) {
+			log.Warn("suspense case resolution rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+		log.Error("failed to resolve suspense case", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to resolve suspense case: %w", err)
+	}
+	log.Info("suspense case resolved", slog.Int64("amount", c.Amount))
+	return c, nil
+}
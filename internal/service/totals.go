@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"wallet-service/internal/models"
+)
+
+// ErrTotalsMismatch means the wallets of a currency hold more or less than
+// the ledger accounts for.
+var ErrTotalsMismatch = errors.New("wallet balances don't match the ledger")
+
+// CurrencyTotals compares, per currency, the balances of all wallets with
+// the credits and debits in their history.
+func (s *WalletService) CurrencyTotals(ctx context.Context) ([]models.CurrencyTotals, error) {
+	totals, err := s.repo.CurrencyTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute currency totals: %w", err)
+	}
+	return totals, nil
+}
+
+// CheckCurrencyTotals asserts that in every currency the balances of
+// customer and system wallets add up to the credits minus the debits of
+// the ledger. It is meant to run periodically; a currency that doesn't add
+// up is logged as an error and reported with ErrTotalsMismatch.
+func (s *WalletService) CheckCurrencyTotals(ctx context.Context) error {
+	op := "service.CheckCurrencyTotals"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	totals, err := s.CurrencyTotals(ctx)
+	if err != nil {
+		log.Error("failed to check currency totals", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	var mismatched []string
+	for _, t := range totals {
+		if d := t.Discrepancy(); d != 0 {
+			log.Error("currency totals don't match the ledger", slog.String("currency", t.Currency),
+				slog.Int64("balance", t.Balance), slog.Int64("system_balance", t.SystemBalance),
+				slog.Int64("credits", t.Credits), slog.Int64("debits", t.Debits), slog.Int64("discrepancy", d))
+			mismatched = append(mismatched, t.Currency)
+		}
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("%w: %s", ErrTotalsMismatch, strings.Join(mismatched, ", "))
+	}
+	return nil
+}
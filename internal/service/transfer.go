@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Transfer moves req.Amount between two wallets of the same currency in a
+// single transaction. The amount counts once against limits; screening
+// and operation hooks see the transfer as the withdrawal and deposit it
+// consists of.
+func (s *WalletService) Transfer(ctx context.Context, req models.TransferRequest) (*models.Transfer, error) {
+	op := "service.Transfer"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("from_wallet_id", req.FromWalletID.String()),
+		slog.String("to_wallet_id", req.ToWalletID.String())))
+
+	if err := validateTransfer(req); err != nil {
+		log.Warn("invalid transfer", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	release := func() {}
+	if s.limiter != nil {
+		var err error
+		release, err = s.limiter.Reserve(limits.ScopeFrom(ctx), req.Amount)
+		if err != nil {
+			log.Warn("transfer rejected by limits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
+		}
+	}
+
+	transfer, err := s.transfer(ctx, req, log)
+	if err != nil {
+		release()
+	}
+	return transfer, err
+}
+
+func (s *WalletService) transfer(ctx context.Context, req models.TransferRequest, log *slog.Logger) (*models.Transfer, error) {
+	legs := []models.WalletOperation{
+		{WalletID: req.FromWalletID, OperationType: models.OperationTypeWithdraw, Amount: req.Amount},
+		{WalletID: req.ToWalletID, OperationType: models.OperationTypeDeposit, Amount: req.Amount},
+	}
+	for _, leg := range legs {
+		if err := s.screenOperation(ctx, leg); err != nil {
+			return nil, err
+		}
+		if err := s.checkDormancy(ctx, leg); err != nil {
+			return nil, err
+		}
+	}
+	for _, leg := range legs {
+		if err := s.runBeforeHooks(ctx, leg, log); err != nil {
+			return nil, err
+		}
+	}
+
+	var from, to *models.Wallet
+	err := s.retry(ctx, "service.Transfer", func() error {
+		return s.withLimitedWithdrawals(ctx, legs, func(ctx context.Context) error {
+			var err error
+			if from, to, err = s.repo.Transfer(ctx, req.FromWalletID, req.ToWalletID, req.Amount); err != nil {
+				return err
+			}
+			if err := s.appendOutbox(ctx, from, legs[0]); err != nil {
+				return err
+			}
+			return s.appendOutbox(ctx, to, legs[1])
+		})
+	})
+	if err != nil {
+		// A transfer has no steps to point the caller at.
+		var stepErr *repository.StepError
+		if errors.As(err, &stepErr) {
+			err = stepErr.Err
+		}
+		switch {
+		case errors.Is(err, repository.ErrRetryable):
+			log.Error("transfer failed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, fmt.Errorf("failed to transfer after multiple retries: %w", err)
+		case errors.Is(err, repository.ErrWalletNotFound),
+			errors.Is(err, repository.ErrInsufficientFunds),
+			errors.Is(err, repository.ErrWalletFrozen),
+			errors.Is(err, repository.ErrWalletClosed),
+			errors.Is(err, repository.ErrCurrencyMismatch),
+			errors.Is(err, ErrLimitExceeded):
+			log.Warn("transfer rejected", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		default:
+			log.Error("transfer failed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+		return nil, fmt.Errorf("failed to transfer: %w", err)
+	}
+
+	transfer := &models.Transfer{
+		ID:           uuid.New(),
+		FromWalletID: from.ID,
+		ToWalletID:   to.ID,
+		Amount:       req.Amount,
+		Currency:     from.Currency,
+		FromBalance:  from.Balance,
+		FromVersion:  from.Version,
+		ToBalance:    to.Balance,
+		ToVersion:    to.Version,
+		CreatedAt:    time.Now().UTC(),
+	}
+	log.Info("transfer completed", slog.String("transfer_id", transfer.ID.String()), slog.Int64("amount", req.Amount))
+	s.publishBalance(ctx, from, legs[0])
+	s.publishBalance(ctx, to, legs[1])
+	afterCommit(ctx, func() {
+		s.balances.put(from)
+		s.balances.put(to)
+		s.runAfterHooks(ctx, from, legs[0])
+		s.runAfterHooks(ctx, to, legs[1])
+	})
+	return transfer, nil
+}
+
+func validateTransfer(req models.TransferRequest) error {
+	if req.Amount <= 0 {
+		return ErrAmountMustBePositive
+	}
+	if req.FromWalletID == uuid.Nil || req.ToWalletID == uuid.Nil {
+		return errors.New("both wallets are required")
+	}
+	if req.FromWalletID == req.ToWalletID {
+		return errors.New("cannot transfer to the same wallet")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+)
+
+type txEffectsKey struct{}
+
+// txEffects holds what the calls made in a WithinTx transaction leave to be
+// done once it is decided.
+type txEffects struct {
+	committed  []func()
+	rolledBack []func()
+}
+
+// WithinTx runs fn in one database transaction: the service calls fn makes
+// with the context it is given, such as creating a wallet, depositing to it
+// and labelling it, take effect together or not at all. Effects outside the
+// database, such as cache updates, after hooks, rewards and webhooks, wait
+// for the commit.
+//
+// Calls inside the transaction don't retry conflicts, which abort the
+// transaction; the whole of fn is retried instead, so it must be safe to
+// run more than once. A WithinTx nested in another joins it.
+func (s *WalletService) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	op := "service.WithinTx"
+
+	if _, ok := ctx.Value(txEffectsKey{}).(*txEffects); ok {
+		return fn(ctx)
+	}
+
+	var effects *txEffects
+	err := s.retry(ctx, op, func() error {
+		if effects != nil {
+			effects.rollback()
+		}
+		effects = &txEffects{}
+		return s.repo.WithinTx(context.WithValue(ctx, txEffectsKey{}, effects), fn)
+	})
+	if err != nil {
+		effects.rollback()
+		s.logger(ctx).Warn("transaction rolled back", slog.String("op", op),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+	for _, f := range effects.committed {
+		f()
+	}
+	return nil
+}
+
+func (e *txEffects) rollback() {
+	for _, f := range e.rolledBack {
+		f()
+	}
+	e.rolledBack = nil
+}
+
+// inTx reports whether ctx belongs to a WithinTx transaction.
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txEffectsKey{}).(*txEffects)
+	return ok
+}
+
+// afterCommit runs f once the transaction of ctx commits, or at once
+// outside of one.
+func afterCommit(ctx context.Context, f func()) {
+	if effects, ok := ctx.Value(txEffectsKey{}).(*txEffects); ok {
+		effects.committed = append(effects.committed, f)
+		return
+	}
+	f()
+}
+
+// onRollback runs f if the transaction of ctx rolls back; outside of one,
+// a change that succeeded is final and f is dropped.
+func onRollback(ctx context.Context, f func()) {
+	if effects, ok := ctx.Value(txEffectsKey{}).(*txEffects); ok {
+		effects.rolledBack = append(effects.rolledBack, f)
+	}
+}
@@ -2,12 +2,25 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/maintenance"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/rewards"
+	"wallet-service/internal/sandbox"
+	"wallet-service/internal/screening"
+	"wallet-service/internal/storage"
+	"wallet-service/internal/webhook"
 
 	"github.com/google/uuid"
 )
@@ -22,40 +35,204 @@ var (
 type WalletService struct {
 	repo WalletRepository
 	log  *slog.Logger
+
+	store        storage.ObjectStore
+	signedURLTTL time.Duration
+
+	ownerBalances  *aggregateCache[uuid.UUID, *models.OwnerBalance]
+	tenantBalances *aggregateCache[string, *models.TenantBalance]
+	jobs           *jobs.Manager
+	bulkWorkers    int
+	retryBudget    *retryBudget
+
+	walletReads  flightGroup[uuid.UUID, *models.Wallet]
+	balanceReads flightGroup[uuid.UUID, *models.WalletBalance]
+	misses       *missCache
+	balances     *balanceCache
+
+	screener       screening.Screener
+	largeOperation int64
+
+	limiter          *limits.Limiter
+	withdrawalLimits WithdrawalLimits
+	gate             *maintenance.Gate
+	rewards          rewards.Engine
+
+	disputeWindow time.Duration
+	notifier      webhook.Notifier
+	webhooks      *webhookDelivery
+	outbox        *outbox
+	eventSourcing *EventSourcingPolicy
+
+	sandbox  *sandbox.Sandbox
+	dormancy *dormancyPolicy
+
+	recoveryWindow   time.Duration
+	auditRetention   time.Duration
+	attemptRetention time.Duration
+
+	beforeHooks []BeforeOperation
+	afterHooks  []AfterOperation
 }
 
-func NewWalletService(repo WalletRepository, log *slog.Logger) *WalletService {
-	return &WalletService{
-		repo: repo,
-		log:  log,
+type Option func(*WalletService)
+
+// WithObjectStore enables exports to object storage; download links are
+// valid for urlTTL.
+func WithObjectStore(store storage.ObjectStore, urlTTL time.Duration) Option {
+	return func(s *WalletService) {
+		s.store = store
+		s.signedURLTTL = urlTTL
 	}
 }
 
-func (s *WalletService) CreateWallet(ctx context.Context) (*models.Wallet, error) {
+// WithOwnerBalanceCacheTTL sets how long aggregated owner and tenant
+// balances are served from memory before being recomputed.
+func WithOwnerBalanceCacheTTL(ttl time.Duration) Option {
+	return func(s *WalletService) {
+		s.ownerBalances = newAggregateCache[uuid.UUID, *models.OwnerBalance](ttl)
+		s.tenantBalances = newAggregateCache[string, *models.TenantBalance](ttl)
+	}
+}
+
+// WithJobs runs background jobs on the given manager instead of a private
+// one, so the caller can persist, resume and stop them. The service
+// registers its job kinds on it.
+func WithJobs(m *jobs.Manager) Option {
+	return func(s *WalletService) {
+		s.jobs = m
+	}
+}
+
+// WithLimiter enforces per-scope operation amount limits; the scope is taken
+// from the request context (see limits.WithScope).
+func WithLimiter(l *limits.Limiter) Option {
+	return func(s *WalletService) {
+		s.limiter = l
+	}
+}
+
+// WithMaintenanceGate restricts destructive bulk actions to maintenance
+// windows.
+func WithMaintenanceGate(g *maintenance.Gate) Option {
+	return func(s *WalletService) {
+		s.gate = g
+	}
+}
+
+func NewWalletService(repo WalletRepository, log *slog.Logger, opts ...Option) *WalletService {
+	s := &WalletService{
+		repo:           repo,
+		log:            log,
+		ownerBalances:  newAggregateCache[uuid.UUID, *models.OwnerBalance](DefaultOwnerBalanceCacheTTL),
+		tenantBalances: newAggregateCache[string, *models.TenantBalance](DefaultOwnerBalanceCacheTTL),
+		misses:         newMissCache(DefaultMissCacheTTL),
+		disputeWindow:  DefaultDisputeWindow,
+		bulkWorkers:    DefaultBulkWorkers,
+		retryBudget:    newRetryBudget(DefaultRetryBudgetRatio, DefaultRetryBudgetWindow, DefaultRetryBudgetMinRetries),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.jobs == nil {
+		s.jobs = jobs.NewManager(log)
+	}
+	s.registerJobs()
+	return s
+}
+
+func (s *WalletService) CreateWallet(ctx context.Context, req models.CreateWalletRequest) (*models.Wallet, error) {
 	op := "service.CreateWallet"
-	log := s.log.With(slog.String("op", op))
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op)))
+
+	if err := s.checkNewWallet(ctx, log, &req); err != nil {
+		return nil, err
+	}
 
 	id, err := uuid.NewRandom()
 	if err != nil {
 		log.Error("failed to generate wallet ID", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, fmt.Errorf("failed to generate wallet ID: %w", err)
 	}
-	wallet, err := s.repo.CreateWallet(ctx, id)
+	wallet, err := s.repo.CreateWallet(ctx, id, req)
 	if err != nil {
 		log.Error("failed to create wallet", slog.String("wallet_id", id.String()), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, fmt.Errorf("failed to create wallet: %w", err)
 	}
+	s.misses.forget(wallet.ID)
+	s.publish(ctx, EventWalletCreated, wallet)
 	log.Info("wallet created successfully", slog.String("wallet_id", wallet.ID.String()))
 	return wallet, nil
 }
 
+// MaxBulkWallets bounds the number of wallets a single CreateWallets call
+// creates, and so the size of its insert.
+const MaxBulkWallets = 10000
+
+// CreateWallets creates req.Count wallets with the same attributes in one
+// insert and returns their ids, for onboarding flows that provision many
+// wallets at once. Unlike CreateWallet it sends no wallet.created events.
+func (s *WalletService) CreateWallets(ctx context.Context, req models.CreateWalletsRequest) (*models.CreateWalletsResponse, error) {
+	op := "service.CreateWallets"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.Int("count", req.Count)))
+
+	if req.Count <= 0 || req.Count > MaxBulkWallets {
+		log.Warn("invalid number of wallets")
+		return nil, fmt.Errorf("%w: between 1 and %d wallets required", ErrInvalidInput, MaxBulkWallets)
+	}
+	if err := s.checkNewWallet(ctx, log, &req.CreateWalletRequest); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, req.Count)
+	for i := range ids {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			log.Error("failed to generate wallet ID", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, fmt.Errorf("failed to generate wallet ID: %w", err)
+		}
+		ids[i] = id
+	}
+	if err := s.repo.CreateWallets(ctx, ids, req.CreateWalletRequest); err != nil {
+		log.Error("failed to create wallets", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to create wallets: %w", err)
+	}
+	for _, id := range ids {
+		s.misses.forget(id)
+	}
+	log.Info("wallets created successfully")
+	return &models.CreateWalletsResponse{IDs: ids}, nil
+}
+
+// checkNewWallet defaults the currency of a new wallet, validates it and
+// screens the wallet's owner.
+func (s *WalletService) checkNewWallet(ctx context.Context, log *slog.Logger, req *models.CreateWalletRequest) error {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	if !isCurrencyCode(req.Currency) {
+		log.Warn("invalid currency", slog.String("currency", req.Currency))
+		return ErrInvalidInput
+	}
+	if req.OwnerID.Valid {
+		return s.screen(ctx, "wallet.create", screening.Subject{Kind: screening.KindOwner, ID: req.OwnerID.UUID.String()})
+	}
+	return nil
+}
+
 func (s *WalletService) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
 	op := "service.GetWallet"
-	log := s.log.With(slog.String("op", op), slog.String("wallet_id", id.String()))
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
 
-	wallet, err := s.repo.GetWallet(ctx, id)
+	if s.misses.missing(id) {
+		return nil, ErrInvalidInput
+	}
+	wallet, err := s.walletReads.do(ctx, id, func(ctx context.Context) (*models.Wallet, error) {
+		return s.repo.GetWallet(ctx, id)
+	})
 	if err != nil {
 		if errors.Is(err, repository.ErrWalletNotFound) {
+			s.misses.add(id)
 			log.Warn("wallet not found")
 			return nil, ErrInvalidInput
 		}
@@ -63,41 +240,362 @@ func (s *WalletService) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wa
 		return nil, fmt.Errorf("failed to retrieve wallet: %w", err)
 	}
 	log.Info("wallet retrieved successfully")
-	return wallet, nil
+	copied := *wallet
+	return &copied, nil
+}
+
+// GetWalletBalance returns only the balance of a wallet. It serves the hot
+// read path, so successful reads aren't logged.
+//
+// Like GetWallet, concurrent reads of the same wallet share one query and
+// ids recently found missing are rejected without one. Hot wallets may be
+// answered from the balance cache (see WithBalanceCache).
+func (s *WalletService) GetWalletBalance(ctx context.Context, id uuid.UUID) (*models.WalletBalance, error) {
+	if s.misses.missing(id) {
+		return nil, repository.ErrWalletNotFound
+	}
+	// Within a transaction the balance may differ from the committed one
+	// the cache holds.
+	tx := inTx(ctx)
+	if !tx {
+		if cached, ok := s.balances.get(id); ok {
+			return &cached, nil
+		}
+	}
+	balance, err := s.balanceReads.do(ctx, id, func(ctx context.Context) (*models.WalletBalance, error) {
+		gen := s.balances.generation()
+		b, err := s.repo.GetWalletBalance(ctx, id)
+		if err == nil && !tx {
+			s.balances.fill(*b, gen)
+		}
+		return b, err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			s.misses.add(id)
+			return nil, err
+		}
+		s.logger(ctx).Error("failed to retrieve wallet balance", slog.String("op", "service.GetWalletBalance"), slog.String("wallet_id", id.String()),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to retrieve wallet balance: %w", err)
+	}
+	copied := *balance
+	return &copied, nil
+}
+
+func (s *WalletService) GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	op := "service.GetWalletVersions"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	versions, err := s.repo.GetWalletVersions(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
+			return nil, ErrInvalidInput
+		}
+		log.Error("failed to retrieve wallet versions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to retrieve wallet versions: %w", err)
+	}
+	return versions, nil
+}
+
+// WalletVersionsAfter returns up to limit versions of a wallet after
+// afterVersion, oldest first, for clients catching up on its changes.
+// Unknown wallets fail with repository.ErrWalletNotFound.
+func (s *WalletService) WalletVersionsAfter(ctx context.Context, id uuid.UUID, afterVersion, limit int) ([]models.WalletVersion, error) {
+	op := "service.WalletVersionsAfter"
+	log := s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", id.String()))
+
+	if afterVersion < 0 || limit <= 0 {
+		return nil, fmt.Errorf("%w: invalid version range", ErrInvalidInput)
+	}
+	versions, err := s.repo.WalletVersionsAfter(ctx, id, afterVersion, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
+			return nil, err
+		}
+		log.Error("failed to retrieve wallet versions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to retrieve wallet versions: %w", err)
+	}
+	return versions, nil
 }
 
 func (s *WalletService) ProcessOperation(ctx context.Context, operation models.WalletOperation) (*models.Wallet, error) {
 	op := "service.ProcessOperation"
-	log := s.log.With(slog.String("op", op), slog.String("wallet_id", operation.WalletID.String()), slog.String("operation", string(operation.OperationType)))
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("wallet_id", operation.WalletID.String()), slog.String("operation", string(operation.OperationType))))
 
 	if err := validateOperation(operation); err != nil {
 		log.Warn("invalid operation", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return nil, ErrInvalidInput
 	}
 
-	maxRetries := 5
-	var lastErr error
-	backoff := 10 * time.Millisecond
-
-	for i := 0; i < maxRetries; i++ {
-		wallet, err := s.repo.UpdateWalletBalance(ctx, operation.WalletID, operation.Amount, operation.OperationType)
-		if err == nil {
-			log.Info("operation processed successfully")
-			return wallet, nil
+	release := func() {}
+	if s.limiter != nil {
+		var err error
+		release, err = s.limiter.Reserve(limits.ScopeFrom(ctx), operation.Amount)
+		if err != nil {
+			log.Warn("operation rejected by limits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return nil, err
 		}
+	}
+
+	wallet, err := s.processOperation(ctx, operation, log)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	onRollback(ctx, release)
+	return wallet, nil
+}
 
-		if errors.Is(err, repository.ErrWalletNotFound) || errors.Is(err, repository.ErrInsufficientFunds) {
-			log.Warn("operation failed due to invalid input", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+func (s *WalletService) processOperation(ctx context.Context, operation models.WalletOperation, log *slog.Logger) (*models.Wallet, error) {
+	if err := s.sandboxOperation(ctx, operation, log); err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
 			return nil, ErrInvalidInput
 		}
+		return nil, err
+	}
+	if err := s.screenOperation(ctx, operation); err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
+			return nil, ErrInvalidInput
+		}
+		return nil, err
+	}
+	if err := s.checkDormancy(ctx, operation); err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			log.Warn("wallet not found")
+			return nil, ErrInvalidInput
+		}
+		return nil, err
+	}
+	if err := s.runBeforeHooks(ctx, operation, log); err != nil {
+		return nil, err
+	}
 
-		lastErr = err
-		// exponential delay
-		time.Sleep(backoff)
-		backoff *= 2
+	var wallet *models.Wallet
+	err := s.retry(ctx, "service.ProcessOperation", func() error {
+		var err error
+		wallet, err = s.applyOperation(ctx, operation, true)
+		return err
+	})
+	switch {
+	case err == nil:
+		log.Info("operation processed successfully")
+		s.publishBalance(ctx, wallet, operation)
+		afterCommit(ctx, func() {
+			s.balances.put(wallet)
+			s.accrueReward(ctx, wallet, operation)
+			s.runAfterHooks(ctx, wallet, operation)
+		})
+		return wallet, nil
+	case errors.Is(err, repository.ErrWalletNotFound) || errors.Is(err, repository.ErrInsufficientFunds):
+		log.Warn("operation failed due to invalid input", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, ErrInvalidInput
+	case errors.Is(err, repository.ErrWalletFrozen), errors.Is(err, repository.ErrWalletClosed):
+		log.Warn("operation rejected for frozen or closed wallet")
+		return nil, fmt.Errorf("failed to process operation: %w", err)
+	case errors.Is(err, ErrLimitExceeded):
+		log.Warn("operation rejected by withdrawal limits", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to process operation: %w", err)
 	}
 
-	return nil, fmt.Errorf("failed to process operation after multiple retries: %w", lastErr)
+	log.Error("operation failed", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())},
+		slog.String("error_kind", string(repository.Classify(err))))
+	if errors.Is(err, repository.ErrRetryable) {
+		return nil, fmt.Errorf("failed to process operation after multiple retries: %w", err)
+	}
+	return nil, fmt.Errorf("failed to process operation: %w", err)
+}
+
+// applyOperation applies operation as one unit of work: the wallet is
+// locked, the ledger rules work out the change, and the new balance, the
+// version recording it, any promo credits spent and the outbox event are
+// stored together. With WithEventSourcing the change applies to the
+// balances replayed from the wallet's history. With limited, withdrawals
+// are checked against the limits of WithWithdrawalLimits.
+func (s *WalletService) applyOperation(ctx context.Context, operation models.WalletOperation, limited bool) (*models.Wallet, error) {
+	var updated *models.Wallet
+	err := s.repo.WithinWalletTx(ctx, operation.WalletID, func(ctx context.Context) error {
+		wallet, err := s.repo.Wallets().LockWallet(ctx, operation.WalletID)
+		if err != nil {
+			return err
+		}
+		replayed, err := s.replayWallet(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		if limited {
+			if err := s.checkWithdrawalLimits(ctx, operation); err != nil {
+				return err
+			}
+		}
+		change, err := ledger.Apply(*wallet, operation.Amount, operation.OperationType)
+		if err != nil {
+			return err
+		}
+		if updated, err = s.repo.Wallets().SaveBalance(ctx, wallet, change); err != nil {
+			return err
+		}
+		err = s.repo.Versions().AppendVersion(ctx, models.WalletVersion{
+			WalletID:      updated.ID,
+			Version:       updated.Version,
+			Balance:       updated.Balance,
+			OperationType: operation.OperationType,
+			Amount:        change.Amount,
+			CreatedAt:     updated.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if change.PromoSpent > 0 {
+			if err := s.repo.Promos().ConsumePromoCredits(ctx, wallet.ID, change.PromoSpent); err != nil {
+				return err
+			}
+		}
+		if err := s.snapshotWallet(ctx, updated, replayed+1); err != nil {
+			return err
+		}
+		return s.appendOutbox(ctx, updated, operation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+const (
+	DefaultExportBatchSize = 1000
+	MaxExportBatchSize     = 10000
+)
+
+// ExportWallets streams a snapshot-consistent dump of all wallets to fn in
+// batches. batchSize is clamped to (0, MaxExportBatchSize].
+func (s *WalletService) ExportWallets(ctx context.Context, batchSize int, fn func([]models.Wallet) error) error {
+	op := "service.ExportWallets"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if batchSize <= 0 {
+		batchSize = DefaultExportBatchSize
+	}
+	if batchSize > MaxExportBatchSize {
+		batchSize = MaxExportBatchSize
+	}
+
+	exported := 0
+	err := s.repo.ExportWallets(ctx, batchSize, func(batch []models.Wallet) error {
+		exported += len(batch)
+		return fn(batch)
+	})
+	if err != nil {
+		log.Error("wallet export failed", slog.Int("exported", exported), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to export wallets: %w", err)
+	}
+	log.Info("wallet export completed", slog.Int("exported", exported))
+	return nil
+}
+
+func (s *WalletService) BalanceSummary(ctx context.Context, from, to time.Time) (*models.BalanceSummary, error) {
+	op := "service.BalanceSummary"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	summary, err := s.repo.BalanceSummary(ctx, from, to)
+	if err != nil {
+		log.Error("failed to compute balance summary", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to compute balance summary: %w", err)
+	}
+	return summary, nil
+}
+
+// ExportWalletsToStore writes the wallet export as NDJSON to object storage
+// and returns a signed download URL.
+func (s *WalletService) ExportWalletsToStore(ctx context.Context, batchSize int) (*models.ExportResult, error) {
+	op := "service.ExportWalletsToStore"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if s.store == nil {
+		return nil, storage.ErrNotConfigured
+	}
+
+	now := time.Now().UTC()
+	result := &models.ExportResult{
+		Key:       fmt.Sprintf("exports/wallets-%s.ndjson", now.Format("20060102T150405Z")),
+		ExpiresAt: now.Add(s.signedURLTTL),
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		enc := json.NewEncoder(pw)
+		pw.CloseWithError(s.ExportWallets(ctx, batchSize, func(batch []models.Wallet) error {
+			result.Count += len(batch)
+			for i := range batch {
+				if err := enc.Encode(&batch[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}()
+
+	if err := s.store.Put(ctx, result.Key, pr, "application/x-ndjson"); err != nil {
+		pr.CloseWithError(err)
+		log.Error("failed to upload export", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	url, err := s.store.SignedURL(result.Key, s.signedURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign export URL: %w", err)
+	}
+	result.URL = url
+
+	log.Info("export uploaded", slog.String("key", result.Key), slog.Int("count", result.Count))
+	return result, nil
+}
+
+// StartExportWalletsToStore runs ExportWalletsToStore as a background job;
+// the export result, with its download URL, is attached to the job as JSON.
+// An interrupted export starts over under a new key.
+func (s *WalletService) StartExportWalletsToStore(ctx context.Context, batchSize int) (jobs.Job, error) {
+	op := "service.StartExportWalletsToStore"
+	log := s.logger(ctx).With(slog.String("op", op))
+
+	if s.store == nil {
+		return jobs.Job{}, storage.ErrNotConfigured
+	}
+	job, err := s.jobs.Submit(ctx, ExportWalletsJobKind, exportWalletsPayload{BatchSize: batchSize})
+	if err != nil {
+		log.Error("failed to start export", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return jobs.Job{}, fmt.Errorf("failed to start export: %w", err)
+	}
+	log.Info("export started", slog.String("job_id", job.ID.String()))
+	return job, nil
+}
+
+type exportWalletsPayload struct {
+	BatchSize int `json:"batchSize"`
+}
+
+// runExportWallets is the handler of export jobs.
+func (s *WalletService) runExportWallets(ctx context.Context, payload json.RawMessage, p *jobs.Progress) error {
+	var req exportWalletsPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	result, err := s.ExportWalletsToStore(ctx, req.BatchSize)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode export result: %w", err)
+	}
+	p.SetTotal(int64(result.Count))
+	p.Add(int64(result.Count))
+	p.AppendReport("application/json", data)
+	return nil
 }
 
 func validateOperation(operation models.WalletOperation) error {
@@ -109,3 +607,25 @@ func validateOperation(operation models.WalletOperation) error {
 	}
 	return nil
 }
+
+// withSubject adds the authenticated caller, if any, to log so that the
+// audit trail shows who requested each change.
+func withSubject(ctx context.Context, log *slog.Logger) *slog.Logger {
+	sub, ok := auth.SubjectFrom(ctx)
+	switch {
+	case !ok:
+		return log
+	case sub.APIKey:
+		return log.With(slog.String("api_key", sub.ID))
+	case sub.Workload:
+		return log.With(slog.String("workload", sub.ID))
+	case sub.Client:
+		return log.With(slog.String("oauth_client", sub.ID))
+	}
+	return log.With(slog.String("subject", sub.ID))
+}
+
+// logger returns the service's logger with the id of the request in ctx.
+func (s *WalletService) logger(ctx context.Context) *slog.Logger {
+	return requestid.Logger(ctx, s.log)
+}
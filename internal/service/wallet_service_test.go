@@ -1,13 +1,35 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
 	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/limits"
+	"wallet-service/internal/maintenance"
 	mockrepository "wallet-service/internal/mock/mock_repository"
 	"wallet-service/internal/models"
+	"wallet-service/internal/reconcile"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/requestid"
+	"wallet-service/internal/rewards"
+	"wallet-service/internal/sandbox"
+	"wallet-service/internal/screening"
+	"wallet-service/internal/storage"
+	"wallet-service/internal/webhook"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -22,16 +44,30 @@ func TestWalletService_CreateWallet(t *testing.T) {
 
 		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
 		mockRepo.EXPECT().
-			CreateWallet(gomock.Any(), gomock.Any()).
-			DoAndReturn(func(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
-				return &models.Wallet{ID: id}, nil
+			CreateWallet(gomock.Any(), gomock.Any(), models.CreateWalletRequest{Currency: models.DefaultCurrency}).
+			DoAndReturn(func(_ context.Context, id uuid.UUID, req models.CreateWalletRequest) (*models.Wallet, error) {
+				return &models.Wallet{ID: id, Currency: req.Currency}, nil
 			})
 
 		s := NewWalletService(mockRepo, slog.Default())
-		wallet, err := s.CreateWallet(context.Background())
+		wallet, err := s.CreateWallet(context.Background(), models.CreateWalletRequest{})
 
 		assert.NoError(t, err)
 		assert.NotEqual(t, uuid.Nil, wallet.ID)
+		assert.Equal(t, models.DefaultCurrency, wallet.Currency)
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		wallet, err := s.CreateWallet(context.Background(), models.CreateWalletRequest{Currency: "usd"})
+
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.Nil(t, wallet)
 	})
 
 	t.Run("repository error", func(t *testing.T) {
@@ -40,11 +76,11 @@ func TestWalletService_CreateWallet(t *testing.T) {
 
 		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
 		mockRepo.EXPECT().
-			CreateWallet(gomock.Any(), gomock.Any()).
+			CreateWallet(gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("db error"))
 
 		s := NewWalletService(mockRepo, slog.Default())
-		wallet, err := s.CreateWallet(context.Background())
+		wallet, err := s.CreateWallet(context.Background(), models.CreateWalletRequest{})
 
 		assert.ErrorContains(t, err, "failed to create wallet")
 		assert.Nil(t, wallet)
@@ -128,9 +164,8 @@ func TestWalletService_ProcessOperation(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
-		mockRepo.EXPECT().
-			UpdateWalletBalance(gomock.Any(), validOp.WalletID, validOp.Amount, validOp.OperationType).
-			Return(&models.Wallet{ID: validOp.WalletID}, nil)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, &models.Wallet{ID: validOp.WalletID}, nil)
 
 		s := NewWalletService(mockRepo, slog.Default())
 		_, err := s.ProcessOperation(context.Background(), validOp)
@@ -143,9 +178,8 @@ func TestWalletService_ProcessOperation(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
-		mockRepo.EXPECT().
-			UpdateWalletBalance(gomock.Any(), validOp.WalletID, validOp.Amount, validOp.OperationType).
-			Return(nil, repository.ErrWalletNotFound)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, repository.ErrWalletNotFound)
 
 		s := NewWalletService(mockRepo, slog.Default())
 		_, err := s.ProcessOperation(context.Background(), validOp)
@@ -158,15 +192,96 @@ func TestWalletService_ProcessOperation(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
-		mockRepo.EXPECT().
-			UpdateWalletBalance(gomock.Any(), validOp.WalletID, validOp.Amount, validOp.OperationType).
-			Times(5).
-			Return(nil, errors.New("transient error"))
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, repository.ErrConcurrentModification).Times(5)
 
 		s := NewWalletService(mockRepo, slog.Default())
 		_, err := s.ProcessOperation(context.Background(), validOp)
 
 		assert.ErrorContains(t, err, "failed to process operation after multiple retries")
+		assert.ErrorIs(t, err, repository.ErrRetryable)
+		var exhausted *RetriesExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		assert.Equal(t, 5, exhausted.Attempts)
+	})
+
+	t.Run("retryable error then success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		gomock.InOrder(
+			uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, fmt.Errorf("%w: serialization failure", repository.ErrRetryable)),
+			uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, &models.Wallet{ID: validOp.WalletID, Balance: validOp.Amount, Version: 2}, nil),
+		)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		wallet, err := s.ProcessOperation(context.Background(), validOp)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, wallet.Version)
+	})
+
+	t.Run("attempts are recorded under the request id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		gomock.InOrder(
+			uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, fmt.Errorf("%w: serialization failure", repository.ErrRetryable)),
+			uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, &models.Wallet{ID: validOp.WalletID, Balance: validOp.Amount, Version: 2}, nil),
+		)
+		var recorded []models.OperationAttempt
+		mockRepo.EXPECT().RecordOperationAttempts(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, attempts []models.OperationAttempt) error {
+				recorded = attempts
+				return nil
+			})
+
+		s := NewWalletService(mockRepo, slog.Default(), WithAttemptHistory(time.Hour))
+		_, err := s.ProcessOperation(requestid.WithID(context.Background(), "req-1"), validOp)
+
+		require.NoError(t, err)
+		require.Len(t, recorded, 2)
+		assert.Equal(t, "req-1", recorded[0].OperationID)
+		assert.Equal(t, "service.ProcessOperation", recorded[0].Op)
+		assert.Equal(t, []string{"conflict", ""}, []string{recorded[0].ErrorClass, recorded[1].ErrorClass})
+		assert.Equal(t, 2, recorded[1].Attempt)
+	})
+
+	t.Run("retry budget exhausted fails fast", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, fmt.Errorf("%w: serialization failure", repository.ErrRetryable)).Times(1)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithRetryBudget(0, time.Minute, 0))
+		_, err := s.ProcessOperation(context.Background(), validOp)
+
+		assert.ErrorIs(t, err, ErrRetryBudgetExhausted)
+		assert.ErrorIs(t, err, repository.ErrRetryable)
+		var exhausted *RetriesExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		assert.Equal(t, 1, exhausted.Attempts)
+	})
+
+	t.Run("permanent error is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(validOp.WalletID, validOp.Amount, validOp.OperationType, nil, errors.New("permission denied for table wallets")).Times(1)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		_, err := s.ProcessOperation(context.Background(), validOp)
+
+		assert.ErrorContains(t, err, "failed to process operation: permission denied")
+		assert.NotErrorIs(t, err, repository.ErrRetryable)
 	})
 }
 
@@ -228,3 +343,2392 @@ func TestValidateOperatioеn(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletService_ExportWallets(t *testing.T) {
+	t.Run("clamps batch size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			ExportWallets(gomock.Any(), MaxExportBatchSize, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, fn func([]models.Wallet) error) error {
+				return fn([]models.Wallet{{ID: uuid.New()}})
+			})
+
+		s := NewWalletService(mockRepo, slog.Default())
+		count := 0
+		err := s.ExportWallets(context.Background(), MaxExportBatchSize+1, func(batch []models.Wallet) error {
+			count += len(batch)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			ExportWallets(gomock.Any(), DefaultExportBatchSize, gomock.Any()).
+			Return(errors.New("db error"))
+
+		s := NewWalletService(mockRepo, slog.Default())
+		err := s.ExportWallets(context.Background(), 0, func([]models.Wallet) error { return nil })
+
+		assert.ErrorContains(t, err, "failed to export wallets")
+	})
+}
+
+type fakeStore struct {
+	objects map[string][]byte
+}
+
+func (f *fakeStore) Put(_ context.Context, key string, body io.Reader, _ string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.objects[key] = data
+	return nil
+}
+
+func (f *fakeStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(f.objects[key])), nil
+}
+
+func (f *fakeStore) SignedURL(key string, _ time.Duration) (string, error) {
+	return "https://storage.example/" + key, nil
+}
+
+func TestWalletService_ExportWalletsToStore(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		s := NewWalletService(nil, slog.Default())
+		_, err := s.ExportWalletsToStore(context.Background(), 0)
+		assert.ErrorIs(t, err, storage.ErrNotConfigured)
+	})
+
+	t.Run("uploads ndjson", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			ExportWallets(gomock.Any(), DefaultExportBatchSize, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, fn func([]models.Wallet) error) error {
+				return fn([]models.Wallet{{ID: uuid.New()}, {ID: uuid.New()}})
+			})
+
+		store := &fakeStore{objects: map[string][]byte{}}
+		s := NewWalletService(mockRepo, slog.Default(), WithObjectStore(store, time.Minute))
+		result, err := s.ExportWalletsToStore(context.Background(), 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, "https://storage.example/"+result.Key, result.URL)
+		assert.Equal(t, 2, bytes.Count(store.objects[result.Key], []byte("\n")))
+	})
+}
+
+func TestWalletService_GetWalletVersions(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			GetWalletVersions(gomock.Any(), id).
+			Return(nil, repository.ErrWalletNotFound)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		_, err := s.GetWalletVersions(context.Background(), id)
+
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		expected := []models.WalletVersion{{WalletID: id, Version: 1}, {WalletID: id, Version: 2, Balance: 10}}
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			GetWalletVersions(gomock.Any(), id).
+			Return(expected, nil)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		versions, err := s.GetWalletVersions(context.Background(), id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, versions)
+	})
+}
+
+func TestWalletService_OwnerBalance_Cached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownerID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().
+		OwnerBalances(gomock.Any(), ownerID).
+		Return([]models.CurrencyBalance{{Currency: "USD", Balance: 500, WalletCount: 2}}, nil).
+		Times(2)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithOwnerBalanceCacheTTL(time.Minute))
+	now := time.Now()
+	s.ownerBalances.now = func() time.Time { return now }
+
+	first, err := s.OwnerBalance(context.Background(), ownerID)
+	require.NoError(t, err)
+	second, err := s.OwnerBalance(context.Background(), ownerID)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, int64(500), first.Balances[0].Balance)
+
+	now = now.Add(time.Minute)
+	third, err := s.OwnerBalance(context.Background(), ownerID)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+}
+
+func TestWalletService_TenantBalance_MaxStaleness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().
+		TenantBalances(gomock.Any(), "acme").
+		Return([]models.CurrencyBalance{{Currency: "USD", Balance: 900, WalletCount: 3}}, nil).
+		Times(2)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithOwnerBalanceCacheTTL(time.Minute))
+	now := time.Now()
+	s.tenantBalances.now = func() time.Time { return now }
+
+	first, err := s.TenantBalance(context.Background(), "acme", AnyStaleness)
+	require.NoError(t, err)
+	assert.Equal(t, now, first.AsOf)
+
+	now = now.Add(10 * time.Second)
+	second, err := s.TenantBalance(context.Background(), "acme", 30*time.Second)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	third, err := s.TenantBalance(context.Background(), "acme", 5*time.Second)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, now, third.AsOf)
+
+	_, err = s.TenantBalance(context.Background(), "", AnyStaleness)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestWalletService_SetWalletsStatus(t *testing.T) {
+	t.Run("empty filter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+		_, err := s.SetWalletsStatus(context.Background(), models.WalletFilter{}, models.WalletStatusFrozen)
+
+		assert.ErrorIs(t, err, ErrFilterRequired)
+	})
+
+	t.Run("freezes in batches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		filter := models.WalletFilter{Tenant: "acme"}
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().CountWalletsToSetStatus(gomock.Any(), filter, models.WalletStatusFrozen).Return(int64(700), nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().SetWalletsStatus(gomock.Any(), filter, models.WalletStatusFrozen, BulkStatusBatchSize).Return(int64(500), nil),
+			mockRepo.EXPECT().SetWalletsStatus(gomock.Any(), filter, models.WalletStatusFrozen, BulkStatusBatchSize).Return(int64(200), nil),
+			mockRepo.EXPECT().SetWalletsStatus(gomock.Any(), filter, models.WalletStatusFrozen, BulkStatusBatchSize).Return(int64(0), nil),
+		)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		started, err := s.SetWalletsStatus(context.Background(), filter, models.WalletStatusFrozen)
+		require.NoError(t, err)
+		assert.Equal(t, "wallets.freeze", started.Kind)
+
+		s.jobs.Stop()
+		job, err := s.GetJob(context.Background(), started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, jobs.StateSucceeded, job.State)
+		assert.Equal(t, int64(700), job.Total)
+		assert.Equal(t, int64(700), job.Processed)
+	})
+}
+
+type fakeScreener struct {
+	denied map[string]bool
+	err    error
+}
+
+func (f fakeScreener) Screen(_ context.Context, s screening.Subject) (screening.Result, error) {
+	if f.err != nil {
+		return screening.Result{}, f.err
+	}
+	return screening.Result{Match: f.denied[s.ID], List: "test", Reason: "sanctioned"}, nil
+}
+
+func TestWalletService_Screening(t *testing.T) {
+	owner := uuid.New()
+
+	t.Run("blocks wallet creation for denied owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			RecordScreeningHit(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, hit models.ScreeningHit) error {
+				assert.Equal(t, owner.String(), hit.SubjectID)
+				assert.Equal(t, "wallet.create", hit.Action)
+				return nil
+			})
+
+		s := NewWalletService(mockRepo, slog.Default(),
+			WithScreener(fakeScreener{denied: map[string]bool{owner.String(): true}}, 1000))
+		_, err := s.CreateWallet(context.Background(), models.CreateWalletRequest{OwnerID: uuid.NullUUID{UUID: owner, Valid: true}})
+
+		assert.ErrorIs(t, err, ErrScreeningBlocked)
+	})
+
+	t.Run("small operations skip screening", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(id, int64(10), models.OperationTypeDeposit, &models.Wallet{ID: id, Balance: 10}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithScreener(fakeScreener{err: errors.New("down")}, 1000))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: id, OperationType: models.OperationTypeDeposit, Amount: 10})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("large operation by denied owner is blocked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().
+			GetWallet(gomock.Any(), id).
+			Return(&models.Wallet{ID: id, OwnerID: uuid.NullUUID{UUID: owner, Valid: true}}, nil)
+		mockRepo.EXPECT().RecordScreeningHit(gomock.Any(), gomock.Any()).Return(nil)
+
+		s := NewWalletService(mockRepo, slog.Default(),
+			WithScreener(fakeScreener{denied: map[string]bool{owner.String(): true}}, 1000))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: id, OperationType: models.OperationTypeWithdraw, Amount: 5000})
+
+		assert.ErrorIs(t, err, ErrScreeningBlocked)
+	})
+
+	t.Run("screening outage fails closed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithScreener(fakeScreener{err: errors.New("down")}, 1000))
+		_, err := s.CreateWallet(context.Background(), models.CreateWalletRequest{OwnerID: uuid.NullUUID{UUID: owner, Valid: true}})
+
+		assert.ErrorIs(t, err, ErrScreeningUnavailable)
+	})
+}
+
+func TestWalletService_ProcessOperation_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	uow.expectApply(id, int64(80), models.OperationTypeWithdraw, nil, repository.ErrInsufficientFunds)
+	uow.expectApply(id, int64(100), models.OperationTypeWithdraw, &models.Wallet{ID: id}, nil)
+
+	limiter := limits.NewLimiter(limits.Config{Scopes: map[string]limits.Limit{"batch": {MaxAmount: 100, DailyTotal: 100}}})
+	s := NewWalletService(mockRepo, slog.Default(), WithLimiter(limiter))
+	ctx := limits.WithScope(context.Background(), "batch")
+	withdraw := func(amount int64) error {
+		_, err := s.ProcessOperation(ctx, models.WalletOperation{WalletID: id, OperationType: models.OperationTypeWithdraw, Amount: amount})
+		return err
+	}
+
+	assert.ErrorIs(t, withdraw(101), limits.ErrLimitExceeded)
+	// A failed operation doesn't count towards the daily total.
+	assert.ErrorIs(t, withdraw(80), ErrInvalidInput)
+	assert.NoError(t, withdraw(100))
+	assert.ErrorIs(t, withdraw(1), limits.ErrLimitExceeded)
+}
+
+func TestWalletService_SetWalletsStatus_MaintenanceGate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filter := models.WalletFilter{Tenant: "acme"}
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().CountWalletsToSetStatus(gomock.Any(), filter, models.WalletStatusFrozen).Return(int64(0), nil)
+	mockRepo.EXPECT().SetWalletsStatus(gomock.Any(), filter, models.WalletStatusFrozen, BulkStatusBatchSize).Return(int64(0), nil)
+
+	gate := maintenance.NewGate(true, maintenance.PolicyReject, nil)
+	s := NewWalletService(mockRepo, slog.Default(), WithMaintenanceGate(gate))
+
+	_, err := s.SetWalletsStatus(context.Background(), filter, models.WalletStatusActive)
+	assert.ErrorIs(t, err, maintenance.ErrOutsideWindow)
+
+	_, err = s.SetWalletsStatus(context.Background(), filter, models.WalletStatusFrozen)
+	assert.NoError(t, err)
+	s.jobs.Stop()
+}
+
+func TestWalletService_ProcessAtomic(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	steps := []models.WalletOperation{
+		{WalletID: a, OperationType: models.OperationTypeWithdraw, Amount: 30},
+		{WalletID: b, OperationType: models.OperationTypeDeposit, Amount: 30},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().ApplyAtomic(gomock.Any(), steps).Return([]models.AtomicStepResult{
+			{Index: 0, WalletID: a, Balance: 70},
+			{Index: 1, WalletID: b, Balance: 30},
+		}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		receipt, err := s.ProcessAtomic(context.Background(), models.AtomicRequest{Steps: steps})
+
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, receipt.ID)
+		assert.Len(t, receipt.Steps, 2)
+	})
+
+	t.Run("invalid step", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+		_, err := s.ProcessAtomic(context.Background(), models.AtomicRequest{Steps: []models.WalletOperation{
+			steps[0],
+			{WalletID: b, OperationType: models.OperationTypeDeposit, Amount: 0},
+		}})
+
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.ErrorContains(t, err, "step 1")
+	})
+
+	t.Run("step failure is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().ApplyAtomic(gomock.Any(), steps).
+			Return(nil, &repository.StepError{Index: 0, Err: repository.ErrInsufficientFunds}).
+			Times(1)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		_, err := s.ProcessAtomic(context.Background(), models.AtomicRequest{Steps: steps})
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+	})
+}
+
+func TestWalletService_CreateMandate_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+
+	for _, req := range []models.CreateMandateRequest{
+		{Limit: 100, Period: models.MandatePeriodDay},
+		{Counterparty: "acme", Period: models.MandatePeriodDay},
+		{Counterparty: "acme", Limit: 100, Period: "YEAR"},
+	} {
+		_, err := s.CreateMandate(context.Background(), uuid.New(), req)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	}
+}
+
+func TestWalletService_DebitMandate(t *testing.T) {
+	mandate := &models.Mandate{
+		ID:           uuid.New(),
+		WalletID:     uuid.New(),
+		Counterparty: "acme",
+		Limit:        100,
+		Period:       models.MandatePeriodMonth,
+		Status:       models.MandateStatusActive,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().GetMandate(gomock.Any(), mandate.ID).Return(mandate, nil)
+		mockRepo.EXPECT().DebitMandate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d models.MandateDebit, periodStart time.Time) (*models.MandateDebit, error) {
+				assert.Equal(t, 1, periodStart.Day())
+				d.WalletID = mandate.WalletID
+				d.Balance = 60
+				return &d, nil
+			})
+
+		s := NewWalletService(mockRepo, slog.Default())
+		debit, err := s.DebitMandate(context.Background(), mandate.ID, models.MandateDebitRequest{Counterparty: "acme", Amount: 40})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), debit.Amount)
+		assert.Equal(t, int64(60), debit.Balance)
+	})
+
+	t.Run("limit exceeded is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().GetMandate(gomock.Any(), mandate.ID).Return(mandate, nil)
+		mockRepo.EXPECT().DebitMandate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, repository.ErrMandateLimitExceeded).Times(1)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		_, err := s.DebitMandate(context.Background(), mandate.ID, models.MandateDebitRequest{Counterparty: "acme", Amount: 400})
+
+		assert.ErrorIs(t, err, repository.ErrMandateLimitExceeded)
+	})
+}
+
+func TestWalletService_DebitMandate_WithdrawalLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mandate := &models.Mandate{ID: uuid.New(), WalletID: uuid.New(), Counterparty: "acme", Limit: 1000,
+		Period: models.MandatePeriodMonth, Status: models.MandateStatusActive}
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	mockRepo.EXPECT().GetMandate(gomock.Any(), mandate.ID).Return(mandate, nil)
+	uow.wallets.EXPECT().LockWallet(gomock.Any(), mandate.WalletID).Return(&models.Wallet{ID: mandate.WalletID, Balance: 500}, nil)
+	uow.versions.EXPECT().WithdrawnSince(gomock.Any(), mandate.WalletID, gomock.Any()).Return(int64(80), nil)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithWithdrawalLimits(WithdrawalLimits{Daily: 100}))
+	_, err := s.DebitMandate(context.Background(), mandate.ID, models.MandateDebitRequest{Counterparty: "acme", Amount: 40})
+
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestWalletService_CaptureHold_WithdrawalLimits(t *testing.T) {
+	walletID, holdID := uuid.New(), uuid.New()
+	limits := WithdrawalLimits{Daily: 100}
+
+	t.Run("whole hold counts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.wallets.EXPECT().LockWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID, Balance: 500}, nil)
+		mockRepo.EXPECT().ListHolds(gomock.Any(), walletID).
+			Return([]models.FundsHold{{ID: holdID, WalletID: walletID, Amount: 60, Status: models.FundsHoldPending}}, nil)
+		uow.versions.EXPECT().WithdrawnSince(gomock.Any(), walletID, gomock.Any()).Return(int64(50), nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithWithdrawalLimits(limits))
+		_, err := s.CaptureHold(context.Background(), walletID, holdID, models.CaptureHoldRequest{})
+
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.wallets.EXPECT().LockWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID, Balance: 500}, nil)
+		uow.versions.EXPECT().WithdrawnSince(gomock.Any(), walletID, gomock.Any()).Return(int64(50), nil)
+		mockRepo.EXPECT().CaptureHold(gomock.Any(), walletID, holdID, int64(30), gomock.Any()).
+			Return(&models.FundsHold{ID: holdID, WalletID: walletID, Amount: 60, CapturedAmount: 30, Status: models.FundsHoldCaptured}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithWithdrawalLimits(limits))
+		hold, err := s.CaptureHold(context.Background(), walletID, holdID, models.CaptureHoldRequest{Amount: 30})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), hold.CapturedAmount)
+	})
+}
+
+func TestWalletService_ExpirePromoCredits_SkipsFrozen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	frozen, due := uuid.New(), uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().DuePromoCredits(gomock.Any(), gomock.Any(), PromoExpiryBatchSize).
+		Return([]models.PromoCredit{{ID: frozen, WalletID: uuid.New()}, {ID: due, WalletID: uuid.New()}}, nil)
+	mockRepo.EXPECT().ExpirePromoCredit(gomock.Any(), frozen, gomock.Any()).Return(int64(0), repository.ErrWalletFrozen)
+	mockRepo.EXPECT().ExpirePromoCredit(gomock.Any(), due, gomock.Any()).Return(int64(25), nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+
+	assert.NoError(t, s.ExpirePromoCredits(context.Background()))
+}
+
+func TestWalletService_GrantPromo_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+
+	_, err := s.GrantPromo(context.Background(), uuid.New(), models.GrantPromoRequest{Amount: 10, ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = s.GrantPromo(context.Background(), uuid.New(), models.GrantPromoRequest{ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestWalletService_ProcessOperation_AccruesReward(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	operation := models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000, Category: "groceries"}
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	uow.expectApply(walletID, int64(1000), models.OperationTypeWithdraw, &models.Wallet{ID: walletID, Balance: 500, Version: 7}, nil)
+	mockRepo.EXPECT().AccrueReward(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, a models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+			assert.Equal(t, walletID.String()+":7", a.TransactionID)
+			assert.Equal(t, "groceries", a.Rule)
+			assert.Equal(t, int64(20), a.Amount)
+			return &a, true, nil
+		})
+
+	engine := rewards.Rules{{Name: "groceries", BasisPoints: 200, Categories: []string{"groceries"}}}
+	s := NewWalletService(mockRepo, slog.Default(), WithRewards(engine))
+
+	_, err := s.ProcessOperation(context.Background(), operation)
+	require.NoError(t, err)
+}
+
+func TestWalletService_ProcessOperation_RewardFailureIsIgnored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	uow.expectApply(walletID, int64(1000), models.OperationTypeWithdraw, &models.Wallet{ID: walletID, Balance: 500, Version: 7}, nil)
+	mockRepo.EXPECT().AccrueReward(gomock.Any(), gomock.Any()).Return(nil, false, errors.New("db down"))
+
+	s := NewWalletService(mockRepo, slog.Default(), WithRewards(rewards.Rules{{Name: "base", BasisPoints: 100}}))
+
+	wallet, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), wallet.Balance)
+}
+
+func TestWalletService_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().ListWalletVersions(gomock.Any(), day, day.AddDate(0, 0, 2)).Return([]models.WalletVersion{
+		{WalletID: walletID, Version: 2, OperationType: models.OperationTypeDeposit, Amount: 1000, CreatedAt: day.Add(9 * time.Hour)},
+		{WalletID: walletID, Version: 3, OperationType: models.OperationTypeWithdraw, Amount: 250, CreatedAt: day.Add(30 * time.Hour)},
+	}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+	report, err := s.Reconcile(context.Background(), []reconcile.Entry{
+		{Line: 2, Reference: walletID.String() + ":2", Amount: 1000, Date: day},
+		{Line: 3, Reference: "BANK1", Amount: -250, Date: day.AddDate(0, 0, 1)},
+	}, time.Time{}, time.Time{})
+
+	require.NoError(t, err)
+	require.Len(t, report.Matched, 2)
+	assert.Equal(t, reconcile.ByReference, report.Matched[0].By)
+	assert.Equal(t, reconcile.ByAmountDate, report.Matched[1].By)
+	assert.Empty(t, report.Missing)
+	assert.Empty(t, report.Unexpected)
+}
+
+func TestWalletService_ReceiveInbound(t *testing.T) {
+	walletID := uuid.New()
+
+	t.Run("credited to wallet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).
+			Return(&models.Wallet{ID: walletID, Currency: "USD", Status: models.WalletStatusActive}, nil)
+		uow.expectApply(walletID, int64(300), models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 300}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		result, err := s.ReceiveInbound(context.Background(), models.InboundCredit{
+			WalletID:  uuid.NullUUID{UUID: walletID, Valid: true},
+			Reference: "REF1",
+			Amount:    300,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.InboundCredited, result.Status)
+		assert.Equal(t, int64(300), result.Wallet.Balance)
+	})
+
+	t.Run("currency mismatch goes to suspense", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).
+			Return(&models.Wallet{ID: walletID, Currency: "EUR", Status: models.WalletStatusActive}, nil)
+		mockRepo.EXPECT().ReceiveToSuspense(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+				assert.Equal(t, SuspenseReasonCurrencyMismatch, c.Reason)
+				assert.Equal(t, "USD", c.Currency)
+				return &c, nil
+			})
+
+		s := NewWalletService(mockRepo, slog.Default())
+		result, err := s.ReceiveInbound(context.Background(), models.InboundCredit{
+			WalletID:  uuid.NullUUID{UUID: walletID, Valid: true},
+			Reference: "REF1",
+			Amount:    300,
+			Currency:  "USD",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.InboundSuspense, result.Status)
+		assert.Equal(t, "REF1", result.Case.Reference)
+	})
+
+	t.Run("no wallet goes to suspense", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().ReceiveToSuspense(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+				assert.Equal(t, SuspenseReasonNoWallet, c.Reason)
+				return &c, nil
+			})
+
+		s := NewWalletService(mockRepo, slog.Default())
+		result, err := s.ReceiveInbound(context.Background(), models.InboundCredit{Reference: "REF2", Amount: 50})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.InboundSuspense, result.Status)
+	})
+}
+
+type fakeNotifier struct {
+	events []webhook.Event
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, e webhook.Event) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+func TestWalletService_DisputeLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID, disputeID := uuid.New(), uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().OpenDispute(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, d models.Dispute) (*models.Dispute, error) {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), d.Deadline, time.Minute)
+			d.ID, d.Status = disputeID, models.DisputeStatusOpen
+			return &d, nil
+		})
+	mockRepo.EXPECT().CloseDispute(gomock.Any(), disputeID, models.DisputeStatusReversed, "chargeback", gomock.Any()).
+		Return(&models.Dispute{ID: disputeID, Status: models.DisputeStatusReversed}, nil)
+
+	notifier := &fakeNotifier{}
+	s := NewWalletService(mockRepo, slog.Default(), WithDisputeWindow(time.Hour), WithNotifier(notifier))
+
+	_, err := s.OpenDispute(context.Background(), models.OpenDisputeRequest{WalletID: walletID, Version: 2, Reason: "fraud"})
+	require.NoError(t, err)
+	_, err = s.ResolveDispute(context.Background(), disputeID, models.ResolveDisputeRequest{Outcome: "REVERSE", Note: "chargeback"})
+	require.NoError(t, err)
+
+	_, err = s.ResolveDispute(context.Background(), disputeID, models.ResolveDisputeRequest{Outcome: "KEEP"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	require.Len(t, notifier.events, 2)
+	assert.Equal(t, EventDisputeOpened, notifier.events[0].Type)
+	assert.Equal(t, EventDisputeReversed, notifier.events[1].Type)
+}
+
+func TestWalletService_DeliverWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var signatures []string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures = append(signatures, r.Header.Get(webhook.SignatureHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	payload := []byte(`{"type":"wallet.created"}`)
+	delivered := models.WebhookDelivery{EventID: uuid.New(), SubscriptionID: uuid.New(), EventType: EventWalletCreated,
+		Payload: payload, URL: ok.URL, Secret: "s1"}
+	retried := models.WebhookDelivery{EventID: uuid.New(), SubscriptionID: uuid.New(), EventType: EventWalletCreated,
+		Payload: payload, Attempts: 2, URL: failing.URL, Secret: "s2"}
+	exhausted := retried
+	exhausted.SubscriptionID, exhausted.Attempts = uuid.New(), 4
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+		Return([]models.WebhookDelivery{delivered, retried, exhausted}, nil)
+	mockRepo.EXPECT().RecordWebhookDeliveryAttempt(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, a models.WebhookDeliveryAttempt, status models.WebhookDeliveryStatus, next time.Time) error {
+			switch a.SubscriptionID {
+			case delivered.SubscriptionID:
+				assert.Equal(t, 1, a.Attempt)
+				assert.Equal(t, http.StatusNoContent, a.StatusCode)
+				assert.Equal(t, models.WebhookDeliveryDelivered, status)
+			case retried.SubscriptionID:
+				assert.Equal(t, 3, a.Attempt)
+				assert.Equal(t, http.StatusBadGateway, a.StatusCode)
+				assert.NotEmpty(t, a.Error)
+				assert.Equal(t, models.WebhookDeliveryPending, status)
+				assert.WithinDuration(t, a.AttemptedAt.Add(4*time.Minute), next, time.Second)
+			case exhausted.SubscriptionID:
+				assert.Equal(t, models.WebhookDeliveryFailed, status)
+			}
+			return nil
+		})
+
+	s := NewWalletService(mockRepo, slog.Default(), WithWebhookDelivery(WebhookDeliveryPolicy{
+		Timeout: time.Second, MaxAttempts: 5, BackoffBase: time.Minute, BackoffMax: time.Hour, BatchSize: 10, Workers: 2,
+	}))
+	require.NoError(t, s.DeliverWebhooks(context.Background()))
+
+	require.Len(t, signatures, 1)
+	assert.True(t, webhook.Verify("s1", payload, signatures[0]))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	p := WebhookDeliveryPolicy{BackoffBase: 30 * time.Second, BackoffMax: 5 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, webhookBackoff(p, 1))
+	assert.Equal(t, time.Minute, webhookBackoff(p, 2))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(p, 4))
+	assert.Equal(t, 5*time.Minute, webhookBackoff(p, 5))
+	assert.Equal(t, 5*time.Minute, webhookBackoff(p, 60))
+}
+
+func TestWalletService_ExpireDisputes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closed, open := uuid.New(), uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().DueDisputes(gomock.Any(), gomock.Any(), DisputeExpiryBatchSize).Return([]uuid.UUID{closed, open}, nil)
+	mockRepo.EXPECT().CloseDispute(gomock.Any(), closed, models.DisputeStatusExpired, gomock.Any(), gomock.Any()).
+		Return(nil, repository.ErrDisputeClosed)
+	mockRepo.EXPECT().CloseDispute(gomock.Any(), open, models.DisputeStatusExpired, gomock.Any(), gomock.Any()).
+		Return(&models.Dispute{ID: open, Status: models.DisputeStatusExpired}, nil)
+
+	notifier := &fakeNotifier{}
+	s := NewWalletService(mockRepo, slog.Default(), WithNotifier(notifier))
+
+	require.NoError(t, s.ExpireDisputes(context.Background()))
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, EventDisputeExpired, notifier.events[0].Type)
+}
+
+func TestWalletService_ProcessOperation_Sandbox(t *testing.T) {
+	walletID := uuid.New()
+	sb := sandbox.New([]string{"acme-test"}, time.Millisecond)
+
+	t.Run("provider failure on sandbox tenant", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID, Tenant: "acme-test"}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithSandbox(sb))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{
+			WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: sandbox.AmountProviderFailure,
+		})
+
+		assert.ErrorIs(t, err, sandbox.ErrProviderFailure)
+	})
+
+	t.Run("insufficient funds on sandbox tenant", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID, Tenant: "acme-test"}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithSandbox(sb))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{
+			WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: sandbox.AmountInsufficientFunds,
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("magic amount on regular tenant is processed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID, Tenant: "acme"}, nil)
+		uow.expectApply(walletID, sandbox.AmountProviderFailure, models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: sandbox.AmountProviderFailure}, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithSandbox(sb))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{
+			WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: sandbox.AmountProviderFailure,
+		})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestWalletService_WipeSandbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().WipeTenant(gomock.Any(), "acme-test").Return(int64(2), nil)
+	mockRepo.EXPECT().WipeTenant(gomock.Any(), "beta-test").Return(int64(0), nil)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithSandbox(sandbox.New([]string{"acme-test", "beta-test"}, 0)))
+
+	assert.NoError(t, s.WipeSandbox(context.Background()))
+}
+
+func TestWalletService_ExportEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().ListEvents(gomock.Any(), int64(10), gomock.Any(), 3).
+		DoAndReturn(func(_ context.Context, _ int64, until time.Time, _ int) ([]models.LedgerEvent, error) {
+			assert.True(t, until.Before(time.Now().Add(-EventSettleDelay+time.Second)))
+			return []models.LedgerEvent{{Offset: 11, WalletID: walletID}, {Offset: 14, WalletID: walletID}}, nil
+		})
+	mockRepo.EXPECT().ListEvents(gomock.Any(), int64(14), gomock.Any(), 1).Return([]models.LedgerEvent{}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+	var got []models.LedgerEvent
+	next, err := s.ExportEvents(context.Background(), 10, 3, func(batch []models.LedgerEvent) error {
+		got = append(got, batch...)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), next)
+	assert.Len(t, got, 2)
+}
+
+func TestWalletService_GetWalletBalance_CollapsesConcurrentReads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	release := make(chan struct{})
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().GetWalletBalance(gomock.Any(), walletID).
+		DoAndReturn(func(context.Context, uuid.UUID) (*models.WalletBalance, error) {
+			<-release
+			return &models.WalletBalance{WalletID: walletID, Balance: 100}, nil
+		}).Times(1)
+
+	s := NewWalletService(mockRepo, slog.Default())
+
+	const readers = 10
+	results := make(chan int64, readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			b, err := s.GetWalletBalance(context.Background(), walletID)
+			if err != nil {
+				results <- -1
+				return
+			}
+			results <- b.Balance
+		}()
+	}
+	require.Eventually(t, func() bool {
+		s.balanceReads.mu.Lock()
+		defer s.balanceReads.mu.Unlock()
+		c, ok := s.balanceReads.calls[walletID]
+		return ok && c.dups == readers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	for i := 0; i < readers; i++ {
+		assert.Equal(t, int64(100), <-results)
+	}
+}
+
+func TestWalletService_GetWallet_NegativeCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(nil, repository.ErrWalletNotFound).Times(1)
+	mockRepo.EXPECT().GetWalletBalance(gomock.Any(), walletID).Times(0)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithMissCacheTTL(time.Minute))
+
+	_, err := s.GetWallet(context.Background(), walletID)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.GetWallet(context.Background(), walletID)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.GetWalletBalance(context.Background(), walletID)
+	assert.ErrorIs(t, err, repository.ErrWalletNotFound)
+
+	s.misses.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(&models.Wallet{ID: walletID}, nil)
+	_, err = s.GetWallet(context.Background(), walletID)
+	assert.NoError(t, err)
+}
+
+func TestWalletService_ListWallets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filter := models.WalletFilter{Currency: "USD"}
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().
+		ListWallets(gomock.Any(), filter, models.WalletSort{}, models.WalletCursor{}, 3).
+		Return([]models.Wallet{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+	wallets, hasMore, err := s.ListWallets(context.Background(), filter, uuid.Nil, 2)
+
+	require.NoError(t, err)
+	assert.Len(t, wallets, 2)
+	assert.True(t, hasMore)
+}
+
+func TestWalletService_ListWallets_InvalidFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+	minBalance, maxBalance := int64(10), int64(5)
+	after := time.Now()
+	before := after.Add(-time.Hour)
+
+	for _, filter := range []models.WalletFilter{
+		{Status: "DELETED"},
+		{MinBalance: &minBalance, MaxBalance: &maxBalance},
+		{CreatedAfter: &after, CreatedBefore: &before},
+	} {
+		_, _, err := s.ListWallets(context.Background(), filter, uuid.Nil, 10)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	}
+	_, _, err := s.ListWalletsSorted(context.Background(), models.WalletFilter{}, models.WalletSort{Field: "label"}, models.WalletCursor{}, 10)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestWalletService_AuthorizeWalletFilter(t *testing.T) {
+	s := NewWalletService(nil, slog.Default())
+	owner := uuid.New()
+	user := auth.WithSubject(context.Background(), auth.Subject{ID: owner.String(), Role: auth.RoleUser})
+
+	filter := models.WalletFilter{Currency: "USD"}
+	require.NoError(t, s.AuthorizeWalletFilter(user, &filter))
+	assert.Equal(t, uuid.NullUUID{UUID: owner, Valid: true}, filter.OwnerID)
+
+	filter = models.WalletFilter{OwnerID: uuid.NullUUID{UUID: uuid.New(), Valid: true}}
+	assert.ErrorIs(t, s.AuthorizeWalletFilter(user, &filter), ErrForbidden)
+	named := auth.WithSubject(context.Background(), auth.Subject{ID: "user-42", Role: auth.RoleUser})
+	assert.ErrorIs(t, s.AuthorizeWalletFilter(named, &models.WalletFilter{}), ErrForbidden)
+
+	// Admins list every wallet.
+	admin := auth.WithSubject(context.Background(), auth.Subject{ID: "ops", Role: auth.RoleAdmin})
+	filter = models.WalletFilter{}
+	require.NoError(t, s.AuthorizeWalletFilter(admin, &filter))
+	assert.True(t, filter.IsEmpty())
+}
+
+func TestWalletService_StartBulkOperations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	good, bad := uuid.New(), uuid.New()
+	ops := []models.WalletOperation{
+		{WalletID: good, OperationType: models.OperationTypeDeposit, Amount: 100},
+		{WalletID: bad, OperationType: models.OperationTypeWithdraw, Amount: 50},
+	}
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	uow.expectApply(good, int64(100), models.OperationTypeDeposit, &models.Wallet{ID: good, Balance: 100, Version: 2}, nil)
+	uow.expectApply(bad, int64(50), models.OperationTypeWithdraw, nil, repository.ErrInsufficientFunds)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithBulkWorkers(2))
+	started, err := s.StartBulkOperations(context.Background(), ops)
+	require.NoError(t, err)
+	assert.Equal(t, BulkOperationsJobKind, started.Kind)
+
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		job, err = s.GetBulkOperationsJob(context.Background(), started.ID)
+		return err == nil && job.State == jobs.StateSucceeded
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(2), job.Processed)
+	assert.Equal(t, int64(1), job.Failed)
+	assert.Equal(t, int64(0), job.Remaining)
+
+	report, err := s.GetBulkOperationsReport(context.Background(), started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "index,walletId,operationType,amount,status,version,balance,error\n"+
+		"0,"+good.String()+",DEPOSIT,100,succeeded,2,100,\n"+
+		"1,"+bad.String()+",WITHDRAW,50,failed,,,invalid input\n", string(report.Data))
+}
+
+// claimStore hands out one interrupted job and keeps what it is saved as.
+type claimStore struct {
+	mu     sync.Mutex
+	rec    jobs.Record
+	report []byte
+}
+
+func (c *claimStore) CreateJob(context.Context, jobs.Record) error { return nil }
+
+func (c *claimStore) SaveJob(_ context.Context, _ string, rec jobs.Record, report []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rec.Job, c.rec.Checkpoint = rec.Job, rec.Checkpoint
+	c.report = append(c.report, report...)
+	return nil
+}
+
+func (c *claimStore) GetJob(context.Context, uuid.UUID) (jobs.Record, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rec, nil
+}
+
+func (c *claimStore) ListJobs(context.Context, string, int) ([]jobs.Job, error) { return nil, nil }
+
+func (c *claimStore) ClaimJobs(context.Context, string, time.Time, int) ([]jobs.Record, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []jobs.Record{c.rec}, nil
+}
+
+func (c *claimStore) JobReport(context.Context, uuid.UUID) (jobs.Report, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return jobs.Report{ContentType: "text/csv", Data: c.report}, nil
+}
+
+func TestWalletService_BulkOperationsResume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	ops := make([]models.WalletOperation, len(ids))
+	for i, id := range ids {
+		ops[i] = models.WalletOperation{WalletID: id, OperationType: models.OperationTypeDeposit, Amount: 10}
+	}
+	payload, err := json.Marshal(bulkOperationsPayload{Operations: ops})
+	require.NoError(t, err)
+
+	// Interrupted after the first operation was reported and while the
+	// second one was in flight.
+	jobID := uuid.New()
+	store := &claimStore{rec: jobs.Record{
+		Job:        jobs.Job{ID: jobID, Kind: BulkOperationsJobKind, State: jobs.StateRunning, Attempts: 2, Owner: "b", Processed: 2},
+		Payload:    payload,
+		Checkpoint: json.RawMessage(`{"next":1,"dispatched":2,"processed":1}`),
+	}}
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	uow.expectApply(ids[2], int64(10), models.OperationTypeDeposit, &models.Wallet{ID: ids[2], Balance: 10, Version: 2}, nil)
+
+	manager := jobs.NewManager(slog.Default(), jobs.WithStore(store, "b"))
+	s := NewWalletService(mockRepo, slog.Default(), WithJobs(manager))
+	defer manager.Stop()
+	require.NoError(t, manager.Resume(context.Background()))
+
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		job, err = s.GetBulkOperationsJob(context.Background(), jobID)
+		return err == nil && job.State == jobs.StateSucceeded
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(3), job.Processed)
+	assert.Equal(t, int64(1), job.Failed)
+
+	report, err := s.GetBulkOperationsReport(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Equal(t, "1,"+ids[1].String()+",DEPOSIT,10,unknown,,,interrupted while in progress; not retried\n"+
+		"2,"+ids[2].String()+",DEPOSIT,10,succeeded,2,10,\n", string(report.Data))
+}
+
+func TestWalletService_StartBulkOperations_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+	_, err := s.StartBulkOperations(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	other := s.jobs.Start("wallets.freeze", func(context.Context, *jobs.Progress) error { return nil })
+	s.jobs.Stop()
+	_, err = s.GetBulkOperationsJob(context.Background(), other.ID)
+	assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+}
+
+func TestWalletService_GetWalletBalance_HotCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	mockRepo.EXPECT().GetWalletBalance(gomock.Any(), walletID).
+		Return(&models.WalletBalance{WalletID: walletID, Balance: 100}, nil).Times(1)
+	uow.expectApply(walletID, int64(50), models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 150}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithBalanceCache(10, time.Minute))
+
+	for range 2 {
+		b, err := s.GetWalletBalance(context.Background(), walletID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), b.Balance)
+	}
+	_, err := s.ProcessOperation(context.Background(), models.WalletOperation{
+		WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 50,
+	})
+	require.NoError(t, err)
+
+	b, err := s.GetWalletBalance(context.Background(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), b.Balance, "own writes update the cache")
+	assert.Equal(t, BalanceCacheStats{Hits: 2, Misses: 1, Entries: 1}, s.BalanceCacheStats())
+}
+
+func TestBalanceCache_EvictsAndExpires(t *testing.T) {
+	now := time.Now()
+	c := newBalanceCache(2, time.Second)
+	c.now = func() time.Time { return now }
+	a, b, d := uuid.New(), uuid.New(), uuid.New()
+
+	c.put(&models.Wallet{ID: a, Balance: 1})
+	c.put(&models.Wallet{ID: b, Balance: 2})
+	_, _ = c.get(a)
+	c.put(&models.Wallet{ID: d, Balance: 3, HeldBalance: 1})
+	_, ok := c.get(b)
+	assert.False(t, ok, "least recently used entry is evicted")
+	v, ok := c.get(a)
+	assert.True(t, ok)
+	assert.Equal(t, models.WalletBalance{WalletID: a, Balance: 1, Available: 1}, v)
+
+	gen := c.generation()
+	c.forget(a)
+	c.fill(models.WalletBalance{WalletID: a, Balance: 1, Available: 1}, gen)
+	_, ok = c.get(a)
+	assert.False(t, ok, "a read older than a write is not cached")
+
+	now = now.Add(time.Second)
+	_, ok = c.get(d)
+	assert.False(t, ok)
+}
+
+func TestWalletService_SearchWallets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().
+		SearchWallets(gomock.Any(), "3f2a", uuid.Nil, 3).
+		Return([]models.Wallet{{ID: uuid.New()}, {ID: uuid.New()}}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+	wallets, hasMore, err := s.SearchWallets(context.Background(), " 3f2a ", uuid.Nil, 2)
+
+	require.NoError(t, err)
+	assert.Len(t, wallets, 2)
+	assert.False(t, hasMore)
+
+	_, _, err = s.SearchWallets(context.Background(), "3f", uuid.Nil, 2)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestWalletService_AddTransactionNote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().AddTransactionNote(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, n models.TransactionNote) (*models.TransactionNote, error) {
+			assert.Equal(t, int64(42), n.TransactionID)
+			assert.Equal(t, "alice", n.Author)
+			assert.Equal(t, []string{"ticket-1"}, n.Attachments)
+			return &n, nil
+		})
+
+	s := NewWalletService(mockRepo, slog.Default())
+	n, err := s.AddTransactionNote(context.Background(), 42, models.AddTransactionNoteRequest{
+		Author: " alice ", Body: "refund approved", Attachments: []string{" ticket-1 "},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "refund approved", n.Body)
+
+	for _, req := range []models.AddTransactionNoteRequest{
+		{Body: "no author"},
+		{Author: "alice"},
+		{Author: "alice", Attachments: []string{" "}},
+	} {
+		_, err := s.AddTransactionNote(context.Background(), 42, req)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	}
+}
+
+func TestWalletService_OperationHooks(t *testing.T) {
+	walletID := uuid.New()
+	operation := models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 100}
+
+	t.Run("run in order around the operation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var calls []string
+		before := func(name string) BeforeOperation {
+			return BeforeOperationFunc(func(_ context.Context, op models.WalletOperation) error {
+				assert.Equal(t, operation, op)
+				calls = append(calls, name)
+				return nil
+			})
+		}
+		after := func(name string) AfterOperation {
+			return AfterOperationFunc(func(_ context.Context, w *models.Wallet, _ models.WalletOperation) {
+				assert.Equal(t, int64(100), w.Balance)
+				calls = append(calls, name)
+			})
+		}
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(walletID, int64(100), models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 100}, nil).
+			Do(func(context.Context, uuid.UUID) {
+				calls = append(calls, "apply")
+			})
+
+		s := NewWalletService(mockRepo, slog.Default(),
+			WithBeforeOperation(before("before1"), before("before2")),
+			WithAfterOperation(after("after1")),
+			WithAfterOperation(after("after2")))
+		_, err := s.ProcessOperation(context.Background(), operation)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"before1", "before2", "apply", "after1", "after2"}, calls)
+	})
+
+	t.Run("first rejection stops the operation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		errRisk := errors.New("risk score too high")
+		var ran []string
+		s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default(),
+			WithBeforeOperation(
+				BeforeOperationFunc(func(context.Context, models.WalletOperation) error {
+					ran = append(ran, "risk")
+					return errRisk
+				}),
+				BeforeOperationFunc(func(context.Context, models.WalletOperation) error {
+					ran = append(ran, "rules")
+					return nil
+				})),
+			WithAfterOperation(AfterOperationFunc(func(context.Context, *models.Wallet, models.WalletOperation) {
+				t.Error("after hook ran for a rejected operation")
+			})))
+		_, err := s.ProcessOperation(context.Background(), operation)
+
+		assert.ErrorIs(t, err, ErrOperationRejected)
+		assert.ErrorIs(t, err, errRisk)
+		assert.Equal(t, []string{"risk"}, ran)
+	})
+
+	t.Run("after hooks skipped on failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+		uow.expectApply(walletID, int64(100), models.OperationTypeDeposit, nil, repository.ErrWalletNotFound)
+
+		s := NewWalletService(mockRepo, slog.Default(),
+			WithAfterOperation(AfterOperationFunc(func(context.Context, *models.Wallet, models.WalletOperation) {
+				t.Error("after hook ran for a failed operation")
+			})))
+		_, err := s.ProcessOperation(context.Background(), operation)
+
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("atomic step rejection rejects the request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		steps := []models.WalletOperation{operation, {WalletID: uuid.New(), OperationType: models.OperationTypeWithdraw, Amount: 500}}
+		s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default(),
+			WithBeforeOperation(BeforeOperationFunc(func(_ context.Context, op models.WalletOperation) error {
+				if op.Amount > 200 {
+					return errors.New("over rule limit")
+				}
+				return nil
+			})))
+		_, err := s.ProcessAtomic(context.Background(), models.AtomicRequest{Steps: steps})
+
+		assert.ErrorIs(t, err, ErrOperationRejected)
+		assert.ErrorContains(t, err, "step 1")
+	})
+
+	t.Run("atomic after hooks see every step", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		other := uuid.New()
+		steps := []models.WalletOperation{operation, {WalletID: other, OperationType: models.OperationTypeDeposit, Amount: 5}}
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().ApplyAtomic(gomock.Any(), steps).Return([]models.AtomicStepResult{
+			{Index: 0, WalletID: walletID, Balance: 100, Version: 2},
+			{Index: 1, WalletID: other, Balance: 5, Version: 1},
+		}, nil)
+
+		var seen []uuid.UUID
+		s := NewWalletService(mockRepo, slog.Default(),
+			WithAfterOperation(AfterOperationFunc(func(_ context.Context, w *models.Wallet, _ models.WalletOperation) {
+				seen = append(seen, w.ID)
+			})))
+		_, err := s.ProcessAtomic(context.Background(), models.AtomicRequest{Steps: steps})
+
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{walletID, other}, seen)
+	})
+}
+
+func TestWalletService_Transfer(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+
+	t.Run("success counts once against limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().Transfer(gomock.Any(), from, to, int64(100)).
+			Return(&models.Wallet{ID: from, Balance: 50, Currency: "USD", Version: 4}, &models.Wallet{ID: to, Balance: 100, Currency: "USD", Version: 1}, nil)
+
+		var legs []models.OperationType
+		limiter := limits.NewLimiter(limits.Config{Scopes: map[string]limits.Limit{"batch": {MaxAmount: 100, DailyTotal: 150}}})
+		s := NewWalletService(mockRepo, slog.Default(), WithLimiter(limiter),
+			WithAfterOperation(AfterOperationFunc(func(_ context.Context, _ *models.Wallet, op models.WalletOperation) {
+				legs = append(legs, op.OperationType)
+			})))
+		ctx := limits.WithScope(context.Background(), "batch")
+		transfer, err := s.Transfer(ctx, models.TransferRequest{FromWalletID: from, ToWalletID: to, Amount: 100})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), transfer.FromBalance)
+		assert.Equal(t, int64(100), transfer.ToBalance)
+		assert.Equal(t, "USD", transfer.Currency)
+		assert.Equal(t, []models.OperationType{models.OperationTypeWithdraw, models.OperationTypeDeposit}, legs)
+
+		_, err = s.Transfer(ctx, models.TransferRequest{FromWalletID: from, ToWalletID: to, Amount: 51})
+		assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewWalletService(mockrepository.NewMockWalletRepository(ctrl), slog.Default())
+		for _, req := range []models.TransferRequest{
+			{FromWalletID: from, ToWalletID: to},
+			{FromWalletID: from, ToWalletID: from, Amount: 10},
+			{ToWalletID: to, Amount: 10},
+		} {
+			_, err := s.Transfer(context.Background(), req)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		}
+	})
+
+	t.Run("rejection passes through", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().Transfer(gomock.Any(), from, to, int64(10)).Return(nil, nil, repository.ErrCurrencyMismatch).Times(1)
+
+		s := NewWalletService(mockRepo, slog.Default())
+		_, err := s.Transfer(context.Background(), models.TransferRequest{FromWalletID: from, ToWalletID: to, Amount: 10})
+
+		assert.ErrorIs(t, err, repository.ErrCurrencyMismatch)
+	})
+}
+
+func TestWalletService_ListTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().ListTransactions(gomock.Any(), id, 0, 3).Return([]models.Transaction{
+		{ID: 9, Version: 4}, {ID: 7, Version: 3}, {ID: 5, Version: 2},
+	}, nil)
+	mockRepo.EXPECT().ListTransactions(gomock.Any(), id, 3, 3).Return([]models.Transaction{{ID: 5, Version: 2}}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default())
+	page, hasMore, err := s.ListTransactions(context.Background(), id, 0, 2)
+	require.NoError(t, err)
+	assert.True(t, hasMore)
+	assert.Len(t, page, 2)
+
+	page, hasMore, err = s.ListTransactions(context.Background(), id, 3, 2)
+	require.NoError(t, err)
+	assert.False(t, hasMore)
+	assert.Len(t, page, 1)
+
+	_, _, err = s.ListTransactions(context.Background(), id, -1, 2)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := newRetryBudget(0.2, 10*time.Second, 1)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	for range 10 {
+		b.attempt()
+	}
+	// 1 free retry plus 20% of the attempts, retries included.
+	assert.True(t, b.allowRetry())
+	assert.True(t, b.allowRetry())
+	assert.True(t, b.allowRetry())
+	assert.False(t, b.allowRetry())
+
+	// Once the attempts age out of the window only the free retry is left.
+	now = now.Add(11 * time.Second)
+	assert.True(t, b.allowRetry())
+	assert.False(t, b.allowRetry())
+
+	assert.Nil(t, newRetryBudget(1, time.Second, 0))
+	var unlimited *retryBudget
+	unlimited.attempt()
+	assert.True(t, unlimited.allowRetry())
+}
+
+func TestWalletService_FlagDormantWallets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	mockRepo.EXPECT().FlagDormantWallets(gomock.Any(), gomock.Any(), DormancyBatchSize).
+		DoAndReturn(func(_ context.Context, idleSince time.Time, _ int) ([]models.Wallet, error) {
+			assert.WithinDuration(t, time.Now().Add(-90*24*time.Hour), idleSince, time.Minute)
+			return []models.Wallet{{ID: uuid.New(), Status: models.WalletStatusDormant}}, nil
+		})
+
+	notifier := &fakeNotifier{}
+	s := NewWalletService(mockRepo, slog.Default(), WithNotifier(notifier), WithDormancy(90*24*time.Hour, 1000, true))
+
+	require.NoError(t, s.FlagDormantWallets(context.Background()))
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, EventWalletDormant, notifier.events[0].Type)
+
+	// Without WithDormancy the job does nothing.
+	require.NoError(t, NewWalletService(mockRepo, slog.Default()).FlagDormantWallets(context.Background()))
+}
+
+func TestWalletService_ProcessOperation_Dormant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	dormant := &models.Wallet{ID: walletID, Balance: 5000, Status: models.WalletStatusDormant}
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	s := NewWalletService(mockRepo, slog.Default(), WithDormancy(time.Hour, 1000, false))
+
+	// Large withdrawals need reactivation.
+	mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(dormant, nil)
+	_, err := s.ProcessOperation(context.Background(), models.WalletOperation{
+		WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000,
+	})
+	assert.ErrorIs(t, err, ErrReactivationRequired)
+
+	// Small withdrawals and deposits go through without a lookup.
+	uow.expectApply(walletID, int64(999), models.OperationTypeWithdraw, dormant, nil)
+	_, err = s.ProcessOperation(context.Background(), models.WalletOperation{
+		WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 999,
+	})
+	require.NoError(t, err)
+	uow.expectApply(walletID, int64(5000), models.OperationTypeDeposit, dormant, nil)
+	_, err = s.ProcessOperation(context.Background(), models.WalletOperation{
+		WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 5000,
+	})
+	require.NoError(t, err)
+
+	// Once reactivated, large withdrawals are allowed again.
+	active := &models.Wallet{ID: walletID, Balance: 5000, Status: models.WalletStatusActive}
+	mockRepo.EXPECT().ReactivateWallet(gomock.Any(), walletID).Return(active, nil)
+	_, err = s.ReactivateWallet(context.Background(), walletID)
+	require.NoError(t, err)
+	mockRepo.EXPECT().GetWallet(gomock.Any(), walletID).Return(active, nil)
+	uow.expectApply(walletID, int64(1000), models.OperationTypeWithdraw, active, nil)
+	_, err = s.ProcessOperation(context.Background(), models.WalletOperation{
+		WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000,
+	})
+	require.NoError(t, err)
+
+	mockRepo.EXPECT().ReactivateWallet(gomock.Any(), walletID).Return(nil, repository.ErrWalletNotDormant)
+	_, err = s.ReactivateWallet(context.Background(), walletID)
+	assert.ErrorIs(t, err, repository.ErrWalletNotDormant)
+}
+
+func TestWalletService_ForceCloseWallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	s := NewWalletService(mockRepo, slog.Default())
+	walletID := uuid.New()
+
+	// The balance is swept off before the wallet closes.
+	swept := &models.Wallet{ID: walletID, Status: models.WalletStatusActive}
+	uow.expectApply(walletID, int64(70), models.OperationTypeWithdraw, swept, nil).Times(2)
+	closed := &models.Wallet{ID: walletID, Status: models.WalletStatusClosed}
+	mockRepo.EXPECT().CloseWallet(gomock.Any(), walletID).Return(closed, nil)
+	mockRepo.EXPECT().AppendAuditEvent(gomock.Any(), gomock.Cond(func(e models.AuditEvent) bool {
+		return e.WalletID.UUID == walletID && e.Action == models.AuditWalletClosed
+	})).Return(nil)
+	wallet, err := s.ForceCloseWallet(context.Background(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, closed, wallet)
+
+	// Held funds can't be swept, so the wallet stays open.
+	held := &models.Wallet{ID: walletID, Status: models.WalletStatusActive, Balance: 70, HeldBalance: 20}
+	uow.wallets.EXPECT().LockWallet(gomock.Any(), walletID).Return(held, nil)
+	mockRepo.EXPECT().CloseWallet(gomock.Any(), walletID).Return(nil, repository.ErrWalletNotEmpty)
+	_, err = s.ForceCloseWallet(context.Background(), walletID)
+	assert.ErrorIs(t, err, repository.ErrWalletNotEmpty)
+}
+
+func TestWalletService_ForceCloseWallet_IgnoresWithdrawalLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	s := NewWalletService(mockRepo, slog.Default(), WithWithdrawalLimits(WithdrawalLimits{Daily: 100, Monthly: 100}))
+	walletID := uuid.New()
+
+	// The sweep of 500 is far above the limits, and what was withdrawn
+	// before isn't even looked up.
+	swept := &models.Wallet{ID: walletID, Status: models.WalletStatusActive}
+	uow.expectApply(walletID, int64(500), models.OperationTypeWithdraw, swept, nil).Times(2)
+	closed := &models.Wallet{ID: walletID, Status: models.WalletStatusClosed}
+	mockRepo.EXPECT().CloseWallet(gomock.Any(), walletID).Return(closed, nil)
+	mockRepo.EXPECT().AppendAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+
+	wallet, err := s.ForceCloseWallet(context.Background(), walletID)
+
+	require.NoError(t, err)
+	assert.Equal(t, closed, wallet)
+}
+
+// truncatingStore loses the last byte of every object it stores.
+type truncatingStore struct{ fakeStore }
+
+func (f *truncatingStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data := f.objects[key]
+	return io.NopCloser(bytes.NewReader(data[:len(data)-1])), nil
+}
+
+func TestWalletService_CheckCurrencyTotals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	s := NewWalletService(mockRepo, slog.Default())
+
+	balanced := models.CurrencyTotals{Currency: "EUR", Balance: 300, SystemBalance: 20, Credits: 500, Debits: 180}
+	mockRepo.EXPECT().CurrencyTotals(gomock.Any()).Return([]models.CurrencyTotals{balanced}, nil)
+	assert.NoError(t, s.CheckCurrencyTotals(context.Background()))
+
+	// Money a wallet holds without a ledger entry is reported.
+	mockRepo.EXPECT().CurrencyTotals(gomock.Any()).Return([]models.CurrencyTotals{
+		balanced, {Currency: "USD", Balance: 1250, Credits: 2000, Debits: 800},
+	}, nil)
+	err := s.CheckCurrencyTotals(context.Background())
+	assert.ErrorIs(t, err, ErrTotalsMismatch)
+	assert.ErrorContains(t, err, "USD")
+	assert.NotContains(t, err.Error(), "EUR")
+}
+
+func TestWalletService_PurgeClosedWallets(t *testing.T) {
+	walletID := uuid.New()
+	transactions := []models.ArchivedTransaction{
+		{Transaction: models.Transaction{ID: 7, WalletID: walletID, Version: 1, Amount: 100, Balance: 100}},
+		{Transaction: models.Transaction{ID: 9, WalletID: walletID, Version: 2, Amount: 100}, Notes: []models.TransactionNote{{Body: "refund"}}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		s := NewWalletService(nil, slog.Default())
+		assert.NoError(t, s.PurgeClosedWallets(context.Background()))
+	})
+
+	t.Run("archives before deleting", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		expectUnitOfWork(ctrl, mockRepo)
+		mockRepo.EXPECT().WalletsToPurge(gomock.Any(), gomock.Any(), PurgeBatchSize).Return([]uuid.UUID{walletID}, nil)
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), walletID, 0, PurgeBatchSize).Return(transactions, nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().DeleteTransactions(gomock.Any(), walletID, 2, PurgeBatchSize).Return(int64(2), nil),
+			mockRepo.EXPECT().DeleteTransactions(gomock.Any(), walletID, 2, PurgeBatchSize).Return(int64(0), nil),
+			mockRepo.EXPECT().MarkWalletPurged(gomock.Any(), walletID).Return(nil),
+			mockRepo.EXPECT().AppendAuditEvent(gomock.Any(), gomock.Cond(func(e models.AuditEvent) bool {
+				return e.WalletID.UUID == walletID && e.Action == models.AuditWalletPurged
+			})).Return(nil),
+		)
+
+		store := &fakeStore{objects: map[string][]byte{}}
+		s := NewWalletService(mockRepo, slog.Default(), WithObjectStore(store, time.Minute), WithPurge(time.Hour))
+		require.NoError(t, s.PurgeClosedWallets(context.Background()))
+
+		archive := store.objects[fmt.Sprintf("archives/wallets/%s/transactions-from-1.ndjson", walletID)]
+		require.Equal(t, 2, bytes.Count(archive, []byte("\n")))
+		var last models.ArchivedTransaction
+		require.NoError(t, json.Unmarshal(bytes.Split(archive, []byte("\n"))[1], &last))
+		assert.Equal(t, transactions[1], last)
+	})
+
+	t.Run("keeps transactions if the archive doesn't verify", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		mockRepo.EXPECT().WalletsToPurge(gomock.Any(), gomock.Any(), PurgeBatchSize).Return([]uuid.UUID{walletID}, nil)
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), walletID, 0, PurgeBatchSize).Return(transactions, nil)
+
+		store := &truncatingStore{fakeStore{objects: map[string][]byte{}}}
+		s := NewWalletService(mockRepo, slog.Default(), WithObjectStore(store, time.Minute), WithPurge(time.Hour))
+		assert.ErrorIs(t, s.PurgeClosedWallets(context.Background()), ErrArchiveMismatch)
+	})
+
+	t.Run("skips wallets put on legal hold", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		expectUnitOfWork(ctrl, mockRepo)
+		mockRepo.EXPECT().WalletsToPurge(gomock.Any(), gomock.Any(), PurgeBatchSize).Return([]uuid.UUID{walletID}, nil)
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), walletID, 0, PurgeBatchSize).Return(nil, nil)
+		mockRepo.EXPECT().MarkWalletPurged(gomock.Any(), walletID).Return(repository.ErrWalletOnLegalHold)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithObjectStore(&fakeStore{}, time.Minute), WithPurge(time.Hour))
+		assert.NoError(t, s.PurgeClosedWallets(context.Background()))
+	})
+
+	t.Run("no object store", func(t *testing.T) {
+		s := NewWalletService(nil, slog.Default(), WithPurge(time.Hour))
+		assert.ErrorIs(t, s.PurgeClosedWallets(context.Background()), storage.ErrNotConfigured)
+	})
+}
+
+func TestWalletService_PlaceLegalHold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	expectUnitOfWork(ctrl, mockRepo)
+	s := NewWalletService(mockRepo, slog.Default())
+	walletID := uuid.New()
+
+	_, err := s.PlaceLegalHold(context.Background(), walletID, " ")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	// The hold and its audit event commit together.
+	ctx := auth.WithSubject(context.Background(), auth.Subject{ID: "counsel", Role: auth.RoleAdmin})
+	mockRepo.EXPECT().PlaceLegalHold(gomock.Any(), gomock.Cond(func(h models.LegalHold) bool {
+		return h.WalletID == walletID && h.Reason == "case 42" && h.PlacedBy == "counsel"
+	})).DoAndReturn(func(_ context.Context, h models.LegalHold) (*models.LegalHold, error) {
+		return &h, nil
+	})
+	mockRepo.EXPECT().AppendAuditEvent(gomock.Any(), gomock.Cond(func(e models.AuditEvent) bool {
+		return e.WalletID.UUID == walletID && e.Action == models.AuditLegalHoldPlaced && e.Actor == "counsel"
+	})).Return(nil)
+	hold, err := s.PlaceLegalHold(ctx, walletID, "case 42")
+	require.NoError(t, err)
+	assert.Equal(t, "case 42", hold.Reason)
+
+	mockRepo.EXPECT().PlaceLegalHold(gomock.Any(), gomock.Any()).Return(nil, repository.ErrLegalHoldExists)
+	_, err = s.PlaceLegalHold(ctx, walletID, "case 43")
+	assert.ErrorIs(t, err, repository.ErrLegalHoldExists)
+}
+
+func TestWalletService_ImportWallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	s := NewWalletService(mockRepo, slog.Default())
+
+	future := time.Now().Add(time.Hour)
+	for name, req := range map[string]models.ImportWalletRequest{
+		"no external id":   {Balance: 100},
+		"negative balance": {ExternalID: "legacy-1", Balance: -1},
+		"bad currency":     {ExternalID: "legacy-1", Currency: "dollars"},
+		"opened later":     {ExternalID: "legacy-1", OpenedAt: &future},
+	} {
+		_, err := s.ImportWallet(context.Background(), req)
+		assert.ErrorIs(t, err, ErrInvalidInput, name)
+	}
+
+	// Wallet ids follow from external ids, so retries hit the same wallet.
+	var ids []uuid.UUID
+	mockRepo.EXPECT().ImportWallet(gomock.Any(), gomock.Any(), models.ImportWalletRequest{ExternalID: "legacy-1", Currency: "USD", Balance: 100}).
+		DoAndReturn(func(_ context.Context, id uuid.UUID, req models.ImportWalletRequest) (*models.Wallet, error) {
+			ids = append(ids, id)
+			if len(ids) > 1 {
+				return nil, repository.ErrWalletAlreadyImported
+			}
+			return &models.Wallet{ID: id, Balance: req.Balance}, nil
+		}).Times(2)
+
+	wallet, err := s.ImportWallet(context.Background(), models.ImportWalletRequest{ExternalID: "legacy-1", Balance: 100})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), wallet.Balance)
+	_, err = s.ImportWallet(context.Background(), models.ImportWalletRequest{ExternalID: "legacy-1", Balance: 100})
+	assert.ErrorIs(t, err, repository.ErrWalletAlreadyImported)
+	assert.Equal(t, ids[0], ids[1])
+}
+
+func TestWalletService_WithinTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID := uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	gomock.InOrder(
+		uow.expectApply(walletID, int64(10), models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 10, Version: 2}, nil),
+		uow.expectApply(walletID, int64(10), models.OperationTypeDeposit, nil, repository.ErrConcurrentModification),
+		uow.expectApply(walletID, int64(10), models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 10, Version: 2}, nil),
+	)
+
+	var observed int
+	s := NewWalletService(mockRepo, slog.Default(),
+		WithAfterOperation(AfterOperationFunc(func(context.Context, *models.Wallet, models.WalletOperation) {
+			observed++
+		})))
+	deposit := models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 10}
+
+	// Effects outside the database wait for the commit and are dropped
+	// with a rolled back transaction.
+	failed := errors.New("label taken")
+	err := s.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := s.ProcessOperation(ctx, deposit); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Zero(t, observed)
+
+	// A conflict isn't retried by the call that hit it but by WithinTx,
+	// running fn again in a new transaction.
+	attempts := 0
+	err = s.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		_, err := s.ProcessOperation(ctx, deposit)
+		assert.Zero(t, observed)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, observed)
+}
+
+// unitOfWork stands in for the stores of a mock repository, which the
+// service composes operations from.
+type unitOfWork struct {
+	wallets   *mockrepository.MockWalletStore
+	versions  *mockrepository.MockVersionStore
+	snapshots *mockrepository.MockSnapshotStore
+}
+
+func expectUnitOfWork(ctrl *gomock.Controller, repo *mockrepository.MockWalletRepository) *unitOfWork {
+	u := &unitOfWork{
+		wallets:   mockrepository.NewMockWalletStore(ctrl),
+		versions:  mockrepository.NewMockVersionStore(ctrl),
+		snapshots: mockrepository.NewMockSnapshotStore(ctrl),
+	}
+	repo.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	repo.EXPECT().WithinWalletTx(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ uuid.UUID, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	repo.EXPECT().Wallets().Return(u.wallets).AnyTimes()
+	repo.EXPECT().Versions().Return(u.versions).AnyTimes()
+	repo.EXPECT().Snapshots().Return(u.snapshots).AnyTimes()
+	return u
+}
+
+// expectApply expects an operation of amount to be applied to wallet id
+// and returns the expected lock of the wallet. With err set the lock fails
+// with it; otherwise the wallet is saved as result and its version
+// recorded.
+func (u *unitOfWork) expectApply(id uuid.UUID, amount int64, operation models.OperationType, result *models.Wallet, err error) *gomock.Call {
+	if err != nil {
+		return u.wallets.EXPECT().LockWallet(gomock.Any(), id).Return(nil, err)
+	}
+	locked := &models.Wallet{ID: id, Status: models.WalletStatusActive, Balance: amount}
+	u.wallets.EXPECT().SaveBalance(gomock.Any(), locked, gomock.Any()).Return(result, nil)
+	u.versions.EXPECT().AppendVersion(gomock.Any(), gomock.Cond(func(v models.WalletVersion) bool {
+		return v.WalletID == id && v.OperationType == operation && v.Amount == amount
+	})).Return(nil)
+	return u.wallets.EXPECT().LockWallet(gomock.Any(), id).Return(locked, nil)
+}
+
+type outboxPublisher struct {
+	batches [][]models.OutboxEvent
+	err     error
+}
+
+func (p *outboxPublisher) Publish(_ context.Context, events []models.OutboxEvent) error {
+	p.batches = append(p.batches, events)
+	return p.err
+}
+
+func TestWalletService_Outbox_WrittenWithTheChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walletID, otherID := uuid.New(), uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	uow := expectUnitOfWork(ctrl, mockRepo)
+	store := mockrepository.NewMockOutboxStore(ctrl)
+	mockRepo.EXPECT().Outbox().Return(store).AnyTimes()
+
+	var events []models.OutboxEvent
+	store.EXPECT().AppendOutboxEvent(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, e models.OutboxEvent) error {
+			events = append(events, e)
+			return nil
+		})
+	uow.expectApply(walletID, 10, models.OperationTypeDeposit, &models.Wallet{ID: walletID, Balance: 10, Version: 2}, nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), walletID, otherID, int64(4)).
+		Return(&models.Wallet{ID: walletID, Balance: 6, Version: 3}, &models.Wallet{ID: otherID, Balance: 4, Version: 2}, nil)
+
+	s := NewWalletService(mockRepo, slog.Default(), WithOutbox(OutboxPolicy{BatchSize: 10}, &outboxPublisher{}))
+	_, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 10})
+	require.NoError(t, err)
+	_, err = s.Transfer(context.Background(), models.TransferRequest{FromWalletID: walletID, ToWalletID: otherID, Amount: 4})
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, []uuid.UUID{walletID, walletID, otherID}, []uuid.UUID{events[0].WalletID, events[1].WalletID, events[2].WalletID})
+	for _, e := range events {
+		assert.Equal(t, EventBalanceUpdated, e.Type)
+		var body webhook.Event
+		require.NoError(t, json.Unmarshal(e.Payload, &body))
+		assert.Equal(t, e.ID, body.ID)
+	}
+}
+
+func TestWalletService_Outbox_FailureRollsBackTheChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	from, to := uuid.New(), uuid.New()
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	store := mockrepository.NewMockOutboxStore(ctrl)
+	mockRepo.EXPECT().Outbox().Return(store).AnyTimes()
+
+	// The transfer and its events share a transaction, which fails as a
+	// whole when an event can't be written.
+	failed := errors.New("outbox unavailable")
+	mockRepo.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	mockRepo.EXPECT().Transfer(gomock.Any(), from, to, int64(4)).
+		Return(&models.Wallet{ID: from, Balance: 6}, &models.Wallet{ID: to, Balance: 4}, nil)
+	store.EXPECT().AppendOutboxEvent(gomock.Any(), gomock.Any()).Return(failed)
+
+	var observed int
+	s := NewWalletService(mockRepo, slog.Default(), WithOutbox(OutboxPolicy{BatchSize: 10}, &outboxPublisher{}),
+		WithAfterOperation(AfterOperationFunc(func(context.Context, *models.Wallet, models.WalletOperation) {
+			observed++
+		})))
+	_, err := s.Transfer(context.Background(), models.TransferRequest{FromWalletID: from, ToWalletID: to, Amount: 4})
+
+	assert.ErrorIs(t, err, failed)
+	assert.Zero(t, observed)
+}
+
+func TestWalletService_Outbox_CoversEveryBalanceChange(t *testing.T) {
+	walletID, otherID, id := uuid.New(), uuid.New(), uuid.New()
+	reversal := 4
+	expires := time.Now().Add(time.Hour)
+	importID := uuid.NewSHA1(importNamespace, []byte("legacy-1"))
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		expect func(repo *mockrepository.MockWalletRepository)
+		run    func(s *WalletService) error
+		want   []models.WalletOperation
+	}{
+		{
+			name: "mandate debit",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().GetMandate(gomock.Any(), id).Return(&models.Mandate{ID: id, WalletID: walletID}, nil)
+				repo.EXPECT().DebitMandate(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&models.MandateDebit{ID: uuid.New(), WalletID: walletID, Amount: 40}, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.DebitMandate(context.Background(), id, models.MandateDebitRequest{Counterparty: "acme", Amount: 40})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 40}},
+		},
+		{
+			name: "reward",
+			opts: []Option{WithRewards(rewards.Rules{{Name: "base", BasisPoints: 100}})},
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().GetMandate(gomock.Any(), id).Return(&models.Mandate{ID: id, WalletID: walletID}, nil)
+				repo.EXPECT().DebitMandate(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&models.MandateDebit{ID: uuid.New(), WalletID: walletID, Amount: 1000, Version: 3}, nil)
+				repo.EXPECT().AccrueReward(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, a models.RewardAccrual) (*models.RewardAccrual, bool, error) {
+						a.RewardsWalletID = otherID
+						return &a, true, nil
+					})
+			},
+			run: func(s *WalletService) error {
+				_, err := s.DebitMandate(context.Background(), id, models.MandateDebitRequest{Counterparty: "acme", Amount: 1000})
+				return err
+			},
+			want: []models.WalletOperation{
+				{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000},
+				{WalletID: otherID, OperationType: models.OperationTypeReward, Amount: 10},
+			},
+		},
+		{
+			name: "hold capture",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().CaptureHold(gomock.Any(), walletID, id, int64(30), gomock.Any()).
+					Return(&models.FundsHold{ID: id, WalletID: walletID, CapturedAmount: 30}, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.CaptureHold(context.Background(), walletID, id, models.CaptureHoldRequest{Amount: 30})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 30}},
+		},
+		{
+			name: "dispute reversal",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				disputed := models.Dispute{ID: id, WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 25, Status: models.DisputeStatusOpen}
+				repo.EXPECT().GetDispute(gomock.Any(), id).Return(&disputed, nil)
+				reversed := disputed
+				reversed.Status, reversed.ReversalVersion = models.DisputeStatusReversed, &reversal
+				repo.EXPECT().CloseDispute(gomock.Any(), id, models.DisputeStatusReversed, "", gomock.Any()).Return(&reversed, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.ResolveDispute(context.Background(), id, models.ResolveDisputeRequest{Outcome: models.DisputeOutcomeReverse})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypeReversalDebit, Amount: 25}},
+		},
+		{
+			name: "promo grant",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().GrantPromo(gomock.Any(), gomock.Any()).Return(&models.Wallet{ID: walletID, Balance: 50}, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.GrantPromo(context.Background(), walletID, models.GrantPromoRequest{Amount: 50, ExpiresAt: expires})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypePromoCredit, Amount: 50}},
+		},
+		{
+			name: "promo expiry",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().DuePromoCredits(gomock.Any(), gomock.Any(), PromoExpiryBatchSize).
+					Return([]models.PromoCredit{{ID: id, WalletID: walletID}}, nil)
+				repo.EXPECT().ExpirePromoCredit(gomock.Any(), id, gomock.Any()).Return(int64(15), nil)
+			},
+			run: func(s *WalletService) error {
+				return s.ExpirePromoCredits(context.Background())
+			},
+			want: []models.WalletOperation{{WalletID: walletID, OperationType: models.OperationTypePromoExpiry, Amount: 15}},
+		},
+		{
+			name: "inbound credit to suspense",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().ReceiveToSuspense(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, c models.SuspenseCase) (*models.SuspenseCase, error) {
+						c.SuspenseWalletID = otherID
+						return &c, nil
+					})
+			},
+			run: func(s *WalletService) error {
+				_, err := s.ReceiveInbound(context.Background(), models.InboundCredit{Reference: "REF1", Amount: 300})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: otherID, OperationType: models.OperationTypeDeposit, Amount: 300}},
+		},
+		{
+			name: "suspense resolution",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().ResolveSuspenseCase(gomock.Any(), id, walletID, "", gomock.Any()).
+					Return(&models.SuspenseCase{ID: id, SuspenseWalletID: otherID, Amount: 300}, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.ResolveSuspenseCase(context.Background(), id, models.ResolveSuspenseRequest{WalletID: walletID})
+				return err
+			},
+			want: []models.WalletOperation{
+				{WalletID: otherID, OperationType: models.OperationTypeWithdraw, Amount: 300},
+				{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 300},
+			},
+		},
+		{
+			name: "import",
+			expect: func(repo *mockrepository.MockWalletRepository) {
+				repo.EXPECT().ImportWallet(gomock.Any(), importID, gomock.Any()).Return(&models.Wallet{ID: importID, Balance: 700}, nil)
+			},
+			run: func(s *WalletService) error {
+				_, err := s.ImportWallet(context.Background(), models.ImportWalletRequest{ExternalID: "legacy-1", Balance: 700})
+				return err
+			},
+			want: []models.WalletOperation{{WalletID: importID, OperationType: models.OperationTypeOpeningBalance, Amount: 700}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+			expectUnitOfWork(ctrl, mockRepo)
+			store := mockrepository.NewMockOutboxStore(ctrl)
+			mockRepo.EXPECT().Outbox().Return(store).AnyTimes()
+			mockRepo.EXPECT().GetWallet(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+					return &models.Wallet{ID: id, Status: models.WalletStatusActive}, nil
+				}).AnyTimes()
+			tt.expect(mockRepo)
+
+			var got []models.WalletOperation
+			store.EXPECT().AppendOutboxEvent(gomock.Any(), gomock.Any()).Times(len(tt.want)).
+				DoAndReturn(func(_ context.Context, e models.OutboxEvent) error {
+					var body struct {
+						Data models.BalanceUpdate `json:"data"`
+					}
+					require.NoError(t, json.Unmarshal(e.Payload, &body))
+					assert.Equal(t, EventBalanceUpdated, e.Type)
+					assert.Equal(t, e.WalletID, body.Data.Operation.WalletID)
+					got = append(got, body.Data.Operation)
+					return nil
+				})
+
+			opts := append([]Option{WithOutbox(OutboxPolicy{BatchSize: 10}, &outboxPublisher{})}, tt.opts...)
+			s := NewWalletService(mockRepo, slog.Default(), opts...)
+			require.NoError(t, tt.run(s))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWalletService_RelayOutbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	batch := func(n int) []models.OutboxEvent {
+		events := make([]models.OutboxEvent, n)
+		for i := range events {
+			events[i] = models.OutboxEvent{ID: uuid.New(), WalletID: uuid.New(), Type: EventBalanceUpdated}
+		}
+		return events
+	}
+	relay := func(events []models.OutboxEvent) func(context.Context, int, func([]models.OutboxEvent) error) (int, error) {
+		return func(_ context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+			assert.Equal(t, 2, limit)
+			if err := publish(events); err != nil {
+				return 0, err
+			}
+			return len(events), nil
+		}
+	}
+
+	mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+	full, rest := batch(2), batch(1)
+	gomock.InOrder(
+		mockRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(full)),
+		mockRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(rest)),
+	)
+
+	publisher := &outboxPublisher{}
+	s := NewWalletService(mockRepo, slog.Default(), WithOutbox(OutboxPolicy{BatchSize: 2}, publisher))
+	require.NoError(t, s.RelayOutbox(context.Background()))
+	assert.Equal(t, [][]models.OutboxEvent{full, rest}, publisher.batches)
+
+	// A batch the broker rejects is left for the next run.
+	publisher.err = errors.New("broker down")
+	mockRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(rest))
+	assert.ErrorIs(t, s.RelayOutbox(context.Background()), publisher.err)
+}
+
+func TestWalletService_EventSourcing(t *testing.T) {
+	id := uuid.New()
+	now := time.Now().UTC()
+	history := []models.WalletVersion{
+		{WalletID: id, Version: 6, Balance: 150, OperationType: models.OperationTypeDeposit, Amount: 50},
+		{WalletID: id, Version: 7, Balance: 120, OperationType: models.OperationTypeWithdraw, Amount: 30},
+	}
+	snapshot := &models.WalletSnapshot{WalletID: id, Version: 5, Balance: 100}
+
+	tests := []struct {
+		name          string
+		stored        int64
+		snapshotEvery int
+		snapshot      bool
+	}{
+		{"below snapshot interval", 120, 10, false},
+		{"snapshot due", 120, 3, true},
+		{"drifted balance is replayed", 999, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+			uow := expectUnitOfWork(ctrl, mockRepo)
+
+			locked := &models.Wallet{ID: id, Status: models.WalletStatusActive, Balance: tt.stored, Version: 7}
+			uow.wallets.EXPECT().LockWallet(gomock.Any(), id).Return(locked, nil)
+			uow.snapshots.EXPECT().LatestSnapshot(gomock.Any(), id).Return(snapshot, nil)
+			uow.snapshots.EXPECT().VersionsAfter(gomock.Any(), id, 5).Return(history, nil)
+			uow.wallets.EXPECT().SaveBalance(gomock.Any(), gomock.Any(), ledger.Change{Balance: 100, Amount: 20}).
+				Return(&models.Wallet{ID: id, Balance: 100, Version: 8, UpdatedAt: now}, nil)
+			uow.versions.EXPECT().AppendVersion(gomock.Any(), gomock.Any()).Return(nil)
+			if tt.snapshot {
+				uow.snapshots.EXPECT().SaveSnapshot(gomock.Any(), models.WalletSnapshot{WalletID: id, Version: 8, Balance: 100, CreatedAt: now}).Return(nil)
+			}
+
+			s := NewWalletService(mockRepo, slog.Default(), WithEventSourcing(EventSourcingPolicy{SnapshotEvery: tt.snapshotEvery}))
+			w, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: id, OperationType: models.OperationTypeWithdraw, Amount: 20})
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(100), w.Balance)
+		})
+	}
+
+	t.Run("history behind the wallet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+		uow := expectUnitOfWork(ctrl, mockRepo)
+
+		uow.wallets.EXPECT().LockWallet(gomock.Any(), id).Return(&models.Wallet{ID: id, Status: models.WalletStatusActive, Balance: 120, Version: 9}, nil)
+		uow.snapshots.EXPECT().LatestSnapshot(gomock.Any(), id).Return(snapshot, nil)
+		uow.snapshots.EXPECT().VersionsAfter(gomock.Any(), id, 5).Return(history, nil)
+
+		s := NewWalletService(mockRepo, slog.Default(), WithEventSourcing(EventSourcingPolicy{SnapshotEvery: 10}))
+		_, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: id, OperationType: models.OperationTypeWithdraw, Amount: 20})
+
+		assert.ErrorIs(t, err, ledger.ErrHistoryGap)
+	})
+}
+
+func TestWalletService_WithdrawalLimits(t *testing.T) {
+	id := uuid.New()
+	limits := WithdrawalLimits{Daily: 100, Monthly: 300}
+
+	tests := []struct {
+		name      string
+		operation models.OperationType
+		daily     int64
+		monthly   int64
+		wantErr   bool
+	}{
+		{"within limits", models.OperationTypeWithdraw, 50, 200, false},
+		{"daily limit reached", models.OperationTypeWithdraw, 80, -1, true},
+		{"monthly limit reached", models.OperationTypeWithdraw, 0, 280, true},
+		{"deposits are not limited", models.OperationTypeDeposit, -1, -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mockrepository.NewMockWalletRepository(ctrl)
+			uow := expectUnitOfWork(ctrl, mockRepo)
+
+			locked := &models.Wallet{ID: id, Status: models.WalletStatusActive, Balance: 500, Version: 3}
+			uow.wallets.EXPECT().LockWallet(gomock.Any(), id).Return(locked, nil)
+			if tt.daily >= 0 {
+				uow.versions.EXPECT().WithdrawnSince(gomock.Any(), id, gomock.Any()).Return(tt.daily, nil)
+			}
+			if tt.monthly >= 0 {
+				uow.versions.EXPECT().WithdrawnSince(gomock.Any(), id, gomock.Any()).Return(tt.monthly, nil)
+			}
+			if !tt.wantErr {
+				uow.wallets.EXPECT().SaveBalance(gomock.Any(), locked, gomock.Any()).
+					Return(&models.Wallet{ID: id, Balance: 460, Version: 4}, nil)
+				uow.versions.EXPECT().AppendVersion(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			s := NewWalletService(mockRepo, slog.Default(), WithWithdrawalLimits(limits))
+			_, err := s.ProcessOperation(context.Background(), models.WalletOperation{WalletID: id, OperationType: tt.operation, Amount: 40})
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrLimitExceeded)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+var errLockTimeout = errors.New("lock wait timed out, likely a deadlock")
+
+// rowLockRepository mimics Postgres row locks over the in-memory
+// repository: a transaction holds the wallets it locks until it ends, and
+// Transfer locks both of its wallets in id order. Transactions run
+// concurrently, so lock orders that could deadlock on Postgres time out.
+type rowLockRepository struct {
+	*memory.Repository
+	locks map[uuid.UUID]chan struct{}
+	// transfers waits for the other transfer to lock its wallets too, so
+	// that conflicting lock orders meet.
+	transfers sync.WaitGroup
+}
+
+type rowLockTxKey struct{}
+
+func (r *rowLockRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(rowLockTxKey{}) != nil {
+		return fn(ctx)
+	}
+	held := map[uuid.UUID]bool{}
+	defer func() {
+		for id := range held {
+			<-r.locks[id]
+		}
+	}()
+	return fn(context.WithValue(ctx, rowLockTxKey{}, held))
+}
+
+func (r *rowLockRepository) WithinWalletTx(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return r.WithinTx(ctx, fn)
+}
+
+func (r *rowLockRepository) lock(ctx context.Context, id uuid.UUID) error {
+	held := ctx.Value(rowLockTxKey{}).(map[uuid.UUID]bool)
+	if held[id] {
+		return nil
+	}
+	select {
+	case r.locks[id] <- struct{}{}:
+		held[id] = true
+		return nil
+	case <-time.After(time.Second):
+		return errLockTimeout
+	}
+}
+
+func (r *rowLockRepository) Wallets() repository.WalletStore {
+	return rowLockWallets{r.Repository.Wallets(), r}
+}
+
+func (r *rowLockRepository) Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64) (*models.Wallet, *models.Wallet, error) {
+	r.transfers.Done()
+	done := make(chan struct{})
+	go func() { r.transfers.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ids := []uuid.UUID{fromID, toID}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	for _, id := range ids {
+		if err := r.lock(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+	return r.Repository.Transfer(ctx, fromID, toID, amount)
+}
+
+type rowLockWallets struct {
+	repository.WalletStore
+	r *rowLockRepository
+}
+
+func (w rowLockWallets) LockWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	if err := w.r.lock(ctx, id); err != nil {
+		return nil, err
+	}
+	return w.WalletStore.LockWallet(ctx, id)
+}
+
+func TestWalletService_Transfer_OppositeDirectionsUnderWithdrawalLimits(t *testing.T) {
+	repo := &rowLockRepository{Repository: memory.New(), locks: map[uuid.UUID]chan struct{}{}}
+	s := NewWalletService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithWithdrawalLimits(WithdrawalLimits{Daily: 1000}))
+	ctx := context.Background()
+	var ids [2]uuid.UUID
+	for i := range ids {
+		w, err := s.CreateWallet(ctx, models.CreateWalletRequest{Currency: "USD"})
+		require.NoError(t, err)
+		repo.locks[w.ID] = make(chan struct{}, 1)
+		_, err = s.ProcessOperation(ctx, models.WalletOperation{WalletID: w.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+		require.NoError(t, err)
+		ids[i] = w.ID
+	}
+
+	repo.transfers.Add(2)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.Transfer(ctx, models.TransferRequest{FromWalletID: ids[i], ToWalletID: ids[1-i], Amount: 10})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryPolicy configures the delivery of events to webhook
+// subscriptions. A failed delivery is retried after BackoffBase, doubling
+// up to BackoffMax, until MaxAttempts attempts have failed. Each run of
+// DeliverWebhooks attempts up to BatchSize deliveries, Workers at a time.
+// Finished deliveries and their attempts are kept for Retention, forever
+// if zero.
+type WebhookDeliveryPolicy struct {
+	Timeout     time.Duration
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	BatchSize   int
+	Workers     int
+	Retention   time.Duration
+}
+
+type webhookDelivery struct {
+	policy WebhookDeliveryPolicy
+	client *http.Client
+}
+
+// WithWebhookDelivery queues the events of every subscribed type for the
+// subscriptions registered with CreateWebhookSubscription, to be delivered
+// by DeliverWebhooks. Without it subscriptions receive nothing.
+func WithWebhookDelivery(p WebhookDeliveryPolicy) Option {
+	return func(s *WalletService) {
+		s.webhooks = &webhookDelivery{policy: p, client: &http.Client{Timeout: p.Timeout}}
+	}
+}
+
+// publish queues an event for the subscriptions of its type. Inside a
+// WithinTx transaction the event is queued by the transaction, so it is
+// delivered only if the transaction commits. A failure to queue it is
+// logged; the change it reports has been made regardless.
+func (s *WalletService) publish(ctx context.Context, typ string, data any) {
+	if s.webhooks == nil {
+		return
+	}
+	e := webhook.NewEvent(typ, data)
+	payload, err := json.Marshal(e)
+	if err == nil {
+		event := models.WebhookEvent{
+			ID:        e.ID,
+			Type:      e.Type,
+			Payload:   payload,
+			CreatedAt: e.CreatedAt,
+		}
+		if u, ok := data.(models.BalanceUpdate); ok {
+			event.OperationType, event.Amount = u.Operation.OperationType, u.Operation.Amount
+		}
+		_, err = s.repo.EnqueueWebhookEvent(context.WithoutCancel(ctx), event)
+	}
+	if err != nil {
+		s.logger(ctx).Error("failed to queue webhook event", slog.String("event_id", e.ID.String()), slog.String("type", typ),
+			slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+}
+
+// publishBalance queues a balance.updated event for an operation applied
+// to wallet.
+func (s *WalletService) publishBalance(ctx context.Context, wallet *models.Wallet, operation models.WalletOperation) {
+	s.publish(ctx, EventBalanceUpdated, models.BalanceUpdate{Wallet: wallet, Operation: operation})
+}
+
+// DeliverWebhooks attempts the deliveries that are due. Each is POSTed with
+// the subscription's secret in webhook.SignatureHeader; a 2xx response
+// completes it, anything else schedules a retry with exponential backoff
+// or, after the last attempt, fails it for good. Every attempt is logged
+// and can be listed with ListWebhookDeliveryAttempts. It is meant to run
+// periodically and does nothing without WithWebhookDelivery.
+func (s *WalletService) DeliverWebhooks(ctx context.Context) error {
+	if s.webhooks == nil {
+		return nil
+	}
+	op := "service.DeliverWebhooks"
+	log := s.logger(ctx).With(slog.String("op", op))
+	p := s.webhooks.policy
+
+	// The lease outlasts an attempt, so a delivery is never attempted by
+	// two runs at once.
+	lease := 2*p.Timeout + time.Minute
+	deliveries, err := s.repo.ClaimWebhookDeliveries(ctx, time.Now(), lease, p.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+		errs      []error
+	)
+	sem := make(chan struct{}, max(p.Workers, 1))
+	for _, d := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			ok, err := s.deliverWebhook(ctx, d)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				delivered++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	log.Info("webhook deliveries attempted", slog.Int("attempted", len(deliveries)), slog.Int("delivered", delivered))
+	return errors.Join(errs...)
+}
+
+// deliverWebhook makes one attempt of d and records it, reporting whether
+// d was delivered.
+func (s *WalletService) deliverWebhook(ctx context.Context, d models.WebhookDelivery) (bool, error) {
+	p := s.webhooks.policy
+	log := s.logger(ctx).With(slog.String("event_id", d.EventID.String()), slog.String("subscription_id", d.SubscriptionID.String()))
+
+	start := time.Now()
+	code, err := webhook.Post(ctx, s.webhooks.client, d.URL, d.Secret, d.Payload)
+	attempt := models.WebhookDeliveryAttempt{
+		EventID:        d.EventID,
+		SubscriptionID: d.SubscriptionID,
+		EventType:      d.EventType,
+		Attempt:        d.Attempts + 1,
+		StatusCode:     code,
+		DurationMs:     float64(time.Since(start).Microseconds()) / 1000,
+		AttemptedAt:    start.UTC(),
+	}
+
+	status, next := models.WebhookDeliveryDelivered, start
+	if err != nil {
+		attempt.Error = err.Error()
+		status, next = models.WebhookDeliveryPending, start.Add(webhookBackoff(p, attempt.Attempt))
+		if attempt.Attempt >= p.MaxAttempts {
+			status = models.WebhookDeliveryFailed
+			log.Warn("webhook delivery failed for good", slog.Int("attempts", attempt.Attempt),
+				slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+	}
+	if err := s.repo.RecordWebhookDeliveryAttempt(context.WithoutCancel(ctx), attempt, status, next); err != nil {
+		return false, fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return status == models.WebhookDeliveryDelivered, nil
+}
+
+// webhookBackoff is the delay after the attempt-th failed attempt.
+func webhookBackoff(p WebhookDeliveryPolicy, attempt int) time.Duration {
+	d := p.BackoffBase
+	for i := 1; i < attempt && d < p.BackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, p.BackoffMax)
+}
+
+// MaxWebhookDeliveryAttempts bounds the attempts ListWebhookDeliveryAttempts
+// returns.
+const MaxWebhookDeliveryAttempts = 1000
+
+// ListWebhookDeliveryAttempts returns the latest limit delivery attempts of
+// subscription id, newest first. limit is clamped to
+// (0, MaxWebhookDeliveryAttempts].
+func (s *WalletService) ListWebhookDeliveryAttempts(ctx context.Context, id uuid.UUID, limit int) ([]models.WebhookDeliveryAttempt, error) {
+	if limit <= 0 || limit > MaxWebhookDeliveryAttempts {
+		limit = MaxWebhookDeliveryAttempts
+	}
+	if _, err := s.GetWebhookSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListWebhookDeliveryAttempts(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// WebhookSubscriptionStats sums up the deliveries to subscription id that
+// are still retained.
+func (s *WalletService) WebhookSubscriptionStats(ctx context.Context, id uuid.UUID) (*models.WebhookSubscriptionStats, error) {
+	if _, err := s.GetWebhookSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+	stats, err := s.repo.WebhookSubscriptionStats(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription stats: %w", err)
+	}
+	return stats, nil
+}
+
+// DefaultWebhookTestTimeout bounds a test delivery without
+// WithWebhookDelivery.
+const DefaultWebhookTestTimeout = 10 * time.Second
+
+// TestWebhookSubscription POSTs a webhook.test event to subscription id
+// right away, paused or not, signed like any delivery, and reports how the
+// receiver answered. The test isn't retried or recorded as a delivery.
+func (s *WalletService) TestWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookTestResult, error) {
+	op := "service.TestWebhookSubscription"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("subscription_id", id.String())))
+
+	sub, err := s.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	e := webhook.NewEvent(EventWebhookTest, map[string]uuid.UUID{"subscriptionId": id})
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: DefaultWebhookTestTimeout}
+	if s.webhooks != nil {
+		client = s.webhooks.client
+	}
+	start := time.Now()
+	code, err := webhook.Post(ctx, client, sub.URL, sub.Secret, payload)
+	result := &models.WebhookTestResult{
+		EventID:    e.ID,
+		Delivered:  err == nil,
+		StatusCode: code,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	log.Info("webhook test delivery sent", slog.Bool("delivered", result.Delivered), slog.Int("status_code", code))
+	return result, nil
+}
+
+// PurgeWebhookDeliveries deletes finished deliveries older than the
+// retention of WithWebhookDelivery. It is meant to run periodically.
+func (s *WalletService) PurgeWebhookDeliveries(ctx context.Context) error {
+	if s.webhooks == nil || s.webhooks.policy.Retention <= 0 {
+		return nil
+	}
+	op := "service.PurgeWebhookDeliveries"
+
+	n, err := s.repo.PurgeWebhookDeliveries(ctx, time.Now().Add(-s.webhooks.policy.Retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	if n > 0 {
+		s.logger(ctx).Info("webhook deliveries purged", slog.String("op", op), slog.Int64("deleted", n))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types of the wallet lifecycle.
+const (
+	EventWalletCreated  = "wallet.created"
+	EventBalanceUpdated = "balance.updated"
+)
+
+// EventWebhookTest is the type of the events sent by TestWebhookSubscription.
+// Subscriptions can't register for it.
+const EventWebhookTest = "webhook.test"
+
+// WebhookEventTypes are the event types webhook subscriptions may register
+// for.
+var WebhookEventTypes = []string{
+	EventWalletCreated, EventBalanceUpdated,
+	EventWalletDormant, EventWalletReactivated,
+	EventDisputeOpened, EventDisputeReversed, EventDisputeReleased, EventDisputeExpired,
+}
+
+// MaxWebhookSecretLength bounds the secret of a webhook subscription, in
+// bytes.
+const MaxWebhookSecretLength = 256
+
+// CreateWebhookSubscription registers req.URL for the events in
+// req.EventTypes that pass req.Filter. A secret is required, so that
+// receivers can verify deliveries.
+func (s *WalletService) CreateWebhookSubscription(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	op := "service.CreateWebhookSubscription"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op)))
+
+	if req.Secret == "" {
+		return nil, fmt.Errorf("%w: secret is required", ErrInvalidInput)
+	}
+	eventTypes, err := checkWebhookSubscription(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sub, err := s.repo.CreateWebhookSubscription(ctx, models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: eventTypes,
+		Filter:     req.Filter,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		log.Error("failed to create webhook subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	log.Info("webhook subscription created", slog.String("subscription_id", sub.ID.String()),
+		slog.String("url", sub.URL), slog.Any("event_types", sub.EventTypes))
+	return sub, nil
+}
+
+func (s *WalletService) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// ListWebhookSubscriptions returns all subscriptions, oldest first.
+func (s *WalletService) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs, err := s.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// UpdateWebhookSubscription replaces the URL, event types and filter of
+// subscription id. Its secret is replaced too, unless req.Secret is empty.
+func (s *WalletService) UpdateWebhookSubscription(ctx context.Context, id uuid.UUID, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	op := "service.UpdateWebhookSubscription"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("subscription_id", id.String())))
+
+	eventTypes, err := checkWebhookSubscription(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.UpdateWebhookSubscription(ctx, models.WebhookSubscription{
+		ID:         id,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: eventTypes,
+		Filter:     req.Filter,
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			log.Warn("webhook subscription not found")
+			return nil, err
+		}
+		log.Error("failed to update webhook subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	log.Info("webhook subscription updated", slog.String("url", sub.URL), slog.Any("event_types", sub.EventTypes),
+		slog.Bool("secret_rotated", req.Secret != ""))
+	return sub, nil
+}
+
+// PauseWebhookSubscription pauses subscription id, or resumes it unless
+// paused. Events keep being queued for a paused subscription; they are
+// delivered once it is resumed.
+func (s *WalletService) PauseWebhookSubscription(ctx context.Context, id uuid.UUID, paused bool) (*models.WebhookSubscription, error) {
+	op := "service.PauseWebhookSubscription"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("subscription_id", id.String())))
+
+	sub, err := s.repo.PauseWebhookSubscription(ctx, id, paused, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			log.Warn("webhook subscription not found")
+			return nil, err
+		}
+		log.Error("failed to pause webhook subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, fmt.Errorf("failed to pause webhook subscription: %w", err)
+	}
+	if paused {
+		log.Info("webhook subscription paused")
+	} else {
+		log.Info("webhook subscription resumed")
+	}
+	return sub, nil
+}
+
+func (s *WalletService) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	op := "service.DeleteWebhookSubscription"
+	log := withSubject(ctx, s.logger(ctx).With(slog.String("op", op), slog.String("subscription_id", id.String())))
+
+	if err := s.repo.DeleteWebhookSubscription(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			log.Warn("webhook subscription not found")
+			return err
+		}
+		log.Error("failed to delete webhook subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	log.Info("webhook subscription deleted")
+	return nil
+}
+
+// checkWebhookSubscription validates req and returns its event types
+// sorted and without duplicates.
+func checkWebhookSubscription(req *models.WebhookSubscriptionRequest) ([]string, error) {
+	req.URL = strings.TrimSpace(req.URL)
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidInput)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: url must not contain credentials", ErrInvalidInput)
+	}
+	if len(req.Secret) > MaxWebhookSecretLength {
+		return nil, fmt.Errorf("%w: secret exceeds %d bytes", ErrInvalidInput, MaxWebhookSecretLength)
+	}
+	if len(req.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidInput)
+	}
+	for _, t := range req.EventTypes {
+		if !slices.Contains(WebhookEventTypes, t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, t)
+		}
+	}
+	if req.Filter.MinAmount < 0 {
+		return nil, fmt.Errorf("%w: filter minAmount must not be negative", ErrInvalidInput)
+	}
+	for _, t := range req.Filter.OperationTypes {
+		if t != models.OperationTypeDeposit && t != models.OperationTypeWithdraw {
+			return nil, fmt.Errorf("%w: unknown operation type %q in filter", ErrInvalidInput, t)
+		}
+	}
+	if len(req.Filter.OperationTypes) > 0 {
+		req.Filter.OperationTypes = slices.Compact(slices.Sorted(slices.Values(req.Filter.OperationTypes)))
+	}
+	eventTypes := slices.Clone(req.EventTypes)
+	slices.Sort(eventTypes)
+	return slices.Compact(eventTypes), nil
+}
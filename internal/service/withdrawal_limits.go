@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrLimitExceeded means a withdrawal would take its wallet past a
+// withdrawal limit.
+var ErrLimitExceeded = errors.New("withdrawal limit exceeded")
+
+// WithdrawalLimits caps what each wallet may withdraw per UTC day and per
+// UTC calendar month. Zero means unlimited.
+type WithdrawalLimits struct {
+	Daily   int64
+	Monthly int64
+}
+
+func (l WithdrawalLimits) enabled() bool {
+	return l.Daily > 0 || l.Monthly > 0
+}
+
+// WithWithdrawalLimits rejects withdrawals that would take a wallet past
+// limits with ErrLimitExceeded: single operations, mandate debits, hold
+// captures, the withdrawing side of transfers, and the withdrawals of
+// atomic requests and batches, which count together per wallet. What a wallet has withdrawn is summed from
+// its transactions while it is locked, so concurrent withdrawals, on any
+// instance, can't overshoot a limit. Sweeps of force-closed wallets are
+// exempt: closing a wallet must not depend on how much it holds.
+func WithWithdrawalLimits(limits WithdrawalLimits) Option {
+	return func(s *WalletService) {
+		s.withdrawalLimits = limits
+	}
+}
+
+// withdrawalPeriod is a span a withdrawal limit applies to, up to now.
+type withdrawalPeriod struct {
+	name  string
+	limit int64
+	since time.Time
+}
+
+func (l WithdrawalLimits) periods() []withdrawalPeriod {
+	now := time.Now().UTC()
+	return []withdrawalPeriod{
+		{"daily", l.Daily, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)},
+		{"monthly", l.Monthly, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+// checkWithdrawalLimits fails with ErrLimitExceeded if operation, a
+// withdrawal from a wallet locked in the transaction of ctx, would take
+// the wallet past its daily or monthly limit.
+func (s *WalletService) checkWithdrawalLimits(ctx context.Context, operation models.WalletOperation) error {
+	if operation.OperationType != models.OperationTypeWithdraw || !s.withdrawalLimits.enabled() {
+		return nil
+	}
+	for _, p := range s.withdrawalLimits.periods() {
+		if p.limit <= 0 {
+			continue
+		}
+		withdrawn, err := s.repo.Versions().WithdrawnSince(ctx, operation.WalletID, p.since)
+		if err != nil {
+			return err
+		}
+		if withdrawn+operation.Amount > p.limit {
+			return fmt.Errorf("%w: %s limit %d, already withdrawn %d", ErrLimitExceeded, p.name, p.limit, withdrawn)
+		}
+	}
+	return nil
+}
+
+// checkStepWithdrawalLimits checks the withdrawals among steps, those of a
+// transfer or an atomic request about to be applied in the transaction of
+// ctx, against the limits. Every wallet the steps touch is locked first, in
+// id order like the repository locks them: locking only the withdrawing
+// ones would let two transfers going opposite ways between the same
+// wallets each hold one and wait for the other. The first step that would
+// take its wallet past a limit fails with a *repository.StepError; wallets
+// that don't exist are left for the repository to report.
+func (s *WalletService) checkStepWithdrawalLimits(ctx context.Context, steps []models.WalletOperation) error {
+	if !s.withdrawalLimits.enabled() || !slices.ContainsFunc(steps, func(step models.WalletOperation) bool {
+		return step.OperationType == models.OperationTypeWithdraw
+	}) {
+		return nil
+	}
+	var ids []uuid.UUID
+	for _, step := range steps {
+		if !slices.Contains(ids, step.WalletID) {
+			ids = append(ids, step.WalletID)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	locked := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		_, err := s.repo.Wallets().LockWallet(ctx, id)
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			continue
+		case err != nil:
+			return err
+		}
+		locked[id] = true
+	}
+
+	for _, p := range s.withdrawalLimits.periods() {
+		if p.limit <= 0 {
+			continue
+		}
+		withdrawn := make(map[uuid.UUID]int64, len(locked))
+		for i, step := range steps {
+			if step.OperationType != models.OperationTypeWithdraw || !locked[step.WalletID] {
+				continue
+			}
+			total, ok := withdrawn[step.WalletID]
+			if !ok {
+				var err error
+				if total, err = s.repo.Versions().WithdrawnSince(ctx, step.WalletID, p.since); err != nil {
+					return err
+				}
+			}
+			if total+step.Amount > p.limit {
+				return &repository.StepError{Index: i, Err: fmt.Errorf("%w: %s limit %d, already withdrawn %d",
+					ErrLimitExceeded, p.name, p.limit, total)}
+			}
+			withdrawn[step.WalletID] = total + step.Amount
+		}
+	}
+	return nil
+}
+
+// withLimitedWithdrawals runs fn, which applies steps, like withOutbox,
+// but in a transaction whenever withdrawals are limited, with the
+// withdrawals among steps checked against the limits first.
+func (s *WalletService) withLimitedWithdrawals(ctx context.Context, steps []models.WalletOperation, fn func(ctx context.Context) error) error {
+	if !s.withdrawalLimits.enabled() {
+		return s.withOutbox(ctx, fn)
+	}
+	return s.repo.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkStepWithdrawalLimits(ctx, steps); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
+
+// withWithdrawalLimit runs fn, which withdraws from walletID outside of
+// applyOperation, such as a mandate debit or a hold capture, in a
+// transaction whenever withdrawals are limited, with the wallet locked and
+// the withdrawal of amount checked against the limits first. amount is
+// resolved once the wallet is locked; a wallet that doesn't exist is left
+// for fn to report.
+func (s *WalletService) withWithdrawalLimit(ctx context.Context, walletID uuid.UUID,
+	amount func(ctx context.Context) (int64, error), fn func(ctx context.Context) error) error {
+	if !s.withdrawalLimits.enabled() {
+		return fn(ctx)
+	}
+	return s.repo.WithinWalletTx(ctx, walletID, func(ctx context.Context) error {
+		_, err := s.repo.Wallets().LockWallet(ctx, walletID)
+		switch {
+		case errors.Is(err, repository.ErrWalletNotFound):
+			return fn(ctx)
+		case err != nil:
+			return err
+		}
+		n, err := amount(ctx)
+		if err != nil {
+			return err
+		}
+		withdrawal := models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: n}
+		if err := s.checkWithdrawalLimits(ctx, withdrawal); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
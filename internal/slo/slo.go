@@ -0,0 +1,229 @@
+// Package slo tracks a service level objective in process: the share of
+// events that must succeed within a latency target, over a rolling window,
+// and how fast failures are eating into the error budget that leaves.
+package slo
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Objective asks for Target of all events, e.g. 0.999, to succeed within
+// Latency.
+type Objective struct {
+	Name    string
+	Target  float64
+	Latency time.Duration
+}
+
+const (
+	bucketWidth = time.Minute
+
+	// ShortWindow and LongWindow are the windows of the burn rate alert:
+	// it fires when both burn faster than the threshold, so that a brief
+	// spike doesn't page and a recovered incident stops paging quickly.
+	ShortWindow = 5 * time.Minute
+	LongWindow  = time.Hour
+)
+
+type bucket struct {
+	minute int64
+	total  int64
+	bad    int64
+}
+
+// Tracker counts good and bad events per minute over a rolling window.
+type Tracker struct {
+	objective Objective
+	window    time.Duration
+	burnAlert float64
+	log       *slog.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	buckets  []bucket
+	good     int64
+	bad      int64
+	alerting bool
+
+	eventsDesc *prometheus.Desc
+	ratioDesc  *prometheus.Desc
+	budgetDesc *prometheus.Desc
+	burnDesc   *prometheus.Desc
+}
+
+// NewTracker tracks objective over window, which is rounded up to whole
+// minutes and to at least LongWindow. Check alerts once both alert windows
+// burn the error budget burnAlert times faster than sustainable.
+func NewTracker(objective Objective, window time.Duration, burnAlert float64, log *slog.Logger) *Tracker {
+	window = max(window, LongWindow)
+	n := int((window + bucketWidth - 1) / bucketWidth)
+	labels := prometheus.Labels{"objective": objective.Name}
+	return &Tracker{
+		objective: objective,
+		window:    time.Duration(n) * bucketWidth,
+		burnAlert: burnAlert,
+		log:       log.With(slog.String("objective", objective.Name)),
+		now:       time.Now,
+		buckets:   make([]bucket, n),
+
+		eventsDesc: prometheus.NewDesc("wallet_slo_events_total",
+			"Events counted against the SLO by result.", []string{"result"}, labels),
+		ratioDesc: prometheus.NewDesc("wallet_slo_success_ratio",
+			"Share of good events over the SLO window.", nil, labels),
+		budgetDesc: prometheus.NewDesc("wallet_slo_error_budget_remaining",
+			"Share of the error budget of the SLO window left; negative once exhausted.", nil, labels),
+		burnDesc: prometheus.NewDesc("wallet_slo_burn_rate",
+			"Error budget burn rate; 1 spends exactly the budget over the SLO window.", []string{"window"}, labels),
+	}
+}
+
+// Observe records one event. It is bad if it failed or took longer than
+// the objective's latency.
+func (t *Tracker) Observe(failed bool, elapsed time.Duration) {
+	bad := failed || elapsed > t.objective.Latency
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	minute := t.now().Unix() / int64(bucketWidth/time.Second)
+	b := &t.buckets[minute%int64(len(t.buckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if bad {
+		b.bad++
+		t.bad++
+	} else {
+		t.good++
+	}
+}
+
+// counts sums the buckets of the last d. The caller holds t.mu.
+func (t *Tracker) counts(d time.Duration) (total, bad int64) {
+	current := t.now().Unix() / int64(bucketWidth/time.Second)
+	oldest := current - int64(d/bucketWidth) + 1
+	for _, b := range t.buckets {
+		if b.minute >= oldest && b.minute <= current {
+			total += b.total
+			bad += b.bad
+		}
+	}
+	return total, bad
+}
+
+// burnRate is the error rate relative to the one the objective allows.
+func (t *Tracker) burnRate(total, bad int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / (1 - t.objective.Target)
+}
+
+// Status is a point-in-time view of the objective over its window.
+type Status struct {
+	Objective            string             `json:"objective"`
+	Target               float64            `json:"target"`
+	Latency              time.Duration      `json:"latency"`
+	Window               time.Duration      `json:"window"`
+	Total                int64              `json:"total"`
+	Bad                  int64              `json:"bad"`
+	SuccessRatio         float64            `json:"successRatio"`
+	ErrorBudgetRemaining float64            `json:"errorBudgetRemaining"`
+	BurnRates            map[string]float64 `json:"burnRates"`
+	Alerting             bool               `json:"alerting"`
+}
+
+func (t *Tracker) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status()
+}
+
+func (t *Tracker) status() Status {
+	total, bad := t.counts(t.window)
+	s := Status{
+		Objective:            t.objective.Name,
+		Target:               t.objective.Target,
+		Latency:              t.objective.Latency,
+		Window:               t.window,
+		Total:                total,
+		Bad:                  bad,
+		SuccessRatio:         1,
+		ErrorBudgetRemaining: 1 - t.burnRate(total, bad),
+		BurnRates:            make(map[string]float64, 3),
+		Alerting:             t.alerting,
+	}
+	if total > 0 {
+		s.SuccessRatio = float64(total-bad) / float64(total)
+	}
+	for _, d := range []time.Duration{ShortWindow, LongWindow, t.window} {
+		s.BurnRates[windowLabel(d)] = t.burnRate(t.counts(d))
+	}
+	return s
+}
+
+// Check evaluates the burn rate alert and logs when it starts and stops
+// firing. It is meant to run periodically on the scheduler.
+func (t *Tracker) Check(ctx context.Context) error {
+	t.mu.Lock()
+	short := t.burnRate(t.counts(ShortWindow))
+	long := t.burnRate(t.counts(LongWindow))
+	firing := short >= t.burnAlert && long >= t.burnAlert
+	changed := firing != t.alerting
+	t.alerting = firing
+	status := t.status()
+	t.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	attrs := []any{
+		slog.Float64("burn_rate_short", short),
+		slog.Float64("burn_rate_long", long),
+		slog.Float64("threshold", t.burnAlert),
+		slog.Float64("error_budget_remaining", status.ErrorBudgetRemaining),
+	}
+	if firing {
+		t.log.Warn("SLO error budget burning too fast", attrs...)
+	} else {
+		t.log.Info("SLO error budget burn back to normal", attrs...)
+	}
+	return nil
+}
+
+// Describe and Collect make the tracker a prometheus.Collector.
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.eventsDesc
+	ch <- t.ratioDesc
+	ch <- t.budgetDesc
+	ch <- t.burnDesc
+}
+
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	good, bad := t.good, t.bad
+	status := t.status()
+	t.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(t.eventsDesc, prometheus.CounterValue, float64(good), "good")
+	ch <- prometheus.MustNewConstMetric(t.eventsDesc, prometheus.CounterValue, float64(bad), "bad")
+	ch <- prometheus.MustNewConstMetric(t.ratioDesc, prometheus.GaugeValue, status.SuccessRatio)
+	ch <- prometheus.MustNewConstMetric(t.budgetDesc, prometheus.GaugeValue, status.ErrorBudgetRemaining)
+	for window, rate := range status.BurnRates {
+		ch <- prometheus.MustNewConstMetric(t.burnDesc, prometheus.GaugeValue, rate, window)
+	}
+}
+
+func windowLabel(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	}
+	return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+}
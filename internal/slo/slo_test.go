@@ -0,0 +1,110 @@
+package slo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestTracker(t *testing.T, log *slog.Logger) (*Tracker, *clock) {
+	t.Helper()
+	c := &clock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	tr := NewTracker(Objective{Name: "operations", Target: 0.99, Latency: 100 * time.Millisecond}, 2*time.Hour, 10, log)
+	tr.now = c.now
+	return tr, c
+}
+
+func TestTracker_Status(t *testing.T) {
+	tr, _ := newTestTracker(t, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for range 97 {
+		tr.Observe(false, time.Millisecond)
+	}
+	tr.Observe(true, time.Millisecond)
+	tr.Observe(false, time.Second)
+	tr.Observe(false, 100*time.Millisecond)
+
+	s := tr.Status()
+	assert.Equal(t, int64(100), s.Total)
+	assert.Equal(t, int64(2), s.Bad)
+	assert.InDelta(t, 0.98, s.SuccessRatio, 1e-9)
+	assert.InDelta(t, 2.0, s.BurnRates["5m"], 1e-9)
+	assert.InDelta(t, -1.0, s.ErrorBudgetRemaining, 1e-9)
+	assert.Equal(t, 2*time.Hour, s.Window)
+}
+
+func TestTracker_WindowRollsOver(t *testing.T) {
+	tr, c := newTestTracker(t, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tr.Observe(true, 0)
+	c.t = c.t.Add(10 * time.Minute)
+	tr.Observe(false, 0)
+
+	s := tr.Status()
+	assert.Equal(t, int64(2), s.Total)
+	assert.Zero(t, s.BurnRates["5m"])
+	assert.InDelta(t, 50.0, s.BurnRates["1h"], 1e-9)
+
+	c.t = c.t.Add(2 * time.Hour)
+	s = tr.Status()
+	assert.Zero(t, s.Total)
+	assert.Equal(t, 1.0, s.SuccessRatio)
+	assert.Equal(t, 1.0, s.ErrorBudgetRemaining)
+}
+
+func TestTracker_CheckAlertsOnFastBurn(t *testing.T) {
+	var buf strings.Builder
+	tr, c := newTestTracker(t, slog.New(slog.NewTextHandler(&buf, nil)))
+
+	for range 10 {
+		tr.Observe(true, 0)
+		tr.Observe(false, 0)
+	}
+	require.NoError(t, tr.Check(context.Background()))
+	assert.True(t, tr.Status().Alerting)
+	assert.Contains(t, buf.String(), "burning too fast")
+
+	buf.Reset()
+	require.NoError(t, tr.Check(context.Background()))
+	assert.Empty(t, buf.String(), "an ongoing alert is logged once")
+
+	// The short window recovers long before the hour does.
+	c.t = c.t.Add(10 * time.Minute)
+	tr.Observe(false, 0)
+	require.NoError(t, tr.Check(context.Background()))
+	assert.False(t, tr.Status().Alerting)
+	assert.Contains(t, buf.String(), "back to normal")
+}
+
+func TestTracker_Collect(t *testing.T) {
+	tr, _ := newTestTracker(t, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tr.Observe(false, 0)
+	tr.Observe(true, 0)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(tr))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP wallet_slo_events_total Events counted against the SLO by result.
+# TYPE wallet_slo_events_total counter
+wallet_slo_events_total{objective="operations",result="bad"} 1
+wallet_slo_events_total{objective="operations",result="good"} 1
+# HELP wallet_slo_success_ratio Share of good events over the SLO window.
+# TYPE wallet_slo_success_ratio gauge
+wallet_slo_success_ratio{objective="operations"} 0.5
+`), "wallet_slo_events_total", "wallet_slo_success_ratio")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, testutil.CollectAndCount(tr, "wallet_slo_burn_rate"))
+}
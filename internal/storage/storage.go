@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotConfigured = errors.New("object storage is not configured")
+
+// ObjectStore is a destination for exports, backups and archives.
+type ObjectStore interface {
+	// Put uploads body under key (relative to the configured prefix).
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get downloads the object stored under key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// SignedURL returns a time-limited URL that allows downloading key
+	// without credentials.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
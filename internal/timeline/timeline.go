@@ -0,0 +1,312 @@
+// Package timeline fans committed wallet operations out to live
+// subscribers, such as the transaction stream of the admin dashboard.
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SubscriberBuffer is the number of events a subscriber may fall behind
+// before its overflow policy applies, unless the broker is given another
+// policy.
+const SubscriberBuffer = 256
+
+// ErrSlowConsumer is why a subscription with the Disconnect policy was
+// closed: its subscriber fell a full buffer behind.
+var ErrSlowConsumer = errors.New("subscriber fell too far behind")
+
+// Overflow is what happens to a subscription whose buffer is full when an
+// event for it is published.
+type Overflow int
+
+const (
+	// DropNewest drops the event published.
+	DropNewest Overflow = iota
+	// DropOldest drops the oldest buffered event to make room, so the
+	// subscriber sees the latest events once it catches up.
+	DropOldest
+	// Disconnect closes the subscription. The subscriber still receives
+	// the events buffered before, then Err returns ErrSlowConsumer.
+	Disconnect
+)
+
+var overflowNames = []string{"drop-newest", "drop-oldest", "disconnect"}
+
+func (o Overflow) String() string {
+	if o < 0 || int(o) >= len(overflowNames) {
+		return fmt.Sprintf("Overflow(%d)", int(o))
+	}
+	return overflowNames[o]
+}
+
+// ParseOverflow parses the name of an overflow policy: drop-newest,
+// drop-oldest or disconnect.
+func ParseOverflow(s string) (Overflow, error) {
+	for i, name := range overflowNames {
+		if s == name {
+			return Overflow(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown overflow policy %q", s)
+}
+
+// Policy bounds a subscription: it buffers up to Buffer events, at least
+// one, and applies Overflow once they are all unread.
+type Policy struct {
+	Buffer   int
+	Overflow Overflow
+}
+
+// Event is a committed operation on one wallet. Transfers appear as their
+// withdrawal and deposit.
+type Event struct {
+	WalletID      uuid.UUID            `json:"walletId"`
+	Tenant        string               `json:"tenant,omitempty"`
+	Currency      string               `json:"currency"`
+	OperationType models.OperationType `json:"operationType"`
+	Amount        int64                `json:"amount"`
+	Balance       int64                `json:"balance"`
+	Version       int                  `json:"version"`
+	At            time.Time            `json:"at"`
+}
+
+// Filter selects the events a subscriber receives. The zero Filter
+// matches everything.
+type Filter struct {
+	// WalletID, if set, matches operations on that wallet only.
+	WalletID uuid.UUID
+	// Tenant, if set, matches wallets of that tenant only.
+	Tenant string
+	// MinAmount, if positive, matches operations of at least that amount.
+	MinAmount int64
+}
+
+func (f Filter) match(e Event) bool {
+	return (f.WalletID == uuid.Nil || e.WalletID == f.WalletID) &&
+		(f.Tenant == "" || e.Tenant == f.Tenant) && e.Amount >= f.MinAmount
+}
+
+// Subscription receives matching events on C until Close.
+type Subscription struct {
+	C <-chan Event
+
+	ch       chan Event
+	filter   Filter
+	overflow Overflow
+	broker   *Broker
+	dropped  atomic.Uint64
+	// err is set, under the broker's lock, when the subscription is
+	// closed for falling behind.
+	err error
+}
+
+// Dropped returns how many events were dropped because the subscriber
+// didn't keep up.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Err returns ErrSlowConsumer once the subscription has been closed for
+// falling behind, and nil otherwise.
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close unsubscribes and closes C. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, nil)
+}
+
+// CloseSlow closes the subscription of a subscriber that stopped keeping
+// up for reasons of its own, such as a client that can't be written to in
+// time, counting it as a slow consumer disconnect.
+func (s *Subscription) CloseSlow() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, ErrSlowConsumer)
+}
+
+// Broker publishes events to its subscribers. Publishing never blocks: a
+// subscriber whose buffer is full misses events or is disconnected,
+// following its policy. It is a service.AfterOperation, so registering it
+// with the wallet service publishes every committed operation, atomic step
+// and transfer leg. It is also a prometheus.Collector of subscriber
+// metrics.
+type Broker struct {
+	policy Policy
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+
+	dropped       prometheus.Counter
+	disconnects   prometheus.Counter
+	subscribers   *prometheus.Desc
+	buffered      *prometheus.Desc
+	maxBufferFill *prometheus.Desc
+}
+
+type Option func(*Broker)
+
+// WithPolicy sets the policy of the subscriptions made with Subscribe,
+// which defaults to SubscriberBuffer events and DropNewest.
+func WithPolicy(p Policy) Option {
+	return func(b *Broker) {
+		b.policy = p
+	}
+}
+
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{
+		policy: Policy{Buffer: SubscriberBuffer, Overflow: DropNewest},
+		subs:   make(map[*Subscription]struct{}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_stream_events_dropped_total",
+			Help: "Events dropped for streaming subscribers that fell behind.",
+		}),
+		disconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_stream_slow_consumer_disconnects_total",
+			Help: "Streaming subscribers disconnected for falling behind.",
+		}),
+		subscribers: prometheus.NewDesc("wallet_stream_subscribers",
+			"Open streaming subscriptions.", nil, nil),
+		buffered: prometheus.NewDesc("wallet_stream_buffered_events",
+			"Events buffered for streaming subscribers, not yet read.", nil, nil),
+		maxBufferFill: prometheus.NewDesc("wallet_stream_max_buffer_fill_ratio",
+			"Fill ratio of the fullest subscriber buffer, 1 meaning the subscriber is a full buffer behind.", nil, nil),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscribe returns a subscription to the events matching f, bounded by
+// the broker's policy.
+func (b *Broker) Subscribe(f Filter) *Subscription {
+	return b.SubscribeWith(f, b.policy)
+}
+
+// SubscribeWith returns a subscription to the events matching f, bounded
+// by p rather than the broker's policy. Subscribers that only need to
+// know that something changed are best served by a buffer of one with
+// DropOldest.
+func (b *Broker) SubscribeWith(f Filter, p Policy) *Subscription {
+	ch := make(chan Event, max(p.Buffer, 1))
+	s := &Subscription{C: ch, ch: ch, filter: f, overflow: p.Overflow, broker: b}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// remove closes s, if still open, for err. b.mu must be held.
+func (b *Broker) remove(s *Subscription, err error) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+	if err != nil {
+		s.err = err
+		b.disconnects.Inc()
+	}
+}
+
+// Close ends every subscription, e.g. so that streaming responses finish
+// when the server shuts down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		b.remove(s, nil)
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Publish sends e to every subscriber it matches.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if !s.filter.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+			continue
+		default:
+		}
+
+		switch s.overflow {
+		case Disconnect:
+			b.remove(s, ErrSlowConsumer)
+			continue
+		case DropOldest:
+			// Only Publish sends, under b.mu, so once an event is taken
+			// out there is room for e.
+			select {
+			case <-s.ch:
+			default:
+			}
+			s.ch <- e
+		}
+		s.dropped.Add(1)
+		b.dropped.Inc()
+	}
+}
+
+func (b *Broker) Describe(ch chan<- *prometheus.Desc) {
+	b.dropped.Describe(ch)
+	b.disconnects.Describe(ch)
+	ch <- b.subscribers
+	ch <- b.buffered
+	ch <- b.maxBufferFill
+}
+
+func (b *Broker) Collect(ch chan<- prometheus.Metric) {
+	b.mu.Lock()
+	subscribers, buffered, fill := len(b.subs), 0, 0.0
+	for s := range b.subs {
+		buffered += len(s.ch)
+		fill = max(fill, float64(len(s.ch))/float64(cap(s.ch)))
+	}
+	b.mu.Unlock()
+
+	b.dropped.Collect(ch)
+	b.disconnects.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(b.subscribers, prometheus.GaugeValue, float64(subscribers))
+	ch <- prometheus.MustNewConstMetric(b.buffered, prometheus.GaugeValue, float64(buffered))
+	ch <- prometheus.MustNewConstMetric(b.maxBufferFill, prometheus.GaugeValue, fill)
+}
+
+func (b *Broker) AfterOperation(_ context.Context, wallet *models.Wallet, operation models.WalletOperation) {
+	b.Publish(Event{
+		WalletID:      wallet.ID,
+		Tenant:        wallet.Tenant,
+		Currency:      wallet.Currency,
+		OperationType: operation.OperationType,
+		Amount:        operation.Amount,
+		Balance:       wallet.Balance,
+		Version:       wallet.Version,
+		At:            wallet.UpdatedAt,
+	})
+}
@@ -0,0 +1,145 @@
+package timeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_FiltersSubscribers(t *testing.T) {
+	b := NewBroker()
+	all := b.Subscribe(Filter{})
+	acme := b.Subscribe(Filter{Tenant: "acme"})
+	large := b.Subscribe(Filter{MinAmount: 1000})
+	wallet := &models.Wallet{ID: uuid.New(), Tenant: "acme", Currency: "USD", Balance: 150, Version: 2}
+	one := b.Subscribe(Filter{WalletID: wallet.ID})
+	defer all.Close()
+	defer acme.Close()
+	defer large.Close()
+	defer one.Close()
+
+	b.AfterOperation(context.Background(), wallet, models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationTypeDeposit, Amount: 100})
+	b.Publish(Event{Tenant: "other", Amount: 5000})
+
+	got := <-all.C
+	assert.Equal(t, wallet.ID, got.WalletID)
+	assert.Equal(t, int64(100), got.Amount)
+	assert.Equal(t, int64(150), got.Balance)
+	assert.Equal(t, "other", (<-all.C).Tenant)
+	assert.Equal(t, "acme", (<-acme.C).Tenant)
+	assert.Equal(t, int64(5000), (<-large.C).Amount)
+	assert.Equal(t, 2, (<-one.C).Version)
+	assert.Empty(t, acme.C)
+	assert.Empty(t, large.C)
+	assert.Empty(t, one.C)
+}
+
+func TestBroker_DropsForSlowSubscribers(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe(Filter{})
+	for range SubscriberBuffer + 3 {
+		b.Publish(Event{Amount: 1})
+	}
+	assert.Equal(t, uint64(3), sub.Dropped())
+	assert.Len(t, sub.C, SubscriberBuffer)
+
+	sub.Close()
+	sub.Close()
+	assert.Zero(t, b.Subscribers())
+}
+
+func TestBroker_CloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe(Filter{})
+	b.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+	sub.Close()
+}
+
+func TestBroker_DropsOldestForSlowSubscribers(t *testing.T) {
+	b := NewBroker(WithPolicy(Policy{Buffer: 2, Overflow: DropOldest}))
+	sub := b.Subscribe(Filter{})
+	defer sub.Close()
+
+	for i := range 5 {
+		b.Publish(Event{Version: i})
+	}
+
+	assert.Equal(t, uint64(3), sub.Dropped())
+	assert.Equal(t, 3, (<-sub.C).Version)
+	assert.Equal(t, 4, (<-sub.C).Version)
+	assert.NoError(t, sub.Err())
+}
+
+func TestBroker_DisconnectsSlowSubscribers(t *testing.T) {
+	b := NewBroker(WithPolicy(Policy{Buffer: 2, Overflow: Disconnect}))
+	slow := b.Subscribe(Filter{})
+	wakeups := b.SubscribeWith(Filter{}, Policy{Buffer: 1, Overflow: DropOldest})
+	defer wakeups.Close()
+
+	for i := range 3 {
+		b.Publish(Event{Version: i})
+	}
+
+	assert.Equal(t, 0, (<-slow.C).Version, "events buffered before are still delivered")
+	assert.Equal(t, 1, (<-slow.C).Version)
+	_, ok := <-slow.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+	assert.Equal(t, 2, (<-wakeups.C).Version)
+	assert.Equal(t, 1, b.Subscribers())
+
+	b.Subscribe(Filter{}).CloseSlow()
+	assert.Equal(t, float64(2), testutil.ToFloat64(b.disconnects))
+}
+
+func TestBroker_Metrics(t *testing.T) {
+	b := NewBroker(WithPolicy(Policy{Buffer: 4}))
+	full := b.Subscribe(Filter{})
+	defer full.Close()
+	half := b.Subscribe(Filter{WalletID: uuid.New()})
+	defer half.Close()
+
+	for range 5 {
+		b.Publish(Event{})
+	}
+	b.Publish(Event{WalletID: half.filter.WalletID})
+	b.Publish(Event{WalletID: half.filter.WalletID})
+
+	err := testutil.CollectAndCompare(b, strings.NewReader(`
+# HELP wallet_stream_buffered_events Events buffered for streaming subscribers, not yet read.
+# TYPE wallet_stream_buffered_events gauge
+wallet_stream_buffered_events 6
+# HELP wallet_stream_events_dropped_total Events dropped for streaming subscribers that fell behind.
+# TYPE wallet_stream_events_dropped_total counter
+wallet_stream_events_dropped_total 3
+# HELP wallet_stream_max_buffer_fill_ratio Fill ratio of the fullest subscriber buffer, 1 meaning the subscriber is a full buffer behind.
+# TYPE wallet_stream_max_buffer_fill_ratio gauge
+wallet_stream_max_buffer_fill_ratio 1
+# HELP wallet_stream_slow_consumer_disconnects_total Streaming subscribers disconnected for falling behind.
+# TYPE wallet_stream_slow_consumer_disconnects_total counter
+wallet_stream_slow_consumer_disconnects_total 0
+# HELP wallet_stream_subscribers Open streaming subscriptions.
+# TYPE wallet_stream_subscribers gauge
+wallet_stream_subscribers 2
+`))
+	assert.NoError(t, err)
+}
+
+func TestParseOverflow(t *testing.T) {
+	for _, o := range []Overflow{DropNewest, DropOldest, Disconnect} {
+		got, err := ParseOverflow(o.String())
+		require.NoError(t, err)
+		assert.Equal(t, o, got)
+	}
+	_, err := ParseOverflow("block")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Notify(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, Sign("secret", body), r.Header.Get(SignatureHeader))
+		require.NoError(t, json.Unmarshal(body, &got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	e := NewEvent("dispute.opened", map[string]string{"id": "d1"})
+	require.NoError(t, NewSender(srv.URL, "secret", time.Second).Notify(context.Background(), e))
+
+	assert.Equal(t, e.ID, got.ID)
+	assert.Equal(t, "dispute.opened", got.Type)
+}
+
+func TestSender_NotifyFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewSender(srv.URL, "", time.Second).Notify(context.Background(), NewEvent("dispute.opened", nil))
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"wallet.created"}`)
+
+	assert.True(t, Verify("secret", body, Sign("secret", body)))
+	assert.False(t, Verify("other", body, Sign("secret", body)))
+	assert.False(t, Verify("secret", []byte(`{"type":"wallet.dormant"}`), Sign("secret", body)))
+	assert.False(t, Verify("secret", body, ""))
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS wallet_versions (
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	version INTEGER NOT NULL,
+	balance BIGINT NOT NULL,
+	operation_type TEXT NOT NULL,
+	amount BIGINT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (wallet_id, version)
+);
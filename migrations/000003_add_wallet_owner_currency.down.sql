@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS wallets_owner_id_currency_idx;
+
+ALTER TABLE wallets
+	DROP COLUMN IF EXISTS currency,
+	DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,5 @@
+ALTER TABLE wallets
+	ADD COLUMN IF NOT EXISTS owner_id UUID,
+	ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
+
+CREATE INDEX IF NOT EXISTS wallets_owner_id_currency_idx ON wallets (owner_id, currency);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS wallets_tenant_idx;
+
+ALTER TABLE wallets
+	DROP COLUMN IF EXISTS tenant,
+	DROP COLUMN IF EXISTS label,
+	DROP COLUMN IF EXISTS status;
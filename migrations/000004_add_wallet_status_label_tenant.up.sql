@@ -0,0 +1,6 @@
+ALTER TABLE wallets
+	ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ACTIVE',
+	ADD COLUMN IF NOT EXISTS label TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS wallets_tenant_idx ON wallets (tenant);
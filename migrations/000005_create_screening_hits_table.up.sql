@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS screening_hits (
+	id UUID PRIMARY KEY,
+	subject_kind TEXT NOT NULL,
+	subject_id TEXT NOT NULL,
+	list TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	action TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS screening_hits_subject_idx ON screening_hits (subject_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mandate_debits;
+DROP TABLE IF EXISTS mandates;
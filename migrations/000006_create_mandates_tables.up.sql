@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS mandates (
+	id UUID PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	counterparty TEXT NOT NULL,
+	amount_limit BIGINT NOT NULL,
+	max_debits INTEGER NOT NULL DEFAULT 0,
+	period TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'ACTIVE',
+	created_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS mandates_wallet_id_idx ON mandates (wallet_id);
+
+CREATE TABLE IF NOT EXISTS mandate_debits (
+	id UUID PRIMARY KEY,
+	mandate_id UUID NOT NULL REFERENCES mandates (id),
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	counterparty TEXT NOT NULL,
+	amount BIGINT NOT NULL,
+	balance BIGINT NOT NULL,
+	version INTEGER NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS mandate_debits_mandate_id_created_at_idx ON mandate_debits (mandate_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS promo_credits;
+ALTER TABLE wallets DROP COLUMN IF EXISTS promo_balance;
@@ -0,0 +1,14 @@
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS promo_balance BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS promo_credits (
+	id UUID PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	amount BIGINT NOT NULL,
+	remaining BIGINT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expired_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS promo_credits_wallet_id_expires_at_idx ON promo_credits (wallet_id, expires_at);
+CREATE INDEX IF NOT EXISTS promo_credits_unexpired_idx ON promo_credits (expires_at) WHERE expired_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reward_accruals;
+DROP TABLE IF EXISTS reward_wallets;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS reward_wallets (
+	wallet_id UUID PRIMARY KEY REFERENCES wallets (id),
+	rewards_wallet_id UUID NOT NULL REFERENCES wallets (id)
+);
+
+CREATE TABLE IF NOT EXISTS reward_accruals (
+	transaction_id TEXT PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	rewards_wallet_id UUID NOT NULL REFERENCES wallets (id),
+	rule TEXT NOT NULL,
+	amount BIGINT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS reward_accruals_wallet_id_idx ON reward_accruals (wallet_id, created_at);
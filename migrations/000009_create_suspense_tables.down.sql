@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS suspense_cases;
+DROP TABLE IF EXISTS suspense_wallets;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS suspense_wallets (
+	currency TEXT PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id)
+);
+
+CREATE TABLE IF NOT EXISTS suspense_cases (
+	id UUID PRIMARY KEY,
+	suspense_wallet_id UUID NOT NULL REFERENCES wallets (id),
+	amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	reference TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'OPEN',
+	created_at TIMESTAMP NOT NULL,
+	credit_version INTEGER NOT NULL,
+	resolved_at TIMESTAMP,
+	target_wallet_id UUID REFERENCES wallets (id),
+	release_version INTEGER,
+	target_version INTEGER,
+	resolution_note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS suspense_cases_status_idx ON suspense_cases (status, created_at);
@@ -0,0 +1,20 @@
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS held_balance BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS disputes (
+	id UUID PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	version INTEGER NOT NULL,
+	operation_type TEXT NOT NULL,
+	amount BIGINT NOT NULL,
+	hold_amount BIGINT NOT NULL,
+	reason TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'OPEN',
+	deadline TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	resolved_at TIMESTAMP,
+	reversal_version INTEGER,
+	note TEXT NOT NULL DEFAULT '',
+	UNIQUE (wallet_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS disputes_open_deadline_idx ON disputes (deadline) WHERE status = 'OPEN';
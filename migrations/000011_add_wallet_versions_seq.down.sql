@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS wallet_versions_seq_idx;
+
+ALTER TABLE wallet_versions DROP COLUMN IF EXISTS seq;
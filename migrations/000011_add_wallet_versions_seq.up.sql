@@ -0,0 +1,3 @@
+ALTER TABLE wallet_versions ADD COLUMN IF NOT EXISTS seq BIGSERIAL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_versions_seq_idx ON wallet_versions (seq);
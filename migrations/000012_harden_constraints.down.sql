@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS wallet_versions_created_at_idx;
+DROP INDEX IF EXISTS wallets_created_at_id_idx;
+
+ALTER TABLE wallet_versions
+	DROP CONSTRAINT IF EXISTS wallet_versions_balance_check,
+	DROP CONSTRAINT IF EXISTS wallet_versions_amount_check;
+
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_currency_check,
+	DROP CONSTRAINT IF EXISTS wallets_status_check,
+	DROP CONSTRAINT IF EXISTS wallets_version_check,
+	DROP CONSTRAINT IF EXISTS wallets_held_balance_check,
+	DROP CONSTRAINT IF EXISTS wallets_promo_balance_check,
+	DROP CONSTRAINT IF EXISTS wallets_balance_check;
@@ -0,0 +1,27 @@
+-- Constraints are added NOT VALID and validated separately so that the
+-- table is only briefly locked against writes.
+ALTER TABLE wallets
+	ADD CONSTRAINT wallets_balance_check CHECK (balance >= 0) NOT VALID,
+	ADD CONSTRAINT wallets_promo_balance_check CHECK (promo_balance >= 0 AND promo_balance <= balance) NOT VALID,
+	ADD CONSTRAINT wallets_held_balance_check CHECK (held_balance >= 0) NOT VALID,
+	ADD CONSTRAINT wallets_version_check CHECK (version >= 1) NOT VALID,
+	ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN')) NOT VALID,
+	ADD CONSTRAINT wallets_currency_check CHECK (currency ~ '^[A-Z]{3}$') NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_promo_balance_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_held_balance_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_version_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_status_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_currency_check;
+
+ALTER TABLE wallet_versions
+	ADD CONSTRAINT wallet_versions_amount_check CHECK (amount >= 0) NOT VALID,
+	ADD CONSTRAINT wallet_versions_balance_check CHECK (balance >= 0) NOT VALID;
+
+ALTER TABLE wallet_versions VALIDATE CONSTRAINT wallet_versions_amount_check;
+ALTER TABLE wallet_versions VALIDATE CONSTRAINT wallet_versions_balance_check;
+
+-- Lookups by owner use wallets_owner_id_currency_idx.
+CREATE INDEX IF NOT EXISTS wallets_created_at_id_idx ON wallets (created_at, id);
+CREATE INDEX IF NOT EXISTS wallet_versions_created_at_idx ON wallet_versions (created_at, wallet_id, version);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallets_currency_balance_idx;
+DROP INDEX IF EXISTS wallets_status_currency_id_idx;
@@ -0,0 +1,4 @@
+-- Indexes for the admin wallet listing filters; the listing pages by id.
+-- Created-at ranges use wallets_created_at_id_idx.
+CREATE INDEX IF NOT EXISTS wallets_status_currency_id_idx ON wallets (status, currency, id);
+CREATE INDEX IF NOT EXISTS wallets_currency_balance_idx ON wallets (currency, balance);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS job_report_parts;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,30 @@
+-- Persisted background jobs. owner holds the lease of the running instance
+-- and is empty once a job is released; updated_at doubles as the heartbeat.
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    state TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    owner TEXT NOT NULL DEFAULT '',
+    total BIGINT NOT NULL DEFAULT 0,
+    processed BIGINT NOT NULL DEFAULT 0,
+    failed BIGINT NOT NULL DEFAULT 0,
+    checkpoint JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    report_type TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS jobs_unfinished_idx ON jobs (created_at) WHERE state IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS jobs_kind_created_at_idx ON jobs (kind, created_at DESC);
+
+-- Reports are appended in parts as jobs checkpoint.
+CREATE TABLE IF NOT EXISTS job_report_parts (
+    job_id UUID NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    seq INT NOT NULL,
+    data BYTEA NOT NULL,
+    PRIMARY KEY (job_id, seq)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS wallets_tenant_lower_idx;
+DROP INDEX IF EXISTS wallets_label_lower_idx;
+DROP INDEX IF EXISTS wallets_owner_id_text_idx;
+DROP INDEX IF EXISTS wallets_id_text_idx;
@@ -0,0 +1,6 @@
+-- Prefix indexes for the admin wallet search. text_pattern_ops lets
+-- LIKE 'prefix%' use them whatever the database collation.
+CREATE INDEX IF NOT EXISTS wallets_id_text_idx ON wallets ((id::text) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS wallets_owner_id_text_idx ON wallets ((owner_id::text) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS wallets_label_lower_idx ON wallets ((lower(label)) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS wallets_tenant_lower_idx ON wallets ((lower(tenant)) text_pattern_ops);
@@ -0,0 +1,12 @@
+-- Notes on committed transactions, kept apart from wallet_versions so the
+-- financial record stays immutable.
+CREATE TABLE IF NOT EXISTS transaction_notes (
+	id UUID PRIMARY KEY,
+	seq BIGINT NOT NULL REFERENCES wallet_versions (seq),
+	author TEXT NOT NULL,
+	body TEXT NOT NULL,
+	attachments JSONB NOT NULL DEFAULT '[]',
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transaction_notes_seq_idx ON transaction_notes (seq, created_at);
@@ -0,0 +1,12 @@
+-- Responses stored per Idempotency-Key; status is NULL while the first
+-- request under the key is still being processed.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key TEXT PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	status INT,
+	content_type TEXT NOT NULL DEFAULT '',
+	body BYTEA NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS wallets_active_updated_at_idx;
+
+UPDATE wallets SET status = 'ACTIVE' WHERE status = 'DORMANT';
+
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_status_check,
+	ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN')) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_status_check;
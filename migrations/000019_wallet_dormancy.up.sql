@@ -0,0 +1,9 @@
+-- Wallets idle for longer than the dormancy period are flagged DORMANT.
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_status_check,
+	ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'DORMANT')) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_status_check;
+
+-- The dormancy check scans active wallets by last change.
+CREATE INDEX IF NOT EXISTS wallets_active_updated_at_idx ON wallets (updated_at, id) WHERE status = 'ACTIVE';
@@ -0,0 +1,10 @@
+-- API keys of internal callers, stored as the SHA-256 hex digest of the
+-- key. routes lists the route patterns a key may call, all if empty; a key
+-- stops working once revoked_at is set.
+CREATE TABLE IF NOT EXISTS api_keys (
+	hash TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	routes TEXT[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS wallets_closed_at_idx;
+
+-- Frozen is the closest status that still blocks operations.
+UPDATE wallets SET status = 'FROZEN' WHERE status = 'CLOSED';
+
+ALTER TABLE wallets
+	DROP COLUMN IF EXISTS closed_at,
+	DROP COLUMN IF EXISTS purged_at,
+	DROP CONSTRAINT IF EXISTS wallets_status_check,
+	ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'DORMANT')) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_status_check;
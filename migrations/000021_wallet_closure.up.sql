@@ -0,0 +1,14 @@
+-- Closed wallets take no operations. Until purged_at is set they can be
+-- restored; purging archives and deletes their transactions.
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_status_check,
+	ADD CONSTRAINT wallets_status_check CHECK (status IN ('ACTIVE', 'FROZEN', 'DORMANT', 'CLOSED')) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_status_check;
+
+ALTER TABLE wallets
+	ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ;
+
+-- The purge looks for closed, unpurged wallets by closing time.
+CREATE INDEX IF NOT EXISTS wallets_closed_at_idx ON wallets (closed_at) WHERE status = 'CLOSED' AND purged_at IS NULL;
@@ -0,0 +1,3 @@
+-- API keys get the user role unless granted admin, which also opens the
+-- admin routes to them.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
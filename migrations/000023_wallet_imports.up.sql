@@ -0,0 +1,9 @@
+-- Wallets migrated from legacy ledgers, one per external id. The opening
+-- balance is recorded as an OPENING_BALANCE version; metadata keeps what
+-- the import carried about it.
+CREATE TABLE IF NOT EXISTS wallet_imports (
+	external_id TEXT PRIMARY KEY,
+	wallet_id UUID NOT NULL UNIQUE REFERENCES wallets (id),
+	metadata JSONB NOT NULL DEFAULT '{}',
+	imported_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS wallets_balance_id_idx;
@@ -0,0 +1,3 @@
+-- Wallet listings sorted by balance page by (balance, id). Listings sorted
+-- by creation time use wallets_created_at_id_idx.
+CREATE INDEX IF NOT EXISTS wallets_balance_id_idx ON wallets (balance, id);
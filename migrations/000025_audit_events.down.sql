@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS legal_holds;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,23 @@
+-- Audit events are partitioned by month; the service creates each month's
+-- partition on first use, and retention drops partitions once nothing in
+-- them is on legal hold.
+CREATE TABLE IF NOT EXISTS audit_events (
+	id UUID NOT NULL,
+	wallet_id UUID,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	occurred_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (occurred_at, id)
+) PARTITION BY RANGE (occurred_at);
+
+CREATE INDEX IF NOT EXISTS audit_events_wallet_id_idx ON audit_events (wallet_id, occurred_at, id);
+
+-- Wallets on legal hold keep their transactions and audit events however
+-- old they are.
+CREATE TABLE IF NOT EXISTS legal_holds (
+	wallet_id UUID PRIMARY KEY,
+	reason TEXT NOT NULL,
+	placed_by TEXT NOT NULL DEFAULT '',
+	placed_at TIMESTAMPTZ NOT NULL
+);
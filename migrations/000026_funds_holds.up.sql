@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS holds (
+	id UUID PRIMARY KEY,
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	amount BIGINT NOT NULL CHECK (amount > 0),
+	reference TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'PENDING',
+	captured_amount BIGINT NOT NULL DEFAULT 0,
+	capture_version INTEGER,
+	created_at TIMESTAMPTZ NOT NULL,
+	resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS holds_wallet_id_idx ON holds (wallet_id, created_at);
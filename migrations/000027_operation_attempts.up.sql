@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS operation_attempts (
+	operation_id TEXT NOT NULL,
+	attempt INTEGER NOT NULL,
+	op TEXT NOT NULL,
+	error_class TEXT NOT NULL DEFAULT '',
+	duration_ms DOUBLE PRECISION NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS operation_attempts_operation_id_idx ON operation_attempts (operation_id, started_at);
+
+CREATE INDEX IF NOT EXISTS operation_attempts_started_at_idx ON operation_attempts (started_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id UUID PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL DEFAULT '',
+	event_types TEXT[] NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
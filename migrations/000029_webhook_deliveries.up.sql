@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	event_id UUID NOT NULL,
+	subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	event_type TEXT NOT NULL,
+	payload BYTEA NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	delivered_at TIMESTAMPTZ,
+	PRIMARY KEY (event_id, subscription_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+	event_id UUID NOT NULL,
+	subscription_id UUID NOT NULL,
+	event_type TEXT NOT NULL,
+	attempt INTEGER NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms DOUBLE PRECISION NOT NULL,
+	attempted_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (event_id, subscription_id, attempt),
+	FOREIGN KEY (event_id, subscription_id) REFERENCES webhook_deliveries (event_id, subscription_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_subscription_idx ON webhook_delivery_attempts (subscription_id, attempted_at);
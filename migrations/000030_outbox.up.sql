@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox (
+	seq BIGSERIAL PRIMARY KEY,
+	id UUID NOT NULL UNIQUE,
+	wallet_id UUID NOT NULL,
+	event_type TEXT NOT NULL,
+	payload BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (seq) WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
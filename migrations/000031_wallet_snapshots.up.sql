@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS wallet_snapshots (
+	wallet_id UUID NOT NULL REFERENCES wallets (id),
+	version INTEGER NOT NULL,
+	balance BIGINT NOT NULL,
+	promo_balance BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (wallet_id, version)
+);
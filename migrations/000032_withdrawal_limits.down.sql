@@ -0,0 +1 @@
+DROP INDEX IF EXISTS wallet_versions_withdrawals_idx;
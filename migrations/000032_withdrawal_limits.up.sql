@@ -0,0 +1,3 @@
+-- Withdrawal limits sum a wallet's withdrawals since the start of the day
+-- or month.
+CREATE INDEX IF NOT EXISTS wallet_versions_withdrawals_idx ON wallet_versions (wallet_id, created_at) WHERE operation_type = 'WITHDRAW';
@@ -0,0 +1,4 @@
+ALTER TABLE webhook_subscriptions
+	DROP COLUMN IF EXISTS operation_types,
+	DROP COLUMN IF EXISTS min_amount,
+	DROP COLUMN IF EXISTS paused;
@@ -0,0 +1,6 @@
+-- Subscriptions may narrow balance events down to some operation types and
+-- a minimum amount, and be paused, which holds their deliveries back.
+ALTER TABLE webhook_subscriptions
+	ADD COLUMN IF NOT EXISTS operation_types TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN IF NOT EXISTS min_amount BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT false;
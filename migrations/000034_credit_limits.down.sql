@@ -0,0 +1,15 @@
+-- Fails while a wallet is overdrawn.
+ALTER TABLE wallet_versions
+	ADD CONSTRAINT wallet_versions_balance_check CHECK (balance >= 0) NOT VALID;
+
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_promo_balance_check,
+	DROP CONSTRAINT IF EXISTS wallets_balance_check,
+	DROP CONSTRAINT IF EXISTS wallets_credit_limit_check,
+	ADD CONSTRAINT wallets_balance_check CHECK (balance >= 0) NOT VALID,
+	ADD CONSTRAINT wallets_promo_balance_check CHECK (promo_balance >= 0 AND promo_balance <= balance) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_promo_balance_check;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS credit_limit;
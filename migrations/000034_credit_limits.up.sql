@@ -0,0 +1,17 @@
+-- Wallets may be given a credit limit, down to which their balance may go
+-- negative. A version records whatever balance its operation left, so the
+-- history no longer checks balances itself.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE wallets
+	DROP CONSTRAINT IF EXISTS wallets_balance_check,
+	DROP CONSTRAINT IF EXISTS wallets_promo_balance_check,
+	ADD CONSTRAINT wallets_credit_limit_check CHECK (credit_limit >= 0) NOT VALID,
+	ADD CONSTRAINT wallets_balance_check CHECK (balance + credit_limit >= 0) NOT VALID,
+	ADD CONSTRAINT wallets_promo_balance_check CHECK (promo_balance >= 0 AND promo_balance <= GREATEST(balance, 0)) NOT VALID;
+
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_credit_limit_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_balance_check;
+ALTER TABLE wallets VALIDATE CONSTRAINT wallets_promo_balance_check;
+
+ALTER TABLE wallet_versions DROP CONSTRAINT IF EXISTS wallet_versions_balance_check;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shard_transfer_claims;
+DROP TABLE IF EXISTS shard_transfers;
@@ -0,0 +1,21 @@
+-- A transfer between wallets on different shards is recorded on the source
+-- shard, with its debit, until its credit or reversal is applied.
+CREATE TABLE IF NOT EXISTS shard_transfers (
+	id UUID PRIMARY KEY,
+	from_wallet_id UUID NOT NULL REFERENCES wallets (id),
+	to_wallet_id UUID NOT NULL,
+	amount BIGINT NOT NULL CHECK (amount > 0),
+	status TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	settled_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS shard_transfers_pending_idx ON shard_transfers (created_at) WHERE status = 'PENDING';
+
+-- The target shard records whether a transfer was credited or given up on,
+-- so the credit and its reversal can't both happen.
+CREATE TABLE IF NOT EXISTS shard_transfer_claims (
+	transfer_id UUID PRIMARY KEY,
+	outcome TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
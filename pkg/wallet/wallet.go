@@ -0,0 +1,236 @@
+// Package wallet embeds the wallet engine in another Go program. It
+// exposes the service without the HTTP layer: build a Repository, backed
+// by Postgres or kept in memory, and pass it to New.
+//
+// The types are aliases of the engine's own, so values can be passed
+// between this package and the engine freely.
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/repository/memory"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+type (
+	Wallet                = models.Wallet
+	WalletStatus          = models.WalletStatus
+	WalletBalance         = models.WalletBalance
+	WalletVersion         = models.WalletVersion
+	WalletFilter          = models.WalletFilter
+	WalletSort            = models.WalletSort
+	WalletSortField       = models.WalletSortField
+	WalletCursor          = models.WalletCursor
+	CreateWalletRequest   = models.CreateWalletRequest
+	CreateWalletsRequest  = models.CreateWalletsRequest
+	CreateWalletsResponse = models.CreateWalletsResponse
+	Operation             = models.WalletOperation
+	OperationType         = models.OperationType
+	AtomicRequest         = models.AtomicRequest
+	AtomicReceipt         = models.AtomicReceipt
+	BatchRequest          = models.BatchRequest
+	AtomicStepResult      = models.AtomicStepResult
+	TransferRequest       = models.TransferRequest
+	Transfer              = models.Transfer
+	Transaction           = models.Transaction
+	OwnerBalance          = models.OwnerBalance
+	TenantBalance         = models.TenantBalance
+	CurrencyBalance       = models.CurrencyBalance
+	CurrencyTotals        = models.CurrencyTotals
+	ImportWalletRequest   = models.ImportWalletRequest
+	AuditEvent            = models.AuditEvent
+	LegalHold             = models.LegalHold
+)
+
+const (
+	Deposit  = models.OperationTypeDeposit
+	Withdraw = models.OperationTypeWithdraw
+
+	SortByID        = models.WalletSortID
+	SortByBalance   = models.WalletSortBalance
+	SortByCreatedAt = models.WalletSortCreatedAt
+
+	StatusActive  = models.WalletStatusActive
+	StatusFrozen  = models.WalletStatusFrozen
+	StatusDormant = models.WalletStatusDormant
+	StatusClosed  = models.WalletStatusClosed
+
+	// AnyStaleness accepts cached balances up to the cache TTL.
+	AnyStaleness = service.AnyStaleness
+)
+
+// Errors callers may test for with errors.Is.
+var (
+	ErrInvalidInput      = service.ErrInvalidInput
+	ErrWalletNotFound    = repository.ErrWalletNotFound
+	ErrInsufficientFunds = repository.ErrInsufficientFunds
+	ErrWalletFrozen      = repository.ErrWalletFrozen
+	ErrWalletNotDormant  = repository.ErrWalletNotDormant
+	ErrWalletClosed      = repository.ErrWalletClosed
+	ErrWalletNotClosed   = repository.ErrWalletNotClosed
+	ErrWalletNotEmpty    = repository.ErrWalletNotEmpty
+	ErrWalletPurged      = repository.ErrWalletPurged
+	ErrWalletNotFrozen   = repository.ErrWalletNotFrozen
+	// ErrWalletAlreadyImported rejects a second import of an external id.
+	ErrWalletAlreadyImported = repository.ErrWalletAlreadyImported
+	ErrCurrencyMismatch      = repository.ErrCurrencyMismatch
+	ErrOperationRejected     = service.ErrOperationRejected
+	// ErrReactivationRequired rejects large withdrawals from dormant
+	// wallets; see WithDormancy.
+	ErrReactivationRequired = service.ErrReactivationRequired
+	// ErrRetryable matches conflicts that outlasted the engine's own
+	// retries; the operation was not applied and may be retried.
+	ErrRetryable = repository.ErrRetryable
+	// ErrRetryBudgetExhausted matches retryable failures returned without
+	// retrying because too many recent attempts were retries.
+	ErrRetryBudgetExhausted = service.ErrRetryBudgetExhausted
+	// ErrTotalsMismatch reports currencies whose wallets don't add up to
+	// the ledger; see CheckCurrencyTotals.
+	ErrTotalsMismatch = service.ErrTotalsMismatch
+	// ErrWalletOnLegalHold reports that a wallet's records are kept
+	// regardless of retention; see PlaceLegalHold.
+	ErrWalletOnLegalHold = repository.ErrWalletOnLegalHold
+	ErrLegalHoldExists   = repository.ErrLegalHoldExists
+	ErrLegalHoldNotFound = repository.ErrLegalHoldNotFound
+)
+
+// RetriesExhaustedError reports how many attempts a retryable failure
+// outlasted.
+type RetriesExhaustedError = service.RetriesExhaustedError
+
+// Service is the embeddable surface of the engine.
+type Service interface {
+	CreateWallet(ctx context.Context, req CreateWalletRequest) (*Wallet, error)
+	CreateWallets(ctx context.Context, req CreateWalletsRequest) (*CreateWalletsResponse, error)
+	GetWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	GetWalletBalance(ctx context.Context, id uuid.UUID) (*WalletBalance, error)
+	GetWalletVersions(ctx context.Context, id uuid.UUID) ([]WalletVersion, error)
+	ListTransactions(ctx context.Context, walletID uuid.UUID, beforeVersion, limit int) ([]Transaction, bool, error)
+	ProcessOperation(ctx context.Context, op Operation) (*Wallet, error)
+	ProcessAtomic(ctx context.Context, req AtomicRequest) (*AtomicReceipt, error)
+	ProcessBatch(ctx context.Context, req BatchRequest) (*AtomicReceipt, error)
+	Transfer(ctx context.Context, req TransferRequest) (*Transfer, error)
+	ListWallets(ctx context.Context, filter WalletFilter, after uuid.UUID, limit int) ([]Wallet, bool, error)
+	ListWalletsSorted(ctx context.Context, filter WalletFilter, sort WalletSort, after WalletCursor, limit int) ([]Wallet, bool, error)
+	SearchWallets(ctx context.Context, q string, after uuid.UUID, limit int) ([]Wallet, bool, error)
+	OwnerBalance(ctx context.Context, ownerID uuid.UUID) (*OwnerBalance, error)
+	OwnerBalanceWithin(ctx context.Context, ownerID uuid.UUID, maxStaleness time.Duration) (*OwnerBalance, error)
+	TenantBalance(ctx context.Context, tenant string, maxStaleness time.Duration) (*TenantBalance, error)
+	CurrencyTotals(ctx context.Context) ([]CurrencyTotals, error)
+	CheckCurrencyTotals(ctx context.Context) error
+	FlagDormantWallets(ctx context.Context) error
+	ReactivateWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	FreezeWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	UnfreezeWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	CloseWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	ForceCloseWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	RestoreWallet(ctx context.Context, id uuid.UUID) (*Wallet, error)
+	ImportWallet(ctx context.Context, req ImportWalletRequest) (*Wallet, error)
+	PlaceLegalHold(ctx context.Context, id uuid.UUID, reason string) (*LegalHold, error)
+	GetLegalHold(ctx context.Context, id uuid.UUID) (*LegalHold, error)
+	ReleaseLegalHold(ctx context.Context, id uuid.UUID) (*LegalHold, error)
+	ExportWalletRecords(ctx context.Context, id uuid.UUID, w io.Writer) error
+	PurgeAuditEvents(ctx context.Context) error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Service = (*service.WalletService)(nil)
+
+// Repository is the storage the engine runs on.
+type Repository = service.WalletRepository
+
+// NewPostgresRepository stores wallets in db, which must use the lib/pq
+// driver. Call CreateSchema, or apply the migrations, before first use.
+func NewPostgresRepository(db *sql.DB, log *slog.Logger) Repository {
+	return repository.NewWalletRepository(db, log)
+}
+
+// CreateSchema creates the tables the Postgres repository needs if they
+// don't exist yet. Concurrent callers are serialized.
+func CreateSchema(ctx context.Context, db *sql.DB, log *slog.Logger) error {
+	return repository.NewWalletRepository(db, log).CreateTabeIfNotExists(ctx)
+}
+
+// NewMemoryRepository keeps wallets in memory, for tests and tools. It
+// supports wallets, operations and history; other features report an
+// error.
+func NewMemoryRepository() Repository {
+	return memory.New()
+}
+
+// Option configures the engine.
+type Option = service.Option
+
+// WithBalanceCache keeps up to size balances in memory for at most ttl.
+func WithBalanceCache(size int, ttl time.Duration) Option {
+	return service.WithBalanceCache(size, ttl)
+}
+
+// WithRetryBudget caps retries across all requests: over window, at most
+// minRetries plus ratio of all attempts may be retries. The default allows
+// 20% over 10s, plus 10 retries.
+func WithRetryBudget(ratio float64, window time.Duration, minRetries int) Option {
+	return service.WithRetryBudget(ratio, window, minRetries)
+}
+
+// WithMissCacheTTL sets how long missing wallet ids are remembered; zero
+// disables that.
+func WithMissCacheTTL(ttl time.Duration) Option {
+	return service.WithMissCacheTTL(ttl)
+}
+
+// WithOwnerBalanceCacheTTL sets how long aggregated owner and tenant
+// balances are served from memory.
+func WithOwnerBalanceCacheTTL(ttl time.Duration) Option {
+	return service.WithOwnerBalanceCacheTTL(ttl)
+}
+
+// WithDisputeWindow sets how long disputes stay open.
+func WithDisputeWindow(window time.Duration) Option {
+	return service.WithDisputeWindow(window)
+}
+
+// WithDormancy flags wallets idle for longer than idle as dormant whenever
+// FlagDormantWallets runs; withdrawals of at least reactivationThreshold
+// from them are rejected until ReactivateWallet.
+func WithDormancy(idle time.Duration, reactivationThreshold int64) Option {
+	return service.WithDormancy(idle, reactivationThreshold, false)
+}
+
+// WithAuditRetention purges audit events older than retention, except
+// those of wallets on legal hold, whenever PurgeAuditEvents runs.
+func WithAuditRetention(retention time.Duration) Option {
+	return service.WithAuditRetention(retention)
+}
+
+// Hooks run around every operation; see the interfaces' docs for ordering
+// and error semantics.
+type (
+	BeforeOperation     = service.BeforeOperation
+	AfterOperation      = service.AfterOperation
+	BeforeOperationFunc = service.BeforeOperationFunc
+	AfterOperationFunc  = service.AfterOperationFunc
+)
+
+// WithBeforeOperation appends hooks that may reject operations.
+func WithBeforeOperation(hooks ...BeforeOperation) Option {
+	return service.WithBeforeOperation(hooks...)
+}
+
+// WithAfterOperation appends hooks that observe committed operations.
+func WithAfterOperation(hooks ...AfterOperation) Option {
+	return service.WithAfterOperation(hooks...)
+}
+
+// New returns the engine running on repo.
+func New(repo Repository, log *slog.Logger, opts ...Option) Service {
+	return service.NewWalletService(repo, log, opts...)
+}
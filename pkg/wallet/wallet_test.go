@@ -0,0 +1,29 @@
+package wallet_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"wallet-service/pkg/wallet"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedEngine(t *testing.T) {
+	ctx := context.Background()
+	svc := wallet.New(wallet.NewMemoryRepository(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	w, err := svc.CreateWallet(ctx, wallet.CreateWalletRequest{Currency: "EUR"})
+	require.NoError(t, err)
+
+	_, err = svc.ProcessOperation(ctx, wallet.Operation{WalletID: w.ID, OperationType: wallet.Deposit, Amount: 500})
+	require.NoError(t, err)
+	_, err = svc.ProcessOperation(ctx, wallet.Operation{WalletID: w.ID, OperationType: wallet.Withdraw, Amount: 900})
+	assert.ErrorIs(t, err, wallet.ErrInvalidInput)
+
+	b, err := svc.GetWalletBalance(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), b.Balance)
+}
@@ -0,0 +1,135 @@
+package wallettest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// FakeService is an in-memory Service. It validates input and reports
+// failures with the same errors as the real service, but has no limits,
+// screening, promo credits or holds. It is safe for concurrent use.
+type FakeService struct {
+	mu       sync.Mutex
+	wallets  map[uuid.UUID]*models.Wallet
+	versions map[uuid.UUID][]models.WalletVersion
+
+	// Now returns the time stamped on changes; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewFakeService returns a fake holding the given wallets, each with a
+// single CREATE version.
+func NewFakeService(wallets ...models.Wallet) *FakeService {
+	f := &FakeService{
+		wallets:  make(map[uuid.UUID]*models.Wallet),
+		versions: make(map[uuid.UUID][]models.WalletVersion),
+		Now:      time.Now,
+	}
+	for _, w := range wallets {
+		f.Put(w)
+	}
+	return f
+}
+
+// Put stores w, replacing any wallet with the same id and its history.
+func (f *FakeService) Put(w models.Wallet) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.wallets[w.ID] = &w
+	f.versions[w.ID] = []models.WalletVersion{{
+		WalletID:      w.ID,
+		Version:       w.Version,
+		Balance:       w.Balance,
+		OperationType: models.OperationTypeCreate,
+		CreatedAt:     w.CreatedAt,
+	}}
+}
+
+func (f *FakeService) CreateWallet(_ context.Context, req models.CreateWalletRequest) (*models.Wallet, error) {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	if len(req.Currency) != 3 {
+		return nil, service.ErrInvalidInput
+	}
+
+	now := f.Now().UTC()
+	w := NewWallet(WithCurrency(req.Currency), WithTenant(req.Tenant), WithLabel(req.Label))
+	w.OwnerID = req.OwnerID
+	w.CreatedAt, w.UpdatedAt = now, now
+	f.Put(w)
+	return &w, nil
+}
+
+func (f *FakeService) GetWallet(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w, ok := f.wallets[id]
+	if !ok {
+		return nil, service.ErrInvalidInput
+	}
+	copied := *w
+	return &copied, nil
+}
+
+func (f *FakeService) GetWalletVersions(_ context.Context, id uuid.UUID) ([]models.WalletVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	versions, ok := f.versions[id]
+	if !ok {
+		return nil, service.ErrInvalidInput
+	}
+	return append([]models.WalletVersion(nil), versions...), nil
+}
+
+func (f *FakeService) ProcessOperation(_ context.Context, operation models.WalletOperation) (*models.Wallet, error) {
+	if operation.Amount <= 0 {
+		return nil, service.ErrInvalidInput
+	}
+	if operation.OperationType != models.OperationTypeDeposit && operation.OperationType != models.OperationTypeWithdraw {
+		return nil, service.ErrInvalidInput
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w, ok := f.wallets[operation.WalletID]
+	if !ok {
+		return nil, service.ErrInvalidInput
+	}
+	if w.Status == models.WalletStatusFrozen {
+		return nil, fmt.Errorf("failed to process operation: %w", repository.ErrWalletFrozen)
+	}
+
+	balance := w.Balance + operation.Amount
+	if operation.OperationType == models.OperationTypeWithdraw {
+		if w.Balance < operation.Amount {
+			return nil, service.ErrInvalidInput
+		}
+		balance = w.Balance - operation.Amount
+	}
+
+	w.Balance = balance
+	w.Version++
+	w.UpdatedAt = f.Now().UTC()
+	f.versions[w.ID] = append(f.versions[w.ID], models.WalletVersion{
+		WalletID:      w.ID,
+		Version:       w.Version,
+		Balance:       w.Balance,
+		OperationType: operation.OperationType,
+		Amount:        operation.Amount,
+		CreatedAt:     w.UpdatedAt,
+	})
+	copied := *w
+	return &copied, nil
+}
@@ -0,0 +1,55 @@
+package wallettest
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeService_ProcessOperation(t *testing.T) {
+	w := NewWallet(WithBalance(100))
+	f := NewFakeService(w)
+	ctx := context.Background()
+
+	updated, err := f.ProcessOperation(ctx, Deposit(w.ID, 50))
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), updated.Balance)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = f.ProcessOperation(ctx, Withdraw(w.ID, 500))
+	assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+	_, err = f.ProcessOperation(ctx, Deposit(uuid.New(), 50))
+	assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+	versions, err := f.GetWalletVersions(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, models.OperationTypeDeposit, versions[1].OperationType)
+}
+
+func TestFakeService_FrozenWallet(t *testing.T) {
+	w := NewWallet(WithBalance(100), Frozen())
+	f := NewFakeService(w)
+
+	_, err := f.ProcessOperation(context.Background(), Withdraw(w.ID, 10))
+
+	assert.ErrorIs(t, err, repository.ErrWalletFrozen)
+}
+
+func TestHistory(t *testing.T) {
+	id := uuid.New()
+
+	versions := History(id, Deposit(id, 100), Withdraw(id, 30))
+
+	require.Len(t, versions, 3)
+	assert.Equal(t, models.OperationTypeCreate, versions[0].OperationType)
+	assert.Equal(t, int64(70), versions[2].Balance)
+	assert.Equal(t, 3, versions[2].Version)
+}
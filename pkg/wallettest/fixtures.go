@@ -0,0 +1,100 @@
+package wallettest
+
+import (
+	"time"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Epoch is the creation time of fixture wallets and transactions.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type WalletOption func(*models.Wallet)
+
+func WithID(id uuid.UUID) WalletOption {
+	return func(w *models.Wallet) { w.ID = id }
+}
+
+func WithBalance(balance int64) WalletOption {
+	return func(w *models.Wallet) { w.Balance = balance }
+}
+
+func WithOwner(ownerID uuid.UUID) WalletOption {
+	return func(w *models.Wallet) { w.OwnerID = uuid.NullUUID{UUID: ownerID, Valid: true} }
+}
+
+func WithCurrency(currency string) WalletOption {
+	return func(w *models.Wallet) { w.Currency = currency }
+}
+
+func WithTenant(tenant string) WalletOption {
+	return func(w *models.Wallet) { w.Tenant = tenant }
+}
+
+func WithLabel(label string) WalletOption {
+	return func(w *models.Wallet) { w.Label = label }
+}
+
+func WithVersion(version int) WalletOption {
+	return func(w *models.Wallet) { w.Version = version }
+}
+
+// Frozen makes the wallet reject operations.
+func Frozen() WalletOption {
+	return func(w *models.Wallet) { w.Status = models.WalletStatusFrozen }
+}
+
+// NewWallet returns an active, empty wallet in the default currency with a
+// random id, as the service would create it, modified by opts.
+func NewWallet(opts ...WalletOption) models.Wallet {
+	w := models.Wallet{
+		ID:        uuid.New(),
+		CreatedAt: Epoch,
+		UpdatedAt: Epoch,
+		Version:   1,
+		Currency:  models.DefaultCurrency,
+		Status:    models.WalletStatusActive,
+	}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return w
+}
+
+func Deposit(walletID uuid.UUID, amount int64) models.WalletOperation {
+	return models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: amount}
+}
+
+func Withdraw(walletID uuid.UUID, amount int64) models.WalletOperation {
+	return models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: amount}
+}
+
+// History returns the transaction history of a new wallet after ops, as
+// returned by GetWalletVersions: the creation at version 1 followed by one
+// version per operation, a minute apart. Operations are not validated.
+func History(walletID uuid.UUID, ops ...models.WalletOperation) []models.WalletVersion {
+	versions := []models.WalletVersion{{
+		WalletID:      walletID,
+		Version:       1,
+		OperationType: models.OperationTypeCreate,
+		CreatedAt:     Epoch,
+	}}
+	var balance int64
+	for i, op := range ops {
+		if op.OperationType == models.OperationTypeWithdraw {
+			balance -= op.Amount
+		} else {
+			balance += op.Amount
+		}
+		versions = append(versions, models.WalletVersion{
+			WalletID:      walletID,
+			Version:       i + 2,
+			Balance:       balance,
+			OperationType: op.OperationType,
+			Amount:        op.Amount,
+			CreatedAt:     Epoch.Add(time.Duration(i+1) * time.Minute),
+		})
+	}
+	return versions
+}
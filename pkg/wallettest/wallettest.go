@@ -0,0 +1,42 @@
+// Package wallettest provides fixtures and an in-memory fake of the wallet
+// service, so that consumers can unit test code built on this API without
+// writing mocks of their own.
+package wallettest
+
+import (
+	"context"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// Aliases of the API types, which live in an internal package that
+// consumers can't import.
+type (
+	Wallet              = models.Wallet
+	WalletOperation     = models.WalletOperation
+	WalletVersion       = models.WalletVersion
+	CreateWalletRequest = models.CreateWalletRequest
+)
+
+// Errors reported by Service implementations.
+var (
+	ErrInvalidInput = service.ErrInvalidInput
+	ErrWalletFrozen = repository.ErrWalletFrozen
+)
+
+// Service is the part of the wallet service consumers usually depend on.
+// Both *service.WalletService and *FakeService implement it.
+type Service interface {
+	CreateWallet(ctx context.Context, req models.CreateWalletRequest) (*models.Wallet, error)
+	GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	GetWalletVersions(ctx context.Context, id uuid.UUID) ([]models.WalletVersion, error)
+	ProcessOperation(ctx context.Context, operation models.WalletOperation) (*models.Wallet, error)
+}
+
+var (
+	_ Service = (*service.WalletService)(nil)
+	_ Service = (*FakeService)(nil)
+)
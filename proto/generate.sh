@@ -0,0 +1,9 @@
+#!/bin/sh
+# Regenerates the Go types and gRPC stubs of the .proto files in this
+# directory. Requires protoc, protoc-gen-go v1.36.9 and protoc-gen-go-grpc
+# v1.5.1 on PATH.
+set -e
+cd "$(dirname "$0")"
+protoc --go_out=. --go_opt=paths=source_relative \
+	--go-grpc_out=. --go-grpc_opt=paths=source_relative \
+	wallet/v1/*.proto
@@ -0,0 +1,512 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: wallet/v1/wallet_service.proto
+
+package walletv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateWalletRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional.
+	OwnerId string `protobuf:"bytes,1,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	// ISO 4217 code; defaults to the service's default currency.
+	Currency      string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Label         string `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	Tenant        string `protobuf:"bytes,4,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWalletRequest) Reset() {
+	*x = CreateWalletRequest{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWalletRequest) ProtoMessage() {}
+
+func (x *CreateWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWalletRequest.ProtoReflect.Descriptor instead.
+func (*CreateWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateWalletRequest) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *CreateWalletRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateWalletRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *CreateWalletRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+type CreateWalletResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWalletResponse) Reset() {
+	*x = CreateWalletResponse{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWalletResponse) ProtoMessage() {}
+
+func (x *CreateWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWalletResponse.ProtoReflect.Descriptor instead.
+func (*CreateWalletResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateWalletResponse) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+type GetWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletRequest) Reset() {
+	*x = GetWalletRequest{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletRequest) ProtoMessage() {}
+
+func (x *GetWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetWalletRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetWalletResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletResponse) Reset() {
+	*x = GetWalletResponse{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletResponse) ProtoMessage() {}
+
+func (x *GetWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletResponse.ProtoReflect.Descriptor instead.
+func (*GetWalletResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetWalletResponse) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+type ProcessOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operation     *WalletOperation       `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessOperationRequest) Reset() {
+	*x = ProcessOperationRequest{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessOperationRequest) ProtoMessage() {}
+
+func (x *ProcessOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessOperationRequest.ProtoReflect.Descriptor instead.
+func (*ProcessOperationRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProcessOperationRequest) GetOperation() *WalletOperation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+type ProcessOperationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessOperationResponse) Reset() {
+	*x = ProcessOperationResponse{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessOperationResponse) ProtoMessage() {}
+
+func (x *ProcessOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessOperationResponse.ProtoReflect.Descriptor instead.
+func (*ProcessOperationResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProcessOperationResponse) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+type WatchWalletRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Last version the client has seen; 0 replays the whole history.
+	FromVersion   int32 `protobuf:"varint,2,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchWalletRequest) Reset() {
+	*x = WatchWalletRequest{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchWalletRequest) ProtoMessage() {}
+
+func (x *WatchWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchWalletRequest.ProtoReflect.Descriptor instead.
+func (*WatchWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchWalletRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WatchWalletRequest) GetFromVersion() int32 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+type WatchWalletResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transaction   *Transaction           `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchWalletResponse) Reset() {
+	*x = WatchWalletResponse{}
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchWalletResponse) ProtoMessage() {}
+
+func (x *WatchWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_v1_wallet_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchWalletResponse.ProtoReflect.Descriptor instead.
+func (*WatchWalletResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_v1_wallet_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchWalletResponse) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+var File_wallet_v1_wallet_service_proto protoreflect.FileDescriptor
+
+const file_wallet_v1_wallet_service_proto_rawDesc = "" +
+	"\n" +
+	"\x1ewallet/v1/wallet_service.proto\x12\twallet.v1\x1a\x16wallet/v1/wallet.proto\"z\n" +
+	"\x13CreateWalletRequest\x12\x19\n" +
+	"\bowner_id\x18\x01 \x01(\tR\aownerId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\x12\x16\n" +
+	"\x06tenant\x18\x04 \x01(\tR\x06tenant\"A\n" +
+	"\x14CreateWalletResponse\x12)\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x11.wallet.v1.WalletR\x06wallet\"\"\n" +
+	"\x10GetWalletRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\">\n" +
+	"\x11GetWalletResponse\x12)\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x11.wallet.v1.WalletR\x06wallet\"S\n" +
+	"\x17ProcessOperationRequest\x128\n" +
+	"\toperation\x18\x01 \x01(\v2\x1a.wallet.v1.WalletOperationR\toperation\"E\n" +
+	"\x18ProcessOperationResponse\x12)\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x11.wallet.v1.WalletR\x06wallet\"G\n" +
+	"\x12WatchWalletRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\ffrom_version\x18\x02 \x01(\x05R\vfromVersion\"O\n" +
+	"\x13WatchWalletResponse\x128\n" +
+	"\vtransaction\x18\x01 \x01(\v2\x16.wallet.v1.TransactionR\vtransaction2\xd5\x02\n" +
+	"\rWalletService\x12O\n" +
+	"\fCreateWallet\x12\x1e.wallet.v1.CreateWalletRequest\x1a\x1f.wallet.v1.CreateWalletResponse\x12F\n" +
+	"\tGetWallet\x12\x1b.wallet.v1.GetWalletRequest\x1a\x1c.wallet.v1.GetWalletResponse\x12[\n" +
+	"\x10ProcessOperation\x12\".wallet.v1.ProcessOperationRequest\x1a#.wallet.v1.ProcessOperationResponse\x12N\n" +
+	"\vWatchWallet\x12\x1d.wallet.v1.WatchWalletRequest\x1a\x1e.wallet.v1.WatchWalletResponse0\x01B)Z'wallet-service/proto/wallet/v1;walletv1b\x06proto3"
+
+var (
+	file_wallet_v1_wallet_service_proto_rawDescOnce sync.Once
+	file_wallet_v1_wallet_service_proto_rawDescData []byte
+)
+
+func file_wallet_v1_wallet_service_proto_rawDescGZIP() []byte {
+	file_wallet_v1_wallet_service_proto_rawDescOnce.Do(func() {
+		file_wallet_v1_wallet_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wallet_v1_wallet_service_proto_rawDesc), len(file_wallet_v1_wallet_service_proto_rawDesc)))
+	})
+	return file_wallet_v1_wallet_service_proto_rawDescData
+}
+
+var file_wallet_v1_wallet_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_wallet_v1_wallet_service_proto_goTypes = []any{
+	(*CreateWalletRequest)(nil),      // 0: wallet.v1.CreateWalletRequest
+	(*CreateWalletResponse)(nil),     // 1: wallet.v1.CreateWalletResponse
+	(*GetWalletRequest)(nil),         // 2: wallet.v1.GetWalletRequest
+	(*GetWalletResponse)(nil),        // 3: wallet.v1.GetWalletResponse
+	(*ProcessOperationRequest)(nil),  // 4: wallet.v1.ProcessOperationRequest
+	(*ProcessOperationResponse)(nil), // 5: wallet.v1.ProcessOperationResponse
+	(*WatchWalletRequest)(nil),       // 6: wallet.v1.WatchWalletRequest
+	(*WatchWalletResponse)(nil),      // 7: wallet.v1.WatchWalletResponse
+	(*Wallet)(nil),                   // 8: wallet.v1.Wallet
+	(*WalletOperation)(nil),          // 9: wallet.v1.WalletOperation
+	(*Transaction)(nil),              // 10: wallet.v1.Transaction
+}
+var file_wallet_v1_wallet_service_proto_depIdxs = []int32{
+	8,  // 0: wallet.v1.CreateWalletResponse.wallet:type_name -> wallet.v1.Wallet
+	8,  // 1: wallet.v1.GetWalletResponse.wallet:type_name -> wallet.v1.Wallet
+	9,  // 2: wallet.v1.ProcessOperationRequest.operation:type_name -> wallet.v1.WalletOperation
+	8,  // 3: wallet.v1.ProcessOperationResponse.wallet:type_name -> wallet.v1.Wallet
+	10, // 4: wallet.v1.WatchWalletResponse.transaction:type_name -> wallet.v1.Transaction
+	0,  // 5: wallet.v1.WalletService.CreateWallet:input_type -> wallet.v1.CreateWalletRequest
+	2,  // 6: wallet.v1.WalletService.GetWallet:input_type -> wallet.v1.GetWalletRequest
+	4,  // 7: wallet.v1.WalletService.ProcessOperation:input_type -> wallet.v1.ProcessOperationRequest
+	6,  // 8: wallet.v1.WalletService.WatchWallet:input_type -> wallet.v1.WatchWalletRequest
+	1,  // 9: wallet.v1.WalletService.CreateWallet:output_type -> wallet.v1.CreateWalletResponse
+	3,  // 10: wallet.v1.WalletService.GetWallet:output_type -> wallet.v1.GetWalletResponse
+	5,  // 11: wallet.v1.WalletService.ProcessOperation:output_type -> wallet.v1.ProcessOperationResponse
+	7,  // 12: wallet.v1.WalletService.WatchWallet:output_type -> wallet.v1.WatchWalletResponse
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_wallet_v1_wallet_service_proto_init() }
+func file_wallet_v1_wallet_service_proto_init() {
+	if File_wallet_v1_wallet_service_proto != nil {
+		return
+	}
+	file_wallet_v1_wallet_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wallet_v1_wallet_service_proto_rawDesc), len(file_wallet_v1_wallet_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wallet_v1_wallet_service_proto_goTypes,
+		DependencyIndexes: file_wallet_v1_wallet_service_proto_depIdxs,
+		MessageInfos:      file_wallet_v1_wallet_service_proto_msgTypes,
+	}.Build()
+	File_wallet_v1_wallet_service_proto = out.File
+	file_wallet_v1_wallet_service_proto_goTypes = nil
+	file_wallet_v1_wallet_service_proto_depIdxs = nil
+}
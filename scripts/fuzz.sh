@@ -0,0 +1,13 @@
+#!/bin/sh
+# Runs every fuzz target in the module for FUZZTIME each (default 30s).
+# New failing inputs are written to the package's testdata/fuzz directory;
+# commit them so that plain `go test` keeps replaying them.
+set -e
+cd "$(dirname "$0")/.."
+fuzztime="${FUZZTIME:-30s}"
+for pkg in $(go list ./...); do
+	for target in $(go test -list '^Fuzz' "$pkg" | grep '^Fuzz' || true); do
+		echo "== $pkg $target"
+		go test "$pkg" -run '^$' -fuzz "^$target\$" -fuzztime "$fuzztime"
+	done
+done